@@ -22,6 +22,59 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// 批量任务状态
+type BatchJobState int32
+
+const (
+	BatchJobState_BATCH_JOB_STATE_UNSPECIFIED BatchJobState = 0
+	BatchJobState_BATCH_JOB_STATE_RUNNING     BatchJobState = 1
+	BatchJobState_BATCH_JOB_STATE_COMPLETED   BatchJobState = 2
+	BatchJobState_BATCH_JOB_STATE_CANCELLED   BatchJobState = 3 // 服务停止等原因未处理完全部操作
+)
+
+// Enum value maps for BatchJobState.
+var (
+	BatchJobState_name = map[int32]string{
+		0: "BATCH_JOB_STATE_UNSPECIFIED",
+		1: "BATCH_JOB_STATE_RUNNING",
+		2: "BATCH_JOB_STATE_COMPLETED",
+		3: "BATCH_JOB_STATE_CANCELLED",
+	}
+	BatchJobState_value = map[string]int32{
+		"BATCH_JOB_STATE_UNSPECIFIED": 0,
+		"BATCH_JOB_STATE_RUNNING":     1,
+		"BATCH_JOB_STATE_COMPLETED":   2,
+		"BATCH_JOB_STATE_CANCELLED":   3,
+	}
+)
+
+func (x BatchJobState) Enum() *BatchJobState {
+	p := new(BatchJobState)
+	*p = x
+	return p
+}
+
+func (x BatchJobState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BatchJobState) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_proto_counter_counter_proto_enumTypes[0].Descriptor()
+}
+
+func (BatchJobState) Type() protoreflect.EnumType {
+	return &file_api_proto_counter_counter_proto_enumTypes[0]
+}
+
+func (x BatchJobState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BatchJobState.Descriptor instead.
+func (BatchJobState) EnumDescriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{0}
+}
+
 // 增量请求
 type IncrementRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -29,6 +82,8 @@ type IncrementRequest struct {
 	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
 	Delta         int64                  `protobuf:"varint,3,opt,name=delta,proto3" json:"delta,omitempty"`
 	Metadata      map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UserId        string                 `protobuf:"bytes,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`       // 可选，操作用户ID，写入计数事件
+	ClientIp      string                 `protobuf:"bytes,6,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"` // 可选，网关转发时填写的客户端IP，未填写时取gRPC对端地址
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -91,6 +146,20 @@ func (x *IncrementRequest) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *IncrementRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *IncrementRequest) GetClientIp() string {
+	if x != nil {
+		return x.ClientIp
+	}
+	return ""
+}
+
 // 增量响应
 type IncrementResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -160,29 +229,34 @@ func (x *IncrementResponse) GetCounterType() string {
 	return ""
 }
 
-// 获取计数器请求
-type GetCounterRequest struct {
+// 递减请求
+type DecrementRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
 	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Delta         int64                  `protobuf:"varint,3,opt,name=delta,proto3" json:"delta,omitempty"`                                  // 递减量，必须为正数，0时使用计数器类型的默认增量
+	ClampAtZero   bool                   `protobuf:"varint,4,opt,name=clamp_at_zero,json=clampAtZero,proto3" json:"clamp_at_zero,omitempty"` // 结果低于0时截断为0
+	Metadata      map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UserId        string                 `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`       // 可选，操作用户ID，写入计数事件
+	ClientIp      string                 `protobuf:"bytes,7,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"` // 可选，网关转发时填写的客户端IP，未填写时取gRPC对端地址
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetCounterRequest) Reset() {
-	*x = GetCounterRequest{}
+func (x *DecrementRequest) Reset() {
+	*x = DecrementRequest{}
 	mi := &file_api_proto_counter_counter_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetCounterRequest) String() string {
+func (x *DecrementRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetCounterRequest) ProtoMessage() {}
+func (*DecrementRequest) ProtoMessage() {}
 
-func (x *GetCounterRequest) ProtoReflect() protoreflect.Message {
+func (x *DecrementRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_counter_counter_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -194,51 +268,86 @@ func (x *GetCounterRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetCounterRequest.ProtoReflect.Descriptor instead.
-func (*GetCounterRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DecrementRequest.ProtoReflect.Descriptor instead.
+func (*DecrementRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *GetCounterRequest) GetResourceId() string {
+func (x *DecrementRequest) GetResourceId() string {
 	if x != nil {
 		return x.ResourceId
 	}
 	return ""
 }
 
-func (x *GetCounterRequest) GetCounterType() string {
+func (x *DecrementRequest) GetCounterType() string {
 	if x != nil {
 		return x.CounterType
 	}
 	return ""
 }
 
-// 获取计数器响应
-type GetCounterResponse struct {
+func (x *DecrementRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *DecrementRequest) GetClampAtZero() bool {
+	if x != nil {
+		return x.ClampAtZero
+	}
+	return false
+}
+
+func (x *DecrementRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *DecrementRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DecrementRequest) GetClientIp() string {
+	if x != nil {
+		return x.ClientIp
+	}
+	return ""
+}
+
+// 递减响应
+type DecrementResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Value         int64                  `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	CurrentValue  int64                  `protobuf:"varint,2,opt,name=current_value,json=currentValue,proto3" json:"current_value,omitempty"`
 	ResourceId    string                 `protobuf:"bytes,3,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
 	CounterType   string                 `protobuf:"bytes,4,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
-	LastUpdated   *common.Timestamp      `protobuf:"bytes,5,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	Clamped       bool                   `protobuf:"varint,5,opt,name=clamped,proto3" json:"clamped,omitempty"` // 结果被截断为0
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetCounterResponse) Reset() {
-	*x = GetCounterResponse{}
+func (x *DecrementResponse) Reset() {
+	*x = DecrementResponse{}
 	mi := &file_api_proto_counter_counter_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetCounterResponse) String() string {
+func (x *DecrementResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetCounterResponse) ProtoMessage() {}
+func (*DecrementResponse) ProtoMessage() {}
 
-func (x *GetCounterResponse) ProtoReflect() protoreflect.Message {
+func (x *DecrementResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_counter_counter_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -250,68 +359,74 @@ func (x *GetCounterResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetCounterResponse.ProtoReflect.Descriptor instead.
-func (*GetCounterResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use DecrementResponse.ProtoReflect.Descriptor instead.
+func (*DecrementResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *GetCounterResponse) GetStatus() *common.Status {
+func (x *DecrementResponse) GetStatus() *common.Status {
 	if x != nil {
 		return x.Status
 	}
 	return nil
 }
 
-func (x *GetCounterResponse) GetValue() int64 {
+func (x *DecrementResponse) GetCurrentValue() int64 {
 	if x != nil {
-		return x.Value
+		return x.CurrentValue
 	}
 	return 0
 }
 
-func (x *GetCounterResponse) GetResourceId() string {
+func (x *DecrementResponse) GetResourceId() string {
 	if x != nil {
 		return x.ResourceId
 	}
 	return ""
 }
 
-func (x *GetCounterResponse) GetCounterType() string {
+func (x *DecrementResponse) GetCounterType() string {
 	if x != nil {
 		return x.CounterType
 	}
 	return ""
 }
 
-func (x *GetCounterResponse) GetLastUpdated() *common.Timestamp {
+func (x *DecrementResponse) GetClamped() bool {
 	if x != nil {
-		return x.LastUpdated
+		return x.Clamped
 	}
-	return nil
+	return false
 }
 
-// 批量获取请求
-type BatchGetRequest struct {
+// 比较并设置请求，不存在的计数器按0比较；new_value不能为负数，变化量受计数器类型的增量上限约束
+type CompareAndSwapRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Requests      []*GetCounterRequest   `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Expected      int64                  `protobuf:"varint,3,opt,name=expected,proto3" json:"expected,omitempty"`                 // 期望的当前值
+	NewValue      int64                  `protobuf:"varint,4,opt,name=new_value,json=newValue,proto3" json:"new_value,omitempty"` // 当前值等于expected时设置的新值
+	Metadata      map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UserId        string                 `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`       // 可选，操作用户ID，写入计数事件
+	ClientIp      string                 `protobuf:"bytes,7,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"` // 可选，网关转发时填写的客户端IP，未填写时取gRPC对端地址
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchGetRequest) Reset() {
-	*x = BatchGetRequest{}
+func (x *CompareAndSwapRequest) Reset() {
+	*x = CompareAndSwapRequest{}
 	mi := &file_api_proto_counter_counter_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchGetRequest) String() string {
+func (x *CompareAndSwapRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchGetRequest) ProtoMessage() {}
+func (*CompareAndSwapRequest) ProtoMessage() {}
 
-func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
+func (x *CompareAndSwapRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_counter_counter_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -323,41 +438,85 @@ func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchGetRequest.ProtoReflect.Descriptor instead.
-func (*BatchGetRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use CompareAndSwapRequest.ProtoReflect.Descriptor instead.
+func (*CompareAndSwapRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *BatchGetRequest) GetRequests() []*GetCounterRequest {
+func (x *CompareAndSwapRequest) GetResourceId() string {
 	if x != nil {
-		return x.Requests
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *CompareAndSwapRequest) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *CompareAndSwapRequest) GetExpected() int64 {
+	if x != nil {
+		return x.Expected
+	}
+	return 0
+}
+
+func (x *CompareAndSwapRequest) GetNewValue() int64 {
+	if x != nil {
+		return x.NewValue
+	}
+	return 0
+}
+
+func (x *CompareAndSwapRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
 	}
 	return nil
 }
 
-// 批量获取响应
-type BatchGetResponse struct {
+func (x *CompareAndSwapRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CompareAndSwapRequest) GetClientIp() string {
+	if x != nil {
+		return x.ClientIp
+	}
+	return ""
+}
+
+// 比较并设置响应
+type CompareAndSwapResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Counters      []*GetCounterResponse  `protobuf:"bytes,2,rep,name=counters,proto3" json:"counters,omitempty"`
+	Swapped       bool                   `protobuf:"varint,2,opt,name=swapped,proto3" json:"swapped,omitempty"` // 当前值与expected一致并已设置为new_value
+	ResourceId    string                 `protobuf:"bytes,3,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,4,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchGetResponse) Reset() {
-	*x = BatchGetResponse{}
+func (x *CompareAndSwapResponse) Reset() {
+	*x = CompareAndSwapResponse{}
 	mi := &file_api_proto_counter_counter_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchGetResponse) String() string {
+func (x *CompareAndSwapResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchGetResponse) ProtoMessage() {}
+func (*CompareAndSwapResponse) ProtoMessage() {}
 
-func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
+func (x *CompareAndSwapResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_counter_counter_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -369,47 +528,62 @@ func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchGetResponse.ProtoReflect.Descriptor instead.
-func (*BatchGetResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use CompareAndSwapResponse.ProtoReflect.Descriptor instead.
+func (*CompareAndSwapResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *BatchGetResponse) GetStatus() *common.Status {
+func (x *CompareAndSwapResponse) GetStatus() *common.Status {
 	if x != nil {
 		return x.Status
 	}
 	return nil
 }
 
-func (x *BatchGetResponse) GetCounters() []*GetCounterResponse {
+func (x *CompareAndSwapResponse) GetSwapped() bool {
 	if x != nil {
-		return x.Counters
+		return x.Swapped
 	}
-	return nil
+	return false
 }
 
-// 健康检查请求
-type HealthCheckRequest struct {
+func (x *CompareAndSwapResponse) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *CompareAndSwapResponse) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+// 获取计数器请求
+type GetCounterRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Service       string                 `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckRequest) Reset() {
-	*x = HealthCheckRequest{}
+func (x *GetCounterRequest) Reset() {
+	*x = GetCounterRequest{}
 	mi := &file_api_proto_counter_counter_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckRequest) String() string {
+func (x *GetCounterRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckRequest) ProtoMessage() {}
+func (*GetCounterRequest) ProtoMessage() {}
 
-func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+func (x *GetCounterRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_counter_counter_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -421,42 +595,51 @@ func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
-func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetCounterRequest.ProtoReflect.Descriptor instead.
+func (*GetCounterRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *HealthCheckRequest) GetService() string {
+func (x *GetCounterRequest) GetResourceId() string {
 	if x != nil {
-		return x.Service
+		return x.ResourceId
 	}
 	return ""
 }
 
-// 健康检查响应
-type HealthCheckResponse struct {
+func (x *GetCounterRequest) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+// 获取计数器响应
+type GetCounterResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Service       string                 `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
-	Details       map[string]string      `protobuf:"bytes,3,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Value         int64                  `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	ResourceId    string                 `protobuf:"bytes,3,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,4,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	LastUpdated   *common.Timestamp      `protobuf:"bytes,5,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckResponse) Reset() {
-	*x = HealthCheckResponse{}
+func (x *GetCounterResponse) Reset() {
+	*x = GetCounterResponse{}
 	mi := &file_api_proto_counter_counter_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckResponse) String() string {
+func (x *GetCounterResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckResponse) ProtoMessage() {}
+func (*GetCounterResponse) ProtoMessage() {}
 
-func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+func (x *GetCounterResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_counter_counter_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -468,56 +651,1217 @@ func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
-func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetCounterResponse.ProtoReflect.Descriptor instead.
+func (*GetCounterResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *HealthCheckResponse) GetStatus() *common.Status {
+func (x *GetCounterResponse) GetStatus() *common.Status {
 	if x != nil {
 		return x.Status
 	}
 	return nil
 }
 
-func (x *HealthCheckResponse) GetService() string {
+func (x *GetCounterResponse) GetValue() int64 {
 	if x != nil {
-		return x.Service
+		return x.Value
+	}
+	return 0
+}
+
+func (x *GetCounterResponse) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
 	}
 	return ""
 }
 
-func (x *HealthCheckResponse) GetDetails() map[string]string {
+func (x *GetCounterResponse) GetCounterType() string {
 	if x != nil {
-		return x.Details
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *GetCounterResponse) GetLastUpdated() *common.Timestamp {
+	if x != nil {
+		return x.LastUpdated
 	}
 	return nil
 }
 
-// 新增：批量增量请求
-type BatchIncrementRequest struct {
+// 批量获取请求
+type BatchGetRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Operations    []*IncrementRequest    `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
-	Async         bool                   `protobuf:"varint,2,opt,name=async,proto3" json:"async,omitempty"` // 是否异步处理
+	Requests      []*GetCounterRequest   `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchIncrementRequest) Reset() {
-	*x = BatchIncrementRequest{}
+func (x *BatchGetRequest) Reset() {
+	*x = BatchGetRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRequest) ProtoMessage() {}
+
+func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_counter_counter_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BatchGetRequest) GetRequests() []*GetCounterRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+// 批量获取响应
+type BatchGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Counters      []*GetCounterResponse  `protobuf:"bytes,2,rep,name=counters,proto3" json:"counters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResponse) Reset() {
+	*x = BatchGetResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResponse) ProtoMessage() {}
+
+func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BatchGetResponse) GetStatus() *common.Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *BatchGetResponse) GetCounters() []*GetCounterResponse {
+	if x != nil {
+		return x.Counters
+	}
+	return nil
+}
+
+// 健康检查请求
+type HealthCheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Service       string                 `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckRequest) Reset() {
+	*x = HealthCheckRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckRequest) ProtoMessage() {}
+
+func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *HealthCheckRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+// 健康检查响应
+type HealthCheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Service       string                 `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	Details       map[string]string      `protobuf:"bytes,3,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckResponse) Reset() {
+	*x = HealthCheckResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckResponse) ProtoMessage() {}
+
+func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *HealthCheckResponse) GetStatus() *common.Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *HealthCheckResponse) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *HealthCheckResponse) GetDetails() map[string]string {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+// 新增：批量增量请求
+type BatchIncrementRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*IncrementRequest    `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+	Async         bool                   `protobuf:"varint,2,opt,name=async,proto3" json:"async,omitempty"`                       // 是否异步处理
+	TrackJob      bool                   `protobuf:"varint,3,opt,name=track_job,json=trackJob,proto3" json:"track_job,omitempty"` // 异步模式下创建批量任务并返回job_id，可通过GetBatchStatus查询进度和结果
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchIncrementRequest) Reset() {
+	*x = BatchIncrementRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchIncrementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchIncrementRequest) ProtoMessage() {}
+
+func (x *BatchIncrementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchIncrementRequest.ProtoReflect.Descriptor instead.
+func (*BatchIncrementRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BatchIncrementRequest) GetOperations() []*IncrementRequest {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+func (x *BatchIncrementRequest) GetAsync() bool {
+	if x != nil {
+		return x.Async
+	}
+	return false
+}
+
+func (x *BatchIncrementRequest) GetTrackJob() bool {
+	if x != nil {
+		return x.TrackJob
+	}
+	return false
+}
+
+// 新增：批量增量响应
+type BatchIncrementResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Results        []*IncrementResponse   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Status         *common.Status         `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	ProcessedCount int32                  `protobuf:"varint,3,opt,name=processed_count,json=processedCount,proto3" json:"processed_count,omitempty"` // 处理成功的数量
+	FailedCount    int32                  `protobuf:"varint,4,opt,name=failed_count,json=failedCount,proto3" json:"failed_count,omitempty"`          // 处理失败的数量
+	JobId          string                 `protobuf:"bytes,5,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`                             // 异步模式且track_job时返回的批量任务ID
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BatchIncrementResponse) Reset() {
+	*x = BatchIncrementResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchIncrementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchIncrementResponse) ProtoMessage() {}
+
+func (x *BatchIncrementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchIncrementResponse.ProtoReflect.Descriptor instead.
+func (*BatchIncrementResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchIncrementResponse) GetResults() []*IncrementResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BatchIncrementResponse) GetStatus() *common.Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *BatchIncrementResponse) GetProcessedCount() int32 {
+	if x != nil {
+		return x.ProcessedCount
+	}
+	return 0
+}
+
+func (x *BatchIncrementResponse) GetFailedCount() int32 {
+	if x != nil {
+		return x.FailedCount
+	}
+	return 0
+}
+
+func (x *BatchIncrementResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+// 查询批量任务请求
+type GetBatchStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBatchStatusRequest) Reset() {
+	*x = GetBatchStatusRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchStatusRequest) ProtoMessage() {}
+
+func (x *GetBatchStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetBatchStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetBatchStatusRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+// 查询批量任务响应
+type GetBatchStatusResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Status         *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	JobId          string                 `protobuf:"bytes,2,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	State          BatchJobState          `protobuf:"varint,3,opt,name=state,proto3,enum=counter.BatchJobState" json:"state,omitempty"`
+	TotalCount     int32                  `protobuf:"varint,4,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`             // 操作总数
+	ProcessedCount int32                  `protobuf:"varint,5,opt,name=processed_count,json=processedCount,proto3" json:"processed_count,omitempty"` // 已处理成功的数量
+	FailedCount    int32                  `protobuf:"varint,6,opt,name=failed_count,json=failedCount,proto3" json:"failed_count,omitempty"`          // 已处理失败的数量
+	Results        []*IncrementResponse   `protobuf:"bytes,7,rep,name=results,proto3" json:"results,omitempty"`                                      // 按请求顺序返回已处理操作的结果，包含每个操作的新值
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetBatchStatusResponse) Reset() {
+	*x = GetBatchStatusResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchStatusResponse) ProtoMessage() {}
+
+func (x *GetBatchStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetBatchStatusResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetBatchStatusResponse) GetStatus() *common.Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *GetBatchStatusResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *GetBatchStatusResponse) GetState() BatchJobState {
+	if x != nil {
+		return x.State
+	}
+	return BatchJobState_BATCH_JOB_STATE_UNSPECIFIED
+}
+
+func (x *GetBatchStatusResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *GetBatchStatusResponse) GetProcessedCount() int32 {
+	if x != nil {
+		return x.ProcessedCount
+	}
+	return 0
+}
+
+func (x *GetBatchStatusResponse) GetFailedCount() int32 {
+	if x != nil {
+		return x.FailedCount
+	}
+	return 0
+}
+
+func (x *GetBatchStatusResponse) GetResults() []*IncrementResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// 获取或初始化计数器请求
+type GetOrInitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	InitialValue  int64                  `protobuf:"varint,3,opt,name=initial_value,json=initialValue,proto3" json:"initial_value,omitempty"` // 计数器不存在时的初始值
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrInitRequest) Reset() {
+	*x = GetOrInitRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrInitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrInitRequest) ProtoMessage() {}
+
+func (x *GetOrInitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrInitRequest.ProtoReflect.Descriptor instead.
+func (*GetOrInitRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetOrInitRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *GetOrInitRequest) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *GetOrInitRequest) GetInitialValue() int64 {
+	if x != nil {
+		return x.InitialValue
+	}
+	return 0
+}
+
+// 获取或初始化计数器响应
+type GetOrInitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Value         int64                  `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	ResourceId    string                 `protobuf:"bytes,3,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,4,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Created       bool                   `protobuf:"varint,5,opt,name=created,proto3" json:"created,omitempty"` // true: 本次调用创建了计数器；false: 计数器已存在
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrInitResponse) Reset() {
+	*x = GetOrInitResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrInitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrInitResponse) ProtoMessage() {}
+
+func (x *GetOrInitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrInitResponse.ProtoReflect.Descriptor instead.
+func (*GetOrInitResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetOrInitResponse) GetStatus() *common.Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *GetOrInitResponse) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *GetOrInitResponse) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *GetOrInitResponse) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *GetOrInitResponse) GetCreated() bool {
+	if x != nil {
+		return x.Created
+	}
+	return false
+}
+
+// 获取资源计数器请求
+type GetResourceCountersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResourceCountersRequest) Reset() {
+	*x = GetResourceCountersRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResourceCountersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResourceCountersRequest) ProtoMessage() {}
+
+func (x *GetResourceCountersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResourceCountersRequest.ProtoReflect.Descriptor instead.
+func (*GetResourceCountersRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetResourceCountersRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+// 获取资源计数器响应
+type GetResourceCountersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	ResourceId    string                 `protobuf:"bytes,2,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	Counters      map[string]int64       `protobuf:"bytes,3,rep,name=counters,proto3" json:"counters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 计数器类型 -> 计数值
+	Truncated     bool                   `protobuf:"varint,4,opt,name=truncated,proto3" json:"truncated,omitempty"`                                                                         // 类型数量超过上限或扫描范围用尽，可能还有未返回的类型
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResourceCountersResponse) Reset() {
+	*x = GetResourceCountersResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResourceCountersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResourceCountersResponse) ProtoMessage() {}
+
+func (x *GetResourceCountersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResourceCountersResponse.ProtoReflect.Descriptor instead.
+func (*GetResourceCountersResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetResourceCountersResponse) GetStatus() *common.Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *GetResourceCountersResponse) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *GetResourceCountersResponse) GetCounters() map[string]int64 {
+	if x != nil {
+		return x.Counters
+	}
+	return nil
+}
+
+func (x *GetResourceCountersResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// 查找超过阈值的计数器请求
+type FindCountersAboveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CounterType   string                 `protobuf:"bytes,1,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Threshold     int64                  `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"` // 只返回计数值大于threshold的资源
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`         // 最多返回的数量，<=0或超过服务端上限时使用上限
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindCountersAboveRequest) Reset() {
+	*x = FindCountersAboveRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindCountersAboveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindCountersAboveRequest) ProtoMessage() {}
+
+func (x *FindCountersAboveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindCountersAboveRequest.ProtoReflect.Descriptor instead.
+func (*FindCountersAboveRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *FindCountersAboveRequest) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *FindCountersAboveRequest) GetThreshold() int64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *FindCountersAboveRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// 超过阈值的计数器
+type CounterAboveEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Value         int64                  `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CounterAboveEntry) Reset() {
+	*x = CounterAboveEntry{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterAboveEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterAboveEntry) ProtoMessage() {}
+
+func (x *CounterAboveEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterAboveEntry.ProtoReflect.Descriptor instead.
+func (*CounterAboveEntry) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CounterAboveEntry) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *CounterAboveEntry) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *CounterAboveEntry) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+// 指标快照流请求
+type StreamStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IntervalMs    int32                  `protobuf:"varint,1,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"` // 推送间隔，<=0时使用服务端默认值，过小时按服务端下限处理
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamStatsRequest) Reset() {
+	*x = StreamStatsRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamStatsRequest) ProtoMessage() {}
+
+func (x *StreamStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamStatsRequest.ProtoReflect.Descriptor instead.
+func (*StreamStatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StreamStatsRequest) GetIntervalMs() int32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+// 服务指标快照
+type StatsSnapshot struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	TimestampMs           int64                  `protobuf:"varint,1,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	Qps                   float64                `protobuf:"fixed64,2,opt,name=qps,proto3" json:"qps,omitempty"`                                                                    // 距上一次快照的请求速率
+	ErrorRate             float64                `protobuf:"fixed64,3,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`                                       // 距上一次快照的错误请求占比，0-1
+	TotalRequests         int64                  `protobuf:"varint,4,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`                            // 启动以来的请求总数
+	TotalErrors           int64                  `protobuf:"varint,5,opt,name=total_errors,json=totalErrors,proto3" json:"total_errors,omitempty"`                                  // 启动以来的错误请求总数
+	WorkerPoolRunning     int32                  `protobuf:"varint,6,opt,name=worker_pool_running,json=workerPoolRunning,proto3" json:"worker_pool_running,omitempty"`              // 计数器工作池正在执行的任务数
+	WorkerPoolCapacity    int32                  `protobuf:"varint,7,opt,name=worker_pool_capacity,json=workerPoolCapacity,proto3" json:"worker_pool_capacity,omitempty"`           // 计数器工作池容量
+	WorkerPoolUtilization float64                `protobuf:"fixed64,8,opt,name=worker_pool_utilization,json=workerPoolUtilization,proto3" json:"worker_pool_utilization,omitempty"` // 计数器工作池使用率，0-1
+	ObjectPoolHitRate     float64                `protobuf:"fixed64,9,opt,name=object_pool_hit_rate,json=objectPoolHitRate,proto3" json:"object_pool_hit_rate,omitempty"`           // 响应对象池命中率
+	EventsDropped         int64                  `protobuf:"varint,10,opt,name=events_dropped,json=eventsDropped,proto3" json:"events_dropped,omitempty"`                           // 发送失败被丢弃的Kafka事件数
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *StatsSnapshot) Reset() {
+	*x = StatsSnapshot{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsSnapshot) ProtoMessage() {}
+
+func (x *StatsSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsSnapshot.ProtoReflect.Descriptor instead.
+func (*StatsSnapshot) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *StatsSnapshot) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetQps() float64 {
+	if x != nil {
+		return x.Qps
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetErrorRate() float64 {
+	if x != nil {
+		return x.ErrorRate
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetTotalRequests() int64 {
+	if x != nil {
+		return x.TotalRequests
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetTotalErrors() int64 {
+	if x != nil {
+		return x.TotalErrors
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetWorkerPoolRunning() int32 {
+	if x != nil {
+		return x.WorkerPoolRunning
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetWorkerPoolCapacity() int32 {
+	if x != nil {
+		return x.WorkerPoolCapacity
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetWorkerPoolUtilization() float64 {
+	if x != nil {
+		return x.WorkerPoolUtilization
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetObjectPoolHitRate() float64 {
+	if x != nil {
+		return x.ObjectPoolHitRate
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetEventsDropped() int64 {
+	if x != nil {
+		return x.EventsDropped
+	}
+	return 0
+}
+
+// 监听计数器请求
+type WatchCounterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	IntervalMs    int32                  `protobuf:"varint,3,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"` // 服务端检查变化的间隔，<=0时使用服务端默认值，过小时按服务端下限处理
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchCounterRequest) Reset() {
+	*x = WatchCounterRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchCounterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchCounterRequest) ProtoMessage() {}
+
+func (x *WatchCounterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchCounterRequest.ProtoReflect.Descriptor instead.
+func (*WatchCounterRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *WatchCounterRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *WatchCounterRequest) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *WatchCounterRequest) GetIntervalMs() int32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+// 计数器值更新
+type CounterUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Value         int64                  `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	TimestampMs   int64                  `protobuf:"varint,4,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CounterUpdate) Reset() {
+	*x = CounterUpdate{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterUpdate) ProtoMessage() {}
+
+func (x *CounterUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterUpdate.ProtoReflect.Descriptor instead.
+func (*CounterUpdate) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CounterUpdate) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *CounterUpdate) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *CounterUpdate) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *CounterUpdate) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+// 热点排行请求
+type GetHotRankRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CounterType   string                 `protobuf:"bytes,1,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Period        string                 `protobuf:"bytes,2,opt,name=period,proto3" json:"period,omitempty"` // hour、day、week，为空时按天统计
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`  // 返回数量，<=0时使用默认值，超过服务端上限时使用上限
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHotRankRequest) Reset() {
+	*x = GetHotRankRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchIncrementRequest) String() string {
+func (x *GetHotRankRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchIncrementRequest) ProtoMessage() {}
+func (*GetHotRankRequest) ProtoMessage() {}
 
-func (x *BatchIncrementRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_counter_counter_proto_msgTypes[8]
+func (x *GetHotRankRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -528,51 +1872,57 @@ func (x *BatchIncrementRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchIncrementRequest.ProtoReflect.Descriptor instead.
-func (*BatchIncrementRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use GetHotRankRequest.ProtoReflect.Descriptor instead.
+func (*GetHotRankRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *BatchIncrementRequest) GetOperations() []*IncrementRequest {
+func (x *GetHotRankRequest) GetCounterType() string {
 	if x != nil {
-		return x.Operations
+		return x.CounterType
 	}
-	return nil
+	return ""
 }
 
-func (x *BatchIncrementRequest) GetAsync() bool {
+func (x *GetHotRankRequest) GetPeriod() string {
 	if x != nil {
-		return x.Async
+		return x.Period
 	}
-	return false
+	return ""
 }
 
-// 新增：批量增量响应
-type BatchIncrementResponse struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Results        []*IncrementResponse   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
-	Status         *common.Status         `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
-	ProcessedCount int32                  `protobuf:"varint,3,opt,name=processed_count,json=processedCount,proto3" json:"processed_count,omitempty"` // 处理成功的数量
-	FailedCount    int32                  `protobuf:"varint,4,opt,name=failed_count,json=failedCount,proto3" json:"failed_count,omitempty"`          // 处理失败的数量
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+func (x *GetHotRankRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
 }
 
-func (x *BatchIncrementResponse) Reset() {
-	*x = BatchIncrementResponse{}
-	mi := &file_api_proto_counter_counter_proto_msgTypes[9]
+// 热点排行项
+type HotRankEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	Score         int64                  `protobuf:"varint,2,opt,name=score,proto3" json:"score,omitempty"` // 时间桶内的累计增量
+	Rank          int32                  `protobuf:"varint,3,opt,name=rank,proto3" json:"rank,omitempty"`   // 从1开始的名次
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HotRankEntry) Reset() {
+	*x = HotRankEntry{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchIncrementResponse) String() string {
+func (x *HotRankEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchIncrementResponse) ProtoMessage() {}
+func (*HotRankEntry) ProtoMessage() {}
 
-func (x *BatchIncrementResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_counter_counter_proto_msgTypes[9]
+func (x *HotRankEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -583,35 +1933,235 @@ func (x *BatchIncrementResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchIncrementResponse.ProtoReflect.Descriptor instead.
-func (*BatchIncrementResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use HotRankEntry.ProtoReflect.Descriptor instead.
+func (*HotRankEntry) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *BatchIncrementResponse) GetResults() []*IncrementResponse {
+func (x *HotRankEntry) GetResourceId() string {
 	if x != nil {
-		return x.Results
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *HotRankEntry) GetScore() int64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *HotRankEntry) GetRank() int32 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+// 热点排行响应
+type GetHotRankResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	Period        string                 `protobuf:"bytes,3,opt,name=period,proto3" json:"period,omitempty"`
+	Items         []*HotRankEntry        `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHotRankResponse) Reset() {
+	*x = GetHotRankResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHotRankResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHotRankResponse) ProtoMessage() {}
+
+func (x *GetHotRankResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHotRankResponse.ProtoReflect.Descriptor instead.
+func (*GetHotRankResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetHotRankResponse) GetStatus() *common.Status {
+	if x != nil {
+		return x.Status
 	}
 	return nil
 }
 
-func (x *BatchIncrementResponse) GetStatus() *common.Status {
+func (x *GetHotRankResponse) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *GetHotRankResponse) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *GetHotRankResponse) GetItems() []*HotRankEntry {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// 重置计数器请求
+type ResetCounterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,2,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 可选，执行重置的操作人，写入计数事件
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetCounterRequest) Reset() {
+	*x = ResetCounterRequest{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetCounterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetCounterRequest) ProtoMessage() {}
+
+func (x *ResetCounterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetCounterRequest.ProtoReflect.Descriptor instead.
+func (*ResetCounterRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ResetCounterRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *ResetCounterRequest) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *ResetCounterRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// 重置计数器响应
+type ResetCounterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *common.Status         `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	ResourceId    string                 `protobuf:"bytes,2,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CounterType   string                 `protobuf:"bytes,3,opt,name=counter_type,json=counterType,proto3" json:"counter_type,omitempty"`
+	PreviousValue int64                  `protobuf:"varint,4,opt,name=previous_value,json=previousValue,proto3" json:"previous_value,omitempty"` // 重置前的计数值
+	FencingToken  int64                  `protobuf:"varint,5,opt,name=fencing_token,json=fencingToken,proto3" json:"fencing_token,omitempty"`    // 本次重置持有的锁令牌，单调递增，用于排查重置顺序
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetCounterResponse) Reset() {
+	*x = ResetCounterResponse{}
+	mi := &file_api_proto_counter_counter_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetCounterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetCounterResponse) ProtoMessage() {}
+
+func (x *ResetCounterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_counter_counter_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetCounterResponse.ProtoReflect.Descriptor instead.
+func (*ResetCounterResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_counter_counter_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ResetCounterResponse) GetStatus() *common.Status {
 	if x != nil {
 		return x.Status
 	}
 	return nil
 }
 
-func (x *BatchIncrementResponse) GetProcessedCount() int32 {
+func (x *ResetCounterResponse) GetResourceId() string {
 	if x != nil {
-		return x.ProcessedCount
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *ResetCounterResponse) GetCounterType() string {
+	if x != nil {
+		return x.CounterType
+	}
+	return ""
+}
+
+func (x *ResetCounterResponse) GetPreviousValue() int64 {
+	if x != nil {
+		return x.PreviousValue
 	}
 	return 0
 }
 
-func (x *BatchIncrementResponse) GetFailedCount() int32 {
+func (x *ResetCounterResponse) GetFencingToken() int64 {
 	if x != nil {
-		return x.FailedCount
+		return x.FencingToken
 	}
 	return 0
 }
@@ -620,13 +2170,15 @@ var File_api_proto_counter_counter_proto protoreflect.FileDescriptor
 
 const file_api_proto_counter_counter_proto_rawDesc = "" +
 	"\n" +
-	"\x1fapi/proto/counter/counter.proto\x12\acounter\x1a\x1capi/proto/common/types.proto\"\xee\x01\n" +
+	"\x1fapi/proto/counter/counter.proto\x12\acounter\x1a\x1capi/proto/common/types.proto\"\xa4\x02\n" +
 	"\x10IncrementRequest\x12\x1f\n" +
 	"\vresource_id\x18\x01 \x01(\tR\n" +
 	"resourceId\x12!\n" +
 	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x14\n" +
 	"\x05delta\x18\x03 \x01(\x03R\x05delta\x12C\n" +
-	"\bmetadata\x18\x04 \x03(\v2'.counter.IncrementRequest.MetadataEntryR\bmetadata\x1a;\n" +
+	"\bmetadata\x18\x04 \x03(\v2'.counter.IncrementRequest.MetadataEntryR\bmetadata\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tclient_ip\x18\x06 \x01(\tR\bclientIp\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa4\x01\n" +
@@ -635,6 +2187,43 @@ const file_api_proto_counter_counter_proto_rawDesc = "" +
 	"\rcurrent_value\x18\x02 \x01(\x03R\fcurrentValue\x12\x1f\n" +
 	"\vresource_id\x18\x03 \x01(\tR\n" +
 	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x04 \x01(\tR\vcounterType\"\xc8\x02\n" +
+	"\x10DecrementRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x14\n" +
+	"\x05delta\x18\x03 \x01(\x03R\x05delta\x12\"\n" +
+	"\rclamp_at_zero\x18\x04 \x01(\bR\vclampAtZero\x12C\n" +
+	"\bmetadata\x18\x05 \x03(\v2'.counter.DecrementRequest.MetadataEntryR\bmetadata\x12\x17\n" +
+	"\auser_id\x18\x06 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tclient_ip\x18\a \x01(\tR\bclientIp\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbe\x01\n" +
+	"\x11DecrementResponse\x12&\n" +
+	"\x06status\x18\x01 \x01(\v2\x0e.common.StatusR\x06status\x12#\n" +
+	"\rcurrent_value\x18\x02 \x01(\x03R\fcurrentValue\x12\x1f\n" +
+	"\vresource_id\x18\x03 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x04 \x01(\tR\vcounterType\x12\x18\n" +
+	"\aclamped\x18\x05 \x01(\bR\aclamped\"\xd1\x02\n" +
+	"\x15CompareAndSwapRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x1a\n" +
+	"\bexpected\x18\x03 \x01(\x03R\bexpected\x12\x1b\n" +
+	"\tnew_value\x18\x04 \x01(\x03R\bnewValue\x12H\n" +
+	"\bmetadata\x18\x05 \x03(\v2,.counter.CompareAndSwapRequest.MetadataEntryR\bmetadata\x12\x17\n" +
+	"\auser_id\x18\x06 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tclient_ip\x18\a \x01(\tR\bclientIp\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9e\x01\n" +
+	"\x16CompareAndSwapResponse\x12&\n" +
+	"\x06status\x18\x01 \x01(\v2\x0e.common.StatusR\x06status\x12\x18\n" +
+	"\aswapped\x18\x02 \x01(\bR\aswapped\x12\x1f\n" +
+	"\vresource_id\x18\x03 \x01(\tR\n" +
+	"resourceId\x12!\n" +
 	"\fcounter_type\x18\x04 \x01(\tR\vcounterType\"W\n" +
 	"\x11GetCounterRequest\x12\x1f\n" +
 	"\vresource_id\x18\x01 \x01(\tR\n" +
@@ -660,24 +2249,140 @@ const file_api_proto_counter_counter_proto_rawDesc = "" +
 	"\adetails\x18\x03 \x03(\v2).counter.HealthCheckResponse.DetailsEntryR\adetails\x1a:\n" +
 	"\fDetailsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"h\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x85\x01\n" +
 	"\x15BatchIncrementRequest\x129\n" +
 	"\n" +
 	"operations\x18\x01 \x03(\v2\x19.counter.IncrementRequestR\n" +
 	"operations\x12\x14\n" +
-	"\x05async\x18\x02 \x01(\bR\x05async\"\xc2\x01\n" +
+	"\x05async\x18\x02 \x01(\bR\x05async\x12\x1b\n" +
+	"\ttrack_job\x18\x03 \x01(\bR\btrackJob\"\xd9\x01\n" +
 	"\x16BatchIncrementResponse\x124\n" +
 	"\aresults\x18\x01 \x03(\v2\x1a.counter.IncrementResponseR\aresults\x12&\n" +
 	"\x06status\x18\x02 \x01(\v2\x0e.common.StatusR\x06status\x12'\n" +
 	"\x0fprocessed_count\x18\x03 \x01(\x05R\x0eprocessedCount\x12!\n" +
-	"\ffailed_count\x18\x04 \x01(\x05R\vfailedCount2\x90\x03\n" +
+	"\ffailed_count\x18\x04 \x01(\x05R\vfailedCount\x12\x15\n" +
+	"\x06job_id\x18\x05 \x01(\tR\x05jobId\".\n" +
+	"\x15GetBatchStatusRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\xa8\x02\n" +
+	"\x16GetBatchStatusResponse\x12&\n" +
+	"\x06status\x18\x01 \x01(\v2\x0e.common.StatusR\x06status\x12\x15\n" +
+	"\x06job_id\x18\x02 \x01(\tR\x05jobId\x12,\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x16.counter.BatchJobStateR\x05state\x12\x1f\n" +
+	"\vtotal_count\x18\x04 \x01(\x05R\n" +
+	"totalCount\x12'\n" +
+	"\x0fprocessed_count\x18\x05 \x01(\x05R\x0eprocessedCount\x12!\n" +
+	"\ffailed_count\x18\x06 \x01(\x05R\vfailedCount\x124\n" +
+	"\aresults\x18\a \x03(\v2\x1a.counter.IncrementResponseR\aresults\"{\n" +
+	"\x10GetOrInitRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12#\n" +
+	"\rinitial_value\x18\x03 \x01(\x03R\finitialValue\"\xaf\x01\n" +
+	"\x11GetOrInitResponse\x12&\n" +
+	"\x06status\x18\x01 \x01(\v2\x0e.common.StatusR\x06status\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value\x12\x1f\n" +
+	"\vresource_id\x18\x03 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x04 \x01(\tR\vcounterType\x12\x18\n" +
+	"\acreated\x18\x05 \x01(\bR\acreated\"=\n" +
+	"\x1aGetResourceCountersRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\"\x91\x02\n" +
+	"\x1bGetResourceCountersResponse\x12&\n" +
+	"\x06status\x18\x01 \x01(\v2\x0e.common.StatusR\x06status\x12\x1f\n" +
+	"\vresource_id\x18\x02 \x01(\tR\n" +
+	"resourceId\x12N\n" +
+	"\bcounters\x18\x03 \x03(\v22.counter.GetResourceCountersResponse.CountersEntryR\bcounters\x12\x1c\n" +
+	"\ttruncated\x18\x04 \x01(\bR\ttruncated\x1a;\n" +
+	"\rCountersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"q\n" +
+	"\x18FindCountersAboveRequest\x12!\n" +
+	"\fcounter_type\x18\x01 \x01(\tR\vcounterType\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x03R\tthreshold\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"m\n" +
+	"\x11CounterAboveEntry\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x03R\x05value\"5\n" +
+	"\x12StreamStatsRequest\x12\x1f\n" +
+	"\vinterval_ms\x18\x01 \x01(\x05R\n" +
+	"intervalMs\"\x9f\x03\n" +
+	"\rStatsSnapshot\x12!\n" +
+	"\ftimestamp_ms\x18\x01 \x01(\x03R\vtimestampMs\x12\x10\n" +
+	"\x03qps\x18\x02 \x01(\x01R\x03qps\x12\x1d\n" +
+	"\n" +
+	"error_rate\x18\x03 \x01(\x01R\terrorRate\x12%\n" +
+	"\x0etotal_requests\x18\x04 \x01(\x03R\rtotalRequests\x12!\n" +
+	"\ftotal_errors\x18\x05 \x01(\x03R\vtotalErrors\x12.\n" +
+	"\x13worker_pool_running\x18\x06 \x01(\x05R\x11workerPoolRunning\x120\n" +
+	"\x14worker_pool_capacity\x18\a \x01(\x05R\x12workerPoolCapacity\x126\n" +
+	"\x17worker_pool_utilization\x18\b \x01(\x01R\x15workerPoolUtilization\x12/\n" +
+	"\x14object_pool_hit_rate\x18\t \x01(\x01R\x11objectPoolHitRate\x12%\n" +
+	"\x0eevents_dropped\x18\n" +
+	" \x01(\x03R\reventsDropped\"z\n" +
+	"\x13WatchCounterRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x1f\n" +
+	"\vinterval_ms\x18\x03 \x01(\x05R\n" +
+	"intervalMs\"\x8c\x01\n" +
+	"\rCounterUpdate\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x03R\x05value\x12!\n" +
+	"\ftimestamp_ms\x18\x04 \x01(\x03R\vtimestampMs\"d\n" +
+	"\x11GetHotRankRequest\x12!\n" +
+	"\fcounter_type\x18\x01 \x01(\tR\vcounterType\x12\x16\n" +
+	"\x06period\x18\x02 \x01(\tR\x06period\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"Y\n" +
+	"\fHotRankEntry\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x03R\x05score\x12\x12\n" +
+	"\x04rank\x18\x03 \x01(\x05R\x04rank\"\xa4\x01\n" +
+	"\x12GetHotRankResponse\x12&\n" +
+	"\x06status\x18\x01 \x01(\v2\x0e.common.StatusR\x06status\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x16\n" +
+	"\x06period\x18\x03 \x01(\tR\x06period\x12+\n" +
+	"\x05items\x18\x04 \x03(\v2\x15.counter.HotRankEntryR\x05items\"r\n" +
+	"\x13ResetCounterRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x02 \x01(\tR\vcounterType\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\"\xce\x01\n" +
+	"\x14ResetCounterResponse\x12&\n" +
+	"\x06status\x18\x01 \x01(\v2\x0e.common.StatusR\x06status\x12\x1f\n" +
+	"\vresource_id\x18\x02 \x01(\tR\n" +
+	"resourceId\x12!\n" +
+	"\fcounter_type\x18\x03 \x01(\tR\vcounterType\x12%\n" +
+	"\x0eprevious_value\x18\x04 \x01(\x03R\rpreviousValue\x12#\n" +
+	"\rfencing_token\x18\x05 \x01(\x03R\ffencingToken*\x8b\x01\n" +
+	"\rBatchJobState\x12\x1f\n" +
+	"\x1bBATCH_JOB_STATE_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17BATCH_JOB_STATE_RUNNING\x10\x01\x12\x1d\n" +
+	"\x19BATCH_JOB_STATE_COMPLETED\x10\x02\x12\x1d\n" +
+	"\x19BATCH_JOB_STATE_CANCELLED\x10\x032\xad\t\n" +
 	"\x0eCounterService\x12I\n" +
-	"\x10IncrementCounter\x12\x19.counter.IncrementRequest\x1a\x1a.counter.IncrementResponse\x12E\n" +
+	"\x10IncrementCounter\x12\x19.counter.IncrementRequest\x1a\x1a.counter.IncrementResponse\x12I\n" +
+	"\x10DecrementCounter\x12\x19.counter.DecrementRequest\x1a\x1a.counter.DecrementResponse\x12X\n" +
+	"\x15CompareAndSwapCounter\x12\x1e.counter.CompareAndSwapRequest\x1a\x1f.counter.CompareAndSwapResponse\x12E\n" +
 	"\n" +
 	"GetCounter\x12\x1a.counter.GetCounterRequest\x1a\x1b.counter.GetCounterResponse\x12G\n" +
 	"\x10BatchGetCounters\x12\x18.counter.BatchGetRequest\x1a\x19.counter.BatchGetResponse\x12H\n" +
 	"\vHealthCheck\x12\x1b.counter.HealthCheckRequest\x1a\x1c.counter.HealthCheckResponse\x12Y\n" +
-	"\x16BatchIncrementCounters\x12\x1e.counter.BatchIncrementRequest\x1a\x1f.counter.BatchIncrementResponseB!Z\x1fhigh-go-press/api/proto/counterb\x06proto3"
+	"\x16BatchIncrementCounters\x12\x1e.counter.BatchIncrementRequest\x1a\x1f.counter.BatchIncrementResponse\x12Q\n" +
+	"\x0eGetBatchStatus\x12\x1e.counter.GetBatchStatusRequest\x1a\x1f.counter.GetBatchStatusResponse\x12I\n" +
+	"\x10GetOrInitCounter\x12\x19.counter.GetOrInitRequest\x1a\x1a.counter.GetOrInitResponse\x12`\n" +
+	"\x13GetResourceCounters\x12#.counter.GetResourceCountersRequest\x1a$.counter.GetResourceCountersResponse\x12T\n" +
+	"\x11FindCountersAbove\x12!.counter.FindCountersAboveRequest\x1a\x1a.counter.CounterAboveEntry0\x01\x12D\n" +
+	"\vStreamStats\x12\x1b.counter.StreamStatsRequest\x1a\x16.counter.StatsSnapshot0\x01\x12F\n" +
+	"\fWatchCounter\x12\x1c.counter.WatchCounterRequest\x1a\x16.counter.CounterUpdate0\x01\x12E\n" +
+	"\n" +
+	"GetHotRank\x12\x1a.counter.GetHotRankRequest\x1a\x1b.counter.GetHotRankResponse\x12K\n" +
+	"\fResetCounter\x12\x1c.counter.ResetCounterRequest\x1a\x1d.counter.ResetCounterResponseB!Z\x1fhigh-go-press/api/proto/counterb\x06proto3"
 
 var (
 	file_api_proto_counter_counter_proto_rawDescOnce sync.Once
@@ -691,51 +2396,110 @@ func file_api_proto_counter_counter_proto_rawDescGZIP() []byte {
 	return file_api_proto_counter_counter_proto_rawDescData
 }
 
-var file_api_proto_counter_counter_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_api_proto_counter_counter_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_proto_counter_counter_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_api_proto_counter_counter_proto_goTypes = []any{
-	(*IncrementRequest)(nil),       // 0: counter.IncrementRequest
-	(*IncrementResponse)(nil),      // 1: counter.IncrementResponse
-	(*GetCounterRequest)(nil),      // 2: counter.GetCounterRequest
-	(*GetCounterResponse)(nil),     // 3: counter.GetCounterResponse
-	(*BatchGetRequest)(nil),        // 4: counter.BatchGetRequest
-	(*BatchGetResponse)(nil),       // 5: counter.BatchGetResponse
-	(*HealthCheckRequest)(nil),     // 6: counter.HealthCheckRequest
-	(*HealthCheckResponse)(nil),    // 7: counter.HealthCheckResponse
-	(*BatchIncrementRequest)(nil),  // 8: counter.BatchIncrementRequest
-	(*BatchIncrementResponse)(nil), // 9: counter.BatchIncrementResponse
-	nil,                            // 10: counter.IncrementRequest.MetadataEntry
-	nil,                            // 11: counter.HealthCheckResponse.DetailsEntry
-	(*common.Status)(nil),          // 12: common.Status
-	(*common.Timestamp)(nil),       // 13: common.Timestamp
+	(BatchJobState)(0),                  // 0: counter.BatchJobState
+	(*IncrementRequest)(nil),            // 1: counter.IncrementRequest
+	(*IncrementResponse)(nil),           // 2: counter.IncrementResponse
+	(*DecrementRequest)(nil),            // 3: counter.DecrementRequest
+	(*DecrementResponse)(nil),           // 4: counter.DecrementResponse
+	(*CompareAndSwapRequest)(nil),       // 5: counter.CompareAndSwapRequest
+	(*CompareAndSwapResponse)(nil),      // 6: counter.CompareAndSwapResponse
+	(*GetCounterRequest)(nil),           // 7: counter.GetCounterRequest
+	(*GetCounterResponse)(nil),          // 8: counter.GetCounterResponse
+	(*BatchGetRequest)(nil),             // 9: counter.BatchGetRequest
+	(*BatchGetResponse)(nil),            // 10: counter.BatchGetResponse
+	(*HealthCheckRequest)(nil),          // 11: counter.HealthCheckRequest
+	(*HealthCheckResponse)(nil),         // 12: counter.HealthCheckResponse
+	(*BatchIncrementRequest)(nil),       // 13: counter.BatchIncrementRequest
+	(*BatchIncrementResponse)(nil),      // 14: counter.BatchIncrementResponse
+	(*GetBatchStatusRequest)(nil),       // 15: counter.GetBatchStatusRequest
+	(*GetBatchStatusResponse)(nil),      // 16: counter.GetBatchStatusResponse
+	(*GetOrInitRequest)(nil),            // 17: counter.GetOrInitRequest
+	(*GetOrInitResponse)(nil),           // 18: counter.GetOrInitResponse
+	(*GetResourceCountersRequest)(nil),  // 19: counter.GetResourceCountersRequest
+	(*GetResourceCountersResponse)(nil), // 20: counter.GetResourceCountersResponse
+	(*FindCountersAboveRequest)(nil),    // 21: counter.FindCountersAboveRequest
+	(*CounterAboveEntry)(nil),           // 22: counter.CounterAboveEntry
+	(*StreamStatsRequest)(nil),          // 23: counter.StreamStatsRequest
+	(*StatsSnapshot)(nil),               // 24: counter.StatsSnapshot
+	(*WatchCounterRequest)(nil),         // 25: counter.WatchCounterRequest
+	(*CounterUpdate)(nil),               // 26: counter.CounterUpdate
+	(*GetHotRankRequest)(nil),           // 27: counter.GetHotRankRequest
+	(*HotRankEntry)(nil),                // 28: counter.HotRankEntry
+	(*GetHotRankResponse)(nil),          // 29: counter.GetHotRankResponse
+	(*ResetCounterRequest)(nil),         // 30: counter.ResetCounterRequest
+	(*ResetCounterResponse)(nil),        // 31: counter.ResetCounterResponse
+	nil,                                 // 32: counter.IncrementRequest.MetadataEntry
+	nil,                                 // 33: counter.DecrementRequest.MetadataEntry
+	nil,                                 // 34: counter.CompareAndSwapRequest.MetadataEntry
+	nil,                                 // 35: counter.HealthCheckResponse.DetailsEntry
+	nil,                                 // 36: counter.GetResourceCountersResponse.CountersEntry
+	(*common.Status)(nil),               // 37: common.Status
+	(*common.Timestamp)(nil),            // 38: common.Timestamp
 }
 var file_api_proto_counter_counter_proto_depIdxs = []int32{
-	10, // 0: counter.IncrementRequest.metadata:type_name -> counter.IncrementRequest.MetadataEntry
-	12, // 1: counter.IncrementResponse.status:type_name -> common.Status
-	12, // 2: counter.GetCounterResponse.status:type_name -> common.Status
-	13, // 3: counter.GetCounterResponse.last_updated:type_name -> common.Timestamp
-	2,  // 4: counter.BatchGetRequest.requests:type_name -> counter.GetCounterRequest
-	12, // 5: counter.BatchGetResponse.status:type_name -> common.Status
-	3,  // 6: counter.BatchGetResponse.counters:type_name -> counter.GetCounterResponse
-	12, // 7: counter.HealthCheckResponse.status:type_name -> common.Status
-	11, // 8: counter.HealthCheckResponse.details:type_name -> counter.HealthCheckResponse.DetailsEntry
-	0,  // 9: counter.BatchIncrementRequest.operations:type_name -> counter.IncrementRequest
-	1,  // 10: counter.BatchIncrementResponse.results:type_name -> counter.IncrementResponse
-	12, // 11: counter.BatchIncrementResponse.status:type_name -> common.Status
-	0,  // 12: counter.CounterService.IncrementCounter:input_type -> counter.IncrementRequest
-	2,  // 13: counter.CounterService.GetCounter:input_type -> counter.GetCounterRequest
-	4,  // 14: counter.CounterService.BatchGetCounters:input_type -> counter.BatchGetRequest
-	6,  // 15: counter.CounterService.HealthCheck:input_type -> counter.HealthCheckRequest
-	8,  // 16: counter.CounterService.BatchIncrementCounters:input_type -> counter.BatchIncrementRequest
-	1,  // 17: counter.CounterService.IncrementCounter:output_type -> counter.IncrementResponse
-	3,  // 18: counter.CounterService.GetCounter:output_type -> counter.GetCounterResponse
-	5,  // 19: counter.CounterService.BatchGetCounters:output_type -> counter.BatchGetResponse
-	7,  // 20: counter.CounterService.HealthCheck:output_type -> counter.HealthCheckResponse
-	9,  // 21: counter.CounterService.BatchIncrementCounters:output_type -> counter.BatchIncrementResponse
-	17, // [17:22] is the sub-list for method output_type
-	12, // [12:17] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	32, // 0: counter.IncrementRequest.metadata:type_name -> counter.IncrementRequest.MetadataEntry
+	37, // 1: counter.IncrementResponse.status:type_name -> common.Status
+	33, // 2: counter.DecrementRequest.metadata:type_name -> counter.DecrementRequest.MetadataEntry
+	37, // 3: counter.DecrementResponse.status:type_name -> common.Status
+	34, // 4: counter.CompareAndSwapRequest.metadata:type_name -> counter.CompareAndSwapRequest.MetadataEntry
+	37, // 5: counter.CompareAndSwapResponse.status:type_name -> common.Status
+	37, // 6: counter.GetCounterResponse.status:type_name -> common.Status
+	38, // 7: counter.GetCounterResponse.last_updated:type_name -> common.Timestamp
+	7,  // 8: counter.BatchGetRequest.requests:type_name -> counter.GetCounterRequest
+	37, // 9: counter.BatchGetResponse.status:type_name -> common.Status
+	8,  // 10: counter.BatchGetResponse.counters:type_name -> counter.GetCounterResponse
+	37, // 11: counter.HealthCheckResponse.status:type_name -> common.Status
+	35, // 12: counter.HealthCheckResponse.details:type_name -> counter.HealthCheckResponse.DetailsEntry
+	1,  // 13: counter.BatchIncrementRequest.operations:type_name -> counter.IncrementRequest
+	2,  // 14: counter.BatchIncrementResponse.results:type_name -> counter.IncrementResponse
+	37, // 15: counter.BatchIncrementResponse.status:type_name -> common.Status
+	37, // 16: counter.GetBatchStatusResponse.status:type_name -> common.Status
+	0,  // 17: counter.GetBatchStatusResponse.state:type_name -> counter.BatchJobState
+	2,  // 18: counter.GetBatchStatusResponse.results:type_name -> counter.IncrementResponse
+	37, // 19: counter.GetOrInitResponse.status:type_name -> common.Status
+	37, // 20: counter.GetResourceCountersResponse.status:type_name -> common.Status
+	36, // 21: counter.GetResourceCountersResponse.counters:type_name -> counter.GetResourceCountersResponse.CountersEntry
+	37, // 22: counter.GetHotRankResponse.status:type_name -> common.Status
+	28, // 23: counter.GetHotRankResponse.items:type_name -> counter.HotRankEntry
+	37, // 24: counter.ResetCounterResponse.status:type_name -> common.Status
+	1,  // 25: counter.CounterService.IncrementCounter:input_type -> counter.IncrementRequest
+	3,  // 26: counter.CounterService.DecrementCounter:input_type -> counter.DecrementRequest
+	5,  // 27: counter.CounterService.CompareAndSwapCounter:input_type -> counter.CompareAndSwapRequest
+	7,  // 28: counter.CounterService.GetCounter:input_type -> counter.GetCounterRequest
+	9,  // 29: counter.CounterService.BatchGetCounters:input_type -> counter.BatchGetRequest
+	11, // 30: counter.CounterService.HealthCheck:input_type -> counter.HealthCheckRequest
+	13, // 31: counter.CounterService.BatchIncrementCounters:input_type -> counter.BatchIncrementRequest
+	15, // 32: counter.CounterService.GetBatchStatus:input_type -> counter.GetBatchStatusRequest
+	17, // 33: counter.CounterService.GetOrInitCounter:input_type -> counter.GetOrInitRequest
+	19, // 34: counter.CounterService.GetResourceCounters:input_type -> counter.GetResourceCountersRequest
+	21, // 35: counter.CounterService.FindCountersAbove:input_type -> counter.FindCountersAboveRequest
+	23, // 36: counter.CounterService.StreamStats:input_type -> counter.StreamStatsRequest
+	25, // 37: counter.CounterService.WatchCounter:input_type -> counter.WatchCounterRequest
+	27, // 38: counter.CounterService.GetHotRank:input_type -> counter.GetHotRankRequest
+	30, // 39: counter.CounterService.ResetCounter:input_type -> counter.ResetCounterRequest
+	2,  // 40: counter.CounterService.IncrementCounter:output_type -> counter.IncrementResponse
+	4,  // 41: counter.CounterService.DecrementCounter:output_type -> counter.DecrementResponse
+	6,  // 42: counter.CounterService.CompareAndSwapCounter:output_type -> counter.CompareAndSwapResponse
+	8,  // 43: counter.CounterService.GetCounter:output_type -> counter.GetCounterResponse
+	10, // 44: counter.CounterService.BatchGetCounters:output_type -> counter.BatchGetResponse
+	12, // 45: counter.CounterService.HealthCheck:output_type -> counter.HealthCheckResponse
+	14, // 46: counter.CounterService.BatchIncrementCounters:output_type -> counter.BatchIncrementResponse
+	16, // 47: counter.CounterService.GetBatchStatus:output_type -> counter.GetBatchStatusResponse
+	18, // 48: counter.CounterService.GetOrInitCounter:output_type -> counter.GetOrInitResponse
+	20, // 49: counter.CounterService.GetResourceCounters:output_type -> counter.GetResourceCountersResponse
+	22, // 50: counter.CounterService.FindCountersAbove:output_type -> counter.CounterAboveEntry
+	24, // 51: counter.CounterService.StreamStats:output_type -> counter.StatsSnapshot
+	26, // 52: counter.CounterService.WatchCounter:output_type -> counter.CounterUpdate
+	29, // 53: counter.CounterService.GetHotRank:output_type -> counter.GetHotRankResponse
+	31, // 54: counter.CounterService.ResetCounter:output_type -> counter.ResetCounterResponse
+	40, // [40:55] is the sub-list for method output_type
+	25, // [25:40] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_counter_counter_proto_init() }
@@ -748,13 +2512,14 @@ func file_api_proto_counter_counter_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_counter_counter_proto_rawDesc), len(file_api_proto_counter_counter_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   12,
+			NumEnums:      1,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_proto_counter_counter_proto_goTypes,
 		DependencyIndexes: file_api_proto_counter_counter_proto_depIdxs,
+		EnumInfos:         file_api_proto_counter_counter_proto_enumTypes,
 		MessageInfos:      file_api_proto_counter_counter_proto_msgTypes,
 	}.Build()
 	File_api_proto_counter_counter_proto = out.File
@@ -20,10 +20,20 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	CounterService_IncrementCounter_FullMethodName       = "/counter.CounterService/IncrementCounter"
+	CounterService_DecrementCounter_FullMethodName       = "/counter.CounterService/DecrementCounter"
+	CounterService_CompareAndSwapCounter_FullMethodName  = "/counter.CounterService/CompareAndSwapCounter"
 	CounterService_GetCounter_FullMethodName             = "/counter.CounterService/GetCounter"
 	CounterService_BatchGetCounters_FullMethodName       = "/counter.CounterService/BatchGetCounters"
 	CounterService_HealthCheck_FullMethodName            = "/counter.CounterService/HealthCheck"
 	CounterService_BatchIncrementCounters_FullMethodName = "/counter.CounterService/BatchIncrementCounters"
+	CounterService_GetBatchStatus_FullMethodName         = "/counter.CounterService/GetBatchStatus"
+	CounterService_GetOrInitCounter_FullMethodName       = "/counter.CounterService/GetOrInitCounter"
+	CounterService_GetResourceCounters_FullMethodName    = "/counter.CounterService/GetResourceCounters"
+	CounterService_FindCountersAbove_FullMethodName      = "/counter.CounterService/FindCountersAbove"
+	CounterService_StreamStats_FullMethodName            = "/counter.CounterService/StreamStats"
+	CounterService_WatchCounter_FullMethodName           = "/counter.CounterService/WatchCounter"
+	CounterService_GetHotRank_FullMethodName             = "/counter.CounterService/GetHotRank"
+	CounterService_ResetCounter_FullMethodName           = "/counter.CounterService/ResetCounter"
 )
 
 // CounterServiceClient is the client API for CounterService service.
@@ -34,6 +44,10 @@ const (
 type CounterServiceClient interface {
 	// 计数器增量操作
 	IncrementCounter(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error)
+	// 计数器递减操作，可选择将结果截断在0
+	DecrementCounter(ctx context.Context, in *DecrementRequest, opts ...grpc.CallOption) (*DecrementResponse, error)
+	// 比较并设置：当前值等于expected时原子地设置为new_value，用于重试时避免重复计数
+	CompareAndSwapCounter(ctx context.Context, in *CompareAndSwapRequest, opts ...grpc.CallOption) (*CompareAndSwapResponse, error)
 	// 获取单个计数器值
 	GetCounter(ctx context.Context, in *GetCounterRequest, opts ...grpc.CallOption) (*GetCounterResponse, error)
 	// 批量获取计数器
@@ -42,6 +56,25 @@ type CounterServiceClient interface {
 	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
 	// 新增：批量增量操作
 	BatchIncrementCounters(ctx context.Context, in *BatchIncrementRequest, opts ...grpc.CallOption) (*BatchIncrementResponse, error)
+	// 查询异步批量任务的进度和结果
+	// 任务按租户隔离，保存在提交批量请求的实例内存中，需要在同一实例上查询
+	GetBatchStatus(ctx context.Context, in *GetBatchStatusRequest, opts ...grpc.CallOption) (*GetBatchStatusResponse, error)
+	// 获取计数器，不存在时原子地初始化为指定值
+	GetOrInitCounter(ctx context.Context, in *GetOrInitRequest, opts ...grpc.CallOption) (*GetOrInitResponse, error)
+	// 获取资源下所有类型的计数器
+	GetResourceCounters(ctx context.Context, in *GetResourceCountersRequest, opts ...grpc.CallOption) (*GetResourceCountersResponse, error)
+	// 管理接口：流式返回指定类型中计数值超过阈值的资源，按计数值从高到低
+	// 没有排行榜时回退到扫描计数器key，扫描达到服务端上限时trailer中x-scan-truncated为true
+	FindCountersAbove(ctx context.Context, in *FindCountersAboveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CounterAboveEntry], error)
+	// 管理接口：按固定间隔流式推送服务指标快照，客户端取消时结束
+	StreamStats(ctx context.Context, in *StreamStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StatsSnapshot], error)
+	// 流式推送计数器值：连接后立即推送当前值，之后值变化时推送
+	WatchCounter(ctx context.Context, in *WatchCounterRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CounterUpdate], error)
+	// 获取当前时间桶内的热点排行，需在counter.hot_rank_periods中开启对应时间范围
+	GetHotRank(ctx context.Context, in *GetHotRankRequest, opts ...grpc.CallOption) (*GetHotRankResponse, error)
+	// 管理接口：将计数器重置为0，通过Redis分布式锁保证多实例间同一计数器不会并发重置
+	// 其他实例正在重置同一计数器时返回Aborted
+	ResetCounter(ctx context.Context, in *ResetCounterRequest, opts ...grpc.CallOption) (*ResetCounterResponse, error)
 }
 
 type counterServiceClient struct {
@@ -62,6 +95,26 @@ func (c *counterServiceClient) IncrementCounter(ctx context.Context, in *Increme
 	return out, nil
 }
 
+func (c *counterServiceClient) DecrementCounter(ctx context.Context, in *DecrementRequest, opts ...grpc.CallOption) (*DecrementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DecrementResponse)
+	err := c.cc.Invoke(ctx, CounterService_DecrementCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) CompareAndSwapCounter(ctx context.Context, in *CompareAndSwapRequest, opts ...grpc.CallOption) (*CompareAndSwapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareAndSwapResponse)
+	err := c.cc.Invoke(ctx, CounterService_CompareAndSwapCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *counterServiceClient) GetCounter(ctx context.Context, in *GetCounterRequest, opts ...grpc.CallOption) (*GetCounterResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetCounterResponse)
@@ -102,6 +155,113 @@ func (c *counterServiceClient) BatchIncrementCounters(ctx context.Context, in *B
 	return out, nil
 }
 
+func (c *counterServiceClient) GetBatchStatus(ctx context.Context, in *GetBatchStatusRequest, opts ...grpc.CallOption) (*GetBatchStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBatchStatusResponse)
+	err := c.cc.Invoke(ctx, CounterService_GetBatchStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) GetOrInitCounter(ctx context.Context, in *GetOrInitRequest, opts ...grpc.CallOption) (*GetOrInitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrInitResponse)
+	err := c.cc.Invoke(ctx, CounterService_GetOrInitCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) GetResourceCounters(ctx context.Context, in *GetResourceCountersRequest, opts ...grpc.CallOption) (*GetResourceCountersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResourceCountersResponse)
+	err := c.cc.Invoke(ctx, CounterService_GetResourceCounters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) FindCountersAbove(ctx context.Context, in *FindCountersAboveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CounterAboveEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CounterService_ServiceDesc.Streams[0], CounterService_FindCountersAbove_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FindCountersAboveRequest, CounterAboveEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_FindCountersAboveClient = grpc.ServerStreamingClient[CounterAboveEntry]
+
+func (c *counterServiceClient) StreamStats(ctx context.Context, in *StreamStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StatsSnapshot], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CounterService_ServiceDesc.Streams[1], CounterService_StreamStats_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamStatsRequest, StatsSnapshot]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_StreamStatsClient = grpc.ServerStreamingClient[StatsSnapshot]
+
+func (c *counterServiceClient) WatchCounter(ctx context.Context, in *WatchCounterRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CounterUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CounterService_ServiceDesc.Streams[2], CounterService_WatchCounter_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCounterRequest, CounterUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_WatchCounterClient = grpc.ServerStreamingClient[CounterUpdate]
+
+func (c *counterServiceClient) GetHotRank(ctx context.Context, in *GetHotRankRequest, opts ...grpc.CallOption) (*GetHotRankResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetHotRankResponse)
+	err := c.cc.Invoke(ctx, CounterService_GetHotRank_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *counterServiceClient) ResetCounter(ctx context.Context, in *ResetCounterRequest, opts ...grpc.CallOption) (*ResetCounterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetCounterResponse)
+	err := c.cc.Invoke(ctx, CounterService_ResetCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CounterServiceServer is the server API for CounterService service.
 // All implementations must embed UnimplementedCounterServiceServer
 // for forward compatibility.
@@ -110,6 +270,10 @@ func (c *counterServiceClient) BatchIncrementCounters(ctx context.Context, in *B
 type CounterServiceServer interface {
 	// 计数器增量操作
 	IncrementCounter(context.Context, *IncrementRequest) (*IncrementResponse, error)
+	// 计数器递减操作，可选择将结果截断在0
+	DecrementCounter(context.Context, *DecrementRequest) (*DecrementResponse, error)
+	// 比较并设置：当前值等于expected时原子地设置为new_value，用于重试时避免重复计数
+	CompareAndSwapCounter(context.Context, *CompareAndSwapRequest) (*CompareAndSwapResponse, error)
 	// 获取单个计数器值
 	GetCounter(context.Context, *GetCounterRequest) (*GetCounterResponse, error)
 	// 批量获取计数器
@@ -118,6 +282,25 @@ type CounterServiceServer interface {
 	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
 	// 新增：批量增量操作
 	BatchIncrementCounters(context.Context, *BatchIncrementRequest) (*BatchIncrementResponse, error)
+	// 查询异步批量任务的进度和结果
+	// 任务按租户隔离，保存在提交批量请求的实例内存中，需要在同一实例上查询
+	GetBatchStatus(context.Context, *GetBatchStatusRequest) (*GetBatchStatusResponse, error)
+	// 获取计数器，不存在时原子地初始化为指定值
+	GetOrInitCounter(context.Context, *GetOrInitRequest) (*GetOrInitResponse, error)
+	// 获取资源下所有类型的计数器
+	GetResourceCounters(context.Context, *GetResourceCountersRequest) (*GetResourceCountersResponse, error)
+	// 管理接口：流式返回指定类型中计数值超过阈值的资源，按计数值从高到低
+	// 没有排行榜时回退到扫描计数器key，扫描达到服务端上限时trailer中x-scan-truncated为true
+	FindCountersAbove(*FindCountersAboveRequest, grpc.ServerStreamingServer[CounterAboveEntry]) error
+	// 管理接口：按固定间隔流式推送服务指标快照，客户端取消时结束
+	StreamStats(*StreamStatsRequest, grpc.ServerStreamingServer[StatsSnapshot]) error
+	// 流式推送计数器值：连接后立即推送当前值，之后值变化时推送
+	WatchCounter(*WatchCounterRequest, grpc.ServerStreamingServer[CounterUpdate]) error
+	// 获取当前时间桶内的热点排行，需在counter.hot_rank_periods中开启对应时间范围
+	GetHotRank(context.Context, *GetHotRankRequest) (*GetHotRankResponse, error)
+	// 管理接口：将计数器重置为0，通过Redis分布式锁保证多实例间同一计数器不会并发重置
+	// 其他实例正在重置同一计数器时返回Aborted
+	ResetCounter(context.Context, *ResetCounterRequest) (*ResetCounterResponse, error)
 	mustEmbedUnimplementedCounterServiceServer()
 }
 
@@ -131,6 +314,12 @@ type UnimplementedCounterServiceServer struct{}
 func (UnimplementedCounterServiceServer) IncrementCounter(context.Context, *IncrementRequest) (*IncrementResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IncrementCounter not implemented")
 }
+func (UnimplementedCounterServiceServer) DecrementCounter(context.Context, *DecrementRequest) (*DecrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DecrementCounter not implemented")
+}
+func (UnimplementedCounterServiceServer) CompareAndSwapCounter(context.Context, *CompareAndSwapRequest) (*CompareAndSwapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSwapCounter not implemented")
+}
 func (UnimplementedCounterServiceServer) GetCounter(context.Context, *GetCounterRequest) (*GetCounterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCounter not implemented")
 }
@@ -143,6 +332,30 @@ func (UnimplementedCounterServiceServer) HealthCheck(context.Context, *HealthChe
 func (UnimplementedCounterServiceServer) BatchIncrementCounters(context.Context, *BatchIncrementRequest) (*BatchIncrementResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchIncrementCounters not implemented")
 }
+func (UnimplementedCounterServiceServer) GetBatchStatus(context.Context, *GetBatchStatusRequest) (*GetBatchStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBatchStatus not implemented")
+}
+func (UnimplementedCounterServiceServer) GetOrInitCounter(context.Context, *GetOrInitRequest) (*GetOrInitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrInitCounter not implemented")
+}
+func (UnimplementedCounterServiceServer) GetResourceCounters(context.Context, *GetResourceCountersRequest) (*GetResourceCountersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResourceCounters not implemented")
+}
+func (UnimplementedCounterServiceServer) FindCountersAbove(*FindCountersAboveRequest, grpc.ServerStreamingServer[CounterAboveEntry]) error {
+	return status.Errorf(codes.Unimplemented, "method FindCountersAbove not implemented")
+}
+func (UnimplementedCounterServiceServer) StreamStats(*StreamStatsRequest, grpc.ServerStreamingServer[StatsSnapshot]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamStats not implemented")
+}
+func (UnimplementedCounterServiceServer) WatchCounter(*WatchCounterRequest, grpc.ServerStreamingServer[CounterUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCounter not implemented")
+}
+func (UnimplementedCounterServiceServer) GetHotRank(context.Context, *GetHotRankRequest) (*GetHotRankResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHotRank not implemented")
+}
+func (UnimplementedCounterServiceServer) ResetCounter(context.Context, *ResetCounterRequest) (*ResetCounterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetCounter not implemented")
+}
 func (UnimplementedCounterServiceServer) mustEmbedUnimplementedCounterServiceServer() {}
 func (UnimplementedCounterServiceServer) testEmbeddedByValue()                        {}
 
@@ -182,6 +395,42 @@ func _CounterService_IncrementCounter_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CounterService_DecrementCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).DecrementCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_DecrementCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).DecrementCounter(ctx, req.(*DecrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_CompareAndSwapCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareAndSwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).CompareAndSwapCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_CompareAndSwapCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).CompareAndSwapCounter(ctx, req.(*CompareAndSwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CounterService_GetCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetCounterRequest)
 	if err := dec(in); err != nil {
@@ -254,6 +503,129 @@ func _CounterService_BatchIncrementCounters_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CounterService_GetBatchStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBatchStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).GetBatchStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_GetBatchStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).GetBatchStatus(ctx, req.(*GetBatchStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_GetOrInitCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrInitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).GetOrInitCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_GetOrInitCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).GetOrInitCounter(ctx, req.(*GetOrInitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_GetResourceCounters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResourceCountersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).GetResourceCounters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_GetResourceCounters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).GetResourceCounters(ctx, req.(*GetResourceCountersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_FindCountersAbove_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FindCountersAboveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CounterServiceServer).FindCountersAbove(m, &grpc.GenericServerStream[FindCountersAboveRequest, CounterAboveEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_FindCountersAboveServer = grpc.ServerStreamingServer[CounterAboveEntry]
+
+func _CounterService_StreamStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamStatsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CounterServiceServer).StreamStats(m, &grpc.GenericServerStream[StreamStatsRequest, StatsSnapshot]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_StreamStatsServer = grpc.ServerStreamingServer[StatsSnapshot]
+
+func _CounterService_WatchCounter_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCounterRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CounterServiceServer).WatchCounter(m, &grpc.GenericServerStream[WatchCounterRequest, CounterUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CounterService_WatchCounterServer = grpc.ServerStreamingServer[CounterUpdate]
+
+func _CounterService_GetHotRank_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHotRankRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).GetHotRank(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_GetHotRank_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).GetHotRank(ctx, req.(*GetHotRankRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CounterService_ResetCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetCounterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CounterServiceServer).ResetCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CounterService_ResetCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CounterServiceServer).ResetCounter(ctx, req.(*ResetCounterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CounterService_ServiceDesc is the grpc.ServiceDesc for CounterService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -265,6 +637,14 @@ var CounterService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "IncrementCounter",
 			Handler:    _CounterService_IncrementCounter_Handler,
 		},
+		{
+			MethodName: "DecrementCounter",
+			Handler:    _CounterService_DecrementCounter_Handler,
+		},
+		{
+			MethodName: "CompareAndSwapCounter",
+			Handler:    _CounterService_CompareAndSwapCounter_Handler,
+		},
 		{
 			MethodName: "GetCounter",
 			Handler:    _CounterService_GetCounter_Handler,
@@ -281,7 +661,43 @@ var CounterService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BatchIncrementCounters",
 			Handler:    _CounterService_BatchIncrementCounters_Handler,
 		},
+		{
+			MethodName: "GetBatchStatus",
+			Handler:    _CounterService_GetBatchStatus_Handler,
+		},
+		{
+			MethodName: "GetOrInitCounter",
+			Handler:    _CounterService_GetOrInitCounter_Handler,
+		},
+		{
+			MethodName: "GetResourceCounters",
+			Handler:    _CounterService_GetResourceCounters_Handler,
+		},
+		{
+			MethodName: "GetHotRank",
+			Handler:    _CounterService_GetHotRank_Handler,
+		},
+		{
+			MethodName: "ResetCounter",
+			Handler:    _CounterService_ResetCounter_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FindCountersAbove",
+			Handler:       _CounterService_FindCountersAbove_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamStats",
+			Handler:       _CounterService_StreamStats_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchCounter",
+			Handler:       _CounterService_WatchCounter_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/counter/counter.proto",
 }
@@ -6,7 +6,6 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
 	"syscall"
 	"time"
 
@@ -15,41 +14,52 @@ import (
 	"google.golang.org/grpc/reflection"
 
 	pb "high-go-press/api/proto/analytics"
+	"high-go-press/internal/analytics/aggregation"
 	"high-go-press/internal/analytics/dao"
 	"high-go-press/internal/analytics/server"
+	counterdao "high-go-press/internal/dao"
+	"high-go-press/pkg/auth"
 	"high-go-press/pkg/config"
 	"high-go-press/pkg/consul"
 	"high-go-press/pkg/kafka"
 	"high-go-press/pkg/logger"
 	"high-go-press/pkg/metrics"
 	"high-go-press/pkg/middleware"
+	"high-go-press/pkg/shutdown"
+	"high-go-press/pkg/stats"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 )
 
-// setupHTTPMonitoringServer 设置HTTP监控服务器
-func setupHTTPMonitoringServer(metricsManager *metrics.MetricsManager, logger *zap.Logger) *http.Server {
+// setupHTTPMonitoringServer 设置HTTP监控服务器，statsCollector为nil时不挂载/debug/stats
+func setupHTTPMonitoringServer(metricsManager *metrics.MetricsManager, metricsConfig *middleware.HTTPMetricsConfig, consumer kafka.Consumer, statsCollector *stats.Collector, logger *zap.Logger) *http.Server {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Recovery())
 
-	// 添加HTTP指标中间件
-	router.Use(middleware.HTTPMetricsMiddleware(metricsManager, "analytics"))
+	// 添加HTTP指标中间件，排除的路径（默认/metrics、/health）不计入请求统计
+	router.Use(middleware.HTTPMetricsMiddlewareWithConfig(metricsManager, "analytics", metricsConfig))
 
 	// 健康检查端点
+	// standby实例（未分配分区）同样健康，分区所有者故障时由它接管
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"status":    "healthy",
 			"service":   "analytics",
 			"timestamp": time.Now().Unix(),
 			"version":   "2.0.0",
+			"consumer":  kafka.GetConsumerStatus(consumer),
 		})
 	})
 
 	// Prometheus指标端点
 	router.GET("/metrics", gin.WrapH(metricsManager.GetHandler()))
 
+	// 统一组件统计端点
+	if statsCollector != nil {
+		router.GET("/debug/stats", gin.WrapH(statsCollector))
+	}
+
 	// 服务状态端点
 	router.GET("/status", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -74,19 +84,39 @@ func setupHTTPMonitoringServer(metricsManager *metrics.MetricsManager, logger *z
 	return server
 }
 
+// flushInterval 根据聚合策略确定缓存刷新间隔
+func flushInterval(cfg config.AggregationConfig) time.Duration {
+	switch {
+	case cfg.Strategy == aggregation.StrategyWindowed && cfg.Window > 0:
+		return cfg.Window
+	case cfg.Strategy == aggregation.StrategyRateLimited && cfg.RatePerSecond > 0:
+		return time.Second / time.Duration(cfg.RatePerSecond)
+	default:
+		return time.Second
+	}
+}
+
 func main() {
+	// 服务启动或运行失败时以非0状态码退出，run中的defer已完成清理
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run 启动Analytics服务并阻塞到收到退出信号或服务失败，服务失败时返回错误
+func run() error {
 	// 初始化配置
 	cfg, err := config.Load("configs/config.yaml")
 	if err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
 	// 初始化日志
 	log, err := logger.NewLogger(cfg.Log.Level, cfg.Log.Format)
 	if err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 	defer log.Sync()
 
@@ -106,24 +136,34 @@ func main() {
 	metricsManager := metrics.NewMetricsManager(metricsConfig, log)
 	log.Info("✅ Metrics manager initialized")
 
-	// 🔧 初始化Redis连接
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379", // 可以通过环境变量配置
-		Password: "",               // 可以通过环境变量配置
-		DB:       0,                // 可以通过环境变量配置
-	})
+	// 退出时输出运行汇总
+	shutdownReporter := shutdown.NewReporter("analytics", log)
+	shutdownReporter.SetMetricsManager(metricsManager)
+
+	// 🔧 初始化Redis连接，按redis.mode连接单机、集群或哨兵
+	redisClient, err := counterdao.NewRedisClient(cfg.Redis)
+	if err != nil {
+		log.Error("Failed to create Redis client", zap.Error(err))
+		return err
+	}
+	defer redisClient.Close()
 
 	// 测试Redis连接
 	ctx := context.Background()
 	_, err = redisClient.Ping(ctx).Result()
 	if err != nil {
-		log.Fatal("Failed to connect to Redis", zap.Error(err))
+		log.Error("Failed to connect to Redis", zap.Error(err))
+		return err
 	}
 
 	log.Info("✅ Redis connection established successfully")
 
 	// 创建Analytics DAO
 	analyticsDAO := dao.NewMemoryAnalyticsDAO()
+	if cfg.Analytics.MockData {
+		analyticsDAO.SetMockData(true)
+		log.Warn("Analytics mock data is enabled, top counters and stats are NOT real data")
+	}
 
 	// 🔥 初始化Kafka
 	kafkaConfig := kafka.DefaultKafkaConfig()
@@ -139,31 +179,68 @@ func main() {
 		log.Info("Using real Kafka",
 			zap.Strings("brokers", kafkaConfig.Consumer.Brokers))
 	}
+	kafkaConfig.FallbackToMock = cfg.Kafka.FallbackToMock
+	// 生产者（死信）和消费者使用同一套TLS/SASL认证
+	security := kafka.SecurityConfigFromAppConfig(cfg.Kafka.Security)
+	kafkaConfig.Producer.Security = security
+	kafkaConfig.Consumer.Security = security
+	kafkaConfig.Fallback.RetryInterval = cfg.Kafka.FallbackRetryInterval
+
+	// 事件处理语义：at_least_once 或 effectively_once（事件ID去重 + 处理后同步提交offset）
+	processingMode, err := kafka.ParseProcessingMode(cfg.Kafka.Consumer.ProcessingMode)
+	if err != nil {
+		log.Error("Invalid Kafka processing mode", zap.Error(err))
+		return err
+	}
+	processingConfig := kafka.DefaultEventProcessingConfig()
+	processingConfig.Mode = processingMode
+	kafkaConfig.Consumer.ProcessingMode = processingMode
+	kafkaConfig.Consumer.CommitBatchSize = cfg.Kafka.Consumer.CommitBatchSize
+	kafkaConfig.Consumer.CommitInterval = cfg.Kafka.Consumer.CommitInterval
+	kafkaConfig.Consumer.PartitionConcurrency = cfg.Kafka.Consumer.PartitionConcurrency
+	kafkaConfig.Consumer.DeadLetterTopic = cfg.Kafka.Consumer.DeadLetterTopic
+	kafkaConfig.Consumer.MaxProcessAttempts = cfg.Kafka.Consumer.MaxProcessAttempts
+	kafkaConfig.Consumer.LagReportInterval = cfg.Kafka.Consumer.LagReportInterval
+	kafkaConfig.Consumer.EnableAutoCommit = cfg.Kafka.Consumer.EnableAutoCommit
+	// 多实例热备：共享去重记录，分区交接后新的所有者不会重复计数
+	if processingMode == kafka.ProcessingModeEffectivelyOnce && cfg.Kafka.Consumer.DedupeStore == "redis" {
+		processingConfig.Deduper = kafka.NewRedisDeduper(redisClient, processingConfig.DedupeTTL, log)
+	}
+	log.Info("Kafka event processing mode", zap.String("mode", string(processingMode)))
 
 	kafkaManager, err := kafka.NewKafkaManager(kafkaConfig, log)
 	if err != nil {
-		log.Fatal("Failed to initialize Kafka manager", zap.Error(err))
+		log.Error("Failed to initialize Kafka manager", zap.Error(err))
+		return err
 	}
 	defer kafkaManager.Close()
+	shutdownReporter.SetConsumer(kafkaManager.GetConsumer())
 
 	log.Info("✅ Kafka manager initialized successfully",
-		zap.String("mode", string(kafkaManager.GetMode())))
+		zap.String("mode", string(kafkaManager.GetMode())),
+		zap.Bool("degraded", kafkaManager.IsDegraded()))
 
 	// 🌐 初始化Consul客户端并注册服务
 	consulConfig := &consul.Config{
 		Address: "localhost:8500",
 		Scheme:  "http",
+		Timeout: cfg.Discovery.Consul.Timeout,
 	}
 
 	consulClient, err := consul.NewClient(consulConfig, log)
 	if err != nil {
-		log.Fatal("Failed to create consul client", zap.Error(err))
+		log.Error("Failed to create consul client", zap.Error(err))
+		return err
 	}
 	defer consulClient.Close()
 
-	// 注册Analytics服务到Consul
+	// 注册Analytics服务到Consul，多实例部署时通过ANALYTICS_INSTANCE_ID区分
+	instanceID := os.Getenv("ANALYTICS_INSTANCE_ID")
+	if instanceID == "" {
+		instanceID = "analytics-1"
+	}
 	serviceConfig := &consul.ServiceConfig{
-		ID:      "analytics-1",
+		ID:      instanceID,
 		Name:    "high-go-press-analytics",
 		Tags:    []string{"analytics", "grpc", "microservice", "v2.0"},
 		Address: "localhost",
@@ -176,14 +253,15 @@ func main() {
 	}
 
 	if err := consulClient.RegisterService(serviceConfig); err != nil {
-		log.Fatal("Failed to register service to Consul", zap.Error(err))
+		log.Error("Failed to register service to Consul", zap.Error(err))
+		return err
 	}
 
 	log.Info("✅ Analytics service registered to Consul successfully")
 
 	// 确保在退出时注销服务
 	defer func() {
-		if err := consulClient.DeregisterService("analytics-1"); err != nil {
+		if err := consulClient.DeregisterService(instanceID); err != nil {
 			log.Error("Failed to deregister service from Consul", zap.Error(err))
 		} else {
 			log.Info("Analytics service deregistered from Consul")
@@ -193,11 +271,29 @@ func main() {
 	// 订阅counter-events主题
 	kafkaConsumer := kafkaManager.GetConsumer()
 	if err := kafkaConsumer.Subscribe([]string{"counter-events"}); err != nil {
-		log.Fatal("Failed to subscribe to Kafka topics", zap.Error(err))
+		log.Error("Failed to subscribe to Kafka topics", zap.Error(err))
+		return err
 	}
 
+	// 定期上报消费延迟
+	if monitor, ok := kafkaConsumer.(kafka.LagMonitor); ok {
+		monitor.SetLagRecorder(metricsManager)
+	}
+
+	// 创建事件聚合策略，决定事件增量如何写入统计数据
+	aggregationStrategy, err := aggregation.NewStrategy(&aggregation.Config{
+		Strategy:      cfg.Analytics.Aggregation.Strategy,
+		Window:        cfg.Analytics.Aggregation.Window,
+		RatePerSecond: cfg.Analytics.Aggregation.RatePerSecond,
+	}, analyticsDAO.UpdateCounterStats, log)
+	if err != nil {
+		log.Error("Invalid analytics aggregation strategy", zap.Error(err))
+		return err
+	}
+	log.Info("Analytics aggregation strategy", zap.String("strategy", aggregationStrategy.Name()))
+
 	// 创建计数器事件处理器，添加业务指标记录
-	eventHandler := kafka.NewCounterEventHandler(
+	eventHandler := kafka.NewCounterEventHandlerWithConfig(
 		func(ctx context.Context, event *kafka.CounterEvent) error {
 			// 记录业务指标
 			businessWrapper := middleware.NewBusinessMetricsWrapper(metricsManager, "analytics", log)
@@ -211,7 +307,7 @@ func main() {
 					zap.Int64("delta", event.Delta),
 					zap.Int64("new_value", event.NewValue))
 
-				err := analyticsDAO.UpdateCounterStats(ctx, event.ResourceID, event.CounterType, event.Delta)
+				err := aggregationStrategy.Apply(ctx, event)
 
 				// 更新业务指标
 				if err == nil {
@@ -222,6 +318,7 @@ func main() {
 				return err
 			})
 		},
+		processingConfig,
 		log,
 	)
 
@@ -229,9 +326,20 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 定期刷新聚合策略中缓存的增量，消费者停止后执行最后一次刷新
+	flushDone := make(chan struct{})
+	go func() {
+		defer close(flushDone)
+		aggregation.RunFlushLoop(ctx, aggregationStrategy, flushInterval(cfg.Analytics.Aggregation), log)
+	}()
+
+	// 统一包装消息处理：指标、日志、panic恢复
+	messageHandler := kafka.ChainHandler(eventHandler.HandleMessage,
+		kafka.DefaultHandlerMiddlewares(metricsManager, "analytics", log)...)
+
 	go func() {
 		log.Info("Starting Kafka consumer for Analytics...")
-		if err := kafkaConsumer.ConsumeMessages(ctx, eventHandler.HandleMessage); err != nil {
+		if err := kafkaConsumer.ConsumeMessages(ctx, messageHandler); err != nil {
 			if err != context.Canceled {
 				log.Error("Kafka consumer error", zap.Error(err))
 			}
@@ -242,12 +350,75 @@ func main() {
 	time.Sleep(100 * time.Millisecond)
 
 	// 创建Analytics gRPC服务器
-	analyticsServer := server.NewAnalyticsServer(analyticsDAO, kafkaConsumer, log)
+	preload := cfg.Analytics.Preload
+	analyticsServer := server.NewAnalyticsServerWithConfig(analyticsDAO, kafkaConsumer, &server.Config{
+		PreloadCounterTypes: preload.CounterTypes,
+		PreloadTimeRanges:   preload.TimeRanges,
+		PreloadLimit:        int32(preload.Limit),
+		RefreshInterval:     preload.RefreshInterval,
+	}, log)
+
+	// 预热排行榜缓存，避免重启后的首批查询全部未命中
+	preloadCtx, cancelPreload := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := analyticsServer.Preload(preloadCtx); err != nil {
+		log.Warn("Analytics cache preload incomplete", zap.Error(err))
+	} else {
+		log.Info("Analytics cache preloaded", zap.Strings("counter_types", preload.CounterTypes))
+	}
+	cancelPreload()
 
 	// 创建gRPC服务器，添加指标拦截器
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(middleware.GRPCMetricsUnaryInterceptor(metricsManager, "analytics")),
-	)
+	metadataLimit := &middleware.MetadataLimitConfig{
+		MaxSize:         cfg.Analytics.GRPC.Metadata.MaxSize,
+		DisallowedKeys:  cfg.Analytics.GRPC.Metadata.DisallowedKeys,
+		StripDisallowed: cfg.Analytics.GRPC.Metadata.StripDisallowed,
+	}
+	responseSize := &middleware.ResponseSizeConfig{
+		MaxSize: cfg.Analytics.GRPC.MaxResponseSize,
+	}
+	keepaliveConfig := &middleware.KeepaliveConfig{
+		Time:                cfg.Analytics.GRPC.KeepAlive.Time,
+		Timeout:             cfg.Analytics.GRPC.KeepAlive.Timeout,
+		MinTime:             cfg.Analytics.GRPC.KeepAlive.MinTime,
+		PermitWithoutStream: cfg.Analytics.GRPC.KeepAlive.PermitWithoutStream,
+	}
+	unaryInterceptors := []middleware.OrderedUnaryInterceptor{
+		middleware.ClientInfoUnary(log),
+		middleware.MetadataLimitUnary(metadataLimit),
+		middleware.GRPCMetricsUnary(metricsManager, "analytics"),
+		middleware.ResponseSizeUnary(metricsManager, "analytics", responseSize),
+	}
+	streamInterceptors := []middleware.OrderedStreamInterceptor{
+		middleware.ClientInfoStream(log),
+		middleware.MetadataLimitStream(metadataLimit),
+		middleware.ResponseSizeStream(metricsManager, "analytics", responseSize),
+	}
+
+	// 认证：与Gateway共用认证提供者，认证失败计入请求指标
+	if cfg.Auth.Enabled {
+		authenticator, err := auth.NewAuthenticator(&cfg.Auth)
+		if err != nil {
+			log.Error("Failed to create authenticator", zap.Error(err))
+			return err
+		}
+		unaryInterceptors = append(unaryInterceptors, middleware.AuthUnary(authenticator))
+		streamInterceptors = append(streamInterceptors, middleware.AuthStream(authenticator))
+		log.Info("✅ gRPC authentication enabled", zap.String("provider", cfg.Auth.Provider))
+	}
+
+	// 拦截器顺序不符合阶段约定时启动失败
+	unaryChain, err := middleware.ChainUnaryInterceptors(unaryInterceptors...)
+	if err != nil {
+		log.Error("Invalid unary interceptor chain", zap.Error(err))
+		return err
+	}
+	streamChain, err := middleware.ChainStreamInterceptors(streamInterceptors...)
+	if err != nil {
+		log.Error("Invalid stream interceptor chain", zap.Error(err))
+		return err
+	}
+	serverOpts := append(middleware.KeepaliveServerOptions(keepaliveConfig), unaryChain, streamChain)
+	grpcServer := grpc.NewServer(serverOpts...)
 
 	// 注册服务
 	pb.RegisterAnalyticsServiceServer(grpcServer, analyticsServer)
@@ -258,39 +429,48 @@ func main() {
 	// 监听gRPC端口
 	grpcLis, err := net.Listen("tcp", ":9002")
 	if err != nil {
-		log.Fatal("Failed to listen on gRPC port", zap.Error(err))
+		log.Error("Failed to listen on gRPC port", zap.Error(err))
+		return err
+	}
+
+	// 统一组件统计端点，与Gateway一致只在非release模式开启
+	var statsCollector *stats.Collector
+	if cfg.Analytics.Server.Mode != "release" {
+		statsCollector = stats.NewCollector()
+		if err := statsCollector.RegisterAll(map[string]stats.StatsProvider{
+			"kafka_consumer": stats.ProviderFunc(func() map[string]interface{} {
+				return map[string]interface{}{"status": kafka.GetConsumerStatus(kafkaConsumer)}
+			}),
+		}); err != nil {
+			log.Error("Failed to register stats providers", zap.Error(err))
+			return err
+		}
 	}
 
 	// 设置HTTP监控服务器
-	httpServer := setupHTTPMonitoringServer(metricsManager, log)
+	httpMetricsConfig := &middleware.HTTPMetricsConfig{ExcludePaths: cfg.Monitoring.Metrics.HTTP.ExcludePaths}
+	httpServer := setupHTTPMonitoringServer(metricsManager, httpMetricsConfig, kafkaConsumer, statsCollector, log)
 
-	// 启动gRPC服务器
-	go func() {
-		log.Info("Analytics gRPC server starting",
-			zap.String("address", grpcLis.Addr().String()))
-		if err := grpcServer.Serve(grpcLis); err != nil {
-			log.Error("gRPC server failed", zap.Error(err))
-		}
-	}()
+	// 启动gRPC服务器和HTTP监控服务器，启动或运行失败时通知主goroutine
+	servers := shutdown.NewServerGroup(log)
 
-	// 启动HTTP监控服务器
-	go func() {
-		log.Info("Analytics HTTP monitoring server starting",
-			zap.String("address", httpServer.Addr))
+	log.Info("Analytics gRPC server starting",
+		zap.String("address", grpcLis.Addr().String()))
+	servers.Go("grpc", func() error { return grpcServer.Serve(grpcLis) })
 
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Error("HTTP monitoring server failed", zap.Error(err))
-		}
-	}()
+	log.Info("Analytics HTTP monitoring server starting",
+		zap.String("address", httpServer.Addr))
+	servers.Go("http-monitoring", httpServer.ListenAndServe)
 
 	// 设置服务健康状态
 	metricsManager.SetServiceHealth("analytics", "main", true)
 	metricsManager.SetServiceHealth("analytics", "kafka", true)
 
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// 等待中断信号或服务失败
+	serveErr := servers.Wait(syscall.SIGINT, syscall.SIGTERM)
+	if serveErr != nil {
+		metricsManager.SetServiceHealth("analytics", "main", false)
+	}
 
 	log.Info("Shutting down Analytics service...")
 
@@ -303,11 +483,36 @@ func main() {
 		log.Error("HTTP server shutdown error", zap.Error(err))
 	}
 
-	// 停止Kafka消费者
+	// 排空Kafka消费者：停止拉取新消息，等待在途事件处理完成并提交offset
+	drainTimeout := cfg.Kafka.Consumer.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = 10 * time.Second
+	}
+	if drainer, ok := kafkaConsumer.(kafka.Drainer); ok {
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), drainTimeout)
+		if err := drainer.Drain(drainCtx); err != nil {
+			log.Warn("Kafka consumer drain incomplete, aborting in-flight events", zap.Error(err))
+		}
+		drainCancel()
+	}
+
+	// 停止Kafka消费者，并等待聚合结果写入
 	cancel()
+	<-flushDone
 
 	// 关闭gRPC服务器
 	grpcServer.GracefulStop()
 
+	// 停止系统指标收集
+	if err := metricsManager.Shutdown(shutdownCtx); err != nil {
+		log.Error("Failed to shutdown metrics manager", zap.Error(err))
+	}
+
+	shutdownReporter.Report()
+	if serveErr != nil {
+		log.Error("Analytics service stopped after server failure", zap.Error(serveErr))
+		return serveErr
+	}
 	log.Info("Analytics service stopped gracefully")
+	return nil
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"high-go-press/pkg/config"
+)
+
+// encryptionOptions 按-encryption-key-file和-encrypt-fields生成配置中心的加密选项
+// 密钥文件内容与ConfigEncryptionKeyEnv格式相同，为base64编码的32字节密钥；未指定时仍从环境变量读取
+func encryptionOptions(keyFile, fields string) ([]config.ConfigCenterOption, error) {
+	var opts []config.ConfigCenterOption
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encryption key file: %w", err)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("encryption key file must contain a base64 encoded key: %w", err)
+		}
+		opts = append(opts, config.WithEncryptionKey(key))
+	}
+
+	var paths []string
+	for _, path := range strings.Split(fields, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 {
+		opts = append(opts, config.WithEncryptedFields(paths...))
+	}
+	return opts, nil
+}
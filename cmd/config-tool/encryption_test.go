@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/config"
+
+	"go.uber.org/zap"
+)
+
+func writeKeyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.key")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	return path
+}
+
+func TestEncryptionOptionsConfigureConfigCenter(t *testing.T) {
+	t.Setenv(config.ConfigEncryptionKeyEnv, "")
+	// 只需响应创建配置中心时的leader检查
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`"127.0.0.1:8300"`))
+	}))
+	t.Cleanup(server.Close)
+	consulConfig := &config.ConsulConfig{Address: server.URL, Timeout: time.Second}
+	keyFile := writeKeyFile(t, base64.StdEncoding.EncodeToString(make([]byte, 32))+"\n")
+
+	opts, err := encryptionOptions(keyFile, "kafka.security.sasl.username, kafka.security.sasl.mechanism")
+	if err != nil {
+		t.Fatalf("encryptionOptions failed: %v", err)
+	}
+	if _, err := config.NewConsulConfigCenterWithConfig(consulConfig, zap.NewNop(), opts...); err != nil {
+		t.Errorf("Expected config center with key file and fields, got %v", err)
+	}
+
+	// 字段路径会传给配置中心校验
+	opts, err = encryptionOptions(keyFile, "redis.no_such_field")
+	if err != nil {
+		t.Fatalf("encryptionOptions failed: %v", err)
+	}
+	if _, err := config.NewConsulConfigCenterWithConfig(consulConfig, zap.NewNop(), opts...); err == nil {
+		t.Error("Expected error for an unknown field path")
+	}
+
+	// 只指定字段而没有密钥时报错，而不是静默以明文写入
+	opts, err = encryptionOptions("", "redis.address")
+	if err != nil {
+		t.Fatalf("encryptionOptions failed: %v", err)
+	}
+	if _, err := config.NewConsulConfigCenterWithConfig(consulConfig, zap.NewNop(), opts...); !errors.Is(err, config.ErrEncryptionKeyMissing) {
+		t.Errorf("Expected ErrEncryptionKeyMissing for fields without a key, got %v", err)
+	}
+}
+
+func TestEncryptionOptionsRejectsBadKeyFile(t *testing.T) {
+	if _, err := encryptionOptions(filepath.Join(t.TempDir(), "missing.key"), ""); err == nil {
+		t.Error("Expected error for a missing key file")
+	}
+	if _, err := encryptionOptions(writeKeyFile(t, "not base64!"), ""); err == nil {
+		t.Error("Expected error for a key file that is not base64")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"high-go-press/pkg/config"
+)
+
+// printConfigList 以表格输出配置列表，environment非空时只输出该环境的配置
+func printConfigList(out io.Writer, keys []config.ConfigKey, environment string) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENVIRONMENT\tSERVICE\tMODIFY INDEX")
+
+	count := 0
+	for _, key := range keys {
+		if environment != "" && key.Environment != environment {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", key.Environment, key.Service, key.ModifyIndex)
+		count++
+	}
+	tw.Flush()
+
+	if environment != "" {
+		fmt.Fprintf(out, "%d config(s) in environment %s\n", count, environment)
+	} else {
+		fmt.Fprintf(out, "%d config(s)\n", count)
+	}
+}
+
+// envFlagSet 命令行是否显式指定了-env，list未指定时列出全部环境
+func envFlagSet() bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "env" {
+			set = true
+		}
+	})
+	return set
+}
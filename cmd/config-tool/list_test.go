@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"high-go-press/pkg/config"
+)
+
+func TestPrintConfigListFiltersByEnvironment(t *testing.T) {
+	keys := []config.ConfigKey{
+		{Service: "counter", Environment: "dev", ModifyIndex: 12},
+		{Service: "counter", Environment: "prod", ModifyIndex: 40},
+		{Service: "gateway", Environment: "prod", ModifyIndex: 41},
+	}
+
+	var out bytes.Buffer
+	printConfigList(&out, keys, "")
+	if got := out.String(); !strings.Contains(got, "dev") || !strings.Contains(got, "gateway") || !strings.Contains(got, "3 config(s)") {
+		t.Errorf("Expected all configs to be listed, got:\n%s", got)
+	}
+
+	// 指定环境时只列出该环境
+	out.Reset()
+	printConfigList(&out, keys, "prod")
+	got := out.String()
+	if strings.Contains(got, "dev") || strings.Count(got, "prod") != 3 || !strings.Contains(got, "41") {
+		t.Errorf("Expected only prod configs, got:\n%s", got)
+	}
+}
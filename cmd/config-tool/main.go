@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"time"
 
 	"high-go-press/pkg/config"
+	resilience "high-go-press/pkg/grpc"
 	"high-go-press/pkg/logger"
 
 	"go.uber.org/zap"
@@ -16,17 +18,28 @@ import (
 
 var (
 	consulAddr  = flag.String("consul", "localhost:8500", "Consul address")
+	retries     = flag.Int("retries", 3, "Retries for transient Consul errors on get/put")
+	timeout     = flag.Duration("timeout", 10*time.Second, "Timeout for each Consul request")
 	service     = flag.String("service", "", "Service name")
-	environment = flag.String("env", "dev", "Environment")
+	environment = flag.String("env", "dev", "Environment (for list, only filters when set explicitly)")
 	configFile  = flag.String("config", "", "Config file path")
-	action      = flag.String("action", "get", "Action: get, put, delete, list, history, watch")
+	action      = flag.String("action", "get", "Action: get, put, delete, list, history, rollback, prune, watch, validate")
+	keep        = flag.Int("keep", 20, "Config history versions to keep on put and prune")
 	version     = flag.String("version", "", "Config version for rollback")
+	keyFile     = flag.String("encryption-key-file", "", "File with the base64 encoded 32-byte config encryption key (default from "+config.ConfigEncryptionKeyEnv+")")
+	encFields   = flag.String("encrypt-fields", "", "Comma separated extra field paths to encrypt, e.g. kafka.security.sasl.username")
 )
 
 func main() {
 	flag.Parse()
 
-	if *service == "" {
+	// 校验本地配置文件，不需要服务名和Consul
+	if *action == "validate" {
+		os.Exit(runValidate(*configFile, os.Stdout, zap.NewNop()))
+	}
+
+	// list列出全部服务，不需要服务名
+	if *service == "" && *action != "list" {
 		fmt.Println("Service name is required")
 		flag.Usage()
 		os.Exit(1)
@@ -39,11 +52,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	// 创建配置中心
-	configCenter, err := config.NewConsulConfigCenter(*consulAddr, logger)
+	// 创建配置中心，put加密敏感字段、get解密都需要与服务一致的密钥和字段
+	encryption, err := encryptionOptions(*keyFile, *encFields)
+	if err != nil {
+		logger.Fatal("Invalid encryption options", zap.Error(err))
+	}
+	configCenter, err := config.NewConsulConfigCenterWithConfig(&config.ConsulConfig{
+		Address:      *consulAddr,
+		Timeout:      *timeout,
+		HistoryLimit: *keep,
+	}, logger, encryption...)
 	if err != nil {
 		logger.Fatal("Failed to create config center", zap.Error(err))
 	}
+	// 部署期间Consul短暂不可用时重试读写
+	configCenter.SetRetry(&config.ConsulRetryConfig{
+		MaxAttempts: *retries + 1,
+		NewBackoff: func() config.Backoff {
+			backoff := resilience.NewExponentialBackoff()
+			backoff.InitialInterval = 200 * time.Millisecond
+			backoff.MaxInterval = 2 * time.Second
+			backoff.MaxElapsedTime = 20 * time.Second
+			return backoff
+		},
+	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -59,6 +91,10 @@ func main() {
 		handleList(ctx, configCenter, logger)
 	case "history":
 		handleHistory(ctx, configCenter, logger)
+	case "rollback":
+		handleRollback(ctx, configCenter, logger)
+	case "prune":
+		handlePrune(ctx, configCenter, logger)
 	case "watch":
 		handleWatch(ctx, configCenter, logger)
 	default:
@@ -113,8 +149,16 @@ func handleDelete(ctx context.Context, configCenter *config.ConsulConfigCenter,
 }
 
 func handleList(ctx context.Context, configCenter *config.ConsulConfigCenter, logger *zap.Logger) {
-	// 这里实现列出所有服务的配置
-	fmt.Printf("Listing configs is not implemented yet\n")
+	keys, err := configCenter.ListConfigs(ctx)
+	if err != nil {
+		logger.Fatal("Failed to list configs", zap.Error(err))
+	}
+
+	filter := ""
+	if envFlagSet() {
+		filter = *environment
+	}
+	printConfigList(os.Stdout, keys, filter)
 }
 
 func handleHistory(ctx context.Context, configCenter *config.ConsulConfigCenter, logger *zap.Logger) {
@@ -130,6 +174,33 @@ func handleHistory(ctx context.Context, configCenter *config.ConsulConfigCenter,
 	}
 }
 
+func handleRollback(ctx context.Context, configCenter *config.ConsulConfigCenter, logger *zap.Logger) {
+	if *version == "" {
+		logger.Fatal("Config version is required for rollback action, see -action history")
+	}
+
+	err := configCenter.RollbackConfig(ctx, *service, *environment, *version)
+	if errors.Is(err, config.ErrConfigVersionNotFound) {
+		logger.Fatal("Config version not found, see -action history for available versions",
+			zap.String("version", *version), zap.Error(err))
+	}
+	if err != nil {
+		logger.Fatal("Failed to rollback config", zap.Error(err))
+	}
+
+	fmt.Printf("Config rolled back to version %s for service %s in environment %s\n", *version, *service, *environment)
+}
+
+func handlePrune(ctx context.Context, configCenter *config.ConsulConfigCenter, logger *zap.Logger) {
+	deleted, err := configCenter.PruneConfigHistory(ctx, *service, *environment, *keep)
+	if err != nil {
+		logger.Fatal("Failed to prune config history", zap.Int("deleted", deleted), zap.Error(err))
+	}
+
+	fmt.Printf("Pruned %d config versions for service %s in environment %s, kept latest %d\n",
+		deleted, *service, *environment, *keep)
+}
+
 func handleWatch(ctx context.Context, configCenter *config.ConsulConfigCenter, logger *zap.Logger) {
 	fmt.Printf("Watching config changes for service %s in environment %s...\n", *service, *environment)
 
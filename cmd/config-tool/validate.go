@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"high-go-press/pkg/config"
+
+	"go.uber.org/zap"
+)
+
+// runValidate 校验配置文件并输出结果，返回进程退出码：通过为0，失败为1
+func runValidate(configPath string, out io.Writer, logger *zap.Logger) int {
+	if configPath == "" {
+		fmt.Fprintln(out, "FAIL: -config is required for validate action")
+		return 1
+	}
+
+	_, err := config.NewManager(logger).ValidateFile(configPath)
+	if err == nil {
+		fmt.Fprintf(out, "PASS: %s is valid\n", configPath)
+		return 0
+	}
+
+	var validationErrs config.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		// 文件无法读取或解析
+		fmt.Fprintf(out, "FAIL: %s could not be loaded: %v\n", configPath, err)
+		return 1
+	}
+
+	fmt.Fprintf(out, "FAIL: %s has %d error(s)\n", configPath, len(validationErrs))
+	for _, fe := range validationErrs {
+		fmt.Fprintf(out, "  - %s\n", fe)
+	}
+	return 1
+}
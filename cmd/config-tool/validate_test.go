@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const validConfigYAML = `
+environment: "test"
+redis:
+  address: "localhost:6379"
+kafka:
+  mode: "mock"
+`
+
+// badConfigYAML 日志级别非法，且Gateway与Counter端口冲突
+const badConfigYAML = `
+environment: "test"
+gateway:
+  server:
+    port: 9001
+redis:
+  address: "localhost:6379"
+kafka:
+  mode: "mock"
+log:
+  level: "verbose"
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestValidateReportsFailingFields(t *testing.T) {
+	var out bytes.Buffer
+	if code := runValidate(writeConfig(t, badConfigYAML), &out, zap.NewNop()); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+
+	report := out.String()
+	if !strings.Contains(report, "FAIL:") || !strings.Contains(report, "2 error(s)") {
+		t.Errorf("Expected failure summary with 2 errors, got:\n%s", report)
+	}
+	// 结构体标签校验指出具体字段
+	if !strings.Contains(report, `log.level: must be one of [debug info warn error], got "verbose"`) {
+		t.Errorf("Expected log.level error, got:\n%s", report)
+	}
+	// Manager语义校验
+	if !strings.Contains(report, "port conflict: 9001 is used by both gateway and counter") {
+		t.Errorf("Expected port conflict error, got:\n%s", report)
+	}
+}
+
+func TestValidatePassesValidConfig(t *testing.T) {
+	var out bytes.Buffer
+	path := writeConfig(t, validConfigYAML)
+	if code := runValidate(path, &out, zap.NewNop()); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d:\n%s", code, out.String())
+	}
+	if got := out.String(); got != "PASS: "+path+" is valid\n" {
+		t.Errorf("Unexpected output: %q", got)
+	}
+}
+
+func TestValidateReportsUnreadableFile(t *testing.T) {
+	var out bytes.Buffer
+	if code := runValidate(filepath.Join(t.TempDir(), "missing.yaml"), &out, zap.NewNop()); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(out.String(), "could not be loaded") {
+		t.Errorf("Expected load failure, got:\n%s", out.String())
+	}
+}
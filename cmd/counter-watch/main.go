@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	resilience "high-go-press/pkg/grpc"
+	"high-go-press/pkg/logger"
+	"high-go-press/pkg/middleware"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	addr        = flag.String("addr", "localhost:9001", "Counter service gRPC address")
+	resourceID  = flag.String("resource", "", "Resource ID to watch")
+	counterType = flag.String("type", "", "Counter type to watch")
+	tenant      = flag.String("tenant", "", "Tenant ID (multi-tenant deployments)")
+	interval    = flag.Duration("interval", time.Second, "How often the server checks for changes")
+	maxRetries  = flag.Int("max-retries", 10, "Reconnect attempts before giving up")
+	backoff     = flag.Duration("backoff", 500*time.Millisecond, "Initial reconnect backoff")
+)
+
+func main() {
+	flag.Parse()
+
+	if *resourceID == "" || *counterType == "" {
+		fmt.Println("Both -resource and -type are required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// 初始化日志
+	logger, err := logger.NewLogger("info", "console")
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		logger.Fatal("Failed to create gRPC client", zap.String("addr", *addr), zap.Error(err))
+	}
+	defer conn.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	if *tenant != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, middleware.TenantMetadataKey, *tenant)
+	}
+
+	retry := resilience.DefaultRetryConfig()
+	retry.MaxAttempts = *maxRetries
+	retry.InitialBackoff = *backoff
+	retry.MaxBackoff = 10 * time.Second
+
+	w := newWatcher(counter.NewCounterServiceClient(conn), &counter.WatchCounterRequest{
+		ResourceId:  *resourceID,
+		CounterType: *counterType,
+		IntervalMs:  int32(*interval / time.Millisecond),
+	}, retry, os.Stdout, logger)
+
+	if err := w.run(ctx); err != nil {
+		logger.Fatal("Watch failed", zap.Error(err))
+	}
+}
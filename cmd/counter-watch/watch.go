@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	resilience "high-go-press/pkg/grpc"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errStreamClosed 服务端正常结束流，视为可重连的断开
+var errStreamClosed = status.Error(codes.Unavailable, "watch stream closed by server")
+
+// watcher 订阅计数器更新并打印，连接断开时按重试策略重连
+type watcher struct {
+	client    counter.CounterServiceClient
+	req       *counter.WatchCounterRequest
+	retryer   *resilience.Retryer
+	converter *resilience.ErrorConverter
+	out       io.Writer
+	logger    *zap.Logger
+
+	last    int64
+	hasLast bool
+}
+
+// newWatcher 创建计数器监听器
+func newWatcher(client counter.CounterServiceClient, req *counter.WatchCounterRequest, retry *resilience.RetryConfig, out io.Writer, logger *zap.Logger) *watcher {
+	return &watcher{
+		client:    client,
+		req:       req,
+		retryer:   resilience.NewRetryer(retry, logger),
+		converter: resilience.NewErrorConverter(logger),
+		out:       out,
+		logger:    logger,
+	}
+}
+
+// run 持续接收更新直到ctx取消；重连次数耗尽或遇到不可重试的错误时返回错误
+func (w *watcher) run(ctx context.Context) error {
+	for {
+		stream, err := w.connect(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = w.receive(stream)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !w.converter.IsRetryableError(err) {
+			return err
+		}
+		w.logger.Warn("Watch stream disconnected, reconnecting", zap.Error(err))
+	}
+}
+
+// connect 建立监听流并收到首个更新，失败时按重试策略退避重连
+func (w *watcher) connect(ctx context.Context) (counter.CounterService_WatchCounterClient, error) {
+	var stream counter.CounterService_WatchCounterClient
+	err := w.retryer.Execute(ctx, func(context.Context) error {
+		// 流的生命周期跟随ctx，而不是单次重试的超时
+		s, err := w.client.WatchCounter(ctx, w.req)
+		if err != nil {
+			return err
+		}
+		update, err := recvUpdate(s)
+		if err != nil {
+			return err
+		}
+		w.print(update)
+		stream = s
+		return nil
+	})
+	return stream, err
+}
+
+// receive 接收更新直到流出错
+func (w *watcher) receive(stream counter.CounterService_WatchCounterClient) error {
+	for {
+		update, err := recvUpdate(stream)
+		if err != nil {
+			return err
+		}
+		w.print(update)
+	}
+}
+
+// recvUpdate 接收一条更新，服务端结束流时返回可重连的错误
+func recvUpdate(stream counter.CounterService_WatchCounterClient) (*counter.CounterUpdate, error) {
+	update, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil, errStreamClosed
+	}
+	return update, err
+}
+
+// print 打印一条更新，重连后值未变化时不重复打印
+func (w *watcher) print(update *counter.CounterUpdate) {
+	if w.hasLast && update.Value == w.last {
+		return
+	}
+
+	at := time.UnixMilli(update.TimestampMs).Format("15:04:05.000")
+	if w.hasLast {
+		fmt.Fprintf(w.out, "%s %s/%s = %d (%+d)\n", at, update.ResourceId, update.CounterType, update.Value, update.Value-w.last)
+	} else {
+		fmt.Fprintf(w.out, "%s %s/%s = %d\n", at, update.ResourceId, update.CounterType, update.Value)
+	}
+	w.last, w.hasLast = update.Value, true
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/testutil"
+	resilience "high-go-press/pkg/grpc"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// syncBuffer 并发安全的输出缓冲
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitForOutput 等待输出包含want
+func waitForOutput(t *testing.T, out *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %q, got:\n%s", want, out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// flakyClient 第一次建立的监听流在收到首个更新后断开
+type flakyClient struct {
+	counter.CounterServiceClient
+	connects int32
+}
+
+func (c *flakyClient) WatchCounter(ctx context.Context, req *counter.WatchCounterRequest, opts ...grpc.CallOption) (counter.CounterService_WatchCounterClient, error) {
+	stream, err := c.CounterServiceClient.WatchCounter(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if atomic.AddInt32(&c.connects, 1) == 1 {
+		return &brokenStream{CounterService_WatchCounterClient: stream}, nil
+	}
+	return stream, nil
+}
+
+// brokenStream 收到一个更新后返回Unavailable
+type brokenStream struct {
+	counter.CounterService_WatchCounterClient
+	received int
+}
+
+func (s *brokenStream) Recv() (*counter.CounterUpdate, error) {
+	if s.received >= 1 {
+		return nil, status.Error(codes.Unavailable, "connection reset")
+	}
+	s.received++
+	return s.CounterService_WatchCounterClient.Recv()
+}
+
+func testRetryConfig() *resilience.RetryConfig {
+	retry := resilience.DefaultRetryConfig()
+	retry.InitialBackoff = 10 * time.Millisecond
+	retry.MaxBackoff = 50 * time.Millisecond
+	return retry
+}
+
+func startWatcher(t *testing.T, client counter.CounterServiceClient, out *syncBuffer) (cancel func() error) {
+	t.Helper()
+
+	ctx, stop := context.WithCancel(context.Background())
+	w := newWatcher(client, &counter.WatchCounterRequest{ResourceId: "article_1", CounterType: "like", IntervalMs: 100},
+		testRetryConfig(), out, zap.NewNop())
+
+	done := make(chan error, 1)
+	go func() { done <- w.run(ctx) }()
+
+	return func() error {
+		stop()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(3 * time.Second):
+			t.Fatal("Watcher did not stop after cancel")
+			return nil
+		}
+	}
+}
+
+func TestWatcherPrintsStreamedUpdates(t *testing.T) {
+	h := testutil.StartCounterServer(t, nil, nil)
+	out := &syncBuffer{}
+	stop := startWatcher(t, h.Client, out)
+
+	// 连接后立即打印当前值
+	waitForOutput(t, out, "article_1/like = 0\n")
+
+	ctx := context.Background()
+	if _, err := h.Client.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 3}); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	waitForOutput(t, out, "article_1/like = 3 (+3)\n")
+
+	if _, err := h.Client.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 2}); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	waitForOutput(t, out, "article_1/like = 5 (+2)\n")
+
+	if err := stop(); err != nil {
+		t.Errorf("Expected nil on cancel, got %v", err)
+	}
+}
+
+func TestWatcherReconnectsOnDisconnect(t *testing.T) {
+	h := testutil.StartCounterServer(t, nil, nil)
+	client := &flakyClient{CounterServiceClient: h.Client}
+	out := &syncBuffer{}
+	stop := startWatcher(t, client, out)
+
+	waitForOutput(t, out, "article_1/like = 0\n")
+
+	// 第一条流断开后重连，继续收到更新
+	if _, err := h.Client.IncrementCounter(context.Background(), &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 4}); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	waitForOutput(t, out, "article_1/like = 4 (+4)\n")
+
+	if got := atomic.LoadInt32(&client.connects); got < 2 {
+		t.Errorf("Expected watcher to reconnect, got %d connects", got)
+	}
+	if strings.Count(out.String(), "= 0\n") != 1 {
+		t.Errorf("Expected unchanged value not reprinted after reconnect, got:\n%s", out.String())
+	}
+
+	if err := stop(); err != nil {
+		t.Errorf("Expected nil on cancel, got %v", err)
+	}
+}
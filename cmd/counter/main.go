@@ -2,353 +2,62 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
 	"syscall"
 	"time"
 
-	"high-go-press/api/proto/common"
 	"high-go-press/api/proto/counter"
+	"high-go-press/internal/biz"
+	"high-go-press/internal/counter/server"
 	"high-go-press/internal/dao"
+	"high-go-press/pkg/auth"
+	"high-go-press/pkg/config"
 	"high-go-press/pkg/consul"
 	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/logger"
 	"high-go-press/pkg/metrics"
 	"high-go-press/pkg/middleware"
+	"high-go-press/pkg/pool"
+	"high-go-press/pkg/quota"
+	"high-go-press/pkg/redislock"
+	"high-go-press/pkg/shutdown"
+	"high-go-press/pkg/stats"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
 )
 
-// CounterServer 带Redis和Kafka集成的Counter服务实现
-type CounterServer struct {
-	counter.UnimplementedCounterServiceServer
-	logger         *zap.Logger
-	redisDAO       *dao.RedisRepo
-	kafkaManager   *kafka.KafkaManager
-	metricsManager *metrics.MetricsManager
-	eventCounter   int64 // 事件计数器
+// quotaMethods 消耗每日配额的增量写入方法
+var quotaMethods = []string{
+	counter.CounterService_IncrementCounter_FullMethodName,
+	counter.CounterService_BatchIncrementCounters_FullMethodName,
 }
 
-func NewCounterServer(logger *zap.Logger, redisDAO *dao.RedisRepo, kafkaManager *kafka.KafkaManager, metricsManager *metrics.MetricsManager) *CounterServer {
-	return &CounterServer{
-		logger:         logger,
-		redisDAO:       redisDAO,
-		kafkaManager:   kafkaManager,
-		metricsManager: metricsManager,
-		eventCounter:   0,
-	}
+// adminMethods 需要管理员身份的管理接口
+var adminMethods = []string{
+	counter.CounterService_FindCountersAbove_FullMethodName,
+	counter.CounterService_ResetCounter_FullMethodName,
 }
 
-func (s *CounterServer) IncrementCounter(ctx context.Context, req *counter.IncrementRequest) (*counter.IncrementResponse, error) {
-	start := time.Now()
-
-	// 记录gRPC指标
-	defer func() {
-		duration := time.Since(start)
-		s.metricsManager.RecordGRPCRequest("/counter.CounterService/IncrementCounter", "counter", "OK", duration)
-	}()
-
-	if req.ResourceId == "" || req.CounterType == "" {
-		return &counter.IncrementResponse{
-			Status: &common.Status{
-				Success: false,
-				Message: "resource_id and counter_type are required",
-				Code:    int32(codes.InvalidArgument),
-			},
-		}, nil
-	}
-
-	delta := req.Delta
-	if delta == 0 {
-		delta = 1
-	}
-
-	// 🔧 修复: 使用统一的Redis key格式
-	key := fmt.Sprintf("counter:%s:%s", req.ResourceId, req.CounterType)
-
-	// 记录业务指标
-	businessWrapper := middleware.NewBusinessMetricsWrapper(s.metricsManager, "counter", s.logger)
-	var newValue int64
-	var err error
-
-	businessErr := businessWrapper.WrapOperation("increment_counter", func() error {
-		newValue, err = s.redisDAO.IncrementCounter(ctx, key, delta)
-		return err
-	})
-
-	if businessErr != nil {
-		s.logger.Error("Failed to increment counter in Redis",
-			zap.String("key", key),
-			zap.Int64("delta", delta),
-			zap.Error(businessErr))
-
-		return &counter.IncrementResponse{
-			Status: &common.Status{
-				Success: false,
-				Message: "Failed to increment counter",
-				Code:    int32(codes.Internal),
-			},
-		}, nil
-	}
-
-	s.logger.Info("Counter incremented",
-		zap.String("key", key),
-		zap.Int64("delta", delta),
-		zap.Int64("new_value", newValue))
-
-	// 🔥 发送Kafka事件
-	if err := s.sendCounterEvent(ctx, req.ResourceId, req.CounterType, delta, newValue); err != nil {
-		s.logger.Error("Failed to send counter event", zap.Error(err))
-		// 注意：这里我们不返回错误，因为计数器更新已经成功
-		// 只是事件发送失败，可以考虑重试或异步处理
-	}
-
-	// 更新业务指标
-	businessWrapper.SetGauge("current_counter_value", float64(newValue))
-
-	return &counter.IncrementResponse{
-		Status: &common.Status{
-			Success: true,
-			Message: "Counter incremented successfully",
-			Code:    int32(codes.OK),
-		},
-		CurrentValue: newValue,
-		ResourceId:   req.ResourceId,
-		CounterType:  req.CounterType,
-	}, nil
-}
-
-// sendCounterEvent 发送计数器事件到Kafka
-func (s *CounterServer) sendCounterEvent(ctx context.Context, resourceID, counterType string, delta, newValue int64) error {
-	s.eventCounter++
-
-	event := &kafka.CounterEvent{
-		EventID:     fmt.Sprintf("evt_%d_%d", time.Now().Unix(), s.eventCounter),
-		ResourceID:  resourceID,
-		CounterType: counterType,
-		Delta:       delta,
-		NewValue:    newValue,
-		Timestamp:   time.Now(),
-		Source:      "counter-microservice",
-	}
-
-	producer := s.kafkaManager.GetProducer()
-	return producer.SendCounterEvent(ctx, event)
-}
-
-func (s *CounterServer) GetCounter(ctx context.Context, req *counter.GetCounterRequest) (*counter.GetCounterResponse, error) {
-	start := time.Now()
-
-	// 记录gRPC指标
-	defer func() {
-		duration := time.Since(start)
-		s.metricsManager.RecordGRPCRequest("/counter.CounterService/GetCounter", "counter", "OK", duration)
-	}()
-
-	if req.ResourceId == "" || req.CounterType == "" {
-		return &counter.GetCounterResponse{
-			Status: &common.Status{
-				Success: false,
-				Message: "resource_id and counter_type are required",
-				Code:    int32(codes.InvalidArgument),
-			},
-		}, nil
-	}
-
-	// 🔧 修复: 使用统一的Redis key格式
-	key := fmt.Sprintf("counter:%s:%s", req.ResourceId, req.CounterType)
-
-	// 记录数据库指标
-	dbWrapper := middleware.NewDBMetricsWrapper(s.metricsManager, "counter", "redis", s.logger)
-	var value int64
-	var err error
-
-	_, dbErr := dbWrapper.WrapQueryWithResult("get", func() (interface{}, error) {
-		value, err = s.redisDAO.GetCounter(ctx, key)
-		return value, err
-	})
-
-	if dbErr != nil {
-		s.logger.Error("Failed to get counter from Redis",
-			zap.String("key", key),
-			zap.Error(dbErr))
-
-		return &counter.GetCounterResponse{
-			Status: &common.Status{
-				Success: false,
-				Message: "Failed to get counter",
-				Code:    int32(codes.Internal),
-			},
-		}, nil
-	}
-
-	return &counter.GetCounterResponse{
-		Status: &common.Status{
-			Success: true,
-			Message: "Counter retrieved successfully",
-			Code:    int32(codes.OK),
-		},
-		Value:       value,
-		ResourceId:  req.ResourceId,
-		CounterType: req.CounterType,
-		LastUpdated: &common.Timestamp{
-			Seconds: time.Now().Unix(),
-			Nanos:   int32(time.Now().Nanosecond()),
-		},
-	}, nil
-}
-
-func (s *CounterServer) BatchGetCounters(ctx context.Context, req *counter.BatchGetRequest) (*counter.BatchGetResponse, error) {
-	start := time.Now()
-
-	// 记录gRPC指标
-	defer func() {
-		duration := time.Since(start)
-		s.metricsManager.RecordGRPCRequest("/counter.CounterService/BatchGetCounters", "counter", "OK", duration)
-	}()
-
-	results := make([]*counter.GetCounterResponse, 0, len(req.Requests))
-
-	// 🔧 修复: 使用Redis批量获取
-	keys := make([]string, 0, len(req.Requests))
-	keyToReq := make(map[string]*counter.GetCounterRequest)
-
-	for _, r := range req.Requests {
-		if r.ResourceId == "" || r.CounterType == "" {
-			continue
-		}
-
-		key := fmt.Sprintf("counter:%s:%s", r.ResourceId, r.CounterType)
-		keys = append(keys, key)
-		keyToReq[key] = r
-	}
-
-	if len(keys) == 0 {
-		return &counter.BatchGetResponse{
-			Status: &common.Status{
-				Success: true,
-				Message: "Empty batch request",
-				Code:    int32(codes.OK),
-			},
-			Counters: results,
-		}, nil
-	}
-
-	// 批量从Redis获取
-	dbWrapper := middleware.NewDBMetricsWrapper(s.metricsManager, "counter", "redis", s.logger)
-	var values map[string]int64
-	var err error
-
-	_, dbErr := dbWrapper.WrapQueryWithResult("batch_get", func() (interface{}, error) {
-		values, err = s.redisDAO.GetMultiCounters(ctx, keys)
-		return values, err
-	})
-
-	if dbErr != nil {
-		s.logger.Error("Failed to batch get counters from Redis", zap.Error(dbErr))
-		return &counter.BatchGetResponse{
-			Status: &common.Status{
-				Success: false,
-				Message: "Failed to batch get counters",
-				Code:    int32(codes.Internal),
-			},
-		}, nil
-	}
-
-	for _, key := range keys {
-		r := keyToReq[key]
-		if r == nil {
-			continue
-		}
-
-		value := values[key] // Redis会返回0如果key不存在
-
-		results = append(results, &counter.GetCounterResponse{
-			Status: &common.Status{
-				Success: true,
-				Message: "Success",
-				Code:    int32(codes.OK),
-			},
-			Value:       value,
-			ResourceId:  r.ResourceId,
-			CounterType: r.CounterType,
-			LastUpdated: &common.Timestamp{
-				Seconds: time.Now().Unix(),
-				Nanos:   int32(time.Now().Nanosecond()),
-			},
-		})
-	}
-
-	return &counter.BatchGetResponse{
-		Status: &common.Status{
-			Success: true,
-			Message: "Batch get completed",
-			Code:    int32(codes.OK),
-		},
-		Counters: results,
-	}, nil
-}
-
-func (s *CounterServer) HealthCheck(ctx context.Context, req *counter.HealthCheckRequest) (*counter.HealthCheckResponse, error) {
-	// 检查Redis连接
-	_, err := s.redisDAO.GetCounter(ctx, "health_check_test")
-	if err != nil {
-		// 更新健康状态指标
-		s.metricsManager.SetServiceHealth("counter", "redis", false)
-
-		return &counter.HealthCheckResponse{
-			Status: &common.Status{
-				Success: false,
-				Message: "Redis connection failed",
-				Code:    int32(codes.Unavailable),
-			},
-			Service: "counter",
-			Details: map[string]string{
-				"redis": "unhealthy",
-				"error": err.Error(),
-			},
-		}, nil
-	}
-
-	// 获取Kafka健康状态
-	kafkaHealth := s.kafkaManager.HealthCheck()
-
-	// 更新健康状态指标
-	s.metricsManager.SetServiceHealth("counter", "redis", true)
-	s.metricsManager.SetServiceHealth("counter", "kafka", true)
-
-	details := map[string]string{
-		"redis":       "healthy",
-		"event_count": fmt.Sprintf("%d", s.eventCounter),
-		"kafka_mode":  fmt.Sprintf("%v", kafkaHealth["mode"]),
-	}
-
-	return &counter.HealthCheckResponse{
-		Status: &common.Status{
-			Success: true,
-			Message: "Service is healthy",
-			Code:    int32(codes.OK),
-		},
-		Service: "counter",
-		Details: details,
-	}, nil
+// newCounterServer 按应用配置创建Counter服务端，counter.*和kafka.producer.*配置在此生效
+func newCounterServer(cfg *config.Config, store biz.CounterRepo, workerPool *pool.WorkerPool, objectPool *pool.ObjectPool, producer kafka.Producer, logger *zap.Logger) *server.CounterServer {
+	return server.NewCounterServer(store, workerPool, objectPool, producer, server.NewConfigFromAppConfig(cfg), logger)
 }
 
-// setupHTTPMonitoringServer 设置HTTP监控服务器
-func setupHTTPMonitoringServer(metricsManager *metrics.MetricsManager, logger *zap.Logger) *http.Server {
+// setupHTTPMonitoringServer 设置HTTP监控服务器，statsCollector为nil时不挂载/debug/stats
+func setupHTTPMonitoringServer(metricsManager *metrics.MetricsManager, grpcPort int, statsCollector *stats.Collector, logger *zap.Logger) *http.Server {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Recovery())
 
-	// 添加HTTP指标中间件
-	router.Use(middleware.HTTPMetricsMiddleware(metricsManager, "counter"))
+	// 添加HTTP指标中间件，/metrics抓取和/health轮询不计入请求统计
+	router.Use(middleware.HTTPMetricsMiddlewareWithConfig(metricsManager, "counter", middleware.DefaultHTTPMetricsConfig()))
 
 	// 健康检查端点
 	router.GET("/health", func(c *gin.Context) {
@@ -363,18 +72,27 @@ func setupHTTPMonitoringServer(metricsManager *metrics.MetricsManager, logger *z
 	// Prometheus指标端点
 	router.GET("/metrics", gin.WrapH(metricsManager.GetHandler()))
 
+	// 负载评分端点 - 供外部自动扩缩容使用
+	router.GET("/load", gin.WrapH(metrics.NewLoadScorer(metricsManager, "counter", metrics.DefaultLoadScoreConfig())))
+
+	// 统一组件统计端点
+	if statsCollector != nil {
+		router.GET("/debug/stats", gin.WrapH(statsCollector))
+	}
+
 	// 服务状态端点
 	router.GET("/status", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"service": "counter",
 			"ports": gin.H{
-				"grpc":       9001,
+				"grpc":       grpcPort,
 				"monitoring": 8081,
 			},
 			"endpoints": gin.H{
 				"health":  "/health",
 				"metrics": "/metrics",
 				"status":  "/status",
+				"load":    "/load",
 			},
 		})
 	})
@@ -388,11 +106,34 @@ func setupHTTPMonitoringServer(metricsManager *metrics.MetricsManager, logger *z
 }
 
 func main() {
-	// 创建logger
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
+	configPath := flag.String("config", "configs/config.yaml", "配置文件路径")
+	flag.Parse()
 
-	logger.Info("Starting Counter microservice with Redis, Kafka and Monitoring integration...",
+	// 服务启动或运行失败时以非0状态码退出，run中的defer已完成清理
+	if err := run(*configPath); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run 启动Counter服务并阻塞到收到退出信号或服务失败，服务失败时返回错误
+func run(configPath string) error {
+	// 加载配置
+	configManager := config.NewManager(zap.L())
+	cfg, err := configManager.Load(configPath)
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		return err
+	}
+
+	// 初始化日志
+	log, err := logger.NewLogger(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Printf("Failed to initialize log: %v\n", err)
+		return err
+	}
+	defer log.Sync()
+
+	log.Info("Starting Counter microservice with Redis, Kafka and Monitoring integration...",
 		zap.String("service", "counter"),
 		zap.String("version", "2.0.0"))
 
@@ -405,29 +146,49 @@ func main() {
 		EnableDB:       true,
 		EnableCache:    true,
 	}
-	metricsManager := metrics.NewMetricsManager(metricsConfig, logger)
-	logger.Info("✅ Metrics manager initialized")
+	metricsManager := metrics.NewMetricsManager(metricsConfig, log)
+	log.Info("✅ Metrics manager initialized")
 
-	// 🔧 初始化Redis连接
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379", // 可以通过环境变量配置
-		Password: "",               // 可以通过环境变量配置
-		DB:       0,                // 可以通过环境变量配置
+	// 生效配置与本地文件不一致时上报config_drift指标
+	configManager.SetDriftObserver(func(drifted bool, fields []string) {
+		metricsManager.SetConfigDrift("counter", drifted)
 	})
 
-	// 测试Redis连接
-	ctx := context.Background()
-	_, err := redisClient.Ping(ctx).Result()
+	// 退出时输出运行汇总
+	shutdownReporter := shutdown.NewReporter("counter", log)
+	shutdownReporter.SetMetricsManager(metricsManager)
+
+	// 🔧 初始化Redis连接，按redis.mode连接单机、集群或哨兵
+	redisDAO, err := dao.NewRedisDAO(cfg.Redis, log)
 	if err != nil {
-		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+		log.Error("Failed to connect to Redis", zap.Error(err))
+		return err
 	}
 
-	logger.Info("✅ Redis connection established successfully")
+	log.Info("✅ Redis connection established successfully",
+		zap.String("mode", cfg.Redis.Mode))
 
-	// 创建Redis DAO
-	redisDAO := &dao.RedisRepo{}
-	redisDAO.SetClient(redisClient)
-	redisDAO.SetLogger(logger)
+	// 存储迁移期间可开启双写：写入新旧两个Redis，读取主存储并比对差异
+	var counterStore biz.CounterRepo = redisDAO
+	if dualWrite := cfg.Counter.DualWrite; dualWrite.Enabled {
+		secondaryDAO, err := dao.NewRedisDAO(dualWrite.Secondary, log)
+		if err != nil {
+			log.Error("Failed to connect to secondary Redis", zap.Error(err))
+			return err
+		}
+		defer secondaryDAO.Close()
+
+		dualWriteStore := dao.NewDualWriteCounterStore(redisDAO, secondaryDAO, &dao.DualWriteConfig{
+			CompareReads:         dualWrite.CompareReads,
+			FailOnSecondaryError: dualWrite.FailOnSecondaryError,
+		}, log)
+		dualWriteStore.SetMetricsManager(metricsManager)
+		counterStore = dualWriteStore
+
+		log.Info("✅ Dual write enabled",
+			zap.String("secondary", dualWrite.Secondary.Address),
+			zap.Bool("compare_reads", dualWrite.CompareReads))
+	}
 
 	// 🔥 初始化Kafka（使用Mock模式开始）
 	kafkaConfig := kafka.DefaultKafkaConfig()
@@ -440,18 +201,26 @@ func main() {
 		if len(kafkaConfig.Producer.Brokers) == 0 || kafkaConfig.Producer.Brokers[0] == "" {
 			kafkaConfig.Producer.Brokers = []string{"localhost:9092"}
 		}
-		logger.Info("Using real Kafka",
+		log.Info("Using real Kafka",
 			zap.Strings("brokers", kafkaConfig.Producer.Brokers))
 	}
+	// 托管Kafka的TLS/SASL认证，与Analytics共用kafka.security配置
+	kafkaConfig.Producer.Security = kafka.SecurityConfigFromAppConfig(cfg.Kafka.Security)
+	// Kafka不可用时降级运行，事件先缓冲在内存中，恢复后补发
+	kafkaConfig.FallbackToMock = cfg.Kafka.FallbackToMock
+	kafkaConfig.Fallback.RetryInterval = cfg.Kafka.FallbackRetryInterval
 
-	kafkaManager, err := kafka.NewKafkaManager(kafkaConfig, logger)
+	kafkaManager, err := kafka.NewKafkaManager(kafkaConfig, log)
 	if err != nil {
-		logger.Fatal("Failed to initialize Kafka manager", zap.Error(err))
+		log.Error("Failed to initialize Kafka manager", zap.Error(err))
+		return err
 	}
 	defer kafkaManager.Close()
+	shutdownReporter.SetProducer(kafkaManager.GetProducer())
 
-	logger.Info("✅ Kafka manager initialized successfully",
-		zap.String("mode", string(kafkaManager.GetMode())))
+	log.Info("✅ Kafka manager initialized successfully",
+		zap.String("mode", string(kafkaManager.GetMode())),
+		zap.Bool("degraded", kafkaManager.IsDegraded()))
 
 	// 🌐 初始化Consul客户端并注册服务
 	consulConfig := &consul.Config{
@@ -459,91 +228,212 @@ func main() {
 		Scheme:  "http",
 	}
 
-	consulClient, err := consul.NewClient(consulConfig, logger)
+	consulClient, err := consul.NewClient(consulConfig, log)
 	if err != nil {
-		logger.Fatal("Failed to create consul client", zap.Error(err))
+		log.Error("Failed to create consul client", zap.Error(err))
+		return err
 	}
 	defer consulClient.Close()
 
 	// 注册Counter服务到Consul
+	grpcPort := cfg.Counter.Server.Port
 	serviceConfig := &consul.ServiceConfig{
 		ID:      "counter-1",
 		Name:    "high-go-press-counter",
 		Tags:    []string{"counter", "grpc", "microservice", "v2.0"},
 		Address: "localhost",
-		Port:    9001,
+		Port:    grpcPort,
 		Check: &consul.HealthCheck{
-			TCP:      "localhost:9001",
+			TCP:      fmt.Sprintf("localhost:%d", grpcPort),
 			Interval: "10s",
 			Timeout:  "3s",
 		},
 	}
 
 	if err := consulClient.RegisterService(serviceConfig); err != nil {
-		logger.Fatal("Failed to register service to Consul", zap.Error(err))
+		log.Error("Failed to register service to Consul", zap.Error(err))
+		return err
 	}
 
-	logger.Info("✅ Counter service registered to Consul successfully")
+	log.Info("✅ Counter service registered to Consul successfully")
 
 	// 确保在退出时注销服务
 	defer func() {
 		if err := consulClient.DeregisterService("counter-1"); err != nil {
-			logger.Error("Failed to deregister service from Consul", zap.Error(err))
+			log.Error("Failed to deregister service from Consul", zap.Error(err))
 		} else {
-			logger.Info("Counter service deregistered from Consul")
+			log.Info("Counter service deregistered from Consul")
 		}
 	}()
 
-	// 创建gRPC服务器，添加指标拦截器
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(middleware.GRPCMetricsUnaryInterceptor(metricsManager, "counter")),
-	)
+	// 多租户：开启后所有请求必须携带tenant-id元数据，或由认证身份确定租户
+	tenantConfig := &middleware.TenantConfig{
+		Enabled: cfg.Tenancy.Enabled,
+	}
+
+	// 请求元数据限制，拒绝超大或携带禁止键的元数据
+	metadataLimit := &middleware.MetadataLimitConfig{
+		MaxSize:         cfg.Counter.GRPC.Metadata.MaxSize,
+		DisallowedKeys:  cfg.Counter.GRPC.Metadata.DisallowedKeys,
+		StripDisallowed: cfg.Counter.GRPC.Metadata.StripDisallowed,
+	}
+
+	// 响应大小：记录序列化后的字节数，0表示不限制
+	responseSize := &middleware.ResponseSizeConfig{
+		MaxSize: cfg.Counter.GRPC.MaxResponseSize,
+	}
+	keepaliveConfig := &middleware.KeepaliveConfig{
+		Time:                cfg.Counter.GRPC.KeepAlive.Time,
+		Timeout:             cfg.Counter.GRPC.KeepAlive.Timeout,
+		MinTime:             cfg.Counter.GRPC.KeepAlive.MinTime,
+		PermitWithoutStream: cfg.Counter.GRPC.KeepAlive.PermitWithoutStream,
+	}
+
+	unaryInterceptors := []middleware.OrderedUnaryInterceptor{
+		middleware.ClientInfoUnary(log),
+		middleware.MetadataLimitUnary(metadataLimit),
+		middleware.GRPCMetricsUnary(metricsManager, "counter"),
+		middleware.ResponseSizeUnary(metricsManager, "counter", responseSize),
+	}
+	streamInterceptors := []middleware.OrderedStreamInterceptor{
+		middleware.ClientInfoStream(log),
+		middleware.MetadataLimitStream(metadataLimit),
+		middleware.ResponseSizeStream(metricsManager, "counter", responseSize),
+	}
+
+	// 认证：与Gateway共用认证提供者，由counter.auth单独开启，Gateway转发的请求不携带调用方凭证
+	if cfg.Counter.Auth.Enabled {
+		authenticator, err := auth.NewAuthenticator(&cfg.Auth)
+		if err != nil {
+			log.Error("Failed to create authenticator", zap.Error(err))
+			return err
+		}
+		unaryInterceptors = append(unaryInterceptors, middleware.AuthUnary(authenticator))
+		streamInterceptors = append(streamInterceptors, middleware.AuthStream(authenticator))
+		log.Info("✅ gRPC authentication enabled", zap.String("provider", cfg.Auth.Provider))
+	}
+
+	// 租户挂在认证之后：调用方身份携带租户时以身份为准，tenant-id元数据与之不一致时拒绝
+	unaryInterceptors = append(unaryInterceptors, middleware.TenantUnary(tenantConfig))
+	streamInterceptors = append(streamInterceptors, middleware.TenantStream(tenantConfig))
+
+	// 管理接口只允许auth.admin_subjects中的调用方，counter.auth未开启时没有调用方身份，不做限制
+	adminConfig := &middleware.AdminConfig{
+		Enabled:  cfg.Counter.Auth.Enabled,
+		Methods:  adminMethods,
+		Subjects: cfg.Auth.AdminSubjects,
+	}
+	unaryInterceptors = append(unaryInterceptors, middleware.AdminUnary(adminConfig))
+	streamInterceptors = append(streamInterceptors, middleware.AdminStream(adminConfig))
+
+	// 每日配额：只限制增量写入，需挂在认证之后以取得调用方身份
+	// Gateway已按HTTP请求扣除配额，由counter.auth.quota单独开启以免重复扣除
+	if cfg.Counter.Auth.Quota {
+		quotaConfig, err := quota.ConfigFromAppConfig(cfg.Quota)
+		if err != nil {
+			log.Error("Failed to create quota config", zap.Error(err))
+			return err
+		}
+		quotaClient, err := dao.NewRedisClient(cfg.Redis)
+		if err != nil {
+			log.Error("Failed to create quota Redis client", zap.Error(err))
+			return err
+		}
+		defer quotaClient.Close()
+		quotaService := quota.NewService(quotaClient, quotaConfig, log)
+		unaryInterceptors = append(unaryInterceptors, middleware.QuotaUnary(quotaService, quotaMethods, log))
+		log.Info("✅ Daily quota enabled", zap.String("default_tier", cfg.Quota.DefaultTier))
+	}
+
+	// 创建gRPC服务器，添加keepalive约束和拦截器，拦截器顺序不符合阶段约定时启动失败
+	unaryChain, err := middleware.ChainUnaryInterceptors(unaryInterceptors...)
+	if err != nil {
+		log.Error("Invalid unary interceptor chain", zap.Error(err))
+		return err
+	}
+	streamChain, err := middleware.ChainStreamInterceptors(streamInterceptors...)
+	if err != nil {
+		log.Error("Invalid stream interceptor chain", zap.Error(err))
+		return err
+	}
+	serverOpts := append(middleware.KeepaliveServerOptions(keepaliveConfig), unaryChain, streamChain)
+	grpcServer := grpc.NewServer(serverOpts...)
+
+	// 事件发送等异步任务使用Worker Pool，响应对象复用对象池
+	workerPool, err := pool.NewWorkerPool(log)
+	if err != nil {
+		log.Error("Failed to create worker pool", zap.Error(err))
+		return err
+	}
+	objectPool := pool.NewObjectPool()
+
+	// 定期上报Worker Pool运行数、等待数和利用率
+	poolStatsCtx, stopPoolStats := context.WithCancel(context.Background())
+	defer stopPoolStats()
+	go workerPool.RunStatsCollector(poolStatsCtx, metricsManager, "counter", 5*time.Second)
 
 	// 注册Counter服务
-	counterSrv := NewCounterServer(logger, redisDAO, kafkaManager, metricsManager)
+	counterSrv := newCounterServer(cfg, counterStore, workerPool, objectPool, kafkaManager.GetProducer(), log)
+	counterSrv.SetMetricsManager(metricsManager)
+
+	// 管理操作的分布式锁：多实例间互斥执行计数器重置
+	lockClient, err := dao.NewRedisClient(cfg.Redis)
+	if err != nil {
+		log.Error("Failed to create lock Redis client", zap.Error(err))
+		return err
+	}
+	defer lockClient.Close()
+	counterSrv.SetLocker(redislock.NewLocker(lockClient, log))
 	counter.RegisterCounterServiceServer(grpcServer, counterSrv)
 
 	// 启用反射 (用于grpcurl等工具)
 	reflection.Register(grpcServer)
 
 	// 监听gRPC端口
-	grpcListen, err := net.Listen("tcp", ":9001")
+	grpcListen, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.Counter.Server.Host, grpcPort))
 	if err != nil {
-		logger.Fatal("Failed to listen on gRPC port", zap.Error(err))
+		log.Error("Failed to listen on gRPC port", zap.Error(err))
+		return err
 	}
 
-	// 设置HTTP监控服务器
-	httpServer := setupHTTPMonitoringServer(metricsManager, logger)
+	// 统一组件统计端点，与Gateway一致只在非release模式开启
+	var statsCollector *stats.Collector
+	if cfg.Counter.Server.Mode != "release" {
+		statsCollector = stats.NewCollector()
+		if err := statsCollector.RegisterAll(map[string]stats.StatsProvider{
+			"worker_pool":           workerPool,
+			"object_pool":           objectPool,
+			"event_circuit_breaker": counterSrv.EventCircuitBreaker(),
+		}); err != nil {
+			log.Error("Failed to register stats providers", zap.Error(err))
+			return err
+		}
+	}
 
-	// 启动gRPC服务器
-	go func() {
-		logger.Info("Counter gRPC server starting",
-			zap.String("address", grpcListen.Addr().String()))
+	// 设置HTTP监控服务器
+	httpServer := setupHTTPMonitoringServer(metricsManager, grpcPort, statsCollector, log)
 
-		if err := grpcServer.Serve(grpcListen); err != nil {
-			logger.Error("gRPC server failed", zap.Error(err))
-		}
-	}()
+	// 启动gRPC服务器和HTTP监控服务器，启动或运行失败时通知主goroutine
+	servers := shutdown.NewServerGroup(log)
 
-	// 启动HTTP监控服务器
-	go func() {
-		logger.Info("Counter HTTP monitoring server starting",
-			zap.String("address", httpServer.Addr))
+	log.Info("Counter gRPC server starting",
+		zap.String("address", grpcListen.Addr().String()))
+	servers.Go("grpc", func() error { return grpcServer.Serve(grpcListen) })
 
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("HTTP monitoring server failed", zap.Error(err))
-		}
-	}()
+	log.Info("Counter HTTP monitoring server starting",
+		zap.String("address", httpServer.Addr))
+	servers.Go("http-monitoring", httpServer.ListenAndServe)
 
 	// 设置服务健康状态
 	metricsManager.SetServiceHealth("counter", "main", true)
 
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// 等待中断信号或服务失败
+	serveErr := servers.Wait(syscall.SIGINT, syscall.SIGTERM)
+	if serveErr != nil {
+		metricsManager.SetServiceHealth("counter", "main", false)
+	}
 
-	logger.Info("Shutting down Counter service...")
+	log.Info("Shutting down Counter service...")
 
 	// 优雅关闭
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -551,14 +441,38 @@ func main() {
 
 	// 关闭HTTP服务器
 	if err := httpServer.Shutdown(ctx); err != nil {
-		logger.Error("HTTP server shutdown error", zap.Error(err))
+		log.Error("HTTP server shutdown error", zap.Error(err))
 	}
 
 	// 关闭gRPC服务器
 	grpcServer.GracefulStop()
 
+	// 发送完队列中剩余的Kafka事件
+	if err := counterSrv.DrainEvents(ctx); err != nil {
+		log.Error("Kafka event queue drain error", zap.Error(err))
+	}
+
+	// 等待在途的事件发送任务完成
+	if err := workerPool.Shutdown(ctx); err != nil {
+		log.Error("Worker pool shutdown error", zap.Error(err))
+	}
+
+	// 输出被限流抑制的错误汇总
+	counterSrv.FlushErrorLog()
+
 	// 关闭Redis连接
-	redisClient.Close()
+	redisDAO.Close()
 
-	logger.Info("Counter service stopped gracefully")
+	// 停止系统指标收集
+	if err := metricsManager.Shutdown(ctx); err != nil {
+		log.Error("Failed to shutdown metrics manager", zap.Error(err))
+	}
+
+	shutdownReporter.Report()
+	if serveErr != nil {
+		log.Error("Counter service stopped after server failure", zap.Error(serveErr))
+		return serveErr
+	}
+	log.Info("Counter service stopped gracefully")
+	return nil
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/config"
+	"high-go-press/pkg/pool"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// counterConfigYAML 设置了like的增量上限和计数器过期时间
+const counterConfigYAML = `
+environment: "test"
+redis:
+  address: "localhost:6379"
+kafka:
+  mode: "mock"
+counter:
+  performance:
+    counter_ttl: 1h
+  delta:
+    types:
+      like:
+        default_delta: 1
+        max_delta: 3
+`
+
+func TestCounterServerUsesLoadedConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(counterConfigYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cfg, err := config.NewManager(zap.NewNop()).Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	repo := daotest.NewMemoryCounterRepo()
+	s := newCounterServer(cfg, repo, nil, pool.NewObjectPool(), nil, zap.NewNop())
+	ctx := context.Background()
+
+	// YAML中的max_delta生效：超过3的增量被拒绝
+	_, err = s.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 4})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument for delta above configured max, got %v", err)
+	}
+	if _, err := s.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 3}); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+
+	// YAML中的counter_ttl生效：新建的计数器带过期时间
+	if ttl, ok := repo.TTL("counter:article_1:like"); !ok || ttl != time.Hour {
+		t.Errorf("Expected counter ttl 1h, got %v (set=%v)", ttl, ok)
+	}
+}
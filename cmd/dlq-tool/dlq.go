@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"high-go-press/pkg/kafka"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// messageReader 读取主题中已有的全部消息
+type messageReader interface {
+	ReadAll(ctx context.Context, topic string) ([]*kafka.Message, error)
+}
+
+// consumerReader 基于Consumer接口读取消息，超过idle时间没有新消息即认为读取完毕
+type consumerReader struct {
+	consumer kafka.Consumer
+	idle     time.Duration
+}
+
+// ReadAll 读取主题消息
+func (r *consumerReader) ReadAll(ctx context.Context, topic string) ([]*kafka.Message, error) {
+	if err := r.consumer.Subscribe([]string{topic}); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var messages []*kafka.Message
+	received := make(chan struct{}, 1)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.consumer.ConsumeMessages(readCtx, func(ctx context.Context, msg *kafka.Message) error {
+			// Consumer可能投递其他主题的消息，只保留目标主题
+			if msg.Topic == topic {
+				copied := *msg
+				mu.Lock()
+				messages = append(messages, &copied)
+				mu.Unlock()
+			}
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+			return nil
+		})
+	}()
+
+	idle := time.NewTimer(r.idle)
+	defer idle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return nil, ctx.Err()
+		case err := <-done:
+			if err != nil && err != context.Canceled {
+				return nil, fmt.Errorf("failed to consume %s: %w", topic, err)
+			}
+			return messages, nil
+		case <-received:
+			idle.Reset(r.idle)
+		case <-idle.C:
+			cancel()
+			<-done
+			mu.Lock()
+			defer mu.Unlock()
+			return messages, nil
+		}
+	}
+}
+
+// partitionReader 直接从各分区读取消息，不加入消费者组也不提交offset，多次查看结果一致
+type partitionReader struct {
+	brokers []string
+	logger  *zap.Logger
+}
+
+// ReadAll 从最早的offset读到当前高水位
+func (r *partitionReader) ReadAll(ctx context.Context, topic string) ([]*kafka.Message, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	config.Consumer.Return.Errors = true
+
+	client, err := sarama.NewClient(r.brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions of %s: %w", topic, err)
+	}
+
+	var messages []*kafka.Message
+	for _, partition := range partitions {
+		oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+		}
+		newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get newest offset of %s/%d: %w", topic, partition, err)
+		}
+		if newest <= oldest {
+			continue
+		}
+
+		pc, err := consumer.ConsumePartition(topic, partition, oldest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+		}
+
+		for offset := oldest; offset < newest; {
+			select {
+			case <-ctx.Done():
+				pc.Close()
+				return nil, ctx.Err()
+			case consumerErr := <-pc.Errors():
+				pc.Close()
+				return nil, fmt.Errorf("failed to read %s/%d: %w", topic, partition, consumerErr)
+			case saramaMsg := <-pc.Messages():
+				msg := &kafka.Message{
+					Topic:     saramaMsg.Topic,
+					Key:       string(saramaMsg.Key),
+					Value:     saramaMsg.Value,
+					Headers:   make(map[string]string, len(saramaMsg.Headers)),
+					Timestamp: saramaMsg.Timestamp,
+				}
+				for _, header := range saramaMsg.Headers {
+					msg.Headers[string(header.Key)] = string(header.Value)
+				}
+				messages = append(messages, msg)
+				offset = saramaMsg.Offset + 1
+			}
+		}
+		pc.Close()
+
+		r.logger.Debug("Read DLQ partition",
+			zap.String("topic", topic),
+			zap.Int32("partition", partition),
+			zap.Int64("messages", newest-oldest))
+	}
+	return messages, nil
+}
+
+// deadLetterFilter 死信过滤条件
+type deadLetterFilter struct {
+	OriginalTopic string    // 原主题，为空时不过滤
+	Since         time.Time // 失败时间下限，零值时不过滤
+	Until         time.Time // 失败时间上限，零值时不过滤
+}
+
+// match 判断死信是否满足过滤条件
+func (f deadLetterFilter) match(dl *kafka.DeadLetter) bool {
+	if f.OriginalTopic != "" && dl.OriginalTopic != f.OriginalTopic {
+		return false
+	}
+	if !f.Since.IsZero() && dl.FailedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && dl.FailedAt.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// readDeadLetters 读取并过滤死信，按失败时间排序
+func readDeadLetters(ctx context.Context, reader messageReader, dlqTopic string, filter deadLetterFilter) ([]*kafka.DeadLetter, error) {
+	messages, err := reader.ReadAll(ctx, dlqTopic)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*kafka.DeadLetter
+	for _, msg := range messages {
+		dl := kafka.ParseDeadLetter(msg)
+		if filter.match(dl) {
+			result = append(result, dl)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FailedAt.Before(result[j].FailedAt)
+	})
+	return result, nil
+}
+
+// selectDeadLetters 按ID选择死信，all为true时选择全部
+func selectDeadLetters(deadLetters []*kafka.DeadLetter, ids []string, all bool) ([]*kafka.DeadLetter, error) {
+	if all {
+		return deadLetters, nil
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no dead letters selected, use -ids or -all")
+	}
+
+	byID := make(map[string]*kafka.DeadLetter, len(deadLetters))
+	for _, dl := range deadLetters {
+		byID[dl.ID] = dl
+	}
+
+	selected := make([]*kafka.DeadLetter, 0, len(ids))
+	var missing []string
+	for _, id := range ids {
+		dl, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		selected = append(selected, dl)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("dead letters not found: %s", strings.Join(missing, ","))
+	}
+	return selected, nil
+}
+
+// requeueDeadLetters 将死信发回原主题，dryRun时只输出将要执行的操作
+func requeueDeadLetters(ctx context.Context, producer kafka.Producer, deadLetters []*kafka.DeadLetter, dryRun bool, out io.Writer) (int, error) {
+	requeued := 0
+	for _, dl := range deadLetters {
+		msg := dl.RequeueMessage()
+		if dryRun {
+			fmt.Fprintf(out, "[dry-run] would requeue %s to %s (key=%s)\n", dl.ID, msg.Topic, msg.Key)
+			continue
+		}
+
+		if err := producer.SendMessage(ctx, msg); err != nil {
+			return requeued, fmt.Errorf("failed to requeue %s: %w", dl.ID, err)
+		}
+		requeued++
+		fmt.Fprintf(out, "requeued %s to %s (key=%s)\n", dl.ID, msg.Topic, msg.Key)
+	}
+	return requeued, nil
+}
+
+// printDeadLetters 输出死信列表
+func printDeadLetters(out io.Writer, deadLetters []*kafka.DeadLetter, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(deadLetters)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tORIGINAL_TOPIC\tKEY\tFAILED_AT\tATTEMPTS\tERROR")
+	for _, dl := range deadLetters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			dl.ID, dl.OriginalTopic, dl.Message.Key,
+			dl.FailedAt.Format(time.RFC3339), dl.Attempts, dl.Error)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d dead letter(s)\n", len(deadLetters))
+	return nil
+}
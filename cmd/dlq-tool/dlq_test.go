@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/kafka"
+
+	"go.uber.org/zap"
+)
+
+// seedDeadLetter 写入一条指定失败时间的死信
+func seedDeadLetter(t *testing.T, producer *kafka.MockProducer, topic, key string, failedAt time.Time) *kafka.Message {
+	t.Helper()
+
+	msg := kafka.NewDeadLetterMessage(&kafka.Message{
+		Topic:   topic,
+		Key:     key,
+		Value:   []byte(`{"resource_id":"` + key + `"}`),
+		Headers: map[string]string{"event_type": "counter_update"},
+	}, errors.New("redis unavailable"), 3)
+	msg.Headers[kafka.HeaderDLQFailedAt] = failedAt.Format(time.RFC3339Nano)
+
+	if err := producer.SendMessage(context.Background(), msg); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	return msg
+}
+
+func newSeededReader(t *testing.T) (*kafka.MockProducer, *consumerReader, time.Time) {
+	t.Helper()
+
+	producer := kafka.NewMockProducer(zap.NewNop())
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	seedDeadLetter(t, producer, "counter-events", "article_1:like", now.Add(-3*time.Hour))
+	seedDeadLetter(t, producer, "counter-events", "article_2:like", now.Add(-30*time.Minute))
+	seedDeadLetter(t, producer, "audit-events", "article_3:view", now.Add(-10*time.Minute))
+	// 非死信主题的消息不应出现在结果中
+	if err := producer.SendMessage(context.Background(), &kafka.Message{Topic: "counter-events", Key: "article_4:like"}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	consumer := kafka.NewMockConsumer(producer, zap.NewNop())
+	consumer.SetPollInterval(10 * time.Millisecond)
+	return producer, &consumerReader{consumer: consumer, idle: 100 * time.Millisecond}, now
+}
+
+func TestListDeadLettersWithFilters(t *testing.T) {
+	_, reader, now := newSeededReader(t)
+	dlqTopic := kafka.DLQTopic("counter-events")
+
+	// 死信主题按原主题命名，只包含counter-events的死信
+	all, err := readDeadLetters(context.Background(), reader, dlqTopic, deadLetterFilter{})
+	if err != nil {
+		t.Fatalf("readDeadLetters failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 dead letters in %s, got %d", dlqTopic, len(all))
+	}
+	if all[0].Message.Key != "article_1:like" || all[0].Error != "redis unavailable" || all[0].Attempts != 3 {
+		t.Errorf("Unexpected first dead letter: %+v", all[0])
+	}
+
+	// 按时间过滤
+	filter, err := buildFilter("counter-events", "1h", "", now)
+	if err != nil {
+		t.Fatalf("buildFilter failed: %v", err)
+	}
+	recent, err := readDeadLetters(context.Background(), reader, dlqTopic, filter)
+	if err != nil {
+		t.Fatalf("readDeadLetters failed: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Message.Key != "article_2:like" {
+		t.Fatalf("Expected only article_2 within 1h, got %v", recent)
+	}
+
+	var out bytes.Buffer
+	if err := printDeadLetters(&out, recent, false); err != nil {
+		t.Fatalf("printDeadLetters failed: %v", err)
+	}
+	if !strings.Contains(out.String(), recent[0].ID) || !strings.Contains(out.String(), "redis unavailable") {
+		t.Errorf("Expected listing to include ID and error, got:\n%s", out.String())
+	}
+}
+
+func TestRequeueDeadLetters(t *testing.T) {
+	producer, reader, _ := newSeededReader(t)
+
+	deadLetters, err := readDeadLetters(context.Background(), reader, kafka.DLQTopic("counter-events"), deadLetterFilter{})
+	if err != nil {
+		t.Fatalf("readDeadLetters failed: %v", err)
+	}
+	selected, err := selectDeadLetters(deadLetters, []string{deadLetters[1].ID}, false)
+	if err != nil {
+		t.Fatalf("selectDeadLetters failed: %v", err)
+	}
+
+	before := len(producer.GetMessages())
+
+	// dry-run不发送消息
+	var out bytes.Buffer
+	requeued, err := requeueDeadLetters(context.Background(), producer, selected, true, &out)
+	if err != nil {
+		t.Fatalf("dry-run requeue failed: %v", err)
+	}
+	if requeued != 0 || len(producer.GetMessages()) != before {
+		t.Fatalf("Expected dry-run to send nothing, requeued=%d", requeued)
+	}
+	if !strings.Contains(out.String(), "[dry-run]") {
+		t.Errorf("Expected dry-run output, got %q", out.String())
+	}
+
+	requeued, err = requeueDeadLetters(context.Background(), producer, selected, false, &out)
+	if err != nil {
+		t.Fatalf("requeue failed: %v", err)
+	}
+	messages := producer.GetMessages()
+	if requeued != 1 || len(messages) != before+1 {
+		t.Fatalf("Expected 1 requeued message, requeued=%d sent=%d", requeued, len(messages)-before)
+	}
+
+	// 重新投递到原主题，去掉死信消息头，保留原消息头
+	sent := messages[len(messages)-1]
+	if sent.Topic != "counter-events" || sent.Key != "article_2:like" {
+		t.Errorf("Unexpected requeued message: topic=%s key=%s", sent.Topic, sent.Key)
+	}
+	if _, ok := sent.Headers[kafka.HeaderDLQError]; ok {
+		t.Errorf("Expected DLQ headers removed, got %v", sent.Headers)
+	}
+	if sent.Headers["event_type"] != "counter_update" {
+		t.Errorf("Expected original headers kept, got %v", sent.Headers)
+	}
+
+	// 未知ID报错
+	if _, err := selectDeadLetters(deadLetters, []string{"missing"}, false); err == nil {
+		t.Error("Expected error for unknown dead letter ID")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	mode          = flag.String("mode", "real", "Kafka mode: real, mock")
+	brokers       = flag.String("brokers", "localhost:9092", "Kafka brokers, comma separated")
+	dlqTopic      = flag.String("dlq-topic", kafka.DLQTopic("counter-events"), "Dead-letter topic")
+	action        = flag.String("action", "list", "Action: list, requeue")
+	originalTopic = flag.String("original-topic", "", "Only dead letters from this original topic")
+	since         = flag.String("since", "", "Only dead letters failed after this time (RFC3339 or duration like 1h)")
+	until         = flag.String("until", "", "Only dead letters failed before this time (RFC3339 or duration like 10m)")
+	ids           = flag.String("ids", "", "Dead letter IDs to requeue, comma separated")
+	all           = flag.Bool("all", false, "Requeue all dead letters matching the filters")
+	dryRun        = flag.Bool("dry-run", false, "Print what would be requeued without sending")
+	jsonOutput    = flag.Bool("json", false, "Output dead letters as JSON")
+	timeout       = flag.Duration("timeout", 30*time.Second, "Overall timeout")
+)
+
+func main() {
+	flag.Parse()
+
+	// 初始化日志
+	logger, err := logger.NewLogger("info", "console")
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	filter, err := buildFilter(*originalTopic, *since, *until, time.Now())
+	if err != nil {
+		fmt.Printf("Invalid filter: %v\n", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	reader, producer, err := newClients(logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize Kafka clients", zap.Error(err))
+	}
+	defer producer.Close()
+
+	deadLetters, err := readDeadLetters(ctx, reader, *dlqTopic, filter)
+	if err != nil {
+		logger.Fatal("Failed to read dead letters", zap.String("topic", *dlqTopic), zap.Error(err))
+	}
+
+	switch *action {
+	case "list":
+		if err := printDeadLetters(os.Stdout, deadLetters, *jsonOutput); err != nil {
+			logger.Fatal("Failed to print dead letters", zap.Error(err))
+		}
+	case "requeue":
+		selected, err := selectDeadLetters(deadLetters, splitList(*ids), *all)
+		if err != nil {
+			logger.Fatal("Failed to select dead letters", zap.Error(err))
+		}
+		requeued, err := requeueDeadLetters(ctx, producer, selected, *dryRun, os.Stdout)
+		if err != nil {
+			logger.Fatal("Failed to requeue dead letters", zap.Int("requeued", requeued), zap.Error(err))
+		}
+		if !*dryRun {
+			fmt.Printf("Requeued %d dead letter(s)\n", requeued)
+		}
+	default:
+		fmt.Printf("Unknown action: %s\n", *action)
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+// newClients 按模式创建读取器和用于重新投递的Producer
+func newClients(log *zap.Logger) (messageReader, kafka.Producer, error) {
+	switch kafka.KafkaMode(*mode) {
+	case kafka.ModeReal:
+		brokerList := splitList(*brokers)
+		producerConfig := kafka.DefaultProducerConfig()
+		producerConfig.Brokers = brokerList
+		producer, err := kafka.NewRealProducer(producerConfig, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &partitionReader{brokers: brokerList, logger: log}, producer, nil
+	case kafka.ModeMock:
+		producer := kafka.NewMockProducer(log)
+		consumer := kafka.NewMockConsumer(producer, log)
+		consumer.SetPollInterval(100 * time.Millisecond)
+		return &consumerReader{consumer: consumer, idle: time.Second}, producer, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported kafka mode: %s", *mode)
+	}
+}
+
+// buildFilter 解析过滤参数，时间可以是RFC3339格式或相对now的时长
+func buildFilter(originalTopic, since, until string, now time.Time) (deadLetterFilter, error) {
+	filter := deadLetterFilter{OriginalTopic: originalTopic}
+
+	var err error
+	if filter.Since, err = parseTimeFlag(since, now); err != nil {
+		return filter, fmt.Errorf("since: %w", err)
+	}
+	if filter.Until, err = parseTimeFlag(until, now); err != nil {
+		return filter, fmt.Errorf("until: %w", err)
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return filter, fmt.Errorf("until must not be before since")
+	}
+	return filter, nil
+}
+
+// parseTimeFlag 解析时间参数，空值返回零值
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// splitList 解析逗号分隔的列表
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
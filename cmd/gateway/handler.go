@@ -1,10 +1,12 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"high-go-press/internal/biz"
+	"high-go-press/internal/dao"
 	"high-go-press/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -171,6 +173,14 @@ func (h *Handler) getHotRank(c *gin.Context) {
 	}
 
 	resp, err := h.counterUseCase.GetHotRank(c.Request.Context(), query)
+	if errors.Is(err, dao.ErrInvalidHotRankPeriod) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid parameters",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to get hot rank",
 			zap.String("counter_type", counterTypeStr),
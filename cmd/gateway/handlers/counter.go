@@ -3,12 +3,15 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	pb "high-go-press/api/proto/counter"
 	"high-go-press/internal/biz"
 	"high-go-press/internal/gateway/client"
 	"high-go-press/internal/gateway/service"
+	resilience "high-go-press/pkg/grpc"
+	"high-go-press/pkg/middleware"
 	"high-go-press/pkg/pool"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +23,8 @@ type CounterHandler struct {
 	serviceManager    *service.ServiceManager
 	objPool           *pool.ObjectPool
 	timeout           time.Duration
+	fallbackCache     *resilience.CacheFallbackHandler
+	protoJSON         bool // 默认以protojson编码返回gRPC响应
 }
 
 // NewCounterHandler 创建计数器处理器 - 使用连接池
@@ -58,8 +63,14 @@ func (h *CounterHandler) IncrementCounter(c *gin.Context) {
 		req.Delta = 1
 	}
 
-	// 创建gRPC请求上下文
-	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	// 客户端IP：优先X-Forwarded-For，其次连接地址
+	req.ClientIP = c.ClientIP()
+
+	// 创建gRPC请求上下文，携带请求的租户
+	ctx, cancel, _, ok := h.counterContext(c)
+	if !ok {
+		return
+	}
 	defer cancel()
 
 	// HTTP请求转换为gRPC请求
@@ -67,6 +78,8 @@ func (h *CounterHandler) IncrementCounter(c *gin.Context) {
 		ResourceId:  req.ResourceID,
 		CounterType: req.CounterType,
 		Delta:       req.Delta,
+		UserId:      req.UserID,
+		ClientIp:    req.ClientIP,
 	}
 
 	var grpcResp *pb.IncrementResponse
@@ -99,7 +112,7 @@ func (h *CounterHandler) IncrementCounter(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(middleware.HTTPStatusFromError(err), gin.H{
 			"status":  "error",
 			"error":   "Failed to increment counter",
 			"details": err.Error(),
@@ -107,6 +120,11 @@ func (h *CounterHandler) IncrementCounter(c *gin.Context) {
 		return
 	}
 
+	if h.wantsProtoJSON(c) {
+		respondProto(c, grpcResp)
+		return
+	}
+
 	// 转换gRPC响应为HTTP响应
 	resp := &biz.CounterResponse{
 		ResourceID:   grpcReq.ResourceId,
@@ -134,8 +152,11 @@ func (h *CounterHandler) GetCounter(c *gin.Context) {
 		return
 	}
 
-	// 创建gRPC请求上下文
-	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	// 创建gRPC请求上下文，携带请求的租户
+	ctx, cancel, tenantID, ok := h.counterContext(c)
+	if !ok {
+		return
+	}
 	defer cancel()
 
 	// 创建gRPC请求
@@ -152,6 +173,9 @@ func (h *CounterHandler) GetCounter(c *gin.Context) {
 		// 使用ServiceManager
 		conn, connErr := h.serviceManager.GetCounterConnection()
 		if connErr != nil {
+			if h.serveStaleCounter(c, tenantID, resourceID, counterType) {
+				return
+			}
 			c.JSON(http.StatusServiceUnavailable, gin.H{
 				"status":  "error",
 				"error":   "Counter service unavailable",
@@ -174,20 +198,30 @@ func (h *CounterHandler) GetCounter(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		// 后端不可达时优先返回缓存的旧值
+		if isBackendUnavailable(err) && h.serveStaleCounter(c, tenantID, resourceID, counterType) {
+			return
+		}
+		c.JSON(middleware.HTTPStatusFromError(err), gin.H{
 			"status":  "error",
 			"error":   "Failed to get counter",
 			"details": err.Error(),
 		})
 		return
 	}
+	h.cacheCounter(tenantID, resourceID, counterType, grpcResp.Value)
+
+	if h.wantsProtoJSON(c) {
+		respondProto(c, grpcResp)
+		return
+	}
 
 	// 转换gRPC响应为HTTP响应
 	counter := &biz.Counter{
 		ResourceID:   resourceID,
 		CounterType:  counterType,
 		CurrentValue: grpcResp.Value,
-		UpdatedAt:    time.Now().Unix(),
+		UpdatedAt:    grpcResp.GetLastUpdated().GetSeconds(),
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -207,8 +241,11 @@ func (h *CounterHandler) BatchGetCounters(c *gin.Context) {
 		return
 	}
 
-	// 创建gRPC请求上下文
-	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	// 创建gRPC请求上下文，携带请求的租户
+	ctx, cancel, _, ok := h.counterContext(c)
+	if !ok {
+		return
+	}
 	defer cancel()
 
 	// 转换HTTP请求为gRPC请求
@@ -254,7 +291,7 @@ func (h *CounterHandler) BatchGetCounters(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(middleware.HTTPStatusFromError(err), gin.H{
 			"status":  "error",
 			"error":   "Failed to batch get counters",
 			"details": err.Error(),
@@ -262,6 +299,11 @@ func (h *CounterHandler) BatchGetCounters(c *gin.Context) {
 		return
 	}
 
+	if h.wantsProtoJSON(c) {
+		respondProto(c, grpcResp)
+		return
+	}
+
 	// 转换gRPC响应为HTTP响应
 	results := make([]biz.Counter, len(grpcResp.Counters))
 	for i, result := range grpcResp.Counters {
@@ -269,7 +311,7 @@ func (h *CounterHandler) BatchGetCounters(c *gin.Context) {
 			ResourceID:   result.ResourceId,
 			CounterType:  result.CounterType,
 			CurrentValue: result.Value,
-			UpdatedAt:    time.Now().Unix(),
+			UpdatedAt:    result.GetLastUpdated().GetSeconds(),
 		}
 	}
 
@@ -283,3 +325,141 @@ func (h *CounterHandler) BatchGetCounters(c *gin.Context) {
 		"data":   resp,
 	})
 }
+
+// GetHotRank 获取热点排行 - HTTP转gRPC (使用连接池或ServiceManager)
+// GET /api/v1/counter/hot/:counter_type?period=day&limit=10
+func (h *CounterHandler) GetHotRank(c *gin.Context) {
+	counterType := c.Param("counter_type")
+	if counterType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "counter_type is required",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	// 创建gRPC请求上下文，携带请求的租户
+	ctx, cancel, _, ok := h.counterContext(c)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	grpcReq := &pb.GetHotRankRequest{
+		CounterType: counterType,
+		Period:      c.DefaultQuery("period", "day"),
+		Limit:       int32(limit),
+	}
+
+	var grpcResp *pb.GetHotRankResponse
+
+	// 根据配置选择使用连接池还是ServiceManager
+	if h.serviceManager != nil {
+		conn, connErr := h.serviceManager.GetCounterConnection()
+		if connErr != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"error":   "Counter service unavailable",
+				"details": connErr.Error(),
+			})
+			return
+		}
+
+		client := pb.NewCounterServiceClient(conn)
+		grpcResp, err = client.GetHotRank(ctx, grpcReq)
+	} else if h.counterClientPool != nil {
+		grpcResp, err = h.counterClientPool.GetHotRank(ctx, grpcReq)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error":  "No counter client configured",
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(middleware.HTTPStatusFromError(err), gin.H{
+			"status":  "error",
+			"error":   "Failed to get hot rank",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if h.wantsProtoJSON(c) {
+		respondProto(c, grpcResp)
+		return
+	}
+
+	// 转换gRPC响应为HTTP响应
+	items := make([]*biz.HotRankItem, len(grpcResp.Items))
+	for i, item := range grpcResp.Items {
+		items[i] = &biz.HotRankItem{
+			ResourceID:  item.ResourceId,
+			CounterType: biz.CounterType(grpcResp.CounterType),
+			Count:       item.Score,
+			Rank:        int(item.Rank),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"period": grpcResp.Period,
+		"data":   items,
+	})
+}
+
+// Readiness 就绪检查 - 对Counter服务的所有gRPC连接执行健康检查
+func (h *CounterHandler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	req := &pb.HealthCheckRequest{Service: "counter"}
+
+	// 根据配置选择使用连接池还是ServiceManager
+	if h.serviceManager != nil {
+		conn, connErr := h.serviceManager.GetCounterConnection()
+		if connErr != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  client.PoolUnhealthy,
+				"error":   "Counter service unavailable",
+				"details": connErr.Error(),
+			})
+			return
+		}
+
+		resp, err := pb.NewCounterServiceClient(conn).HealthCheck(ctx, req)
+		if err != nil || !resp.GetStatus().GetSuccess() {
+			details := resp.GetStatus().GetMessage()
+			if err != nil {
+				details = err.Error()
+			}
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  client.PoolUnhealthy,
+				"error":   "Counter service health check failed",
+				"details": details,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": client.PoolHealthy})
+	} else if h.counterClientPool != nil {
+		health := h.counterClientPool.HealthCheckAll(ctx, req)
+
+		// 部分连接不健康时仍可服务，返回degraded；全部不健康时不再接收流量
+		code := http.StatusOK
+		if health.Status == client.PoolUnhealthy {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, health)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error":  "No counter client configured",
+		})
+	}
+}
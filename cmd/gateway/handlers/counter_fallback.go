@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"high-go-press/internal/biz"
+	resilience "high-go-press/pkg/grpc"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetFallbackCache 设置读降级缓存，Counter服务不可用时GetCounter返回最近一次成功读取的值
+func (h *CounterHandler) SetFallbackCache(cache *resilience.CacheFallbackHandler) {
+	h.fallbackCache = cache
+}
+
+// counterCacheKey 计数器在降级缓存中的键，按租户隔离
+func counterCacheKey(tenantID, resourceID, counterType string) string {
+	if tenantID != "" {
+		return tenantID + ":" + resourceID + ":" + counterType
+	}
+	return resourceID + ":" + counterType
+}
+
+// cacheCounter 记录成功读取的计数器值
+func (h *CounterHandler) cacheCounter(tenantID, resourceID, counterType string, value int64) {
+	if h.fallbackCache == nil {
+		return
+	}
+	h.fallbackCache.Set(counterCacheKey(tenantID, resourceID, counterType), value)
+}
+
+// serveStaleCounter 后端不可用时返回缓存的计数器值并标记stale，缓存未命中时返回false
+func (h *CounterHandler) serveStaleCounter(c *gin.Context, tenantID, resourceID, counterType string) bool {
+	if h.fallbackCache == nil {
+		return false
+	}
+	entry, ok := h.fallbackCache.Get(counterCacheKey(tenantID, resourceID, counterType))
+	if !ok {
+		return false
+	}
+	value, ok := entry.Data.(int64)
+	if !ok {
+		return false
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"stale":  true,
+		"data": &biz.Counter{
+			ResourceID:   resourceID,
+			CounterType:  counterType,
+			CurrentValue: value,
+			UpdatedAt:    entry.Timestamp.Unix(),
+		},
+	})
+	return true
+}
+
+// isBackendUnavailable 判断错误是否表示后端不可达，业务错误不触发降级
+func isBackendUnavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
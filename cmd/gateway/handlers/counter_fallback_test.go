@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/common"
+	pb "high-go-press/api/proto/counter"
+	"high-go-press/internal/gateway/client"
+	resilience "high-go-press/pkg/grpc"
+	"high-go-press/pkg/middleware"
+	"high-go-press/pkg/pool"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// stubCounterServer 返回固定值的Counter服务，notFound置位后返回业务错误
+type stubCounterServer struct {
+	pb.UnimplementedCounterServiceServer
+	value     int64
+	notFound  atomic.Bool
+	updatedAt map[string]time.Time // resource_id -> 最后写入时间，未记录的计数器不返回last_updated
+	tenant    atomic.Value         // 最近一次GetCounter请求携带的tenant-id元数据
+}
+
+func (s *stubCounterServer) GetCounter(ctx context.Context, req *pb.GetCounterRequest) (*pb.GetCounterResponse, error) {
+	tenantID := ""
+	if values := metadata.ValueFromIncomingContext(ctx, middleware.TenantMetadataKey); len(values) > 0 {
+		tenantID = values[0]
+	}
+	s.tenant.Store(tenantID)
+	if s.notFound.Load() {
+		return nil, status.Error(codes.NotFound, "counter not found")
+	}
+	return &pb.GetCounterResponse{ResourceId: req.ResourceId, CounterType: req.CounterType, Value: s.value}, nil
+}
+
+// BatchGetCounters 按请求返回固定值，updatedAt中有记录的计数器带写入时间
+func (s *stubCounterServer) BatchGetCounters(ctx context.Context, req *pb.BatchGetRequest) (*pb.BatchGetResponse, error) {
+	counters := make([]*pb.GetCounterResponse, len(req.Requests))
+	for i, r := range req.Requests {
+		counters[i] = &pb.GetCounterResponse{ResourceId: r.ResourceId, CounterType: r.CounterType, Value: s.value}
+		if at, ok := s.updatedAt[r.ResourceId]; ok {
+			counters[i].LastUpdated = &common.Timestamp{Seconds: at.Unix()}
+		}
+	}
+	return &pb.BatchGetResponse{Counters: counters}, nil
+}
+
+// GetHotRank 返回固定的两条排行
+func (s *stubCounterServer) GetHotRank(ctx context.Context, req *pb.GetHotRankRequest) (*pb.GetHotRankResponse, error) {
+	if req.Period != "day" {
+		return nil, status.Error(codes.FailedPrecondition, "hot rank is not enabled")
+	}
+	return &pb.GetHotRankResponse{
+		CounterType: req.CounterType,
+		Period:      req.Period,
+		Items: []*pb.HotRankEntry{
+			{ResourceId: "article_2", Score: 7, Rank: 1},
+			{ResourceId: "article_1", Score: 2, Rank: 2},
+		},
+	}, nil
+}
+
+// counterResponse GetCounter的HTTP响应
+type counterResponse struct {
+	Status string `json:"status"`
+	Stale  bool   `json:"stale"`
+	Data   struct {
+		CurrentValue int64 `json:"current_value"`
+		UpdatedAt    int64 `json:"updated_at"`
+	} `json:"data"`
+}
+
+// newFallbackTestHandler 启动Counter服务并创建带降级缓存的处理器
+func newFallbackTestHandler(t *testing.T, stub *stubCounterServer) (*gin.Engine, *grpc.Server) {
+	t.Helper()
+	_, router, srv := newTestCounterHandler(t, stub)
+	return router, srv
+}
+
+// newTestCounterHandler 启动Counter服务，返回带降级缓存的处理器及其路由
+func newTestCounterHandler(t *testing.T, stub *stubCounterServer) (*CounterHandler, *gin.Engine, *grpc.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterCounterServiceServer(srv, stub)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	poolConfig := client.DefaultPoolConfig(lis.Addr().String())
+	poolConfig.PoolSize = 1
+	clientPool, err := client.NewCounterClientPool(poolConfig, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create client pool: %v", err)
+	}
+	t.Cleanup(func() { clientPool.Close() })
+
+	h := NewCounterHandler(clientPool, pool.NewObjectPool())
+	h.timeout = time.Second
+	h.SetFallbackCache(resilience.NewCacheFallbackHandler(time.Minute, 0, zap.NewNop()))
+
+	router := gin.New()
+	router.GET("/counter/:resource_id/:counter_type", h.GetCounter)
+	return h, router, srv
+}
+
+func getCounter(t *testing.T, router *gin.Engine, resourceID string) (int, counterResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/counter/"+resourceID+"/like", nil))
+
+	var resp counterResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestGetCounterServesStaleValueWhenBackendDown(t *testing.T) {
+	router, backend := newFallbackTestHandler(t, &stubCounterServer{value: 42})
+
+	code, resp := getCounter(t, router, "article_1")
+	if code != http.StatusOK || resp.Stale || resp.Data.CurrentValue != 42 {
+		t.Fatalf("Expected fresh value 42, got %d %+v", code, resp)
+	}
+
+	// 后端宕机后返回缓存值并标记stale
+	backend.Stop()
+	code, resp = getCounter(t, router, "article_1")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200 with cached value, got %d", code)
+	}
+	if !resp.Stale || resp.Data.CurrentValue != 42 {
+		t.Errorf("Expected stale cached value 42, got %+v", resp)
+	}
+	if resp.Data.UpdatedAt == 0 {
+		t.Error("Expected updated_at to reflect when the value was cached")
+	}
+
+	// 未缓存过的计数器仍然返回错误
+	if code, _ := getCounter(t, router, "article_2"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for uncached counter, got %d", code)
+	}
+}
+
+func TestGetCounterDoesNotFallBackOnBusinessErrors(t *testing.T) {
+	stub := &stubCounterServer{value: 7}
+	router, _ := newFallbackTestHandler(t, stub)
+
+	if code, _ := getCounter(t, router, "article_1"); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+
+	// 业务错误不是后端故障，即使有缓存也不返回旧值
+	stub.notFound.Store(true)
+	if code, resp := getCounter(t, router, "article_1"); code != http.StatusNotFound || resp.Stale {
+		t.Errorf("Expected NotFound to bypass fallback, got %d %+v", code, resp)
+	}
+}
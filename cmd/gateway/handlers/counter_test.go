@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"high-go-press/internal/biz"
+	"high-go-press/pkg/auth"
+	"high-go-press/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetHotRank(t *testing.T) {
+	h, router, _ := newTestCounterHandler(t, &stubCounterServer{})
+	// 与网关一致：静态的hot路由与/:resource_id/:counter_type并存
+	router.GET("/counter/hot/:counter_type", h.GetHotRank)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/counter/hot/view?limit=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Period string             `json:"period"`
+		Data   []*biz.HotRankItem `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Period != "day" || len(resp.Data) != 2 {
+		t.Fatalf("Unexpected hot rank response: %s", rec.Body.String())
+	}
+	if resp.Data[0].ResourceID != "article_2" || resp.Data[0].Count != 7 || resp.Data[0].Rank != 1 || resp.Data[0].CounterType != "view" {
+		t.Errorf("Unexpected first item: %+v", resp.Data[0])
+	}
+
+	// 未开启的时间范围按gRPC状态码映射
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/counter/hot/view?period=hour", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for disabled period, got %d", rec.Code)
+	}
+}
+
+func TestCounterResponsesOmitUnknownUpdatedAt(t *testing.T) {
+	writtenAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	h, router, _ := newTestCounterHandler(t, &stubCounterServer{
+		value:     3,
+		updatedAt: map[string]time.Time{"article_2": writtenAt},
+	})
+	router.POST("/counter/batch", h.BatchGetCounters)
+
+	// 写入时间未知时不输出updated_at，而不是返回0
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/counter/article_1/like", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "updated_at") {
+		t.Errorf("Expected updated_at to be omitted, got %s", rec.Body.String())
+	}
+
+	body := `{"items":[{"resource_id":"article_1","counter_type":"like"},{"resource_id":"article_2","counter_type":"like"}]}`
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/counter/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Results []map[string]interface{} `json:"results"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Data.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %s", rec.Body.String())
+	}
+	if _, ok := resp.Data.Results[0]["updated_at"]; ok {
+		t.Errorf("Expected updated_at to be omitted for unknown write time, got %v", resp.Data.Results[0])
+	}
+	if got, ok := resp.Data.Results[1]["updated_at"].(float64); !ok || int64(got) != writtenAt.Unix() {
+		t.Errorf("Expected updated_at %d, got %v", writtenAt.Unix(), resp.Data.Results[1]["updated_at"])
+	}
+}
+
+func TestGetCounterPropagatesTenant(t *testing.T) {
+	stub := &stubCounterServer{value: 3}
+	h, _, _ := newTestCounterHandler(t, stub)
+
+	// 模拟认证中间件：带identity请求头时写入属于租户acme的调用方身份
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("identity") != "" {
+			ctx := auth.WithIdentity(c.Request.Context(), auth.Identity{Subject: "acme-client", TenantID: "acme"})
+			c.Request = c.Request.WithContext(ctx)
+		}
+		c.Next()
+	})
+	router.GET("/counter/:resource_id/:counter_type", h.GetCounter)
+
+	serve := func(headers map[string]string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/counter/article_1/like", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		tenant  string
+	}{
+		{"tenant header", map[string]string{middleware.TenantMetadataKey: "globex"}, "globex"},
+		{"identity tenant", map[string]string{"identity": "1"}, "acme"},
+		{"matching header and identity", map[string]string{"identity": "1", middleware.TenantMetadataKey: "acme"}, "acme"},
+		{"no tenant", nil, ""},
+	}
+	for _, c := range cases {
+		stub.tenant.Store("unset")
+		if code := serve(c.headers); code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", c.name, code)
+		}
+		if got := stub.tenant.Load(); got != c.tenant {
+			t.Errorf("%s: expected tenant-id %q to reach the counter service, got %q", c.name, c.tenant, got)
+		}
+	}
+
+	// 请求头与认证身份的租户不一致时不调用Counter服务
+	stub.tenant.Store("unset")
+	if code := serve(map[string]string{"identity": "1", middleware.TenantMetadataKey: "globex"}); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a tenant header not matching the identity, got %d", code)
+	}
+	if got := stub.tenant.Load(); got != "unset" {
+		t.Errorf("Expected mismatched tenant not to reach the counter service, got %q", got)
+	}
+}
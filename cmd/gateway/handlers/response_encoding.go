@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// 网关响应编码
+const (
+	EncodingJSON      = "json"      // 网关自定义的响应结构（默认）
+	EncodingProtoJSON = "protojson" // 直接以protojson编码gRPC响应消息，与gRPC契约保持一致
+)
+
+// ProtoJSONMediaType 请求Accept包含该类型时返回protojson编码，不受配置影响
+const ProtoJSONMediaType = "application/x-protojson"
+
+// protoJSONMarshaler 使用proto字段名并输出零值字段，字段集合与proto定义一致
+var protoJSONMarshaler = protojson.MarshalOptions{
+	UseProtoNames:   true,
+	EmitUnpopulated: true,
+}
+
+// SetResponseEncoding 设置默认响应编码，json或protojson
+func (h *CounterHandler) SetResponseEncoding(encoding string) {
+	h.protoJSON = encoding == EncodingProtoJSON
+}
+
+// wantsProtoJSON 判断本次请求是否返回protojson编码
+func (h *CounterHandler) wantsProtoJSON(c *gin.Context) bool {
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == ProtoJSONMediaType {
+			return true
+		}
+	}
+	return h.protoJSON
+}
+
+// respondProto 以protojson编码返回gRPC响应消息
+func respondProto(c *gin.Context, msg proto.Message) {
+	data, err := protoJSONMarshaler.Marshal(msg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"error":   "Failed to encode response",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "high-go-press/api/proto/counter"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func getCounterWithAccept(router *gin.Engine, accept string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/counter/article_1/like", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// assertProtoJSONCounter 校验响应字段与GetCounterResponse的proto字段一致
+func assertProtoJSONCounter(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	protoFields := (&pb.GetCounterResponse{}).ProtoReflect().Descriptor().Fields()
+	if len(fields) != protoFields.Len() {
+		t.Errorf("Expected %d fields, got %d: %s", protoFields.Len(), len(fields), rec.Body.String())
+	}
+	for i := 0; i < protoFields.Len(); i++ {
+		if name := string(protoFields.Get(i).Name()); fields[name] == nil {
+			t.Errorf("Expected proto field %q in response", name)
+		}
+	}
+
+	var resp pb.GetCounterResponse
+	if err := protojson.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Response is not valid protojson: %v", err)
+	}
+	if resp.ResourceId != "article_1" || resp.CounterType != "like" || resp.Value != 42 {
+		t.Errorf("Unexpected counter response: %+v", &resp)
+	}
+}
+
+func TestGetCounterProtoJSONViaAcceptHeader(t *testing.T) {
+	router, _ := newFallbackTestHandler(t, &stubCounterServer{value: 42})
+
+	assertProtoJSONCounter(t, getCounterWithAccept(router, ProtoJSONMediaType+", application/json;q=0.9"))
+
+	// 未指定时保持网关自定义结构
+	var legacy counterResponse
+	rec := getCounterWithAccept(router, "application/json")
+	if err := json.Unmarshal(rec.Body.Bytes(), &legacy); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if legacy.Status != "success" || legacy.Data.CurrentValue != 42 {
+		t.Errorf("Expected default JSON response, got %s", rec.Body.String())
+	}
+}
+
+func TestGetCounterProtoJSONViaConfig(t *testing.T) {
+	h, router, _ := newTestCounterHandler(t, &stubCounterServer{value: 42})
+	h.SetResponseEncoding(EncodingProtoJSON)
+
+	assertProtoJSONCounter(t, getCounterWithAccept(router, ""))
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+
+	"high-go-press/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+// counterContext 创建调用Counter服务的gRPC上下文，HTTP请求的租户写入tenant-id元数据
+// 租户取自认证身份或tenant-id请求头，不合法时直接返回错误响应，ok为false
+func (h *CounterHandler) counterContext(c *gin.Context) (ctx context.Context, cancel context.CancelFunc, tenantID string, ok bool) {
+	tenantID, err := middleware.HTTPTenantID(c.Request)
+	if err != nil {
+		c.JSON(middleware.HTTPStatusFromError(err), gin.H{
+			"status":  "error",
+			"error":   "Invalid tenant",
+			"details": err.Error(),
+		})
+		return nil, nil, "", false
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), h.timeout)
+	if tenantID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, middleware.TenantMetadataKey, tenantID)
+	}
+	return ctx, cancel, tenantID, true
+}
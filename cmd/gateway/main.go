@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"os/signal"
 	"syscall"
 	"time"
 
@@ -13,28 +12,42 @@ import (
 	"go.uber.org/zap"
 
 	"high-go-press/cmd/gateway/handlers"
+	"high-go-press/internal/dao"
 	"high-go-press/internal/gateway/service"
+	"high-go-press/pkg/auth"
 	"high-go-press/pkg/config"
+	resilience "high-go-press/pkg/grpc"
 	"high-go-press/pkg/logger"
 	"high-go-press/pkg/metrics"
 	"high-go-press/pkg/middleware"
 	"high-go-press/pkg/pool"
 	"high-go-press/pkg/pprof"
+	"high-go-press/pkg/quota"
+	"high-go-press/pkg/shutdown"
+	"high-go-press/pkg/stats"
 )
 
 func main() {
+	// 服务启动或运行失败时以非0状态码退出，run中的defer已完成清理
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run 启动Gateway服务并阻塞到收到退出信号或服务失败，服务失败时返回错误
+func run() error {
 	// 初始化配置
 	cfg, err := config.Load("configs/config.yaml")
 	if err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
 	// 初始化日志
 	log, err := logger.NewLogger(cfg.Log.Level, cfg.Log.Format)
 	if err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 	defer log.Sync()
 
@@ -42,6 +55,9 @@ func main() {
 		zap.String("host", cfg.Gateway.Server.Host),
 		zap.Int("port", cfg.Gateway.Server.Port))
 
+	// 退出时输出运行汇总
+	shutdownReporter := shutdown.NewReporter("gateway", log)
+
 	// 初始化指标管理器
 	var metricsManager *metrics.MetricsManager
 	if cfg.Monitoring.Prometheus.Enabled {
@@ -57,6 +73,7 @@ func main() {
 
 		// 设置服务健康状态
 		metricsManager.SetServiceHealth("gateway", "main", true)
+		shutdownReporter.SetMetricsManager(metricsManager)
 
 		log.Info("✅ Metrics manager initialized",
 			zap.String("namespace", metricsConfig.Namespace),
@@ -65,20 +82,29 @@ func main() {
 
 	// 初始化Object Pool (仍需要用于请求对象复用)
 	objectPool := pool.NewObjectPool()
+	if metricsManager != nil {
+		if err := objectPool.SetMetricsManager(metricsManager, "gateway"); err != nil {
+			log.Warn("Failed to register object pool metrics", zap.Error(err))
+		}
+	}
 
 	// 初始化微服务管理器
 	log.Info("🔧 Initializing ServiceManager...",
 		zap.String("consul_address", "localhost:8500"))
 
 	serviceConfig := &service.Config{
-		ConsulAddress:        "localhost:8500",
-		TimeoutDuration:      5 * time.Second,
-		MaxRecvMsgSize:       1024 * 1024 * 4,  // 4MB
-		MaxSendMsgSize:       1024 * 1024 * 4,  // 4MB
-		KeepAliveTime:        30 * time.Second, // 30秒keep-alive
-		KeepAliveTimeout:     5 * time.Second,  // 5秒超时
-		CounterServiceName:   "high-go-press-counter",
-		AnalyticsServiceName: "high-go-press-analytics",
+		ConsulAddress:            "localhost:8500",
+		ConsulTimeout:            cfg.Discovery.Consul.Timeout,
+		DiscoveryStaleThreshold:  cfg.Discovery.StaleThreshold,
+		DiscoveryRefreshInterval: cfg.Discovery.RefreshInterval,
+		ServiceRefreshIntervals:  serviceRefreshIntervals(cfg.Discovery.Services),
+		TimeoutDuration:          5 * time.Second,
+		MaxRecvMsgSize:           1024 * 1024 * 4,  // 4MB
+		MaxSendMsgSize:           1024 * 1024 * 4,  // 4MB
+		KeepAliveTime:            30 * time.Second, // 30秒keep-alive
+		KeepAliveTimeout:         5 * time.Second,  // 5秒超时
+		CounterServiceName:       "high-go-press-counter",
+		AnalyticsServiceName:     "high-go-press-analytics",
 	}
 
 	log.Info("🔧 Creating ServiceManager with config...",
@@ -87,7 +113,8 @@ func main() {
 
 	serviceManager, err := service.NewServiceManager(serviceConfig, log)
 	if err != nil {
-		log.Fatal("Failed to initialize service manager", zap.Error(err))
+		log.Error("Failed to initialize service manager", zap.Error(err))
+		return err
 	}
 	defer serviceManager.Close()
 
@@ -100,25 +127,74 @@ func main() {
 	// 使用ServiceManager创建Counter处理器，不再使用独立的连接池
 	counterHandler := handlers.NewCounterHandlerWithServiceManager(serviceManager, objectPool)
 
+	counterHandler.SetResponseEncoding(cfg.Gateway.Response.Encoding)
+
+	// 读降级：Counter服务不可用时GetCounter返回缓存的旧值
+	if fallback := cfg.Resilience.Fallback; fallback.Enabled && fallback.Strategy == "cache" {
+		counterHandler.SetFallbackCache(resilience.NewCacheFallbackHandler(fallback.CacheTTL, fallback.CacheMaxEntries, log))
+		log.Info("✅ Counter read fallback cache enabled",
+			zap.Duration("cache_ttl", fallback.CacheTTL),
+			zap.Int("cache_max_entries", fallback.CacheMaxEntries))
+	}
+
 	// 创建Gin路由器
 	if cfg.Gateway.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
+	// 只采用可信代理转发的X-Forwarded-For，未配置时c.ClientIP()使用连接地址
+	if err := router.SetTrustedProxies(cfg.Gateway.Server.TrustedProxies); err != nil {
+		log.Error("Failed to set trusted proxies", zap.Error(err))
+		return err
+	}
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	// 启动就绪门：Counter服务就绪前只响应/livez，其余请求返回503
+	var readinessGate *middleware.ReadinessGate
+	if cfg.Gateway.Startup.WaitForServices {
+		readinessGate = middleware.NewReadinessGate(&middleware.ReadinessGateConfig{
+			Timeout: cfg.Gateway.Startup.ReadyTimeout,
+		}, log)
+		router.Use(readinessGate.Middleware())
+	}
+	router.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive"})
+	})
+
 	// 添加指标收集中间件
 	if metricsManager != nil {
 		router.Use(middleware.HTTPMetricsMiddleware(metricsManager, "gateway"))
 		log.Info("✅ HTTP metrics middleware enabled")
 	}
 
-	// 添加pprof路由（开发环境）
+	// 认证：除skip_paths外的请求必须通过认证
+	if cfg.Auth.Enabled {
+		authenticator, err := auth.NewAuthenticator(&cfg.Auth)
+		if err != nil {
+			log.Error("Failed to create authenticator", zap.Error(err))
+			return err
+		}
+		router.Use(middleware.AuthMiddleware(authenticator, &middleware.AuthConfig{SkipPaths: cfg.Auth.SkipPaths}, log))
+		log.Info("✅ Authentication enabled", zap.String("provider", cfg.Auth.Provider))
+	}
+
+	// 添加pprof和统一组件统计路由（开发环境）
 	if cfg.Gateway.Server.Mode != "release" {
 		pprof.AddPprofRoutes(router)
 		log.Info("Pprof routes enabled", zap.String("path", "/debug/pprof"))
+
+		statsCollector := stats.NewCollector()
+		if err := statsCollector.RegisterAll(map[string]stats.StatsProvider{
+			"object_pool": objectPool,
+			"discovery":   serviceManager.DiscoveryManager(),
+		}); err != nil {
+			log.Error("Failed to register stats providers", zap.Error(err))
+			return err
+		}
+		router.GET("/debug/stats", gin.WrapH(statsCollector))
+		log.Info("Stats endpoint enabled", zap.String("path", "/debug/stats"))
 	}
 
 	// 添加指标暴露端点
@@ -128,16 +204,30 @@ func main() {
 			zap.String("path", cfg.Monitoring.Prometheus.Path))
 	}
 
+	// 每日配额：按认证后的调用方限制增量请求
+	incrementHandlers := []gin.HandlerFunc{counterHandler.IncrementCounter}
+	if cfg.Quota.Enabled {
+		quotaService, err := newQuotaService(cfg, log)
+		if err != nil {
+			log.Error("Failed to create quota service", zap.Error(err))
+			return err
+		}
+		incrementHandlers = append([]gin.HandlerFunc{middleware.QuotaMiddleware(quotaService, log)}, incrementHandlers...)
+		log.Info("✅ Daily quota enabled", zap.String("default_tier", cfg.Quota.DefaultTier))
+	}
+
 	// API路由 - 保持现有API接口不变
 	v1 := router.Group("/api/v1")
 	{
 		// 健康检查
 		v1.GET("/health", healthHandler.HealthCheck)
+		v1.GET("/ready", counterHandler.Readiness)
 
 		// 计数器相关 - 现在转发到Counter微服务
 		counterGroup := v1.Group("/counter")
 		{
-			counterGroup.POST("/increment", counterHandler.IncrementCounter)
+			counterGroup.POST("/increment", incrementHandlers...)
+			counterGroup.GET("/hot/:counter_type", counterHandler.GetHotRank)
 			counterGroup.GET("/:resource_id/:counter_type", counterHandler.GetCounter)
 			counterGroup.POST("/batch", counterHandler.BatchGetCounters)
 		}
@@ -147,10 +237,10 @@ func main() {
 		{
 			// 对象池统计
 			systemGroup.GET("/object-pools", func(c *gin.Context) {
-				stats := objectPool.GetStats()
+				poolStats := objectPool.GetStats()
 				c.JSON(http.StatusOK, gin.H{
 					"status": "success",
-					"data":   stats,
+					"data":   poolStats,
 				})
 			})
 
@@ -193,6 +283,15 @@ func main() {
 				})
 			})
 
+			// 负载评分 - 供外部自动扩缩容使用
+			loadScorer := metrics.NewLoadScorer(metricsManager, "gateway", &metrics.LoadScoreConfig{
+				MaxInFlight:       cfg.Monitoring.LoadScore.MaxInFlight,
+				InFlightWeight:    cfg.Monitoring.LoadScore.InFlightWeight,
+				UtilizationWeight: cfg.Monitoring.LoadScore.UtilizationWeight,
+				ErrorRateWeight:   cfg.Monitoring.LoadScore.ErrorRateWeight,
+			})
+			systemGroup.GET("/load", gin.WrapH(loadScorer))
+
 			// 指标统计端点
 			if metricsManager != nil {
 				systemGroup.GET("/metrics/stats", func(c *gin.Context) {
@@ -209,6 +308,9 @@ func main() {
 		}
 	}
 
+	// 启动或运行失败的服务通知主goroutine
+	servers := shutdown.NewServerGroup(log)
+
 	// 启动指标服务器（独立端口）
 	var metricsServer *http.Server
 	if metricsManager != nil && cfg.Monitoring.Prometheus.Port != cfg.Gateway.Server.Port {
@@ -220,14 +322,10 @@ func main() {
 			Handler: metricsRouter,
 		}
 
-		go func() {
-			log.Info("Metrics server starting",
-				zap.Int("port", cfg.Monitoring.Prometheus.Port),
-				zap.String("path", cfg.Monitoring.Prometheus.Path))
-			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				log.Error("Metrics server error", zap.Error(err))
-			}
-		}()
+		log.Info("Metrics server starting",
+			zap.Int("port", cfg.Monitoring.Prometheus.Port),
+			zap.String("path", cfg.Monitoring.Prometheus.Path))
+		servers.Go("metrics", metricsServer.ListenAndServe)
 	}
 
 	// 启动HTTP服务器
@@ -237,19 +335,20 @@ func main() {
 	}
 
 	// 启动服务器
-	go func() {
-		log.Info("Gateway server starting",
-			zap.String("addr", server.Addr),
-			zap.String("mode", "microservices"))
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Failed to start server", zap.Error(err))
-		}
-	}()
+	log.Info("Gateway server starting",
+		zap.String("addr", server.Addr),
+		zap.String("mode", "microservices"))
+	servers.Go("http", server.ListenAndServe)
+
+	// 等待Counter服务就绪后放行全部路由
+	readyCtx, cancelReady := context.WithCancel(context.Background())
+	defer cancelReady()
+	if readinessGate != nil {
+		go readinessGate.Wait(readyCtx, serviceManager.CounterReady)
+	}
 
-	// 等待中断信号
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// 等待中断信号或服务失败
+	serveErr := servers.Wait(syscall.SIGINT, syscall.SIGTERM)
 
 	log.Info("Shutting down Gateway server...")
 
@@ -259,7 +358,7 @@ func main() {
 
 	// 关闭主服务器
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown", zap.Error(err))
+		log.Error("Server forced to shutdown", zap.Error(err))
 	}
 
 	// 关闭指标服务器
@@ -276,5 +375,35 @@ func main() {
 		}
 	}
 
+	shutdownReporter.Report()
+	if serveErr != nil {
+		log.Error("Gateway server exited after server failure", zap.Error(serveErr))
+		return serveErr
+	}
 	log.Info("Gateway server exited")
+	return nil
+}
+
+// serviceRefreshIntervals 提取按服务配置的发现刷新间隔
+func serviceRefreshIntervals(services map[string]config.ServiceDiscoveryConfig) map[string]time.Duration {
+	intervals := make(map[string]time.Duration, len(services))
+	for name, svc := range services {
+		if svc.RefreshInterval > 0 {
+			intervals[name] = svc.RefreshInterval
+		}
+	}
+	return intervals
+}
+
+// newQuotaService 根据配置创建基于Redis的配额服务
+func newQuotaService(cfg *config.Config, log *zap.Logger) (*quota.Service, error) {
+	quotaConfig, err := quota.ConfigFromAppConfig(cfg.Quota)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dao.NewRedisClient(cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+	return quota.NewService(client, quotaConfig, log), nil
 }
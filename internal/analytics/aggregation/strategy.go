@@ -0,0 +1,316 @@
+package aggregation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"high-go-press/pkg/kafka"
+
+	"go.uber.org/zap"
+)
+
+// 聚合策略名称
+const (
+	StrategyRaw         = "raw"          // 每个事件立即写入
+	StrategyRateLimited = "rate_limited" // 按key限制写入频率，超出部分累积到下次写入
+	StrategyWindowed    = "windowed"     // 按时间窗口聚合后批量写入
+)
+
+// StatsUpdater 统计数据写入函数，与AnalyticsDAO.UpdateCounterStats签名一致
+type StatsUpdater func(ctx context.Context, resourceID, counterType string, delta int64) error
+
+// AggregationStrategy 事件聚合策略
+//
+// 非raw策略会先缓存增量再写入，Apply返回nil只表示事件已被接收；
+// 配合effectively_once使用时，进程在Flush前退出会丢失缓存中的增量。
+type AggregationStrategy interface {
+	// Name 策略名称
+	Name() string
+	// Apply 处理一个事件，按策略立即写入或缓存
+	Apply(ctx context.Context, event *kafka.CounterEvent) error
+	// Flush 将缓存中的增量全部写入
+	Flush(ctx context.Context) error
+}
+
+// Config 聚合策略配置
+type Config struct {
+	Strategy      string        // raw, rate_limited, windowed
+	Window        time.Duration // windowed策略的聚合窗口
+	RatePerSecond int           // rate_limited策略每个key每秒最多写入次数
+}
+
+// DefaultConfig 默认聚合策略配置
+func DefaultConfig() *Config {
+	return &Config{
+		Strategy:      StrategyRaw,
+		Window:        time.Second,
+		RatePerSecond: 10,
+	}
+}
+
+// NewStrategy 根据配置创建聚合策略
+func NewStrategy(config *Config, updater StatsUpdater, logger *zap.Logger) (AggregationStrategy, error) {
+	defaults := DefaultConfig()
+	if config == nil {
+		config = defaults
+	}
+
+	switch config.Strategy {
+	case "", StrategyRaw:
+		return NewRawStrategy(updater), nil
+	case StrategyRateLimited:
+		rate := config.RatePerSecond
+		if rate <= 0 {
+			rate = defaults.RatePerSecond
+		}
+		return NewRateLimitedStrategy(updater, rate, logger), nil
+	case StrategyWindowed:
+		window := config.Window
+		if window <= 0 {
+			window = defaults.Window
+		}
+		return NewWindowedStrategy(updater, window, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown aggregation strategy: %s", config.Strategy)
+	}
+}
+
+// RunFlushLoop 按固定间隔刷新策略缓存，ctx取消时执行最后一次刷新
+func RunFlushLoop(ctx context.Context, strategy AggregationStrategy, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := strategy.Flush(ctx); err != nil {
+				logger.Error("Failed to flush aggregated stats",
+					zap.String("strategy", strategy.Name()),
+					zap.Error(err))
+			}
+		case <-ctx.Done():
+			// 使用新的context完成最后一次刷新
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := strategy.Flush(flushCtx); err != nil {
+				logger.Error("Failed to flush aggregated stats on shutdown",
+					zap.String("strategy", strategy.Name()),
+					zap.Error(err))
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// statsKey 聚合key
+type statsKey struct {
+	resourceID  string
+	counterType string
+}
+
+// RawStrategy 原始策略 - 每个事件立即写入
+type RawStrategy struct {
+	updater StatsUpdater
+}
+
+// NewRawStrategy 创建原始策略
+func NewRawStrategy(updater StatsUpdater) *RawStrategy {
+	return &RawStrategy{updater: updater}
+}
+
+// Name 策略名称
+func (s *RawStrategy) Name() string {
+	return StrategyRaw
+}
+
+// Apply 立即写入事件增量
+func (s *RawStrategy) Apply(ctx context.Context, event *kafka.CounterEvent) error {
+	return s.updater(ctx, event.ResourceID, event.CounterType, event.Delta)
+}
+
+// Flush 原始策略没有缓存
+func (s *RawStrategy) Flush(ctx context.Context) error {
+	return nil
+}
+
+// pendingDelta 待写入的增量
+type pendingDelta struct {
+	delta       int64
+	lastWritten time.Time
+}
+
+// RateLimitedStrategy 限频策略 - 每个key在1/RatePerSecond间隔内最多写入一次，
+// 间隔内的事件增量累积到下一次写入，不会丢失
+type RateLimitedStrategy struct {
+	updater  StatsUpdater
+	interval time.Duration
+	logger   *zap.Logger
+
+	pending map[statsKey]*pendingDelta
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// NewRateLimitedStrategy 创建限频策略
+func NewRateLimitedStrategy(updater StatsUpdater, ratePerSecond int, logger *zap.Logger) *RateLimitedStrategy {
+	return &RateLimitedStrategy{
+		updater:  updater,
+		interval: time.Second / time.Duration(ratePerSecond),
+		logger:   logger,
+		pending:  make(map[statsKey]*pendingDelta),
+		now:      time.Now,
+	}
+}
+
+// Name 策略名称
+func (s *RateLimitedStrategy) Name() string {
+	return StrategyRateLimited
+}
+
+// Apply 未超过频率时立即写入（包含累积的增量），否则累积
+func (s *RateLimitedStrategy) Apply(ctx context.Context, event *kafka.CounterEvent) error {
+	key := statsKey{resourceID: event.ResourceID, counterType: event.CounterType}
+
+	s.mu.Lock()
+	now := s.now()
+	p, exists := s.pending[key]
+	if !exists {
+		p = &pendingDelta{}
+		s.pending[key] = p
+	}
+	p.delta += event.Delta
+
+	if exists && now.Sub(p.lastWritten) < s.interval {
+		s.mu.Unlock()
+		return nil
+	}
+
+	delta := p.delta
+	p.delta = 0
+	p.lastWritten = now
+	s.mu.Unlock()
+
+	if err := s.updater(ctx, key.resourceID, key.counterType, delta); err != nil {
+		s.restore(key, delta)
+		return err
+	}
+	return nil
+}
+
+// Flush 写入所有累积的增量
+func (s *RateLimitedStrategy) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	now := s.now()
+	batch := make(map[statsKey]int64)
+	for key, p := range s.pending {
+		if p.delta != 0 {
+			batch[key] = p.delta
+			p.delta = 0
+			p.lastWritten = now
+		}
+	}
+	s.mu.Unlock()
+
+	return flushBatch(ctx, batch, s.updater, s.restore)
+}
+
+// restore 写入失败时将增量放回缓存
+func (s *RateLimitedStrategy) restore(key statsKey, delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, exists := s.pending[key]
+	if !exists {
+		p = &pendingDelta{}
+		s.pending[key] = p
+	}
+	p.delta += delta
+}
+
+// WindowedStrategy 窗口聚合策略 - 窗口内同一key的增量合并，窗口结束后一次写入
+type WindowedStrategy struct {
+	updater StatsUpdater
+	window  time.Duration
+	logger  *zap.Logger
+
+	deltas      map[statsKey]int64
+	windowStart time.Time
+	now         func() time.Time
+	mu          sync.Mutex
+}
+
+// NewWindowedStrategy 创建窗口聚合策略
+func NewWindowedStrategy(updater StatsUpdater, window time.Duration, logger *zap.Logger) *WindowedStrategy {
+	return &WindowedStrategy{
+		updater: updater,
+		window:  window,
+		logger:  logger,
+		deltas:  make(map[statsKey]int64),
+		now:     time.Now,
+	}
+}
+
+// Name 策略名称
+func (s *WindowedStrategy) Name() string {
+	return StrategyWindowed
+}
+
+// Apply 累积事件增量，窗口结束时写入
+func (s *WindowedStrategy) Apply(ctx context.Context, event *kafka.CounterEvent) error {
+	s.mu.Lock()
+	now := s.now()
+	if len(s.deltas) == 0 {
+		s.windowStart = now
+	}
+	s.deltas[statsKey{resourceID: event.ResourceID, counterType: event.CounterType}] += event.Delta
+	expired := now.Sub(s.windowStart) >= s.window
+	s.mu.Unlock()
+
+	if expired {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush 写入当前窗口的聚合结果并开启新窗口
+func (s *WindowedStrategy) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	batch := s.deltas
+	s.deltas = make(map[statsKey]int64)
+	s.mu.Unlock()
+
+	if len(batch) > 0 {
+		s.logger.Debug("Flushing windowed stats", zap.Int("keys", len(batch)))
+	}
+	return flushBatch(ctx, batch, s.updater, s.restore)
+}
+
+// restore 写入失败时将增量放回缓存
+func (s *WindowedStrategy) restore(key statsKey, delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.deltas) == 0 {
+		s.windowStart = s.now()
+	}
+	s.deltas[key] += delta
+}
+
+// flushBatch 写入一批聚合增量，失败的增量放回缓存，返回第一个错误
+func flushBatch(ctx context.Context, batch map[statsKey]int64, updater StatsUpdater, restore func(statsKey, int64)) error {
+	var firstErr error
+	for key, delta := range batch {
+		if delta == 0 {
+			continue
+		}
+		if err := updater(ctx, key.resourceID, key.counterType, delta); err != nil {
+			restore(key, delta)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to flush %s:%s: %w", key.resourceID, key.counterType, err)
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,168 @@
+package aggregation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/kafka"
+
+	"go.uber.org/zap"
+)
+
+// recordingUpdater 记录所有写入调用
+type recordingUpdater struct {
+	mu     sync.Mutex
+	calls  int
+	totals map[string]int64
+	err    error
+}
+
+func newRecordingUpdater() *recordingUpdater {
+	return &recordingUpdater{totals: make(map[string]int64)}
+}
+
+func (u *recordingUpdater) update(ctx context.Context, resourceID, counterType string, delta int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.err != nil {
+		return u.err
+	}
+	u.calls++
+	u.totals[resourceID+":"+counterType] += delta
+	return nil
+}
+
+// sampleStream 示例事件流：两个资源交替产生事件，article_1共10次+1，article_2共5次+2
+func sampleStream() []*kafka.CounterEvent {
+	var events []*kafka.CounterEvent
+	for i := 0; i < 10; i++ {
+		events = append(events, &kafka.CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: 1})
+		if i%2 == 0 {
+			events = append(events, &kafka.CounterEvent{ResourceID: "article_2", CounterType: "view", Delta: 2})
+		}
+	}
+	return events
+}
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestRawStrategyAppliesEveryEvent(t *testing.T) {
+	updater := newRecordingUpdater()
+	strategy, err := NewStrategy(&Config{Strategy: StrategyRaw}, updater.update, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewStrategy failed: %v", err)
+	}
+
+	events := sampleStream()
+	for _, event := range events {
+		if err := strategy.Apply(context.Background(), event); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+
+	if updater.calls != len(events) {
+		t.Errorf("Expected %d writes, got %d", len(events), updater.calls)
+	}
+	if updater.totals["article_1:like"] != 10 || updater.totals["article_2:view"] != 10 {
+		t.Errorf("Unexpected totals: %v", updater.totals)
+	}
+}
+
+func TestWindowedStrategyAggregatesPerWindow(t *testing.T) {
+	updater := newRecordingUpdater()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	strategy := NewWindowedStrategy(updater.update, time.Second, zap.NewNop())
+	strategy.now = clock.Now
+
+	// 第一个窗口内的事件只缓存不写入
+	for _, event := range sampleStream() {
+		if err := strategy.Apply(context.Background(), event); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		clock.now = clock.now.Add(10 * time.Millisecond)
+	}
+	if updater.calls != 0 {
+		t.Fatalf("Expected no writes within window, got %d", updater.calls)
+	}
+
+	// 窗口结束后的首个事件触发写入，每个key合并为一次写入
+	clock.now = clock.now.Add(time.Second)
+	if err := strategy.Apply(context.Background(), &kafka.CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: 1}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if updater.calls != 2 {
+		t.Errorf("Expected 2 aggregated writes, got %d", updater.calls)
+	}
+	if updater.totals["article_1:like"] != 11 || updater.totals["article_2:view"] != 10 {
+		t.Errorf("Unexpected totals: %v", updater.totals)
+	}
+
+	// Flush写入剩余增量
+	if err := strategy.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if updater.calls != 2 {
+		t.Errorf("Expected no further writes for empty window, got %d", updater.calls)
+	}
+}
+
+func TestWindowedStrategyRetainsDeltaOnFailure(t *testing.T) {
+	updater := newRecordingUpdater()
+	strategy := NewWindowedStrategy(updater.update, time.Minute, zap.NewNop())
+
+	for _, event := range sampleStream() {
+		_ = strategy.Apply(context.Background(), event)
+	}
+
+	// 写入失败时增量保留，下一次Flush重试
+	updater.err = errors.New("storage unavailable")
+	if err := strategy.Flush(context.Background()); err == nil {
+		t.Fatal("Expected flush error")
+	}
+	updater.err = nil
+	if err := strategy.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if updater.totals["article_1:like"] != 10 || updater.totals["article_2:view"] != 10 {
+		t.Errorf("Unexpected totals after retry: %v", updater.totals)
+	}
+}
+
+func TestRateLimitedStrategyAccumulates(t *testing.T) {
+	updater := newRecordingUpdater()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	strategy := NewRateLimitedStrategy(updater.update, 10, zap.NewNop())
+	strategy.now = clock.Now
+
+	// 每个key在100ms内最多写入一次
+	for _, event := range sampleStream() {
+		if err := strategy.Apply(context.Background(), event); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		clock.now = clock.now.Add(10 * time.Millisecond)
+	}
+	if updater.calls >= len(sampleStream()) {
+		t.Errorf("Expected writes to be limited, got %d", updater.calls)
+	}
+
+	if err := strategy.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if updater.totals["article_1:like"] != 10 || updater.totals["article_2:view"] != 10 {
+		t.Errorf("Expected no delta lost, got %v", updater.totals)
+	}
+}
+
+func TestNewStrategyUnknown(t *testing.T) {
+	if _, err := NewStrategy(&Config{Strategy: "median"}, newRecordingUpdater().update, zap.NewNop()); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
@@ -2,6 +2,10 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -47,9 +51,13 @@ type AnalyticsDAO interface {
 }
 
 // MemoryAnalyticsDAO 内存版本DAO（用于开发测试）
+// 所有方法在执行前检查ctx，已取消的请求（如消费者再均衡时）不再修改数据
+// 默认只返回UpdateCounterStats累积的真实数据，演示用的模拟数据需显式开启
 type MemoryAnalyticsDAO struct {
 	counters   map[string]*CounterItem
 	timeSeries map[string][]TimeSeriesPoint
+	mockData   bool // 返回固定的模拟排行榜和统计数据，仅用于本地开发演示
+	mu         sync.RWMutex
 }
 
 // NewMemoryAnalyticsDAO 创建内存版DAO
@@ -60,9 +68,123 @@ func NewMemoryAnalyticsDAO() *MemoryAnalyticsDAO {
 	}
 }
 
-// GetTopCounters 获取热门计数器排行榜
+// SetMockData 开启后GetTopCounters/GetCounterStats忽略真实数据返回固定的模拟数据，只应在开发环境使用
+func (dao *MemoryAnalyticsDAO) SetMockData(enabled bool) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+	dao.mockData = enabled
+}
+
+// mockDataEnabled 是否返回模拟数据
+func (dao *MemoryAnalyticsDAO) mockDataEnabled() bool {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+	return dao.mockData
+}
+
+// ErrInvalidTimeRange 时间范围不是正的Go duration（如"1h"、"168h"）
+var ErrInvalidTimeRange = errors.New("invalid time range")
+
+// timeRangeStart 时间范围的起始时间，timeRange为空时返回零值表示不限制
+// 无法解析的时间范围（如"7d"）返回ErrInvalidTimeRange，而不是当作不限制
+func timeRangeStart(timeRange string, now time.Time) (time.Time, error) {
+	if timeRange == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.ParseDuration(timeRange)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidTimeRange, timeRange)
+	}
+	return now.Add(-d), nil
+}
+
+// GetTopCounters 获取热门计数器排行榜，按计数值从高到低排序，只包含时间范围内有更新的计数器
 func (dao *MemoryAnalyticsDAO) GetTopCounters(ctx context.Context, counterType, timeRange string, limit int) ([]*CounterItem, error) {
-	// 模拟热门数据
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	since, err := timeRangeStart(timeRange, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if dao.mockDataEnabled() {
+		return mockTopCounters(counterType, limit), nil
+	}
+
+	dao.mu.RLock()
+	counters := make([]*CounterItem, 0, len(dao.counters))
+	for _, counter := range dao.counters {
+		if counter.CounterType != counterType || counter.LastUpdated.Before(since) {
+			continue
+		}
+		copied := *counter
+		counters = append(counters, &copied)
+	}
+	dao.mu.RUnlock()
+
+	sort.Slice(counters, func(i, j int) bool {
+		if counters[i].Value != counters[j].Value {
+			return counters[i].Value > counters[j].Value
+		}
+		return counters[i].ResourceID < counters[j].ResourceID
+	})
+
+	if limit > 0 && limit < len(counters) {
+		counters = counters[:limit]
+	}
+	return counters, nil
+}
+
+// GetCounterStats 获取计数器统计信息，没有数据的计数器返回零值统计
+// Total为累计值，TimeSeries为时间范围内的增量数据点，Average和Peak按这些数据点计算
+func (dao *MemoryAnalyticsDAO) GetCounterStats(ctx context.Context, resourceID, counterType, timeRange string) (*CounterStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	since, err := timeRangeStart(timeRange, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if dao.mockDataEnabled() {
+		return mockCounterStats(resourceID, counterType), nil
+	}
+
+	key := resourceID + ":" + counterType
+	stats := &CounterStats{
+		ResourceID:  resourceID,
+		CounterType: counterType,
+		TimeSeries:  []TimeSeriesPoint{},
+	}
+
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	counter, exists := dao.counters[key]
+	if !exists {
+		return stats, nil
+	}
+	stats.Total = counter.Value
+	stats.LastUpdated = counter.LastUpdated
+
+	var sum float64
+	for _, point := range dao.timeSeries[key+":timeseries"] {
+		if point.Timestamp.Before(since) {
+			continue
+		}
+		stats.TimeSeries = append(stats.TimeSeries, point)
+		sum += point.Value
+		if int64(point.Value) > stats.Peak {
+			stats.Peak = int64(point.Value)
+		}
+	}
+	if len(stats.TimeSeries) > 0 {
+		stats.Average = sum / float64(len(stats.TimeSeries))
+	}
+	return stats, nil
+}
+
+// mockTopCounters 固定的模拟排行榜数据
+func mockTopCounters(counterType string, limit int) []*CounterItem {
 	mockCounters := []*CounterItem{
 		{
 			ResourceID:     "article_123",
@@ -102,15 +224,13 @@ func (dao *MemoryAnalyticsDAO) GetTopCounters(ctx context.Context, counterType,
 	}
 
 	if limit > 0 && limit < len(mockCounters) {
-		return mockCounters[:limit], nil
+		return mockCounters[:limit]
 	}
-
-	return mockCounters, nil
+	return mockCounters
 }
 
-// GetCounterStats 获取计数器统计信息
-func (dao *MemoryAnalyticsDAO) GetCounterStats(ctx context.Context, resourceID, counterType, timeRange string) (*CounterStats, error) {
-	// 模拟统计数据
+// mockCounterStats 固定的模拟统计数据
+func mockCounterStats(resourceID, counterType string) *CounterStats {
 	now := time.Now()
 	timeSeries := []TimeSeriesPoint{
 		{Timestamp: now.Add(-4 * time.Hour), Value: 100},
@@ -128,13 +248,20 @@ func (dao *MemoryAnalyticsDAO) GetCounterStats(ctx context.Context, resourceID,
 		Peak:        1000,
 		TimeSeries:  timeSeries,
 		LastUpdated: now,
-	}, nil
+	}
 }
 
 // UpdateCounterStats 更新计数器统计数据
 func (dao *MemoryAnalyticsDAO) UpdateCounterStats(ctx context.Context, resourceID, counterType string, delta int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	key := resourceID + ":" + counterType
 
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
 	if counter, exists := dao.counters[key]; exists {
 		counter.Value += delta
 		counter.IncrementCount++
@@ -161,10 +288,17 @@ func (dao *MemoryAnalyticsDAO) UpdateCounterStats(ctx context.Context, resourceI
 
 // GetCounterHistory 获取计数器历史数据
 func (dao *MemoryAnalyticsDAO) GetCounterHistory(ctx context.Context, resourceID, counterType, timeRange string) ([]TimeSeriesPoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	key := resourceID + ":" + counterType + ":timeseries"
 
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
 	if series, exists := dao.timeSeries[key]; exists {
-		return series, nil
+		return append([]TimeSeriesPoint(nil), series...), nil
 	}
 
 	return []TimeSeriesPoint{}, nil
@@ -0,0 +1,141 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryAnalyticsDAOCancelledContext(t *testing.T) {
+	d := NewMemoryAnalyticsDAO()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := d.UpdateCounterStats(ctx, "article_1", "like", 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from UpdateCounterStats, got %v", err)
+	}
+	if _, err := d.GetTopCounters(ctx, "like", "1h", 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from GetTopCounters, got %v", err)
+	}
+	if _, err := d.GetCounterStats(ctx, "article_1", "like", "1h"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from GetCounterStats, got %v", err)
+	}
+	if _, err := d.GetCounterHistory(ctx, "article_1", "like", "1h"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from GetCounterHistory, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected cancelled operations to return promptly, took %v", elapsed)
+	}
+
+	// 已取消的更新不应修改数据
+	history, err := d.GetCounterHistory(context.Background(), "article_1", "like", "1h")
+	if err != nil {
+		t.Fatalf("GetCounterHistory failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected no data written by cancelled update, got %d points", len(history))
+	}
+}
+
+func TestMemoryAnalyticsDAODeadlineExceeded(t *testing.T) {
+	d := NewMemoryAnalyticsDAO()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if err := d.UpdateCounterStats(ctx, "article_1", "like", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := d.UpdateCounterStats(context.Background(), "article_1", "like", 1); err != nil {
+		t.Errorf("Expected update with live context to succeed, got %v", err)
+	}
+}
+
+func TestMemoryAnalyticsDAOReturnsNoMockDataByDefault(t *testing.T) {
+	d := NewMemoryAnalyticsDAO()
+	ctx := context.Background()
+
+	counters, err := d.GetTopCounters(ctx, "like", "24h", 10)
+	if err != nil {
+		t.Fatalf("GetTopCounters failed: %v", err)
+	}
+	if len(counters) != 0 {
+		t.Errorf("Expected no counters from an empty DAO, got %d", len(counters))
+	}
+
+	stats, err := d.GetCounterStats(ctx, "article_123", "like", "24h")
+	if err != nil {
+		t.Fatalf("GetCounterStats failed: %v", err)
+	}
+	if stats.Total != 0 || stats.Peak != 0 || len(stats.TimeSeries) != 0 {
+		t.Errorf("Expected empty stats from an empty DAO, got %+v", stats)
+	}
+
+	// 开启模拟数据后才返回固定数据
+	d.SetMockData(true)
+	if counters, _ := d.GetTopCounters(ctx, "like", "24h", 3); len(counters) != 3 {
+		t.Errorf("Expected 3 mock counters with mock data enabled, got %d", len(counters))
+	}
+}
+
+func TestMemoryAnalyticsDAOReturnsAccumulatedData(t *testing.T) {
+	d := NewMemoryAnalyticsDAO()
+	ctx := context.Background()
+
+	updates := []struct {
+		resourceID, counterType string
+		delta                   int64
+	}{
+		{"article_1", "like", 2},
+		{"article_1", "like", 5},
+		{"article_2", "like", 3},
+		{"article_3", "view", 100},
+	}
+	for _, u := range updates {
+		if err := d.UpdateCounterStats(ctx, u.resourceID, u.counterType, u.delta); err != nil {
+			t.Fatalf("UpdateCounterStats failed: %v", err)
+		}
+	}
+
+	// 只包含请求的类型，按计数值从高到低
+	counters, err := d.GetTopCounters(ctx, "like", "", 10)
+	if err != nil {
+		t.Fatalf("GetTopCounters failed: %v", err)
+	}
+	if len(counters) != 2 || counters[0].ResourceID != "article_1" || counters[0].Value != 7 || counters[1].ResourceID != "article_2" {
+		t.Errorf("Unexpected top counters: %+v %+v", counters[0], counters[len(counters)-1])
+	}
+	if limited, _ := d.GetTopCounters(ctx, "like", "24h", 1); len(limited) != 1 {
+		t.Errorf("Expected limit to apply, got %d counters", len(limited))
+	}
+
+	stats, err := d.GetCounterStats(ctx, "article_1", "like", "1h")
+	if err != nil {
+		t.Fatalf("GetCounterStats failed: %v", err)
+	}
+	if stats.Total != 7 || stats.Peak != 5 || stats.Average != 3.5 || len(stats.TimeSeries) != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestMemoryAnalyticsDAORejectsInvalidTimeRange(t *testing.T) {
+	d := NewMemoryAnalyticsDAO()
+	ctx := context.Background()
+	if err := d.UpdateCounterStats(ctx, "article_1", "like", 1); err != nil {
+		t.Fatalf("UpdateCounterStats failed: %v", err)
+	}
+
+	// "7d"不是合法的Go duration，不能被当作不限时间范围
+	for _, timeRange := range []string{"7d", "-1h", "0s"} {
+		if _, err := d.GetTopCounters(ctx, "like", timeRange, 10); !errors.Is(err, ErrInvalidTimeRange) {
+			t.Errorf("GetTopCounters(%q): expected ErrInvalidTimeRange, got %v", timeRange, err)
+		}
+		if _, err := d.GetCounterStats(ctx, "article_1", "like", timeRange); !errors.Is(err, ErrInvalidTimeRange) {
+			t.Errorf("GetCounterStats(%q): expected ErrInvalidTimeRange, got %v", timeRange, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
@@ -16,12 +17,33 @@ import (
 	"google.golang.org/grpc/codes"
 )
 
+// Config Analytics服务端配置
+type Config struct {
+	PreloadCounterTypes []string      // 启动时预加载排行榜的计数器类型
+	PreloadTimeRanges   []string      // 预加载的时间范围，空字符串表示请求未指定时间范围
+	PreloadLimit        int32         // 预加载的排行榜条数，需与客户端请求的limit一致才能命中
+	RefreshInterval     time.Duration // 定期刷新预加载排行榜的间隔
+	PreloadTimeout      time.Duration // 单次预加载/刷新的超时时间
+}
+
+// DefaultConfig 默认服务端配置
+func DefaultConfig() *Config {
+	return &Config{
+		PreloadCounterTypes: []string{"like", "view"},
+		PreloadTimeRanges:   []string{"", "24h"},
+		PreloadLimit:        10,
+		RefreshInterval:     30 * time.Second,
+		PreloadTimeout:      10 * time.Second,
+	}
+}
+
 // AnalyticsServer Analytics gRPC服务器
 type AnalyticsServer struct {
 	pb.UnimplementedAnalyticsServiceServer
 
 	dao      dao.AnalyticsDAO
 	consumer kafka.Consumer
+	config   *Config
 	logger   *zap.Logger
 
 	// 内存缓存热点数据
@@ -31,11 +53,35 @@ type AnalyticsServer struct {
 	lastCacheUpdate  time.Time
 }
 
-// NewAnalyticsServer 创建Analytics服务器
+// NewAnalyticsServer 使用默认配置创建Analytics服务器
 func NewAnalyticsServer(dao dao.AnalyticsDAO, consumer kafka.Consumer, logger *zap.Logger) *AnalyticsServer {
+	return NewAnalyticsServerWithConfig(dao, consumer, DefaultConfig(), logger)
+}
+
+// NewAnalyticsServerWithConfig 使用指定配置创建Analytics服务器
+func NewAnalyticsServerWithConfig(dao dao.AnalyticsDAO, consumer kafka.Consumer, config *Config, logger *zap.Logger) *AnalyticsServer {
+	defaults := DefaultConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+	if cfg.PreloadLimit <= 0 {
+		cfg.PreloadLimit = defaults.PreloadLimit
+	}
+	if len(cfg.PreloadTimeRanges) == 0 {
+		cfg.PreloadTimeRanges = []string{""}
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaults.RefreshInterval
+	}
+	if cfg.PreloadTimeout <= 0 {
+		cfg.PreloadTimeout = defaults.PreloadTimeout
+	}
+
 	server := &AnalyticsServer{
 		dao:              dao,
 		consumer:         consumer,
+		config:           &cfg,
 		logger:           logger,
 		topCountersCache: make(map[string][]*pb.CounterItem),
 		statsCache:       make(map[string]*pb.StatsResponse),
@@ -68,36 +114,16 @@ func (s *AnalyticsServer) GetTopCounters(ctx context.Context, req *pb.TopCounter
 		req.Limit = 10 // 默认返回10条
 	}
 
-	// 构建缓存键
-	cacheKey := fmt.Sprintf("%s:%s:%d", req.CounterType, req.TimeRange, req.Limit)
-
-	// 尝试从缓存获取
-	s.cacheMu.RLock()
-	if cached, exists := s.topCountersCache[cacheKey]; exists {
-		s.cacheMu.RUnlock()
-
-		// 处理分页
-		start, end := s.calculatePagination(len(cached), req.Pagination)
-		result := cached[start:end]
-
+	// 获取完整排行榜（缓存与分页无关），再按请求分页
+	counters, err := s.getRankedCounters(ctx, req.CounterType, req.TimeRange, req.Limit)
+	if errors.Is(err, dao.ErrInvalidTimeRange) {
 		return &pb.TopCountersResponse{
 			Status: &commonpb.Status{
-				Code:    int32(codes.OK),
-				Message: "Success",
-			},
-			Counters: result,
-			Pagination: &commonpb.PaginationResponse{
-				Total:   int32(len(cached)),
-				Page:    req.Pagination.GetPage(),
-				Size:    req.Limit,
-				HasNext: end < len(cached),
+				Code:    int32(codes.InvalidArgument),
+				Message: err.Error(),
 			},
 		}, nil
 	}
-	s.cacheMu.RUnlock()
-
-	// 缓存未命中，从数据源获取
-	counters, err := s.dao.GetTopCounters(ctx, req.CounterType, req.TimeRange, int(req.Limit))
 	if err != nil {
 		s.logger.Error("Failed to get top counters from DAO", zap.Error(err))
 		return &pb.TopCountersResponse{
@@ -108,6 +134,58 @@ func (s *AnalyticsServer) GetTopCounters(ctx context.Context, req *pb.TopCounter
 		}, nil
 	}
 
+	result, pagination := s.paginateCounters(counters, req.Pagination, req.Limit)
+
+	return &pb.TopCountersResponse{
+		Status: &commonpb.Status{
+			Code:    int32(codes.OK),
+			Message: "Success",
+		},
+		Counters:   result,
+		Pagination: pagination,
+	}, nil
+}
+
+// topCountersCacheKey 排行榜缓存键，不包含分页参数
+func topCountersCacheKey(counterType, timeRange string, limit int32) string {
+	return fmt.Sprintf("%s:%s:%d", counterType, timeRange, limit)
+}
+
+// getRankedCounters 获取完整排行榜，优先从缓存读取
+//
+// 缓存保存的是limit条的完整列表，同一排行榜的不同页共享缓存条目，
+// 调用方只读取返回的切片，不应修改。
+func (s *AnalyticsServer) getRankedCounters(ctx context.Context, counterType, timeRange string, limit int32) ([]*pb.CounterItem, error) {
+	cacheKey := topCountersCacheKey(counterType, timeRange, limit)
+
+	s.cacheMu.RLock()
+	cached, exists := s.topCountersCache[cacheKey]
+	s.cacheMu.RUnlock()
+	if exists {
+		return cached, nil
+	}
+
+	// 缓存未命中，从数据源获取
+	pbCounters, err := s.loadRankedCounters(ctx, counterType, timeRange, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// 更新缓存
+	s.cacheMu.Lock()
+	s.topCountersCache[cacheKey] = pbCounters
+	s.cacheMu.Unlock()
+
+	return pbCounters, nil
+}
+
+// loadRankedCounters 从数据源读取排行榜并转换为protobuf格式
+func (s *AnalyticsServer) loadRankedCounters(ctx context.Context, counterType, timeRange string, limit int32) ([]*pb.CounterItem, error) {
+	counters, err := s.dao.GetTopCounters(ctx, counterType, timeRange, int(limit))
+	if err != nil {
+		return nil, err
+	}
+
 	// 转换为protobuf格式
 	pbCounters := make([]*pb.CounterItem, len(counters))
 	for i, counter := range counters {
@@ -122,29 +200,7 @@ func (s *AnalyticsServer) GetTopCounters(ctx context.Context, req *pb.TopCounter
 			},
 		}
 	}
-
-	// 更新缓存
-	s.cacheMu.Lock()
-	s.topCountersCache[cacheKey] = pbCounters
-	s.cacheMu.Unlock()
-
-	// 处理分页
-	start, end := s.calculatePagination(len(pbCounters), req.Pagination)
-	result := pbCounters[start:end]
-
-	return &pb.TopCountersResponse{
-		Status: &commonpb.Status{
-			Code:    int32(codes.OK),
-			Message: "Success",
-		},
-		Counters: result,
-		Pagination: &commonpb.PaginationResponse{
-			Total:   int32(len(pbCounters)),
-			Page:    req.Pagination.GetPage(),
-			Size:    int32(len(result)),
-			HasNext: end < len(pbCounters),
-		},
-	}, nil
+	return pbCounters, nil
 }
 
 // GetCounterStats 获取计数器统计信息
@@ -177,6 +233,14 @@ func (s *AnalyticsServer) GetCounterStats(ctx context.Context, req *pb.StatsRequ
 
 	// 从数据源获取统计数据
 	stats, err := s.dao.GetCounterStats(ctx, req.ResourceId, req.CounterType, req.TimeRange)
+	if errors.Is(err, dao.ErrInvalidTimeRange) {
+		return &pb.StatsResponse{
+			Status: &commonpb.Status{
+				Code:    int32(codes.InvalidArgument),
+				Message: err.Error(),
+			},
+		}, nil
+	}
 	if err != nil {
 		s.logger.Error("Failed to get counter stats from DAO", zap.Error(err))
 		return &pb.StatsResponse{
@@ -250,7 +314,9 @@ func (s *AnalyticsServer) GetSystemMetrics(ctx context.Context, req *pb.SystemMe
 		// 根据组件类型收集指标
 		switch component {
 		case "analytics":
+			s.cacheMu.RLock()
 			metrics.Values["cache_size"] = float64(len(s.topCountersCache))
+			s.cacheMu.RUnlock()
 			metrics.Values["cache_hit_rate"] = 0.95 // 模拟数据
 		case "memory":
 			// 模拟内存指标
@@ -272,8 +338,13 @@ func (s *AnalyticsServer) HealthCheck(ctx context.Context, req *pb.HealthCheckRe
 	details := make(map[string]string)
 	details["service"] = "analytics"
 	details["status"] = "healthy"
+	s.cacheMu.RLock()
 	details["cache_size"] = strconv.Itoa(len(s.topCountersCache))
 	details["uptime"] = time.Since(s.lastCacheUpdate).String()
+	if !s.lastCacheUpdate.IsZero() {
+		details["last_cache_update"] = s.lastCacheUpdate.Format(time.RFC3339)
+	}
+	s.cacheMu.RUnlock()
 
 	return &pb.HealthCheckResponse{
 		Status: &commonpb.Status{
@@ -285,24 +356,28 @@ func (s *AnalyticsServer) HealthCheck(ctx context.Context, req *pb.HealthCheckRe
 	}, nil
 }
 
-// calculatePagination 计算分页
-func (s *AnalyticsServer) calculatePagination(total int, pagination *commonpb.PaginationRequest) (start, end int) {
-	if pagination == nil {
-		return 0, total
-	}
-
-	page := int(pagination.Page)
-	pageSize := int(pagination.Size)
+// normalizePagination 规范化分页参数，未指定分页时返回第1页、每页limit条
+func normalizePagination(pagination *commonpb.PaginationRequest, limit int32) (page, size int) {
+	page = int(pagination.GetPage())
+	size = int(pagination.GetSize())
 
 	if page <= 0 {
 		page = 1
 	}
-	if pageSize <= 0 {
-		pageSize = 10
+	if size <= 0 {
+		size = int(limit)
+	}
+	if size <= 0 {
+		size = 10
 	}
 
-	start = (page - 1) * pageSize
-	end = start + pageSize
+	return page, size
+}
+
+// calculatePagination 计算分页
+func (s *AnalyticsServer) calculatePagination(total, page, size int) (start, end int) {
+	start = (page - 1) * size
+	end = start + size
 
 	if start > total {
 		start = total
@@ -314,9 +389,23 @@ func (s *AnalyticsServer) calculatePagination(total int, pagination *commonpb.Pa
 	return start, end
 }
 
+// paginateCounters 对排行榜分页，返回的Size/HasNext与实际切片一致
+func (s *AnalyticsServer) paginateCounters(counters []*pb.CounterItem, pagination *commonpb.PaginationRequest, limit int32) ([]*pb.CounterItem, *commonpb.PaginationResponse) {
+	page, size := normalizePagination(pagination, limit)
+	start, end := s.calculatePagination(len(counters), page, size)
+	result := counters[start:end]
+
+	return result, &commonpb.PaginationResponse{
+		Total:   int32(len(counters)),
+		Page:    int32(page),
+		Size:    int32(len(result)),
+		HasNext: end < len(counters),
+	}
+}
+
 // startCacheUpdater 启动缓存更新器
 func (s *AnalyticsServer) startCacheUpdater() {
-	ticker := time.NewTicker(30 * time.Second) // 每30秒更新缓存
+	ticker := time.NewTicker(s.config.RefreshInterval)
 	defer ticker.Stop()
 
 	for {
@@ -327,13 +416,48 @@ func (s *AnalyticsServer) startCacheUpdater() {
 	}
 }
 
-// updateCache 更新缓存
+// updateCache 定期刷新预加载的排行榜
 func (s *AnalyticsServer) updateCache() {
 	s.logger.Debug("Updating analytics cache")
 
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.PreloadTimeout)
+	defer cancel()
+
+	if err := s.Preload(ctx); err != nil {
+		s.logger.Warn("Failed to refresh analytics cache", zap.Error(err))
+	}
+}
+
+// Preload 从数据源加载配置的计数器类型和时间范围的排行榜并写入缓存，
+// 全部成功后更新lastCacheUpdate；部分失败时保留已加载的部分并返回第一个错误
+func (s *AnalyticsServer) Preload(ctx context.Context) error {
+	var firstErr error
+	loaded := 0
+	for _, counterType := range s.config.PreloadCounterTypes {
+		for _, timeRange := range s.config.PreloadTimeRanges {
+			counters, err := s.loadRankedCounters(ctx, counterType, timeRange, s.config.PreloadLimit)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to preload top counters for %s/%s: %w", counterType, timeRange, err)
+				}
+				continue
+			}
+
+			s.cacheMu.Lock()
+			s.topCountersCache[topCountersCacheKey(counterType, timeRange, s.config.PreloadLimit)] = counters
+			s.cacheMu.Unlock()
+			loaded++
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
 	s.cacheMu.Lock()
 	s.lastCacheUpdate = time.Now()
 	s.cacheMu.Unlock()
 
-	// TODO: 在真实环境中，这里会从数据库预加载热点数据
+	s.logger.Debug("Analytics cache preloaded", zap.Int("rankings", loaded))
+	return nil
 }
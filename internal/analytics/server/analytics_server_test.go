@@ -0,0 +1,271 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "high-go-press/api/proto/analytics"
+	commonpb "high-go-press/api/proto/common"
+	"high-go-press/internal/analytics/dao"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// rankedDAO 按limit返回固定排行榜的DAO
+type rankedDAO struct {
+	dao.AnalyticsDAO
+	total int
+	calls int
+}
+
+func (d *rankedDAO) GetTopCounters(ctx context.Context, counterType, timeRange string, limit int) ([]*dao.CounterItem, error) {
+	d.calls++
+	n := d.total
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	items := make([]*dao.CounterItem, n)
+	for i := range items {
+		items[i] = &dao.CounterItem{
+			ResourceID:  fmt.Sprintf("article_%d", i+1),
+			CounterType: counterType,
+			Value:       int64(1000 - i),
+		}
+	}
+	return items, nil
+}
+
+func TestGetTopCountersPagination(t *testing.T) {
+	tests := []struct {
+		name        string
+		pagination  *commonpb.PaginationRequest
+		wantPage    int32
+		wantFirst   string
+		wantSize    int32
+		wantHasNext bool
+	}{
+		{
+			name:        "nil pagination",
+			pagination:  nil,
+			wantPage:    1,
+			wantFirst:   "article_1",
+			wantSize:    20,
+			wantHasNext: false,
+		},
+		{
+			name:        "page 1",
+			pagination:  &commonpb.PaginationRequest{Page: 1, Size: 8},
+			wantPage:    1,
+			wantFirst:   "article_1",
+			wantSize:    8,
+			wantHasNext: true,
+		},
+		{
+			name:        "page 2",
+			pagination:  &commonpb.PaginationRequest{Page: 2, Size: 15},
+			wantPage:    2,
+			wantFirst:   "article_16",
+			wantSize:    5,
+			wantHasNext: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAnalyticsServer(&rankedDAO{total: 50}, nil, zap.NewNop())
+
+			// 第一次走数据源，第二次命中缓存，两条路径结果应一致
+			for _, path := range []string{"miss", "hit"} {
+				resp, err := s.GetTopCounters(context.Background(), &pb.TopCountersRequest{
+					CounterType: "like",
+					Limit:       20,
+					TimeRange:   "24h",
+					Pagination:  tt.pagination,
+				})
+				if err != nil {
+					t.Fatalf("%s: GetTopCounters failed: %v", path, err)
+				}
+				if resp.Status.Code != int32(codes.OK) {
+					t.Fatalf("%s: unexpected status: %v", path, resp.Status)
+				}
+
+				p := resp.Pagination
+				if p.Page != tt.wantPage {
+					t.Errorf("%s: Page = %d, want %d", path, p.Page, tt.wantPage)
+				}
+				if p.Total != 20 {
+					t.Errorf("%s: Total = %d, want 20", path, p.Total)
+				}
+				if p.Size != tt.wantSize || int32(len(resp.Counters)) != p.Size {
+					t.Errorf("%s: Size = %d, len(counters) = %d, want %d", path, p.Size, len(resp.Counters), tt.wantSize)
+				}
+				if p.HasNext != tt.wantHasNext {
+					t.Errorf("%s: HasNext = %v, want %v", path, p.HasNext, tt.wantHasNext)
+				}
+				if len(resp.Counters) > 0 && resp.Counters[0].ResourceId != tt.wantFirst {
+					t.Errorf("%s: first counter = %s, want %s", path, resp.Counters[0].ResourceId, tt.wantFirst)
+				}
+			}
+		})
+	}
+}
+
+func TestGetTopCountersPageOutOfRange(t *testing.T) {
+	s := NewAnalyticsServer(&rankedDAO{total: 50}, nil, zap.NewNop())
+
+	resp, err := s.GetTopCounters(context.Background(), &pb.TopCountersRequest{
+		CounterType: "like",
+		Limit:       10,
+		Pagination:  &commonpb.PaginationRequest{Page: 5, Size: 10},
+	})
+	if err != nil {
+		t.Fatalf("GetTopCounters failed: %v", err)
+	}
+
+	// 超出范围的页返回空结果
+	if len(resp.Counters) != 0 || resp.Pagination.Size != 0 || resp.Pagination.HasNext {
+		t.Errorf("unexpected out-of-range page: counters=%d pagination=%v", len(resp.Counters), resp.Pagination)
+	}
+}
+
+func TestGetTopCountersPagesShareCachedList(t *testing.T) {
+	ranked := &rankedDAO{total: 50}
+	s := NewAnalyticsServer(ranked, nil, zap.NewNop())
+
+	get := func(page int32) *pb.TopCountersResponse {
+		resp, err := s.GetTopCounters(context.Background(), &pb.TopCountersRequest{
+			CounterType: "view",
+			Limit:       25,
+			TimeRange:   "1h",
+			Pagination:  &commonpb.PaginationRequest{Page: page, Size: 10},
+		})
+		if err != nil {
+			t.Fatalf("GetTopCounters(page %d) failed: %v", page, err)
+		}
+		return resp
+	}
+
+	page1 := get(1)
+	page2 := get(2)
+	page3 := get(3)
+
+	// 不同页共享同一份缓存的完整列表，只访问一次数据源
+	if ranked.calls != 1 {
+		t.Errorf("expected 1 DAO call, got %d", ranked.calls)
+	}
+
+	if page1.Counters[0].ResourceId != "article_1" || !page1.Pagination.HasNext {
+		t.Errorf("unexpected page 1: first=%s pagination=%v", page1.Counters[0].ResourceId, page1.Pagination)
+	}
+	if len(page2.Counters) != 10 || page2.Counters[0].ResourceId != "article_11" || page2.Counters[9].ResourceId != "article_20" {
+		t.Errorf("unexpected page 2 items: %v", page2.Counters)
+	}
+	if !page2.Pagination.HasNext || page2.Pagination.Size != 10 {
+		t.Errorf("unexpected page 2 pagination: %v", page2.Pagination)
+	}
+	if len(page3.Counters) != 5 || page3.Pagination.HasNext || page3.Pagination.Size != 5 {
+		t.Errorf("unexpected page 3: counters=%d pagination=%v", len(page3.Counters), page3.Pagination)
+	}
+
+	// 不同limit是不同的排行榜
+	get(1)
+	if _, err := s.GetTopCounters(context.Background(), &pb.TopCountersRequest{CounterType: "view", Limit: 5, TimeRange: "1h"}); err != nil {
+		t.Fatalf("GetTopCounters failed: %v", err)
+	}
+	if ranked.calls != 2 {
+		t.Errorf("expected 2 DAO calls after new limit, got %d", ranked.calls)
+	}
+}
+
+func TestPreloadPopulatesConfiguredTypes(t *testing.T) {
+	ranked := &rankedDAO{total: 50}
+	s := NewAnalyticsServerWithConfig(ranked, nil, &Config{
+		PreloadCounterTypes: []string{"like", "share"},
+		PreloadTimeRanges:   []string{"", "24h"},
+		PreloadLimit:        10,
+		RefreshInterval:     time.Hour,
+	}, zap.NewNop())
+
+	if err := s.Preload(context.Background()); err != nil {
+		t.Fatalf("Preload failed: %v", err)
+	}
+	if ranked.calls != 4 {
+		t.Errorf("Expected 4 DAO calls for 2 types x 2 time ranges, got %d", ranked.calls)
+	}
+
+	s.cacheMu.RLock()
+	for _, counterType := range []string{"like", "share"} {
+		for _, timeRange := range []string{"", "24h"} {
+			cached := s.topCountersCache[topCountersCacheKey(counterType, timeRange, 10)]
+			if len(cached) != 10 || cached[0].CounterType != counterType {
+				t.Errorf("Expected preloaded %s/%q ranking, got %v", counterType, timeRange, cached)
+			}
+		}
+	}
+	if _, ok := s.topCountersCache[topCountersCacheKey("view", "", 10)]; ok {
+		t.Error("Expected unconfigured type not to be preloaded")
+	}
+	lastUpdate := s.lastCacheUpdate
+	s.cacheMu.RUnlock()
+	if lastUpdate.IsZero() {
+		t.Error("Expected lastCacheUpdate to be set after preload")
+	}
+
+	// 预加载的排行榜直接命中缓存
+	if _, err := s.GetTopCounters(context.Background(), &pb.TopCountersRequest{CounterType: "like", Limit: 10, TimeRange: "24h"}); err != nil {
+		t.Fatalf("GetTopCounters failed: %v", err)
+	}
+	if ranked.calls != 4 {
+		t.Errorf("Expected preloaded ranking served from cache, got %d DAO calls", ranked.calls)
+	}
+}
+
+func TestPreloadFailureKeepsLastCacheUpdate(t *testing.T) {
+	s := NewAnalyticsServerWithConfig(failingDAO{}, nil, &Config{
+		PreloadCounterTypes: []string{"like"},
+		RefreshInterval:     time.Hour,
+	}, zap.NewNop())
+
+	if err := s.Preload(context.Background()); err == nil {
+		t.Fatal("Expected preload error from failing DAO")
+	}
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	if !s.lastCacheUpdate.IsZero() {
+		t.Error("Expected lastCacheUpdate unchanged after failed preload")
+	}
+}
+
+// failingDAO 查询总是失败的DAO
+type failingDAO struct {
+	dao.AnalyticsDAO
+}
+
+func (failingDAO) GetTopCounters(ctx context.Context, counterType, timeRange string, limit int) ([]*dao.CounterItem, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func TestInvalidTimeRangeReturnsInvalidArgument(t *testing.T) {
+	s := NewAnalyticsServer(dao.NewMemoryAnalyticsDAO(), nil, zap.NewNop())
+	ctx := context.Background()
+
+	top, err := s.GetTopCounters(ctx, &pb.TopCountersRequest{CounterType: "like", Limit: 10, TimeRange: "7d"})
+	if err != nil {
+		t.Fatalf("GetTopCounters failed: %v", err)
+	}
+	if top.Status.Code != int32(codes.InvalidArgument) {
+		t.Errorf("Expected InvalidArgument for time range 7d, got %v", top.Status)
+	}
+
+	stats, err := s.GetCounterStats(ctx, &pb.StatsRequest{ResourceId: "article_1", CounterType: "like", TimeRange: "7d"})
+	if err != nil {
+		t.Fatalf("GetCounterStats failed: %v", err)
+	}
+	if stats.Status.Code != int32(codes.InvalidArgument) {
+		t.Errorf("Expected InvalidArgument for time range 7d, got %v", stats.Status)
+	}
+}
@@ -81,6 +81,82 @@ type CounterRepo interface {
 	SetCounter(ctx context.Context, key string, value int64) error
 }
 
+// CounterInitializer 支持原子"获取或初始化"的计数器仓库（可选能力）
+type CounterInitializer interface {
+	// GetOrInitCounter 获取计数器值，不存在时原子地设置为initial
+	// created为true表示本次调用创建了计数器
+	GetOrInitCounter(ctx context.Context, key string, initial int64) (value int64, created bool, err error)
+}
+
+// CounterTTLIncrementer 支持带过期时间增量的计数器仓库（可选能力）
+type CounterTTLIncrementer interface {
+	// IncrementCounterWithTTL 增加计数器，key由本次调用创建时设置ttl过期，已存在的key不刷新过期时间
+	IncrementCounterWithTTL(ctx context.Context, key string, increment int64, ttl time.Duration) (int64, error)
+}
+
+// CounterDecrementer 支持递减计数器的仓库（可选能力）
+type CounterDecrementer interface {
+	// DecrementCounter 将计数器减少delta，clampAtZero为true时结果低于0则截断为0
+	// applied为计数器实际的变化量，未截断时等于-delta
+	DecrementCounter(ctx context.Context, key string, delta int64, clampAtZero bool) (value int64, applied int64, err error)
+}
+
+// CounterCompareAndSetter 支持比较并设置计数器的仓库（可选能力）
+type CounterCompareAndSetter interface {
+	// CompareAndSetCounter 当前值等于expected时原子地设置为newValue，不存在的计数器按0比较
+	CompareAndSetCounter(ctx context.Context, key string, expected, newValue int64) (bool, error)
+}
+
+// CounterPartialGetter 支持部分失败的批量读取（可选能力）
+type CounterPartialGetter interface {
+	// GetMultiCountersPartial 批量获取计数器，单个key失败不影响其他key
+	// values为读取成功的key（不存在的key为0），errs为读取失败的key及原因；所有key都失败时返回err
+	GetMultiCountersPartial(ctx context.Context, keys []string) (values map[string]int64, errs map[string]error, err error)
+}
+
+// CounterUpdateTimeReader 支持读取计数器最后写入时间的仓库（可选能力）
+type CounterUpdateTimeReader interface {
+	// GetCounterUpdatedAt 批量返回计数器最后一次写入的时间，未记录写入时间的key不在结果中
+	GetCounterUpdatedAt(ctx context.Context, keys []string) (map[string]time.Time, error)
+}
+
+// CounterScanner 支持按key前缀扫描计数器的仓库（可选能力）
+type CounterScanner interface {
+	// ScanCounters 返回key以prefix开头的计数器，最多limit个
+	ScanCounters(ctx context.Context, prefix string, limit int) (map[string]int64, error)
+}
+
+// LeaderboardEntry 排行榜条目
+type LeaderboardEntry struct {
+	Member string // 资源ID
+	Score  int64  // 计数值
+}
+
+// LeaderboardReader 支持读取排行榜ZSET的仓库（可选能力）
+type LeaderboardReader interface {
+	// RangeLeaderboardAbove 按分数从高到低返回分数大于threshold的成员，最多limit个
+	// 排行榜不存在时返回错误，由调用方决定是否回退到其他方式
+	RangeLeaderboardAbove(ctx context.Context, key string, threshold int64, limit int) ([]LeaderboardEntry, error)
+}
+
+// LeaderboardWriter 支持写入排行榜ZSET的仓库（可选能力）
+type LeaderboardWriter interface {
+	// SetLeaderboardScore 将成员分数设置为计数器当前值，用于总榜；只在score大于当前分数时更新
+	SetLeaderboardScore(ctx context.Context, key, member string, score int64) error
+
+	// IncrementLeaderboard 为成员增加分数，ttl大于0时同时刷新排行榜的过期时间
+	IncrementLeaderboard(ctx context.Context, key, member string, increment int64, ttl time.Duration) error
+}
+
+// HotRanker 支持按时间范围维护热点排行的仓库（可选能力）
+type HotRanker interface {
+	// AddToHotRank 将资源在当前时间桶内的热度增加value，period为hour、day、week
+	AddToHotRank(ctx context.Context, counterType, period, resourceID string, value int64) error
+
+	// GetHotRank 按热度从高到低返回当前时间桶内的前limit个资源
+	GetHotRank(ctx context.Context, counterType, period string, limit int) ([]*HotRankItem, error)
+}
+
 // buildCounterKey 构建计数器的Redis key
 func BuildCounterKey(resourceID string, counterType CounterType) string {
 	return "counter:" + string(counterType) + ":" + resourceID
@@ -101,7 +177,7 @@ type Counter struct {
 	ResourceID   string `json:"resource_id"`
 	CounterType  string `json:"counter_type"`
 	CurrentValue int64  `json:"current_value"`
-	UpdatedAt    int64  `json:"updated_at"`
+	UpdatedAt    int64  `json:"updated_at,omitempty"` // 最后一次写入的unix秒，未知时为0且不输出
 }
 
 // IncrementRequest 增量请求
@@ -109,6 +185,13 @@ type IncrementRequest struct {
 	ResourceID  string `json:"resource_id" binding:"required"`
 	CounterType string `json:"counter_type" binding:"required"`
 	Delta       int64  `json:"delta,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+	ClientIP    string `json:"-"` // 由HTTP层根据X-Forwarded-For或连接地址填写
+}
+
+// Reset 将请求对象恢复为零值，供对象池复用
+func (r *IncrementRequest) Reset() {
+	*r = IncrementRequest{}
 }
 
 // CounterResponse 计数器响应
@@ -121,6 +204,11 @@ type CounterResponse struct {
 	Timestamp    int64  `json:"timestamp"`
 }
 
+// Reset 将响应对象恢复为零值，供对象池复用
+func (r *CounterResponse) Reset() {
+	*r = CounterResponse{}
+}
+
 // BatchRequest 批量查询请求
 type BatchRequest struct {
 	Items []BatchItem `json:"items" binding:"required,dive"`
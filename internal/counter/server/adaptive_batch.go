@@ -0,0 +1,118 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// AdaptiveBatchConfig 异步批量处理的自适应批次配置
+type AdaptiveBatchConfig struct {
+	InitialSize   int           // 初始批次大小
+	MinSize       int           // 批次大小下限
+	MaxSize       int           // 批次大小上限
+	TargetLatency time.Duration // 单个操作的目标平均Redis耗时
+	InitialDelay  time.Duration // 初始批次间隔
+	MinDelay      time.Duration // 批次间隔下限
+	MaxDelay      time.Duration // 批次间隔上限
+}
+
+// DefaultAdaptiveBatchConfig 默认自适应批次配置
+func DefaultAdaptiveBatchConfig() *AdaptiveBatchConfig {
+	return &AdaptiveBatchConfig{
+		InitialSize:   100,
+		MinSize:       10,
+		MaxSize:       1000,
+		TargetLatency: 2 * time.Millisecond,
+		InitialDelay:  10 * time.Millisecond,
+		MinDelay:      time.Millisecond,
+		MaxDelay:      200 * time.Millisecond,
+	}
+}
+
+// adaptiveBatcher 根据每批Redis耗时调整批次大小和批次间隔
+//
+// 采用AIMD策略：平均耗时超过目标时批次减半、间隔加倍以快速退避；
+// 耗时低于目标一半时批次增加1/4、间隔减半以逐步恢复吞吐。
+type adaptiveBatcher struct {
+	config *AdaptiveBatchConfig
+
+	mu    sync.Mutex
+	size  int
+	delay time.Duration
+}
+
+// newAdaptiveBatcher 创建自适应批次控制器
+func newAdaptiveBatcher(cfg *AdaptiveBatchConfig) *adaptiveBatcher {
+	if cfg == nil {
+		cfg = DefaultAdaptiveBatchConfig()
+	}
+
+	b := &adaptiveBatcher{config: cfg}
+	b.size = b.clampSize(cfg.InitialSize)
+	b.delay = b.clampDelay(cfg.InitialDelay)
+	return b
+}
+
+// next 返回下一批的批次大小和批次间隔
+func (b *adaptiveBatcher) next() (int, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size, b.delay
+}
+
+// observe 记录一批操作的耗时并调整批次参数，返回调整后的批次大小
+func (b *adaptiveBatcher) observe(ops int, elapsed time.Duration) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ops <= 0 || b.config.TargetLatency <= 0 {
+		return b.size
+	}
+
+	avg := elapsed / time.Duration(ops)
+	switch {
+	case avg > b.config.TargetLatency:
+		// Redis变慢，快速退避
+		b.size = b.clampSize(b.size / 2)
+		b.delay = b.clampDelay(b.delay * 2)
+	case avg < b.config.TargetLatency/2:
+		// Redis余量充足，逐步恢复
+		b.size = b.clampSize(b.size + b.size/4 + 1)
+		b.delay = b.clampDelay(b.delay / 2)
+	}
+
+	return b.size
+}
+
+// Size 当前批次大小
+func (b *adaptiveBatcher) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// clampSize 将批次大小限制在配置范围内
+func (b *adaptiveBatcher) clampSize(size int) int {
+	minSize := b.config.MinSize
+	if minSize <= 0 {
+		minSize = 1
+	}
+	if size < minSize {
+		return minSize
+	}
+	if b.config.MaxSize > 0 && size > b.config.MaxSize {
+		return b.config.MaxSize
+	}
+	return size
+}
+
+// clampDelay 将批次间隔限制在配置范围内
+func (b *adaptiveBatcher) clampDelay(delay time.Duration) time.Duration {
+	if delay < b.config.MinDelay {
+		return b.config.MinDelay
+	}
+	if b.config.MaxDelay > 0 && delay > b.config.MaxDelay {
+		return b.config.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+func TestAdaptiveBatcherShrinksOnRisingLatency(t *testing.T) {
+	b := newAdaptiveBatcher(&AdaptiveBatchConfig{
+		InitialSize:   100,
+		MinSize:       10,
+		MaxSize:       1000,
+		TargetLatency: 2 * time.Millisecond,
+		InitialDelay:  10 * time.Millisecond,
+		MinDelay:      time.Millisecond,
+		MaxDelay:      200 * time.Millisecond,
+	})
+
+	// 模拟Redis单操作耗时逐步升高，批次应持续缩小、间隔持续加大
+	prevSize, prevDelay := b.next()
+	for _, perOp := range []time.Duration{3 * time.Millisecond, 5 * time.Millisecond, 8 * time.Millisecond} {
+		size, _ := b.next()
+		b.observe(size, time.Duration(size)*perOp)
+
+		newSize, newDelay := b.next()
+		if newSize >= prevSize {
+			t.Errorf("latency %v: expected batch to shrink below %d, got %d", perOp, prevSize, newSize)
+		}
+		if newDelay <= prevDelay {
+			t.Errorf("latency %v: expected delay to grow above %v, got %v", perOp, prevDelay, newDelay)
+		}
+		prevSize, prevDelay = newSize, newDelay
+	}
+
+	// 持续高延迟时不低于下限
+	for i := 0; i < 10; i++ {
+		b.observe(b.Size(), time.Duration(b.Size())*50*time.Millisecond)
+	}
+	if size, delay := b.next(); size != 10 || delay != 200*time.Millisecond {
+		t.Errorf("expected size/delay clamped to 10/200ms, got %d/%v", size, delay)
+	}
+
+	// 延迟恢复后逐步放大批次
+	for i := 0; i < 5; i++ {
+		b.observe(b.Size(), time.Duration(b.Size())*100*time.Microsecond)
+	}
+	if size, delay := b.next(); size <= 10 || delay >= 200*time.Millisecond {
+		t.Errorf("expected batch to grow after latency recovered, got %d/%v", size, delay)
+	}
+}
+
+func TestProcessBatchIncrementAsyncAdaptsToLatency(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	repo.Delay = 3 * time.Millisecond
+
+	cfg := DefaultConfig()
+	cfg.AsyncBatch = &AdaptiveBatchConfig{
+		InitialSize:   16,
+		MinSize:       2,
+		MaxSize:       64,
+		TargetLatency: time.Millisecond,
+	}
+	s := NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableBusiness: true}, zap.NewNop())
+	s.SetMetricsManager(mm)
+	if got := businessGaugeValue(t, mm, "async_batch_size"); got != 16 {
+		t.Fatalf("expected initial async_batch_size gauge 16, got %v", got)
+	}
+
+	s.processBatchIncrementAsync(context.Background(), buildOperations(30), nil)
+
+	// 所有操作都已执行
+	if v := repo.Value("counter:article_1:like"); v != 30 {
+		t.Fatalf("expected counter value 30, got %d", v)
+	}
+
+	// Redis耗时超过目标，批次缩小并上报当前值
+	size := s.AsyncBatchSize()
+	if size >= 16 {
+		t.Errorf("expected batch size to shrink below 16, got %d", size)
+	}
+	if got := businessGaugeValue(t, mm, "async_batch_size"); got != float64(size) {
+		t.Errorf("expected async_batch_size gauge %d, got %v", size, got)
+	}
+}
+
+// businessGaugeValue 读取业务指标当前值
+func businessGaugeValue(t *testing.T, mm *metrics.MetricsManager, metric string) float64 {
+	t.Helper()
+
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_business_current_value" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "metric" && label.GetValue() == metric {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("business gauge %s not found", metric)
+	return 0
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"high-go-press/api/proto/common"
+	"high-go-press/api/proto/counter"
+	"high-go-press/pkg/kafka"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// batchJob 异步批量任务，按请求顺序记录每个操作的结果
+type batchJob struct {
+	mu         sync.Mutex
+	id         string
+	tenantID   string // 创建任务的租户，只有同一租户可以查询
+	state      counter.BatchJobState
+	results    []*counter.IncrementResponse
+	done       int // 已处理的操作数，results[:done]有效
+	processed  int32
+	failed     int32
+	finishedAt time.Time
+}
+
+// record 记录第index个操作的结果
+func (j *batchJob) record(index int, result *counter.IncrementResponse, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err != nil {
+		j.failed++
+		result = &counter.IncrementResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: err.Error(),
+				Code:    int32(status.Code(err)),
+			},
+		}
+	} else {
+		j.processed++
+	}
+	j.results[index] = result
+	if index+1 > j.done {
+		j.done = index + 1
+	}
+}
+
+// finish 标记任务结束
+func (j *batchJob) finish(state counter.BatchJobState, now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state = state
+	j.finishedAt = now
+}
+
+// snapshot 生成当前进度的响应
+func (j *batchJob) snapshot() *counter.GetBatchStatusResponse {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	results := make([]*counter.IncrementResponse, j.done)
+	copy(results, j.results[:j.done])
+	return &counter.GetBatchStatusResponse{
+		Status: &common.Status{
+			Success: true,
+			Code:    int32(codes.OK),
+		},
+		JobId:          j.id,
+		State:          j.state,
+		TotalCount:     int32(len(j.results)),
+		ProcessedCount: j.processed,
+		FailedCount:    j.failed,
+		Results:        results,
+	}
+}
+
+// expired 任务结束超过ttl后过期，运行中的任务不过期
+func (j *batchJob) expired(now time.Time, ttl time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state != counter.BatchJobState_BATCH_JOB_STATE_RUNNING && now.Sub(j.finishedAt) > ttl
+}
+
+// batchJobStore 内存中的批量任务存储，任务结束ttl后清理
+// 任务只保存在创建它的Counter实例上，不跨实例共享，实例重启后丢失；
+// 多实例部署时GetBatchStatus需要路由到提交批量请求的同一实例（如同一连接），否则返回NotFound
+type batchJobStore struct {
+	mu    sync.Mutex
+	jobs  map[string]*batchJob
+	ttl   time.Duration
+	idGen kafka.IDGenerator
+	now   func() time.Time
+}
+
+// newBatchJobStore 创建批量任务存储
+func newBatchJobStore(ttl time.Duration) *batchJobStore {
+	return &batchJobStore{
+		jobs:  make(map[string]*batchJob),
+		ttl:   ttl,
+		idGen: kafka.NewULIDGenerator(),
+		now:   time.Now,
+	}
+}
+
+// create 为租户创建包含total个操作的运行中任务，未启用多租户时tenantID为空
+func (s *batchJobStore) create(tenantID string, total int) *batchJob {
+	job := &batchJob{
+		id:       s.idGen.NewID(),
+		tenantID: tenantID,
+		state:    counter.BatchJobState_BATCH_JOB_STATE_RUNNING,
+		results:  make([]*counter.IncrementResponse, total),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpiredLocked()
+	s.jobs[job.id] = job
+	return job
+}
+
+// get 查询租户的任务，已过期或属于其它租户的任务视为不存在
+func (s *batchJobStore) get(tenantID, id string) (*batchJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpiredLocked()
+	job, ok := s.jobs[id]
+	if !ok || job.tenantID != tenantID {
+		return nil, false
+	}
+	return job, true
+}
+
+// evictExpiredLocked 清理过期任务，调用方需持有锁
+func (s *batchJobStore) evictExpiredLocked() {
+	now := s.now()
+	for id, job := range s.jobs {
+		if job.expired(now, s.ttl) {
+			delete(s.jobs, id)
+		}
+	}
+}
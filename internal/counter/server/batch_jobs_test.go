@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/middleware"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// waitForBatchJob 轮询批量任务直到结束
+func waitForBatchJob(t *testing.T, s *CounterServer, jobID string) *counter.GetBatchStatusResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := s.GetBatchStatus(context.Background(), &counter.GetBatchStatusRequest{JobId: jobID})
+		if err != nil {
+			t.Fatalf("GetBatchStatus failed: %v", err)
+		}
+		if resp.State != counter.BatchJobState_BATCH_JOB_STATE_RUNNING {
+			return resp
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for batch job, last status %+v", resp)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBatchIncrementAsyncJobPolledToCompletion(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newTestCounterServer(repo)
+
+	const total = 250 // 跨越多个自适应批次
+	operations := buildOperations(total)
+	operations[10] = &counter.IncrementRequest{ResourceId: "article_1"} // 缺少counter_type
+
+	resp, err := s.BatchIncrementCounters(context.Background(), &counter.BatchIncrementRequest{
+		Operations: operations,
+		Async:      true,
+		TrackJob:   true,
+	})
+	if err != nil {
+		t.Fatalf("BatchIncrementCounters failed: %v", err)
+	}
+	if resp.JobId == "" {
+		t.Fatal("Expected job_id for tracked async batch")
+	}
+
+	final := waitForBatchJob(t, s, resp.JobId)
+	if final.State != counter.BatchJobState_BATCH_JOB_STATE_COMPLETED {
+		t.Fatalf("Expected completed job, got %v", final.State)
+	}
+	if final.TotalCount != total || final.ProcessedCount != total-1 || final.FailedCount != 1 {
+		t.Errorf("Expected %d total, %d processed, 1 failed, got %d/%d/%d",
+			total, total-1, final.TotalCount, final.ProcessedCount, final.FailedCount)
+	}
+	if len(final.Results) != total {
+		t.Fatalf("Expected %d results, got %d", total, len(final.Results))
+	}
+
+	// 按顺序处理，每个成功操作返回递增的新值
+	var expected int64
+	for i, result := range final.Results {
+		if i == 10 {
+			if result.Status.Success {
+				t.Errorf("Expected invalid operation %d to fail", i)
+			}
+			continue
+		}
+		expected++
+		if !result.Status.Success || result.CurrentValue != expected {
+			t.Fatalf("Expected result %d to have value %d, got %+v", i, expected, result)
+		}
+	}
+}
+
+func TestBatchIncrementAsyncWithoutTrackingHasNoJob(t *testing.T) {
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+
+	resp, err := s.BatchIncrementCounters(context.Background(), &counter.BatchIncrementRequest{
+		Operations: buildOperations(5),
+		Async:      true,
+	})
+	if err != nil {
+		t.Fatalf("BatchIncrementCounters failed: %v", err)
+	}
+	if resp.JobId != "" {
+		t.Errorf("Expected no job_id without track_job, got %q", resp.JobId)
+	}
+}
+
+func TestGetBatchStatusUnknownAndExpiredJobs(t *testing.T) {
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+
+	if _, err := s.GetBatchStatus(context.Background(), &counter.GetBatchStatusRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for empty job_id, got %v", err)
+	}
+	if _, err := s.GetBatchStatus(context.Background(), &counter.GetBatchStatusRequest{JobId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for unknown job, got %v", err)
+	}
+
+	now := time.Now()
+	s.batchJobs.now = func() time.Time { return now }
+	job := s.batchJobs.create("", 1)
+	job.finish(counter.BatchJobState_BATCH_JOB_STATE_COMPLETED, now)
+
+	// 保留时间内可以查询
+	if _, err := s.GetBatchStatus(context.Background(), &counter.GetBatchStatusRequest{JobId: job.id}); err != nil {
+		t.Fatalf("Expected finished job queryable within TTL, got %v", err)
+	}
+
+	// 超过保留时间后清理
+	now = now.Add(s.config.BatchJobTTL + time.Second)
+	if _, err := s.GetBatchStatus(context.Background(), &counter.GetBatchStatusRequest{JobId: job.id}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected expired job to be NotFound, got %v", err)
+	}
+}
+
+func TestGetBatchStatusScopedByTenant(t *testing.T) {
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+
+	tenantA := middleware.WithTenantID(context.Background(), "tenant-a")
+	resp, err := s.BatchIncrementCounters(tenantA, &counter.BatchIncrementRequest{
+		Async:      true,
+		TrackJob:   true,
+		Operations: []*counter.IncrementRequest{{ResourceId: "article_1", CounterType: "like", Delta: 1}},
+	})
+	if err != nil {
+		t.Fatalf("BatchIncrementCounters failed: %v", err)
+	}
+
+	// 同一租户可以查询
+	if _, err := s.GetBatchStatus(tenantA, &counter.GetBatchStatusRequest{JobId: resp.JobId}); err != nil {
+		t.Fatalf("Expected owning tenant to query job, got %v", err)
+	}
+
+	// 其它租户和未带租户的请求查询不到
+	tenantB := middleware.WithTenantID(context.Background(), "tenant-b")
+	if _, err := s.GetBatchStatus(tenantB, &counter.GetBatchStatusRequest{JobId: resp.JobId}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for another tenant, got %v", err)
+	}
+	if _, err := s.GetBatchStatus(context.Background(), &counter.GetBatchStatusRequest{JobId: resp.JobId}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound without tenant, got %v", err)
+	}
+}
@@ -2,45 +2,313 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"high-go-press/api/proto/common"
 	"high-go-press/api/proto/counter"
+	"high-go-press/internal/biz"
 	"high-go-press/internal/dao"
+	"high-go-press/pkg/config"
+	resilience "high-go-press/pkg/grpc"
 	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/logger"
+	"high-go-press/pkg/metrics"
+	"high-go-press/pkg/middleware"
 	"high-go-press/pkg/pool"
+	"high-go-press/pkg/redislock"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// ErrDeltaTooLarge 增量超过计数器类型允许的上限
+var ErrDeltaTooLarge = errors.New("delta exceeds max allowed")
+
+// ErrNegativeDecrement 递减量为负数
+var ErrNegativeDecrement = errors.New("decrement delta must be positive")
+
+// ErrNegativeSwapValue 比较并设置的新值为负数
+var ErrNegativeSwapValue = errors.New("new value must not be negative")
+
+// ScanTruncatedTrailer FindCountersAbove扫描达到MaxFindScanKeys上限、结果可能不完整时设置的trailer
+const ScanTruncatedTrailer = "x-scan-truncated"
+
+const (
+	// 同步批量处理并发数的上下限
+	minBatchConcurrency = 1
+	maxBatchConcurrency = 256
+)
+
+// Config Counter服务端配置
+type Config struct {
+	BatchConcurrency     int                              // 同步批量处理的最大并发数
+	EventSendTimeout     time.Duration                    // 异步发送Kafka事件的超时时间
+	EventQueueSize       int                              // 待发送Kafka事件队列容量，队列满时丢弃最旧的事件，0表示不使用队列直接提交到Worker Pool；队列由单个goroutine串行发送
+	EventCircuitBreaker  *resilience.CircuitBreakerConfig // Kafka事件发送熔断器配置
+	ErrorLog             *logger.RateLimitedConfig        // 热路径错误日志限流配置
+	DefaultDeltaLimit    DeltaLimit                       // 未单独配置的计数器类型的增量限制
+	DeltaLimits          map[string]DeltaLimit            // 按计数器类型配置的增量限制
+	MaxResourceTypes     int                              // GetResourceCounters最多返回的计数器类型数
+	MaxFindResults       int                              // FindCountersAbove最多返回的资源数
+	MaxFindScanKeys      int                              // FindCountersAbove无排行榜时最多扫描的key数
+	AsyncBatch           *AdaptiveBatchConfig             // 异步批量处理的自适应批次配置
+	StatsStreamInterval  time.Duration                    // StreamStats客户端未指定间隔时的推送间隔
+	WatchInterval        time.Duration                    // WatchCounter客户端未指定间隔时检查变化的间隔
+	BatchJobTTL          time.Duration                    // 异步批量任务结束后可查询状态的保留时间
+	Leaderboards         map[string][]time.Duration       // 维护排行榜的计数器类型及其时间窗口，未配置的类型不写排行榜
+	ClampBatchDecrements bool                             // 批量操作中的负增量走递减路径时，是否将结果截断在0
+	CounterTTL           time.Duration                    // 计数器首次写入后的过期时间，0表示永不过期
+	HotRankPeriods       []string                         // 每次增量后更新热点排行的时间范围，默认为空即不维护热点排行
+	MetricCounterTypes   []string                         // 业务指标中单独统计的计数器类型，其它类型记为other
+	TrustedProxies       *middleware.TrustedProxies       // 可信代理，只采用来自可信代理的client_ip和x-forwarded-for，nil表示不信任任何代理
+	ResetLockTTL         time.Duration                    // ResetCounter持有分布式锁的最长时间，实例在重置中途退出时锁到期自动释放
+}
+
+// DeltaLimit 计数器增量限制
+type DeltaLimit struct {
+	Default int64 // 请求未指定增量时使用的默认值
+	Max     int64 // 单次增量绝对值上限，0表示不限制
+}
+
+// DefaultConfig 默认服务端配置
+func DefaultConfig() *Config {
+	return &Config{
+		BatchConcurrency:    10,
+		EventSendTimeout:    3 * time.Second,
+		EventQueueSize:      10000,
+		EventCircuitBreaker: resilience.DefaultCircuitBreakerConfig(),
+		ErrorLog:            logger.DefaultRateLimitedConfig(),
+		DefaultDeltaLimit:   DeltaLimit{Default: 1},
+		MaxResourceTypes:    50,
+		MaxFindResults:      1000,
+		MaxFindScanKeys:     10000,
+		AsyncBatch:          DefaultAdaptiveBatchConfig(),
+		StatsStreamInterval: time.Second,
+		WatchInterval:       time.Second,
+		BatchJobTTL:         10 * time.Minute,
+		ResetLockTTL:        30 * time.Second,
+		MetricCounterTypes: []string{
+			string(biz.CounterTypeLike),
+			string(biz.CounterTypeView),
+			string(biz.CounterTypeFollow),
+		},
+	}
+}
+
+// NewConfigFromAppConfig 根据应用配置构建服务端配置
+func NewConfigFromAppConfig(appConfig *config.Config) *Config {
+	cfg := DefaultConfig()
+	if appConfig == nil {
+		return cfg
+	}
+
+	perf := appConfig.Counter.Performance
+	if perf.BatchConcurrency > 0 {
+		cfg.BatchConcurrency = perf.BatchConcurrency
+	} else if perf.WorkerPoolSize > 0 {
+		cfg.BatchConcurrency = perf.WorkerPoolSize
+	}
+
+	cfg.CounterTTL = perf.CounterTTL
+
+	if appConfig.Kafka.Producer.SendTimeout > 0 {
+		cfg.EventSendTimeout = appConfig.Kafka.Producer.SendTimeout
+	}
+	// queue_size显式设为0时关闭队列，未设置时沿用默认容量
+	if queueSize := appConfig.Kafka.Producer.QueueSize; queueSize != nil {
+		cfg.EventQueueSize = *queueSize
+	}
+
+	delta := appConfig.Counter.Delta
+	cfg.DefaultDeltaLimit = newDeltaLimit(delta.Default, cfg.DefaultDeltaLimit)
+	if len(delta.Types) > 0 {
+		cfg.DeltaLimits = make(map[string]DeltaLimit, len(delta.Types))
+		for counterType, limit := range delta.Types {
+			cfg.DeltaLimits[counterType] = newDeltaLimit(limit, cfg.DefaultDeltaLimit)
+		}
+	}
+	cfg.ClampBatchDecrements = delta.ClampBatchDecrements
+
+	if len(appConfig.Counter.Leaderboards) > 0 {
+		cfg.Leaderboards = make(map[string][]time.Duration, len(appConfig.Counter.Leaderboards))
+		for _, spec := range appConfig.Counter.Leaderboards {
+			cfg.Leaderboards[spec.CounterType] = spec.Windows
+		}
+	}
+
+	cfg.HotRankPeriods = append([]string(nil), appConfig.Counter.HotRankPeriods...)
+
+	// 可信代理在配置加载时已校验，这里的解析错误只会来自未经校验的配置，按不信任任何代理处理
+	if trusted, err := middleware.NewTrustedProxies(appConfig.Counter.Server.TrustedProxies); err == nil {
+		cfg.TrustedProxies = trusted
+	}
+
+	// 单独配置了增量限制或排行榜的类型同样单独统计
+	for counterType := range cfg.DeltaLimits {
+		if !slices.Contains(cfg.MetricCounterTypes, counterType) {
+			cfg.MetricCounterTypes = append(cfg.MetricCounterTypes, counterType)
+		}
+	}
+	for counterType := range cfg.Leaderboards {
+		if !slices.Contains(cfg.MetricCounterTypes, counterType) {
+			cfg.MetricCounterTypes = append(cfg.MetricCounterTypes, counterType)
+		}
+	}
+	return cfg
+}
+
 // CounterServer gRPC服务端实现
 type CounterServer struct {
 	counter.UnimplementedCounterServiceServer
-	dao        *dao.RedisRepo
+	dao        biz.CounterRepo
 	workerPool *pool.WorkerPool
 	objectPool *pool.ObjectPool
 	producer   kafka.Producer
+	config     *Config
 	logger     *zap.Logger
+	errorLog   *logger.RateLimitedLogger // 热路径错误日志，避免故障期间刷屏
+
+	// 异步批量处理的自适应批次控制
+	asyncBatcher   *adaptiveBatcher
+	batchJobs      *batchJobStore
+	metricsManager *metrics.MetricsManager
+
+	// 管理操作的分布式锁，未设置时ResetCounter不可用
+	locker *redislock.Locker
+
+	// Kafka事件发送保护
+	eventBreaker  *resilience.CircuitBreaker
+	eventQueue    *eventQueue
+	eventsSent    int64
+	eventsDropped int64
 }
 
 // NewCounterServer 创建Counter服务端
 func NewCounterServer(
-	dao *dao.RedisRepo,
+	dao biz.CounterRepo,
 	workerPool *pool.WorkerPool,
 	objectPool *pool.ObjectPool,
 	producer kafka.Producer,
+	cfg *Config,
 	logger *zap.Logger,
 ) *CounterServer {
-	return &CounterServer{
-		dao:        dao,
-		workerPool: workerPool,
-		objectPool: objectPool,
-		producer:   producer,
-		logger:     logger,
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	s := &CounterServer{
+		dao:          dao,
+		workerPool:   workerPool,
+		objectPool:   objectPool,
+		producer:     producer,
+		config:       cfg,
+		logger:       logger,
+		errorLog:     newErrorLogger(cfg, logger),
+		asyncBatcher: newAdaptiveBatcher(cfg.AsyncBatch),
+		batchJobs:    newBatchJobStore(cfg.BatchJobTTL),
+		eventBreaker: resilience.NewCircuitBreaker(cfg.EventCircuitBreaker, logger),
+	}
+	if producer != nil && cfg.EventQueueSize > 0 {
+		s.eventQueue = newEventQueue(cfg.EventQueueSize, s.sendQueuedEvents, s.dropQueuedEvents)
+	}
+	return s
+}
+
+// SetMetricsManager 设置监控管理器，用于上报自适应批次大小
+func (s *CounterServer) SetMetricsManager(mm *metrics.MetricsManager) {
+	s.metricsManager = mm
+	s.reportAsyncBatch()
+}
+
+// SetLocker 设置分布式锁，保证ResetCounter在多实例间互斥
+func (s *CounterServer) SetLocker(locker *redislock.Locker) {
+	s.locker = locker
+}
+
+// EventCircuitBreaker Kafka事件发送的熔断器，用于注册统一统计
+func (s *CounterServer) EventCircuitBreaker() *resilience.CircuitBreaker {
+	return s.eventBreaker
+}
+
+// AsyncBatchSize 当前异步批量处理的批次大小
+func (s *CounterServer) AsyncBatchSize() int {
+	return s.asyncBatcher.Size()
+}
+
+// reportAsyncBatch 上报当前自适应批次大小和批次间隔
+func (s *CounterServer) reportAsyncBatch() {
+	if s.metricsManager == nil {
+		return
+	}
+	size, delay := s.asyncBatcher.next()
+	s.metricsManager.SetBusinessGauge("async_batch_size", "counter", float64(size))
+	s.metricsManager.SetBusinessGauge("async_batch_delay_seconds", "counter", delay.Seconds())
+}
+
+// newErrorLogger 创建热路径限流错误日志
+func newErrorLogger(cfg *Config, zapLogger *zap.Logger) *logger.RateLimitedLogger {
+	return logger.NewRateLimitedLogger(cfg.ErrorLog, zapLogger)
+}
+
+// FlushErrorLog 输出被限流抑制的错误汇总，服务关闭前调用
+func (s *CounterServer) FlushErrorLog() {
+	s.errorLog.Flush()
+}
+
+// newDeltaLimit 根据配置构建增量限制，未配置的默认增量沿用fallback
+func newDeltaLimit(limit config.DeltaLimitConfig, fallback DeltaLimit) DeltaLimit {
+	result := DeltaLimit{Default: limit.DefaultDelta, Max: limit.MaxDelta}
+	if result.Default == 0 {
+		result.Default = fallback.Default
 	}
+	return result
+}
+
+// resolveDelta 按计数器类型应用默认增量并校验增量上限
+func (s *CounterServer) resolveDelta(counterType string, delta int64) (int64, error) {
+	limit := DefaultConfig().DefaultDeltaLimit
+	if s.config != nil {
+		limit = s.config.DefaultDeltaLimit
+		if typeLimit, ok := s.config.DeltaLimits[counterType]; ok {
+			limit = typeLimit
+		}
+	}
+
+	if delta == 0 {
+		delta = limit.Default
+		if delta == 0 {
+			delta = 1
+		}
+	}
+
+	if limit.Max > 0 && (delta > limit.Max || delta < -limit.Max) {
+		return 0, fmt.Errorf("%w: |%d| > %d for counter type %s", ErrDeltaTooLarge, delta, limit.Max, counterType)
+	}
+	return delta, nil
+}
+
+// batchConcurrency 获取同步批量处理并发数，限制在合理范围内
+func (s *CounterServer) batchConcurrency() int {
+	n := DefaultConfig().BatchConcurrency
+	if s.config != nil {
+		n = s.config.BatchConcurrency
+	}
+
+	if n < minBatchConcurrency {
+		return minBatchConcurrency
+	}
+	if n > maxBatchConcurrency {
+		return maxBatchConcurrency
+	}
+	return n
 }
 
 // IncrementCounter 实现计数器增量操作
@@ -56,23 +324,37 @@ func (s *CounterServer) IncrementCounter(ctx context.Context, req *counter.Incre
 		}, status.Errorf(codes.InvalidArgument, "resource_id and counter_type are required")
 	}
 
-	// 默认增量为1
-	delta := req.Delta
-	if delta == 0 {
-		delta = 1
+	// 应用默认增量并校验上限
+	delta, err := s.resolveDelta(req.CounterType, req.Delta)
+	if err != nil {
+		return &counter.IncrementResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: err.Error(),
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	// 构建Redis key
-	key := fmt.Sprintf("counter:%s:%s", req.ResourceId, req.CounterType)
+	key := dao.CounterKey(ctx, req.ResourceId, req.CounterType)
 
 	// 执行计数器增量操作
-	newValue, err := s.dao.IncrementCounter(ctx, key, delta)
+	newValue, err := s.incrementCounter(ctx, key, req.CounterType, delta)
+	if errors.Is(err, dao.ErrCounterOverflow) {
+		return &counter.IncrementResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: err.Error(),
+				Code:    int32(codes.OutOfRange),
+			},
+		}, status.Error(codes.OutOfRange, err.Error())
+	}
 	if err != nil {
-		s.logger.Error("Failed to increment counter",
+		s.errorLog.Error("Failed to increment counter", err,
 			zap.String("resource_id", req.ResourceId),
 			zap.String("counter_type", req.CounterType),
-			zap.Int64("delta", delta),
-			zap.Error(err))
+			zap.Int64("delta", delta))
 
 		return &counter.IncrementResponse{
 			Status: &common.Status{
@@ -83,22 +365,22 @@ func (s *CounterServer) IncrementCounter(ctx context.Context, req *counter.Incre
 		}, status.Errorf(codes.Internal, "failed to increment counter: %v", err)
 	}
 
-	// 异步发送Kafka事件 (使用Worker Pool)
-	s.workerPool.SubmitTask(func() {
-		event := &kafka.CounterEvent{
-			EventID:     fmt.Sprintf("%s-%d", key, time.Now().UnixNano()),
-			ResourceID:  req.ResourceId,
-			CounterType: req.CounterType,
-			Delta:       delta,
-			NewValue:    newValue,
-			Timestamp:   time.Now(),
-			Source:      "gRPC",
-		}
+	s.updateLeaderboards(ctx, req.CounterType, req.ResourceId, delta, newValue)
+	s.updateHotRank(ctx, req.CounterType, req.ResourceId, delta)
 
-		if err := s.producer.SendCounterEvent(context.Background(), event); err != nil {
-			s.logger.Error("Failed to send counter event to kafka", zap.Error(err))
-		}
-	})
+	// 异步发送Kafka事件 (使用Worker Pool)
+	event := &kafka.CounterEvent{
+		EventID:     kafka.NewEventID(),
+		ResourceID:  req.ResourceId,
+		CounterType: req.CounterType,
+		Delta:       delta,
+		NewValue:    newValue,
+		UserID:      req.UserId,
+		IP:          s.clientIP(ctx, req.ClientIp),
+		Timestamp:   time.Now(),
+		Source:      "gRPC",
+	}
+	s.publishCounterEvents([]*kafka.CounterEvent{event})
 
 	// 构建成功响应
 	return &counter.IncrementResponse{
@@ -113,6 +395,373 @@ func (s *CounterServer) IncrementCounter(ctx context.Context, req *counter.Incre
 	}, nil
 }
 
+// DecrementCounter 实现计数器递减操作
+func (s *CounterServer) DecrementCounter(ctx context.Context, req *counter.DecrementRequest) (*counter.DecrementResponse, error) {
+	// 参数验证
+	if req.ResourceId == "" || req.CounterType == "" {
+		return &counter.DecrementResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "resource_id and counter_type are required",
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Errorf(codes.InvalidArgument, "resource_id and counter_type are required")
+	}
+
+	// 递减量必须为正数，应用默认增量并校验上限
+	delta, err := s.resolveDecrement(req.CounterType, req.Delta)
+	if err != nil {
+		return &counter.DecrementResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: err.Error(),
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	newValue, applied, err := s.decrementCounter(ctx, req.ResourceId, req.CounterType, delta, req.ClampAtZero)
+	if err != nil {
+		st := status.Convert(err)
+		code, message := st.Code(), st.Message()
+		if code == codes.Unknown {
+			s.errorLog.Error("Failed to decrement counter", err,
+				zap.String("resource_id", req.ResourceId),
+				zap.String("counter_type", req.CounterType),
+				zap.Int64("delta", delta))
+			code, message = codes.Internal, "Failed to decrement counter"
+		}
+
+		return &counter.DecrementResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: message,
+				Code:    int32(code),
+			},
+		}, status.Error(code, st.Message())
+	}
+
+	// 异步发送Kafka事件，Delta为实际变化量（负数）
+	event := &kafka.CounterEvent{
+		EventID:     kafka.NewEventID(),
+		ResourceID:  req.ResourceId,
+		CounterType: req.CounterType,
+		Delta:       applied,
+		NewValue:    newValue,
+		UserID:      req.UserId,
+		IP:          s.clientIP(ctx, req.ClientIp),
+		Timestamp:   time.Now(),
+		Source:      "gRPC",
+	}
+	s.publishCounterEvents([]*kafka.CounterEvent{event})
+
+	return &counter.DecrementResponse{
+		Status: &common.Status{
+			Success: true,
+			Message: "Counter decremented successfully",
+			Code:    int32(codes.OK),
+		},
+		CurrentValue: newValue,
+		ResourceId:   req.ResourceId,
+		CounterType:  req.CounterType,
+		Clamped:      applied != -delta,
+	}, nil
+}
+
+// resolveDecrement 校验递减量为正数，并按计数器类型应用默认增量和上限
+func (s *CounterServer) resolveDecrement(counterType string, delta int64) (int64, error) {
+	if delta < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeDecrement, delta)
+	}
+	return s.resolveDelta(counterType, delta)
+}
+
+// decrementCounter 在存储上递减计数器、记录业务指标并更新排行榜，返回当前值和实际变化量
+// 返回的错误已转换为gRPC状态，codes.Unknown表示存储内部错误
+func (s *CounterServer) decrementCounter(ctx context.Context, resourceID, counterType string, delta int64, clampAtZero bool) (newValue int64, applied int64, err error) {
+	start := time.Now()
+	defer func() {
+		s.recordOperation("decrement_counter", counterType, start, err)
+		if err == nil {
+			s.setBusinessGauge("current_counter_value", float64(newValue))
+		}
+	}()
+
+	decrementer, ok := s.dao.(biz.CounterDecrementer)
+	if !ok {
+		return 0, 0, status.Error(codes.Unimplemented, dao.ErrDecrementUnsupported.Error())
+	}
+
+	key := dao.CounterKey(ctx, resourceID, counterType)
+
+	newValue, applied, err = decrementer.DecrementCounter(ctx, key, delta, clampAtZero)
+	if errors.Is(err, dao.ErrCounterOverflow) {
+		return 0, 0, status.Error(codes.OutOfRange, err.Error())
+	}
+	if errors.Is(err, dao.ErrDecrementUnsupported) {
+		return 0, 0, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decrement counter: %w", err)
+	}
+
+	s.updateLeaderboards(ctx, counterType, resourceID, applied, newValue)
+	s.updateHotRank(ctx, counterType, resourceID, applied)
+	return newValue, applied, nil
+}
+
+// resolveSwap 校验比较并设置：新值不能为负数，变化量与增减操作一样受计数器类型的增量上限约束
+// 返回本次设置带来的变化量
+func (s *CounterServer) resolveSwap(counterType string, expected, newValue int64) (int64, error) {
+	if newValue < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeSwapValue, newValue)
+	}
+	if expected == math.MinInt64 || dao.AddOverflows(newValue, -expected) {
+		return 0, fmt.Errorf("%w: %d -> %d for counter type %s", ErrDeltaTooLarge, expected, newValue, counterType)
+	}
+	delta := newValue - expected
+	if delta == 0 {
+		return 0, nil
+	}
+	return s.resolveDelta(counterType, delta)
+}
+
+// CompareAndSwapCounter 当前值等于expected时原子地设置为new_value，用于重试时的幂等写入
+func (s *CounterServer) CompareAndSwapCounter(ctx context.Context, req *counter.CompareAndSwapRequest) (*counter.CompareAndSwapResponse, error) {
+	// 参数验证
+	if req.ResourceId == "" || req.CounterType == "" {
+		return &counter.CompareAndSwapResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "resource_id and counter_type are required",
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Errorf(codes.InvalidArgument, "resource_id and counter_type are required")
+	}
+
+	// 与增减操作相同的校验，避免绕过增量上限直接写入任意值
+	delta, err := s.resolveSwap(req.CounterType, req.Expected, req.NewValue)
+	if err != nil {
+		return &counter.CompareAndSwapResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: err.Error(),
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	setter, ok := s.dao.(biz.CounterCompareAndSetter)
+	if !ok {
+		return &counter.CompareAndSwapResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: dao.ErrCompareAndSetUnsupported.Error(),
+				Code:    int32(codes.Unimplemented),
+			},
+		}, status.Error(codes.Unimplemented, dao.ErrCompareAndSetUnsupported.Error())
+	}
+
+	key := dao.CounterKey(ctx, req.ResourceId, req.CounterType)
+
+	start := time.Now()
+	swapped, err := setter.CompareAndSetCounter(ctx, key, req.Expected, req.NewValue)
+	s.recordDBOperation("compare_and_set", start, err)
+	if errors.Is(err, dao.ErrCompareAndSetUnsupported) {
+		return &counter.CompareAndSwapResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: err.Error(),
+				Code:    int32(codes.Unimplemented),
+			},
+		}, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		s.errorLog.Error("Failed to compare and swap counter", err,
+			zap.String("resource_id", req.ResourceId),
+			zap.String("counter_type", req.CounterType),
+			zap.Int64("expected", req.Expected),
+			zap.Int64("new_value", req.NewValue))
+
+		return &counter.CompareAndSwapResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "Failed to compare and swap counter",
+				Code:    int32(codes.Internal),
+			},
+		}, status.Errorf(codes.Internal, "failed to compare and swap counter: %v", err)
+	}
+
+	if !swapped {
+		return &counter.CompareAndSwapResponse{
+			Status: &common.Status{
+				Success: true,
+				Message: "Counter value does not match expected value",
+				Code:    int32(codes.OK),
+			},
+			Swapped:     false,
+			ResourceId:  req.ResourceId,
+			CounterType: req.CounterType,
+		}, nil
+	}
+
+	s.updateLeaderboards(ctx, req.CounterType, req.ResourceId, delta, req.NewValue)
+	s.updateHotRank(ctx, req.CounterType, req.ResourceId, delta)
+
+	// 异步发送Kafka事件，Delta为本次设置带来的变化量
+	event := &kafka.CounterEvent{
+		EventID:     kafka.NewEventID(),
+		ResourceID:  req.ResourceId,
+		CounterType: req.CounterType,
+		Delta:       delta,
+		NewValue:    req.NewValue,
+		UserID:      req.UserId,
+		IP:          s.clientIP(ctx, req.ClientIp),
+		Timestamp:   time.Now(),
+		Source:      "gRPC",
+	}
+	s.publishCounterEvents([]*kafka.CounterEvent{event})
+
+	return &counter.CompareAndSwapResponse{
+		Status: &common.Status{
+			Success: true,
+			Message: "Counter swapped successfully",
+			Code:    int32(codes.OK),
+		},
+		Swapped:     true,
+		ResourceId:  req.ResourceId,
+		CounterType: req.CounterType,
+	}, nil
+}
+
+// incrementCounter 增加计数器并记录业务指标，配置了过期时间且存储支持时新建的key设置过期
+func (s *CounterServer) incrementCounter(ctx context.Context, key, counterType string, delta int64) (newValue int64, err error) {
+	start := time.Now()
+	defer func() {
+		s.recordOperation("increment_counter", counterType, start, err)
+		if err == nil {
+			s.setBusinessGauge("current_counter_value", float64(newValue))
+		}
+	}()
+
+	if s.config.CounterTTL > 0 {
+		if incrementer, ok := s.dao.(biz.CounterTTLIncrementer); ok {
+			return incrementer.IncrementCounterWithTTL(ctx, key, delta, s.config.CounterTTL)
+		}
+	}
+	return s.dao.IncrementCounter(ctx, key, delta)
+}
+
+// clientIP 请求的客户端IP
+// 对端是可信代理（如网关）时优先使用其转发的client_ip，其次是x-forwarded-for；
+// 其他调用方填写的client_ip和x-forwarded-for一律忽略，使用gRPC对端地址
+func (s *CounterServer) clientIP(ctx context.Context, forwarded string) string {
+	if forwarded != "" && s.config.TrustedProxies.Contains(middleware.PeerIP(ctx)) {
+		return forwarded
+	}
+	return middleware.ClientIP(ctx, s.config.TrustedProxies)
+}
+
+// sendCounterEvent 带超时和熔断保护地发送Kafka事件，失败时丢弃事件
+func (s *CounterServer) sendCounterEvent(event *kafka.CounterEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.eventSendTimeout())
+	defer cancel()
+
+	err := s.eventBreaker.Execute(ctx, func(ctx context.Context) error {
+		return s.producer.SendCounterEvent(ctx, event)
+	})
+	if err != nil {
+		s.dropCounterEvent(event, err)
+		return
+	}
+	atomic.AddInt64(&s.eventsSent, 1)
+}
+
+// publishCounterEvents 异步发送一组Kafka事件，优先放入有界发送队列，未启用队列时提交到Worker Pool
+func (s *CounterServer) publishCounterEvents(events []*kafka.CounterEvent) {
+	if len(events) == 0 || s.producer == nil {
+		return
+	}
+	if s.eventQueue != nil {
+		s.eventQueue.push(events)
+		return
+	}
+	if s.workerPool == nil {
+		s.sendQueuedEvents(events)
+		return
+	}
+	if err := s.workerPool.SubmitTask(func() { s.sendQueuedEvents(events) }); err != nil {
+		s.dropCounterEvents(events, err)
+	}
+}
+
+// sendQueuedEvents 发送一组事件，单个事件走单条发送，多个事件一次批量发送
+func (s *CounterServer) sendQueuedEvents(events []*kafka.CounterEvent) {
+	if len(events) == 1 {
+		s.sendCounterEvent(events[0])
+		return
+	}
+	s.sendCounterEvents(events)
+}
+
+// dropQueuedEvents 记录因发送队列已满被丢弃的最旧事件
+func (s *CounterServer) dropQueuedEvents(events []*kafka.CounterEvent) {
+	atomic.AddInt64(&s.eventsDropped, int64(len(events)))
+	s.recordDroppedEvents("queue_full", len(events))
+	s.errorLog.Error("Kafka event queue is full, oldest events dropped", errEventQueueFull,
+		zap.Int("events", len(events)),
+		zap.Int("capacity", s.config.EventQueueSize))
+}
+
+// DrainEvents 发送完队列中剩余的Kafka事件，服务关闭前调用
+func (s *CounterServer) DrainEvents(ctx context.Context) error {
+	if s.eventQueue == nil {
+		return nil
+	}
+	return s.eventQueue.Close(ctx)
+}
+
+// sendCounterEvents 带超时和熔断保护地一次发送整批Kafka事件，失败时整批丢弃
+func (s *CounterServer) sendCounterEvents(events []*kafka.CounterEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.eventSendTimeout())
+	defer cancel()
+
+	err := s.eventBreaker.Execute(ctx, func(ctx context.Context) error {
+		return s.producer.SendCounterEvents(ctx, events)
+	})
+	if err != nil {
+		s.dropCounterEvents(events, err)
+		return
+	}
+	atomic.AddInt64(&s.eventsSent, int64(len(events)))
+}
+
+// dropCounterEvents 记录被整批丢弃的Kafka事件
+func (s *CounterServer) dropCounterEvents(events []*kafka.CounterEvent, err error) {
+	atomic.AddInt64(&s.eventsDropped, int64(len(events)))
+	s.recordDroppedEvents("send_failed", len(events))
+	s.errorLog.Error("Failed to send counter event batch to kafka, events dropped", err,
+		zap.Int("events", len(events)),
+		zap.String("breaker_state", s.eventBreaker.GetState().String()))
+}
+
+// dropCounterEvent 记录被丢弃的Kafka事件
+func (s *CounterServer) dropCounterEvent(event *kafka.CounterEvent, err error) {
+	atomic.AddInt64(&s.eventsDropped, 1)
+	s.recordDroppedEvents("send_failed", 1)
+	s.errorLog.Error("Failed to send counter event to kafka, event dropped", err,
+		zap.String("event_id", event.EventID),
+		zap.String("breaker_state", s.eventBreaker.GetState().String()))
+}
+
+// eventSendTimeout 获取Kafka事件发送超时时间
+func (s *CounterServer) eventSendTimeout() time.Duration {
+	if s.config == nil || s.config.EventSendTimeout <= 0 {
+		return DefaultConfig().EventSendTimeout
+	}
+	return s.config.EventSendTimeout
+}
+
 // GetCounter 获取单个计数器值
 func (s *CounterServer) GetCounter(ctx context.Context, req *counter.GetCounterRequest) (*counter.GetCounterResponse, error) {
 	// 参数验证
@@ -127,15 +776,31 @@ func (s *CounterServer) GetCounter(ctx context.Context, req *counter.GetCounterR
 	}
 
 	// 构建Redis key
-	key := fmt.Sprintf("counter:%s:%s", req.ResourceId, req.CounterType)
+	key := dao.CounterKey(ctx, req.ResourceId, req.CounterType)
 
 	// 获取计数器值
+	start := time.Now()
 	value, err := s.dao.GetCounter(ctx, key)
+	s.recordDBOperation("get", start, err)
+	s.recordOperation("get_counter", req.CounterType, start, err)
+	if dao.IsCorruptCounterValue(err) {
+		// 存储的值已损坏，key只记录在服务端日志中方便运维定位，不返回给调用方
+		s.logger.Error("Corrupted counter value in store",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return &counter.GetCounterResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: corruptCounterMessage,
+				Code:    int32(codes.DataLoss),
+			},
+		}, status.Error(codes.DataLoss, corruptCounterMessage)
+	}
 	if err != nil {
-		s.logger.Error("Failed to get counter",
+		s.errorLog.Error("Failed to get counter", err,
 			zap.String("resource_id", req.ResourceId),
-			zap.String("counter_type", req.CounterType),
-			zap.Error(err))
+			zap.String("counter_type", req.CounterType))
 
 		return &counter.GetCounterResponse{
 			Status: &common.Status{
@@ -156,10 +821,7 @@ func (s *CounterServer) GetCounter(ctx context.Context, req *counter.GetCounterR
 		Value:       value,
 		ResourceId:  req.ResourceId,
 		CounterType: req.CounterType,
-		LastUpdated: &common.Timestamp{
-			Seconds: time.Now().Unix(),
-			Nanos:   int32(time.Now().Nanosecond()),
-		},
+		LastUpdated: lastUpdatedTimestamp(s.counterUpdatedAt(ctx, []string{key})[key]),
 	}, nil
 }
 
@@ -186,15 +848,15 @@ func (s *CounterServer) BatchGetCounters(ctx context.Context, req *counter.Batch
 		if r.ResourceId == "" || r.CounterType == "" {
 			continue
 		}
-		key := fmt.Sprintf("counter:%s:%s", r.ResourceId, r.CounterType)
+		key := dao.CounterKey(ctx, r.ResourceId, r.CounterType)
 		*keys = append(*keys, key)
 		reqToKey[key] = r
 	}
 
-	// 批量获取计数器值
-	counts, err := s.dao.GetMultiCounters(ctx, *keys)
+	// 批量获取计数器值，存储支持时保留单个key的错误，只有全部失败才整体报错
+	counts, keyErrs, err := s.getMultiCounters(ctx, *keys)
 	if err != nil {
-		s.logger.Error("Failed to batch get counters", zap.Error(err))
+		s.errorLog.Error("Failed to batch get counters", err)
 		return &counter.BatchGetResponse{
 			Status: &common.Status{
 				Success: false,
@@ -204,6 +866,9 @@ func (s *CounterServer) BatchGetCounters(ctx context.Context, req *counter.Batch
 		}, status.Errorf(codes.Internal, "failed to batch get counters: %v", err)
 	}
 
+	// 读取成功的计数器附带最后写入时间
+	updatedAt := s.counterUpdatedAt(ctx, *keys)
+
 	// 构建响应
 	results := make([]*counter.GetCounterResponse, 0, len(*keys))
 	for _, key := range *keys {
@@ -212,6 +877,18 @@ func (s *CounterServer) BatchGetCounters(ctx context.Context, req *counter.Batch
 			continue
 		}
 
+		if keyErr, failed := keyErrs[key]; failed {
+			s.logger.Warn("Failed to get counter in batch",
+				zap.String("key", key),
+				zap.Error(keyErr))
+			results = append(results, &counter.GetCounterResponse{
+				Status:      batchGetErrorStatus(keyErr),
+				ResourceId:  r.ResourceId,
+				CounterType: r.CounterType,
+			})
+			continue
+		}
+
 		value := counts[key] // 如果key不存在，会返回0值
 		results = append(results, &counter.GetCounterResponse{
 			Status: &common.Status{
@@ -222,28 +899,256 @@ func (s *CounterServer) BatchGetCounters(ctx context.Context, req *counter.Batch
 			Value:       value,
 			ResourceId:  r.ResourceId,
 			CounterType: r.CounterType,
-			LastUpdated: &common.Timestamp{
-				Seconds: time.Now().Unix(),
-				Nanos:   int32(time.Now().Nanosecond()),
-			},
+			LastUpdated: lastUpdatedTimestamp(updatedAt[key]),
 		})
 	}
 
+	message := "Batch get completed"
+	if len(keyErrs) > 0 {
+		s.logger.Warn("Batch get completed with failed counters",
+			zap.Int("failed", len(keyErrs)),
+			zap.Int("total", len(*keys)))
+		message = fmt.Sprintf("Batch get completed with %d failed counters", len(keyErrs))
+	}
+
 	return &counter.BatchGetResponse{
 		Status: &common.Status{
 			Success: true,
-			Message: "Batch get completed",
+			Message: message,
 			Code:    int32(codes.OK),
 		},
 		Counters: results,
 	}, nil
 }
 
+// counterUpdatedAt 读取计数器最后写入时间，存储不支持或读取失败时返回nil，响应中不设置LastUpdated
+func (s *CounterServer) counterUpdatedAt(ctx context.Context, keys []string) map[string]time.Time {
+	reader, ok := s.dao.(biz.CounterUpdateTimeReader)
+	if !ok || len(keys) == 0 {
+		return nil
+	}
+	times, err := reader.GetCounterUpdatedAt(ctx, keys)
+	if err != nil {
+		s.logger.Warn("Failed to read counter update times",
+			zap.Int("keys", len(keys)),
+			zap.Error(err))
+		return nil
+	}
+	return times
+}
+
+// lastUpdatedTimestamp 将计数器最后写入时间转换为响应时间戳，时间未知时返回nil
+func lastUpdatedTimestamp(at time.Time) *common.Timestamp {
+	if at.IsZero() {
+		return nil
+	}
+	return &common.Timestamp{
+		Seconds: at.Unix(),
+		Nanos:   int32(at.Nanosecond()),
+	}
+}
+
+// getMultiCounters 批量读取计数器，存储支持时返回单个key的错误
+func (s *CounterServer) getMultiCounters(ctx context.Context, keys []string) (map[string]int64, map[string]error, error) {
+	if getter, ok := s.dao.(biz.CounterPartialGetter); ok {
+		return getter.GetMultiCountersPartial(ctx, keys)
+	}
+	counts, err := s.dao.GetMultiCounters(ctx, keys)
+	return counts, nil, err
+}
+
+// 读取失败时返回给调用方的通用错误信息，具体错误（含Redis key）只记录在服务端日志中
+const (
+	corruptCounterMessage     = "stored counter value is corrupted"
+	counterUnavailableMessage = "counter temporarily unavailable"
+)
+
+// batchGetErrorStatus 批量读取中单个计数器失败时的状态
+func batchGetErrorStatus(err error) *common.Status {
+	code, message := codes.Unavailable, counterUnavailableMessage
+	if dao.IsCorruptCounterValue(err) {
+		code, message = codes.DataLoss, corruptCounterMessage
+	}
+	return &common.Status{
+		Success: false,
+		Message: message,
+		Code:    int32(code),
+	}
+}
+
+// GetOrInitCounter 获取计数器，不存在时原子地初始化为指定值
+func (s *CounterServer) GetOrInitCounter(ctx context.Context, req *counter.GetOrInitRequest) (*counter.GetOrInitResponse, error) {
+	// 参数验证
+	if req.ResourceId == "" || req.CounterType == "" {
+		return &counter.GetOrInitResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "resource_id and counter_type are required",
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Errorf(codes.InvalidArgument, "resource_id and counter_type are required")
+	}
+
+	initializer, ok := s.dao.(biz.CounterInitializer)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, dao.ErrGetOrInitUnsupported.Error())
+	}
+
+	key := dao.CounterKey(ctx, req.ResourceId, req.CounterType)
+
+	value, created, err := initializer.GetOrInitCounter(ctx, key, req.InitialValue)
+	if err != nil {
+		if errors.Is(err, dao.ErrGetOrInitUnsupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+
+		s.errorLog.Error("Failed to get or init counter", err,
+			zap.String("resource_id", req.ResourceId),
+			zap.String("counter_type", req.CounterType))
+
+		return &counter.GetOrInitResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "Failed to get or init counter",
+				Code:    int32(codes.Internal),
+			},
+		}, status.Errorf(codes.Internal, "failed to get or init counter: %v", err)
+	}
+
+	message := "Counter already exists"
+	if created {
+		message = "Counter initialized"
+	}
+
+	return &counter.GetOrInitResponse{
+		Status: &common.Status{
+			Success: true,
+			Message: message,
+			Code:    int32(codes.OK),
+		},
+		Value:       value,
+		ResourceId:  req.ResourceId,
+		CounterType: req.CounterType,
+		Created:     created,
+	}, nil
+}
+
+// GetResourceCounters 获取资源下所有类型的计数器
+func (s *CounterServer) GetResourceCounters(ctx context.Context, req *counter.GetResourceCountersRequest) (*counter.GetResourceCountersResponse, error) {
+	// 参数验证
+	if req.ResourceId == "" {
+		return &counter.GetResourceCountersResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "resource_id is required",
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Errorf(codes.InvalidArgument, "resource_id is required")
+	}
+
+	scanner, ok := s.dao.(biz.CounterScanner)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, dao.ErrScanUnsupported.Error())
+	}
+
+	limit := s.config.MaxResourceTypes
+	if limit <= 0 {
+		limit = DefaultConfig().MaxResourceTypes
+	}
+
+	counters, truncated, err := dao.ScanResourceCounters(ctx, scanner, req.ResourceId, limit)
+	if err != nil {
+		if errors.Is(err, dao.ErrScanUnsupported) {
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+
+		s.errorLog.Error("Failed to get resource counters", err,
+			zap.String("resource_id", req.ResourceId))
+
+		return &counter.GetResourceCountersResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "Failed to get resource counters",
+				Code:    int32(codes.Internal),
+			},
+		}, status.Errorf(codes.Internal, "failed to get resource counters: %v", err)
+	}
+
+	return &counter.GetResourceCountersResponse{
+		Status: &common.Status{
+			Success: true,
+			Message: "Success",
+			Code:    int32(codes.OK),
+		},
+		ResourceId: req.ResourceId,
+		Counters:   counters,
+		Truncated:  truncated,
+	}, nil
+}
+
+// FindCountersAbove 流式返回指定类型中计数值超过阈值的资源（管理接口）
+func (s *CounterServer) FindCountersAbove(req *counter.FindCountersAboveRequest, stream counter.CounterService_FindCountersAboveServer) error {
+	if req.CounterType == "" {
+		return status.Error(codes.InvalidArgument, "counter_type is required")
+	}
+
+	defaults := DefaultConfig()
+	maxResults := s.config.MaxFindResults
+	if maxResults <= 0 {
+		maxResults = defaults.MaxFindResults
+	}
+	maxScan := s.config.MaxFindScanKeys
+	if maxScan <= 0 {
+		maxScan = defaults.MaxFindScanKeys
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxResults {
+		limit = maxResults
+	}
+
+	ctx := stream.Context()
+	entries, truncated, err := dao.FindCountersAbove(ctx, s.dao, req.CounterType, req.Threshold, limit, maxScan)
+	if err != nil {
+		if errors.Is(err, dao.ErrScanUnsupported) {
+			return status.Error(codes.Unimplemented, err.Error())
+		}
+		s.errorLog.Error("Failed to find counters above threshold", err,
+			zap.String("counter_type", req.CounterType),
+			zap.Int64("threshold", req.Threshold))
+		return status.Errorf(codes.Internal, "failed to find counters: %v", err)
+	}
+
+	// 扫描达到上限时通过trailer告知客户端结果不完整
+	if truncated {
+		s.logger.Warn("FindCountersAbove scan truncated",
+			zap.String("counter_type", req.CounterType),
+			zap.Int("max_scan_keys", maxScan))
+		stream.SetTrailer(metadata.Pairs(ScanTruncatedTrailer, "true"))
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err := stream.Send(&counter.CounterAboveEntry{
+			ResourceId:  entry.Member,
+			CounterType: req.CounterType,
+			Value:       entry.Score,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // HealthCheck 健康检查
 func (s *CounterServer) HealthCheck(ctx context.Context, req *counter.HealthCheckRequest) (*counter.HealthCheckResponse, error) {
 	// 检查Redis连接 - 简单测试获取一个不存在的key
 	_, err := s.dao.GetCounter(ctx, "health_check_test")
 	if err != nil {
+		s.setServiceHealth("redis", false)
+
 		return &counter.HealthCheckResponse{
 			Status: &common.Status{
 				Success: false,
@@ -258,9 +1163,30 @@ func (s *CounterServer) HealthCheck(ctx context.Context, req *counter.HealthChec
 		}, nil
 	}
 
-	// 检查Worker Pool状态
-	poolStats := s.workerPool.GetStats()
-	objectStats := s.objectPool.GetStats()
+	breakerState := s.eventBreaker.GetState()
+	s.setServiceHealth("redis", true)
+	s.setServiceHealth("kafka", breakerState != resilience.StateOpen)
+
+	details := map[string]string{
+		"redis":                "healthy",
+		"event_count":          fmt.Sprintf("%d", atomic.LoadInt64(&s.eventsSent)),
+		"kafka_events_dropped": fmt.Sprintf("%d", atomic.LoadInt64(&s.eventsDropped)),
+		"kafka_breaker_state":  breakerState.String(),
+	}
+	if s.eventQueue != nil {
+		details["kafka_event_queue_length"] = fmt.Sprintf("%d", s.eventQueue.Len())
+		details["kafka_event_queue_dropped"] = fmt.Sprintf("%d", s.eventQueue.Dropped())
+	}
+
+	// 检查Worker Pool和对象池状态
+	if s.workerPool != nil {
+		poolStats := s.workerPool.GetStats()
+		details["worker_pool_general_cap"] = fmt.Sprintf("%d", poolStats.GeneralPool.Cap)
+		details["worker_pool_counter_cap"] = fmt.Sprintf("%d", poolStats.CounterPool.Cap)
+	}
+	if s.objectPool != nil {
+		details["object_pool_hit_rate"] = fmt.Sprintf("%.2f", s.objectPool.GetStats().Response.Hit)
+	}
 
 	return &counter.HealthCheckResponse{
 		Status: &common.Status{
@@ -269,12 +1195,7 @@ func (s *CounterServer) HealthCheck(ctx context.Context, req *counter.HealthChec
 			Code:    int32(codes.OK),
 		},
 		Service: "counter",
-		Details: map[string]string{
-			"redis":                   "healthy",
-			"worker_pool_general_cap": fmt.Sprintf("%d", poolStats.GeneralPool.Cap),
-			"worker_pool_counter_cap": fmt.Sprintf("%d", poolStats.CounterPool.Cap),
-			"object_pool_hit_rate":    fmt.Sprintf("%.2f", objectStats.Response.Hit),
-		},
+		Details: details,
 	}, nil
 }
 
@@ -308,9 +1229,17 @@ func (s *CounterServer) BatchIncrementCounters(ctx context.Context, req *counter
 
 	if req.Async {
 		// 异步处理：立即返回响应，后台处理
-		go s.processBatchIncrementAsync(req.Operations)
+		asyncCtx := asyncBatchContext(ctx)
+		tenantID, _ := middleware.TenantIDFromContext(ctx)
 
-		return &counter.BatchIncrementResponse{
+		// 需要跟踪时创建批量任务，客户端通过GetBatchStatus查询进度和结果
+		var job *batchJob
+		if req.TrackJob {
+			job = s.batchJobs.create(tenantID, len(req.Operations))
+		}
+		go s.processBatchIncrementAsync(asyncCtx, req.Operations, job)
+
+		resp := &counter.BatchIncrementResponse{
 			Status: &common.Status{
 				Success: true,
 				Message: "Batch operations accepted for async processing",
@@ -318,7 +1247,11 @@ func (s *CounterServer) BatchIncrementCounters(ctx context.Context, req *counter
 			},
 			ProcessedCount: 0, // 异步模式下不等待处理完成
 			FailedCount:    0,
-		}, nil
+		}
+		if job != nil {
+			resp.JobId = job.id
+		}
+		return resp, nil
 	}
 
 	// 同步批量处理
@@ -334,49 +1267,86 @@ func (s *CounterServer) processBatchIncrementSync(ctx context.Context, operation
 	type operationResult struct {
 		index  int
 		result *counter.IncrementResponse
+		event  *kafka.CounterEvent
 		err    error
 	}
 
+	// 缓冲区足够容纳全部结果，worker写入不会阻塞
 	resultChan := make(chan operationResult, len(operations))
-	maxWorkers := 10 // 控制并发数，替代s.config.WorkerPoolSize
-	semaphore := make(chan struct{}, maxWorkers)
+	semaphore := make(chan struct{}, s.batchConcurrency()) // 控制并发数
+
+	var wg sync.WaitGroup
 
 	// 启动worker处理每个操作
 	for i, op := range operations {
+		wg.Add(1)
 		go func(index int, operation *counter.IncrementRequest) {
-			semaphore <- struct{}{}        // 获取信号量
+			defer wg.Done()
+
+			// 获取信号量，请求取消时直接退出
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				resultChan <- operationResult{index: index, err: ctx.Err()}
+				return
+			}
 			defer func() { <-semaphore }() // 释放信号量
 
 			// 处理单个增量操作
-			result, err := s.processIncrementOperation(ctx, operation)
+			result, event, err := s.processIncrementOperation(ctx, operation)
 			resultChan <- operationResult{
 				index:  index,
 				result: result,
+				event:  event,
 				err:    err,
 			}
 		}(i, op)
 	}
 
-	// 收集结果
+	// 所有worker退出后关闭结果通道
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	// 收集结果和Kafka事件，整批处理完后一次发送
+	events := make([]*kafka.CounterEvent, 0, len(operations))
 	for i := 0; i < len(operations); i++ {
 		select {
 		case result := <-resultChan:
+			if result.event != nil {
+				events = append(events, result.event)
+			}
 			if result.err != nil {
 				failedCount++
 				results[result.index] = &counter.IncrementResponse{
 					Status: &common.Status{
 						Success: false,
 						Message: result.err.Error(),
+						Code:    int32(status.Code(result.err)),
 					},
 				}
-				s.logger.Error("Batch operation failed",
-					zap.Int("index", result.index),
-					zap.Error(result.err))
+				s.errorLog.Error("Batch operation failed", result.err,
+					zap.Int("index", result.index))
 			} else {
 				processedCount++
 				results[result.index] = result.result
 			}
 		case <-ctx.Done():
+			// 等待worker感知取消并退出，排空结果通道，避免goroutine泄漏
+			// 已经写入存储的操作仍需发送事件
+			for result := range resultChan {
+				if result.event != nil {
+					events = append(events, result.event)
+				}
+			}
+			s.publishCounterEvents(events)
+
+			s.logger.Warn("Batch increment cancelled",
+				zap.Int32("processed", processedCount),
+				zap.Int32("failed", failedCount),
+				zap.Error(ctx.Err()))
+
 			return &counter.BatchIncrementResponse{
 				Status: &common.Status{
 					Success: false,
@@ -386,6 +1356,8 @@ func (s *CounterServer) processBatchIncrementSync(ctx context.Context, operation
 		}
 	}
 
+	s.publishCounterEvents(events)
+
 	s.logger.Info("Batch increment completed",
 		zap.Int32("processed", processedCount),
 		zap.Int32("failed", failedCount))
@@ -401,46 +1373,119 @@ func (s *CounterServer) processBatchIncrementSync(ctx context.Context, operation
 	}, nil
 }
 
-// processBatchIncrementAsync 异步批量处理
-func (s *CounterServer) processBatchIncrementAsync(operations []*counter.IncrementRequest) {
-	ctx := context.Background()
+// GetBatchStatus 查询异步批量任务的进度和已处理操作的结果
+func (s *CounterServer) GetBatchStatus(ctx context.Context, req *counter.GetBatchStatusRequest) (*counter.GetBatchStatusResponse, error) {
+	if req.JobId == "" {
+		return &counter.GetBatchStatusResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "job_id is required",
+				Code:    int32(codes.InvalidArgument),
+			},
+		}, status.Errorf(codes.InvalidArgument, "job_id is required")
+	}
+
+	// 任务按租户隔离，其它租户的任务与不存在的任务返回相同结果
+	tenantID, _ := middleware.TenantIDFromContext(ctx)
+	job, ok := s.batchJobs.get(tenantID, req.JobId)
+	if !ok {
+		return &counter.GetBatchStatusResponse{
+			Status: &common.Status{
+				Success: false,
+				Message: "batch job not found or expired",
+				Code:    int32(codes.NotFound),
+			},
+			JobId: req.JobId,
+		}, status.Errorf(codes.NotFound, "batch job %s not found or expired", req.JobId)
+	}
+
+	return job.snapshot(), nil
+}
+
+// asyncBatchContext 异步批量处理的context，不受请求生命周期影响
+// 保留租户，以及解析事件客户端IP所需的对端地址和x-forwarded-for
+func asyncBatchContext(ctx context.Context) context.Context {
+	asyncCtx := middleware.WithClientInfo(context.Background(), middleware.ClientInfoFromContext(ctx))
+	if forwarded := metadata.ValueFromIncomingContext(ctx, middleware.ForwardedForMetadataKey); len(forwarded) > 0 {
+		md := metadata.MD{middleware.ForwardedForMetadataKey: append([]string(nil), forwarded...)}
+		asyncCtx = metadata.NewIncomingContext(asyncCtx, md)
+	}
+	if tenantID, ok := middleware.TenantIDFromContext(ctx); ok {
+		asyncCtx = middleware.WithTenantID(asyncCtx, tenantID)
+	}
+	return asyncCtx
+}
+
+// processBatchIncrementAsync 异步批量处理，job非空时记录每个操作的结果
+func (s *CounterServer) processBatchIncrementAsync(ctx context.Context, operations []*counter.IncrementRequest, job *batchJob) {
 	s.logger.Info("Starting async batch processing", zap.Int("operations", len(operations)))
 
-	// 分批处理，避免一次性处理太多数据
-	const asyncBatchSize = 100
-	for i := 0; i < len(operations); i += asyncBatchSize {
-		end := i + asyncBatchSize
+	// 分批处理，批次大小和间隔根据Redis耗时自适应调整
+	batchNum := 0
+	for i := 0; i < len(operations); {
+		size, _ := s.asyncBatcher.next()
+		end := i + size
 		if end > len(operations) {
 			end = len(operations)
 		}
 
-		batch := operations[i:end]
-		s.processAsyncBatch(ctx, batch, i/asyncBatchSize+1)
+		batchNum++
+		start := time.Now()
+		s.processAsyncBatch(ctx, operations[i:end], i, batchNum, job)
+		s.asyncBatcher.observe(end-i, time.Since(start))
+		s.reportAsyncBatch()
+
+		i = end
+		if i >= len(operations) {
+			break
+		}
 
-		// 批次间短暂休息，避免Redis过载
-		time.Sleep(10 * time.Millisecond)
+		// 批次间休息，Redis变慢时间隔随之加大
+		_, delay := s.asyncBatcher.next()
+		select {
+		case <-ctx.Done():
+			s.logger.Warn("Async batch processing cancelled",
+				zap.Int("processed", i),
+				zap.Int("total_operations", len(operations)))
+			if job != nil {
+				job.finish(counter.BatchJobState_BATCH_JOB_STATE_CANCELLED, time.Now())
+			}
+			return
+		case <-time.After(delay):
+		}
 	}
 
+	if job != nil {
+		job.finish(counter.BatchJobState_BATCH_JOB_STATE_COMPLETED, time.Now())
+	}
 	s.logger.Info("Async batch processing completed", zap.Int("total_operations", len(operations)))
 }
 
-// processAsyncBatch 处理异步批次
-func (s *CounterServer) processAsyncBatch(ctx context.Context, batch []*counter.IncrementRequest, batchNum int) {
+// processAsyncBatch 处理异步批次，offset为批次首个操作在整个请求中的下标
+func (s *CounterServer) processAsyncBatch(ctx context.Context, batch []*counter.IncrementRequest, offset, batchNum int, job *batchJob) {
 	var successCount, errorCount int
+	events := make([]*kafka.CounterEvent, 0, len(batch))
 
-	for _, op := range batch {
-		_, err := s.processIncrementOperation(ctx, op)
+	for i, op := range batch {
+		result, event, err := s.processIncrementOperation(ctx, op)
+		if event != nil {
+			events = append(events, event)
+		}
+		if job != nil {
+			job.record(offset+i, result, err)
+		}
 		if err != nil {
 			errorCount++
-			s.logger.Error("Async operation failed",
+			s.errorLog.Error("Async operation failed", err,
 				zap.Int("batch", batchNum),
-				zap.String("resource_id", op.ResourceId),
-				zap.Error(err))
+				zap.String("resource_id", op.ResourceId))
 		} else {
 			successCount++
 		}
 	}
 
+	s.publishCounterEvents(events)
+
 	s.logger.Debug("Async batch completed",
 		zap.Int("batch", batchNum),
 		zap.Int("success", successCount),
@@ -448,26 +1493,45 @@ func (s *CounterServer) processAsyncBatch(ctx context.Context, batch []*counter.
 }
 
 // processIncrementOperation 处理单个增量操作 - 提取公共逻辑
-func (s *CounterServer) processIncrementOperation(ctx context.Context, req *counter.IncrementRequest) (*counter.IncrementResponse, error) {
+// 成功时同时返回待发送的Kafka事件，由调用方按批次统一发送
+func (s *CounterServer) processIncrementOperation(ctx context.Context, req *counter.IncrementRequest) (*counter.IncrementResponse, *kafka.CounterEvent, error) {
+	// 请求已取消时不再执行
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
 	// 参数验证
 	if req.ResourceId == "" || req.CounterType == "" {
-		return nil, fmt.Errorf("resource_id and counter_type are required")
+		return nil, nil, fmt.Errorf("resource_id and counter_type are required")
 	}
 
-	// 直接处理增量操作
-	delta := req.Delta
-	if delta == 0 {
-		delta = 1
+	// 应用默认增量并校验上限
+	delta, err := s.resolveDelta(req.CounterType, req.Delta)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// 负增量在存储支持时走递减路径，按配置截断在0
+	if delta < 0 && delta != math.MinInt64 {
+		if _, ok := s.dao.(biz.CounterDecrementer); ok {
+			return s.processDecrementOperation(ctx, req, -delta)
+		}
 	}
 
-	key := fmt.Sprintf("counter:%s:%s", req.ResourceId, req.CounterType)
+	key := dao.CounterKey(ctx, req.ResourceId, req.CounterType)
 
 	// 使用Redis DAO进行增量操作
-	newValue, err := s.dao.IncrementCounter(ctx, key, delta)
+	newValue, err := s.incrementCounter(ctx, key, req.CounterType, delta)
+	if errors.Is(err, dao.ErrCounterOverflow) {
+		return nil, nil, status.Error(codes.OutOfRange, err.Error())
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to increment counter: %w", err)
+		return nil, nil, fmt.Errorf("failed to increment counter: %w", err)
 	}
 
+	s.updateLeaderboards(ctx, req.CounterType, req.ResourceId, delta, newValue)
+	s.updateHotRank(ctx, req.CounterType, req.ResourceId, delta)
+
 	return &counter.IncrementResponse{
 		CurrentValue: newValue,
 		ResourceId:   req.ResourceId,
@@ -477,5 +1541,39 @@ func (s *CounterServer) processIncrementOperation(ctx context.Context, req *coun
 			Message: "Counter incremented successfully",
 			Code:    int32(codes.OK),
 		},
-	}, nil
+	}, s.batchCounterEvent(ctx, req, delta, newValue), nil
+}
+
+// processDecrementOperation 处理批量操作中的负增量，delta为递减量（正数）
+func (s *CounterServer) processDecrementOperation(ctx context.Context, req *counter.IncrementRequest, delta int64) (*counter.IncrementResponse, *kafka.CounterEvent, error) {
+	newValue, applied, err := s.decrementCounter(ctx, req.ResourceId, req.CounterType, delta, s.config.ClampBatchDecrements)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &counter.IncrementResponse{
+		CurrentValue: newValue,
+		ResourceId:   req.ResourceId,
+		CounterType:  req.CounterType,
+		Status: &common.Status{
+			Success: true,
+			Message: "Counter decremented successfully",
+			Code:    int32(codes.OK),
+		},
+	}, s.batchCounterEvent(ctx, req, applied, newValue), nil
+}
+
+// batchCounterEvent 构造批量操作的Kafka事件，Delta为实际变化量
+func (s *CounterServer) batchCounterEvent(ctx context.Context, req *counter.IncrementRequest, delta, newValue int64) *kafka.CounterEvent {
+	return &kafka.CounterEvent{
+		EventID:     kafka.NewEventID(),
+		ResourceID:  req.ResourceId,
+		CounterType: req.CounterType,
+		Delta:       delta,
+		NewValue:    newValue,
+		UserID:      req.UserId,
+		IP:          s.clientIP(ctx, req.ClientIp),
+		Timestamp:   time.Now(),
+		Source:      "BATCH",
+	}
 }
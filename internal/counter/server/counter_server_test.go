@@ -0,0 +1,1300 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/biz"
+	"high-go-press/internal/dao"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/config"
+	resilience "high-go-press/pkg/grpc"
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/metrics"
+	"high-go-press/pkg/middleware"
+	"high-go-press/pkg/pool"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newTestCounterServer(repo *daotest.MemoryCounterRepo) *CounterServer {
+	return NewCounterServer(repo, nil, nil, nil, DefaultConfig(), zap.NewNop())
+}
+
+func buildOperations(n int) []*counter.IncrementRequest {
+	operations := make([]*counter.IncrementRequest, n)
+	for i := range operations {
+		operations[i] = &counter.IncrementRequest{
+			ResourceId:  "article_1",
+			CounterType: "like",
+			Delta:       1,
+		}
+	}
+	return operations
+}
+
+func TestProcessBatchIncrementSync(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newTestCounterServer(repo)
+
+	resp, err := s.processBatchIncrementSync(context.Background(), buildOperations(50))
+	if err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+	if resp.ProcessedCount != 50 || resp.FailedCount != 0 {
+		t.Errorf("Expected 50 processed and 0 failed, got %d/%d", resp.ProcessedCount, resp.FailedCount)
+	}
+	if repo.Value("counter:article_1:like") != 50 {
+		t.Errorf("Expected counter value 50, got %d", repo.Value("counter:article_1:like"))
+	}
+}
+
+func TestProcessBatchIncrementSyncCancelNoLeak(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	repo.Block = true
+	s := newTestCounterServer(repo)
+
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := s.processBatchIncrementSync(ctx, buildOperations(200))
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// 所有worker应在返回前后迅速退出
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			t.Fatalf("Goroutine leak: baseline %d, now %d", baseline, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProcessBatchIncrementSyncConcurrencyLimit(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	repo.Delay = 5 * time.Millisecond
+	s := NewCounterServer(repo, nil, nil, nil, &Config{BatchConcurrency: 3}, zap.NewNop())
+
+	if _, err := s.processBatchIncrementSync(context.Background(), buildOperations(60)); err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+
+	if peak := repo.MaxInFlight(); peak > 3 {
+		t.Errorf("Expected at most 3 concurrent DAO calls, got %d", peak)
+	}
+}
+
+func TestBatchConcurrencyClamp(t *testing.T) {
+	tests := []struct {
+		configured int
+		expected   int
+	}{
+		{0, minBatchConcurrency},
+		{-5, minBatchConcurrency},
+		{20, 20},
+		{100000, maxBatchConcurrency},
+	}
+
+	for _, tt := range tests {
+		s := NewCounterServer(daotest.NewMemoryCounterRepo(), nil, nil, nil, &Config{BatchConcurrency: tt.configured}, zap.NewNop())
+		if got := s.batchConcurrency(); got != tt.expected {
+			t.Errorf("BatchConcurrency %d: expected %d, got %d", tt.configured, tt.expected, got)
+		}
+	}
+}
+
+// hungProducer 模拟卡死的Kafka生产者，发送阻塞直到ctx取消
+type hungProducer struct {
+	calls    int64
+	inFlight int64
+}
+
+func (p *hungProducer) SendMessage(ctx context.Context, msg *kafka.Message) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (p *hungProducer) SendCounterEvent(ctx context.Context, event *kafka.CounterEvent) error {
+	atomic.AddInt64(&p.calls, 1)
+	atomic.AddInt64(&p.inFlight, 1)
+	defer atomic.AddInt64(&p.inFlight, -1)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (p *hungProducer) SendCounterEvents(ctx context.Context, events []*kafka.CounterEvent) error {
+	atomic.AddInt64(&p.calls, 1)
+	atomic.AddInt64(&p.inFlight, 1)
+	defer atomic.AddInt64(&p.inFlight, -1)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (p *hungProducer) Close() error { return nil }
+
+func (p *hungProducer) GetStats() kafka.ProducerStats { return kafka.ProducerStats{} }
+
+func TestIncrementCounterHungProducerDoesNotExhaustPool(t *testing.T) {
+	workerPool, err := pool.NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create worker pool: %v", err)
+	}
+	defer workerPool.Shutdown(context.Background())
+
+	producer := &hungProducer{}
+	cfg := DefaultConfig()
+	cfg.EventSendTimeout = 20 * time.Millisecond
+	s := NewCounterServer(daotest.NewMemoryCounterRepo(), workerPool, nil, producer, cfg, zap.NewNop())
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableBusiness: true}, zap.NewNop())
+	s.SetMetricsManager(mm)
+
+	// 请求数超过worker池容量，卡死的生产者不应阻塞后续请求
+	requests := workerPool.GetStats().GeneralPool.Cap * 2
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < requests; i++ {
+			_, err := s.IncrementCounter(context.Background(), &counter.IncrementRequest{
+				ResourceId:  "article_1",
+				CounterType: "like",
+				Delta:       1,
+			})
+			if err != nil {
+				t.Errorf("IncrementCounter failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("IncrementCounter blocked by hung producer")
+	}
+
+	// 发送超时后worker应被释放，所有事件被丢弃
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt64(&producer.inFlight) > 0 || atomic.LoadInt64(&s.eventsDropped) < int64(requests) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Workers still pinned by hung producer: %d", atomic.LoadInt64(&producer.inFlight))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if s.eventBreaker.GetState() != resilience.StateOpen {
+		t.Errorf("Expected event circuit breaker to be open, got %s", s.eventBreaker.GetState())
+	}
+	if dropped := atomic.LoadInt64(&s.eventsDropped); dropped != int64(requests) {
+		t.Errorf("Expected %d dropped events, got %d", requests, dropped)
+	}
+	// 超时和熔断拒绝的事件都计入丢弃指标
+	if got := counterMetricValue(t, mm, "test_kafka_events_dropped_total", map[string]string{"service": "counter", "reason": "send_failed"}); got != float64(requests) {
+		t.Errorf("Expected send_failed drop metric %d, got %v", requests, got)
+	}
+}
+
+// batchRecordingProducer 记录每次批量发送的事件
+type batchRecordingProducer struct {
+	kafka.Producer
+	mu      sync.Mutex
+	batches [][]*kafka.CounterEvent
+	single  int
+}
+
+func (p *batchRecordingProducer) SendCounterEvent(ctx context.Context, event *kafka.CounterEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.single++
+	return nil
+}
+
+func (p *batchRecordingProducer) SendCounterEvents(ctx context.Context, events []*kafka.CounterEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches = append(p.batches, events)
+	return nil
+}
+
+func TestBatchIncrementFlushesEventsOnce(t *testing.T) {
+	producer := &batchRecordingProducer{}
+	s := NewCounterServer(daotest.NewMemoryCounterRepo(), nil, nil, producer, DefaultConfig(), zap.NewNop())
+
+	operations := buildOperations(20)
+	operations[3] = &counter.IncrementRequest{ResourceId: "", CounterType: "like", Delta: 1}
+	if _, err := s.processBatchIncrementSync(context.Background(), operations); err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+	if err := s.DrainEvents(context.Background()); err != nil {
+		t.Fatalf("DrainEvents failed: %v", err)
+	}
+
+	// 整批只发送一次，失败的操作不产生事件
+	if len(producer.batches) != 1 || producer.single != 0 {
+		t.Fatalf("Expected a single batch send, got %d batches and %d single sends", len(producer.batches), producer.single)
+	}
+	events := producer.batches[0]
+	if len(events) != 19 {
+		t.Fatalf("Expected 19 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Source != "BATCH" || event.Delta != 1 || event.EventID == "" {
+			t.Errorf("Unexpected batch event: %+v", event)
+		}
+	}
+	if sent := atomic.LoadInt64(&s.eventsSent); sent != 19 {
+		t.Errorf("Expected 19 sent events, got %d", sent)
+	}
+}
+
+func TestTenantsDoNotCollide(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newTestCounterServer(repo)
+	s.objectPool = pool.NewObjectPool()
+
+	acme := middleware.WithTenantID(context.Background(), "acme")
+	globex := middleware.WithTenantID(context.Background(), "globex")
+
+	increment := func(ctx context.Context, delta int64) {
+		if _, _, err := s.processIncrementOperation(ctx, &counter.IncrementRequest{
+			ResourceId:  "article_1",
+			CounterType: "like",
+			Delta:       delta,
+		}); err != nil {
+			t.Fatalf("increment failed: %v", err)
+		}
+	}
+	increment(acme, 3)
+	increment(globex, 5)
+
+	get := func(ctx context.Context) int64 {
+		resp, err := s.GetCounter(ctx, &counter.GetCounterRequest{ResourceId: "article_1", CounterType: "like"})
+		if err != nil {
+			t.Fatalf("GetCounter failed: %v", err)
+		}
+		return resp.Value
+	}
+	if got := get(acme); got != 3 {
+		t.Errorf("Expected acme counter 3, got %d", got)
+	}
+	if got := get(globex); got != 5 {
+		t.Errorf("Expected globex counter 5, got %d", got)
+	}
+
+	// 批量查询同样按租户隔离
+	resp, err := s.BatchGetCounters(acme, &counter.BatchGetRequest{
+		Requests: []*counter.GetCounterRequest{{ResourceId: "article_1", CounterType: "like"}},
+	})
+	if err != nil {
+		t.Fatalf("BatchGetCounters failed: %v", err)
+	}
+	if len(resp.Counters) != 1 || resp.Counters[0].Value != 3 {
+		t.Errorf("Expected acme batch value 3, got %+v", resp.Counters)
+	}
+}
+
+func newDeltaLimitedServer(repo *daotest.MemoryCounterRepo) *CounterServer {
+	cfg := DefaultConfig()
+	cfg.DefaultDeltaLimit = DeltaLimit{Default: 1, Max: 100}
+	cfg.DeltaLimits = map[string]DeltaLimit{
+		"view": {Default: 5, Max: 1000},
+	}
+	return NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+}
+
+func TestDefaultDeltaPerCounterType(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newDeltaLimitedServer(repo)
+
+	resp, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "view"},
+		{ResourceId: "article_1", CounterType: "like"},
+		{ResourceId: "article_1", CounterType: "view", Delta: 7},
+	})
+	if err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+	if resp.FailedCount != 0 {
+		t.Fatalf("Expected no failures, got %d", resp.FailedCount)
+	}
+
+	// view使用类型默认增量5，like使用全局默认增量1，显式增量不受默认值影响
+	if got := repo.Value("counter:article_1:view"); got != 12 {
+		t.Errorf("Expected view counter 12, got %d", got)
+	}
+	if got := repo.Value("counter:article_1:like"); got != 1 {
+		t.Errorf("Expected like counter 1, got %d", got)
+	}
+}
+
+func TestMaxDeltaEnforced(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newDeltaLimitedServer(repo)
+
+	// 单次调用：超过上限返回InvalidArgument，且不写入存储
+	for _, delta := range []int64{101, -101, 1000000000} {
+		_, err := s.IncrementCounter(context.Background(), &counter.IncrementRequest{
+			ResourceId:  "article_1",
+			CounterType: "like",
+			Delta:       delta,
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for delta %d, got %v", delta, err)
+		}
+	}
+	if repo.Len() != 0 {
+		t.Errorf("Expected rejected increments not to be stored, got %d counters", repo.Len())
+	}
+
+	// 批量调用：超限的操作单独失败，其余操作正常执行
+	resp, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "like", Delta: 100},
+		{ResourceId: "article_1", CounterType: "like", Delta: 101},
+		{ResourceId: "article_1", CounterType: "view", Delta: 1000},
+	})
+	if err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+	if resp.ProcessedCount != 2 || resp.FailedCount != 1 {
+		t.Fatalf("Expected 2 processed and 1 failed, got %d/%d", resp.ProcessedCount, resp.FailedCount)
+	}
+	if got := resp.Results[1].Status.Code; got != int32(codes.InvalidArgument) {
+		t.Errorf("Expected InvalidArgument code for oversized batch op, got %d", got)
+	}
+	if got := repo.Value("counter:article_1:like"); got != 100 {
+		t.Errorf("Expected like counter 100, got %d", got)
+	}
+}
+
+func TestNewConfigFromAppConfigDeltaLimits(t *testing.T) {
+	appConfig := &config.Config{}
+	appConfig.Counter.Delta = config.DeltaConfig{
+		Default: config.DeltaLimitConfig{DefaultDelta: 1, MaxDelta: 10000},
+		Types: map[string]config.DeltaLimitConfig{
+			"like": {MaxDelta: 100}, // 未配置默认增量时沿用全局默认值
+		},
+	}
+
+	cfg := NewConfigFromAppConfig(appConfig)
+	if cfg.DefaultDeltaLimit != (DeltaLimit{Default: 1, Max: 10000}) {
+		t.Errorf("Unexpected default delta limit: %+v", cfg.DefaultDeltaLimit)
+	}
+	if got := cfg.DeltaLimits["like"]; got != (DeltaLimit{Default: 1, Max: 100}) {
+		t.Errorf("Unexpected like delta limit: %+v", got)
+	}
+}
+
+func TestGetOrInitCounterConcurrent(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newTestCounterServer(repo)
+
+	const callers = 50
+	responses := make([]*counter.GetOrInitResponse, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := s.GetOrInitCounter(context.Background(), &counter.GetOrInitRequest{
+				ResourceId:   "article_1",
+				CounterType:  "view",
+				InitialValue: int64(1000 + i),
+			})
+			if err != nil {
+				t.Errorf("GetOrInitCounter failed: %v", err)
+				return
+			}
+			responses[i] = resp
+		}(i)
+	}
+	wg.Wait()
+
+	// 只有一个调用方创建成功，其余调用方读取到创建的值
+	var created int
+	stored := repo.Value("counter:article_1:view")
+	for _, resp := range responses {
+		if resp == nil {
+			continue
+		}
+		if resp.Created {
+			created++
+		}
+		if resp.Value != stored {
+			t.Errorf("Expected value %d, got %d", stored, resp.Value)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("Expected exactly one created response, got %d", created)
+	}
+
+	// 已存在的计数器不会被初始值覆盖
+	resp, err := s.GetOrInitCounter(context.Background(), &counter.GetOrInitRequest{
+		ResourceId:   "article_1",
+		CounterType:  "view",
+		InitialValue: 1,
+	})
+	if err != nil {
+		t.Fatalf("GetOrInitCounter failed: %v", err)
+	}
+	if resp.Created || resp.Value != stored {
+		t.Errorf("Expected existing value %d, got created=%v value=%d", stored, resp.Created, resp.Value)
+	}
+}
+
+func TestGetOrInitCounterUnsupportedStore(t *testing.T) {
+	s := NewCounterServer(struct{ biz.CounterRepo }{daotest.NewMemoryCounterRepo()}, nil, nil, nil, DefaultConfig(), zap.NewNop())
+
+	_, err := s.GetOrInitCounter(context.Background(), &counter.GetOrInitRequest{
+		ResourceId:  "article_1",
+		CounterType: "view",
+	})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented, got %v", err)
+	}
+}
+
+func TestGetResourceCounters(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	repo.SetValue("counter:article_1:like", 10)
+	repo.SetValue("counter:article_1:view", 200)
+	repo.SetValue("counter:article_1:follow", 3)
+	// 前缀相近的其他资源不应混入
+	repo.SetValue("counter:article_10:like", 7)
+	repo.SetValue("counter:article_1:draft:like", 1)
+	s := newTestCounterServer(repo)
+
+	resp, err := s.GetResourceCounters(context.Background(), &counter.GetResourceCountersRequest{ResourceId: "article_1"})
+	if err != nil {
+		t.Fatalf("GetResourceCounters failed: %v", err)
+	}
+
+	want := map[string]int64{"like": 10, "view": 200, "follow": 3}
+	if len(resp.Counters) != len(want) {
+		t.Fatalf("Expected %d counter types, got %v", len(want), resp.Counters)
+	}
+	for counterType, value := range want {
+		if resp.Counters[counterType] != value {
+			t.Errorf("Type %s: expected %d, got %d", counterType, value, resp.Counters[counterType])
+		}
+	}
+	if resp.Truncated {
+		t.Error("Expected result not truncated")
+	}
+}
+
+func TestGetResourceCountersCapped(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	for i := 0; i < 5; i++ {
+		repo.SetValue(fmt.Sprintf("counter:article_1:type_%d", i), int64(i))
+	}
+	cfg := DefaultConfig()
+	cfg.MaxResourceTypes = 3
+	s := NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+
+	resp, err := s.GetResourceCounters(context.Background(), &counter.GetResourceCountersRequest{ResourceId: "article_1"})
+	if err != nil {
+		t.Fatalf("GetResourceCounters failed: %v", err)
+	}
+	if len(resp.Counters) != 3 || !resp.Truncated {
+		t.Errorf("Expected 3 truncated counter types, got %v (truncated=%v)", resp.Counters, resp.Truncated)
+	}
+}
+
+func TestGetResourceCountersTruncatedOnlyWhenCutOff(t *testing.T) {
+	tests := []struct {
+		name          string
+		types         int
+		nested        int // 前缀匹配但属于其他资源的key数
+		wantTypes     int
+		wantTruncated bool
+	}{
+		{name: "exactly at limit", types: 3, wantTypes: 3},
+		{name: "one above limit", types: 4, wantTypes: 3, wantTruncated: true},
+		// 其他资源的key占用扫描配额时继续扫描，不能因此误报或漏报截断
+		{name: "at limit with nested keys", types: 3, nested: 10, wantTypes: 3},
+		{name: "above limit with nested keys", types: 4, nested: 10, wantTypes: 3, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := daotest.NewMemoryCounterRepo()
+			for i := 0; i < tt.types; i++ {
+				repo.SetValue(fmt.Sprintf("counter:article_1:type_%d", i), int64(i))
+			}
+			for i := 0; i < tt.nested; i++ {
+				repo.SetValue(fmt.Sprintf("counter:article_1:draft_%d:like", i), 1)
+			}
+			cfg := DefaultConfig()
+			cfg.MaxResourceTypes = 3
+			s := NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+
+			resp, err := s.GetResourceCounters(context.Background(), &counter.GetResourceCountersRequest{ResourceId: "article_1"})
+			if err != nil {
+				t.Fatalf("GetResourceCounters failed: %v", err)
+			}
+			if len(resp.Counters) != tt.wantTypes || resp.Truncated != tt.wantTruncated {
+				t.Errorf("Expected %d types (truncated=%v), got %v (truncated=%v)",
+					tt.wantTypes, tt.wantTruncated, resp.Counters, resp.Truncated)
+			}
+		})
+	}
+}
+
+func TestGetResourceCountersValidation(t *testing.T) {
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+	if _, err := s.GetResourceCounters(context.Background(), &counter.GetResourceCountersRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+
+	unsupported := NewCounterServer(struct{ biz.CounterRepo }{daotest.NewMemoryCounterRepo()}, nil, nil, nil, DefaultConfig(), zap.NewNop())
+	if _, err := unsupported.GetResourceCounters(context.Background(), &counter.GetResourceCountersRequest{ResourceId: "article_1"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented, got %v", err)
+	}
+}
+
+// collectStream 收集服务端流式响应和trailer
+type collectStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	entries []*counter.CounterAboveEntry
+	trailer metadata.MD
+}
+
+func (s *collectStream) Context() context.Context { return s.ctx }
+
+func (s *collectStream) SetTrailer(md metadata.MD) { s.trailer = metadata.Join(s.trailer, md) }
+
+func (s *collectStream) Send(entry *counter.CounterAboveEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestFindCountersAboveLeaderboard(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	repo.SetLeaderboard("leaderboard:like", map[string]int64{"article_1": 500, "article_2": 50, "article_3": 1200, "article_4": 100})
+	// 计数器key中的值与排行榜不同，用于确认走的是排行榜
+	repo.SetValue("counter:article_2:like", 9999)
+	s := newTestCounterServer(repo)
+
+	stream := &collectStream{ctx: context.Background()}
+	if err := s.FindCountersAbove(&counter.FindCountersAboveRequest{CounterType: "like", Threshold: 100}, stream); err != nil {
+		t.Fatalf("FindCountersAbove failed: %v", err)
+	}
+
+	// 只返回严格大于阈值的资源，按计数值从高到低
+	want := []struct {
+		resource string
+		value    int64
+	}{{"article_3", 1200}, {"article_1", 500}}
+	if len(stream.entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %v", len(want), stream.entries)
+	}
+	for i, w := range want {
+		got := stream.entries[i]
+		if got.ResourceId != w.resource || got.Value != w.value || got.CounterType != "like" {
+			t.Errorf("Entry %d: expected %s=%d, got %s=%d", i, w.resource, w.value, got.ResourceId, got.Value)
+		}
+	}
+
+	// limit限制返回数量
+	stream = &collectStream{ctx: context.Background()}
+	if err := s.FindCountersAbove(&counter.FindCountersAboveRequest{CounterType: "like", Threshold: 0, Limit: 1}, stream); err != nil {
+		t.Fatalf("FindCountersAbove failed: %v", err)
+	}
+	if len(stream.entries) != 1 || stream.entries[0].ResourceId != "article_3" {
+		t.Errorf("Expected only article_3 with limit 1, got %v", stream.entries)
+	}
+}
+
+func TestFindCountersAboveCappedAndScanFallback(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	for i := 0; i < 10; i++ {
+		repo.SetValue(fmt.Sprintf("counter:article_%d:view", i), int64(i*100))
+	}
+	repo.SetValue("counter:article_9:like", 5000)
+	cfg := DefaultConfig()
+	cfg.MaxFindResults = 3
+	s := NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+
+	// 没有排行榜时回退到扫描计数器key，limit超过上限时按上限返回
+	stream := &collectStream{ctx: context.Background()}
+	if err := s.FindCountersAbove(&counter.FindCountersAboveRequest{CounterType: "view", Threshold: 250, Limit: 100}, stream); err != nil {
+		t.Fatalf("FindCountersAbove failed: %v", err)
+	}
+	if len(stream.entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %v", stream.entries)
+	}
+	for i, want := range []int64{900, 800, 700} {
+		if stream.entries[i].Value != want || stream.entries[i].CounterType != "view" {
+			t.Errorf("Entry %d: expected view=%d, got %v", i, want, stream.entries[i])
+		}
+	}
+
+	// 扫描未达到上限时不设置截断标记
+	if len(stream.trailer.Get(ScanTruncatedTrailer)) != 0 {
+		t.Errorf("Expected no truncation trailer, got %v", stream.trailer)
+	}
+
+	if err := s.FindCountersAbove(&counter.FindCountersAboveRequest{}, &collectStream{ctx: context.Background()}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestFindCountersAboveReportsTruncatedScan(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	for i := 0; i < 5; i++ {
+		repo.SetValue(fmt.Sprintf("counter:article_%d:view", i), int64(i*100+100))
+	}
+	cfg := DefaultConfig()
+	cfg.MaxFindScanKeys = 5
+	s := NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+
+	// 恰好扫描完全部key时没有截断
+	stream := &collectStream{ctx: context.Background()}
+	if err := s.FindCountersAbove(&counter.FindCountersAboveRequest{CounterType: "view"}, stream); err != nil {
+		t.Fatalf("FindCountersAbove failed: %v", err)
+	}
+	if len(stream.entries) != 5 || len(stream.trailer.Get(ScanTruncatedTrailer)) != 0 {
+		t.Errorf("Expected 5 entries without truncation, got %d entries, trailer %v", len(stream.entries), stream.trailer)
+	}
+
+	// 超过扫描上限时通过trailer告知结果不完整
+	repo.SetValue("counter:article_5:view", 600)
+	stream = &collectStream{ctx: context.Background()}
+	if err := s.FindCountersAbove(&counter.FindCountersAboveRequest{CounterType: "view"}, stream); err != nil {
+		t.Fatalf("FindCountersAbove failed: %v", err)
+	}
+	if len(stream.entries) != 5 {
+		t.Errorf("Expected entries from 5 scanned keys, got %d", len(stream.entries))
+	}
+	if got := stream.trailer.Get(ScanTruncatedTrailer); len(got) != 1 || got[0] != "true" {
+		t.Errorf("Expected truncation trailer, got %v", stream.trailer)
+	}
+}
+
+func TestIncrementCounterOverflowNearInt64Boundary(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	repo.SetValue("counter:article_1:view", math.MaxInt64-5)
+	repo.SetValue("counter:article_1:like", math.MinInt64+5)
+
+	workerPool, err := pool.NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create worker pool: %v", err)
+	}
+	defer workerPool.Shutdown(context.Background())
+
+	producer := kafka.NewMockProducer(zap.NewNop())
+	s := NewCounterServer(repo, workerPool, nil, producer, DefaultConfig(), zap.NewNop())
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		counterType string
+		delta       int64
+		wantCode    codes.Code
+		wantValue   int64
+	}{
+		{"reach max", "view", 5, codes.OK, math.MaxInt64},
+		{"exceed max", "view", 1, codes.OutOfRange, math.MaxInt64},
+		{"huge delta", "view", math.MaxInt64, codes.OutOfRange, math.MaxInt64},
+		{"exceed min", "like", -6, codes.OutOfRange, math.MinInt64 + 5},
+		{"reach min", "like", -5, codes.OK, math.MinInt64},
+	}
+
+	for _, tt := range tests {
+		resp, err := s.IncrementCounter(ctx, &counter.IncrementRequest{
+			ResourceId:  "article_1",
+			CounterType: tt.counterType,
+			Delta:       tt.delta,
+		})
+		if status.Code(err) != tt.wantCode {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.wantCode, err)
+			continue
+		}
+		if resp.Status.Code != int32(tt.wantCode) {
+			t.Errorf("%s: expected status code %v, got %d", tt.name, tt.wantCode, resp.Status.Code)
+		}
+		// 溢出时计数器保持原值，不会回绕为负数
+		if got := repo.Value("counter:article_1:" + tt.counterType); got != tt.wantValue {
+			t.Errorf("%s: expected value %d, got %d", tt.name, tt.wantValue, got)
+		}
+	}
+}
+
+func TestBatchIncrementOverflowReportsOutOfRange(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	repo.SetValue("counter:article_1:view", math.MaxInt64)
+	s := newTestCounterServer(repo)
+
+	resp, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "view", Delta: 1},
+		{ResourceId: "article_2", CounterType: "view", Delta: 1},
+	})
+	if err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+	if resp.FailedCount != 1 || resp.ProcessedCount != 1 {
+		t.Errorf("Expected 1 processed and 1 failed, got %d/%d", resp.ProcessedCount, resp.FailedCount)
+	}
+	if code := resp.Results[0].Status.Code; code != int32(codes.OutOfRange) {
+		t.Errorf("Expected OutOfRange for overflowing operation, got %d", code)
+	}
+}
+
+func TestIncrementCounterEventCarriesClientMetadata(t *testing.T) {
+	workerPool, err := pool.NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create worker pool: %v", err)
+	}
+	defer workerPool.Shutdown(context.Background())
+
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.8"), Port: 52100},
+	})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		req     *counter.IncrementRequest
+		trusted []string
+		wantIP  string
+	}{
+		{
+			name:   "peer address",
+			ctx:    peerCtx,
+			req:    &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", UserId: "user_42"},
+			wantIP: "10.0.0.8",
+		},
+		{
+			name:    "forwarded for from trusted proxy",
+			ctx:     metadata.NewIncomingContext(peerCtx, metadata.Pairs("x-forwarded-for", "203.0.113.7, 10.0.0.1")),
+			req:     &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", UserId: "user_42"},
+			trusted: []string{"10.0.0.0/24"},
+			wantIP:  "203.0.113.7",
+		},
+		{
+			name:    "gateway client ip from trusted proxy",
+			ctx:     peerCtx,
+			req:     &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", UserId: "user_42", ClientIp: "198.51.100.23"},
+			trusted: []string{"10.0.0.8"},
+			wantIP:  "198.51.100.23",
+		},
+		// 不可信的调用方不能通过client_ip或x-forwarded-for伪造客户端IP
+		{
+			name:   "forwarded for from untrusted caller",
+			ctx:    metadata.NewIncomingContext(peerCtx, metadata.Pairs("x-forwarded-for", "203.0.113.7")),
+			req:    &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", UserId: "user_42"},
+			wantIP: "10.0.0.8",
+		},
+		{
+			name:   "client ip from untrusted caller",
+			ctx:    peerCtx,
+			req:    &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", UserId: "user_42", ClientIp: "198.51.100.23"},
+			wantIP: "10.0.0.8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := kafka.NewMockProducer(zap.NewNop())
+			cfg := DefaultConfig()
+			trusted, err := middleware.NewTrustedProxies(tt.trusted)
+			if err != nil {
+				t.Fatalf("NewTrustedProxies failed: %v", err)
+			}
+			cfg.TrustedProxies = trusted
+			s := NewCounterServer(daotest.NewMemoryCounterRepo(), workerPool, nil, producer, cfg, zap.NewNop())
+
+			if _, err := s.IncrementCounter(tt.ctx, tt.req); err != nil {
+				t.Fatalf("IncrementCounter failed: %v", err)
+			}
+
+			// 事件通过Worker Pool异步发送
+			deadline := time.Now().Add(time.Second)
+			for len(producer.GetEvents()) == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			events := producer.GetEvents()
+			if len(events) != 1 {
+				t.Fatalf("Expected 1 event, got %d", len(events))
+			}
+			if events[0].UserID != "user_42" {
+				t.Errorf("Expected user_id user_42, got %q", events[0].UserID)
+			}
+			if events[0].IP != tt.wantIP {
+				t.Errorf("Expected ip %s, got %q", tt.wantIP, events[0].IP)
+			}
+		})
+	}
+}
+
+func TestAsyncBatchIncrementEventCarriesClientIP(t *testing.T) {
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.8"), Port: 52100},
+	})
+	ctx := metadata.NewIncomingContext(peerCtx, metadata.Pairs("x-forwarded-for", "203.0.113.7"))
+
+	producer := kafka.NewMockProducer(zap.NewNop())
+	cfg := DefaultConfig()
+	trusted, err := middleware.NewTrustedProxies([]string{"10.0.0.8"})
+	if err != nil {
+		t.Fatalf("NewTrustedProxies failed: %v", err)
+	}
+	cfg.TrustedProxies = trusted
+	s := NewCounterServer(daotest.NewMemoryCounterRepo(), nil, nil, producer, cfg, zap.NewNop())
+
+	// 异步处理在请求返回后执行，客户端IP仍按请求的对端和转发地址解析
+	_, err = s.BatchIncrementCounters(ctx, &counter.BatchIncrementRequest{
+		Async: true,
+		Operations: []*counter.IncrementRequest{
+			{ResourceId: "article_1", CounterType: "like", ClientIp: "198.51.100.23"},
+			{ResourceId: "article_2", CounterType: "like"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BatchIncrementCounters failed: %v", err)
+	}
+
+	events := waitForEvents(producer, 2)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	wantIPs := map[string]string{"article_1": "198.51.100.23", "article_2": "203.0.113.7"}
+	for _, event := range events {
+		if event.IP != wantIPs[event.ResourceID] {
+			t.Errorf("%s: expected ip %s, got %q", event.ResourceID, wantIPs[event.ResourceID], event.IP)
+		}
+	}
+}
+
+func TestGetCounterCorruptValueHidesKey(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	key := dao.CounterKey(context.Background(), "article_1", "like")
+	repo.ReadErr = fmt.Errorf("%w: key=%s", dao.ErrCounterValueOutOfRange, key)
+	s := newTestCounterServer(repo)
+	core, logs := observer.New(zap.ErrorLevel)
+	s.logger = zap.New(core)
+
+	resp, err := s.GetCounter(context.Background(), &counter.GetCounterRequest{ResourceId: "article_1", CounterType: "like"})
+	if status.Code(err) != codes.DataLoss {
+		t.Fatalf("Expected DataLoss for corrupted value, got %v", err)
+	}
+	// 返回给调用方的信息不包含Redis key，key只记录在服务端日志中
+	if strings.Contains(resp.Status.Message, key) || strings.Contains(status.Convert(err).Message(), key) {
+		t.Errorf("Expected error message not to expose key %q, got %q", key, resp.Status.Message)
+	}
+	entries := logs.FilterMessage("Corrupted counter value in store").All()
+	if len(entries) != 1 || entries[0].ContextMap()["key"] != key {
+		t.Errorf("Expected corrupted key %q to be logged, got %v", key, entries)
+	}
+}
+
+// updateTimeRepo 在daotest.MemoryCounterRepo基础上记录写入时间，时间由now控制
+type updateTimeRepo struct {
+	*daotest.MemoryCounterRepo
+	mu        sync.Mutex
+	now       func() time.Time
+	updatedAt map[string]time.Time
+}
+
+func (r *updateTimeRepo) IncrementCounter(ctx context.Context, key string, increment int64) (int64, error) {
+	value, err := r.MemoryCounterRepo.IncrementCounter(ctx, key, increment)
+	if err == nil {
+		r.mu.Lock()
+		r.updatedAt[key] = r.now()
+		r.mu.Unlock()
+	}
+	return value, err
+}
+
+func (r *updateTimeRepo) GetCounterUpdatedAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	times := make(map[string]time.Time)
+	for _, key := range keys {
+		if at, ok := r.updatedAt[key]; ok {
+			times[key] = at
+		}
+	}
+	return times, nil
+}
+
+func TestGetCounterLastUpdatedReflectsWriteTime(t *testing.T) {
+	ctx := context.Background()
+	writtenAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &updateTimeRepo{
+		MemoryCounterRepo: daotest.NewMemoryCounterRepo(),
+		now:               func() time.Time { return writtenAt },
+		updatedAt:         make(map[string]time.Time),
+	}
+	s := NewCounterServer(repo, nil, nil, nil, DefaultConfig(), zap.NewNop())
+	s.objectPool = pool.NewObjectPool()
+
+	if _, err := repo.IncrementCounter(ctx, dao.CounterKey(ctx, "article_1", "like"), 3); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+
+	// 读取时间晚于写入时间，响应返回的是写入时间
+	resp, err := s.GetCounter(ctx, &counter.GetCounterRequest{ResourceId: "article_1", CounterType: "like"})
+	if err != nil {
+		t.Fatalf("GetCounter failed: %v", err)
+	}
+	if got := resp.LastUpdated; got == nil || got.Seconds != writtenAt.Unix() {
+		t.Errorf("Expected LastUpdated %v, got %v", writtenAt.Unix(), got)
+	}
+
+	// 从未写入的计数器不返回写入时间
+	batch, err := s.BatchGetCounters(ctx, &counter.BatchGetRequest{
+		Requests: []*counter.GetCounterRequest{
+			{ResourceId: "article_1", CounterType: "like"},
+			{ResourceId: "article_2", CounterType: "like"},
+		},
+	})
+	if err != nil || len(batch.Counters) != 2 {
+		t.Fatalf("BatchGetCounters failed: %v, %+v", err, batch)
+	}
+	if got := batch.Counters[0].LastUpdated; got == nil || got.Seconds != writtenAt.Unix() {
+		t.Errorf("Expected batch LastUpdated %v, got %v", writtenAt.Unix(), got)
+	}
+	if batch.Counters[1].LastUpdated != nil {
+		t.Errorf("Expected no LastUpdated for an unwritten counter, got %v", batch.Counters[1].LastUpdated)
+	}
+}
+
+func TestBatchGetCountersPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := daotest.NewMemoryCounterRepo()
+	repo.SetValue(dao.CounterKey(ctx, "article_1", "like"), 5)
+	repo.SetValue(dao.CounterKey(ctx, "article_3", "like"), 7)
+	corruptKey := dao.CounterKey(ctx, "article_2", "like")
+	repo.KeyErrs = map[string]error{
+		corruptKey:                               fmt.Errorf("%w: key=%s", dao.ErrCounterValueMalformed, corruptKey),
+		dao.CounterKey(ctx, "article_4", "like"): fmt.Errorf("i/o timeout"),
+	}
+	s := newTestCounterServer(repo)
+	s.objectPool = pool.NewObjectPool()
+
+	resp, err := s.BatchGetCounters(ctx, &counter.BatchGetRequest{
+		Requests: []*counter.GetCounterRequest{
+			{ResourceId: "article_1", CounterType: "like"},
+			{ResourceId: "article_2", CounterType: "like"},
+			{ResourceId: "article_3", CounterType: "like"},
+			{ResourceId: "article_4", CounterType: "like"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected partial success, got %v", err)
+	}
+	if !resp.Status.Success || !strings.Contains(resp.Status.Message, "2 failed") {
+		t.Errorf("Expected successful batch reporting 2 failures, got %+v", resp.Status)
+	}
+	if len(resp.Counters) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(resp.Counters))
+	}
+
+	// 成功的条目返回值，失败的条目带各自的状态码
+	wantCodes := []codes.Code{codes.OK, codes.DataLoss, codes.OK, codes.Unavailable}
+	wantValues := []int64{5, 0, 7, 0}
+	for i, entry := range resp.Counters {
+		if codes.Code(entry.Status.Code) != wantCodes[i] || entry.Status.Success != (wantCodes[i] == codes.OK) {
+			t.Errorf("Entry %d (%s): expected code %v, got %+v", i, entry.ResourceId, wantCodes[i], entry.Status)
+		}
+		if entry.Value != wantValues[i] {
+			t.Errorf("Entry %d (%s): expected value %d, got %d", i, entry.ResourceId, wantValues[i], entry.Value)
+		}
+		// 单个条目的错误信息不暴露Redis key和底层错误
+		if strings.Contains(entry.Status.Message, "counter:") || strings.Contains(entry.Status.Message, "i/o timeout") {
+			t.Errorf("Entry %d (%s): expected generic error message, got %q", i, entry.ResourceId, entry.Status.Message)
+		}
+	}
+}
+
+func TestBatchGetCountersAllFailed(t *testing.T) {
+	ctx := context.Background()
+	repo := daotest.NewMemoryCounterRepo()
+	repo.KeyErrs = map[string]error{dao.CounterKey(ctx, "article_1", "like"): fmt.Errorf("i/o timeout")}
+	s := newTestCounterServer(repo)
+	s.objectPool = pool.NewObjectPool()
+
+	_, err := s.BatchGetCounters(ctx, &counter.BatchGetRequest{
+		Requests: []*counter.GetCounterRequest{{ResourceId: "article_1", CounterType: "like"}},
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Expected Internal when every key fails, got %v", err)
+	}
+}
+
+// waitForEvents 等待Worker Pool异步发送的Kafka事件
+func waitForEvents(producer *kafka.MockProducer, n int) []kafka.CounterEvent {
+	deadline := time.Now().Add(time.Second)
+	for len(producer.GetEvents()) < n && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return producer.GetEvents()
+}
+
+func TestDecrementCounter(t *testing.T) {
+	workerPool, err := pool.NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create worker pool: %v", err)
+	}
+	defer workerPool.Shutdown(context.Background())
+
+	repo := daotest.NewMemoryCounterRepo()
+	key := dao.CounterKey(context.Background(), "article_1", "like")
+	repo.SetValue(key, 5)
+	producer := kafka.NewMockProducer(zap.NewNop())
+	s := NewCounterServer(repo, workerPool, nil, producer, DefaultConfig(), zap.NewNop())
+
+	resp, err := s.DecrementCounter(context.Background(), &counter.DecrementRequest{
+		ResourceId: "article_1", CounterType: "like", Delta: 2, UserId: "user_42",
+	})
+	if err != nil {
+		t.Fatalf("DecrementCounter failed: %v", err)
+	}
+	if resp.CurrentValue != 3 || resp.Clamped {
+		t.Errorf("Expected value 3 without clamping, got %d clamped=%v", resp.CurrentValue, resp.Clamped)
+	}
+
+	// 截断：5-2-10 < 0，结果为0，事件Delta为实际变化量
+	resp, err = s.DecrementCounter(context.Background(), &counter.DecrementRequest{
+		ResourceId: "article_1", CounterType: "like", Delta: 10, ClampAtZero: true,
+	})
+	if err != nil {
+		t.Fatalf("DecrementCounter failed: %v", err)
+	}
+	if resp.CurrentValue != 0 || !resp.Clamped || repo.Value(key) != 0 {
+		t.Errorf("Expected clamped value 0, got %d clamped=%v stored=%d", resp.CurrentValue, resp.Clamped, repo.Value(key))
+	}
+
+	events := waitForEvents(producer, 2)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	deltas := map[int64]int64{}
+	for _, event := range events {
+		deltas[event.NewValue] = event.Delta
+	}
+	if deltas[3] != -2 || deltas[0] != -3 {
+		t.Errorf("Expected event deltas -2 and -3, got %v", deltas)
+	}
+
+	// 不截断时允许低于0
+	resp, err = s.DecrementCounter(context.Background(), &counter.DecrementRequest{
+		ResourceId: "article_1", CounterType: "like", Delta: 1,
+	})
+	if err != nil || resp.CurrentValue != -1 {
+		t.Errorf("Expected value -1 without clamping, got %v, %v", resp, err)
+	}
+
+	// 已经为负的计数器截断时保持不变，而不是被抬回0
+	resp, err = s.DecrementCounter(context.Background(), &counter.DecrementRequest{
+		ResourceId: "article_1", CounterType: "like", Delta: 1, ClampAtZero: true,
+	})
+	if err != nil || resp.CurrentValue != -1 || !resp.Clamped || repo.Value(key) != -1 {
+		t.Errorf("Expected negative counter left at -1, got %v, %v (stored=%d)", resp, err, repo.Value(key))
+	}
+}
+
+func TestDecrementCounterValidation(t *testing.T) {
+	s := newDeltaLimitedServer(daotest.NewMemoryCounterRepo())
+
+	tests := []struct {
+		name string
+		req  *counter.DecrementRequest
+		want codes.Code
+	}{
+		{"missing resource", &counter.DecrementRequest{CounterType: "like", Delta: 1}, codes.InvalidArgument},
+		{"negative delta", &counter.DecrementRequest{ResourceId: "article_1", CounterType: "like", Delta: -1}, codes.InvalidArgument},
+		{"delta too large", &counter.DecrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 1000}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		resp, err := s.DecrementCounter(context.Background(), tt.req)
+		if status.Code(err) != tt.want || resp.Status.Code != int32(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	unsupported := NewCounterServer(struct{ biz.CounterRepo }{daotest.NewMemoryCounterRepo()}, nil, nil, nil, DefaultConfig(), zap.NewNop())
+	_, err := unsupported.DecrementCounter(context.Background(), &counter.DecrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 1})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented for store without decrement, got %v", err)
+	}
+}
+
+func TestBatchIncrementNegativeDeltaUsesDecrement(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	key := dao.CounterKey(context.Background(), "article_1", "like")
+	repo.SetValue(key, 2)
+	cfg := DefaultConfig()
+	cfg.ClampBatchDecrements = true
+	s := NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+
+	resp, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "like", Delta: -5},
+	})
+	if err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+	if resp.ProcessedCount != 1 || resp.Results[0].CurrentValue != 0 {
+		t.Errorf("Expected negative delta clamped to 0, got %+v", resp.Results[0])
+	}
+	if repo.Value(key) != 0 {
+		t.Errorf("Expected stored value 0, got %d", repo.Value(key))
+	}
+}
+
+func TestCounterTTLAppliedToIncrements(t *testing.T) {
+	appConfig := &config.Config{}
+	appConfig.Counter.Performance.CounterTTL = 24 * time.Hour
+	cfg := NewConfigFromAppConfig(appConfig)
+	if cfg.CounterTTL != 24*time.Hour {
+		t.Fatalf("Expected CounterTTL 24h from app config, got %v", cfg.CounterTTL)
+	}
+
+	repo := daotest.NewMemoryCounterRepo()
+	s := NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+
+	resp, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "story_1", CounterType: "view", Delta: 1},
+	})
+	if err != nil || resp.FailedCount != 0 {
+		t.Fatalf("processBatchIncrementSync failed: %v, %+v", err, resp)
+	}
+
+	key := dao.CounterKey(context.Background(), "story_1", "view")
+	if ttl, _ := repo.TTL(key); ttl != 24*time.Hour {
+		t.Errorf("Expected ttl 24h on new counter, got %v", ttl)
+	}
+
+	// 未配置过期时间时不使用带过期的增量
+	plain := daotest.NewMemoryCounterRepo()
+	if _, _, err := newTestCounterServer(plain).processIncrementOperation(context.Background(), &counter.IncrementRequest{
+		ResourceId: "story_1", CounterType: "view", Delta: 1,
+	}); err != nil {
+		t.Fatalf("processIncrementOperation failed: %v", err)
+	}
+	if plain.TTLCount() != 0 {
+		t.Errorf("Expected no ttl without CounterTTL, got %d", plain.TTLCount())
+	}
+}
+
+func TestCompareAndSwapCounter(t *testing.T) {
+	workerPool, err := pool.NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create worker pool: %v", err)
+	}
+	defer workerPool.Shutdown(context.Background())
+
+	repo := daotest.NewMemoryCounterRepo()
+	key := dao.CounterKey(context.Background(), "article_1", "like")
+	repo.SetValue(key, 5)
+	producer := kafka.NewMockProducer(zap.NewNop())
+	s := NewCounterServer(repo, workerPool, nil, producer, DefaultConfig(), zap.NewNop())
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableDB: true}, zap.NewNop())
+	s.SetMetricsManager(mm)
+	ctx := context.Background()
+
+	req := &counter.CompareAndSwapRequest{ResourceId: "article_1", CounterType: "like", Expected: 5, NewValue: 8}
+	resp, err := s.CompareAndSwapCounter(ctx, req)
+	if err != nil || !resp.Swapped || !resp.Status.Success {
+		t.Fatalf("Expected swap, got %+v, %v", resp, err)
+	}
+	if repo.Value(key) != 8 {
+		t.Errorf("Expected counter 8, got %d", repo.Value(key))
+	}
+
+	// 重试同一请求不会重复写入
+	resp, err = s.CompareAndSwapCounter(ctx, req)
+	if err != nil || resp.Swapped || !resp.Status.Success {
+		t.Fatalf("Expected retry not to swap, got %+v, %v", resp, err)
+	}
+	if repo.Value(key) != 8 {
+		t.Errorf("Expected counter to stay 8, got %d", repo.Value(key))
+	}
+
+	// 不存在的计数器按0比较
+	resp, err = s.CompareAndSwapCounter(ctx, &counter.CompareAndSwapRequest{ResourceId: "article_2", CounterType: "like", Expected: 0, NewValue: 1})
+	if err != nil || !resp.Swapped {
+		t.Fatalf("Expected swap on missing counter, got %+v, %v", resp, err)
+	}
+
+	// 只有设置成功的操作发送事件，Delta为变化量
+	events := waitForEvents(producer, 2)
+	if len(events) != 2 || events[0].Delta != 3 || events[0].NewValue != 8 {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+
+	if got := counterMetricValue(t, mm, "test_db_queries_total", map[string]string{"operation": "compare_and_set", "status": "success"}); got != 3 {
+		t.Errorf("Expected 3 compare_and_set queries, got %v", got)
+	}
+
+	if _, err := s.CompareAndSwapCounter(ctx, &counter.CompareAndSwapRequest{CounterType: "like"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestCompareAndSwapCounterValidation(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	key := dao.CounterKey(context.Background(), "article_1", "like")
+	repo.SetValue(key, 5)
+	s := newDeltaLimitedServer(repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		expected int64
+		newValue int64
+		wantErr  error
+	}{
+		{"negative new value", 5, -1, ErrNegativeSwapValue},
+		{"increase above max delta", 5, 106, ErrDeltaTooLarge},
+		{"decrease within max delta", 5, 0, nil},
+		{"overflowing delta", math.MinInt64, 0, ErrDeltaTooLarge},
+	}
+	for _, tt := range tests {
+		_, err := s.resolveSwap("like", tt.expected, tt.newValue)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+	if _, err := s.resolveSwap("like", 200, 50); !errors.Is(err, ErrDeltaTooLarge) {
+		t.Errorf("Expected decrease beyond max delta rejected, got %v", err)
+	}
+
+	// 被拒绝的请求不写入存储
+	for _, newValue := range []int64{-1, 1000} {
+		resp, err := s.CompareAndSwapCounter(ctx, &counter.CompareAndSwapRequest{ResourceId: "article_1", CounterType: "like", Expected: 5, NewValue: newValue})
+		if status.Code(err) != codes.InvalidArgument || resp.Status.Code != int32(codes.InvalidArgument) {
+			t.Errorf("new_value %d: expected InvalidArgument, got %v", newValue, err)
+		}
+	}
+	if repo.Value(key) != 5 {
+		t.Errorf("Expected counter unchanged at 5, got %d", repo.Value(key))
+	}
+
+	// 增量上限内的设置照常生效
+	resp, err := s.CompareAndSwapCounter(ctx, &counter.CompareAndSwapRequest{ResourceId: "article_1", CounterType: "like", Expected: 5, NewValue: 105})
+	if err != nil || !resp.Swapped || repo.Value(key) != 105 {
+		t.Errorf("Expected swap within max delta, got %+v, %v (stored=%d)", resp, err, repo.Value(key))
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"high-go-press/pkg/kafka"
+)
+
+// errEventQueueFull 发送队列已满，最旧的事件被丢弃
+var errEventQueueFull = errors.New("kafka event queue is full")
+
+// eventQueue 待发送Kafka事件的有界队列
+//
+// 增量请求只负责入队，由单独的发送goroutine依次发送；Kafka变慢导致队列写满时
+// 丢弃最旧的事件腾出空间，而不是阻塞增量请求。每个元素是一次发送的一组事件，
+// 单个增量为一个事件，批量增量为整批事件。
+//
+// 队列只有一个发送goroutine，事件组按入队顺序串行发送，发送吞吐上限约为
+// 1/单次发送耗时；Kafka持续慢于增量速率时由丢弃最旧事件兜底，需要更高吞吐时
+// 关闭队列（容量为0），改为直接提交到Worker Pool并发发送。
+type eventQueue struct {
+	pending chan []*kafka.CounterEvent
+	send    func([]*kafka.CounterEvent)
+	onDrop  func([]*kafka.CounterEvent)
+
+	dropped   int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newEventQueue 创建容量为capacity的事件队列并启动发送goroutine
+// send负责实际发送，onDrop在队列满丢弃最旧事件时调用
+func newEventQueue(capacity int, send, onDrop func([]*kafka.CounterEvent)) *eventQueue {
+	q := &eventQueue{
+		pending: make(chan []*kafka.CounterEvent, capacity),
+		send:    send,
+		onDrop:  onDrop,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// push 事件入队，从不阻塞，队列满时丢弃最旧的一组事件
+func (q *eventQueue) push(events []*kafka.CounterEvent) {
+	for {
+		select {
+		case q.pending <- events:
+			return
+		default:
+		}
+
+		// 队列已满，丢弃队头后重试；发送goroutine可能同时取走队头，此时直接重试入队
+		select {
+		case oldest := <-q.pending:
+			atomic.AddInt64(&q.dropped, int64(len(oldest)))
+			q.onDrop(oldest)
+		default:
+		}
+	}
+}
+
+// run 依次发送队列中的事件，关闭时发送完剩余事件后退出
+func (q *eventQueue) run() {
+	defer close(q.done)
+	for {
+		select {
+		case events := <-q.pending:
+			q.send(events)
+		case <-q.stop:
+			for {
+				select {
+				case events := <-q.pending:
+					q.send(events)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Len 队列中等待发送的事件组数
+func (q *eventQueue) Len() int {
+	return len(q.pending)
+}
+
+// Dropped 因队列满被丢弃的事件数
+func (q *eventQueue) Dropped() int64 {
+	return atomic.LoadInt64(&q.dropped)
+}
+
+// Close 停止接收并发送完剩余事件，ctx到期时不再等待
+func (q *eventQueue) Close(ctx context.Context) error {
+	q.closeOnce.Do(func() { close(q.stop) })
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
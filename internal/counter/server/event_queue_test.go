@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/config"
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// blockingProducer 第一次发送阻塞直到release关闭，用于把发送goroutine卡住
+type blockingProducer struct {
+	kafka.Producer
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+
+	mu     sync.Mutex
+	deltas []int64
+}
+
+func (p *blockingProducer) SendCounterEvent(ctx context.Context, event *kafka.CounterEvent) error {
+	p.once.Do(func() {
+		close(p.started)
+		<-p.release
+	})
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deltas = append(p.deltas, event.Delta)
+	return nil
+}
+
+func TestEventQueueDropsOldestWhenFull(t *testing.T) {
+	producer := &blockingProducer{started: make(chan struct{}), release: make(chan struct{})}
+	cfg := DefaultConfig()
+	cfg.EventQueueSize = 2
+	cfg.EventSendTimeout = 5 * time.Second
+	s := NewCounterServer(daotest.NewMemoryCounterRepo(), nil, nil, producer, cfg, zap.NewNop())
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableBusiness: true}, zap.NewNop())
+	s.SetMetricsManager(mm)
+
+	publish := func(delta int64) {
+		s.publishCounterEvents([]*kafka.CounterEvent{{ResourceID: "article_1", CounterType: "like", Delta: delta}})
+	}
+
+	// 第一个事件卡在发送中，之后队列只能容纳2个
+	publish(1)
+	select {
+	case <-producer.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain goroutine did not start sending")
+	}
+
+	// 队列满时入队不阻塞，丢弃最旧的事件
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for delta := int64(2); delta <= 5; delta++ {
+			publish(delta)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publish blocked on a full queue")
+	}
+
+	if dropped := s.eventQueue.Dropped(); dropped != 2 {
+		t.Errorf("Expected 2 dropped events, got %d", dropped)
+	}
+	if got := counterMetricValue(t, mm, "test_kafka_events_dropped_total", map[string]string{"service": "counter", "reason": "queue_full"}); got != 2 {
+		t.Errorf("Expected queue_full drop metric 2, got %v", got)
+	}
+
+	close(producer.release)
+	if err := s.DrainEvents(context.Background()); err != nil {
+		t.Fatalf("DrainEvents failed: %v", err)
+	}
+
+	// 保留最新的事件
+	producer.mu.Lock()
+	defer producer.mu.Unlock()
+	want := []int64{1, 4, 5}
+	if len(producer.deltas) != len(want) {
+		t.Fatalf("Expected sent deltas %v, got %v", want, producer.deltas)
+	}
+	for i := range want {
+		if producer.deltas[i] != want[i] {
+			t.Errorf("Expected sent deltas %v, got %v", want, producer.deltas)
+			break
+		}
+	}
+	if s.eventsDropped != 2 || s.eventsSent != 3 {
+		t.Errorf("Expected 3 sent and 2 dropped, got %d sent and %d dropped", s.eventsSent, s.eventsDropped)
+	}
+}
+
+func TestNewConfigFromAppConfigEventQueueSize(t *testing.T) {
+	queueSize := func(size int) *int { return &size }
+
+	tests := []struct {
+		name      string
+		queueSize *int
+		want      int
+	}{
+		{name: "unset uses default", queueSize: nil, want: DefaultConfig().EventQueueSize},
+		{name: "explicit size", queueSize: queueSize(50), want: 50},
+		{name: "zero disables queue", queueSize: queueSize(0), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appConfig := &config.Config{}
+			appConfig.Kafka.Producer.QueueSize = tt.queueSize
+			if got := NewConfigFromAppConfig(appConfig).EventQueueSize; got != tt.want {
+				t.Errorf("Expected event queue size %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"high-go-press/api/proto/common"
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/biz"
+	"high-go-press/internal/dao"
+	"high-go-press/pkg/middleware"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// leaderboardWindowRetention 时间窗口排行榜的保留窗口数，保证上一个窗口在当前窗口内仍可读取
+const leaderboardWindowRetention = 2
+
+const (
+	hotRankDefaultLimit = 10  // GetHotRank未指定数量时返回的条数
+	hotRankMaxLimit     = 100 // GetHotRank单次最多返回的条数
+)
+
+// updateLeaderboards 计数器增量成功后在Worker Pool中更新该类型的排行榜
+// 总榜使用ZADD GT写入计数器当前值，时间窗口榜累加窗口内的增量；未配置排行榜的类型直接跳过
+// 排行榜写入失败只记录日志，不影响计数结果
+func (s *CounterServer) updateLeaderboards(ctx context.Context, counterType, resourceID string, delta, newValue int64) {
+	windows, ok := s.config.Leaderboards[counterType]
+	if !ok {
+		return
+	}
+	writer, ok := s.dao.(biz.LeaderboardWriter)
+	if !ok {
+		return
+	}
+
+	taskCtx := rankTaskContext(ctx)
+	now := time.Now()
+	task := func() {
+		key := dao.LeaderboardKey(taskCtx, counterType)
+		if err := writer.SetLeaderboardScore(taskCtx, key, resourceID, newValue); err != nil {
+			s.errorLog.Error("Failed to update leaderboard", err,
+				zap.String("key", key),
+				zap.String("resource_id", resourceID))
+		}
+
+		for _, window := range windows {
+			key := dao.LeaderboardWindowKey(taskCtx, counterType, window, now)
+			if err := writer.IncrementLeaderboard(taskCtx, key, resourceID, delta, leaderboardWindowRetention*window); err != nil {
+				s.errorLog.Error("Failed to update leaderboard", err,
+					zap.String("key", key),
+					zap.String("resource_id", resourceID))
+			}
+		}
+	}
+	s.submitRankTask(task, "leaderboard", counterType, resourceID)
+}
+
+// updateHotRank 计数器增加后在Worker Pool中累加各时间范围的热点排行
+// 热点排行记录的是时间桶内的增量，递减不计入热度；写入失败只记录日志
+func (s *CounterServer) updateHotRank(ctx context.Context, counterType, resourceID string, delta int64) {
+	if delta <= 0 || len(s.config.HotRankPeriods) == 0 {
+		return
+	}
+	ranker, ok := s.dao.(biz.HotRanker)
+	if !ok {
+		return
+	}
+
+	taskCtx := rankTaskContext(ctx)
+	task := func() {
+		for _, period := range s.config.HotRankPeriods {
+			if err := ranker.AddToHotRank(taskCtx, counterType, period, resourceID, delta); err != nil {
+				s.errorLog.Error("Failed to update hot rank", err,
+					zap.String("counter_type", counterType),
+					zap.String("period", period),
+					zap.String("resource_id", resourceID))
+			}
+		}
+	}
+	s.submitRankTask(task, "hot rank", counterType, resourceID)
+}
+
+// GetHotRank 返回当前时间桶内热度最高的资源，period为空时按天统计
+// 只有counter.hot_rank_periods中开启的时间范围可查询，未开启时返回FailedPrecondition
+func (s *CounterServer) GetHotRank(ctx context.Context, req *counter.GetHotRankRequest) (*counter.GetHotRankResponse, error) {
+	if req.CounterType == "" {
+		return nil, status.Error(codes.InvalidArgument, "counter_type is required")
+	}
+	period := req.Period
+	if period == "" {
+		period = dao.HotRankPeriodDay
+	}
+	if _, _, err := dao.HotRankKey(ctx, req.CounterType, period, time.Now()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !slices.Contains(s.config.HotRankPeriods, period) {
+		return nil, status.Errorf(codes.FailedPrecondition, "hot rank is not enabled for period %s", period)
+	}
+	ranker, ok := s.dao.(biz.HotRanker)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "hot rank is not supported by the counter store")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = hotRankDefaultLimit
+	}
+	if limit > hotRankMaxLimit {
+		limit = hotRankMaxLimit
+	}
+
+	items, err := ranker.GetHotRank(ctx, req.CounterType, period, limit)
+	if err != nil {
+		s.errorLog.Error("Failed to get hot rank", err,
+			zap.String("counter_type", req.CounterType),
+			zap.String("period", period))
+		return nil, status.Error(codes.Internal, "failed to get hot rank")
+	}
+
+	resp := &counter.GetHotRankResponse{
+		Status: &common.Status{
+			Success: true,
+			Message: "Hot rank retrieved successfully",
+			Code:    int32(codes.OK),
+		},
+		CounterType: req.CounterType,
+		Period:      period,
+		Items:       make([]*counter.HotRankEntry, 0, len(items)),
+	}
+	for _, item := range items {
+		resp.Items = append(resp.Items, &counter.HotRankEntry{
+			ResourceId: item.ResourceID,
+			Score:      item.Count,
+			Rank:       int32(item.Rank),
+		})
+	}
+	return resp, nil
+}
+
+// rankTaskContext 排行任务在请求返回后执行，只保留租户信息，不继承请求的取消
+func rankTaskContext(ctx context.Context) context.Context {
+	taskCtx := context.Background()
+	if tenantID, ok := middleware.TenantIDFromContext(ctx); ok {
+		taskCtx = middleware.WithTenantID(taskCtx, tenantID)
+	}
+	return taskCtx
+}
+
+// submitRankTask 将排行写入提交到Worker Pool，不占用请求路径；未注入Worker Pool时同步执行
+func (s *CounterServer) submitRankTask(task func(), kind, counterType, resourceID string) {
+	if s.workerPool == nil {
+		task()
+		return
+	}
+	if err := s.workerPool.SubmitTask(task); err != nil {
+		s.errorLog.Error("Failed to submit "+kind+" update", err,
+			zap.String("counter_type", counterType),
+			zap.String("resource_id", resourceID))
+	}
+}
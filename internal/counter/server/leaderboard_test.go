@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/biz"
+	"high-go-press/internal/dao"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/config"
+	"high-go-press/pkg/pool"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newLeaderboardServer(repo *daotest.MemoryCounterRepo) *CounterServer {
+	cfg := DefaultConfig()
+	cfg.Leaderboards = map[string][]time.Duration{
+		"like": {time.Hour},
+	}
+	return NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop())
+}
+
+func TestLeaderboardUpdatedForConfiguredType(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newLeaderboardServer(repo)
+	ctx := context.Background()
+
+	resp, err := s.processBatchIncrementSync(ctx, []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "like", Delta: 3},
+		{ResourceId: "article_1", CounterType: "like", Delta: 2},
+	})
+	if err != nil || resp.FailedCount != 0 {
+		t.Fatalf("processBatchIncrementSync failed: %v %+v", err, resp)
+	}
+
+	// 总榜分数为计数器当前值
+	if got := repo.LeaderboardScore(dao.LeaderboardKey(ctx, "like"), "article_1"); got != 5 {
+		t.Errorf("Expected total leaderboard score 5, got %d", got)
+	}
+	// 时间窗口榜累加窗口内增量
+	windowKey := dao.LeaderboardWindowKey(ctx, "like", time.Hour, time.Now())
+	if got := repo.LeaderboardScore(windowKey, "article_1"); got != 5 {
+		t.Errorf("Expected window leaderboard %s score 5, got %d", windowKey, got)
+	}
+	if len(repo.LeaderboardKeys()) != 2 {
+		t.Errorf("Expected total and 1h leaderboards only, got %v", repo.LeaderboardKeys())
+	}
+}
+
+func TestLeaderboardSkippedForUnconfiguredType(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newLeaderboardServer(repo)
+
+	resp, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "view", Delta: 1},
+	})
+	if err != nil || resp.FailedCount != 0 {
+		t.Fatalf("processBatchIncrementSync failed: %v %+v", err, resp)
+	}
+
+	if got := repo.Value("counter:article_1:view"); got != 1 {
+		t.Errorf("Expected view counter 1, got %d", got)
+	}
+	// 未配置排行榜的类型不写任何ZSET
+	if len(repo.LeaderboardKeys()) != 0 {
+		t.Errorf("Expected no leaderboard updates for view, got %v", repo.LeaderboardKeys())
+	}
+}
+
+func TestLeaderboardIgnoresStaleValue(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newLeaderboardServer(repo)
+	ctx := context.Background()
+
+	// 并发增量的写入顺序可能与计数结果相反，较旧的计数值不覆盖总榜
+	s.updateLeaderboards(ctx, "like", "article_1", 2, 5)
+	s.updateLeaderboards(ctx, "like", "article_1", 1, 3)
+	if got := repo.LeaderboardScore(dao.LeaderboardKey(ctx, "like"), "article_1"); got != 5 {
+		t.Errorf("Expected total leaderboard score to stay 5, got %d", got)
+	}
+}
+
+// blockingLeaderboardRepo 排行榜写入阻塞到release关闭
+type blockingLeaderboardRepo struct {
+	*daotest.MemoryCounterRepo
+	release chan struct{}
+}
+
+func (r *blockingLeaderboardRepo) SetLeaderboardScore(ctx context.Context, key, member string, score int64) error {
+	<-r.release
+	return r.MemoryCounterRepo.SetLeaderboardScore(ctx, key, member, score)
+}
+
+func TestLeaderboardUpdatedOffRequestPath(t *testing.T) {
+	repo := &blockingLeaderboardRepo{MemoryCounterRepo: daotest.NewMemoryCounterRepo(), release: make(chan struct{})}
+	workerPool, err := pool.NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewWorkerPool failed: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Leaderboards = map[string][]time.Duration{"like": nil}
+	s := NewCounterServer(repo, workerPool, nil, nil, cfg, zap.NewNop())
+	ctx := context.Background()
+
+	// 排行榜写入阻塞时增量请求仍然返回
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 1})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("IncrementCounter failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("IncrementCounter blocked on leaderboard write")
+	}
+
+	close(repo.release)
+	defer workerPool.Shutdown(ctx)
+	deadline := time.Now().Add(time.Second)
+	for repo.LeaderboardScore(dao.LeaderboardKey(ctx, "like"), "article_1") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected leaderboard score 1 after task ran")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewConfigFromAppConfigLeaderboards(t *testing.T) {
+	appConfig := &config.Config{}
+	appConfig.Counter.Leaderboards = []config.LeaderboardSpec{
+		{CounterType: "like", Windows: []time.Duration{time.Hour, 24 * time.Hour}},
+		{CounterType: "view"},
+	}
+
+	cfg := NewConfigFromAppConfig(appConfig)
+	if got := cfg.Leaderboards["like"]; len(got) != 2 || got[0] != time.Hour {
+		t.Errorf("Unexpected like leaderboard windows: %v", got)
+	}
+	if _, ok := cfg.Leaderboards["view"]; !ok {
+		t.Error("Expected view leaderboard configured without windows")
+	}
+	if _, ok := cfg.Leaderboards["share"]; ok {
+		t.Error("Expected share not to maintain a leaderboard")
+	}
+}
+
+// hotRankRepo 在daotest.MemoryCounterRepo基础上记录热点排行的写入
+type hotRankRepo struct {
+	*daotest.MemoryCounterRepo
+	mu  sync.Mutex
+	hot map[string]int64 // period:resource -> 热度
+}
+
+func (r *hotRankRepo) AddToHotRank(ctx context.Context, counterType, period, resourceID string, value int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hot[period+":"+resourceID] += value
+	return nil
+}
+
+// GetHotRank 按热度从高到低返回指定时间范围的资源
+func (r *hotRankRepo) GetHotRank(ctx context.Context, counterType, period string, limit int) ([]*biz.HotRankItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var items []*biz.HotRankItem
+	for key, count := range r.hot {
+		if resourceID, ok := strings.CutPrefix(key, period+":"); ok {
+			items = append(items, &biz.HotRankItem{ResourceID: resourceID, CounterType: biz.CounterType(counterType), Count: count})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Count > items[j].Count })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	for i, item := range items {
+		item.Rank = i + 1
+	}
+	return items, nil
+}
+
+func newHotRankServer(periods ...string) (*CounterServer, *hotRankRepo) {
+	repo := &hotRankRepo{MemoryCounterRepo: daotest.NewMemoryCounterRepo(), hot: make(map[string]int64)}
+	cfg := DefaultConfig()
+	cfg.HotRankPeriods = periods
+	return NewCounterServer(repo, nil, nil, nil, cfg, zap.NewNop()), repo
+}
+
+func TestHotRankUpdatedForAllPeriods(t *testing.T) {
+	s, repo := newHotRankServer(dao.HotRankPeriods...)
+
+	resp, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "view", Delta: 3},
+		{ResourceId: "article_1", CounterType: "view", Delta: 2},
+	})
+	if err != nil || resp.FailedCount != 0 {
+		t.Fatalf("processBatchIncrementSync failed: %v %+v", err, resp)
+	}
+
+	// 热点排行累加每次增量，不依赖排行榜配置
+	for _, period := range dao.HotRankPeriods {
+		if got := repo.hot[period+":article_1"]; got != 5 {
+			t.Errorf("Expected %s hot rank 5, got %d", period, got)
+		}
+	}
+}
+
+func TestHotRankDisabledByDefault(t *testing.T) {
+	s, repo := newHotRankServer()
+
+	if _, err := s.processBatchIncrementSync(context.Background(), []*counter.IncrementRequest{
+		{ResourceId: "article_1", CounterType: "view", Delta: 1},
+	}); err != nil {
+		t.Fatalf("processBatchIncrementSync failed: %v", err)
+	}
+	if len(repo.hot) != 0 {
+		t.Errorf("Expected no hot rank updates, got %v", repo.hot)
+	}
+}
+
+func TestHotRankSkipsDecrements(t *testing.T) {
+	s, repo := newHotRankServer(dao.HotRankPeriodDay)
+	ctx := context.Background()
+
+	if _, err := s.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "view", Delta: 5}); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	if _, err := s.DecrementCounter(ctx, &counter.DecrementRequest{ResourceId: "article_1", CounterType: "view", Delta: 3}); err != nil {
+		t.Fatalf("DecrementCounter failed: %v", err)
+	}
+	// 递减不写入负分数
+	if got := repo.hot["day:article_1"]; got != 5 {
+		t.Errorf("Expected day hot rank 5 after decrement, got %d", got)
+	}
+}
+
+func TestGetHotRank(t *testing.T) {
+	s, _ := newHotRankServer(dao.HotRankPeriodDay)
+	ctx := context.Background()
+
+	for resourceID, delta := range map[string]int64{"article_1": 2, "article_2": 7, "article_3": 4} {
+		if _, err := s.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: resourceID, CounterType: "view", Delta: delta}); err != nil {
+			t.Fatalf("IncrementCounter failed: %v", err)
+		}
+	}
+
+	resp, err := s.GetHotRank(ctx, &counter.GetHotRankRequest{CounterType: "view", Limit: 2})
+	if err != nil {
+		t.Fatalf("GetHotRank failed: %v", err)
+	}
+	if resp.Period != dao.HotRankPeriodDay || len(resp.Items) != 2 {
+		t.Fatalf("Expected top 2 of day period, got %+v", resp)
+	}
+	if resp.Items[0].ResourceId != "article_2" || resp.Items[0].Score != 7 || resp.Items[0].Rank != 1 {
+		t.Errorf("Unexpected first item: %+v", resp.Items[0])
+	}
+	if resp.Items[1].ResourceId != "article_3" || resp.Items[1].Rank != 2 {
+		t.Errorf("Unexpected second item: %+v", resp.Items[1])
+	}
+
+	// 未开启的时间范围和非法时间范围
+	if _, err := s.GetHotRank(ctx, &counter.GetHotRankRequest{CounterType: "view", Period: dao.HotRankPeriodHour}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for disabled period, got %v", err)
+	}
+	if _, err := s.GetHotRank(ctx, &counter.GetHotRankRequest{CounterType: "view", Period: "month"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for unknown period, got %v", err)
+	}
+}
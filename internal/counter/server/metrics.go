@@ -0,0 +1,65 @@
+package server
+
+import (
+	"slices"
+	"time"
+)
+
+// otherCounterType 未在MetricCounterTypes中的计数器类型在指标中统一记为other，限制标签基数
+const otherCounterType = "other"
+
+// operationStatus 指标中的操作结果标签
+func operationStatus(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// recordOperation 记录按计数器类型区分的业务操作指标，未设置监控管理器时跳过
+func (s *CounterServer) recordOperation(operation, counterType string, start time.Time, err error) {
+	if s.metricsManager == nil {
+		return
+	}
+	s.metricsManager.RecordCounterOperation(operation, "counter", s.counterTypeLabel(counterType), operationStatus(err), time.Since(start))
+}
+
+// counterTypeLabel 指标中的计数器类型标签，未配置的类型记为other
+func (s *CounterServer) counterTypeLabel(counterType string) string {
+	if slices.Contains(s.config.MetricCounterTypes, counterType) {
+		return counterType
+	}
+	return otherCounterType
+}
+
+// recordDBOperation 记录Redis操作指标，未设置监控管理器时跳过
+func (s *CounterServer) recordDBOperation(operation string, start time.Time, err error) {
+	if s.metricsManager == nil {
+		return
+	}
+	s.metricsManager.RecordDBOperation(operation, "redis", "counter", operationStatus(err), time.Since(start))
+}
+
+// recordDroppedEvents 记录被丢弃的Kafka事件数，未设置监控管理器时跳过
+func (s *CounterServer) recordDroppedEvents(reason string, count int) {
+	if s.metricsManager == nil {
+		return
+	}
+	s.metricsManager.RecordDroppedEvents("counter", reason, count)
+}
+
+// setBusinessGauge 设置业务指标值，未设置监控管理器时跳过
+func (s *CounterServer) setBusinessGauge(metric string, value float64) {
+	if s.metricsManager == nil {
+		return
+	}
+	s.metricsManager.SetBusinessGauge(metric, "counter", value)
+}
+
+// setServiceHealth 更新依赖组件的健康状态指标，未设置监控管理器时跳过
+func (s *CounterServer) setServiceHealth(component string, healthy bool) {
+	if s.metricsManager == nil {
+		return
+	}
+	s.metricsManager.SetServiceHealth("counter", component, healthy)
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/config"
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/metrics"
+	"high-go-press/pkg/pool"
+
+	"go.uber.org/zap"
+)
+
+// counterMetricValue 读取指标族中带指定标签的counter或gauge值
+func counterMetricValue(t *testing.T, mm *metrics.MetricsManager, family string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != family {
+			continue
+		}
+	metricLoop:
+		for _, m := range f.GetMetric() {
+			values := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				values[label.GetName()] = label.GetValue()
+			}
+			for name, want := range labels {
+				if values[name] != want {
+					continue metricLoop
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s%v not found", family, labels)
+	return 0
+}
+
+func TestCounterServerRecordsMetricsAndSendsEventsThroughWorkerPool(t *testing.T) {
+	workerPool, err := pool.NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create worker pool: %v", err)
+	}
+	defer workerPool.Shutdown(context.Background())
+
+	producer := kafka.NewMockProducer(zap.NewNop())
+	cfg := DefaultConfig()
+	cfg.EventQueueSize = 0 // 不使用发送队列，直接提交到Worker Pool
+	s := NewCounterServer(daotest.NewMemoryCounterRepo(), workerPool, pool.NewObjectPool(), producer, cfg, zap.NewNop())
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableBusiness: true, EnableDB: true}, zap.NewNop())
+	s.SetMetricsManager(mm)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 2}); err != nil {
+			t.Fatalf("IncrementCounter failed: %v", err)
+		}
+	}
+	if _, err := s.GetCounter(ctx, &counter.GetCounterRequest{ResourceId: "article_1", CounterType: "like"}); err != nil {
+		t.Fatalf("GetCounter failed: %v", err)
+	}
+
+	// 事件通过Worker Pool异步发送
+	events := waitForEvents(producer, 2)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events sent through the worker pool, got %d", len(events))
+	}
+	if events[1].NewValue != 4 || events[1].EventID == "" {
+		t.Errorf("Unexpected event: %+v", events[1])
+	}
+
+	// 业务操作、当前值和Redis查询指标
+	if got := counterMetricValue(t, mm, "test_business_operations_total", map[string]string{"operation": "increment_counter", "status": "success"}); got != 2 {
+		t.Errorf("Expected 2 increment_counter operations, got %v", got)
+	}
+	if got := businessGaugeValue(t, mm, "current_counter_value"); got != 4 {
+		t.Errorf("Expected current_counter_value 4, got %v", got)
+	}
+	if got := counterMetricValue(t, mm, "test_db_queries_total", map[string]string{"operation": "get", "status": "success"}); got != 1 {
+		t.Errorf("Expected 1 redis get query, got %v", got)
+	}
+
+	// 健康检查上报依赖组件状态和已发送事件数
+	resp, err := s.HealthCheck(ctx, &counter.HealthCheckRequest{})
+	if err != nil || !resp.Status.Success {
+		t.Fatalf("HealthCheck failed: %v %+v", err, resp)
+	}
+	if resp.Details["event_count"] != "2" {
+		t.Errorf("Expected event_count 2, got %q", resp.Details["event_count"])
+	}
+	if got := counterMetricValue(t, mm, "test_service_health", map[string]string{"component": "redis"}); got != 1 {
+		t.Errorf("Expected redis health gauge 1, got %v", got)
+	}
+}
+
+func TestBusinessMetricsSeparateCounterTypes(t *testing.T) {
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableBusiness: true}, zap.NewNop())
+	s.SetMetricsManager(mm)
+	ctx := context.Background()
+
+	increment := func(counterType string) {
+		if _, _, err := s.processIncrementOperation(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: counterType, Delta: 1}); err != nil {
+			t.Fatalf("increment %s failed: %v", counterType, err)
+		}
+	}
+	increment("like")
+	increment("like")
+	increment("view")
+	// 未配置的类型统一记为other，避免任意类型产生新的标签序列
+	increment("custom_a")
+	increment("custom_b")
+
+	if _, err := s.GetCounter(ctx, &counter.GetCounterRequest{ResourceId: "article_1", CounterType: "view"}); err != nil {
+		t.Fatalf("GetCounter failed: %v", err)
+	}
+
+	tests := []struct {
+		operation   string
+		counterType string
+		want        float64
+	}{
+		{"increment_counter", "like", 2},
+		{"increment_counter", "view", 1},
+		{"increment_counter", "other", 2},
+		{"get_counter", "view", 1},
+	}
+	for _, tt := range tests {
+		labels := map[string]string{"operation": tt.operation, "counter_type": tt.counterType, "status": "success"}
+		if got := counterMetricValue(t, mm, "test_business_operations_total", labels); got != tt.want {
+			t.Errorf("%s{counter_type=%s}: expected %v, got %v", tt.operation, tt.counterType, tt.want, got)
+		}
+	}
+
+	// 标签值只包含已配置的类型和other
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != "test_business_operations_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "counter_type" && (label.GetValue() == "custom_a" || label.GetValue() == "custom_b") {
+					t.Errorf("Unexpected unbounded counter_type label %q", label.GetValue())
+				}
+			}
+		}
+	}
+}
+
+func TestDecrementRecordsBusinessMetrics(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s := newTestCounterServer(repo)
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableBusiness: true}, zap.NewNop())
+	s.SetMetricsManager(mm)
+	ctx := context.Background()
+
+	if _, err := s.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 5}); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	if _, err := s.DecrementCounter(ctx, &counter.DecrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 2}); err != nil {
+		t.Fatalf("DecrementCounter failed: %v", err)
+	}
+
+	labels := map[string]string{"operation": "decrement_counter", "counter_type": "like", "status": "success"}
+	if got := counterMetricValue(t, mm, "test_business_operations_total", labels); got != 1 {
+		t.Errorf("Expected 1 decrement_counter operation, got %v", got)
+	}
+	if got := businessGaugeValue(t, mm, "current_counter_value"); got != 3 {
+		t.Errorf("Expected current_counter_value 3, got %v", got)
+	}
+
+	// 失败的递减记为error
+	repo.WriteErr = errors.New("redis unavailable")
+	if _, err := s.DecrementCounter(ctx, &counter.DecrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 1}); err == nil {
+		t.Fatal("Expected DecrementCounter to fail")
+	}
+	labels["status"] = "error"
+	if got := counterMetricValue(t, mm, "test_business_operations_total", labels); got != 1 {
+		t.Errorf("Expected 1 failed decrement_counter operation, got %v", got)
+	}
+}
+
+func TestNewConfigFromAppConfigMetricCounterTypes(t *testing.T) {
+	appConfig := &config.Config{}
+	appConfig.Counter.Delta.Types = map[string]config.DeltaLimitConfig{"share": {MaxDelta: 10}}
+	cfg := NewConfigFromAppConfig(appConfig)
+
+	for _, counterType := range []string{"like", "view", "follow", "share"} {
+		if !slices.Contains(cfg.MetricCounterTypes, counterType) {
+			t.Errorf("Expected %s in MetricCounterTypes, got %v", counterType, cfg.MetricCounterTypes)
+		}
+	}
+}
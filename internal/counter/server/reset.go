@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"high-go-press/api/proto/common"
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/biz"
+	"high-go-press/internal/dao"
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/redislock"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// resetLockPrefix 重置计数器的锁key前缀，按租户隔离的计数器key加锁
+	resetLockPrefix = "counter-reset:"
+	// resetAttempts 读取当前值后被并发写入打断时的最大尝试次数
+	resetAttempts = 3
+	// resetReleaseTimeout 释放锁的超时时间，请求已取消时仍尝试释放
+	resetReleaseTimeout = time.Second
+)
+
+// resetFailure 构造ResetCounter的失败响应和对应的gRPC错误
+func resetFailure(req *counter.ResetCounterRequest, code codes.Code, message string) (*counter.ResetCounterResponse, error) {
+	return &counter.ResetCounterResponse{
+		Status: &common.Status{
+			Success: false,
+			Message: message,
+			Code:    int32(code),
+		},
+		ResourceId:  req.ResourceId,
+		CounterType: req.CounterType,
+	}, status.Error(code, message)
+}
+
+// ResetCounter 管理接口：持有分布式锁将计数器重置为0
+// 锁保证多实例间同一计数器不会并发重置；重置本身通过比较并设置完成，不会吞掉读取后写入的增量
+func (s *CounterServer) ResetCounter(ctx context.Context, req *counter.ResetCounterRequest) (*counter.ResetCounterResponse, error) {
+	if req.ResourceId == "" || req.CounterType == "" {
+		return resetFailure(req, codes.InvalidArgument, "resource_id and counter_type are required")
+	}
+	if s.locker == nil {
+		return resetFailure(req, codes.FailedPrecondition, "counter reset requires a distributed lock")
+	}
+	setter, ok := s.dao.(biz.CounterCompareAndSetter)
+	if !ok {
+		return resetFailure(req, codes.Unimplemented, dao.ErrCompareAndSetUnsupported.Error())
+	}
+
+	key := dao.CounterKey(ctx, req.ResourceId, req.CounterType)
+	lock, err := s.locker.Acquire(ctx, resetLockPrefix+key, s.config.ResetLockTTL)
+	if errors.Is(err, redislock.ErrNotAcquired) {
+		return resetFailure(req, codes.Aborted, "counter is being reset by another request")
+	}
+	if err != nil {
+		s.logger.Error("Failed to acquire counter reset lock",
+			zap.String("key", key),
+			zap.Error(err))
+		return resetFailure(req, codes.Unavailable, "failed to acquire counter reset lock")
+	}
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetReleaseTimeout)
+		defer cancel()
+		if err := lock.Release(releaseCtx); err != nil && !errors.Is(err, redislock.ErrNotHeld) {
+			s.logger.Error("Failed to release counter reset lock",
+				zap.String("key", key),
+				zap.Error(err))
+		}
+	}()
+
+	var previous int64
+	swapped := false
+	for attempt := 0; attempt < resetAttempts && !swapped; attempt++ {
+		start := time.Now()
+		previous, err = s.dao.GetCounter(ctx, key)
+		s.recordDBOperation("get_counter", start, err)
+		if err != nil {
+			break
+		}
+
+		start = time.Now()
+		swapped, err = setter.CompareAndSetCounter(ctx, key, previous, 0)
+		s.recordDBOperation("compare_and_set", start, err)
+		if err != nil {
+			break
+		}
+	}
+	if errors.Is(err, dao.ErrCompareAndSetUnsupported) {
+		return resetFailure(req, codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		s.errorLog.Error("Failed to reset counter", err,
+			zap.String("resource_id", req.ResourceId),
+			zap.String("counter_type", req.CounterType))
+		return resetFailure(req, codes.Internal, "failed to reset counter")
+	}
+	if !swapped {
+		return resetFailure(req, codes.Aborted, "counter kept changing during reset")
+	}
+
+	s.logger.Info("Counter reset",
+		zap.String("resource_id", req.ResourceId),
+		zap.String("counter_type", req.CounterType),
+		zap.Int64("previous_value", previous),
+		zap.Int64("fencing_token", lock.Token()))
+
+	if previous != 0 {
+		s.updateLeaderboards(ctx, req.CounterType, req.ResourceId, -previous, 0)
+		s.publishCounterEvents([]*kafka.CounterEvent{{
+			EventID:     kafka.NewEventID(),
+			ResourceID:  req.ResourceId,
+			CounterType: req.CounterType,
+			Delta:       -previous,
+			NewValue:    0,
+			UserID:      req.UserId,
+			IP:          s.clientIP(ctx, ""),
+			Timestamp:   time.Now(),
+			Source:      "gRPC",
+		}})
+	}
+
+	return &counter.ResetCounterResponse{
+		Status: &common.Status{
+			Success: true,
+			Message: "Counter reset successfully",
+			Code:    int32(codes.OK),
+		},
+		ResourceId:    req.ResourceId,
+		CounterType:   req.CounterType,
+		PreviousValue: previous,
+		FencingToken:  lock.Token(),
+	}, nil
+}
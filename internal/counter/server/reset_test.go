@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/dao"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/redislock"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newResetServer 带分布式锁的测试服务端，锁存储在miniredis中
+func newResetServer(t *testing.T, repo *daotest.MemoryCounterRepo) (*CounterServer, *redislock.Locker) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	locker := redislock.NewLocker(client, zap.NewNop())
+	s := newTestCounterServer(repo)
+	s.SetLocker(locker)
+	return s, locker
+}
+
+func TestResetCounter(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s, _ := newResetServer(t, repo)
+	ctx := context.Background()
+	key := dao.CounterKey(ctx, "article_1", "like")
+	repo.SetCounter(ctx, key, 42)
+
+	resp, err := s.ResetCounter(ctx, &counter.ResetCounterRequest{ResourceId: "article_1", CounterType: "like"})
+	if err != nil {
+		t.Fatalf("ResetCounter failed: %v", err)
+	}
+	if resp.PreviousValue != 42 {
+		t.Errorf("Expected previous value 42, got %d", resp.PreviousValue)
+	}
+	if value, _ := repo.GetCounter(ctx, key); value != 0 {
+		t.Errorf("Expected counter reset to 0, got %d", value)
+	}
+
+	// 释放后可再次重置，令牌单调递增
+	next, err := s.ResetCounter(ctx, &counter.ResetCounterRequest{ResourceId: "article_1", CounterType: "like"})
+	if err != nil {
+		t.Fatalf("Second ResetCounter failed: %v", err)
+	}
+	if next.FencingToken <= resp.FencingToken {
+		t.Errorf("Expected fencing token to increase, got %d then %d", resp.FencingToken, next.FencingToken)
+	}
+}
+
+func TestResetCounterRejectsConcurrentReset(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	s, locker := newResetServer(t, repo)
+	ctx := context.Background()
+	key := dao.CounterKey(ctx, "article_1", "like")
+	repo.SetCounter(ctx, key, 42)
+
+	// 模拟其他实例正在重置同一计数器
+	lock, err := locker.Acquire(ctx, resetLockPrefix+key, time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	_, err = s.ResetCounter(ctx, &counter.ResetCounterRequest{ResourceId: "article_1", CounterType: "like"})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("Expected Aborted while another reset holds the lock, got %v", err)
+	}
+	if value, _ := repo.GetCounter(ctx, key); value != 42 {
+		t.Errorf("Expected counter untouched while locked, got %d", value)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, err := s.ResetCounter(ctx, &counter.ResetCounterRequest{ResourceId: "article_1", CounterType: "like"}); err != nil {
+		t.Errorf("Expected reset to succeed after the lock is released, got %v", err)
+	}
+}
+
+func TestResetCounterRequiresLocker(t *testing.T) {
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+
+	_, err := s.ResetCounter(context.Background(), &counter.ResetCounterRequest{ResourceId: "article_1", CounterType: "like"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without a locker, got %v", err)
+	}
+}
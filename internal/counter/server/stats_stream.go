@@ -0,0 +1,90 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/pkg/metrics"
+
+	"google.golang.org/grpc/status"
+)
+
+// minStreamInterval 流式推送间隔下限，避免客户端把服务端当作忙轮询
+const minStreamInterval = 100 * time.Millisecond
+
+// streamInterval 计算流式推送间隔：客户端指定优先，否则使用配置值，不低于下限
+func streamInterval(intervalMs int32, configured, fallback time.Duration) time.Duration {
+	interval := time.Duration(intervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = configured
+	}
+	if interval <= 0 {
+		interval = fallback
+	}
+	if interval < minStreamInterval {
+		interval = minStreamInterval
+	}
+	return interval
+}
+
+// statsStreamInterval StreamStats的推送间隔
+func (s *CounterServer) statsStreamInterval(intervalMs int32) time.Duration {
+	return streamInterval(intervalMs, s.config.StatsStreamInterval, DefaultConfig().StatsStreamInterval)
+}
+
+// StreamStats 按固定间隔流式推送服务指标快照，客户端取消或断开时结束
+func (s *CounterServer) StreamStats(req *counter.StreamStatsRequest, stream counter.CounterService_StreamStatsServer) error {
+	ctx := stream.Context()
+	sampler := metrics.NewRateSampler(s.metricsManager, "counter")
+
+	ticker := time.NewTicker(s.statsStreamInterval(req.IntervalMs))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+			if err := stream.Send(s.statsSnapshot(sampler)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// statsSnapshot 汇总请求速率、工作池和对象池的当前状态
+func (s *CounterServer) statsSnapshot(sampler *metrics.RateSampler) *counter.StatsSnapshot {
+	rate := sampler.Sample()
+	snapshot := &counter.StatsSnapshot{
+		TimestampMs:   time.Now().UnixMilli(),
+		Qps:           rate.QPS,
+		ErrorRate:     rate.ErrorRate,
+		TotalRequests: rate.Requests,
+		TotalErrors:   rate.Errors,
+		EventsDropped: atomic.LoadInt64(&s.eventsDropped),
+	}
+
+	if s.workerPool != nil {
+		poolStats := s.workerPool.GetStats().CounterPool
+		snapshot.WorkerPoolRunning = int32(poolStats.Running)
+		snapshot.WorkerPoolCapacity = int32(poolStats.Cap)
+		snapshot.WorkerPoolUtilization = s.WorkerPoolUtilization()
+	}
+	if s.objectPool != nil {
+		snapshot.ObjectPoolHitRate = s.objectPool.GetStats().Response.Hit
+	}
+	return snapshot
+}
+
+// WorkerPoolUtilization 计数器工作池利用率，0-1；可作为LoadScorer的利用率来源
+func (s *CounterServer) WorkerPoolUtilization() float64 {
+	if s.workerPool == nil {
+		return 0
+	}
+	poolStats := s.workerPool.GetStats().CounterPool
+	if poolStats.Cap <= 0 {
+		return 0
+	}
+	return float64(poolStats.Running) / float64(poolStats.Cap)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/metrics"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// receivedSnapshot 收到的指标快照及到达时间
+type receivedSnapshot struct {
+	at       time.Time
+	snapshot *counter.StatsSnapshot
+}
+
+// snapshotStream 收集指标快照
+type snapshotStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received chan receivedSnapshot
+}
+
+func (s *snapshotStream) Context() context.Context { return s.ctx }
+
+func (s *snapshotStream) Send(snapshot *counter.StatsSnapshot) error {
+	s.received <- receivedSnapshot{at: time.Now(), snapshot: snapshot}
+	return nil
+}
+
+func TestStreamStatsEmitsAtIntervalAndStopsOnCancel(t *testing.T) {
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+	s.SetMetricsManager(mm)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &snapshotStream{ctx: ctx, received: make(chan receivedSnapshot, 10)}
+	const interval = 100 * time.Millisecond
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.StreamStats(&counter.StreamStatsRequest{IntervalMs: int32(interval / time.Millisecond)}, stream)
+	}()
+
+	var arrivals []time.Time
+	for len(arrivals) < 3 {
+		select {
+		case r := <-stream.received:
+			arrivals = append(arrivals, r.at)
+			switch len(arrivals) {
+			case 1:
+				// 第二个区间内有请求，快照中体现为QPS和错误率
+				mm.RecordGRPCRequest("/counter.CounterService/IncrementCounter", "counter", "OK", time.Millisecond)
+				mm.RecordGRPCRequest("/counter.CounterService/IncrementCounter", "counter", "Internal", time.Millisecond)
+			case 2:
+				if r.snapshot.TotalRequests != 2 || r.snapshot.ErrorRate != 0.5 || r.snapshot.Qps <= 0 {
+					t.Errorf("Expected snapshot to reflect 2 requests with 50%% errors, got %+v", r.snapshot)
+				}
+			case 3:
+				if r.snapshot.Qps != 0 || r.snapshot.ErrorRate != 0 {
+					t.Errorf("Expected idle interval to report zero rates, got %+v", r.snapshot)
+				}
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for snapshot %d", len(arrivals)+1)
+		}
+	}
+
+	// 按间隔推送，而不是连续推送
+	if elapsed := arrivals[0].Sub(start); elapsed < interval*8/10 {
+		t.Errorf("Expected first snapshot after ~%v, got %v", interval, elapsed)
+	}
+	for i := 1; i < len(arrivals); i++ {
+		if gap := arrivals[i].Sub(arrivals[i-1]); gap < interval*8/10 {
+			t.Errorf("Expected snapshots %v apart, got %v", interval, gap)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("Expected Canceled after client cancel, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StreamStats did not stop after client cancel")
+	}
+}
+
+func TestStatsStreamIntervalClamp(t *testing.T) {
+	s := newTestCounterServer(daotest.NewMemoryCounterRepo())
+
+	if got := s.statsStreamInterval(0); got != DefaultConfig().StatsStreamInterval {
+		t.Errorf("Expected default interval, got %v", got)
+	}
+	if got := s.statsStreamInterval(1); got != minStreamInterval {
+		t.Errorf("Expected interval clamped to %v, got %v", minStreamInterval, got)
+	}
+	if got := s.statsStreamInterval(250); got != 250*time.Millisecond {
+		t.Errorf("Expected requested interval 250ms, got %v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"time"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/dao"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchCounter 流式推送计数器值，连接后立即推送当前值，之后值变化时推送
+func (s *CounterServer) WatchCounter(req *counter.WatchCounterRequest, stream counter.CounterService_WatchCounterServer) error {
+	if req.ResourceId == "" || req.CounterType == "" {
+		return status.Error(codes.InvalidArgument, "resource_id and counter_type are required")
+	}
+
+	ctx := stream.Context()
+	key := dao.CounterKey(ctx, req.ResourceId, req.CounterType)
+	interval := streamInterval(req.IntervalMs, s.config.WatchInterval, DefaultConfig().WatchInterval)
+
+	err := dao.WatchCounter(ctx, s.dao, key, interval, func(value int64) error {
+		return stream.Send(&counter.CounterUpdate{
+			ResourceId:  req.ResourceId,
+			CounterType: req.CounterType,
+			Value:       value,
+			TimestampMs: time.Now().UnixMilli(),
+		})
+	})
+
+	switch {
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case dao.IsCorruptCounterValue(err):
+		return status.Error(codes.DataLoss, err.Error())
+	case err != nil:
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		s.errorLog.Error("Failed to watch counter", err,
+			zap.String("resource_id", req.ResourceId),
+			zap.String("counter_type", req.CounterType))
+		return status.Errorf(codes.Unavailable, "failed to watch counter: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"strings"
+)
+
+// redisClusterSlots Redis Cluster的slot总数
+const redisClusterSlots = 16384
+
+// crossSlotReply Redis在一次请求涉及多个slot时返回的错误前缀
+const crossSlotReply = "CROSSSLOT"
+
+// isCrossSlotError 判断错误是否为CROSSSLOT
+func isCrossSlotError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), crossSlotReply)
+}
+
+// clusterSlot 计算key所属的slot，规则与Redis Cluster一致：
+// key中包含非空的{hashtag}时只对hashtag计算CRC16，结果对16384取模
+func clusterSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key)) % redisClusterSlots
+}
+
+// groupKeysBySlot 按slot对key分组，组的顺序与每组内key的顺序与首次出现的顺序一致
+func groupKeysBySlot(keys []string) [][]string {
+	index := make(map[int]int)
+	var groups [][]string
+	for _, key := range keys {
+		slot := clusterSlot(key)
+		i, ok := index[slot]
+		if !ok {
+			i = len(groups)
+			index[slot] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+// crc16 CRC16-CCITT (XMODEM)，Redis Cluster使用的slot哈希算法
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"errors"
+	"testing"
+
+	"high-go-press/pkg/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestClusterSlot(t *testing.T) {
+	// CRC16 XMODEM的标准校验值
+	if got := crc16("123456789"); got != 0x31C3 {
+		t.Fatalf("crc16 = %#x, want 0x31c3", got)
+	}
+	if got := clusterSlot("foo"); got != 12182 {
+		t.Errorf("Expected slot 12182 for foo, got %d", got)
+	}
+	// hashtag相同的key落在同一slot，空hashtag按整个key计算
+	if clusterSlot("{user1000}.following") != clusterSlot("{user1000}.followers") {
+		t.Error("Expected keys with the same hashtag to share a slot")
+	}
+	if clusterSlot("foo{}bar") != int(crc16("foo{}bar"))%redisClusterSlots {
+		t.Error("Expected empty hashtag to hash the whole key")
+	}
+}
+
+func TestGroupKeysBySlot(t *testing.T) {
+	keys := []string{"{a}:1", "{b}:1", "{a}:2", "{b}:2", "{a}:3"}
+	groups := groupKeysBySlot(keys)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 slot groups, got %v", groups)
+	}
+	if len(groups[0]) != 3 || groups[0][0] != "{a}:1" || groups[0][2] != "{a}:3" {
+		t.Errorf("Unexpected first group: %v", groups[0])
+	}
+	if len(groups[1]) != 2 || groups[1][0] != "{b}:1" {
+		t.Errorf("Unexpected second group: %v", groups[1])
+	}
+}
+
+func TestIsCrossSlotError(t *testing.T) {
+	if !isCrossSlotError(errors.New("CROSSSLOT Keys in request don't hash to the same slot")) {
+		t.Error("Expected CROSSSLOT error to be detected")
+	}
+	if isCrossSlotError(nil) || isCrossSlotError(redis.Nil) {
+		t.Error("Expected nil and redis.Nil not to be CROSSSLOT")
+	}
+}
+
+func TestNewRedisClientModes(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.RedisConfig
+		want string
+	}{
+		{"standalone", config.RedisConfig{Address: "localhost:6379"}, "*redis.Client"},
+		{"cluster", config.RedisConfig{Mode: config.RedisModeCluster, Addresses: []string{"n1:6379", "n2:6379"}}, "*redis.ClusterClient"},
+		{"sentinel", config.RedisConfig{Mode: config.RedisModeSentinel, MasterName: "mymaster", Addresses: []string{"s1:26379"}}, "*redis.Client"},
+	}
+	for _, tt := range tests {
+		client, err := NewRedisClient(tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: NewRedisClient failed: %v", tt.name, err)
+		}
+		var got string
+		switch client.(type) {
+		case *redis.ClusterClient:
+			got = "*redis.ClusterClient"
+		case *redis.Client:
+			got = "*redis.Client"
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %s, got %T", tt.name, tt.want, client)
+		}
+		client.Close()
+	}
+
+	if _, err := NewRedisClient(config.RedisConfig{Mode: "proxy"}); err == nil {
+		t.Error("Expected error for unsupported mode")
+	}
+	if _, err := NewRedisClient(config.RedisConfig{Mode: config.RedisModeSentinel, Addresses: []string{"s1:26379"}}); err == nil {
+		t.Error("Expected error for sentinel mode without master_name")
+	}
+}
@@ -0,0 +1,161 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"high-go-press/internal/biz"
+)
+
+// resourceScanBudgetFactor ScanResourceCounters扫描key数上限相对limit+1的倍数
+// 前缀扫描会匹配到其他资源的key（如"counter:a:b:like"），这些key不计入结果但占用扫描配额
+const resourceScanBudgetFactor = 8
+
+// ScanResourceCounters 扫描资源下的所有计数器，返回计数器类型到计数值的映射
+// 最多返回limit个类型，超出时按类型名排序截断并返回truncated=true；
+// 扫描到的key大多属于其他资源时逐步扩大扫描范围，达到limit+1的resourceScanBudgetFactor倍
+// 仍未扫描完也返回truncated=true，表示可能还有未返回的类型
+func ScanResourceCounters(ctx context.Context, scanner biz.CounterScanner, resourceID string, limit int) (counters map[string]int64, truncated bool, err error) {
+	prefix := ResourceKeyPrefix(ctx, resourceID)
+
+	// 多取一个用于判断是否超出上限
+	budget := limit + 1
+	maxBudget := budget * resourceScanBudgetFactor
+	for {
+		values, err := scanner.ScanCounters(ctx, prefix, budget)
+		if err != nil {
+			return nil, false, err
+		}
+
+		counters = make(map[string]int64, len(values))
+		for key, value := range values {
+			counterType := strings.TrimPrefix(key, prefix)
+			// 跳过其他资源的key，如扫描资源"a"时匹配到的"counter:a:b:like"
+			if counterType == "" || strings.Contains(counterType, ":") {
+				continue
+			}
+			counters[counterType] = value
+		}
+
+		// 已超出上限，或扫描未用满配额说明已扫描完全部key
+		if len(counters) > limit || len(values) < budget {
+			break
+		}
+		if budget >= maxBudget {
+			truncated = true
+			break
+		}
+		budget = min(budget*2, maxBudget)
+	}
+
+	if len(counters) > limit {
+		types := make([]string, 0, len(counters))
+		for counterType := range counters {
+			types = append(types, counterType)
+		}
+		sort.Strings(types)
+		for _, counterType := range types[limit:] {
+			delete(counters, counterType)
+		}
+		truncated = true
+	}
+
+	return counters, truncated, nil
+}
+
+// FindCountersAbove 查找指定类型中计数值大于threshold的资源，按计数值从高到低返回最多limit个
+//
+// 优先读取排行榜ZSET；存储不支持或排行榜不存在时回退到SCAN全部计数器key，
+// 回退时最多扫描maxScan个key，还有未扫描的key时返回truncated=true，结果可能不完整。
+// 两者都不支持时返回ErrScanUnsupported。
+func FindCountersAbove(ctx context.Context, repo biz.CounterRepo, counterType string, threshold int64, limit, maxScan int) (entries []biz.LeaderboardEntry, truncated bool, err error) {
+	if reader, ok := repo.(biz.LeaderboardReader); ok {
+		entries, err := reader.RangeLeaderboardAbove(ctx, LeaderboardKey(ctx, counterType), threshold, limit)
+		if !errors.Is(err, ErrLeaderboardNotFound) {
+			return entries, false, err
+		}
+	}
+
+	scanner, ok := repo.(biz.CounterScanner)
+	if !ok {
+		return nil, false, ErrScanUnsupported
+	}
+
+	prefix := counterKeyPrefix(ctx)
+	suffix := ":" + counterType
+	// 多取一个用于判断是否还有未扫描的key
+	values, err := scanner.ScanCounters(ctx, prefix, maxScan+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(values) > maxScan {
+		truncated = true
+		dropExtraKey(values, maxScan)
+	}
+
+	entries = make([]biz.LeaderboardEntry, 0)
+	for key, value := range values {
+		if value <= threshold || !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		resourceID := strings.TrimSuffix(strings.TrimPrefix(key, prefix), suffix)
+		if resourceID == "" {
+			continue
+		}
+		entries = append(entries, biz.LeaderboardEntry{Member: resourceID, Score: value})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].Member < entries[j].Member
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, truncated, nil
+}
+
+// dropExtraKey 按key排序删除超出maxScan的部分，使截断后的结果只包含maxScan个key
+func dropExtraKey(values map[string]int64, maxScan int) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys[maxScan:] {
+		delete(values, key)
+	}
+}
+
+// WatchCounter 按interval轮询计数器，立即回调当前值，之后仅在值变化时回调
+// ctx取消、读取失败或回调返回错误时结束
+func WatchCounter(ctx context.Context, repo biz.CounterRepo, key string, interval time.Duration, fn func(value int64) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last int64
+	first := true
+	for {
+		value, err := repo.GetCounter(ctx, key)
+		if err != nil {
+			return err
+		}
+		if first || value != last {
+			if err := fn(value); err != nil {
+				return err
+			}
+			last, first = value, false
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// Package daotest 计数存储的内存实现，供各包测试共用，行为与Redis实现保持一致
+package daotest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"high-go-press/internal/biz"
+	"high-go-press/internal/dao"
+)
+
+// MemoryCounterRepo 内存实现的计数存储，实现CounterRepo及全部可选能力
+//
+// 导出的字段用于模拟故障和延迟，需在开始调用前设置
+type MemoryCounterRepo struct {
+	Block    bool             // IncrementCounter阻塞直到ctx取消
+	Delay    time.Duration    // IncrementCounter模拟耗时
+	ReadErr  error            // 读取操作返回的错误
+	WriteErr error            // 写入操作返回的错误
+	KeyErrs  map[string]error // GetMultiCountersPartial中读取失败的key
+
+	mu            sync.Mutex
+	values        map[string]int64
+	ttls          map[string]time.Duration    // IncrementCounterWithTTL创建key时设置的过期时间
+	leaderboards  map[string]map[string]int64 // 排行榜key -> 成员 -> 分数
+	multiGetCalls [][]string
+
+	inFlight    int64
+	maxInFlight int64
+}
+
+// NewMemoryCounterRepo 创建内存计数存储
+func NewMemoryCounterRepo() *MemoryCounterRepo {
+	return &MemoryCounterRepo{
+		values:       make(map[string]int64),
+		ttls:         make(map[string]time.Duration),
+		leaderboards: make(map[string]map[string]int64),
+	}
+}
+
+// IncrementCounter 增加计数器，与Redis INCRBY一致：溢出时拒绝执行
+func (r *MemoryCounterRepo) IncrementCounter(ctx context.Context, key string, increment int64) (int64, error) {
+	current := atomic.AddInt64(&r.inFlight, 1)
+	defer atomic.AddInt64(&r.inFlight, -1)
+	for {
+		peak := atomic.LoadInt64(&r.maxInFlight)
+		if current <= peak || atomic.CompareAndSwapInt64(&r.maxInFlight, peak, current) {
+			break
+		}
+	}
+
+	if r.Delay > 0 {
+		time.Sleep(r.Delay)
+	}
+	if r.Block {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.WriteErr != nil {
+		return 0, r.WriteErr
+	}
+	if dao.AddOverflows(r.values[key], increment) {
+		return 0, dao.ErrCounterOverflow
+	}
+	r.values[key] += increment
+	return r.values[key], nil
+}
+
+// IncrementCounterWithTTL 增加计数器，key由本次调用创建时记录过期时间
+func (r *MemoryCounterRepo) IncrementCounterWithTTL(ctx context.Context, key string, increment int64, ttl time.Duration) (int64, error) {
+	r.mu.Lock()
+	_, exists := r.values[key]
+	r.mu.Unlock()
+
+	value, err := r.IncrementCounter(ctx, key, increment)
+	if err == nil && !exists {
+		r.mu.Lock()
+		r.ttls[key] = ttl
+		r.mu.Unlock()
+	}
+	return value, err
+}
+
+// DecrementCounter 减少计数器，与Redis DECRBY一致：溢出时拒绝执行，clampAtZero时结果低于0则截断为0，
+// 已经为负的计数器在clampAtZero时保持不变
+func (r *MemoryCounterRepo) DecrementCounter(ctx context.Context, key string, delta int64, clampAtZero bool) (int64, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.WriteErr != nil {
+		return 0, 0, r.WriteErr
+	}
+	previous := r.values[key]
+	if clampAtZero && previous < 0 {
+		return previous, 0, nil
+	}
+	if delta == math.MinInt64 || dao.AddOverflows(previous, -delta) {
+		return 0, 0, dao.ErrCounterOverflow
+	}
+	r.values[key] -= delta
+	if clampAtZero && r.values[key] < 0 {
+		r.values[key] = 0
+	}
+	return r.values[key], r.values[key] - previous, nil
+}
+
+// CompareAndSetCounter 当前值等于expected时设置为newValue，不存在的计数器按0比较
+func (r *MemoryCounterRepo) CompareAndSetCounter(ctx context.Context, key string, expected, newValue int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.WriteErr != nil {
+		return false, r.WriteErr
+	}
+	if r.values[key] != expected {
+		return false, nil
+	}
+	r.values[key] = newValue
+	return true, nil
+}
+
+// GetCounter 获取计数器值，不存在时为0
+func (r *MemoryCounterRepo) GetCounter(ctx context.Context, key string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ReadErr != nil {
+		return 0, r.ReadErr
+	}
+	return r.values[key], nil
+}
+
+// GetMultiCounters 批量获取计数器
+func (r *MemoryCounterRepo) GetMultiCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.multiGetCalls = append(r.multiGetCalls, keys)
+	if r.ReadErr != nil {
+		return nil, r.ReadErr
+	}
+	result := make(map[string]int64, len(keys))
+	for _, key := range keys {
+		result[key] = r.values[key]
+	}
+	return result, nil
+}
+
+// GetMultiCountersPartial 批量获取计数器，KeyErrs中的key单独失败，全部失败时返回错误
+func (r *MemoryCounterRepo) GetMultiCountersPartial(ctx context.Context, keys []string) (map[string]int64, map[string]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.multiGetCalls = append(r.multiGetCalls, keys)
+	if r.ReadErr != nil {
+		return nil, nil, r.ReadErr
+	}
+	values := make(map[string]int64, len(keys))
+	errs := make(map[string]error)
+	for _, key := range keys {
+		if err, ok := r.KeyErrs[key]; ok {
+			errs[key] = err
+			continue
+		}
+		values[key] = r.values[key]
+	}
+	if len(keys) > 0 && len(errs) == len(keys) {
+		return values, errs, fmt.Errorf("all %d keys failed", len(keys))
+	}
+	return values, errs, nil
+}
+
+// SetCounter 设置计数器值
+func (r *MemoryCounterRepo) SetCounter(ctx context.Context, key string, value int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.WriteErr != nil {
+		return r.WriteErr
+	}
+	r.values[key] = value
+	return nil
+}
+
+// GetOrInitCounter 模拟SETNX+GET：key不存在时写入初始值
+func (r *MemoryCounterRepo) GetOrInitCounter(ctx context.Context, key string, initial int64) (int64, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.WriteErr != nil {
+		return 0, false, r.WriteErr
+	}
+	if value, exists := r.values[key]; exists {
+		return value, false, nil
+	}
+	r.values[key] = initial
+	return initial, true, nil
+}
+
+// ScanCounters 模拟SCAN MATCH prefix*，最多返回limit个，limit不大于0时不限制
+func (r *MemoryCounterRepo) ScanCounters(ctx context.Context, prefix string, limit int) (map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ReadErr != nil {
+		return nil, r.ReadErr
+	}
+	result := make(map[string]int64)
+	for key, value := range r.values {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if strings.HasPrefix(key, prefix) {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// RangeLeaderboardAbove 按分数从高到低返回分数大于threshold的成员，排行榜不存在时返回ErrLeaderboardNotFound
+func (r *MemoryCounterRepo) RangeLeaderboardAbove(ctx context.Context, key string, threshold int64, limit int) ([]biz.LeaderboardEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	board, exists := r.leaderboards[key]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", dao.ErrLeaderboardNotFound, key)
+	}
+	entries := make([]biz.LeaderboardEntry, 0)
+	for member, score := range board {
+		if score > threshold {
+			entries = append(entries, biz.LeaderboardEntry{Member: member, Score: score})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Score > entries[j].Score })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// SetLeaderboardScore 按ZADD GT语义设置成员分数：成员不存在或score更大时才更新
+func (r *MemoryCounterRepo) SetLeaderboardScore(ctx context.Context, key, member string, score int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	leaderboard := r.leaderboardLocked(key)
+	if current, ok := leaderboard[member]; !ok || score > current {
+		leaderboard[member] = score
+	}
+	return nil
+}
+
+// IncrementLeaderboard 为成员增加分数
+func (r *MemoryCounterRepo) IncrementLeaderboard(ctx context.Context, key, member string, increment int64, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leaderboardLocked(key)[member] += increment
+	return nil
+}
+
+// leaderboardLocked 获取或创建排行榜，调用方需持有锁
+func (r *MemoryCounterRepo) leaderboardLocked(key string) map[string]int64 {
+	if r.leaderboards[key] == nil {
+		r.leaderboards[key] = make(map[string]int64)
+	}
+	return r.leaderboards[key]
+}
+
+// Value 直接读取key的值，不受ReadErr影响，用于断言
+func (r *MemoryCounterRepo) Value(key string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[key]
+}
+
+// SetValue 直接写入key的值，不受WriteErr影响，用于准备数据
+func (r *MemoryCounterRepo) SetValue(key string, value int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = value
+}
+
+// Len 已存储的计数器数量
+func (r *MemoryCounterRepo) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.values)
+}
+
+// TTL IncrementCounterWithTTL创建key时设置的过期时间
+func (r *MemoryCounterRepo) TTL(key string) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ttl, ok := r.ttls[key]
+	return ttl, ok
+}
+
+// TTLCount 设置了过期时间的key数量
+func (r *MemoryCounterRepo) TTLCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.ttls)
+}
+
+// SetLeaderboard 直接写入排行榜，用于准备数据
+func (r *MemoryCounterRepo) SetLeaderboard(key string, scores map[string]int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	board := make(map[string]int64, len(scores))
+	for member, score := range scores {
+		board[member] = score
+	}
+	r.leaderboards[key] = board
+}
+
+// LeaderboardScore 成员在排行榜中的分数
+func (r *MemoryCounterRepo) LeaderboardScore(key, member string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.leaderboards[key][member]
+}
+
+// LeaderboardKeys 已写入的排行榜key，按字典序排列
+func (r *MemoryCounterRepo) LeaderboardKeys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, 0, len(r.leaderboards))
+	for key := range r.leaderboards {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MaxInFlight IncrementCounter的最大并发调用数
+func (r *MemoryCounterRepo) MaxInFlight() int64 {
+	return atomic.LoadInt64(&r.maxInFlight)
+}
+
+// MultiGetCalls 每次批量读取请求的key
+func (r *MemoryCounterRepo) MultiGetCalls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.multiGetCalls...)
+}
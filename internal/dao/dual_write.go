@@ -0,0 +1,367 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"high-go-press/internal/biz"
+	"high-go-press/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// DualWriteConfig 双写存储配置
+type DualWriteConfig struct {
+	CompareReads         bool // 读取时是否同时读取备存储并比对
+	FailOnSecondaryError bool // 备存储写入失败时是否返回错误
+}
+
+// DefaultDualWriteConfig 默认双写配置
+func DefaultDualWriteConfig() *DualWriteConfig {
+	return &DualWriteConfig{
+		CompareReads:         true,
+		FailOnSecondaryError: false,
+	}
+}
+
+// DualWriteStats 双写统计信息
+type DualWriteStats struct {
+	SecondaryWrites      int64 `json:"secondary_writes"`
+	SecondaryWriteErrors int64 `json:"secondary_write_errors"`
+	SecondaryReadErrors  int64 `json:"secondary_read_errors"`
+	Comparisons          int64 `json:"comparisons"`
+	Discrepancies        int64 `json:"discrepancies"`
+}
+
+// DualWriteCounterStore 双写计数存储 - 同时写入主/备存储，从主存储读取并比对差异
+type DualWriteCounterStore struct {
+	primary   biz.CounterRepo
+	secondary biz.CounterRepo
+	config    *DualWriteConfig
+	logger    *zap.Logger
+	metrics   *metrics.MetricsManager
+
+	stats DualWriteStats
+	mu    sync.Mutex
+}
+
+// NewDualWriteCounterStore 创建双写计数存储
+func NewDualWriteCounterStore(primary, secondary biz.CounterRepo, config *DualWriteConfig, logger *zap.Logger) *DualWriteCounterStore {
+	if config == nil {
+		config = DefaultDualWriteConfig()
+	}
+
+	return &DualWriteCounterStore{
+		primary:   primary,
+		secondary: secondary,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// SetMetricsManager 设置指标管理器，用于上报差异指标
+func (s *DualWriteCounterStore) SetMetricsManager(mm *metrics.MetricsManager) {
+	s.metrics = mm
+}
+
+// IncrementCounter 增加计数器，主存储成功后再写备存储
+func (s *DualWriteCounterStore) IncrementCounter(ctx context.Context, key string, increment int64) (int64, error) {
+	value, err := s.primary.IncrementCounter(ctx, key, increment)
+	if err != nil {
+		return 0, err
+	}
+
+	secondaryValue, secondaryErr := s.secondary.IncrementCounter(ctx, key, increment)
+	if err := s.recordSecondaryWrite(key, "increment", secondaryErr); err != nil {
+		return value, err
+	}
+	if secondaryErr == nil {
+		s.compare(key, value, secondaryValue)
+	}
+
+	return value, nil
+}
+
+// IncrementCounterWithTTL 增加计数器，新建的key在支持过期的存储上设置ttl
+func (s *DualWriteCounterStore) IncrementCounterWithTTL(ctx context.Context, key string, increment int64, ttl time.Duration) (int64, error) {
+	value, err := incrementWithTTL(ctx, s.primary, key, increment, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	secondaryValue, secondaryErr := incrementWithTTL(ctx, s.secondary, key, increment, ttl)
+	if err := s.recordSecondaryWrite(key, "increment", secondaryErr); err != nil {
+		return value, err
+	}
+	if secondaryErr == nil {
+		s.compare(key, value, secondaryValue)
+	}
+
+	return value, nil
+}
+
+// incrementWithTTL 存储支持时使用带过期时间的增量，否则退化为普通增量
+func incrementWithTTL(ctx context.Context, repo biz.CounterRepo, key string, increment int64, ttl time.Duration) (int64, error) {
+	if incrementer, ok := repo.(biz.CounterTTLIncrementer); ok {
+		return incrementer.IncrementCounterWithTTL(ctx, key, increment, ttl)
+	}
+	return repo.IncrementCounter(ctx, key, increment)
+}
+
+// getMultiCountersPartial 存储支持时保留单个key的错误，否则退化为普通批量读取
+func getMultiCountersPartial(ctx context.Context, repo biz.CounterRepo, keys []string) (map[string]int64, map[string]error, error) {
+	if getter, ok := repo.(biz.CounterPartialGetter); ok {
+		return getter.GetMultiCountersPartial(ctx, keys)
+	}
+	values, err := repo.GetMultiCounters(ctx, keys)
+	return values, make(map[string]error), err
+}
+
+// DecrementCounter 在主存储上减少计数器，备存储按主存储的实际变化量同步
+// 截断时备存储增加同样的变化量，避免两边因截断基准不同而产生差异
+func (s *DualWriteCounterStore) DecrementCounter(ctx context.Context, key string, delta int64, clampAtZero bool) (int64, int64, error) {
+	decrementer, ok := s.primary.(biz.CounterDecrementer)
+	if !ok {
+		return 0, 0, ErrDecrementUnsupported
+	}
+
+	value, applied, err := decrementer.DecrementCounter(ctx, key, delta, clampAtZero)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	secondaryValue, secondaryErr := s.secondary.IncrementCounter(ctx, key, applied)
+	if err := s.recordSecondaryWrite(key, "decrement", secondaryErr); err != nil {
+		return value, applied, err
+	}
+	if secondaryErr == nil {
+		s.compare(key, value, secondaryValue)
+	}
+
+	return value, applied, nil
+}
+
+// CompareAndSetCounter 在主存储上比较并设置，设置成功后将新值同步到备存储
+func (s *DualWriteCounterStore) CompareAndSetCounter(ctx context.Context, key string, expected, newValue int64) (bool, error) {
+	setter, ok := s.primary.(biz.CounterCompareAndSetter)
+	if !ok {
+		return false, ErrCompareAndSetUnsupported
+	}
+
+	swapped, err := setter.CompareAndSetCounter(ctx, key, expected, newValue)
+	if err != nil || !swapped {
+		return swapped, err
+	}
+
+	err = s.secondary.SetCounter(ctx, key, newValue)
+	return true, s.recordSecondaryWrite(key, "compare_and_set", err)
+}
+
+// GetCounter 从主存储读取计数器，按配置与备存储比对
+func (s *DualWriteCounterStore) GetCounter(ctx context.Context, key string) (int64, error) {
+	value, err := s.primary.GetCounter(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.config.CompareReads {
+		secondaryValue, err := s.secondary.GetCounter(ctx, key)
+		if err != nil {
+			s.recordSecondaryReadError(key, err)
+		} else {
+			s.compare(key, value, secondaryValue)
+		}
+	}
+
+	return value, nil
+}
+
+// GetMultiCounters 从主存储批量读取计数器，按配置与备存储比对
+func (s *DualWriteCounterStore) GetMultiCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	values, err := s.primary.GetMultiCounters(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	s.compareBatch(ctx, keys, values)
+	return values, nil
+}
+
+// GetMultiCountersPartial 从主存储批量读取计数器并保留单个key的错误，按配置与备存储比对读取成功的key
+func (s *DualWriteCounterStore) GetMultiCountersPartial(ctx context.Context, keys []string) (map[string]int64, map[string]error, error) {
+	values, errs, err := getMultiCountersPartial(ctx, s.primary, keys)
+	if err != nil {
+		return values, errs, err
+	}
+
+	s.compareBatch(ctx, keys, values)
+	return values, errs, nil
+}
+
+// compareBatch 按配置从备存储读取同一批key，与主存储的结果比对
+func (s *DualWriteCounterStore) compareBatch(ctx context.Context, keys []string, values map[string]int64) {
+	if !s.config.CompareReads {
+		return
+	}
+
+	secondaryValues, err := s.secondary.GetMultiCounters(ctx, keys)
+	if err != nil {
+		s.recordSecondaryReadError(fmt.Sprintf("batch(%d)", len(keys)), err)
+		return
+	}
+	for key, value := range values {
+		// 备存储读取失败的key不参与比对
+		if secondaryValue, ok := secondaryValues[key]; ok {
+			s.compare(key, value, secondaryValue)
+		}
+	}
+}
+
+// GetCounterUpdatedAt 从主存储读取计数器最后写入时间，主存储不支持时返回空结果
+func (s *DualWriteCounterStore) GetCounterUpdatedAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	reader, ok := s.primary.(biz.CounterUpdateTimeReader)
+	if !ok {
+		return make(map[string]time.Time), nil
+	}
+	return reader.GetCounterUpdatedAt(ctx, keys)
+}
+
+// SetCounter 同时设置主/备存储的计数器
+func (s *DualWriteCounterStore) SetCounter(ctx context.Context, key string, value int64) error {
+	if err := s.primary.SetCounter(ctx, key, value); err != nil {
+		return err
+	}
+
+	err := s.secondary.SetCounter(ctx, key, value)
+	return s.recordSecondaryWrite(key, "set", err)
+}
+
+// GetOrInitCounter 在主存储上获取或初始化计数器，创建时同步写入备存储
+func (s *DualWriteCounterStore) GetOrInitCounter(ctx context.Context, key string, initial int64) (int64, bool, error) {
+	initializer, ok := s.primary.(biz.CounterInitializer)
+	if !ok {
+		return 0, false, ErrGetOrInitUnsupported
+	}
+
+	value, created, err := initializer.GetOrInitCounter(ctx, key, initial)
+	if err != nil || !created {
+		return value, created, err
+	}
+
+	err = s.secondary.SetCounter(ctx, key, value)
+	if err := s.recordSecondaryWrite(key, "get_or_init", err); err != nil {
+		return value, created, err
+	}
+	return value, created, nil
+}
+
+// ScanCounters 在主存储上按前缀扫描计数器
+func (s *DualWriteCounterStore) ScanCounters(ctx context.Context, prefix string, limit int) (map[string]int64, error) {
+	scanner, ok := s.primary.(biz.CounterScanner)
+	if !ok {
+		return nil, ErrScanUnsupported
+	}
+	return scanner.ScanCounters(ctx, prefix, limit)
+}
+
+// SetLeaderboardScore 只写主存储的排行榜，排行榜不参与双写比对
+func (s *DualWriteCounterStore) SetLeaderboardScore(ctx context.Context, key, member string, score int64) error {
+	writer, ok := s.primary.(biz.LeaderboardWriter)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaderboardUnsupported, key)
+	}
+	return writer.SetLeaderboardScore(ctx, key, member, score)
+}
+
+// IncrementLeaderboard 在主存储上增加排行榜分数
+func (s *DualWriteCounterStore) IncrementLeaderboard(ctx context.Context, key, member string, increment int64, ttl time.Duration) error {
+	writer, ok := s.primary.(biz.LeaderboardWriter)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaderboardUnsupported, key)
+	}
+	return writer.IncrementLeaderboard(ctx, key, member, increment, ttl)
+}
+
+// RangeLeaderboardAbove 在主存储上读取排行榜
+func (s *DualWriteCounterStore) RangeLeaderboardAbove(ctx context.Context, key string, threshold int64, limit int) ([]biz.LeaderboardEntry, error) {
+	reader, ok := s.primary.(biz.LeaderboardReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLeaderboardNotFound, key)
+	}
+	return reader.RangeLeaderboardAbove(ctx, key, threshold, limit)
+}
+
+// GetStats 获取双写统计信息
+func (s *DualWriteCounterStore) GetStats() DualWriteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// recordSecondaryWrite 记录备存储写入结果，按配置决定是否返回错误
+func (s *DualWriteCounterStore) recordSecondaryWrite(key, operation string, err error) error {
+	s.mu.Lock()
+	s.stats.SecondaryWrites++
+	if err != nil {
+		s.stats.SecondaryWriteErrors++
+	}
+	s.mu.Unlock()
+
+	if err == nil {
+		return nil
+	}
+
+	s.logger.Warn("Dual write to secondary store failed",
+		zap.String("key", key),
+		zap.String("operation", operation),
+		zap.Error(err))
+	s.recordMetric("dual_write_secondary", "error")
+
+	if s.config.FailOnSecondaryError {
+		return fmt.Errorf("secondary store %s failed: %w", operation, err)
+	}
+	return nil
+}
+
+// recordSecondaryReadError 记录备存储读取失败
+func (s *DualWriteCounterStore) recordSecondaryReadError(key string, err error) {
+	s.mu.Lock()
+	s.stats.SecondaryReadErrors++
+	s.mu.Unlock()
+
+	s.logger.Warn("Dual write compare read from secondary store failed",
+		zap.String("key", key),
+		zap.Error(err))
+}
+
+// compare 比对主/备存储的值并上报差异
+func (s *DualWriteCounterStore) compare(key string, primary, secondary int64) {
+	s.mu.Lock()
+	s.stats.Comparisons++
+	mismatch := primary != secondary
+	if mismatch {
+		s.stats.Discrepancies++
+	}
+	s.mu.Unlock()
+
+	if !mismatch {
+		s.recordMetric("dual_write_compare", "match")
+		return
+	}
+
+	s.logger.Warn("Dual write discrepancy detected",
+		zap.String("key", key),
+		zap.Int64("primary", primary),
+		zap.Int64("secondary", secondary))
+	s.recordMetric("dual_write_compare", "mismatch")
+}
+
+// recordMetric 上报双写业务指标
+func (s *DualWriteCounterStore) recordMetric(operation, status string) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.RecordBusinessOperation(operation, "counter", status, time.Duration(0))
+}
@@ -0,0 +1,192 @@
+package dao_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"high-go-press/internal/dao"
+	"high-go-press/internal/dao/daotest"
+
+	"go.uber.org/zap"
+)
+
+func TestDualWriteCounterStoreWritesBoth(t *testing.T) {
+	primary := daotest.NewMemoryCounterRepo()
+	secondary := daotest.NewMemoryCounterRepo()
+	store := dao.NewDualWriteCounterStore(primary, secondary, dao.DefaultDualWriteConfig(), zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := store.IncrementCounter(ctx, "counter:a:like", 3); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	if err := store.SetCounter(ctx, "counter:b:view", 10); err != nil {
+		t.Fatalf("SetCounter failed: %v", err)
+	}
+
+	for _, repo := range []*daotest.MemoryCounterRepo{primary, secondary} {
+		if repo.Value("counter:a:like") != 3 {
+			t.Errorf("Expected counter:a:like=3, got %d", repo.Value("counter:a:like"))
+		}
+		if repo.Value("counter:b:view") != 10 {
+			t.Errorf("Expected counter:b:view=10, got %d", repo.Value("counter:b:view"))
+		}
+	}
+
+	stats := store.GetStats()
+	if stats.SecondaryWrites != 2 {
+		t.Errorf("Expected 2 secondary writes, got %d", stats.SecondaryWrites)
+	}
+	if stats.Discrepancies != 0 {
+		t.Errorf("Expected no discrepancies, got %d", stats.Discrepancies)
+	}
+}
+
+func TestDualWriteDecrementAppliesPrimaryChange(t *testing.T) {
+	primary := daotest.NewMemoryCounterRepo()
+	secondary := daotest.NewMemoryCounterRepo()
+	primary.SetValue("counter:a:like", 2)
+	secondary.SetValue("counter:a:like", 2)
+	store := dao.NewDualWriteCounterStore(primary, secondary, dao.DefaultDualWriteConfig(), zap.NewNop())
+
+	value, applied, err := store.DecrementCounter(context.Background(), "counter:a:like", 5, true)
+	if err != nil {
+		t.Fatalf("DecrementCounter failed: %v", err)
+	}
+	if value != 0 || applied != -2 {
+		t.Errorf("Expected value 0 applied -2, got %d/%d", value, applied)
+	}
+	// 备存储按主存储的实际变化量同步
+	if secondary.Value("counter:a:like") != 0 {
+		t.Errorf("Expected secondary value 0, got %d", secondary.Value("counter:a:like"))
+	}
+	if stats := store.GetStats(); stats.Discrepancies != 0 {
+		t.Errorf("Expected no discrepancies, got %d", stats.Discrepancies)
+	}
+}
+
+func TestDualWriteCompareAndSetSyncsSecondaryOnSwap(t *testing.T) {
+	primary := daotest.NewMemoryCounterRepo()
+	secondary := daotest.NewMemoryCounterRepo()
+	primary.SetValue("counter:a:like", 3)
+	secondary.SetValue("counter:a:like", 3)
+	store := dao.NewDualWriteCounterStore(primary, secondary, dao.DefaultDualWriteConfig(), zap.NewNop())
+	ctx := context.Background()
+
+	swapped, err := store.CompareAndSetCounter(ctx, "counter:a:like", 3, 10)
+	if err != nil || !swapped {
+		t.Fatalf("Expected swap, got %v, %v", swapped, err)
+	}
+	if secondary.Value("counter:a:like") != 10 {
+		t.Errorf("Expected secondary synced to 10, got %d", secondary.Value("counter:a:like"))
+	}
+
+	// 期望值不匹配时两边都不变
+	swapped, err = store.CompareAndSetCounter(ctx, "counter:a:like", 3, 20)
+	if err != nil || swapped {
+		t.Fatalf("Expected no swap, got %v, %v", swapped, err)
+	}
+	if primary.Value("counter:a:like") != 10 || secondary.Value("counter:a:like") != 10 {
+		t.Errorf("Expected both stores to stay at 10, got %d/%d", primary.Value("counter:a:like"), secondary.Value("counter:a:like"))
+	}
+}
+
+func TestDualWriteCounterStoreReportsDiscrepancy(t *testing.T) {
+	primary := daotest.NewMemoryCounterRepo()
+	secondary := daotest.NewMemoryCounterRepo()
+	primary.SetValue("counter:a:like", 5)
+	primary.SetValue("counter:b:like", 7)
+	secondary.SetValue("counter:b:like", 7)
+	store := dao.NewDualWriteCounterStore(primary, secondary, dao.DefaultDualWriteConfig(), zap.NewNop())
+	ctx := context.Background()
+
+	value, err := store.GetCounter(ctx, "counter:a:like")
+	if err != nil {
+		t.Fatalf("GetCounter failed: %v", err)
+	}
+	if value != 5 {
+		t.Errorf("Expected value from primary 5, got %d", value)
+	}
+
+	if _, err := store.GetMultiCounters(ctx, []string{"counter:a:like", "counter:b:like"}); err != nil {
+		t.Fatalf("GetMultiCounters failed: %v", err)
+	}
+
+	stats := store.GetStats()
+	if stats.Comparisons != 3 {
+		t.Errorf("Expected 3 comparisons, got %d", stats.Comparisons)
+	}
+	if stats.Discrepancies != 2 {
+		t.Errorf("Expected 2 discrepancies, got %d", stats.Discrepancies)
+	}
+}
+
+func TestDualWriteCounterStoreSecondaryError(t *testing.T) {
+	primary := daotest.NewMemoryCounterRepo()
+	secondary := daotest.NewMemoryCounterRepo()
+	secondary.WriteErr = errors.New("secondary down")
+	ctx := context.Background()
+
+	lenient := dao.NewDualWriteCounterStore(primary, secondary, dao.DefaultDualWriteConfig(), zap.NewNop())
+	if _, err := lenient.IncrementCounter(ctx, "counter:a:like", 1); err != nil {
+		t.Errorf("Expected secondary error to be tolerated, got %v", err)
+	}
+	if stats := lenient.GetStats(); stats.SecondaryWriteErrors != 1 {
+		t.Errorf("Expected 1 secondary write error, got %d", stats.SecondaryWriteErrors)
+	}
+
+	strict := dao.NewDualWriteCounterStore(primary, secondary, &dao.DualWriteConfig{FailOnSecondaryError: true}, zap.NewNop())
+	if _, err := strict.IncrementCounter(ctx, "counter:a:like", 1); err == nil {
+		t.Error("Expected secondary error to be returned")
+	}
+}
+
+func TestDualWriteGetOrInitConcurrent(t *testing.T) {
+	primary := daotest.NewMemoryCounterRepo()
+	secondary := daotest.NewMemoryCounterRepo()
+	store := dao.NewDualWriteCounterStore(primary, secondary, dao.DefaultDualWriteConfig(), zap.NewNop())
+
+	const callers = 50
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+		values  []int64
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 每个调用方尝试用不同的初始值创建，只有一个能成功
+			value, ok, err := store.GetOrInitCounter(context.Background(), "counter:a:like", int64(100+i))
+			if err != nil {
+				t.Errorf("GetOrInitCounter failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				created++
+			}
+			values = append(values, value)
+		}(i)
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Fatalf("Expected exactly one caller to create the counter, got %d", created)
+	}
+	for _, value := range values {
+		if value != primary.Value("counter:a:like") {
+			t.Errorf("Expected all callers to observe the stored value %d, got %d", primary.Value("counter:a:like"), value)
+		}
+	}
+	if secondary.Value("counter:a:like") != primary.Value("counter:a:like") {
+		t.Errorf("Expected secondary to be seeded with %d, got %d",
+			primary.Value("counter:a:like"), secondary.Value("counter:a:like"))
+	}
+	if got := store.GetStats().SecondaryWrites; got != 1 {
+		t.Errorf("Expected only the creating call to write secondary, got %d", got)
+	}
+}
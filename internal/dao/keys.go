@@ -0,0 +1,104 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"high-go-press/pkg/middleware"
+)
+
+// CounterKey 构建计数器的Redis key
+// ctx携带租户ID时使用 {tenant}:counter:{resource}:{type}，否则为 counter:{resource}:{type}
+func CounterKey(ctx context.Context, resourceID, counterType string) string {
+	return tenantPrefix(ctx) + "counter:" + resourceID + ":" + counterType
+}
+
+// UpdatedAtKey 构建记录计数器最后写入时间的Redis key
+// 格式为 updated_at:{计数器key}，hashtag保证与计数器位于同一个Cluster slot，且不会被计数器前缀扫描匹配；
+// 计数器key自身带hashtag时直接拼接，沿用其hashtag
+func UpdatedAtKey(counterKey string) string {
+	if start := strings.IndexByte(counterKey, '{'); start >= 0 && strings.IndexByte(counterKey[start+1:], '}') > 0 {
+		return "updated_at:" + counterKey
+	}
+	return "updated_at:{" + counterKey + "}"
+}
+
+// ResourceKeyPrefix 构建资源下所有计数器key的公共前缀
+// 即 [{tenant}:]counter:{resource}:，拼接计数器类型后与CounterKey一致
+func ResourceKeyPrefix(ctx context.Context, resourceID string) string {
+	return tenantPrefix(ctx) + "counter:" + resourceID + ":"
+}
+
+// LeaderboardKey 构建排行榜的Redis key
+// ctx携带租户ID时使用 {tenant}:leaderboard:{type}，否则为 leaderboard:{type}
+func LeaderboardKey(ctx context.Context, counterType string) string {
+	return tenantPrefix(ctx) + "leaderboard:" + counterType
+}
+
+// LeaderboardWindowKey 构建时间窗口排行榜的Redis key
+// 格式为 [{tenant}:]leaderboard:{type}:{窗口秒数}:{窗口起始unix秒}，同一窗口内的增量写入同一个桶
+func LeaderboardWindowKey(ctx context.Context, counterType string, window time.Duration, at time.Time) string {
+	seconds := int64(window / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	bucket := at.Unix() / seconds * seconds
+	return LeaderboardKey(ctx, counterType) + ":" + strconv.FormatInt(seconds, 10) + ":" + strconv.FormatInt(bucket, 10)
+}
+
+// ErrInvalidHotRankPeriod 热点排行的时间范围不是hour、day、week之一
+var ErrInvalidHotRankPeriod = errors.New("hot rank period must be one of hour, day, week")
+
+// 热点排行支持的时间范围
+const (
+	HotRankPeriodHour = "hour"
+	HotRankPeriodDay  = "day"
+	HotRankPeriodWeek = "week"
+)
+
+// HotRankPeriods 热点排行支持的全部时间范围
+var HotRankPeriods = []string{HotRankPeriodHour, HotRankPeriodDay, HotRankPeriodWeek}
+
+// hotRankBucket 计算时间范围在at时刻所处的桶后缀和桶的过期时间
+// 过期时间为两个周期，保证桶在整个周期内都可读取，周期结束后自动清理
+func hotRankBucket(period string, at time.Time) (string, time.Duration, error) {
+	at = at.UTC()
+	switch period {
+	case HotRankPeriodHour:
+		return at.Format("2006010215"), 2 * time.Hour, nil
+	case HotRankPeriodDay:
+		return at.Format("20060102"), 2 * 24 * time.Hour, nil
+	case HotRankPeriodWeek:
+		year, week := at.ISOWeek()
+		return fmt.Sprintf("%04dW%02d", year, week), 2 * 7 * 24 * time.Hour, nil
+	default:
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidHotRankPeriod, period)
+	}
+}
+
+// HotRankKey 构建热点排行的Redis key及其过期时间
+// 格式为 [{tenant}:]hotrank:{type}:{period}:{桶}，桶按UTC时间划分：hour为2006010215，day为20060102，week为ISO周2006W01
+func HotRankKey(ctx context.Context, counterType, period string, at time.Time) (string, time.Duration, error) {
+	bucket, ttl, err := hotRankBucket(period, at)
+	if err != nil {
+		return "", 0, err
+	}
+	return tenantPrefix(ctx) + "hotrank:" + counterType + ":" + period + ":" + bucket, ttl, nil
+}
+
+// counterKeyPrefix 构建所有计数器key的公共前缀 [{tenant}:]counter:
+func counterKeyPrefix(ctx context.Context) string {
+	return tenantPrefix(ctx) + "counter:"
+}
+
+// tenantPrefix 获取租户key前缀
+func tenantPrefix(ctx context.Context) string {
+	if tenantID, ok := middleware.TenantIDFromContext(ctx); ok {
+		return tenantID + ":"
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/middleware"
+)
+
+func TestCounterKeyTenantNamespace(t *testing.T) {
+	ctx := context.Background()
+	if got := CounterKey(ctx, "article_1", "like"); got != "counter:article_1:like" {
+		t.Errorf("Expected key without tenant prefix, got %s", got)
+	}
+	if got := LeaderboardKey(ctx, "like"); got != "leaderboard:like" {
+		t.Errorf("Expected leaderboard key without tenant prefix, got %s", got)
+	}
+
+	acme := middleware.WithTenantID(ctx, "acme")
+	globex := middleware.WithTenantID(ctx, "globex")
+
+	if got := CounterKey(acme, "article_1", "like"); got != "acme:counter:article_1:like" {
+		t.Errorf("Expected tenant-prefixed key, got %s", got)
+	}
+	if got := LeaderboardKey(acme, "like"); got != "acme:leaderboard:like" {
+		t.Errorf("Expected tenant-prefixed leaderboard key, got %s", got)
+	}
+	if CounterKey(acme, "article_1", "like") == CounterKey(globex, "article_1", "like") {
+		t.Error("Expected different tenants to get different keys")
+	}
+}
+
+func TestHotRankKeyBuckets(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 3, 1, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period  string
+		wantKey string
+		wantTTL time.Duration
+	}{
+		{HotRankPeriodHour, "hotrank:like:hour:2024030115", 2 * time.Hour},
+		{HotRankPeriodDay, "hotrank:like:day:20240301", 48 * time.Hour},
+		{HotRankPeriodWeek, "hotrank:like:week:2024W09", 14 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		key, ttl, err := HotRankKey(ctx, "like", tt.period, at)
+		if err != nil {
+			t.Fatalf("HotRankKey(%s) failed: %v", tt.period, err)
+		}
+		if key != tt.wantKey || ttl != tt.wantTTL {
+			t.Errorf("HotRankKey(%s) = %s, %v; want %s, %v", tt.period, key, ttl, tt.wantKey, tt.wantTTL)
+		}
+	}
+
+	// 租户隔离
+	acme := middleware.WithTenantID(ctx, "acme")
+	if key, _, _ := HotRankKey(acme, "like", HotRankPeriodDay, at); key != "acme:hotrank:like:day:20240301" {
+		t.Errorf("Expected tenant-prefixed hot rank key, got %s", key)
+	}
+
+	if _, _, err := HotRankKey(ctx, "like", "month", at); !errors.Is(err, ErrInvalidHotRankPeriod) {
+		t.Errorf("Expected ErrInvalidHotRankPeriod, got %v", err)
+	}
+}
@@ -2,31 +2,217 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"high-go-press/internal/biz"
 	"high-go-press/pkg/config"
+	"math"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
+// ErrGetOrInitUnsupported 底层存储不支持获取或初始化
+var ErrGetOrInitUnsupported = errors.New("counter store does not support get or init")
+
+// ErrCounterOverflow 增量会使计数器超出int64范围
+var ErrCounterOverflow = errors.New("counter increment would overflow int64")
+
+// ErrCounterValueOutOfRange 存储的计数器值超出int64范围
+var ErrCounterValueOutOfRange = errors.New("stored counter value out of int64 range")
+
+// ErrCounterValueMalformed 存储的计数器值不是合法整数
+var ErrCounterValueMalformed = errors.New("stored counter value is not an integer")
+
+// counterOverflowReply incrementScript检测到溢出时返回的错误标识
+const counterOverflowReply = "COUNTER_OVERFLOW"
+
+// touchUpdatedAtLua 脚本公共部分：将写入时间ARGV记入KEYS[2]，过期时间与计数器KEYS[1]保持一致
+const touchUpdatedAtLua = `
+local function touch_updated_at(at)
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl > 0 then
+		redis.call('SET', KEYS[2], at, 'PX', ttl)
+	else
+		redis.call('SET', KEYS[2], at)
+	end
+end
+`
+
+// incrementScript 执行INCRBY并记录写入时间ARGV[2]，溢出时返回明确的错误标识而不是通用错误
+// INCRBY在结果超出int64时拒绝执行，计数器保持原值
+var incrementScript = redis.NewScript(touchUpdatedAtLua + `
+local result = redis.pcall('INCRBY', KEYS[1], ARGV[1])
+if type(result) == 'table' and result.err then
+	if string.find(result.err, 'overflow', 1, true) then
+		return redis.error_reply('` + counterOverflowReply + `')
+	end
+	return result
+end
+touch_updated_at(ARGV[2])
+return result
+`)
+
+// ErrDecrementUnsupported 底层存储不支持递减
+var ErrDecrementUnsupported = errors.New("counter store does not support decrement")
+
+// decrementScript 执行DECRBY，ARGV[2]为1时将低于0的结果截断为0，返回{当前值, 实际变化量}
+// 截断只针对从非负值减到负数的情况，已经为负的计数器保持不变，返回实际变化量0；
+// 按字符串判断符号避免Lua数字精度丢失。截断使用INCRBY而不是SET，保留key原有的过期时间；ARGV[3]为写入时间
+var decrementScript = redis.NewScript(touchUpdatedAtLua + `
+if ARGV[2] == '1' then
+	local current = redis.call('GET', KEYS[1])
+	if current and string.sub(current, 1, 1) == '-' then
+		return {current, 0}
+	end
+end
+local result = redis.pcall('DECRBY', KEYS[1], ARGV[1])
+if type(result) == 'table' and result.err then
+	if string.find(result.err, 'overflow', 1, true) then
+		return redis.error_reply('` + counterOverflowReply + `')
+	end
+	return result
+end
+if ARGV[2] == '1' and result < 0 then
+	local previous = result + tonumber(ARGV[1])
+	redis.call('INCRBY', KEYS[1], -result)
+	touch_updated_at(ARGV[3])
+	return {0, -previous}
+end
+touch_updated_at(ARGV[3])
+return {result, -tonumber(ARGV[1])}
+`)
+
+// ErrCompareAndSetUnsupported 底层存储不支持比较并设置
+var ErrCompareAndSetUnsupported = errors.New("counter store does not support compare-and-set")
+
+// compareAndSetScript 当前值等于ARGV[1]时设置为ARGV[2]，不存在的key按0比较，返回是否设置
+// 按字符串比较避免Lua数字精度丢失；KEEPTTL保留计数器原有的过期时间；设置成功时记录写入时间ARGV[3]
+var compareAndSetScript = redis.NewScript(touchUpdatedAtLua + `
+local current = redis.call('GET', KEYS[1])
+if current == false then
+	current = '0'
+end
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'KEEPTTL')
+touch_updated_at(ARGV[3])
+return 1
+`)
+
+// ErrScanUnsupported 底层存储不支持按前缀扫描
+var ErrScanUnsupported = errors.New("counter store does not support scanning")
+
+// ErrLeaderboardNotFound 排行榜ZSET不存在
+var ErrLeaderboardNotFound = errors.New("leaderboard not found")
+
+// ErrLeaderboardUnsupported 底层存储不支持写入排行榜
+var ErrLeaderboardUnsupported = errors.New("counter store does not support leaderboards")
+
+// scanBatchSize 每次SCAN建议返回的key数量
+const scanBatchSize = 100
+
+// getOrInitScript 不存在时设置初始值并记录写入时间ARGV[2]，返回{当前值, 是否创建}
+var getOrInitScript = redis.NewScript(touchUpdatedAtLua + `
+if redis.call('SETNX', KEYS[1], ARGV[1]) == 1 then
+	touch_updated_at(ARGV[2])
+	return {ARGV[1], 1}
+end
+return {redis.call('GET', KEYS[1]), 0}
+`)
+
+// incrementWithTTLScript 执行INCRBY，key由本次调用创建时设置过期时间（毫秒）
+// 已存在的key不刷新过期时间，避免每次访问都延长计数窗口；ARGV[3]为写入时间
+var incrementWithTTLScript = redis.NewScript(touchUpdatedAtLua + `
+local created = redis.call('EXISTS', KEYS[1]) == 0
+local result = redis.pcall('INCRBY', KEYS[1], ARGV[1])
+if type(result) == 'table' and result.err then
+	if string.find(result.err, 'overflow', 1, true) then
+		return redis.error_reply('` + counterOverflowReply + `')
+	end
+	return result
+end
+if created then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+touch_updated_at(ARGV[3])
+return result
+`)
+
 type RedisRepo struct {
-	client *redis.Client
+	client redis.UniversalClient
 	logger *zap.Logger
+	now    func() time.Time // 写入时间的时间源，nil时使用time.Now
+}
+
+// NewRedisClient 按部署模式创建Redis客户端
+// standalone使用redis.NewClient，cluster使用redis.NewClusterClient，sentinel使用redis.NewFailoverClient
+func NewRedisClient(cfg config.RedisConfig) (redis.UniversalClient, error) {
+	switch cfg.Mode {
+	case "", config.RedisModeStandalone:
+		return redis.NewClient(&redis.Options{
+			Addr:         cfg.Address,
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+			MaxRetries:   cfg.MaxRetries,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+		}), nil
+	case config.RedisModeCluster:
+		if len(cfg.Addresses) == 0 {
+			return nil, fmt.Errorf("redis cluster mode requires addresses")
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.Addresses,
+			Password:     cfg.Password,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+			MaxRetries:   cfg.MaxRetries,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+		}), nil
+	case config.RedisModeSentinel:
+		if cfg.MasterName == "" || len(cfg.Addresses) == 0 {
+			return nil, fmt.Errorf("redis sentinel mode requires master_name and addresses")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addresses,
+			SentinelPassword: cfg.SentinelPassword,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			PoolSize:         cfg.PoolSize,
+			MinIdleConns:     cfg.MinIdleConns,
+			MaxRetries:       cfg.MaxRetries,
+			DialTimeout:      cfg.DialTimeout,
+			ReadTimeout:      cfg.ReadTimeout,
+			WriteTimeout:     cfg.WriteTimeout,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported redis mode %q", cfg.Mode)
+	}
 }
 
 // NewRedisDAO 创建Redis DAO
 func NewRedisDAO(cfg config.RedisConfig, logger *zap.Logger) (*RedisRepo, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Address,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	rdb, err := NewRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// 测试连接
 	ctx := context.Background()
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
+	if _, err := rdb.Ping(ctx).Result(); err != nil {
+		rdb.Close()
 		return nil, err
 	}
 
@@ -36,14 +222,14 @@ func NewRedisDAO(cfg config.RedisConfig, logger *zap.Logger) (*RedisRepo, error)
 	}, nil
 }
 
-func NewRedisRepo(client *redis.Client) biz.CounterRepo {
+func NewRedisRepo(client redis.UniversalClient) biz.CounterRepo {
 	return &RedisRepo{
 		client: client,
 	}
 }
 
 // SetClient 设置Redis客户端（用于微服务模式）
-func (r *RedisRepo) SetClient(client *redis.Client) {
+func (r *RedisRepo) SetClient(client redis.UniversalClient) {
 	r.client = client
 }
 
@@ -52,14 +238,30 @@ func (r *RedisRepo) SetLogger(logger *zap.Logger) {
 	r.logger = logger
 }
 
+// SetTimeSource 设置记录计数器写入时间的时间源，测试中可固定时间
+func (r *RedisRepo) SetTimeSource(now func() time.Time) {
+	r.now = now
+}
+
+// writeTime 本次写入的时间，以unix毫秒记录在计数器的写入时间key中
+func (r *RedisRepo) writeTime() int64 {
+	if r.now == nil {
+		return time.Now().UnixMilli()
+	}
+	return r.now().UnixMilli()
+}
+
 // Close 关闭Redis连接
 func (r *RedisRepo) Close() error {
 	return r.client.Close()
 }
 
 func (r *RedisRepo) IncrementCounter(ctx context.Context, key string, increment int64) (int64, error) {
-	result, err := r.client.IncrBy(ctx, key, increment).Result()
+	result, err := incrementScript.Run(ctx, r.client, []string{key, UpdatedAtKey(key)}, increment, r.writeTime()).Int64()
 	if err != nil {
+		if strings.Contains(err.Error(), counterOverflowReply) {
+			return 0, fmt.Errorf("%w: key=%s increment=%d", ErrCounterOverflow, key, increment)
+		}
 		r.logger.Error("Failed to increment counter",
 			zap.String("key", key),
 			zap.Int64("increment", increment),
@@ -75,6 +277,88 @@ func (r *RedisRepo) IncrementCounter(ctx context.Context, key string, increment
 	return result, nil
 }
 
+// IncrementCounterWithTTL 增加计数器，key由本次调用创建时设置ttl过期
+// 后续增量不刷新过期时间，计数器在首次写入ttl后自动过期
+func (r *RedisRepo) IncrementCounterWithTTL(ctx context.Context, key string, increment int64, ttl time.Duration) (int64, error) {
+	if ttl <= 0 {
+		return r.IncrementCounter(ctx, key, increment)
+	}
+
+	result, err := incrementWithTTLScript.Run(ctx, r.client, []string{key, UpdatedAtKey(key)}, increment, ttl.Milliseconds(), r.writeTime()).Int64()
+	if err != nil {
+		if strings.Contains(err.Error(), counterOverflowReply) {
+			return 0, fmt.Errorf("%w: key=%s increment=%d", ErrCounterOverflow, key, increment)
+		}
+		r.logger.Error("Failed to increment counter with ttl",
+			zap.String("key", key),
+			zap.Int64("increment", increment),
+			zap.Duration("ttl", ttl),
+			zap.Error(err))
+		return 0, err
+	}
+
+	r.logger.Debug("Counter incremented successfully",
+		zap.String("key", key),
+		zap.Int64("increment", increment),
+		zap.Int64("result", result),
+		zap.Duration("ttl", ttl))
+
+	return result, nil
+}
+
+// DecrementCounter 使用DECRBY减少计数器，clampAtZero为true时结果低于0则原子地截断为0
+func (r *RedisRepo) DecrementCounter(ctx context.Context, key string, delta int64, clampAtZero bool) (int64, int64, error) {
+	clamp := 0
+	if clampAtZero {
+		clamp = 1
+	}
+
+	result, err := decrementScript.Run(ctx, r.client, []string{key, UpdatedAtKey(key)}, delta, clamp, r.writeTime()).Int64Slice()
+	if err != nil {
+		if strings.Contains(err.Error(), counterOverflowReply) {
+			return 0, 0, fmt.Errorf("%w: key=%s decrement=%d", ErrCounterOverflow, key, delta)
+		}
+		r.logger.Error("Failed to decrement counter",
+			zap.String("key", key),
+			zap.Int64("decrement", delta),
+			zap.Error(err))
+		return 0, 0, err
+	}
+	if len(result) != 2 {
+		return 0, 0, fmt.Errorf("unexpected decrement result: %v", result)
+	}
+
+	r.logger.Debug("Counter decremented successfully",
+		zap.String("key", key),
+		zap.Int64("decrement", delta),
+		zap.Int64("result", result[0]),
+		zap.Int64("applied", result[1]))
+
+	return result[0], result[1], nil
+}
+
+// CompareAndSetCounter 当前值等于expected时原子地设置为newValue，不存在的key按0比较
+func (r *RedisRepo) CompareAndSetCounter(ctx context.Context, key string, expected, newValue int64) (bool, error) {
+	swapped, err := compareAndSetScript.Run(ctx, r.client, []string{key, UpdatedAtKey(key)},
+		strconv.FormatInt(expected, 10), strconv.FormatInt(newValue, 10), r.writeTime()).Int()
+	if err != nil {
+		r.logger.Error("Failed to compare and set counter",
+			zap.String("key", key),
+			zap.Int64("expected", expected),
+			zap.Int64("new_value", newValue),
+			zap.Error(err))
+		return false, err
+	}
+
+	r.logger.Debug("Counter compare and set",
+		zap.String("key", key),
+		zap.Int64("expected", expected),
+		zap.Int64("new_value", newValue),
+		zap.Bool("swapped", swapped == 1))
+
+	return swapped == 1, nil
+}
+
 func (r *RedisRepo) GetCounter(ctx context.Context, key string) (int64, error) {
 	result, err := r.client.Get(ctx, key).Result()
 	if err != nil {
@@ -88,9 +372,9 @@ func (r *RedisRepo) GetCounter(ctx context.Context, key string) (int64, error) {
 		return 0, err
 	}
 
-	count, err := strconv.ParseInt(result, 10, 64)
+	count, err := parseCounterValue(key, result)
 	if err != nil {
-		r.logger.Error("Failed to parse counter value",
+		r.logger.Error("Corrupted counter value",
 			zap.String("key", key),
 			zap.String("value", result),
 			zap.Error(err))
@@ -100,56 +384,169 @@ func (r *RedisRepo) GetCounter(ctx context.Context, key string) (int64, error) {
 	return count, nil
 }
 
+// GetCounterUpdatedAt 批量读取计数器最后一次写入的时间，未记录写入时间的key不在结果中
+func (r *RedisRepo) GetCounterUpdatedAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	result := make(map[string]time.Time, len(keys))
+	if len(keys) == 1 {
+		raw, err := r.client.Get(ctx, UpdatedAtKey(keys[0])).Result()
+		if err == redis.Nil {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if at, ok := parseUpdatedAt(raw); ok {
+			result[keys[0]] = at
+		}
+		return result, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make(map[string]*redis.StringCmd, len(keys))
+	for _, key := range keys {
+		cmds[key] = pipe.Get(ctx, UpdatedAtKey(key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for key, cmd := range cmds {
+		if at, ok := parseUpdatedAt(cmd.Val()); ok {
+			result[key] = at
+		}
+	}
+	return result, nil
+}
+
+// parseUpdatedAt 解析以unix毫秒存储的写入时间
+func parseUpdatedAt(raw string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// parseCounterValue 解析存储的计数器值，区分超出int64范围和非整数两种损坏
+func parseCounterValue(key, raw string) (int64, error) {
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err == nil {
+		return value, nil
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("%w: key=%s", ErrCounterValueOutOfRange, key)
+	}
+	return 0, fmt.Errorf("%w: key=%s", ErrCounterValueMalformed, key)
+}
+
+// IsCorruptCounterValue 判断错误是否由存储的计数器值损坏导致
+func IsCorruptCounterValue(err error) bool {
+	return errors.Is(err, ErrCounterValueOutOfRange) || errors.Is(err, ErrCounterValueMalformed)
+}
+
 func (r *RedisRepo) GetMultiCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	values, errs, err := r.GetMultiCountersPartial(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	// 读取失败的key不出现在结果中，调用方按0处理
+	for key, keyErr := range errs {
+		r.logger.Error("Failed to get counter in batch",
+			zap.String("key", key),
+			zap.Error(keyErr))
+	}
+	return values, nil
+}
+
+// GetMultiCountersPartial 批量获取计数器，单个命令失败不影响其他key的结果
+// values包含读取成功的key（不存在的key为0），errs为读取失败的key及原因；
+// 所有key都失败时返回错误
+func (r *RedisRepo) GetMultiCountersPartial(ctx context.Context, keys []string) (map[string]int64, map[string]error, error) {
+	values := make(map[string]int64, len(keys))
+	errs := make(map[string]error)
 	if len(keys) == 0 {
-		return make(map[string]int64), nil
+		return values, errs, nil
+	}
+
+	if _, ok := r.client.(*redis.ClusterClient); ok {
+		// Cluster模式下按slot分组，每组单独执行Pipeline，避免跨slot的CROSSSLOT错误
+		r.getMultiCountersBySlot(ctx, keys, values, errs)
+	} else {
+		r.getMultiCountersPipeline(ctx, keys, values, errs)
+
+		// 通过代理访问集群时同样可能出现CROSSSLOT，失败的key按slot分组后重试
+		var crossSlot []string
+		for _, key := range keys {
+			if isCrossSlotError(errs[key]) {
+				crossSlot = append(crossSlot, key)
+				delete(errs, key)
+			}
+		}
+		if len(crossSlot) > 0 {
+			r.getMultiCountersBySlot(ctx, crossSlot, values, errs)
+		}
 	}
 
-	// 使用 Pipeline 批量获取
+	if len(errs) == len(keys) {
+		return values, errs, fmt.Errorf("all %d keys failed for multi get: %w", len(keys), errs[keys[0]])
+	}
+	return values, errs, nil
+}
+
+// getMultiCountersBySlot 按slot分组批量获取计数器
+func (r *RedisRepo) getMultiCountersBySlot(ctx context.Context, keys []string, values map[string]int64, errs map[string]error) {
+	for _, group := range groupKeysBySlot(keys) {
+		r.getMultiCountersPipeline(ctx, group, values, errs)
+	}
+}
+
+// getMultiCountersPipeline 使用一个Pipeline批量获取计数器
+// Pipeline整体报错时仍逐个检查命令结果，成功的写入values，失败或值损坏的写入errs
+func (r *RedisRepo) getMultiCountersPipeline(ctx context.Context, keys []string, values map[string]int64, errs map[string]error) {
 	pipe := r.client.Pipeline()
-	cmds := make(map[string]*redis.StringCmd)
+	cmds := make(map[string]*redis.StringCmd, len(keys))
 
 	for _, key := range keys {
 		cmds[key] = pipe.Get(ctx, key)
 	}
 
-	_, err := pipe.Exec(ctx)
-	if err != nil && err != redis.Nil {
-		r.logger.Error("Failed to execute pipeline for multi get", zap.Error(err))
-		return nil, err
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		r.logger.Warn("Pipeline for multi get reported errors, collecting partial results",
+			zap.Int("keys", len(keys)),
+			zap.Error(err))
 	}
 
-	result := make(map[string]int64)
 	for key, cmd := range cmds {
 		val, err := cmd.Result()
+		if err == redis.Nil {
+			values[key] = 0
+			continue
+		}
 		if err != nil {
-			if err == redis.Nil {
-				result[key] = 0
-			} else {
-				r.logger.Error("Failed to get counter in batch",
-					zap.String("key", key),
-					zap.Error(err))
-				continue
-			}
-		} else {
-			count, err := strconv.ParseInt(val, 10, 64)
-			if err != nil {
-				r.logger.Error("Failed to parse counter value in batch",
-					zap.String("key", key),
-					zap.String("value", val),
-					zap.Error(err))
-				result[key] = 0
-			} else {
-				result[key] = count
-			}
+			errs[key] = err
+			continue
 		}
-	}
 
-	return result, nil
+		count, err := parseCounterValue(key, val)
+		if err != nil {
+			r.logger.Error("Corrupted counter value in batch",
+				zap.String("key", key),
+				zap.String("value", val),
+				zap.Error(err))
+			errs[key] = err
+			continue
+		}
+		values[key] = count
+	}
 }
 
+// SetCounter 设置计数器值并记录写入时间，两个key位于同一slot，在一个事务中写入
 func (r *RedisRepo) SetCounter(ctx context.Context, key string, value int64) error {
-	err := r.client.Set(ctx, key, value, 0).Err()
+	at := r.writeTime()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, 0)
+		pipe.Set(ctx, UpdatedAtKey(key), at, 0)
+		return nil
+	})
 	if err != nil {
 		r.logger.Error("Failed to set counter",
 			zap.String("key", key),
@@ -164,3 +561,242 @@ func (r *RedisRepo) SetCounter(ctx context.Context, key string, value int64) err
 
 	return nil
 }
+
+// GetOrInitCounter 获取计数器值，不存在时原子地初始化为initial
+func (r *RedisRepo) GetOrInitCounter(ctx context.Context, key string, initial int64) (int64, bool, error) {
+	result, err := getOrInitScript.Run(ctx, r.client, []string{key, UpdatedAtKey(key)}, initial, r.writeTime()).Slice()
+	if err != nil {
+		r.logger.Error("Failed to get or init counter",
+			zap.String("key", key),
+			zap.Int64("initial", initial),
+			zap.Error(err))
+		return 0, false, err
+	}
+	if len(result) != 2 {
+		return 0, false, fmt.Errorf("unexpected get or init result: %v", result)
+	}
+
+	raw, _ := result[0].(string)
+	value, err := parseCounterValue(key, raw)
+	if err != nil {
+		r.logger.Error("Corrupted counter value",
+			zap.String("key", key),
+			zap.String("value", raw),
+			zap.Error(err))
+		return 0, false, err
+	}
+
+	created, _ := result[1].(int64)
+	return value, created == 1, nil
+}
+
+// ScanCounters 使用SCAN获取key以prefix开头的计数器，最多返回limit个
+// SCAN不阻塞Redis，但扫描期间新增或删除的key可能被遗漏
+func (r *RedisRepo) ScanCounters(ctx context.Context, prefix string, limit int) (map[string]int64, error) {
+	if limit <= 0 {
+		return make(map[string]int64), nil
+	}
+
+	pattern := escapeGlob(prefix) + "*"
+	var keys []string
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		// Cluster模式下SCAN只覆盖单个节点，需要在每个主节点上分别扫描后合并
+		var mu sync.Mutex
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			nodeKeys, err := scanKeys(ctx, node, pattern, limit)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, key := range nodeKeys {
+				if len(keys) >= limit {
+					break
+				}
+				keys = append(keys, key)
+			}
+			return nil
+		})
+		if err != nil {
+			r.logger.Error("Failed to scan counters",
+				zap.String("prefix", prefix),
+				zap.Error(err))
+			return nil, err
+		}
+	} else {
+		var err error
+		keys, err = scanKeys(ctx, r.client, pattern, limit)
+		if err != nil {
+			r.logger.Error("Failed to scan counters",
+				zap.String("prefix", prefix),
+				zap.Error(err))
+			return nil, err
+		}
+	}
+
+	return r.GetMultiCounters(ctx, keys)
+}
+
+// scanKeys 在单个节点上SCAN匹配pattern的key，最多返回limit个，已去重
+func scanKeys(ctx context.Context, client redis.Cmdable, pattern string, limit int) ([]string, error) {
+	seen := make(map[string]struct{})
+	keys := make([]string, 0, limit)
+
+	var cursor uint64
+	for {
+		batch, next, err := client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range batch {
+			// SCAN可能重复返回同一个key
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+			if len(keys) >= limit {
+				break
+			}
+		}
+
+		cursor = next
+		if cursor == 0 || len(keys) >= limit {
+			return keys, nil
+		}
+	}
+}
+
+// RangeLeaderboardAbove 使用ZREVRANGEBYSCORE返回分数大于threshold的成员，最多limit个
+func (r *RedisRepo) RangeLeaderboardAbove(ctx context.Context, key string, threshold int64, limit int) ([]biz.LeaderboardEntry, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	members, err := r.client.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:   "(" + strconv.FormatInt(threshold, 10),
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		r.logger.Error("Failed to range leaderboard",
+			zap.String("key", key),
+			zap.Int64("threshold", threshold),
+			zap.Error(err))
+		return nil, err
+	}
+
+	// 结果为空时区分"无超过阈值的成员"和"排行榜不存在"
+	if len(members) == 0 {
+		exists, err := r.client.Exists(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrLeaderboardNotFound, key)
+		}
+	}
+
+	entries := make([]biz.LeaderboardEntry, 0, len(members))
+	for _, m := range members {
+		member, _ := m.Member.(string)
+		entries = append(entries, biz.LeaderboardEntry{Member: member, Score: int64(m.Score)})
+	}
+	return entries, nil
+}
+
+// SetLeaderboardScore 使用ZADD GT设置成员分数，只在score大于当前分数时更新
+// 并发写入时较旧的计数值不会覆盖较新的值，需要Redis 6.2+
+func (r *RedisRepo) SetLeaderboardScore(ctx context.Context, key, member string, score int64) error {
+	err := r.client.ZAddArgs(ctx, key, redis.ZAddArgs{
+		GT:      true,
+		Members: []redis.Z{{Score: float64(score), Member: member}},
+	}).Err()
+	if err != nil {
+		r.logger.Error("Failed to set leaderboard score",
+			zap.String("key", key),
+			zap.String("member", member),
+			zap.Int64("score", score),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// IncrementLeaderboard 使用ZINCRBY为成员增加分数，ttl大于0时刷新过期时间
+func (r *RedisRepo) IncrementLeaderboard(ctx context.Context, key, member string, increment int64, ttl time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.ZIncrBy(ctx, key, float64(increment), member)
+	if ttl > 0 {
+		pipe.Expire(ctx, key, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("Failed to increment leaderboard",
+			zap.String("key", key),
+			zap.String("member", member),
+			zap.Int64("increment", increment),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// AddToHotRank 使用ZINCRBY将资源在当前时间桶内的热度增加value，并设置桶的过期时间
+func (r *RedisRepo) AddToHotRank(ctx context.Context, counterType, period, resourceID string, value int64) error {
+	key, ttl, err := HotRankKey(ctx, counterType, period, time.Now())
+	if err != nil {
+		return err
+	}
+	return r.IncrementLeaderboard(ctx, key, resourceID, value, ttl)
+}
+
+// GetHotRank 使用ZREVRANGE WITHSCORES返回当前时间桶内热度最高的limit个资源，桶不存在时返回空列表
+func (r *RedisRepo) GetHotRank(ctx context.Context, counterType, period string, limit int) ([]*biz.HotRankItem, error) {
+	key, _, err := HotRankKey(ctx, counterType, period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return []*biz.HotRankItem{}, nil
+	}
+
+	members, err := r.client.ZRevRangeWithScores(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		r.logger.Error("Failed to get hot rank",
+			zap.String("key", key),
+			zap.Int("limit", limit),
+			zap.Error(err))
+		return nil, err
+	}
+
+	items := make([]*biz.HotRankItem, 0, len(members))
+	for i, m := range members {
+		member, _ := m.Member.(string)
+		items = append(items, &biz.HotRankItem{
+			ResourceID:  member,
+			CounterType: biz.CounterType(counterType),
+			Count:       int64(m.Score),
+			Rank:        i + 1,
+		})
+	}
+	return items, nil
+}
+
+// AddOverflows 判断a+b是否超出int64范围，供不经过Redis的存储实现复用
+func AddOverflows(a, b int64) bool {
+	return (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b)
+}
+
+// escapeGlob 转义Redis MATCH模式中的特殊字符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
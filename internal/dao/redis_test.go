@@ -0,0 +1,430 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func TestAddOverflows(t *testing.T) {
+	tests := []struct {
+		a, b int64
+		want bool
+	}{
+		{0, math.MaxInt64, false},
+		{1, math.MaxInt64, true},
+		{math.MaxInt64 - 5, 5, false},
+		{math.MaxInt64 - 5, 6, true},
+		{math.MinInt64 + 5, -5, false},
+		{math.MinInt64 + 5, -6, true},
+		{-1, math.MinInt64, true},
+		{math.MaxInt64, math.MinInt64, false},
+	}
+
+	for _, tt := range tests {
+		if got := AddOverflows(tt.a, tt.b); got != tt.want {
+			t.Errorf("AddOverflows(%d, %d) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseCounterValue(t *testing.T) {
+	if v, err := parseCounterValue("counter:a:like", "-42"); err != nil || v != -42 {
+		t.Fatalf("Expected -42, got %d, %v", v, err)
+	}
+
+	// 超出int64范围
+	_, err := parseCounterValue("counter:a:like", "99999999999999999999")
+	if !errors.Is(err, ErrCounterValueOutOfRange) {
+		t.Fatalf("Expected ErrCounterValueOutOfRange, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "counter:a:like") || !IsCorruptCounterValue(err) {
+		t.Errorf("Expected corrupt value error with key, got %v", err)
+	}
+
+	// 非整数
+	_, err = parseCounterValue("counter:b:like", "12abc")
+	if !errors.Is(err, ErrCounterValueMalformed) || errors.Is(err, ErrCounterValueOutOfRange) {
+		t.Errorf("Expected ErrCounterValueMalformed, got %v", err)
+	}
+}
+
+func TestIncrementCounterWithTTL(t *testing.T) {
+	repo, mr := newMiniredisRepo(t)
+	ctx := context.Background()
+	key := "counter:view:story_1"
+
+	// 首次增量创建key并设置过期时间，写入时间key的过期时间与计数器一致
+	value, err := repo.IncrementCounterWithTTL(ctx, key, 1, time.Hour)
+	if err != nil || value != 1 {
+		t.Fatalf("Expected value 1, got %d, %v", value, err)
+	}
+	if ttl := mr.TTL(key); ttl != time.Hour {
+		t.Fatalf("Expected ttl 1h, got %v", ttl)
+	}
+	if ttl := mr.TTL(UpdatedAtKey(key)); ttl != time.Hour {
+		t.Errorf("Expected updated_at ttl 1h, got %v", ttl)
+	}
+
+	// 后续增量不刷新过期时间
+	mr.FastForward(30 * time.Minute)
+	value, err = repo.IncrementCounterWithTTL(ctx, key, 2, time.Hour)
+	if err != nil || value != 3 {
+		t.Fatalf("Expected value 3, got %d, %v", value, err)
+	}
+	if ttl := mr.TTL(key); ttl != 30*time.Minute {
+		t.Errorf("Expected ttl to stay at 30m, got %v", ttl)
+	}
+	if ttl := mr.TTL(UpdatedAtKey(key)); ttl != 30*time.Minute {
+		t.Errorf("Expected updated_at ttl to follow the counter, got %v", ttl)
+	}
+
+	// 过期后计数器和写入时间一起消失，重新计数并设置新的过期时间
+	mr.FastForward(30 * time.Minute)
+	if mr.Exists(key) || mr.Exists(UpdatedAtKey(key)) {
+		t.Fatal("Expected counter and updated_at to expire together")
+	}
+	value, err = repo.IncrementCounterWithTTL(ctx, key, 1, time.Hour)
+	if err != nil || value != 1 {
+		t.Fatalf("Expected counter to restart at 1 after expiry, got %d, %v", value, err)
+	}
+	if ttl := mr.TTL(key); ttl != time.Hour {
+		t.Errorf("Expected new ttl 1h, got %v", ttl)
+	}
+}
+
+func TestIncrementCounterWithoutTTLNeverExpires(t *testing.T) {
+	repo, mr := newMiniredisRepo(t)
+	key := "counter:like:a"
+
+	if _, err := repo.IncrementCounterWithTTL(context.Background(), key, 1, 0); err != nil {
+		t.Fatalf("IncrementCounterWithTTL failed: %v", err)
+	}
+	if ttl := mr.TTL(key); ttl != 0 {
+		t.Errorf("Expected no expiry when ttl is 0, got %v", ttl)
+	}
+	if ttl := mr.TTL(UpdatedAtKey(key)); ttl != 0 {
+		t.Errorf("Expected no updated_at expiry when ttl is 0, got %v", ttl)
+	}
+}
+
+func TestCounterUpdatedAtReflectsWriteTime(t *testing.T) {
+	repo, _ := newMiniredisRepo(t)
+	ctx := context.Background()
+	key := "counter:article_1:like"
+
+	// 未写入过的计数器没有写入时间
+	times, err := repo.GetCounterUpdatedAt(ctx, []string{key})
+	if err != nil || len(times) != 0 {
+		t.Fatalf("Expected no update time before any write, got %v, %v", times, err)
+	}
+
+	writtenAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.SetTimeSource(func() time.Time { return writtenAt })
+	if _, err := repo.IncrementCounter(ctx, key, 1); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+
+	// 读取发生在一小时后，返回的仍是写入时间
+	repo.SetTimeSource(func() time.Time { return writtenAt.Add(time.Hour) })
+	times, err = repo.GetCounterUpdatedAt(ctx, []string{key})
+	if err != nil {
+		t.Fatalf("GetCounterUpdatedAt failed: %v", err)
+	}
+	if !times[key].Equal(writtenAt) {
+		t.Errorf("Expected update time %v, got %v", writtenAt, times[key])
+	}
+}
+
+func TestCounterWritesTouchUpdatedAt(t *testing.T) {
+	repo, mr := newMiniredisRepo(t)
+	ctx := context.Background()
+	key := "counter:article_1:like"
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.SetTimeSource(func() time.Time { return at })
+	assertUpdatedAt := func(step string, want time.Time) {
+		t.Helper()
+		times, err := repo.GetCounterUpdatedAt(ctx, []string{key, "counter:other:like"})
+		if err != nil {
+			t.Fatalf("%s: GetCounterUpdatedAt failed: %v", step, err)
+		}
+		if len(times) != 1 || !times[key].Equal(want) {
+			t.Errorf("%s: expected update time %v, got %v", step, want, times)
+		}
+	}
+
+	if _, _, err := repo.GetOrInitCounter(ctx, key, 5); err != nil {
+		t.Fatalf("GetOrInitCounter failed: %v", err)
+	}
+	assertUpdatedAt("get or init", at)
+
+	// 计数器带过期时间时，后续写入的写入时间key沿用计数器的剩余过期时间
+	mr.SetTTL(key, time.Hour)
+
+	at = at.Add(time.Minute)
+	if _, _, err := repo.DecrementCounter(ctx, key, 2, true); err != nil {
+		t.Fatalf("DecrementCounter failed: %v", err)
+	}
+	assertUpdatedAt("decrement", at)
+	if ttl := mr.TTL(UpdatedAtKey(key)); ttl != time.Hour {
+		t.Errorf("Expected updated_at ttl 1h after decrement, got %v", ttl)
+	}
+
+	// 比较失败不算写入，不更新写入时间
+	at = at.Add(time.Minute)
+	if swapped, err := repo.CompareAndSetCounter(ctx, key, 100, 7); err != nil || swapped {
+		t.Fatalf("Expected compare-and-set to fail on mismatch, got %v, %v", swapped, err)
+	}
+	assertUpdatedAt("failed compare-and-set", at.Add(-time.Minute))
+
+	if swapped, err := repo.CompareAndSetCounter(ctx, key, 3, 7); err != nil || !swapped {
+		t.Fatalf("Expected compare-and-set to succeed, got %v, %v", swapped, err)
+	}
+	assertUpdatedAt("compare-and-set", at)
+	if ttl := mr.TTL(key); ttl != time.Hour {
+		t.Errorf("Expected compare-and-set to keep counter ttl, got %v", ttl)
+	}
+}
+
+func TestUpdatedAtKeySharesClusterSlot(t *testing.T) {
+	for _, key := range []string{"counter:article_1:like", "tenant-a:counter:article_1:like", "{user_1}:counter:article_1:like"} {
+		metaKey := UpdatedAtKey(key)
+		if clusterSlot(metaKey) != clusterSlot(key) {
+			t.Errorf("Expected %s to share a slot with %s", metaKey, key)
+		}
+		if strings.HasPrefix(metaKey, "counter:") || strings.HasPrefix(metaKey, "tenant-a:counter:") {
+			t.Errorf("Expected %s not to match counter scan prefixes", metaKey)
+		}
+	}
+}
+
+// fakeHotRankRedis 模拟ZREVRANGE WITHSCORES，记录查询的key和范围
+type fakeHotRankRedis struct {
+	redis.UniversalClient
+	members     []redis.Z
+	key         string
+	start, stop int64
+}
+
+func (f *fakeHotRankRedis) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	f.key, f.start, f.stop = key, start, stop
+	cmd := redis.NewZSliceCmd(ctx)
+	cmd.SetVal(f.members)
+	return cmd
+}
+
+func TestGetHotRankReturnsRankedItems(t *testing.T) {
+	client := &fakeHotRankRedis{members: []redis.Z{
+		{Member: "article_2", Score: 9},
+		{Member: "article_1", Score: 4},
+	}}
+	repo := &RedisRepo{client: client, logger: zap.NewNop()}
+	ctx := context.Background()
+
+	items, err := repo.GetHotRank(ctx, "like", HotRankPeriodDay, 2)
+	if err != nil {
+		t.Fatalf("GetHotRank failed: %v", err)
+	}
+
+	wantKey, _, _ := HotRankKey(ctx, "like", HotRankPeriodDay, time.Now())
+	if client.key != wantKey || client.start != 0 || client.stop != 1 {
+		t.Errorf("Unexpected ZREVRANGE %s %d %d, want %s 0 1", client.key, client.start, client.stop, wantKey)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].ResourceID != "article_2" || items[0].Count != 9 || items[0].Rank != 1 || items[0].CounterType != "like" {
+		t.Errorf("Unexpected first item: %+v", items[0])
+	}
+	if items[1].ResourceID != "article_1" || items[1].Count != 4 || items[1].Rank != 2 {
+		t.Errorf("Unexpected second item: %+v", items[1])
+	}
+
+	if _, err := repo.GetHotRank(ctx, "like", "month", 10); !errors.Is(err, ErrInvalidHotRankPeriod) {
+		t.Errorf("Expected ErrInvalidHotRankPeriod, got %v", err)
+	}
+}
+
+// fakePipelineRedis 返回fakePipeline的客户端，values中不存在的key视为不存在
+type fakePipelineRedis struct {
+	redis.UniversalClient
+	values  map[string]string
+	failing map[string]error
+}
+
+func (f *fakePipelineRedis) Pipeline() redis.Pipeliner {
+	return &fakePipeline{redis: f}
+}
+
+// fakePipeline 只支持GET，Exec时按key设置每个命令的结果，并像go-redis一样返回第一个错误
+type fakePipeline struct {
+	redis.Pipeliner
+	redis *fakePipelineRedis
+	cmds  []*redis.StringCmd
+}
+
+func (p *fakePipeline) Get(ctx context.Context, key string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx, "get", key)
+	p.cmds = append(p.cmds, cmd)
+	return cmd
+}
+
+func (p *fakePipeline) Exec(ctx context.Context) ([]redis.Cmder, error) {
+	var firstErr error
+	cmders := make([]redis.Cmder, 0, len(p.cmds))
+	for _, cmd := range p.cmds {
+		key := cmd.Args()[1].(string)
+		switch {
+		case p.redis.failing[key] != nil:
+			cmd.SetErr(p.redis.failing[key])
+		case p.redis.values[key] != "":
+			cmd.SetVal(p.redis.values[key])
+		default:
+			cmd.SetErr(redis.Nil)
+		}
+		if err := cmd.Err(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		cmders = append(cmders, cmd)
+	}
+	return cmders, firstErr
+}
+
+func TestGetMultiCountersPartialFailure(t *testing.T) {
+	timeout := errors.New("i/o timeout")
+	client := &fakePipelineRedis{
+		values: map[string]string{
+			"counter:a:like": "1",
+			"counter:b:like": "2",
+			"counter:c:like": "3",
+			"counter:e:like": "bad",
+		},
+		// 第一个key失败，Exec整体返回该错误
+		failing: map[string]error{"counter:x:like": timeout},
+	}
+	repo := &RedisRepo{client: client, logger: zap.NewNop()}
+	keys := []string{"counter:x:like", "counter:a:like", "counter:b:like", "counter:c:like", "counter:d:like", "counter:e:like"}
+
+	values, errs, err := repo.GetMultiCountersPartial(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("Expected partial success, got %v", err)
+	}
+
+	want := map[string]int64{"counter:a:like": 1, "counter:b:like": 2, "counter:c:like": 3, "counter:d:like": 0}
+	if len(values) != len(want) {
+		t.Fatalf("Expected %d values, got %v", len(want), values)
+	}
+	for key, v := range want {
+		if got, ok := values[key]; !ok || got != v {
+			t.Errorf("Expected %s=%d, got %d (present=%v)", key, v, got, ok)
+		}
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 failed keys, got %v", errs)
+	}
+	if !errors.Is(errs["counter:x:like"], timeout) {
+		t.Errorf("Expected timeout for counter:x:like, got %v", errs["counter:x:like"])
+	}
+	if !IsCorruptCounterValue(errs["counter:e:like"]) {
+		t.Errorf("Expected corrupt value error for counter:e:like, got %v", errs["counter:e:like"])
+	}
+
+	// GetMultiCounters保留成功的结果，失败的key不出现在结果中
+	counts, err := repo.GetMultiCounters(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("Expected GetMultiCounters to succeed, got %v", err)
+	}
+	if counts["counter:c:like"] != 3 {
+		t.Errorf("Expected counter:c:like=3, got %d", counts["counter:c:like"])
+	}
+	if _, ok := counts["counter:x:like"]; ok {
+		t.Error("Expected failed key to be absent from GetMultiCounters result")
+	}
+}
+
+func TestGetMultiCountersAllFailed(t *testing.T) {
+	timeout := errors.New("i/o timeout")
+	client := &fakePipelineRedis{
+		failing: map[string]error{"counter:a:like": timeout, "counter:b:like": timeout},
+	}
+	repo := &RedisRepo{client: client, logger: zap.NewNop()}
+
+	_, errs, err := repo.GetMultiCountersPartial(context.Background(), []string{"counter:a:like", "counter:b:like"})
+	if !errors.Is(err, timeout) {
+		t.Fatalf("Expected error when all keys fail, got %v", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 failed keys, got %v", errs)
+	}
+
+	if _, err := repo.GetMultiCounters(context.Background(), []string{"counter:a:like"}); err == nil {
+		t.Error("Expected GetMultiCounters to fail when all keys fail")
+	}
+}
+
+// newMiniredisRepo 基于miniredis的RedisRepo，Lua脚本和Redis命令在真实的实现上执行
+func newMiniredisRepo(t *testing.T) (*RedisRepo, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return &RedisRepo{client: client, logger: zap.NewNop()}, mr
+}
+
+func TestSetLeaderboardScoreOnlyRaises(t *testing.T) {
+	repo, mr := newMiniredisRepo(t)
+	ctx := context.Background()
+	key := "leaderboard:like"
+
+	if err := repo.SetLeaderboardScore(ctx, key, "article_1", 5); err != nil {
+		t.Fatalf("SetLeaderboardScore failed: %v", err)
+	}
+	// 较旧的计数值不覆盖较新的分数
+	if err := repo.SetLeaderboardScore(ctx, key, "article_1", 3); err != nil {
+		t.Fatalf("SetLeaderboardScore failed: %v", err)
+	}
+	if score, err := mr.ZScore(key, "article_1"); err != nil || score != 5 {
+		t.Errorf("Expected score to stay 5, got %v, %v", score, err)
+	}
+
+	if err := repo.SetLeaderboardScore(ctx, key, "article_1", 8); err != nil {
+		t.Fatalf("SetLeaderboardScore failed: %v", err)
+	}
+	if score, err := mr.ZScore(key, "article_1"); err != nil || score != 8 {
+		t.Errorf("Expected score 8, got %v, %v", score, err)
+	}
+}
+
+func TestDecrementClampLeavesNegativeCounters(t *testing.T) {
+	repo, mr := newMiniredisRepo(t)
+	ctx := context.Background()
+	key := "counter:article_1:like"
+
+	// 从非负值减到负数时截断为0
+	mr.Set(key, "3")
+	if value, applied, err := repo.DecrementCounter(ctx, key, 5, true); err != nil || value != 0 || applied != -3 {
+		t.Errorf("Expected clamp to 0 with applied -3, got %d, %d, %v", value, applied, err)
+	}
+
+	// 已经为负的计数器截断时保持不变
+	mr.Set(key, "-4")
+	if value, applied, err := repo.DecrementCounter(ctx, key, 2, true); err != nil || value != -4 || applied != 0 {
+		t.Errorf("Expected negative counter unchanged, got %d, %d, %v", value, applied, err)
+	}
+	if got, _ := mr.Get(key); got != "-4" {
+		t.Errorf("Expected stored value -4, got %s", got)
+	}
+
+	// 不截断时照常递减
+	if value, applied, err := repo.DecrementCounter(ctx, key, 2, false); err != nil || value != -6 || applied != -2 {
+		t.Errorf("Expected -6 without clamping, got %d, %d, %v", value, applied, err)
+	}
+}
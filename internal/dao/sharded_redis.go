@@ -0,0 +1,549 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"high-go-press/internal/biz"
+	"high-go-press/pkg/config"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoShards         = errors.New("no redis shards configured")
+	ErrShardUnavailable = errors.New("redis shard unavailable")
+	ErrDuplicateShard   = errors.New("duplicate redis shard name")
+)
+
+// ShardNode 分片节点
+type ShardNode struct {
+	Name   string          // 节点名称，参与哈希计算，需保持稳定
+	Weight int             // 节点权重，权重越大分配的key越多
+	Repo   biz.CounterRepo // 节点对应的存储
+}
+
+// ShardedRedisConfig 分片存储配置
+type ShardedRedisConfig struct {
+	VirtualNodes int           // 每单位权重的虚拟节点数
+	DownCooldown time.Duration // 节点出错后标记为不可用的时长
+}
+
+// DefaultShardedRedisConfig 默认分片存储配置
+func DefaultShardedRedisConfig() *ShardedRedisConfig {
+	return &ShardedRedisConfig{
+		VirtualNodes: 100,
+		DownCooldown: 5 * time.Second,
+	}
+}
+
+// shard 分片运行时状态
+type shard struct {
+	node      ShardNode
+	downUntil time.Time
+}
+
+// ShardedRedisRepo 基于一致性哈希的分片计数存储，将key分布到多个独立Redis实例
+type ShardedRedisRepo struct {
+	config *ShardedRedisConfig
+	logger *zap.Logger
+
+	shards map[string]*shard
+	ring   []uint32          // 已排序的虚拟节点哈希
+	owners map[uint32]string // 虚拟节点哈希 -> 节点名称
+	mu     sync.RWMutex
+}
+
+// NewShardedRedisRepo 创建分片计数存储
+func NewShardedRedisRepo(nodes []ShardNode, cfg *ShardedRedisConfig, logger *zap.Logger) (*ShardedRedisRepo, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoShards
+	}
+
+	// 在副本上补全默认值，不修改调用方传入的配置
+	defaults := DefaultShardedRedisConfig()
+	if cfg == nil {
+		cfg = defaults
+	} else {
+		copied := *cfg
+		cfg = &copied
+	}
+	if cfg.VirtualNodes <= 0 {
+		cfg.VirtualNodes = defaults.VirtualNodes
+	}
+
+	r := &ShardedRedisRepo{
+		config: cfg,
+		logger: logger,
+		shards: make(map[string]*shard, len(nodes)),
+		owners: make(map[uint32]string),
+	}
+
+	for _, node := range nodes {
+		if _, exists := r.shards[node.Name]; exists {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateShard, node.Name)
+		}
+		if node.Weight <= 0 {
+			node.Weight = 1
+		}
+		r.shards[node.Name] = &shard{node: node}
+
+		// 按权重生成虚拟节点
+		for i := 0; i < node.Weight*cfg.VirtualNodes; i++ {
+			hash := crc32.ChecksumIEEE([]byte(node.Name + "#" + strconv.Itoa(i)))
+			if _, exists := r.owners[hash]; exists {
+				continue // 哈希冲突时保留先加入的节点
+			}
+			r.owners[hash] = node.Name
+			r.ring = append(r.ring, hash)
+		}
+	}
+
+	sort.Slice(r.ring, func(i, j int) bool { return r.ring[i] < r.ring[j] })
+
+	return r, nil
+}
+
+// NewShardedRedisDAO 根据Redis分片配置创建分片计数存储
+func NewShardedRedisDAO(cfg config.RedisConfig, logger *zap.Logger) (*ShardedRedisRepo, error) {
+	nodes := make([]ShardNode, 0, len(cfg.Shards))
+	for _, shardCfg := range cfg.Shards {
+		// 每个分片是独立的单机实例，连接池参数沿用redis配置
+		client, err := NewRedisClient(config.RedisConfig{
+			Address:      shardCfg.Address,
+			Password:     shardCfg.Password,
+			DB:           shardCfg.DB,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+			MaxRetries:   cfg.MaxRetries,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		name := shardCfg.Name
+		if name == "" {
+			name = shardCfg.Address
+		}
+
+		nodes = append(nodes, ShardNode{
+			Name:   name,
+			Weight: shardCfg.Weight,
+			Repo:   &RedisRepo{client: client, logger: logger},
+		})
+	}
+
+	return NewShardedRedisRepo(nodes, DefaultShardedRedisConfig(), logger)
+}
+
+// ShardFor 获取key所在的分片名称
+func (r *ShardedRedisRepo) ShardFor(key string) string {
+	hash := crc32.ChecksumIEEE([]byte(key))
+	idx := sort.Search(len(r.ring), func(i int) bool { return r.ring[i] >= hash })
+	if idx == len(r.ring) {
+		idx = 0 // 环形回绕
+	}
+	return r.owners[r.ring[idx]]
+}
+
+// IncrementCounter 增加计数器
+func (r *ShardedRedisRepo) IncrementCounter(ctx context.Context, key string, increment int64) (int64, error) {
+	s, err := r.acquire(key)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := s.node.Repo.IncrementCounter(ctx, key, increment)
+	r.observe(s, err)
+	return value, err
+}
+
+// IncrementCounterWithTTL 在key所属分片上增加计数器，新建的key设置过期时间
+func (r *ShardedRedisRepo) IncrementCounterWithTTL(ctx context.Context, key string, increment int64, ttl time.Duration) (int64, error) {
+	s, err := r.acquire(key)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := incrementWithTTL(ctx, s.node.Repo, key, increment, ttl)
+	r.observe(s, err)
+	return value, err
+}
+
+// DecrementCounter 在key所属分片上减少计数器
+func (r *ShardedRedisRepo) DecrementCounter(ctx context.Context, key string, delta int64, clampAtZero bool) (int64, int64, error) {
+	s, err := r.acquire(key)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	decrementer, ok := s.node.Repo.(biz.CounterDecrementer)
+	if !ok {
+		return 0, 0, ErrDecrementUnsupported
+	}
+
+	value, applied, err := decrementer.DecrementCounter(ctx, key, delta, clampAtZero)
+	r.observe(s, err)
+	return value, applied, err
+}
+
+// CompareAndSetCounter 在key所属分片上比较并设置计数器
+func (r *ShardedRedisRepo) CompareAndSetCounter(ctx context.Context, key string, expected, newValue int64) (bool, error) {
+	s, err := r.acquire(key)
+	if err != nil {
+		return false, err
+	}
+
+	setter, ok := s.node.Repo.(biz.CounterCompareAndSetter)
+	if !ok {
+		return false, ErrCompareAndSetUnsupported
+	}
+
+	swapped, err := setter.CompareAndSetCounter(ctx, key, expected, newValue)
+	r.observe(s, err)
+	return swapped, err
+}
+
+// GetCounter 获取计数器值
+func (r *ShardedRedisRepo) GetCounter(ctx context.Context, key string) (int64, error) {
+	s, err := r.acquire(key)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := s.node.Repo.GetCounter(ctx, key)
+	r.observe(s, err)
+	return value, err
+}
+
+// SetCounter 设置计数器值
+func (r *ShardedRedisRepo) SetCounter(ctx context.Context, key string, value int64) error {
+	s, err := r.acquire(key)
+	if err != nil {
+		return err
+	}
+
+	err = s.node.Repo.SetCounter(ctx, key, value)
+	r.observe(s, err)
+	return err
+}
+
+// GetOrInitCounter 在key所属分片上获取或初始化计数器
+func (r *ShardedRedisRepo) GetOrInitCounter(ctx context.Context, key string, initial int64) (int64, bool, error) {
+	s, err := r.acquire(key)
+	if err != nil {
+		return 0, false, err
+	}
+
+	initializer, ok := s.node.Repo.(biz.CounterInitializer)
+	if !ok {
+		return 0, false, ErrGetOrInitUnsupported
+	}
+
+	value, created, err := initializer.GetOrInitCounter(ctx, key, initial)
+	r.observe(s, err)
+	return value, created, err
+}
+
+// RangeLeaderboardAbove 在排行榜key所属分片上读取超过阈值的成员
+func (r *ShardedRedisRepo) RangeLeaderboardAbove(ctx context.Context, key string, threshold int64, limit int) ([]biz.LeaderboardEntry, error) {
+	s, err := r.acquire(key)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, ok := s.node.Repo.(biz.LeaderboardReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLeaderboardNotFound, key)
+	}
+
+	entries, err := reader.RangeLeaderboardAbove(ctx, key, threshold, limit)
+	if errors.Is(err, ErrLeaderboardNotFound) {
+		return nil, err
+	}
+	r.observe(s, err)
+	return entries, err
+}
+
+// SetLeaderboardScore 在排行榜key所属分片上设置成员分数
+func (r *ShardedRedisRepo) SetLeaderboardScore(ctx context.Context, key, member string, score int64) error {
+	s, err := r.acquire(key)
+	if err != nil {
+		return err
+	}
+
+	writer, ok := s.node.Repo.(biz.LeaderboardWriter)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaderboardUnsupported, key)
+	}
+
+	err = writer.SetLeaderboardScore(ctx, key, member, score)
+	r.observe(s, err)
+	return err
+}
+
+// IncrementLeaderboard 在排行榜key所属分片上增加成员分数
+func (r *ShardedRedisRepo) IncrementLeaderboard(ctx context.Context, key, member string, increment int64, ttl time.Duration) error {
+	s, err := r.acquire(key)
+	if err != nil {
+		return err
+	}
+
+	writer, ok := s.node.Repo.(biz.LeaderboardWriter)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaderboardUnsupported, key)
+	}
+
+	err = writer.IncrementLeaderboard(ctx, key, member, increment, ttl)
+	r.observe(s, err)
+	return err
+}
+
+// ScanCounters 依次扫描各可用分片中key以prefix开头的计数器，最多返回limit个
+// 同一资源的不同计数器可能分布在不同分片上，某个分片不可用时跳过
+func (r *ShardedRedisRepo) ScanCounters(ctx context.Context, prefix string, limit int) (map[string]int64, error) {
+	result := make(map[string]int64)
+	if limit <= 0 {
+		return result, nil
+	}
+
+	r.mu.RLock()
+	names := make([]string, 0, len(r.shards))
+	for name := range r.shards {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	failed := 0
+	for _, name := range names {
+		r.mu.RLock()
+		s := r.shards[name]
+		r.mu.RUnlock()
+
+		scanner, ok := s.node.Repo.(biz.CounterScanner)
+		if !ok {
+			return nil, ErrScanUnsupported
+		}
+		if !r.available(s) {
+			failed++
+			continue
+		}
+
+		values, err := scanner.ScanCounters(ctx, prefix, limit-len(result))
+		r.observe(s, err)
+		if err != nil {
+			failed++
+			r.logger.Error("Failed to scan counters on shard",
+				zap.String("shard", name),
+				zap.String("prefix", prefix),
+				zap.Error(err))
+			continue
+		}
+
+		for k, v := range values {
+			result[k] = v
+		}
+		if len(result) >= limit {
+			break
+		}
+	}
+
+	if failed == len(names) {
+		return nil, fmt.Errorf("all %d shards failed for scan: %w", failed, ErrShardUnavailable)
+	}
+
+	return result, nil
+}
+
+// GetMultiCounters 批量获取计数器，按分片分组后并发查询
+// 某个分片不可用时跳过其上的key，只返回可用分片的结果，被跳过的key记录在日志中；
+// 需要逐个key的失败原因时使用GetMultiCountersPartial
+func (r *ShardedRedisRepo) GetMultiCounters(ctx context.Context, keys []string) (map[string]int64, error) {
+	values, errs, err := r.GetMultiCountersPartial(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		skipped := make([]string, 0, len(errs))
+		for key := range errs {
+			skipped = append(skipped, key)
+		}
+		sort.Strings(skipped)
+		r.logger.Warn("Counters skipped in multi get",
+			zap.Int("skipped", len(skipped)),
+			zap.Strings("keys", skipped))
+	}
+	return values, nil
+}
+
+// GetMultiCountersPartial 批量获取计数器，按分片分组后并发查询
+// 分片失败时其上的key全部记入errs，分片内单个key的错误同样记入errs；所有分片都失败时返回错误
+func (r *ShardedRedisRepo) GetMultiCountersPartial(ctx context.Context, keys []string) (map[string]int64, map[string]error, error) {
+	result := make(map[string]int64, len(keys))
+	errs := make(map[string]error)
+	if len(keys) == 0 {
+		return result, errs, nil
+	}
+
+	groups := r.GroupByShard(keys)
+
+	var (
+		wg       sync.WaitGroup
+		resultMu sync.Mutex
+		failed   int
+	)
+
+	for name, shardKeys := range groups {
+		wg.Add(1)
+		go func(name string, shardKeys []string) {
+			defer wg.Done()
+
+			r.mu.RLock()
+			s := r.shards[name]
+			r.mu.RUnlock()
+
+			values, keyErrs, err := r.getShardCounters(ctx, s, shardKeys)
+
+			resultMu.Lock()
+			defer resultMu.Unlock()
+			if err != nil {
+				failed++
+				r.logger.Error("Failed to get counters from shard",
+					zap.String("shard", name),
+					zap.Int("keys", len(shardKeys)),
+					zap.Error(err))
+				for _, key := range shardKeys {
+					errs[key] = err
+				}
+				return
+			}
+			for k, v := range values {
+				result[k] = v
+			}
+			for k, keyErr := range keyErrs {
+				errs[k] = keyErr
+			}
+		}(name, shardKeys)
+	}
+
+	wg.Wait()
+
+	if failed == len(groups) {
+		return result, errs, fmt.Errorf("all %d shards failed for multi get: %w", failed, ErrShardUnavailable)
+	}
+
+	return result, errs, nil
+}
+
+// GetCounterUpdatedAt 按分片读取计数器最后写入时间，不可用或不支持的分片上的key不在结果中
+func (r *ShardedRedisRepo) GetCounterUpdatedAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	result := make(map[string]time.Time, len(keys))
+	for name, shardKeys := range r.GroupByShard(keys) {
+		r.mu.RLock()
+		s := r.shards[name]
+		r.mu.RUnlock()
+
+		reader, ok := s.node.Repo.(biz.CounterUpdateTimeReader)
+		if !ok {
+			continue
+		}
+		if !r.available(s) {
+			r.logger.Warn("Shard unavailable, counter update times skipped",
+				zap.String("shard", name), zap.Strings("keys", shardKeys))
+			continue
+		}
+		times, err := reader.GetCounterUpdatedAt(ctx, shardKeys)
+		r.observe(s, err)
+		if err != nil {
+			r.logger.Warn("Failed to read counter update times from shard",
+				zap.String("shard", name), zap.Error(err))
+			continue
+		}
+		for key, at := range times {
+			result[key] = at
+		}
+	}
+	return result, nil
+}
+
+// GroupByShard 将key按所属分片分组
+func (r *ShardedRedisRepo) GroupByShard(keys []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, key := range keys {
+		name := r.ShardFor(key)
+		groups[name] = append(groups[name], key)
+	}
+	return groups
+}
+
+// getShardCounters 从单个分片批量获取计数器
+func (r *ShardedRedisRepo) getShardCounters(ctx context.Context, s *shard, keys []string) (map[string]int64, map[string]error, error) {
+	if !r.available(s) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrShardUnavailable, s.node.Name)
+	}
+
+	values, errs, err := getMultiCountersPartial(ctx, s.node.Repo, keys)
+	r.observe(s, err)
+	return values, errs, err
+}
+
+// acquire 获取key所属的可用分片
+func (r *ShardedRedisRepo) acquire(key string) (*shard, error) {
+	name := r.ShardFor(key)
+
+	r.mu.RLock()
+	s := r.shards[name]
+	r.mu.RUnlock()
+
+	if !r.available(s) {
+		return nil, fmt.Errorf("%w: %s", ErrShardUnavailable, name)
+	}
+	return s, nil
+}
+
+// available 判断分片当前是否可用
+func (r *ShardedRedisRepo) available(s *shard) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return time.Now().After(s.downUntil)
+}
+
+// observe 根据操作结果更新分片状态，出错时在冷却期内快速失败
+func (r *ShardedRedisRepo) observe(s *shard, err error) {
+	// 业务错误说明分片本身正常，不标记为不可用
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCounterOverflow) || IsCorruptCounterValue(err) {
+		return
+	}
+
+	r.mu.Lock()
+	s.downUntil = time.Now().Add(r.config.DownCooldown)
+	r.mu.Unlock()
+
+	r.logger.Warn("Redis shard marked down",
+		zap.String("shard", s.node.Name),
+		zap.Duration("cooldown", r.config.DownCooldown),
+		zap.Error(err))
+}
+
+// GetShardStatus 获取各分片可用状态
+func (r *ShardedRedisRepo) GetShardStatus() map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	status := make(map[string]bool, len(r.shards))
+	for name, s := range r.shards {
+		status[name] = now.After(s.downUntil)
+	}
+	return status
+}
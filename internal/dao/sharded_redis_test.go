@@ -0,0 +1,231 @@
+package dao_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"high-go-press/internal/dao"
+	"high-go-press/internal/dao/daotest"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestShards(weights map[string]int) (map[string]*daotest.MemoryCounterRepo, []dao.ShardNode) {
+	repos := make(map[string]*daotest.MemoryCounterRepo, len(weights))
+	nodes := make([]dao.ShardNode, 0, len(weights))
+	for name, weight := range weights {
+		repo := daotest.NewMemoryCounterRepo()
+		repos[name] = repo
+		nodes = append(nodes, dao.ShardNode{Name: name, Weight: weight, Repo: repo})
+	}
+	return repos, nodes
+}
+
+func TestShardedRedisRepoDistribution(t *testing.T) {
+	_, nodes := newTestShards(map[string]int{"redis-a": 1, "redis-b": 1, "redis-c": 2})
+	repo, err := dao.NewShardedRedisRepo(nodes, nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("dao.NewShardedRedisRepo failed: %v", err)
+	}
+
+	const total = 20000
+	counts := make(map[string]int)
+	for i := 0; i < total; i++ {
+		counts[repo.ShardFor(fmt.Sprintf("counter:article_%d:like", i))]++
+	}
+
+	// 权重为1的节点约占1/4，权重为2的节点约占1/2
+	expected := map[string]float64{"redis-a": 0.25, "redis-b": 0.25, "redis-c": 0.5}
+	for name, ratio := range expected {
+		got := float64(counts[name]) / total
+		if got < ratio*0.75 || got > ratio*1.25 {
+			t.Errorf("Shard %s: expected ratio ~%.2f, got %.3f", name, ratio, got)
+		}
+	}
+
+	// 同一个key总是落在同一个分片
+	key := "counter:article_42:like"
+	first := repo.ShardFor(key)
+	for i := 0; i < 10; i++ {
+		if got := repo.ShardFor(key); got != first {
+			t.Fatalf("Key %s mapped to %s and %s", key, first, got)
+		}
+	}
+}
+
+func TestShardedRedisRepoMinimalRemapping(t *testing.T) {
+	_, three := newTestShards(map[string]int{"redis-a": 1, "redis-b": 1, "redis-c": 1})
+	_, four := newTestShards(map[string]int{"redis-a": 1, "redis-b": 1, "redis-c": 1, "redis-d": 1})
+
+	before, _ := dao.NewShardedRedisRepo(three, nil, zap.NewNop())
+	after, _ := dao.NewShardedRedisRepo(four, nil, zap.NewNop())
+
+	const total = 10000
+	moved := 0
+	for i := 0; i < total; i++ {
+		key := fmt.Sprintf("counter:article_%d:view", i)
+		if before.ShardFor(key) != after.ShardFor(key) {
+			moved++
+		}
+	}
+
+	// 新增一个节点只应迁移约1/4的key
+	if ratio := float64(moved) / total; ratio > 0.4 {
+		t.Errorf("Expected ~25%% of keys to move, got %.2f", ratio)
+	}
+}
+
+func TestShardedRedisRepoBatchFanOut(t *testing.T) {
+	repos, nodes := newTestShards(map[string]int{"redis-a": 1, "redis-b": 1, "redis-c": 1})
+	repo, err := dao.NewShardedRedisRepo(nodes, nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("dao.NewShardedRedisRepo failed: %v", err)
+	}
+	ctx := context.Background()
+
+	keys := make([]string, 0, 30)
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("counter:article_%d:like", i)
+		keys = append(keys, key)
+		if _, err := repo.IncrementCounter(ctx, key, int64(i)); err != nil {
+			t.Fatalf("IncrementCounter failed: %v", err)
+		}
+	}
+
+	values, err := repo.GetMultiCounters(ctx, keys)
+	if err != nil {
+		t.Fatalf("GetMultiCounters failed: %v", err)
+	}
+	for i, key := range keys {
+		if values[key] != int64(i) {
+			t.Errorf("Key %s: expected %d, got %d", key, i, values[key])
+		}
+	}
+
+	// 每个分片只收到一次批量请求，且只包含属于自己的key
+	for name, shardRepo := range repos {
+		if len(shardRepo.MultiGetCalls()) != 1 {
+			t.Errorf("Shard %s: expected 1 multi get call, got %d", name, len(shardRepo.MultiGetCalls()))
+			continue
+		}
+		for _, key := range shardRepo.MultiGetCalls()[0] {
+			if repo.ShardFor(key) != name {
+				t.Errorf("Key %s sent to shard %s, expected %s", key, name, repo.ShardFor(key))
+			}
+		}
+	}
+}
+
+func TestNewShardedRedisRepoDoesNotMutateConfig(t *testing.T) {
+	_, nodes := newTestShards(map[string]int{"redis-a": 1})
+	cfg := &dao.ShardedRedisConfig{DownCooldown: time.Second}
+	if _, err := dao.NewShardedRedisRepo(nodes, cfg, zap.NewNop()); err != nil {
+		t.Fatalf("dao.NewShardedRedisRepo failed: %v", err)
+	}
+	if cfg.VirtualNodes != 0 || cfg.DownCooldown != time.Second {
+		t.Errorf("Expected caller config unchanged, got %+v", cfg)
+	}
+}
+
+func TestShardedRedisRepoShardDown(t *testing.T) {
+	repos, nodes := newTestShards(map[string]int{"redis-a": 1, "redis-b": 1})
+	core, logs := observer.New(zap.WarnLevel)
+	repo, err := dao.NewShardedRedisRepo(nodes, nil, zap.New(core))
+	if err != nil {
+		t.Fatalf("dao.NewShardedRedisRepo failed: %v", err)
+	}
+	ctx := context.Background()
+
+	var downKey, upKey string
+	for i := 0; downKey == "" || upKey == ""; i++ {
+		key := fmt.Sprintf("counter:article_%d:like", i)
+		if repo.ShardFor(key) == "redis-a" {
+			downKey = key
+		} else {
+			upKey = key
+		}
+	}
+	repos["redis-b"].SetValue(upKey, 7)
+	repos["redis-a"].ReadErr = errors.New("connection refused")
+
+	// 不可用分片上的key返回错误，随后在冷却期内快速失败
+	if _, err := repo.GetCounter(ctx, downKey); err == nil {
+		t.Fatal("Expected error from down shard")
+	}
+	if _, err := repo.IncrementCounter(ctx, downKey, 1); !errors.Is(err, dao.ErrShardUnavailable) {
+		t.Errorf("Expected dao.ErrShardUnavailable, got %v", err)
+	}
+	if status := repo.GetShardStatus(); status["redis-a"] || !status["redis-b"] {
+		t.Errorf("Unexpected shard status: %v", status)
+	}
+
+	// 批量查询跳过不可用分片，返回可用分片的结果
+	values, err := repo.GetMultiCounters(ctx, []string{downKey, upKey})
+	if err != nil {
+		t.Fatalf("GetMultiCounters failed: %v", err)
+	}
+	if _, ok := values[downKey]; ok {
+		t.Errorf("Expected key on down shard to be omitted")
+	}
+	if values[upKey] != 7 {
+		t.Errorf("Expected %s=7, got %d", upKey, values[upKey])
+	}
+
+	// 被跳过的key记录在日志中，不会悄无声息地丢失
+	skipped := logs.FilterMessage("Counters skipped in multi get").All()
+	if len(skipped) != 1 {
+		t.Fatalf("Expected skipped keys to be logged once, got %d entries", len(skipped))
+	}
+	if keys, ok := skipped[0].ContextMap()["keys"].([]interface{}); !ok || len(keys) != 1 || keys[0] != downKey {
+		t.Errorf("Expected skipped keys [%s], got %v", downKey, skipped[0].ContextMap()["keys"])
+	}
+}
+
+func TestShardedRedisRepoScanAcrossShards(t *testing.T) {
+	_, nodes := newTestShards(map[string]int{"redis-a": 1, "redis-b": 1, "redis-c": 1})
+	repo, err := dao.NewShardedRedisRepo(nodes, nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("dao.NewShardedRedisRepo failed: %v", err)
+	}
+	ctx := context.Background()
+
+	types := []string{"like", "view", "follow", "share", "comment", "favorite"}
+	for i, counterType := range types {
+		if err := repo.SetCounter(ctx, dao.CounterKey(ctx, "article_1", counterType), int64(i+1)); err != nil {
+			t.Fatalf("SetCounter failed: %v", err)
+		}
+	}
+	if err := repo.SetCounter(ctx, dao.CounterKey(ctx, "article_10", "like"), 99); err != nil {
+		t.Fatalf("SetCounter failed: %v", err)
+	}
+
+	// 同一资源的计数器分布在不同分片上，扫描需合并所有分片的结果
+	counters, truncated, err := dao.ScanResourceCounters(ctx, repo, "article_1", 10)
+	if err != nil {
+		t.Fatalf("dao.ScanResourceCounters failed: %v", err)
+	}
+	if truncated {
+		t.Error("Expected result not truncated")
+	}
+	if len(counters) != len(types) {
+		t.Fatalf("Expected %d counter types, got %v", len(types), counters)
+	}
+	for i, counterType := range types {
+		if counters[counterType] != int64(i+1) {
+			t.Errorf("Type %s: expected %d, got %d", counterType, i+1, counters[counterType])
+		}
+	}
+
+	// 超出上限时截断
+	counters, truncated, err = dao.ScanResourceCounters(ctx, repo, "article_1", 4)
+	if err != nil {
+		t.Fatalf("dao.ScanResourceCounters failed: %v", err)
+	}
+	if !truncated || len(counters) != 4 {
+		t.Errorf("Expected 4 truncated counter types, got %v (truncated=%v)", counters, truncated)
+	}
+}
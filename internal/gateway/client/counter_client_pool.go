@@ -142,12 +142,101 @@ func (p *CounterClientPool) BatchGetCounters(ctx context.Context, req *pb.BatchG
 	return client.BatchGetCounters(ctx, req)
 }
 
+// GetHotRank 获取热点排行 - 使用连接池
+func (p *CounterClientPool) GetHotRank(ctx context.Context, req *pb.GetHotRankRequest) (*pb.GetHotRankResponse, error) {
+	client := p.getClient()
+	return client.GetHotRank(ctx, req)
+}
+
 // HealthCheck 健康检查 - 使用连接池
 func (p *CounterClientPool) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
 	client := p.getClient()
 	return client.HealthCheck(ctx, req)
 }
 
+// 连接池整体健康状态
+const (
+	PoolHealthy   = "healthy"   // 全部连接健康
+	PoolDegraded  = "degraded"  // 部分连接不健康
+	PoolUnhealthy = "unhealthy" // 全部连接不健康
+)
+
+// ConnectionHealth 单个连接的健康检查结果
+type ConnectionHealth struct {
+	Index   int           `json:"index"`
+	State   string        `json:"state"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// PoolHealth 连接池健康检查汇总
+type PoolHealth struct {
+	Status      string             `json:"status"`
+	Healthy     int                `json:"healthy"`
+	Total       int                `json:"total"`
+	Connections []ConnectionHealth `json:"connections"`
+}
+
+// HealthCheckAll 对连接池中所有连接并发执行健康检查，返回汇总及各连接结果
+func (p *CounterClientPool) HealthCheckAll(ctx context.Context, req *pb.HealthCheckRequest) *PoolHealth {
+	p.mutex.RLock()
+	clients := append([]pb.CounterServiceClient(nil), p.clients...)
+	connections := append([]*grpc.ClientConn(nil), p.connections...)
+	p.mutex.RUnlock()
+
+	results := make([]ConnectionHealth, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client pb.CounterServiceClient) {
+			defer wg.Done()
+
+			result := ConnectionHealth{Index: i}
+			if i < len(connections) && connections[i] != nil {
+				result.State = connections[i].GetState().String()
+			}
+
+			start := time.Now()
+			resp, err := client.HealthCheck(ctx, req)
+			result.Latency = time.Since(start)
+
+			switch {
+			case err != nil:
+				result.Error = err.Error()
+			case resp.GetStatus() == nil || !resp.GetStatus().GetSuccess():
+				result.Error = resp.GetStatus().GetMessage()
+			default:
+				result.Healthy = true
+			}
+			results[i] = result
+		}(i, client)
+	}
+	wg.Wait()
+
+	health := &PoolHealth{Total: len(results), Connections: results}
+	for _, result := range results {
+		if result.Healthy {
+			health.Healthy++
+		} else {
+			p.logger.Warn("Pooled gRPC connection unhealthy",
+				zap.Int("connection_id", result.Index),
+				zap.String("state", result.State),
+				zap.String("error", result.Error))
+		}
+	}
+
+	switch {
+	case health.Total > 0 && health.Healthy == health.Total:
+		health.Status = PoolHealthy
+	case health.Healthy > 0:
+		health.Status = PoolDegraded
+	default:
+		health.Status = PoolUnhealthy
+	}
+	return health
+}
+
 // GetPoolStats 获取连接池统计信息
 func (p *CounterClientPool) GetPoolStats() map[string]interface{} {
 	p.mutex.RLock()
@@ -171,6 +260,11 @@ func (p *CounterClientPool) GetPoolStats() map[string]interface{} {
 	return stats
 }
 
+// Stats 以通用格式返回连接池统计，实现stats.StatsProvider
+func (p *CounterClientPool) Stats() map[string]interface{} {
+	return p.GetPoolStats()
+}
+
 // Close 关闭连接池
 func (p *CounterClientPool) Close() error {
 	p.mutex.Lock()
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"high-go-press/api/proto/common"
+	pb "high-go-press/api/proto/counter"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// fakeCounterServiceClient 测试用客户端，仅实现HealthCheck
+type fakeCounterServiceClient struct {
+	pb.CounterServiceClient
+	err     error
+	success bool
+}
+
+func (c *fakeCounterServiceClient) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest, opts ...grpc.CallOption) (*pb.HealthCheckResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &pb.HealthCheckResponse{
+		Status: &common.Status{Success: c.success, Message: "redis unavailable"},
+	}, nil
+}
+
+func newTestPool(clients ...pb.CounterServiceClient) *CounterClientPool {
+	return &CounterClientPool{
+		poolSize:    len(clients),
+		connections: make([]*grpc.ClientConn, len(clients)),
+		clients:     clients,
+		logger:      zap.NewNop(),
+	}
+}
+
+func TestHealthCheckAllReportsDegraded(t *testing.T) {
+	p := newTestPool(
+		&fakeCounterServiceClient{success: true},
+		&fakeCounterServiceClient{err: errors.New("connection refused")},
+		&fakeCounterServiceClient{success: true},
+	)
+
+	health := p.HealthCheckAll(context.Background(), &pb.HealthCheckRequest{})
+	if health.Status != PoolDegraded {
+		t.Fatalf("Expected status %s, got %s", PoolDegraded, health.Status)
+	}
+	if health.Healthy != 2 || health.Total != 3 {
+		t.Errorf("Expected 2/3 healthy connections, got %d/%d", health.Healthy, health.Total)
+	}
+
+	bad := health.Connections[1]
+	if bad.Index != 1 || bad.Healthy || bad.Error != "connection refused" {
+		t.Errorf("Unexpected result for unhealthy connection: %+v", bad)
+	}
+	if !health.Connections[0].Healthy || !health.Connections[2].Healthy {
+		t.Errorf("Expected other connections to be healthy: %+v", health.Connections)
+	}
+}
+
+func TestHealthCheckAllStatus(t *testing.T) {
+	healthy := newTestPool(&fakeCounterServiceClient{success: true}, &fakeCounterServiceClient{success: true})
+	if got := healthy.HealthCheckAll(context.Background(), &pb.HealthCheckRequest{}).Status; got != PoolHealthy {
+		t.Errorf("Expected %s, got %s", PoolHealthy, got)
+	}
+
+	// 服务端返回失败状态同样视为不健康
+	unhealthy := newTestPool(&fakeCounterServiceClient{success: false}, &fakeCounterServiceClient{err: errors.New("timeout")})
+	health := unhealthy.HealthCheckAll(context.Background(), &pb.HealthCheckRequest{})
+	if health.Status != PoolUnhealthy {
+		t.Errorf("Expected %s, got %s", PoolUnhealthy, health.Status)
+	}
+	if health.Connections[0].Error != "redis unavailable" {
+		t.Errorf("Expected server status message as error, got %q", health.Connections[0].Error)
+	}
+}
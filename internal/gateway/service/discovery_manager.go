@@ -14,14 +14,52 @@ import (
 	"google.golang.org/grpc/keepalive"
 )
 
+// ServiceDiscoverer 服务实例发现接口，由consul.Client实现
+type ServiceDiscoverer interface {
+	DiscoverService(serviceName string, healthy bool) ([]*consul.ServiceInstance, error)
+}
+
+// DiscoveryConfig 服务发现管理器配置
+type DiscoveryConfig struct {
+	// StaleThreshold Consul不可用时继续使用上次成功发现结果的最长时间，超过后清空连接；0表示不清空
+	StaleThreshold time.Duration
+	// RefreshInterval 服务实例默认刷新间隔
+	RefreshInterval time.Duration
+	// ServiceRefreshIntervals 按服务名覆盖的刷新间隔
+	ServiceRefreshIntervals map[string]time.Duration
+}
+
+// DefaultDiscoveryConfig 默认服务发现配置
+func DefaultDiscoveryConfig() *DiscoveryConfig {
+	return &DiscoveryConfig{
+		StaleThreshold:  5 * time.Minute,
+		RefreshInterval: 30 * time.Second,
+	}
+}
+
+// refreshInterval 服务的刷新间隔：单独配置优先，否则使用默认值
+func (c *DiscoveryConfig) refreshInterval(serviceName string) time.Duration {
+	if interval := c.ServiceRefreshIntervals[serviceName]; interval > 0 {
+		return interval
+	}
+	if c.RefreshInterval > 0 {
+		return c.RefreshInterval
+	}
+	return DefaultDiscoveryConfig().RefreshInterval
+}
+
 // DiscoveryManager 服务发现管理器
 type DiscoveryManager struct {
-	consul     *consul.Client
+	consul     ServiceDiscoverer
+	config     *DiscoveryConfig
 	logger     *zap.Logger
 	services   map[string]*ServiceEndpoints
 	serviceMux sync.RWMutex
-	ctx        context.Context
-	cancel     context.CancelFunc
+	// endpoints 按实例地址记录的调用统计
+	endpoints   map[string]*endpointStats
+	endpointMux sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
 }
 
 // ServiceEndpoints 服务端点信息
@@ -30,19 +68,52 @@ type ServiceEndpoints struct {
 	Connections []*grpc.ClientConn
 	Instances   []*consul.ServiceInstance
 	LastUpdated time.Time
-	mutex       sync.RWMutex
+	// LastRefreshed 最近一次成功从Consul获取实例的时间
+	LastRefreshed time.Time
+	// Stale 最近一次发现失败，当前实例为上次成功发现的结果
+	Stale     bool
+	LastError error
+	mutex     sync.RWMutex
+
+	// refresh 手动触发立即刷新
+	refresh chan struct{}
+}
+
+// newServiceEndpoints 创建空的服务端点
+func newServiceEndpoints(serviceName string) *ServiceEndpoints {
+	return &ServiceEndpoints{
+		Name:        serviceName,
+		Connections: make([]*grpc.ClientConn, 0),
+		Instances:   make([]*consul.ServiceInstance, 0),
+		LastUpdated: time.Now(),
+		refresh:     make(chan struct{}, 1),
+	}
+}
+
+// staleAge 实例数据距上次成功刷新的时长，数据未过期时为0
+func (se *ServiceEndpoints) staleAge(now time.Time) time.Duration {
+	if !se.Stale || se.LastRefreshed.IsZero() {
+		return 0
+	}
+	return now.Sub(se.LastRefreshed)
 }
 
 // NewDiscoveryManager 创建服务发现管理器
-func NewDiscoveryManager(consulClient *consul.Client, logger *zap.Logger) *DiscoveryManager {
+func NewDiscoveryManager(discoverer ServiceDiscoverer, config *DiscoveryConfig, logger *zap.Logger) *DiscoveryManager {
+	if config == nil {
+		config = DefaultDiscoveryConfig()
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &DiscoveryManager{
-		consul:   consulClient,
-		logger:   logger,
-		services: make(map[string]*ServiceEndpoints),
-		ctx:      ctx,
-		cancel:   cancel,
+		consul:    discoverer,
+		config:    config,
+		logger:    logger,
+		services:  make(map[string]*ServiceEndpoints),
+		endpoints: make(map[string]*endpointStats),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 }
 
@@ -55,15 +126,12 @@ func (dm *DiscoveryManager) RegisterService(serviceName string) error {
 		return fmt.Errorf("service %s already registered", serviceName)
 	}
 
-	dm.services[serviceName] = &ServiceEndpoints{
-		Name:        serviceName,
-		Connections: make([]*grpc.ClientConn, 0),
-		Instances:   make([]*consul.ServiceInstance, 0),
-		LastUpdated: time.Now(),
-	}
+	service := newServiceEndpoints(serviceName)
+	dm.services[serviceName] = service
 
 	dm.logger.Info("Service registered for discovery",
-		zap.String("service", serviceName))
+		zap.String("service", serviceName),
+		zap.Duration("refresh_interval", dm.config.refreshInterval(serviceName)))
 
 	// 异步进行初始服务发现，不阻塞注册流程
 	go func() {
@@ -78,7 +146,7 @@ func (dm *DiscoveryManager) RegisterService(serviceName string) error {
 	}()
 
 	// 启动服务监听
-	go dm.watchService(serviceName)
+	go dm.watchService(service)
 
 	return nil
 }
@@ -153,33 +221,65 @@ func (dm *DiscoveryManager) GetServiceInstances(serviceName string) ([]*consul.S
 	return instances, nil
 }
 
-// watchService 监听服务变化
-func (dm *DiscoveryManager) watchService(serviceName string) {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+// IsReady 服务是否已发现实例并建立连接
+func (dm *DiscoveryManager) IsReady(serviceName string) bool {
+	dm.serviceMux.RLock()
+	service, exists := dm.services[serviceName]
+	dm.serviceMux.RUnlock()
+
+	if !exists {
+		return false
+	}
+
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+	return len(service.Connections) > 0
+}
+
+// TriggerRefresh 立即刷新服务实例，不等待下一个刷新周期；已有待处理的触发时合并
+func (dm *DiscoveryManager) TriggerRefresh(serviceName string) error {
+	dm.serviceMux.RLock()
+	service, exists := dm.services[serviceName]
+	dm.serviceMux.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("service %s not registered", serviceName)
+	}
+
+	select {
+	case service.refresh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// watchService 按服务的刷新间隔监听服务变化，手动触发时立即刷新并重新计时
+func (dm *DiscoveryManager) watchService(service *ServiceEndpoints) {
+	interval := dm.config.refreshInterval(service.Name)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-dm.ctx.Done():
 			return
-		case <-ticker.C:
-			if err := dm.updateService(serviceName); err != nil {
-				dm.logger.Error("Failed to update service",
-					zap.String("service", serviceName),
-					zap.Error(err))
-			}
+		case <-timer.C:
+		case <-service.refresh:
+			dm.logger.Debug("Service refresh triggered",
+				zap.String("service", service.Name))
+		}
+
+		if err := dm.updateService(service.Name); err != nil {
+			dm.logger.Error("Failed to update service",
+				zap.String("service", service.Name),
+				zap.Error(err))
 		}
+		timer.Reset(interval)
 	}
 }
 
 // updateService 更新服务端点
 func (dm *DiscoveryManager) updateService(serviceName string) error {
-	// 从Consul发现服务实例
-	instances, err := dm.consul.DiscoverService(serviceName, true)
-	if err != nil {
-		return fmt.Errorf("failed to discover service %s: %w", serviceName, err)
-	}
-
 	dm.serviceMux.RLock()
 	service, exists := dm.services[serviceName]
 	dm.serviceMux.RUnlock()
@@ -188,9 +288,25 @@ func (dm *DiscoveryManager) updateService(serviceName string) error {
 		return fmt.Errorf("service %s not registered", serviceName)
 	}
 
+	// 从Consul发现服务实例
+	instances, err := dm.consul.DiscoverService(serviceName, true)
+
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
 
+	if err != nil {
+		return dm.handleDiscoveryFailure(service, err)
+	}
+
+	if service.Stale {
+		dm.logger.Info("Service discovery recovered",
+			zap.String("service", serviceName),
+			zap.Duration("stale_for", service.staleAge(time.Now())))
+	}
+	service.Stale = false
+	service.LastError = nil
+	service.LastRefreshed = time.Now()
+
 	// 检查是否有变化
 	if !dm.instancesChanged(service.Instances, instances) {
 		dm.logger.Debug("No changes in service instances",
@@ -230,6 +346,45 @@ func (dm *DiscoveryManager) updateService(serviceName string) error {
 	return nil
 }
 
+// handleDiscoveryFailure 发现失败时保留上次成功的实例和连接，超过过期阈值才清空；调用方需持有service锁
+func (dm *DiscoveryManager) handleDiscoveryFailure(service *ServiceEndpoints, cause error) error {
+	err := fmt.Errorf("failed to discover service %s: %w", service.Name, cause)
+	service.LastError = err
+
+	// 从未成功发现过，没有可保留的数据
+	if service.LastRefreshed.IsZero() {
+		return err
+	}
+
+	service.Stale = true
+	age := service.staleAge(time.Now())
+
+	if dm.config.StaleThreshold <= 0 || age < dm.config.StaleThreshold {
+		dm.logger.Warn("Service discovery unavailable, keeping last known instances",
+			zap.String("service", service.Name),
+			zap.Int("instances", len(service.Instances)),
+			zap.Duration("stale_age", age),
+			zap.Error(cause))
+		return nil
+	}
+
+	if len(service.Connections) > 0 {
+		for _, conn := range service.Connections {
+			conn.Close()
+		}
+		dm.logger.Error("Service discovery stale beyond threshold, connections cleared",
+			zap.String("service", service.Name),
+			zap.Int("instances", len(service.Instances)),
+			zap.Duration("stale_age", age),
+			zap.Duration("threshold", dm.config.StaleThreshold))
+		service.Connections = make([]*grpc.ClientConn, 0)
+		service.Instances = make([]*consul.ServiceInstance, 0)
+		service.LastUpdated = time.Now()
+	}
+
+	return err
+}
+
 // createConnection 创建gRPC连接
 func (dm *DiscoveryManager) createConnection(address string) (*grpc.ClientConn, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -258,6 +413,8 @@ func (dm *DiscoveryManager) createConnection(address string) (*grpc.ClientConn,
 			Timeout:             3 * time.Second,
 			PermitWithoutStream: true,
 		}),
+		// 记录每个实例的调用结果，供grpc-pools端点定位异常后端
+		grpc.WithChainUnaryInterceptor(dm.endpointInterceptor(address)),
 	)
 
 	if err != nil {
@@ -292,26 +449,54 @@ func (dm *DiscoveryManager) instancesChanged(old, new []*consul.ServiceInstance)
 	return false
 }
 
+// Stats 以通用格式返回服务发现统计，实现stats.StatsProvider
+func (dm *DiscoveryManager) Stats() map[string]interface{} {
+	return dm.GetStats()
+}
+
 // GetStats 获取服务发现统计信息
 func (dm *DiscoveryManager) GetStats() map[string]interface{} {
 	dm.serviceMux.RLock()
 	defer dm.serviceMux.RUnlock()
 
 	stats := make(map[string]interface{})
+	now := time.Now()
 
 	for name, service := range dm.services {
 		service.mutex.RLock()
-		stats[name] = map[string]interface{}{
-			"instances":    len(service.Instances),
-			"connections":  len(service.Connections),
-			"last_updated": service.LastUpdated.Unix(),
+		serviceStats := map[string]interface{}{
+			"instances":         len(service.Instances),
+			"connections":       len(service.Connections),
+			"last_updated":      service.LastUpdated.Unix(),
+			"stale":             service.Stale,
+			"stale_age_seconds": service.staleAge(now).Seconds(),
+			"refresh_interval":  dm.config.refreshInterval(name).String(),
 		}
+		if service.LastError != nil {
+			serviceStats["last_error"] = service.LastError.Error()
+		}
+		serviceStats["endpoints"] = dm.instanceStats(service.Instances)
+		stats[name] = serviceStats
 		service.mutex.RUnlock()
 	}
 
 	return stats
 }
 
+// instanceStats 汇总服务各实例的调用统计
+func (dm *DiscoveryManager) instanceStats(instances []*consul.ServiceInstance) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(instances))
+	for _, instance := range instances {
+		address := instance.GetAddress()
+		stats := dm.endpointStatsFor(address).snapshot()
+		stats["id"] = instance.ID
+		stats["address"] = address
+		stats["healthy"] = instance.Healthy
+		result = append(result, stats)
+	}
+	return result
+}
+
 // Close 关闭服务发现管理器
 func (dm *DiscoveryManager) Close() error {
 	dm.cancel()
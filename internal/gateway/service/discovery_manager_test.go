@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/consul"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/connectivity"
+)
+
+// fakeDiscoverer 可模拟Consul故障的服务发现
+type fakeDiscoverer struct {
+	mu        sync.Mutex
+	instances []*consul.ServiceInstance
+	err       error
+	calls     chan string
+}
+
+func (f *fakeDiscoverer) DiscoverService(serviceName string, healthy bool) ([]*consul.ServiceInstance, error) {
+	if f.calls != nil {
+		f.calls <- serviceName
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.instances, nil
+}
+
+func (f *fakeDiscoverer) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// newTestDiscoveryManager 创建不启动后台监听的服务发现管理器
+func newTestDiscoveryManager(t *testing.T, discoverer ServiceDiscoverer, threshold time.Duration, services ...string) *DiscoveryManager {
+	t.Helper()
+	return newTestDiscoveryManagerWithConfig(t, discoverer, &DiscoveryConfig{StaleThreshold: threshold}, services...)
+}
+
+func newTestDiscoveryManagerWithConfig(t *testing.T, discoverer ServiceDiscoverer, config *DiscoveryConfig, services ...string) *DiscoveryManager {
+	t.Helper()
+	dm := NewDiscoveryManager(discoverer, config, zap.NewNop())
+	for _, name := range services {
+		dm.services[name] = newServiceEndpoints(name)
+	}
+	t.Cleanup(func() { dm.Close() })
+	return dm
+}
+
+func serviceStats(t *testing.T, dm *DiscoveryManager, name string) map[string]interface{} {
+	t.Helper()
+	stats, ok := dm.GetStats()[name].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected stats for %s", name)
+	}
+	return stats
+}
+
+func TestDiscoveryManagerKeepsConnectionsDuringTransientOutage(t *testing.T) {
+	const name = "high-go-press-counter"
+	discoverer := &fakeDiscoverer{instances: []*consul.ServiceInstance{
+		{ID: "counter-1", Name: name, Address: "127.0.0.1", Port: 19001},
+	}}
+	dm := newTestDiscoveryManager(t, discoverer, time.Hour, name)
+
+	if err := dm.updateService(name); err != nil {
+		t.Fatalf("Initial discovery failed: %v", err)
+	}
+	before, err := dm.GetConnection(name)
+	if err != nil {
+		t.Fatalf("Expected connection after discovery, got %v", err)
+	}
+
+	// Consul短暂不可用：保留上次成功的实例和连接，不向上报错
+	discoverer.setErr(errors.New("connection refused"))
+	if err := dm.updateService(name); err != nil {
+		t.Fatalf("Expected transient outage to be non-fatal, got %v", err)
+	}
+
+	after, err := dm.GetConnection(name)
+	if err != nil {
+		t.Fatalf("Expected last known connection during outage, got %v", err)
+	}
+	if after != before {
+		t.Error("Expected the existing connection to be preserved")
+	}
+	if after.GetState() == connectivity.Shutdown {
+		t.Error("Expected preserved connection to remain open")
+	}
+	if instances, _ := dm.GetServiceInstances(name); len(instances) != 1 {
+		t.Errorf("Expected 1 last known instance, got %d", len(instances))
+	}
+
+	stats := serviceStats(t, dm, name)
+	if stats["stale"] != true {
+		t.Errorf("Expected service marked stale, got %v", stats["stale"])
+	}
+	if age, _ := stats["stale_age_seconds"].(float64); age <= 0 {
+		t.Errorf("Expected positive stale age, got %v", stats["stale_age_seconds"])
+	}
+	if _, ok := stats["last_error"]; !ok {
+		t.Error("Expected last_error in stats")
+	}
+
+	// Consul恢复后清除过期标记，实例未变化时沿用原连接
+	discoverer.setErr(nil)
+	if err := dm.updateService(name); err != nil {
+		t.Fatalf("Discovery after recovery failed: %v", err)
+	}
+	if conn, _ := dm.GetConnection(name); conn != before {
+		t.Error("Expected connection reused after recovery")
+	}
+	stats = serviceStats(t, dm, name)
+	if stats["stale"] != false || stats["stale_age_seconds"] != 0.0 {
+		t.Errorf("Expected fresh data after recovery, got %+v", stats)
+	}
+}
+
+func TestDiscoveryManagerClearsConnectionsAfterStaleThreshold(t *testing.T) {
+	const name = "high-go-press-analytics"
+	discoverer := &fakeDiscoverer{instances: []*consul.ServiceInstance{
+		{ID: "analytics-1", Name: name, Address: "127.0.0.1", Port: 19002},
+	}}
+	const threshold = 50 * time.Millisecond
+	dm := newTestDiscoveryManager(t, discoverer, threshold, name)
+
+	if err := dm.updateService(name); err != nil {
+		t.Fatalf("Initial discovery failed: %v", err)
+	}
+	conn, err := dm.GetConnection(name)
+	if err != nil {
+		t.Fatalf("Expected connection after discovery, got %v", err)
+	}
+
+	discoverer.setErr(errors.New("connection refused"))
+	if err := dm.updateService(name); err != nil {
+		t.Fatalf("Expected outage within threshold to be non-fatal, got %v", err)
+	}
+
+	// 超过过期阈值后清空连接并返回错误
+	time.Sleep(threshold * 2)
+	if err := dm.updateService(name); err == nil {
+		t.Fatal("Expected error once discovery data exceeded the stale threshold")
+	}
+	if conn.GetState() != connectivity.Shutdown {
+		t.Errorf("Expected expired connection closed, got %v", conn.GetState())
+	}
+	if stats := serviceStats(t, dm, name); stats["connections"] != 0 || stats["instances"] != 0 {
+		t.Errorf("Expected connections cleared, got %+v", stats)
+	}
+}
+
+func TestDiscoveryManagerInitialFailureReturnsError(t *testing.T) {
+	const name = "high-go-press-counter"
+	discoverer := &fakeDiscoverer{err: errors.New("connection refused")}
+	dm := newTestDiscoveryManager(t, discoverer, time.Hour, name)
+
+	// 从未成功发现过，没有可保留的数据
+	if err := dm.updateService(name); err == nil {
+		t.Fatal("Expected error when initial discovery fails")
+	}
+	if stats := serviceStats(t, dm, name); stats["stale"] != false {
+		t.Errorf("Expected never-discovered service not marked stale, got %+v", stats)
+	}
+}
+
+// waitForCall 等待指定服务的下一次发现调用，返回调用时间
+func waitForCall(t *testing.T, calls <-chan string, serviceName string, timeout time.Duration) time.Time {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case name := <-calls:
+			if name == serviceName {
+				return time.Now()
+			}
+		case <-deadline:
+			t.Fatalf("Timed out waiting for discovery of %s", serviceName)
+		}
+	}
+}
+
+func TestDiscoveryManagerRespectsPerServiceRefreshInterval(t *testing.T) {
+	const fast, slow = "high-go-press-counter", "high-go-press-analytics"
+	const interval = 50 * time.Millisecond
+	discoverer := &fakeDiscoverer{calls: make(chan string, 100)}
+	dm := newTestDiscoveryManagerWithConfig(t, discoverer, &DiscoveryConfig{
+		StaleThreshold:          time.Hour,
+		RefreshInterval:         time.Hour,
+		ServiceRefreshIntervals: map[string]time.Duration{fast: interval},
+	}, fast, slow)
+
+	start := time.Now()
+	go dm.watchService(dm.services[fast])
+	go dm.watchService(dm.services[slow])
+
+	// 单独配置的服务按自己的间隔刷新
+	first := waitForCall(t, discoverer.calls, fast, time.Second)
+	second := waitForCall(t, discoverer.calls, fast, time.Second)
+	if elapsed := first.Sub(start); elapsed < interval*8/10 {
+		t.Errorf("Expected first refresh after ~%v, got %v", interval, elapsed)
+	}
+	if gap := second.Sub(first); gap < interval*8/10 {
+		t.Errorf("Expected refreshes %v apart, got %v", interval, gap)
+	}
+
+	// 未单独配置的服务使用默认间隔，此时尚未刷新
+	drained := len(discoverer.calls)
+	for i := 0; i < drained; i++ {
+		if name := <-discoverer.calls; name == slow {
+			t.Fatalf("Expected %s not refreshed before its interval", slow)
+		}
+	}
+
+	stats := serviceStats(t, dm, fast)
+	if stats["refresh_interval"] != interval.String() {
+		t.Errorf("Expected refresh_interval %v in stats, got %v", interval, stats["refresh_interval"])
+	}
+}
+
+func TestDiscoveryManagerTriggerRefreshUpdatesImmediately(t *testing.T) {
+	const name = "high-go-press-analytics"
+	discoverer := &fakeDiscoverer{
+		calls: make(chan string, 10),
+		instances: []*consul.ServiceInstance{
+			{ID: "analytics-1", Name: name, Address: "127.0.0.1", Port: 19002},
+		},
+	}
+	dm := newTestDiscoveryManagerWithConfig(t, discoverer, &DiscoveryConfig{RefreshInterval: time.Hour}, name)
+	go dm.watchService(dm.services[name])
+
+	if err := dm.TriggerRefresh(name); err != nil {
+		t.Fatalf("TriggerRefresh failed: %v", err)
+	}
+	waitForCall(t, discoverer.calls, name, time.Second)
+
+	// 刷新完成后实例可用，无需等待一小时的刷新周期
+	deadline := time.Now().Add(time.Second)
+	for {
+		if instances, _ := dm.GetServiceInstances(name); len(instances) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected instances after triggered refresh")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := dm.TriggerRefresh("unknown"); err == nil {
+		t.Error("Expected error triggering refresh of unregistered service")
+	}
+}
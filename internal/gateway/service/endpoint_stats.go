@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// endpointStats 单个后端实例的调用统计，按地址跨重连保留
+type endpointStats struct {
+	mutex        sync.Mutex
+	successes    int64
+	errors       int64
+	totalLatency time.Duration
+	lastError    string
+	lastErrorAt  time.Time
+}
+
+// record 记录一次调用结果，客户端主动取消不计入实例错误
+func (es *endpointStats) record(latency time.Duration, err error) {
+	if errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled {
+		return
+	}
+
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	es.totalLatency += latency
+	if err != nil {
+		es.errors++
+		es.lastError = err.Error()
+		es.lastErrorAt = time.Now()
+		return
+	}
+	es.successes++
+}
+
+// snapshot 导出统计快照
+func (es *endpointStats) snapshot() map[string]interface{} {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	total := es.successes + es.errors
+	stats := map[string]interface{}{
+		"success":        es.successes,
+		"errors":         es.errors,
+		"error_rate":     0.0,
+		"avg_latency_ms": 0.0,
+	}
+	if total > 0 {
+		stats["error_rate"] = float64(es.errors) / float64(total)
+		stats["avg_latency_ms"] = float64(es.totalLatency) / float64(total) / float64(time.Millisecond)
+	}
+	if es.lastError != "" {
+		stats["last_error"] = es.lastError
+		stats["last_error_at"] = es.lastErrorAt.Unix()
+	}
+	return stats
+}
+
+// endpointStatsFor 获取实例地址对应的统计，不存在时创建
+func (dm *DiscoveryManager) endpointStatsFor(address string) *endpointStats {
+	dm.endpointMux.Lock()
+	defer dm.endpointMux.Unlock()
+
+	stats, exists := dm.endpoints[address]
+	if !exists {
+		stats = &endpointStats{}
+		dm.endpoints[address] = stats
+	}
+	return stats
+}
+
+// endpointInterceptor 记录发往指定实例的一元调用结果和延迟
+func (dm *DiscoveryManager) endpointInterceptor(address string) grpc.UnaryClientInterceptor {
+	stats := dm.endpointStatsFor(address)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		stats.record(time.Since(start), err)
+		return err
+	}
+}
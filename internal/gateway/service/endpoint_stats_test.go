@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/consul"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// startHealthServer 启动只提供健康检查服务的gRPC后端
+func startHealthServer(t *testing.T) *net.TCPAddr {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().(*net.TCPAddr)
+}
+
+func TestDiscoveryStatsIncludePerInstanceCallStats(t *testing.T) {
+	const name = "high-go-press-counter"
+	addr := startHealthServer(t)
+	discoverer := &fakeDiscoverer{instances: []*consul.ServiceInstance{
+		{ID: "counter-1", Name: name, Address: "127.0.0.1", Port: addr.Port, Healthy: true},
+	}}
+	dm := newTestDiscoveryManager(t, discoverer, time.Hour, name)
+
+	if err := dm.updateService(name); err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	conn, err := dm.GetConnection(name)
+	if err != nil {
+		t.Fatalf("Expected connection, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	client := healthpb.NewHealthClient(conn)
+
+	// 两次成功，一次NotFound
+	for i := 0; i < 2; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("Health check failed: %v", err)
+		}
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"}); err == nil {
+		t.Fatal("Expected NotFound for unknown service")
+	}
+
+	endpoints, ok := serviceStats(t, dm, name)["endpoints"].([]map[string]interface{})
+	if !ok || len(endpoints) != 1 {
+		t.Fatalf("Expected 1 endpoint in stats, got %+v", serviceStats(t, dm, name)["endpoints"])
+	}
+	ep := endpoints[0]
+
+	if ep["id"] != "counter-1" || ep["address"] != addr.String() || ep["healthy"] != true {
+		t.Errorf("Expected instance identity in stats, got %+v", ep)
+	}
+	if ep["success"] != int64(2) || ep["errors"] != int64(1) {
+		t.Errorf("Expected 2 successes and 1 error, got %+v", ep)
+	}
+	if rate, _ := ep["error_rate"].(float64); rate < 0.33 || rate > 0.34 {
+		t.Errorf("Expected error_rate ~0.33, got %v", ep["error_rate"])
+	}
+	if latency, _ := ep["avg_latency_ms"].(float64); latency <= 0 {
+		t.Errorf("Expected positive avg_latency_ms, got %v", ep["avg_latency_ms"])
+	}
+	if lastErr, _ := ep["last_error"].(string); !strings.Contains(lastErr, "NotFound") {
+		t.Errorf("Expected last_error to contain NotFound, got %v", ep["last_error"])
+	}
+	if _, ok := ep["last_error_at"]; !ok {
+		t.Error("Expected last_error_at in stats")
+	}
+}
+
+func TestEndpointStatsIgnoreClientCancellation(t *testing.T) {
+	stats := &endpointStats{}
+	stats.record(time.Millisecond, context.Canceled)
+	stats.record(time.Millisecond, status.Error(codes.Canceled, "context canceled"))
+	stats.record(time.Millisecond, nil)
+
+	snapshot := stats.snapshot()
+	if snapshot["errors"] != int64(0) || snapshot["success"] != int64(1) {
+		t.Errorf("Expected client cancellation not counted, got %+v", snapshot)
+	}
+	if _, ok := snapshot["last_error"]; ok {
+		t.Errorf("Expected no last_error, got %+v", snapshot)
+	}
+}
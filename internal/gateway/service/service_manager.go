@@ -23,6 +23,14 @@ type ServiceManager struct {
 type Config struct {
 	// 服务发现配置
 	ConsulAddress string
+	// ConsulTimeout 单次Consul API调用超时
+	ConsulTimeout time.Duration
+	// DiscoveryStaleThreshold Consul不可用时保留上次发现结果的最长时间
+	DiscoveryStaleThreshold time.Duration
+	// DiscoveryRefreshInterval 服务实例默认刷新间隔
+	DiscoveryRefreshInterval time.Duration
+	// ServiceRefreshIntervals 按服务名覆盖的刷新间隔
+	ServiceRefreshIntervals map[string]time.Duration
 
 	// 连接配置
 	TimeoutDuration  time.Duration
@@ -39,14 +47,17 @@ type Config struct {
 // DefaultConfig 默认配置
 func DefaultConfig() *Config {
 	return &Config{
-		ConsulAddress:        "localhost:8500",
-		TimeoutDuration:      5 * time.Second,
-		MaxRecvMsgSize:       1024 * 1024 * 4, // 4MB
-		MaxSendMsgSize:       1024 * 1024 * 4, // 4MB
-		KeepAliveTime:        30 * time.Second,
-		KeepAliveTimeout:     5 * time.Second,
-		CounterServiceName:   "high-go-press-counter",
-		AnalyticsServiceName: "high-go-press-analytics",
+		ConsulAddress:            "localhost:8500",
+		ConsulTimeout:            consul.DefaultTimeout,
+		DiscoveryStaleThreshold:  DefaultDiscoveryConfig().StaleThreshold,
+		DiscoveryRefreshInterval: DefaultDiscoveryConfig().RefreshInterval,
+		TimeoutDuration:          5 * time.Second,
+		MaxRecvMsgSize:           1024 * 1024 * 4, // 4MB
+		MaxSendMsgSize:           1024 * 1024 * 4, // 4MB
+		KeepAliveTime:            30 * time.Second,
+		KeepAliveTimeout:         5 * time.Second,
+		CounterServiceName:       "high-go-press-counter",
+		AnalyticsServiceName:     "high-go-press-analytics",
 	}
 }
 
@@ -60,6 +71,7 @@ func NewServiceManager(config *Config, logger *zap.Logger) (*ServiceManager, err
 	consulConfig := &consul.Config{
 		Address: config.ConsulAddress,
 		Scheme:  "http",
+		Timeout: config.ConsulTimeout,
 	}
 
 	consulClient, err := consul.NewClient(consulConfig, logger)
@@ -68,7 +80,11 @@ func NewServiceManager(config *Config, logger *zap.Logger) (*ServiceManager, err
 	}
 
 	// 创建服务发现管理器
-	discoveryManager := NewDiscoveryManager(consulClient, logger)
+	discoveryManager := NewDiscoveryManager(consulClient, &DiscoveryConfig{
+		StaleThreshold:          config.DiscoveryStaleThreshold,
+		RefreshInterval:         config.DiscoveryRefreshInterval,
+		ServiceRefreshIntervals: config.ServiceRefreshIntervals,
+	}, logger)
 
 	// 注册需要发现的服务
 	if err := discoveryManager.RegisterService(config.CounterServiceName); err != nil {
@@ -151,6 +167,11 @@ func (sm *ServiceManager) GetCounterConnection() (*grpc.ClientConn, error) {
 	return sm.discoveryManager.GetConnection(sm.config.CounterServiceName)
 }
 
+// CounterReady Counter服务是否已通过服务发现建立连接
+func (sm *ServiceManager) CounterReady() bool {
+	return sm.discoveryManager.IsReady(sm.config.CounterServiceName)
+}
+
 // GetAnalyticsConnection 获取Analytics服务的gRPC连接
 func (sm *ServiceManager) GetAnalyticsConnection() (*grpc.ClientConn, error) {
 	return sm.discoveryManager.GetConnection(sm.config.AnalyticsServiceName)
@@ -161,6 +182,11 @@ func (sm *ServiceManager) GetServiceInstances(serviceName string) ([]*consul.Ser
 	return sm.discoveryManager.GetServiceInstances(serviceName)
 }
 
+// RefreshService 立即从Consul刷新指定服务的实例
+func (sm *ServiceManager) RefreshService(serviceName string) error {
+	return sm.discoveryManager.TriggerRefresh(serviceName)
+}
+
 // GetPoolStats 获取服务发现统计信息
 func (sm *ServiceManager) GetPoolStats() map[string]interface{} {
 	stats := sm.discoveryManager.GetStats()
@@ -174,6 +200,11 @@ func (sm *ServiceManager) GetPoolStats() map[string]interface{} {
 	return stats
 }
 
+// DiscoveryManager 服务发现管理器，用于注册统一统计
+func (sm *ServiceManager) DiscoveryManager() *DiscoveryManager {
+	return sm.discoveryManager
+}
+
 // GetDiscoveryStats 获取服务发现详细统计
 func (sm *ServiceManager) GetDiscoveryStats() map[string]interface{} {
 	return sm.discoveryManager.GetStats()
@@ -76,11 +76,13 @@ func (s *CounterService) IncrementCounter(req *biz.IncrementRequest) (*biz.Count
 	// 异步发送Kafka事件
 	go func() {
 		event := &kafka.CounterEvent{
-			EventID:     fmt.Sprintf("%s-%d", key, time.Now().UnixNano()),
+			EventID:     kafka.NewEventID(),
 			ResourceID:  req.ResourceID,
 			CounterType: req.CounterType,
 			Delta:       req.Delta,
 			NewValue:    newValue,
+			UserID:      req.UserID,
+			IP:          req.ClientIP,
 			Timestamp:   time.Now(),
 			Source:      "API",
 		}
@@ -145,19 +147,26 @@ func (s *CounterService) BatchGetCounters(req *biz.BatchRequest) (*biz.BatchResp
 
 	// 批量获取计数器值
 	ctx := context.Background()
-	counts, err := s.dao.GetMultiCounters(ctx, *keys)
+	counts, keyErrs, err := s.dao.GetMultiCountersPartial(ctx, *keys)
 	if err != nil {
 		s.logger.Error("Failed to batch get counters", zap.Error(err))
 		return nil, err
 	}
 
-	// 构建响应
+	// 构建响应，读取失败的计数器不返回
 	results := make([]biz.Counter, 0, len(req.Items))
 	for _, key := range *keys {
 		item := itemToKey[key]
 		if item == nil {
 			continue
 		}
+		if keyErr, failed := keyErrs[key]; failed {
+			s.logger.Warn("Skipping counter that failed in batch get",
+				zap.String("resource_id", item.ResourceID),
+				zap.String("counter_type", string(item.CounterType)),
+				zap.Error(keyErr))
+			continue
+		}
 
 		value := counts[key] // 如果key不存在，会返回0值
 		counter := *biz.NewCounter(item.ResourceID, item.CounterType, value)
@@ -170,20 +179,21 @@ func (s *CounterService) BatchGetCounters(req *biz.BatchRequest) (*biz.BatchResp
 	}, nil
 }
 
+// GetHotRank 从Redis ZSET读取当前时间桶内的热点排行，period为空时按天统计
 func (s *CounterService) GetHotRank(ctx context.Context, query *biz.HotRankQuery) ([]*biz.HotRankItem, error) {
-	// 注意：这是一个简化版的热点排行实现
-	// 在真实场景中，应该使用Redis的ZSET或专门的排行榜数据结构
-	// 这里为了演示，我们暂时返回空列表
-
-	logger.Info("GetHotRank called",
-		zap.String("counter_type", string(query.CounterType)),
-		zap.Int("limit", query.Limit),
-		zap.String("period", query.Period))
-
-	// TODO: 实现真正的热点排行逻辑
-	// 1. 可以使用Redis ZSET存储排行榜数据
-	// 2. 或者通过定时任务计算热点数据并缓存
-	// 3. 这里先返回空数组
+	period := query.Period
+	if period == "" {
+		period = dao.HotRankPeriodDay
+	}
 
-	return []*biz.HotRankItem{}, nil
+	items, err := s.dao.GetHotRank(ctx, string(query.CounterType), period, query.Limit)
+	if err != nil {
+		logger.Error("Failed to get hot rank",
+			zap.String("counter_type", string(query.CounterType)),
+			zap.Int("limit", query.Limit),
+			zap.String("period", period),
+			zap.Error(err))
+		return nil, err
+	}
+	return items, nil
 }
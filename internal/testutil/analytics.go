@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"testing"
+
+	pb "high-go-press/api/proto/analytics"
+	"high-go-press/internal/analytics/dao"
+	"high-go-press/internal/analytics/server"
+
+	"google.golang.org/grpc"
+)
+
+// AnalyticsHarness Analytics服务的内存测试环境
+type AnalyticsHarness struct {
+	*GRPCHarness
+	Client  pb.AnalyticsServiceClient
+	Server  *server.AnalyticsServer
+	Options *Options
+}
+
+// StartAnalyticsServer 启动带完整拦截器链的Analytics内存服务器，analyticsDAO为nil时使用内存DAO
+func StartAnalyticsServer(t testing.TB, analyticsDAO dao.AnalyticsDAO, opts *Options) *AnalyticsHarness {
+	t.Helper()
+
+	if analyticsDAO == nil {
+		analyticsDAO = dao.NewMemoryAnalyticsDAO()
+	}
+	opts = opts.withDefaults()
+
+	analyticsServer := server.NewAnalyticsServer(analyticsDAO, nil, opts.Logger)
+
+	h := NewGRPCHarness(t, func(s *grpc.Server) {
+		pb.RegisterAnalyticsServiceServer(s, analyticsServer)
+	}, opts.serverOptions("analytics")...)
+
+	return &AnalyticsHarness{
+		GRPCHarness: h,
+		Client:      pb.NewAnalyticsServiceClient(h.Conn),
+		Server:      analyticsServer,
+		Options:     opts,
+	}
+}
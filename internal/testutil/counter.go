@@ -0,0 +1,56 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/biz"
+	"high-go-press/internal/counter/server"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/pool"
+
+	"google.golang.org/grpc"
+)
+
+// CounterHarness Counter服务的内存测试环境
+type CounterHarness struct {
+	*GRPCHarness
+	Client   counter.CounterServiceClient
+	Server   *server.CounterServer
+	Producer *kafka.MockProducer
+	Options  *Options
+}
+
+// StartCounterServer 启动带完整拦截器链的Counter内存服务器，repo为nil时使用daotest.MemoryCounterRepo
+func StartCounterServer(t testing.TB, repo biz.CounterRepo, opts *Options) *CounterHarness {
+	t.Helper()
+
+	if repo == nil {
+		repo = daotest.NewMemoryCounterRepo()
+	}
+	opts = opts.withDefaults()
+
+	workerPool, err := pool.NewWorkerPool(opts.Logger)
+	if err != nil {
+		t.Fatalf("Failed to create worker pool: %v", err)
+	}
+	t.Cleanup(func() { workerPool.Shutdown(context.Background()) })
+
+	producer := kafka.NewMockProducer(opts.Logger)
+	counterServer := server.NewCounterServer(repo, workerPool, pool.NewObjectPool(), producer, server.DefaultConfig(), opts.Logger)
+	counterServer.SetMetricsManager(opts.Metrics)
+
+	h := NewGRPCHarness(t, func(s *grpc.Server) {
+		counter.RegisterCounterServiceServer(s, counterServer)
+	}, opts.serverOptions("counter")...)
+
+	return &CounterHarness{
+		GRPCHarness: h,
+		Client:      counter.NewCounterServiceClient(h.Conn),
+		Server:      counterServer,
+		Producer:    producer,
+		Options:     opts,
+	}
+}
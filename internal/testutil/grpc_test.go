@@ -0,0 +1,93 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	pb "high-go-press/api/proto/analytics"
+	"high-go-press/api/proto/counter"
+	"high-go-press/internal/analytics/dao"
+	"high-go-press/internal/dao/daotest"
+	"high-go-press/pkg/middleware"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCounterServerThroughInterceptors(t *testing.T) {
+	repo := daotest.NewMemoryCounterRepo()
+	h := StartCounterServer(t, repo, &Options{Tenant: &middleware.TenantConfig{Enabled: true}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tenantCtx := metadata.AppendToOutgoingContext(ctx, middleware.TenantMetadataKey, "acme")
+
+	resp, err := h.Client.IncrementCounter(tenantCtx, &counter.IncrementRequest{
+		ResourceId:  "article_1",
+		CounterType: "like",
+		Delta:       3,
+	})
+	if err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+	if resp.CurrentValue != 3 {
+		t.Errorf("Expected current value 3, got %d", resp.CurrentValue)
+	}
+
+	// 租户拦截器生效时key带租户前缀
+	if got := repo.Value("acme:counter:article_1:like"); got != 3 {
+		t.Errorf("Expected tenant-scoped key to hold 3, got %d", got)
+	}
+
+	// 开启租户校验后缺少tenant-id被拒绝
+	_, err = h.Client.IncrementCounter(ctx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 1})
+	if status.Code(err) == codes.OK {
+		t.Errorf("Expected request without tenant-id to be rejected")
+	}
+
+	// 超大元数据被元数据限制拦截器拒绝
+	oversizedCtx := metadata.AppendToOutgoingContext(tenantCtx, "x-padding", strings.Repeat("a", 16*1024))
+	_, err = h.Client.IncrementCounter(oversizedCtx, &counter.IncrementRequest{ResourceId: "article_1", CounterType: "like", Delta: 1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for oversized metadata, got %v", err)
+	}
+
+	// 元数据限制位于指标拦截器之前，被拒绝的请求不计入
+	if got := h.Options.Metrics.GetRequestTotals("counter").GRPCRequests; got != 2 {
+		t.Errorf("Expected 2 gRPC requests recorded, got %d", got)
+	}
+}
+
+func TestAnalyticsServerThroughInterceptors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 内存DAO默认只返回真实累积的数据
+	analyticsDAO := dao.NewMemoryAnalyticsDAO()
+	for i := 1; i <= 4; i++ {
+		if err := analyticsDAO.UpdateCounterStats(ctx, fmt.Sprintf("article_%d", i), "like", int64(i)); err != nil {
+			t.Fatalf("UpdateCounterStats failed: %v", err)
+		}
+	}
+	h := StartAnalyticsServer(t, analyticsDAO, nil)
+
+	resp, err := h.Client.GetTopCounters(ctx, &pb.TopCountersRequest{CounterType: "like", Limit: 3, TimeRange: "24h"})
+	if err != nil {
+		t.Fatalf("GetTopCounters failed: %v", err)
+	}
+	if code := resp.GetStatus().GetCode(); code != int32(codes.OK) {
+		t.Fatalf("Expected OK status, got %d: %s", code, resp.GetStatus().GetMessage())
+	}
+	if len(resp.Counters) != 3 {
+		t.Errorf("Expected 3 counters, got %d", len(resp.Counters))
+	}
+
+	totals := h.Options.Metrics.GetRequestTotals("analytics")
+	if totals.GRPCRequests != 1 || totals.GRPCErrors != 0 {
+		t.Errorf("Expected 1 successful gRPC request recorded, got %+v", totals)
+	}
+}
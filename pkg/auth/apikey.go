@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// DefaultAPIKeyHeader 默认携带API Key的请求头/元数据键
+const DefaultAPIKeyHeader = "x-api-key"
+
+// APIKey 单个API Key及其对应的调用方
+type APIKey struct {
+	Key      string // API Key明文
+	Subject  string // 调用方标识
+	TenantID string // 调用方所属租户，可为空
+	Tier     string // 调用方的配额等级，可为空
+}
+
+// APIKeyConfig API Key认证配置
+type APIKeyConfig struct {
+	Header string   // 携带API Key的请求头/元数据键，为空时使用x-api-key
+	Keys   []APIKey // 允许的API Key
+}
+
+// APIKeyAuthenticator 基于静态API Key的认证提供者
+// 只保存Key的SHA-256摘要，按摘要查找调用方
+type APIKeyAuthenticator struct {
+	header     string
+	identities map[[sha256.Size]byte]Identity
+}
+
+// NewAPIKeyAuthenticator 创建API Key认证提供者
+func NewAPIKeyAuthenticator(config *APIKeyConfig) (*APIKeyAuthenticator, error) {
+	if config == nil || len(config.Keys) == 0 {
+		return nil, errors.New("auth: api_key provider requires at least one key")
+	}
+
+	header := config.Header
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	identities := make(map[[sha256.Size]byte]Identity, len(config.Keys))
+	for i, key := range config.Keys {
+		if key.Key == "" || key.Subject == "" {
+			return nil, fmt.Errorf("auth: api key %d requires key and subject", i)
+		}
+		digest := sha256.Sum256([]byte(key.Key))
+		if _, exists := identities[digest]; exists {
+			return nil, fmt.Errorf("auth: duplicate api key for subject %s", key.Subject)
+		}
+		identity := Identity{
+			Subject:  key.Subject,
+			Provider: ProviderAPIKey,
+			TenantID: key.TenantID,
+		}
+		if key.Tier != "" {
+			identity.Attributes = map[string]string{"tier": key.Tier}
+		}
+		identities[digest] = identity
+	}
+
+	return &APIKeyAuthenticator{header: header, identities: identities}, nil
+}
+
+// Authenticate 校验请求携带的API Key
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context) (Identity, error) {
+	key := credentialFromContext(ctx, a.header)
+	if key == "" {
+		return Identity{}, fmt.Errorf("%w: %s", ErrMissingCredentials, a.header)
+	}
+
+	identity, ok := a.identities[sha256.Sum256([]byte(key))]
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: unknown api key", ErrInvalidCredentials)
+	}
+	return identity, nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"high-go-press/pkg/config"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func withMetadata(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func newTestAPIKeyAuthenticator(t *testing.T, header string) *APIKeyAuthenticator {
+	t.Helper()
+	authenticator, err := NewAPIKeyAuthenticator(&APIKeyConfig{
+		Header: header,
+		Keys: []APIKey{
+			{Key: "key-frontend", Subject: "web-frontend"},
+			{Key: "key-acme", Subject: "acme-batch", TenantID: "acme"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator failed: %v", err)
+	}
+	return authenticator
+}
+
+func TestAPIKeyAuthenticatorValidKey(t *testing.T) {
+	authenticator := newTestAPIKeyAuthenticator(t, "")
+
+	identity, err := authenticator.Authenticate(withMetadata(DefaultAPIKeyHeader, "key-acme"))
+	if err != nil {
+		t.Fatalf("Expected valid key to authenticate, got %v", err)
+	}
+	if identity.Subject != "acme-batch" || identity.TenantID != "acme" || identity.Provider != ProviderAPIKey {
+		t.Errorf("Unexpected identity: %+v", identity)
+	}
+}
+
+func TestAPIKeyAuthenticatorRejects(t *testing.T) {
+	authenticator := newTestAPIKeyAuthenticator(t, "")
+
+	// 未携带凭证
+	for _, ctx := range []context.Context{context.Background(), withMetadata("other", "x"), withMetadata(DefaultAPIKeyHeader, "  ")} {
+		if _, err := authenticator.Authenticate(ctx); !errors.Is(err, ErrMissingCredentials) {
+			t.Errorf("Expected ErrMissingCredentials, got %v", err)
+		}
+	}
+
+	// 未知的Key
+	if _, err := authenticator.Authenticate(withMetadata(DefaultAPIKeyHeader, "key-unknown")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestAPIKeyAuthenticatorCustomHeader(t *testing.T) {
+	authenticator := newTestAPIKeyAuthenticator(t, "X-Service-Key")
+
+	if _, err := authenticator.Authenticate(withMetadata("x-service-key", "key-frontend")); err != nil {
+		t.Errorf("Expected custom header to be read case-insensitively, got %v", err)
+	}
+	if _, err := authenticator.Authenticate(withMetadata(DefaultAPIKeyHeader, "key-frontend")); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("Expected default header ignored when custom header configured, got %v", err)
+	}
+}
+
+func TestNewAPIKeyAuthenticatorInvalidConfig(t *testing.T) {
+	configs := map[string]*APIKeyConfig{
+		"nil":       nil,
+		"no keys":   {},
+		"empty key": {Keys: []APIKey{{Subject: "web"}}},
+		"duplicate": {Keys: []APIKey{{Key: "k", Subject: "a"}, {Key: "k", Subject: "b"}}},
+	}
+	for name, cfg := range configs {
+		if _, err := NewAPIKeyAuthenticator(cfg); err == nil {
+			t.Errorf("%s: expected config error", name)
+		}
+	}
+}
+
+func TestNewAuthenticatorFromConfig(t *testing.T) {
+	cfg := &config.AuthConfig{
+		Provider: ProviderAPIKey,
+		APIKey: config.APIKeyAuthConfig{
+			Keys: []config.APIKeyEntry{{Key: "key-frontend", Subject: "web-frontend"}},
+		},
+	}
+	authenticator, err := NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("NewAuthenticator failed: %v", err)
+	}
+	identity, err := authenticator.Authenticate(withMetadata(DefaultAPIKeyHeader, "key-frontend"))
+	if err != nil || identity.Subject != "web-frontend" {
+		t.Errorf("Expected web-frontend identity, got %+v, %v", identity, err)
+	}
+
+	if _, err := NewAuthenticator(&config.AuthConfig{Provider: "oauth"}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"high-go-press/pkg/config"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// 认证方式
+const (
+	ProviderAPIKey = "api_key"
+	ProviderJWT    = "jwt"
+)
+
+var (
+	// ErrMissingCredentials 请求未携带凭证
+	ErrMissingCredentials = errors.New("auth: missing credentials")
+	// ErrInvalidCredentials 凭证无效
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+	// ErrUnknownProvider 未知的认证方式
+	ErrUnknownProvider = errors.New("auth: unknown provider")
+)
+
+// Identity 认证通过后的调用方身份
+type Identity struct {
+	Subject    string            // 调用方标识，限流、审计等按此区分调用方
+	Provider   string            // 认证方式，如api_key
+	TenantID   string            // 调用方所属租户，可为空
+	Attributes map[string]string // 认证方式提供的其他属性
+}
+
+// Authenticator 认证提供者
+//
+// 凭证统一从ctx的gRPC incoming metadata中读取：gRPC拦截器直接使用请求元数据，
+// HTTP中间件将请求头转换为元数据，因此同一个实现可同时用于Gateway和gRPC服务。
+type Authenticator interface {
+	Authenticate(ctx context.Context) (Identity, error)
+}
+
+// AuthenticatorFunc 函数形式的Authenticator
+type AuthenticatorFunc func(ctx context.Context) (Identity, error)
+
+// Authenticate 调用函数本身
+func (f AuthenticatorFunc) Authenticate(ctx context.Context) (Identity, error) {
+	return f(ctx)
+}
+
+// NewAuthenticator 按应用配置创建认证提供者
+func NewAuthenticator(cfg *config.AuthConfig) (Authenticator, error) {
+	switch cfg.Provider {
+	case ProviderAPIKey:
+		keys := make([]APIKey, 0, len(cfg.APIKey.Keys))
+		for _, entry := range cfg.APIKey.Keys {
+			keys = append(keys, APIKey{Key: entry.Key, Subject: entry.Subject, TenantID: entry.TenantID, Tier: entry.Tier})
+		}
+		return NewAPIKeyAuthenticator(&APIKeyConfig{Header: cfg.APIKey.Header, Keys: keys})
+	case ProviderJWT:
+		return NewJWTAuthenticator(&JWTConfig{
+			Algorithm:           cfg.JWT.Algorithm,
+			SigningKey:          cfg.JWT.SigningKey,
+			JWKSURL:             cfg.JWT.JWKSURL,
+			JWKSRefreshInterval: cfg.JWT.JWKSRefreshInterval,
+			Issuer:              cfg.JWT.Issuer,
+			Audience:            cfg.JWT.Audience,
+			Header:              cfg.JWT.Header,
+			SubjectClaim:        cfg.JWT.SubjectClaim,
+			TenantClaim:         cfg.JWT.TenantClaim,
+			ClockSkew:           cfg.JWT.ClockSkew,
+		})
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+}
+
+// identityContextKey 调用方身份的context键
+type identityContextKey struct{}
+
+// WithIdentity 将调用方身份写入context
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext 从context获取调用方身份
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(Identity)
+	return identity, ok
+}
+
+// credentialFromContext 读取incoming metadata中的凭证，key不区分大小写
+func credentialFromContext(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
@@ -0,0 +1,435 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// JWT签名算法
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+const (
+	// DefaultJWTHeader 默认携带JWT的请求头/元数据键，值为"Bearer <token>"
+	DefaultJWTHeader = "authorization"
+	// defaultJWKSRefreshInterval JWKS默认刷新间隔
+	defaultJWKSRefreshInterval = 10 * time.Minute
+	// jwksMinRefreshInterval 遇到未知kid时重新拉取JWKS的最小间隔，避免伪造kid放大请求
+	jwksMinRefreshInterval = 30 * time.Second
+	// jwksInitialBackoff JWKS拉取失败后的首次重试间隔，连续失败时翻倍
+	jwksInitialBackoff = time.Second
+	// jwksMaxBackoff JWKS拉取失败后的最大重试间隔
+	jwksMaxBackoff = 5 * time.Minute
+)
+
+var (
+	// ErrTokenExpired 令牌已过期
+	ErrTokenExpired = fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	// ErrTokenAudience 令牌的受众不匹配
+	ErrTokenAudience = fmt.Errorf("%w: token audience mismatch", ErrInvalidCredentials)
+	// ErrTokenIssuer 令牌的签发者不匹配
+	ErrTokenIssuer = fmt.Errorf("%w: token issuer mismatch", ErrInvalidCredentials)
+	// ErrTokenSignature 令牌签名校验失败
+	ErrTokenSignature = fmt.Errorf("%w: token signature invalid", ErrInvalidCredentials)
+)
+
+// JWTConfig JWT认证配置
+type JWTConfig struct {
+	Algorithm           string        // HS256或RS256，令牌头中的alg必须与此一致
+	SigningKey          string        // HS256为共享密钥；RS256为PEM格式公钥，配置JWKSURL时可为空
+	JWKSURL             string        // RS256按令牌kid从JWKS获取公钥
+	JWKSRefreshInterval time.Duration // JWKS刷新间隔
+	Issuer              string        // 必须匹配的iss
+	Audience            string        // aud中必须包含的受众
+	Header              string        // 携带令牌的请求头/元数据键
+	SubjectClaim        string        // 作为Identity.Subject的claim，默认sub
+	TenantClaim         string        // 作为Identity.TenantID的claim，默认tenant_id
+	ClockSkew           time.Duration // 校验exp/nbf时允许的时钟偏差
+	HTTPClient          *http.Client  // 拉取JWKS使用的客户端
+}
+
+// jwtHeader 令牌头
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// JWTAuthenticator 校验签名JWT的认证提供者
+type JWTAuthenticator struct {
+	config    JWTConfig
+	secret    []byte
+	publicKey *rsa.PublicKey
+	jwks      *jwksCache
+	now       func() time.Time
+}
+
+// NewJWTAuthenticator 创建JWT认证提供者
+func NewJWTAuthenticator(config *JWTConfig) (*JWTAuthenticator, error) {
+	if config == nil {
+		return nil, errors.New("auth: jwt provider requires config")
+	}
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, errors.New("auth: jwt provider requires issuer and audience")
+	}
+
+	cfg := *config
+	if cfg.Header == "" {
+		cfg.Header = DefaultJWTHeader
+	}
+	if cfg.SubjectClaim == "" {
+		cfg.SubjectClaim = "sub"
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant_id"
+	}
+
+	a := &JWTAuthenticator{config: cfg, now: time.Now}
+	switch cfg.Algorithm {
+	case AlgorithmHS256:
+		if cfg.SigningKey == "" {
+			return nil, errors.New("auth: jwt HS256 requires signing key")
+		}
+		a.secret = []byte(cfg.SigningKey)
+	case AlgorithmRS256:
+		switch {
+		case cfg.SigningKey != "":
+			publicKey, err := parseRSAPublicKey(cfg.SigningKey)
+			if err != nil {
+				return nil, err
+			}
+			a.publicKey = publicKey
+		case cfg.JWKSURL != "":
+			a.jwks = newJWKSCache(cfg.JWKSURL, cfg.JWKSRefreshInterval, cfg.HTTPClient)
+		default:
+			return nil, errors.New("auth: jwt RS256 requires signing key or jwks url")
+		}
+	default:
+		return nil, fmt.Errorf("auth: unsupported jwt algorithm %q", cfg.Algorithm)
+	}
+	return a, nil
+}
+
+// Authenticate 校验令牌签名、有效期、签发者和受众，并将claims写入调用方身份
+func (a *JWTAuthenticator) Authenticate(ctx context.Context) (Identity, error) {
+	token := credentialFromContext(ctx, a.config.Header)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	if token == "" {
+		return Identity{}, fmt.Errorf("%w: %s", ErrMissingCredentials, a.config.Header)
+	}
+
+	claims, err := a.verify(ctx, token)
+	if err != nil {
+		return Identity{}, err
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return Identity{}, err
+	}
+
+	subject, _ := claims[a.config.SubjectClaim].(string)
+	if subject == "" {
+		return Identity{}, fmt.Errorf("%w: missing %s claim", ErrInvalidCredentials, a.config.SubjectClaim)
+	}
+	tenantID, _ := claims[a.config.TenantClaim].(string)
+
+	return Identity{
+		Subject:    subject,
+		Provider:   ProviderJWT,
+		TenantID:   tenantID,
+		Attributes: claimAttributes(claims),
+	}, nil
+}
+
+// verify 校验令牌签名并返回claims
+func (a *JWTAuthenticator) verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	// 只接受配置的算法，防止alg=none或算法混淆攻击
+	if header.Alg != a.config.Algorithm {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidCredentials, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	signed := parts[0] + "." + parts[1]
+
+	switch a.config.Algorithm {
+	case AlgorithmHS256:
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, ErrTokenSignature
+		}
+	case AlgorithmRS256:
+		publicKey := a.publicKey
+		if a.jwks != nil {
+			if publicKey, err = a.jwks.key(ctx, header.Kid); err != nil {
+				return nil, err
+			}
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, ErrTokenSignature
+		}
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// validateClaims 校验有效期、签发者和受众
+func (a *JWTAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := a.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidCredentials)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(a.config.ClockSkew)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.config.ClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidCredentials)
+	}
+
+	if issuer, _ := claims["iss"].(string); issuer != a.config.Issuer {
+		return ErrTokenIssuer
+	}
+	if !audienceContains(claims["aud"], a.config.Audience) {
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+// audienceContains aud可以是字符串或字符串数组
+func audienceContains(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimAttributes 将标量claim和字符串数组claim转换为身份属性
+func claimAttributes(claims map[string]interface{}) map[string]string {
+	attributes := make(map[string]string, len(claims))
+	for name, value := range claims {
+		switch v := value.(type) {
+		case string:
+			attributes[name] = v
+		case float64:
+			attributes[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			attributes[name] = strconv.FormatBool(v)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					items = append(items, s)
+				}
+			}
+			attributes[name] = strings.Join(items, ",")
+		}
+	}
+	return attributes
+}
+
+// decodeSegment 解码base64url编码的JSON段
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed token segment", ErrInvalidCredentials)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed token segment", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// parseRSAPublicKey 解析PEM格式的RSA公钥（PKIX或PKCS1）
+func parseRSAPublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("auth: jwt signing key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("auth: parse jwt public key: %w", err)
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("auth: jwt public key is not RSA")
+	}
+	return publicKey, nil
+}
+
+// jwksCache 缓存JWKS中的RSA公钥，按间隔刷新
+// 拉取在锁外进行，并发请求通过singleflight合并为一次HTTP请求；拉取失败按指数退避，退避期内不再请求
+type jwksCache struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+	group    singleflight.Group
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	failures  int       // 连续拉取失败次数
+	retryAt   time.Time // 退避结束时间，之前不再拉取
+	lastErr   error     // 最近一次拉取失败的错误
+}
+
+func newJWKSCache(url string, interval time.Duration, client *http.Client) *jwksCache {
+	if interval <= 0 {
+		interval = defaultJWKSRefreshInterval
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &jwksCache{url: url, interval: interval, client: client}
+}
+
+// key 获取kid对应的公钥，缓存过期或遇到未知kid时重新拉取
+// 拉取失败时继续使用已缓存的公钥，没有缓存时返回拉取错误
+func (c *jwksCache) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	keys, fetchedAt, retryAt, lastErr := c.keys, c.fetchedAt, c.retryAt, c.lastErr
+	c.mu.Unlock()
+
+	age := time.Since(fetchedAt)
+	_, known := keys[kid]
+	if keys == nil || age > c.interval || (!known && age > jwksMinRefreshInterval) {
+		if time.Now().Before(retryAt) {
+			if keys == nil {
+				return nil, lastErr
+			}
+		} else if refreshed, err := c.refresh(ctx); err == nil {
+			keys = refreshed
+		} else if keys == nil {
+			return nil, err
+		}
+	}
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidCredentials, kid)
+	}
+	return key, nil
+}
+
+// refresh 合并并发的拉取请求，拉取结果在锁内更新缓存或退避状态
+// 拉取不继承调用方的取消，避免一个请求取消导致共享同一次拉取的其他请求失败
+func (c *jwksCache) refresh(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	v, err, _ := c.group.Do(c.url, func() (interface{}, error) {
+		keys, err := c.fetch(context.WithoutCancel(ctx))
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err != nil {
+			c.failures++
+			backoff := jwksInitialBackoff << (c.failures - 1)
+			if backoff <= 0 || backoff > jwksMaxBackoff {
+				backoff = jwksMaxBackoff
+			}
+			c.retryAt = time.Now().Add(backoff)
+			c.lastErr = err
+			return nil, err
+		}
+		c.keys = keys
+		c.fetchedAt = time.Now()
+		c.failures = 0
+		c.retryAt = time.Time{}
+		c.lastErr = nil
+		return keys, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]*rsa.PublicKey), nil
+}
+
+// fetch 拉取并解析JWKS中的RSA公钥
+func (c *jwksCache) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("auth: build jwks request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth: fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth: fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("auth: decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
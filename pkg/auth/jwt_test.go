@@ -0,0 +1,338 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-hs256-secret"
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signHS256 生成HS256令牌
+func signHS256(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegment(t, map[string]string{"alg": AlgorithmHS256, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 生成RS256令牌
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegment(t, map[string]string{"alg": AlgorithmRS256, "typ": "JWT", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":       "user-42",
+		"iss":       "https://auth.example.com",
+		"aud":       []string{"high-go-press", "other"},
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"tenant_id": "acme",
+		"scope":     "counter:write",
+	}
+}
+
+func newHS256Authenticator(t *testing.T) *JWTAuthenticator {
+	t.Helper()
+	authenticator, err := NewJWTAuthenticator(&JWTConfig{
+		Algorithm:  AlgorithmHS256,
+		SigningKey: testSecret,
+		Issuer:     "https://auth.example.com",
+		Audience:   "high-go-press",
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator failed: %v", err)
+	}
+	return authenticator
+}
+
+func bearer(token string) context.Context {
+	return withMetadata(DefaultJWTHeader, "Bearer "+token)
+}
+
+func TestJWTAuthenticatorValidToken(t *testing.T) {
+	authenticator := newHS256Authenticator(t)
+
+	identity, err := authenticator.Authenticate(bearer(signHS256(t, testSecret, validClaims())))
+	if err != nil {
+		t.Fatalf("Expected valid token to authenticate, got %v", err)
+	}
+	if identity.Subject != "user-42" || identity.TenantID != "acme" || identity.Provider != ProviderJWT {
+		t.Errorf("Unexpected identity: %+v", identity)
+	}
+	// claims写入身份属性
+	if identity.Attributes["scope"] != "counter:write" || identity.Attributes["aud"] != "high-go-press,other" {
+		t.Errorf("Expected claims in identity attributes, got %v", identity.Attributes)
+	}
+}
+
+func TestJWTAuthenticatorExpiredToken(t *testing.T) {
+	authenticator := newHS256Authenticator(t)
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err := authenticator.Authenticate(bearer(signHS256(t, testSecret, claims)))
+	if !errors.Is(err, ErrTokenExpired) || !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+
+	// 时钟偏差内仍然有效
+	authenticator.config.ClockSkew = 2 * time.Minute
+	if _, err := authenticator.Authenticate(bearer(signHS256(t, testSecret, claims))); err != nil {
+		t.Errorf("Expected token within clock skew to pass, got %v", err)
+	}
+}
+
+func TestJWTAuthenticatorWrongAudienceAndIssuer(t *testing.T) {
+	authenticator := newHS256Authenticator(t)
+
+	claims := validClaims()
+	claims["aud"] = "another-service"
+	if _, err := authenticator.Authenticate(bearer(signHS256(t, testSecret, claims))); !errors.Is(err, ErrTokenAudience) {
+		t.Errorf("Expected ErrTokenAudience, got %v", err)
+	}
+
+	claims = validClaims()
+	claims["iss"] = "https://evil.example.com"
+	if _, err := authenticator.Authenticate(bearer(signHS256(t, testSecret, claims))); !errors.Is(err, ErrTokenIssuer) {
+		t.Errorf("Expected ErrTokenIssuer, got %v", err)
+	}
+}
+
+func TestJWTAuthenticatorRejectsBadTokens(t *testing.T) {
+	authenticator := newHS256Authenticator(t)
+
+	if _, err := authenticator.Authenticate(context.Background()); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("Expected ErrMissingCredentials, got %v", err)
+	}
+	if _, err := authenticator.Authenticate(bearer(signHS256(t, "other-secret", validClaims()))); !errors.Is(err, ErrTokenSignature) {
+		t.Errorf("Expected ErrTokenSignature for wrong secret, got %v", err)
+	}
+	if _, err := authenticator.Authenticate(bearer("not-a-token")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for malformed token, got %v", err)
+	}
+
+	// 配置HS256时拒绝其他算法的令牌
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	if _, err := authenticator.Authenticate(bearer(signRS256(t, key, "k1", validClaims()))); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected algorithm mismatch to be rejected, got %v", err)
+	}
+}
+
+func TestJWTAuthenticatorRS256PublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey failed: %v", err)
+	}
+
+	authenticator, err := NewJWTAuthenticator(&JWTConfig{
+		Algorithm:  AlgorithmRS256,
+		SigningKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		Issuer:     "https://auth.example.com",
+		Audience:   "high-go-press",
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator failed: %v", err)
+	}
+
+	if _, err := authenticator.Authenticate(bearer(signRS256(t, key, "", validClaims()))); err != nil {
+		t.Errorf("Expected RS256 token to authenticate, got %v", err)
+	}
+}
+
+func TestJWTAuthenticatorRS256JWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	var fetches int32
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+
+	authenticator, err := NewJWTAuthenticator(&JWTConfig{
+		Algorithm: AlgorithmRS256,
+		JWKSURL:   jwks.URL,
+		Issuer:    "https://auth.example.com",
+		Audience:  "high-go-press",
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := authenticator.Authenticate(bearer(signRS256(t, key, "k1", validClaims()))); err != nil {
+			t.Fatalf("Expected JWKS token to authenticate, got %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("Expected JWKS fetched once and cached, got %d fetches", got)
+	}
+
+	// 未知kid在最小刷新间隔内不会重新拉取
+	if _, err := authenticator.Authenticate(bearer(signRS256(t, key, "k2", validClaims()))); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected unknown kid rejected, got %v", err)
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("Expected no refetch for unknown kid within min interval, got %d fetches", got)
+	}
+}
+
+// jwksBody 只包含一个RSA公钥的JWKS
+func jwksBody(key *rsa.PrivateKey, kid string) map[string]interface{} {
+	return map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+}
+
+func TestJWKSCacheConcurrentFetchesShareOneRequest(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	var fetches int32
+	release := make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		json.NewEncoder(w).Encode(jwksBody(key, "k1"))
+	}))
+	defer jwks.Close()
+
+	cache := newJWKSCache(jwks.URL, time.Minute, nil)
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.key(context.Background(), "k1")
+			errs <- err
+		}()
+	}
+	// 拉取进行中时缓存的锁未被占用
+	time.Sleep(50 * time.Millisecond)
+	if !cache.mu.TryLock() {
+		t.Fatal("Expected cache lock to be free while fetching")
+	}
+	cache.mu.Unlock()
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected key lookup to succeed, got %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("Expected concurrent lookups to share one fetch, got %d fetches", got)
+	}
+}
+
+func TestJWKSCacheBacksOffAfterFailure(t *testing.T) {
+	var fetches int32
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer jwks.Close()
+
+	cache := newJWKSCache(jwks.URL, time.Minute, nil)
+	ctx := context.Background()
+	if _, err := cache.key(ctx, "k1"); err == nil {
+		t.Fatal("Expected fetch error")
+	}
+
+	// 退避期内直接返回上次的错误，不再请求JWKS
+	for i := 0; i < 5; i++ {
+		if _, err := cache.key(ctx, "k1"); err == nil {
+			t.Fatal("Expected cached fetch error during backoff")
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("Expected no refetch during backoff, got %d fetches", got)
+	}
+
+	// 连续失败时退避时间翻倍
+	cache.mu.Lock()
+	cache.retryAt = time.Time{}
+	cache.mu.Unlock()
+	before := time.Now()
+	cache.key(ctx, "k1")
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if backoff := cache.retryAt.Sub(before); backoff < 2*jwksInitialBackoff || backoff > 3*jwksInitialBackoff {
+		t.Errorf("Expected backoff about %v after second failure, got %v", 2*jwksInitialBackoff, backoff)
+	}
+}
+
+func TestNewJWTAuthenticatorInvalidConfig(t *testing.T) {
+	configs := map[string]*JWTConfig{
+		"no issuer":       {Algorithm: AlgorithmHS256, SigningKey: "s", Audience: "a"},
+		"no secret":       {Algorithm: AlgorithmHS256, Issuer: "i", Audience: "a"},
+		"no rs256 key":    {Algorithm: AlgorithmRS256, Issuer: "i", Audience: "a"},
+		"bad pem":         {Algorithm: AlgorithmRS256, SigningKey: "not pem", Issuer: "i", Audience: "a"},
+		"unsupported alg": {Algorithm: "none", Issuer: "i", Audience: "a"},
+	}
+	for name, cfg := range configs {
+		if _, err := NewJWTAuthenticator(cfg); err == nil {
+			t.Errorf("%s: expected config error", name)
+		}
+	}
+}
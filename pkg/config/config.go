@@ -3,9 +3,11 @@ package config
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/viper"
@@ -24,6 +26,67 @@ type Config struct {
 	Log         LogConfig        `mapstructure:"log"`
 	Monitoring  MonitoringConfig `mapstructure:"monitoring"`
 	Resilience  ResilienceConfig `mapstructure:"resilience"`
+	Tenancy     TenancyConfig    `mapstructure:"tenancy"`
+	Auth        AuthConfig       `mapstructure:"auth"`
+	Quota       QuotaConfig      `mapstructure:"quota"`
+}
+
+// QuotaConfig 按调用方身份的每日增量配额配置，需开启认证
+type QuotaConfig struct {
+	Enabled     bool                 `mapstructure:"enabled"`
+	DefaultTier string               `mapstructure:"default_tier"` // 身份未指定等级时使用的等级
+	Tiers       map[string]int64     `mapstructure:"tiers"`        // 等级 -> 每日增量次数上限，0表示不限制
+	Subjects    []QuotaSubjectConfig `mapstructure:"subjects"`     // 为指定调用方设置等级
+	Timezone    string               `mapstructure:"timezone"`     // 按该时区计算自然日
+}
+
+// QuotaSubjectConfig 调用方的配额等级
+type QuotaSubjectConfig struct {
+	Subject string `mapstructure:"subject"`
+	Tier    string `mapstructure:"tier"`
+}
+
+// AuthConfig 认证配置，Gateway HTTP中间件和gRPC服务拦截器共用
+type AuthConfig struct {
+	Enabled       bool             `mapstructure:"enabled"`
+	Provider      string           `mapstructure:"provider" validate:"omitempty,oneof=api_key jwt"` // 认证方式
+	SkipPaths     []string         `mapstructure:"skip_paths"`                                      // Gateway不需要认证的HTTP路径
+	AdminSubjects []string         `mapstructure:"admin_subjects"`                                  // 允许调用Counter管理接口的调用方Subject，只在counter.auth开启时检查
+	APIKey        APIKeyAuthConfig `mapstructure:"api_key"`
+	JWT           JWTAuthConfig    `mapstructure:"jwt"`
+}
+
+// JWTAuthConfig JWT认证配置
+type JWTAuthConfig struct {
+	Algorithm           string        `mapstructure:"algorithm" validate:"omitempty,oneof=HS256 RS256"`
+	SigningKey          string        `mapstructure:"signing_key" sensitive:"true"` // HS256共享密钥或RS256 PEM公钥
+	JWKSURL             string        `mapstructure:"jwks_url"`                     // RS256从JWKS按kid获取公钥
+	JWKSRefreshInterval time.Duration `mapstructure:"jwks_refresh_interval"`        // JWKS刷新间隔
+	Issuer              string        `mapstructure:"issuer"`                       // 必须匹配的iss
+	Audience            string        `mapstructure:"audience"`                     // aud中必须包含的受众
+	Header              string        `mapstructure:"header"`                       // 携带令牌的请求头，值为"Bearer <token>"
+	SubjectClaim        string        `mapstructure:"subject_claim"`                // 作为调用方标识的claim
+	TenantClaim         string        `mapstructure:"tenant_claim"`                 // 作为租户ID的claim
+	ClockSkew           time.Duration `mapstructure:"clock_skew"`                   // 允许的时钟偏差
+}
+
+// APIKeyAuthConfig API Key认证配置
+type APIKeyAuthConfig struct {
+	Header string        `mapstructure:"header"` // 携带API Key的请求头/元数据键
+	Keys   []APIKeyEntry `mapstructure:"keys"`
+}
+
+// APIKeyEntry 单个API Key及其对应的调用方
+type APIKeyEntry struct {
+	Key      string `mapstructure:"key" sensitive:"true"`
+	Subject  string `mapstructure:"subject"`
+	TenantID string `mapstructure:"tenant_id"`
+	Tier     string `mapstructure:"tier"` // 配额等级
+}
+
+// TenancyConfig 多租户配置
+type TenancyConfig struct {
+	Enabled bool `mapstructure:"enabled"` // 开启后gRPC请求必须携带tenant-id元数据
 }
 
 // GatewayConfig Gateway服务配置
@@ -32,56 +95,128 @@ type GatewayConfig struct {
 	GRPC     GRPCConfig     `mapstructure:"grpc"`
 	Timeout  TimeoutConfig  `mapstructure:"timeout"`
 	Security SecurityConfig `mapstructure:"security"`
+	Startup  StartupConfig  `mapstructure:"startup"`
+	Response ResponseConfig `mapstructure:"response"`
+}
+
+// ResponseConfig Gateway响应编码配置
+type ResponseConfig struct {
+	// Encoding json: 网关自定义响应结构；protojson: 直接以protojson编码gRPC响应消息。
+	// 请求Accept为application/x-protojson时总是返回protojson
+	Encoding string `mapstructure:"encoding" validate:"omitempty,oneof=json protojson"`
+}
+
+// StartupConfig Gateway启动就绪配置
+type StartupConfig struct {
+	WaitForServices bool          `mapstructure:"wait_for_services"` // 开启后Counter服务就绪前除/livez外的请求返回503
+	ReadyTimeout    time.Duration `mapstructure:"ready_timeout"`     // 最长等待时间，超时后放行全部路由
 }
 
 // CounterConfig Counter服务配置
 type CounterConfig struct {
-	Server      ServerConfig      `mapstructure:"server"`
-	GRPC        GRPCConfig        `mapstructure:"grpc"`
-	Performance PerformanceConfig `mapstructure:"performance"`
+	Server       ServerConfig      `mapstructure:"server"`
+	GRPC         GRPCConfig        `mapstructure:"grpc"`
+	Performance  PerformanceConfig `mapstructure:"performance"`
+	DualWrite    DualWriteConfig   `mapstructure:"dual_write"`
+	Delta        DeltaConfig       `mapstructure:"delta"`
+	Leaderboards []LeaderboardSpec `mapstructure:"leaderboards"` // 维护排行榜的计数器类型，未配置的类型不写排行榜
+
+	// HotRankPeriods 每次增量后维护热点排行的时间范围（hour、day、week），为空时不维护热点排行
+	// 每个时间范围每次增量多一次ZINCRBY
+	HotRankPeriods []string `mapstructure:"hot_rank_periods"`
+
+	// Auth gRPC服务端的认证和配额，与Gateway的开关相互独立
+	Auth CounterAuthConfig `mapstructure:"auth"`
+}
+
+// CounterAuthConfig Counter gRPC服务的认证和配额开关，认证提供者和配额等级沿用auth、quota配置
+// Gateway调用Counter时不转发调用方凭证，且已在HTTP层扣除配额，因此默认关闭，只在调用方直连gRPC时开启
+type CounterAuthConfig struct {
+	Enabled bool `mapstructure:"enabled"` // 校验直连调用方的凭证
+	Quota   bool `mapstructure:"quota"`   // 对增量写入扣除每日配额，需开启enabled
 }
 
 // AnalyticsConfig Analytics服务配置
 type AnalyticsConfig struct {
-	Server ServerConfig `mapstructure:"server"`
-	GRPC   GRPCConfig   `mapstructure:"grpc"`
-	Cache  CacheConfig  `mapstructure:"cache"`
+	Server      ServerConfig      `mapstructure:"server"`
+	GRPC        GRPCConfig        `mapstructure:"grpc"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	Aggregation AggregationConfig `mapstructure:"aggregation"`
+	Preload     PreloadConfig     `mapstructure:"preload"`
+	MockData    bool              `mapstructure:"mock_data"` // 内存DAO返回固定的模拟数据而不是真实累积的数据，仅用于本地开发演示
+}
+
+// PreloadConfig Analytics排行榜缓存预加载配置
+type PreloadConfig struct {
+	CounterTypes    []string      `mapstructure:"counter_types"`    // 启动时预加载排行榜的计数器类型
+	TimeRanges      []string      `mapstructure:"time_ranges"`      // 预加载的时间范围，""表示未指定
+	Limit           int           `mapstructure:"limit"`            // 预加载的排行榜条数
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // 定期刷新间隔
+}
+
+// AggregationConfig Analytics事件聚合配置
+type AggregationConfig struct {
+	Strategy      string        `mapstructure:"strategy"`        // raw, rate_limited, windowed
+	Window        time.Duration `mapstructure:"window"`          // windowed策略的聚合窗口
+	RatePerSecond int           `mapstructure:"rate_per_second"` // rate_limited策略每个key每秒最多写入次数
 }
 
 // DiscoveryConfig 服务发现配置
 type DiscoveryConfig struct {
-	Type   string       `mapstructure:"type" validate:"required,oneof=consul static"`
-	Consul ConsulConfig `mapstructure:"consul"`
+	Type            string                            `mapstructure:"type" validate:"required,oneof=consul static"`
+	Consul          ConsulConfig                      `mapstructure:"consul"`
+	StaleThreshold  time.Duration                     `mapstructure:"stale_threshold"`  // Consul不可用时保留上次发现结果的最长时间，0表示不清空
+	RefreshInterval time.Duration                     `mapstructure:"refresh_interval"` // 服务实例默认刷新间隔
+	Services        map[string]ServiceDiscoveryConfig `mapstructure:"services"`         // 按服务名配置的发现参数
+}
+
+// ServiceDiscoveryConfig 单个服务的发现配置
+type ServiceDiscoveryConfig struct {
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // 该服务的刷新间隔，0表示使用默认值
 }
 
 // ConsulConfig Consul配置
 type ConsulConfig struct {
 	Address string        `mapstructure:"address" validate:"required"`
 	Scheme  string        `mapstructure:"scheme" validate:"oneof=http https"`
-	Token   string        `mapstructure:"token"`
+	Token   string        `mapstructure:"token" sensitive:"true"`
 	Timeout time.Duration `mapstructure:"timeout"`
+
+	HistoryLimit int `mapstructure:"history_limit"` // 配置中心每个服务和环境保留的历史版本数，<=0时使用默认值
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Host string `mapstructure:"host" validate:"required"`
-	Port int    `mapstructure:"port" validate:"min=1,max=65535"`
-	Mode string `mapstructure:"mode" validate:"oneof=debug release test"`
+	Host           string   `mapstructure:"host" validate:"required"`
+	Port           int      `mapstructure:"port" validate:"min=1,max=65535"`
+	Mode           string   `mapstructure:"mode" validate:"oneof=debug release test"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // 可信代理的IP或CIDR，只采用来自可信代理的X-Forwarded-For和转发的客户端IP
 }
 
 // GRPCConfig gRPC配置
 type GRPCConfig struct {
-	MaxRecvMsgSize int                  `mapstructure:"max_recv_msg_size"`
-	MaxSendMsgSize int                  `mapstructure:"max_send_msg_size"`
-	MaxConnections int                  `mapstructure:"max_connections"`
-	KeepAlive      KeepAliveConfig      `mapstructure:"keep_alive"`
-	ConnectionPool ConnectionPoolConfig `mapstructure:"connection_pool"`
+	MaxRecvMsgSize  int                  `mapstructure:"max_recv_msg_size"`
+	MaxSendMsgSize  int                  `mapstructure:"max_send_msg_size"`
+	MaxResponseSize int                  `mapstructure:"max_response_size"` // 单个响应序列化后的字节数上限，0表示只记录不限制
+	MaxConnections  int                  `mapstructure:"max_connections"`
+	KeepAlive       KeepAliveConfig      `mapstructure:"keep_alive"`
+	ConnectionPool  ConnectionPoolConfig `mapstructure:"connection_pool"`
+	Metadata        MetadataConfig       `mapstructure:"metadata"`
+}
+
+// MetadataConfig gRPC请求元数据限制配置
+type MetadataConfig struct {
+	MaxSize         int      `mapstructure:"max_size"`         // 元数据总字节数上限，0表示不限制
+	DisallowedKeys  []string `mapstructure:"disallowed_keys"`  // 禁止携带的元数据键
+	StripDisallowed bool     `mapstructure:"strip_disallowed"` // 移除禁止的键而不是拒绝请求
 }
 
 // KeepAliveConfig Keep-Alive配置
 type KeepAliveConfig struct {
-	Time    time.Duration `mapstructure:"time"`
-	Timeout time.Duration `mapstructure:"timeout"`
+	Time                time.Duration `mapstructure:"time"`
+	Timeout             time.Duration `mapstructure:"timeout"`
+	MinTime             time.Duration `mapstructure:"min_time"`              // 服务端允许的客户端最小ping间隔
+	PermitWithoutStream bool          `mapstructure:"permit_without_stream"` // 服务端允许客户端无活跃流时ping
 }
 
 // ConnectionPoolConfig 连接池配置
@@ -123,6 +258,37 @@ type PerformanceConfig struct {
 	WorkerPoolSize    int  `mapstructure:"worker_pool_size"`
 	ObjectPoolEnabled bool `mapstructure:"object_pool_enabled"`
 	BatchSize         int  `mapstructure:"batch_size"`
+	BatchConcurrency  int  `mapstructure:"batch_concurrency"` // 同步批量处理的最大并发数
+
+	CounterTTL time.Duration `mapstructure:"counter_ttl"` // 计数器首次写入后的过期时间，0表示永不过期
+}
+
+// DeltaConfig 计数器增量配置
+type DeltaConfig struct {
+	Default DeltaLimitConfig            `mapstructure:"default"` // 未单独配置的计数器类型使用的限制
+	Types   map[string]DeltaLimitConfig `mapstructure:"types"`   // 按计数器类型配置的限制
+
+	ClampBatchDecrements bool `mapstructure:"clamp_batch_decrements"` // 批量操作中的负增量是否截断在0
+}
+
+// DeltaLimitConfig 单个计数器类型的增量限制
+type DeltaLimitConfig struct {
+	DefaultDelta int64 `mapstructure:"default_delta"` // 请求未指定增量时使用的默认值
+	MaxDelta     int64 `mapstructure:"max_delta"`     // 单次增量绝对值上限，0表示不限制
+}
+
+// LeaderboardSpec 单个计数器类型的排行榜配置
+type LeaderboardSpec struct {
+	CounterType string          `mapstructure:"counter_type"` // 计数器类型
+	Windows     []time.Duration `mapstructure:"windows"`      // 时间窗口排行榜，按窗口分桶；总榜始终维护
+}
+
+// DualWriteConfig 双写配置（存储迁移期间同时写入新旧存储并比对）
+type DualWriteConfig struct {
+	Enabled              bool        `mapstructure:"enabled"`
+	Secondary            RedisConfig `mapstructure:"secondary" validate:"-"` // 仅在开启双写时校验，见validate
+	CompareReads         bool        `mapstructure:"compare_reads"`
+	FailOnSecondaryError bool        `mapstructure:"fail_on_secondary_error"`
 }
 
 // CacheConfig 缓存配置
@@ -132,10 +298,19 @@ type CacheConfig struct {
 	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
 }
 
+// Redis部署模式
+const (
+	RedisModeStandalone = "standalone" // 单实例
+	RedisModeCluster    = "cluster"    // Redis Cluster
+	RedisModeSentinel   = "sentinel"   // 哨兵主从
+)
+
 // RedisConfig Redis配置
 type RedisConfig struct {
-	Address      string        `mapstructure:"address" validate:"required"`
-	Password     string        `mapstructure:"password"`
+	// Mode 部署模式：standalone、cluster、sentinel，为空时按standalone处理
+	Mode         string        `mapstructure:"mode" validate:"omitempty,oneof=standalone cluster sentinel"`
+	Address      string        `mapstructure:"address"` // standalone模式的实例地址
+	Password     string        `mapstructure:"password" sensitive:"true"`
 	DB           int           `mapstructure:"db"`
 	PoolSize     int           `mapstructure:"pool_size"`
 	MinIdleConns int           `mapstructure:"min_idle_conns"`
@@ -143,6 +318,25 @@ type RedisConfig struct {
 	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// Addresses cluster模式为集群节点地址，sentinel模式为哨兵地址
+	Addresses []string `mapstructure:"addresses"`
+	// MasterName sentinel模式下哨兵监控的主节点名称
+	MasterName string `mapstructure:"master_name"`
+	// SentinelPassword 哨兵自身的认证密码，为空时不认证
+	SentinelPassword string `mapstructure:"sentinel_password" sensitive:"true"`
+
+	// Shards 分片实例列表，配置后按一致性哈希将计数器分布到多个独立实例
+	Shards []RedisShardConfig `mapstructure:"shards"`
+}
+
+// RedisShardConfig Redis分片实例配置
+type RedisShardConfig struct {
+	Name     string `mapstructure:"name"`
+	Address  string `mapstructure:"address"`
+	Password string `mapstructure:"password" sensitive:"true"`
+	DB       int    `mapstructure:"db"`
+	Weight   int    `mapstructure:"weight"`
 }
 
 // KafkaConfig Kafka配置
@@ -152,19 +346,64 @@ type KafkaConfig struct {
 	Topic    string         `mapstructure:"topic"`
 	Producer ProducerConfig `mapstructure:"producer"`
 	Consumer ConsumerConfig `mapstructure:"consumer"`
+
+	FallbackToMock        bool          `mapstructure:"fallback_to_mock"`        // real模式连接失败时降级运行并定期重试
+	FallbackRetryInterval time.Duration `mapstructure:"fallback_retry_interval"` // 降级期间重连真实Kafka的间隔
+
+	// Security 生产者和消费者共用的TLS/SASL认证配置
+	Security KafkaSecurityConfig `mapstructure:"security"`
+}
+
+// KafkaSecurityConfig Kafka TLS/SASL认证配置
+type KafkaSecurityConfig struct {
+	TLS  KafkaTLSConfig  `mapstructure:"tls"`
+	SASL KafkaSASLConfig `mapstructure:"sasl"`
+}
+
+// KafkaTLSConfig Kafka TLS配置，ca_file为空时使用系统根证书
+type KafkaTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// KafkaSASLConfig Kafka SASL配置，mechanism为空时不认证
+type KafkaSASLConfig struct {
+	Mechanism string `mapstructure:"mechanism" validate:"omitempty,oneof=PLAIN SCRAM-SHA-256 SCRAM-SHA-512"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password" sensitive:"true"`
 }
 
 // ProducerConfig Kafka生产者配置
 type ProducerConfig struct {
-	BatchSize    int `mapstructure:"batch_size"`
-	LingerMs     int `mapstructure:"linger_ms"`
-	BufferMemory int `mapstructure:"buffer_memory"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	LingerMs     int           `mapstructure:"linger_ms"`
+	BufferMemory int           `mapstructure:"buffer_memory"`
+	SendTimeout  time.Duration `mapstructure:"send_timeout"`                          // 单条事件发送超时
+	QueueSize    *int          `mapstructure:"queue_size" validate:"omitempty,min=0"` // 待发送事件队列容量，队列满时丢弃最旧的事件；0表示不使用队列，未设置时使用默认容量
 }
 
 // ConsumerConfig Kafka消费者配置
 type ConsumerConfig struct {
-	GroupID         string `mapstructure:"group_id"`
-	AutoOffsetReset string `mapstructure:"auto_offset_reset"`
+	GroupID         string        `mapstructure:"group_id"`
+	AutoOffsetReset string        `mapstructure:"auto_offset_reset"`
+	ProcessingMode  string        `mapstructure:"processing_mode" validate:"oneof=at_least_once effectively_once"`
+	DrainTimeout    time.Duration `mapstructure:"drain_timeout"`     // 关闭时等待在途消息处理完成的最长时间
+	CommitBatchSize int           `mapstructure:"commit_batch_size"` // 每N条消息提交一次offset，0表示不按条数
+	CommitInterval  time.Duration `mapstructure:"commit_interval"`   // 每T时间提交一次offset，0表示不按时间
+
+	PartitionConcurrency int `mapstructure:"partition_concurrency"` // 每个分区按key并发处理的worker数，不大于1时顺序处理
+
+	DedupeStore string `mapstructure:"dedupe_store" validate:"oneof=memory redis"` // effectively_once去重记录存储，多实例热备时使用redis共享
+
+	DeadLetterTopic    string `mapstructure:"dead_letter_topic"`    // 重试耗尽的消息发送到的死信主题，为空时直接跳过
+	MaxProcessAttempts int    `mapstructure:"max_process_attempts"` // 每条消息最多处理次数（含首次）
+
+	LagReportInterval time.Duration `mapstructure:"lag_report_interval"` // 上报kafka_consumer_lag指标的间隔
+
+	EnableAutoCommit bool `mapstructure:"enable_auto_commit"` // false时只在处理成功或进入死信主题后提交offset，失败时暂停分区
 }
 
 // LogConfig 日志配置
@@ -190,6 +429,15 @@ type MonitoringConfig struct {
 	HealthCheck HealthCheckConfig `mapstructure:"health_check"`
 	Metrics     MetricsConfig     `mapstructure:"metrics"`
 	System      SystemConfig      `mapstructure:"system"`
+	LoadScore   LoadScoreConfig   `mapstructure:"load_score"`
+}
+
+// LoadScoreConfig 自动扩缩容负载评分配置
+type LoadScoreConfig struct {
+	MaxInFlight       float64 `mapstructure:"max_in_flight"`      // 视为满载的在途请求数
+	InFlightWeight    float64 `mapstructure:"in_flight_weight"`   // 在途请求的权重
+	UtilizationWeight float64 `mapstructure:"utilization_weight"` // 工作池利用率的权重
+	ErrorRateWeight   float64 `mapstructure:"error_rate_weight"`  // 错误率的权重
 }
 
 // PprofConfig Pprof配置
@@ -223,7 +471,7 @@ type HealthCheckConfig struct {
 
 // MetricsConfig 指标配置
 type MetricsConfig struct {
-	HTTP     MetricTypeConfig `mapstructure:"http"`
+	HTTP     HTTPMetricConfig `mapstructure:"http"`
 	GRPC     MetricTypeConfig `mapstructure:"grpc"`
 	Business MetricTypeConfig `mapstructure:"business"`
 	Database MetricTypeConfig `mapstructure:"database"`
@@ -236,6 +484,12 @@ type MetricTypeConfig struct {
 	Buckets []float64 `mapstructure:"buckets"`
 }
 
+// HTTPMetricConfig HTTP指标配置
+type HTTPMetricConfig struct {
+	MetricTypeConfig `mapstructure:",squash"`
+	ExcludePaths     []string `mapstructure:"exclude_paths"` // 不采集指标的路径，如/metrics、/health
+}
+
 // SystemConfig 系统指标配置
 type SystemConfig struct {
 	Enabled            bool          `mapstructure:"enabled"`
@@ -281,6 +535,7 @@ type FallbackConfig struct {
 	Enabled           bool                    `mapstructure:"enabled"`
 	Strategy          string                  `mapstructure:"strategy"`
 	CacheTTL          time.Duration           `mapstructure:"cache_ttl"`
+	CacheMaxEntries   int                     `mapstructure:"cache_max_entries" validate:"min=0"` // 降级缓存最多保留的条目数，超出时淘汰最久未使用的，0表示使用默认值
 	Timeout           time.Duration           `mapstructure:"timeout"`
 	TriggerConditions []FallbackConditionConf `mapstructure:"trigger_conditions"`
 }
@@ -300,13 +555,20 @@ type ErrorHandlingConfig struct {
 	LogLevel           string        `mapstructure:"log_level"`
 }
 
+// DriftObserver 配置漂移观察者，drifted表示生效配置与本地文件是否不一致
+type DriftObserver func(drifted bool, fields []string)
+
 // Manager 配置管理器
 type Manager struct {
-	config       *Config
-	logger       *zap.Logger
-	configCenter ConfigCenter
-	watchers     []ConfigChangeCallback
-	mutex        sync.RWMutex
+	config        *Config
+	logger        *zap.Logger
+	configCenter  ConfigCenter
+	watchers      []ConfigChangeCallback
+	eventWatchers []ConfigChangeEventCallback
+	configPath    string
+	drift         []string
+	driftObserver atomic.Pointer[DriftObserver] // 不依赖mutex读写，观察者可在任意时刻设置
+	mutex         sync.RWMutex
 }
 
 // NewManager 创建配置管理器
@@ -333,6 +595,58 @@ func (m *Manager) SetConfigCenter(configCenter ConfigCenter) {
 	m.configCenter = configCenter
 }
 
+// SetDriftObserver 设置配置漂移观察者（如上报config_drift指标）
+// 配置已加载时立即以当前漂移状态通知一次，加载后才设置的观察者也能拿到初始状态
+func (m *Manager) SetDriftObserver(observer DriftObserver) {
+	if observer == nil {
+		m.driftObserver.Store(nil)
+		return
+	}
+	m.driftObserver.Store(&observer)
+
+	m.mutex.RLock()
+	loaded := m.config != nil
+	fields := append([]string(nil), m.drift...)
+	m.mutex.RUnlock()
+	if loaded {
+		observer(len(fields) > 0, fields)
+	}
+}
+
+// GetDrift 获取生效配置与本地文件存在差异的字段
+func (m *Manager) GetDrift() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return append([]string(nil), m.drift...)
+}
+
+// resolveCenterConfig 将配置中心的配置逐字段合并到本地文件配置之上，并检查漂移
+// 配置中心未设置的字段（零值）沿用文件/默认值
+func (m *Manager) resolveCenterConfig(centerConfig *Config) *Config {
+	fileConfig, err := m.loadFromFile(m.configPath)
+	if err != nil {
+		m.logger.Warn("Failed to load config file, using center config as-is", zap.Error(err))
+		return centerConfig
+	}
+
+	merged := MergeConfig(fileConfig, centerConfig)
+	m.reportDrift(DiffConfig(merged, fileConfig))
+	return merged
+}
+
+// reportDrift 记录配置漂移结果，调用方需持有m.mutex写锁
+func (m *Manager) reportDrift(fields []string) {
+	m.drift = fields
+	if len(fields) > 0 {
+		m.logger.Warn("Active config differs from config file",
+			zap.Strings("fields", fields))
+	}
+
+	if observer := m.driftObserver.Load(); observer != nil {
+		(*observer)(len(fields) > 0, fields)
+	}
+}
+
 // Load 加载配置
 func (m *Manager) Load(configPath string) (*Config, error) {
 	return m.LoadWithServiceInfo(configPath, "", "")
@@ -343,6 +657,8 @@ func (m *Manager) LoadWithServiceInfo(configPath, serviceName, environment strin
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	m.configPath = configPath
+
 	var config *Config
 	var err error
 
@@ -361,6 +677,7 @@ func (m *Manager) LoadWithServiceInfo(configPath, serviceName, environment strin
 			m.logger.Info("Config loaded from config center",
 				zap.String("service", serviceName),
 				zap.String("environment", environment))
+			config = m.resolveCenterConfig(config)
 			m.config = config
 			return config, nil
 		}
@@ -373,6 +690,7 @@ func (m *Manager) LoadWithServiceInfo(configPath, serviceName, environment strin
 	}
 
 	m.config = config
+	m.reportDrift(nil)
 
 	// 如果配置中心可用且服务信息完整，推送配置到配置中心
 	if m.configCenter != nil && serviceName != "" && environment != "" {
@@ -396,6 +714,25 @@ func (m *Manager) LoadWithServiceInfo(configPath, serviceName, environment strin
 
 // loadFromFile 从文件加载配置
 func (m *Manager) loadFromFile(configPath string) (*Config, error) {
+	config, err := m.readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证配置：结构体标签校验和语义校验
+	if err := m.ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	m.logger.Info("Configuration loaded successfully",
+		zap.String("environment", config.Environment),
+		zap.String("config_file", viper.ConfigFileUsed()))
+
+	return config, nil
+}
+
+// readConfigFile 读取并解析配置文件（含默认值和环境变量），不做校验
+func (m *Manager) readConfigFile(configPath string) (*Config, error) {
 	// 设置环境变量前缀
 	viper.SetEnvPrefix("HIGH_GO_PRESS")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -436,15 +773,6 @@ func (m *Manager) loadFromFile(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// 验证配置
-	if err := m.validate(&config); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-
-	m.logger.Info("Configuration loaded successfully",
-		zap.String("environment", config.Environment),
-		zap.String("config_file", viper.ConfigFileUsed()))
-
 	return &config, nil
 }
 
@@ -452,6 +780,21 @@ func (m *Manager) loadFromFile(configPath string) (*Config, error) {
 func (m *Manager) setDefaults() {
 	// 环境设置
 	viper.SetDefault("environment", "dev")
+	viper.SetDefault("tenancy.enabled", false)
+	viper.SetDefault("auth.enabled", false)
+	viper.SetDefault("auth.provider", "api_key")
+	viper.SetDefault("auth.skip_paths", []string{"/livez", "/metrics", "/api/v1/health", "/api/v1/ready"})
+	viper.SetDefault("auth.api_key.header", "x-api-key")
+	viper.SetDefault("auth.jwt.algorithm", "RS256")
+	viper.SetDefault("auth.jwt.jwks_refresh_interval", "10m")
+	viper.SetDefault("auth.jwt.header", "authorization")
+	viper.SetDefault("auth.jwt.subject_claim", "sub")
+	viper.SetDefault("auth.jwt.tenant_claim", "tenant_id")
+	viper.SetDefault("auth.jwt.clock_skew", "30s")
+	viper.SetDefault("quota.enabled", false)
+	viper.SetDefault("quota.default_tier", "default")
+	viper.SetDefault("quota.tiers", map[string]int64{"default": 100000})
+	viper.SetDefault("quota.timezone", "UTC")
 
 	// Gateway默认值
 	viper.SetDefault("gateway.server.host", "0.0.0.0")
@@ -462,6 +805,9 @@ func (m *Manager) setDefaults() {
 	viper.SetDefault("gateway.timeout.idle", "120s")
 	viper.SetDefault("gateway.timeout.grpc", "5s")
 	viper.SetDefault("gateway.security.rate_limit.enabled", false)
+	viper.SetDefault("gateway.startup.wait_for_services", false)
+	viper.SetDefault("gateway.startup.ready_timeout", "30s")
+	viper.SetDefault("gateway.response.encoding", "json")
 	viper.SetDefault("gateway.security.cors.enabled", true)
 
 	// Counter服务默认值
@@ -473,28 +819,62 @@ func (m *Manager) setDefaults() {
 	viper.SetDefault("counter.grpc.max_connections", 1000)
 	viper.SetDefault("counter.grpc.keep_alive.time", "60s")
 	viper.SetDefault("counter.grpc.keep_alive.timeout", "10s")
+	viper.SetDefault("counter.grpc.keep_alive.min_time", "5s")
+	viper.SetDefault("counter.grpc.keep_alive.permit_without_stream", true)
 	viper.SetDefault("counter.grpc.connection_pool.size", 20)
 	viper.SetDefault("counter.grpc.connection_pool.max_idle_time", "300s")
+	viper.SetDefault("counter.grpc.metadata.max_size", 8192)
+	viper.SetDefault("counter.auth.enabled", false)
+	viper.SetDefault("counter.auth.quota", false)
 	viper.SetDefault("counter.performance.worker_pool_size", 1000)
 	viper.SetDefault("counter.performance.object_pool_enabled", true)
 	viper.SetDefault("counter.performance.batch_size", 100)
+	viper.SetDefault("counter.performance.batch_concurrency", 10)
+	viper.SetDefault("counter.performance.counter_ttl", 0)
+	viper.SetDefault("counter.dual_write.enabled", false)
+	viper.SetDefault("counter.dual_write.compare_reads", true)
+	viper.SetDefault("counter.dual_write.fail_on_secondary_error", false)
+	viper.SetDefault("counter.delta.default.default_delta", 1)
+	viper.SetDefault("counter.delta.default.max_delta", 10000)
 
 	// Analytics服务默认值
 	viper.SetDefault("analytics.server.host", "0.0.0.0")
+	viper.SetDefault("analytics.mock_data", false)
 	viper.SetDefault("analytics.server.port", 9002)
 	viper.SetDefault("analytics.server.mode", "debug")
 	viper.SetDefault("analytics.grpc.max_recv_msg_size", 4194304) // 4MB
 	viper.SetDefault("analytics.grpc.max_send_msg_size", 4194304) // 4MB
+	viper.SetDefault("analytics.grpc.metadata.max_size", 8192)
+	viper.SetDefault("analytics.grpc.keep_alive.time", "60s")
+	viper.SetDefault("analytics.grpc.keep_alive.timeout", "10s")
+	viper.SetDefault("analytics.grpc.keep_alive.min_time", "5s")
+	viper.SetDefault("analytics.grpc.keep_alive.permit_without_stream", true)
 	viper.SetDefault("analytics.cache.ttl", "300s")
 	viper.SetDefault("analytics.cache.max_size", 10000)
+	viper.SetDefault("analytics.aggregation.strategy", "raw")
+	viper.SetDefault("analytics.aggregation.window", "1s")
+	viper.SetDefault("analytics.aggregation.rate_per_second", 10)
+	viper.SetDefault("analytics.preload.counter_types", []string{"like", "view"})
+	viper.SetDefault("analytics.preload.time_ranges", []string{"", "24h"})
+	viper.SetDefault("analytics.preload.limit", 10)
+	viper.SetDefault("analytics.preload.refresh_interval", "30s")
 
 	// 服务发现默认值
 	viper.SetDefault("discovery.type", "consul")
 	viper.SetDefault("discovery.consul.address", "localhost:8500")
 	viper.SetDefault("discovery.consul.scheme", "http")
 	viper.SetDefault("discovery.consul.timeout", "10s")
+	viper.SetDefault("discovery.consul.history_limit", 20)
+	viper.SetDefault("discovery.stale_threshold", "5m")
+
+	// 降级默认值
+	viper.SetDefault("resilience.fallback.strategy", "cache")
+	viper.SetDefault("resilience.fallback.cache_ttl", "5m")
+	viper.SetDefault("resilience.fallback.cache_max_entries", 10000)
+	viper.SetDefault("discovery.refresh_interval", "30s")
 
 	// Redis默认值
+	viper.SetDefault("redis.mode", RedisModeStandalone)
 	viper.SetDefault("redis.address", "localhost:6379")
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
@@ -509,11 +889,27 @@ func (m *Manager) setDefaults() {
 	viper.SetDefault("kafka.mode", "mock")
 	viper.SetDefault("kafka.brokers", []string{"localhost:9092"})
 	viper.SetDefault("kafka.topic", "counter-events")
+	viper.SetDefault("kafka.fallback_to_mock", false)
+	viper.SetDefault("kafka.fallback_retry_interval", "30s")
+	viper.SetDefault("kafka.security.tls.enabled", false)
+	viper.SetDefault("kafka.security.sasl.mechanism", "")
 	viper.SetDefault("kafka.producer.batch_size", 16384)
 	viper.SetDefault("kafka.producer.linger_ms", 10)
 	viper.SetDefault("kafka.producer.buffer_memory", 33554432)
+	viper.SetDefault("kafka.producer.send_timeout", "3s")
+	viper.SetDefault("kafka.producer.queue_size", 10000)
 	viper.SetDefault("kafka.consumer.group_id", "high_go_press_analytics")
 	viper.SetDefault("kafka.consumer.auto_offset_reset", "earliest")
+	viper.SetDefault("kafka.consumer.processing_mode", "at_least_once")
+	viper.SetDefault("kafka.consumer.drain_timeout", "10s")
+	viper.SetDefault("kafka.consumer.commit_batch_size", 0)
+	viper.SetDefault("kafka.consumer.commit_interval", "0s")
+	viper.SetDefault("kafka.consumer.partition_concurrency", 1)
+	viper.SetDefault("kafka.consumer.dedupe_store", "memory")
+	viper.SetDefault("kafka.consumer.dead_letter_topic", "counter-events.dlq")
+	viper.SetDefault("kafka.consumer.max_process_attempts", 3)
+	viper.SetDefault("kafka.consumer.lag_report_interval", "15s")
+	viper.SetDefault("kafka.consumer.enable_auto_commit", true)
 
 	// 日志默认值
 	viper.SetDefault("log.level", "info")
@@ -528,6 +924,11 @@ func (m *Manager) setDefaults() {
 	viper.SetDefault("monitoring.prometheus.path", "/metrics")
 	viper.SetDefault("monitoring.health_check.port", 8090)
 	viper.SetDefault("monitoring.health_check.path", "/health")
+	viper.SetDefault("monitoring.metrics.http.exclude_paths", []string{"/metrics", "/health"})
+	viper.SetDefault("monitoring.load_score.max_in_flight", 1000)
+	viper.SetDefault("monitoring.load_score.in_flight_weight", 0.4)
+	viper.SetDefault("monitoring.load_score.utilization_weight", 0.4)
+	viper.SetDefault("monitoring.load_score.error_rate_weight", 0.2)
 }
 
 // validate 验证配置
@@ -551,15 +952,231 @@ func (m *Manager) validate(config *Config) error {
 	}
 
 	// Redis连接验证
-	if config.Redis.Address == "" {
-		return fmt.Errorf("redis address is required")
+	if err := validateRedisConfig("redis", config.Redis); err != nil {
+		return err
+	}
+	if config.Counter.DualWrite.Enabled {
+		secondary := config.Counter.DualWrite.Secondary
+		if secondary.Mode == "" || secondary.Mode == RedisModeStandalone {
+			if secondary.Address == "" {
+				return fmt.Errorf("counter dual_write secondary redis address is required when enabled")
+			}
+		} else if err := validateRedisConfig("counter dual_write secondary redis", secondary); err != nil {
+			return err
+		}
+	}
+
+	// 服务发现刷新间隔验证
+	if config.Discovery.RefreshInterval < 0 {
+		return fmt.Errorf("discovery refresh_interval must not be negative")
+	}
+	for serviceName, svc := range config.Discovery.Services {
+		if svc.RefreshInterval < 0 {
+			return fmt.Errorf("discovery service %s: refresh_interval must not be negative", serviceName)
+		}
+	}
+
+	// 计数器增量限制验证
+	if err := validateDeltaLimit("default", config.Counter.Delta.Default); err != nil {
+		return err
+	}
+	for counterType, limit := range config.Counter.Delta.Types {
+		if err := validateDeltaLimit(counterType, limit); err != nil {
+			return err
+		}
+	}
+
+	// 计数器过期时间验证
+	if config.Counter.Performance.CounterTTL < 0 {
+		return fmt.Errorf("counter performance counter_ttl must not be negative")
+	}
+
+	// 认证配置验证
+	authUsed := config.Auth.Enabled || config.Counter.Auth.Enabled
+	if authUsed && config.Auth.Provider == "api_key" && len(config.Auth.APIKey.Keys) == 0 {
+		return fmt.Errorf("auth api_key provider requires at least one key")
+	}
+	if authUsed && config.Auth.Provider == "jwt" {
+		jwt := config.Auth.JWT
+		if jwt.Issuer == "" || jwt.Audience == "" {
+			return fmt.Errorf("auth jwt provider requires issuer and audience")
+		}
+		if jwt.SigningKey == "" && (jwt.Algorithm != "RS256" || jwt.JWKSURL == "") {
+			return fmt.Errorf("auth jwt provider requires signing_key (or jwks_url for RS256)")
+		}
+	}
+
+	// 配额配置验证
+	if config.Quota.Enabled && !config.Auth.Enabled {
+		return fmt.Errorf("quota requires auth to be enabled")
+	}
+	if config.Counter.Auth.Quota && !config.Counter.Auth.Enabled {
+		return fmt.Errorf("counter auth quota requires counter auth to be enabled")
+	}
+	if config.Quota.Enabled || config.Counter.Auth.Quota {
+		if _, ok := config.Quota.Tiers[config.Quota.DefaultTier]; !ok {
+			return fmt.Errorf("quota default_tier %q is not defined in tiers", config.Quota.DefaultTier)
+		}
+		for tier, limit := range config.Quota.Tiers {
+			if limit < 0 {
+				return fmt.Errorf("quota tier %s: limit must not be negative", tier)
+			}
+		}
+		for _, subject := range config.Quota.Subjects {
+			if _, ok := config.Quota.Tiers[subject.Tier]; !ok {
+				return fmt.Errorf("quota subject %s: tier %q is not defined", subject.Subject, subject.Tier)
+			}
+		}
+		if _, err := time.LoadLocation(config.Quota.Timezone); err != nil {
+			return fmt.Errorf("invalid quota timezone: %w", err)
+		}
+	}
+
+	// 排行榜配置验证
+	leaderboardTypes := make(map[string]bool, len(config.Counter.Leaderboards))
+	for _, spec := range config.Counter.Leaderboards {
+		if spec.CounterType == "" {
+			return fmt.Errorf("counter leaderboard counter_type is required")
+		}
+		if leaderboardTypes[spec.CounterType] {
+			return fmt.Errorf("duplicate counter leaderboard for %s", spec.CounterType)
+		}
+		leaderboardTypes[spec.CounterType] = true
+		for _, window := range spec.Windows {
+			if window < time.Second {
+				return fmt.Errorf("counter leaderboard %s: window must be at least 1s", spec.CounterType)
+			}
+		}
+	}
+
+	// 可信代理验证
+	for _, proxies := range [][]string{config.Gateway.Server.TrustedProxies, config.Counter.Server.TrustedProxies} {
+		for _, proxy := range proxies {
+			if _, err := netip.ParsePrefix(proxy); err == nil {
+				continue
+			}
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				return fmt.Errorf("invalid trusted proxy %q: must be an IP or CIDR", proxy)
+			}
+		}
+	}
+
+	// 热点排行时间范围验证
+	for _, period := range config.Counter.HotRankPeriods {
+		switch period {
+		case "hour", "day", "week":
+		default:
+			return fmt.Errorf("counter hot_rank_periods: period must be one of hour, day, week, got %q", period)
+		}
+	}
+
+	// gRPC元数据限制验证
+	if config.Counter.GRPC.Metadata.MaxSize < 0 || config.Analytics.GRPC.Metadata.MaxSize < 0 {
+		return fmt.Errorf("grpc metadata max_size must not be negative")
+	}
+	if config.Counter.GRPC.MaxResponseSize < 0 || config.Analytics.GRPC.MaxResponseSize < 0 {
+		return fmt.Errorf("grpc max_response_size must not be negative")
+	}
+
+	// Analytics聚合策略验证
+	switch config.Analytics.Aggregation.Strategy {
+	case "", "raw", "rate_limited", "windowed":
+	default:
+		return fmt.Errorf("invalid analytics aggregation strategy: %s", config.Analytics.Aggregation.Strategy)
 	}
 
 	// Kafka配置验证
 	if config.Kafka.Mode == "real" && len(config.Kafka.Brokers) == 0 {
 		return fmt.Errorf("kafka brokers are required when mode is 'real'")
 	}
+	if err := validateKafkaSecurity(config.Kafka); err != nil {
+		return err
+	}
+	switch config.Kafka.Consumer.ProcessingMode {
+	case "", "at_least_once", "effectively_once":
+	default:
+		return fmt.Errorf("invalid kafka consumer processing_mode: %s", config.Kafka.Consumer.ProcessingMode)
+	}
+	if config.Kafka.Consumer.CommitBatchSize < 0 || config.Kafka.Consumer.CommitInterval < 0 {
+		return fmt.Errorf("kafka consumer commit_batch_size and commit_interval must not be negative")
+	}
+	if config.Kafka.FallbackRetryInterval < 0 {
+		return fmt.Errorf("kafka fallback_retry_interval must not be negative")
+	}
+	if config.Kafka.Consumer.PartitionConcurrency < 0 {
+		return fmt.Errorf("kafka consumer partition_concurrency must not be negative")
+	}
+	if config.Kafka.Consumer.MaxProcessAttempts < 0 {
+		return fmt.Errorf("kafka consumer max_process_attempts must not be negative")
+	}
+	switch config.Kafka.Consumer.DedupeStore {
+	case "", "memory", "redis":
+	default:
+		return fmt.Errorf("invalid kafka consumer dedupe_store: %s", config.Kafka.Consumer.DedupeStore)
+	}
+
+	return nil
+}
+
+// validateKafkaSecurity 检查Kafka认证配置，real模式下启用SASL时必须提供用户名和密码
+func validateKafkaSecurity(cfg KafkaConfig) error {
+	sasl := cfg.Security.SASL
+	switch sasl.Mechanism {
+	case "", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+	default:
+		return fmt.Errorf("invalid kafka sasl mechanism: %s", sasl.Mechanism)
+	}
+	if cfg.Mode == "real" && sasl.Mechanism != "" && (sasl.Username == "" || sasl.Password == "") {
+		return fmt.Errorf("kafka sasl username and password are required when mechanism is %s", sasl.Mechanism)
+	}
+
+	tlsCfg := cfg.Security.TLS
+	if tlsCfg.Enabled && (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		return fmt.Errorf("kafka tls cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+// validateRedisConfig 按部署模式检查Redis连接配置
+func validateRedisConfig(name string, cfg RedisConfig) error {
+	switch cfg.Mode {
+	case "", RedisModeStandalone:
+		if cfg.Address == "" {
+			return fmt.Errorf("%s address is required", name)
+		}
+	case RedisModeCluster:
+		if len(cfg.Addresses) == 0 {
+			return fmt.Errorf("%s cluster mode requires at least one node in addresses", name)
+		}
+		if cfg.DB != 0 {
+			return fmt.Errorf("%s cluster mode only supports db 0, got %d", name, cfg.DB)
+		}
+	case RedisModeSentinel:
+		if cfg.MasterName == "" {
+			return fmt.Errorf("%s sentinel mode requires master_name", name)
+		}
+		if len(cfg.Addresses) == 0 {
+			return fmt.Errorf("%s sentinel mode requires at least one sentinel in addresses", name)
+		}
+	default:
+		return fmt.Errorf("%s mode must be one of standalone, cluster, sentinel, got %q", name, cfg.Mode)
+	}
+	for _, addr := range cfg.Addresses {
+		if addr == "" {
+			return fmt.Errorf("%s addresses must not contain empty entries", name)
+		}
+	}
+	return nil
+}
 
+// validateDeltaLimit 检查计数器增量限制
+func validateDeltaLimit(counterType string, limit DeltaLimitConfig) error {
+	if limit.MaxDelta < 0 {
+		return fmt.Errorf("counter delta %s: max_delta must not be negative", counterType)
+	}
+	if limit.MaxDelta > 0 && (limit.DefaultDelta > limit.MaxDelta || limit.DefaultDelta < -limit.MaxDelta) {
+		return fmt.Errorf("counter delta %s: default_delta %d exceeds max_delta %d", counterType, limit.DefaultDelta, limit.MaxDelta)
+	}
 	return nil
 }
 
@@ -613,15 +1230,17 @@ func (m *Manager) StartWatchConfig(ctx context.Context, serviceName, environment
 		m.mutex.Lock()
 		defer m.mutex.Unlock()
 
-		// 验证新配置
+		// 合并本地文件配置并验证新配置
 		if newConfig != nil {
-			if err := m.validate(newConfig); err != nil {
+			newConfig = m.resolveCenterConfig(newConfig)
+			if err := m.ValidateConfig(newConfig); err != nil {
 				m.logger.Error("New config validation failed", zap.Error(err))
 				return err
 			}
 		}
 
 		// 更新内部配置
+		event := NewConfigChangeEvent(m.config, newConfig)
 		m.config = newConfig
 
 		// 通知所有监听器
@@ -630,10 +1249,16 @@ func (m *Manager) StartWatchConfig(ctx context.Context, serviceName, environment
 				m.logger.Error("Config change watcher failed", zap.Error(err))
 			}
 		}
+		for _, watcher := range m.eventWatchers {
+			if err := watcher(event); err != nil {
+				m.logger.Error("Config change event watcher failed", zap.Error(err))
+			}
+		}
 
 		m.logger.Info("Configuration updated from config center",
 			zap.String("service", serviceName),
-			zap.String("environment", environment))
+			zap.String("environment", environment),
+			zap.Strings("changed", event.ChangedPaths))
 
 		return nil
 	}
@@ -655,6 +1280,13 @@ func (m *Manager) AddConfigWatcher(callback ConfigChangeCallback) {
 	m.watchers = append(m.watchers, callback)
 }
 
+// AddConfigEventWatcher 添加带字段级差异的配置变化监听器
+func (m *Manager) AddConfigEventWatcher(callback ConfigChangeEventCallback) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.eventWatchers = append(m.eventWatchers, callback)
+}
+
 // PushConfig 推送配置到配置中心
 func (m *Manager) PushConfig(ctx context.Context, serviceName, environment string, config *Config) error {
 	if m.configCenter == nil {
@@ -662,7 +1294,7 @@ func (m *Manager) PushConfig(ctx context.Context, serviceName, environment strin
 	}
 
 	// 验证配置
-	if err := m.validate(config); err != nil {
+	if err := m.ValidateConfig(config); err != nil {
 		return fmt.Errorf("config validation failed: %w", err)
 	}
 
@@ -3,8 +3,13 @@ package config
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"path"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,11 +31,44 @@ type ConfigCenter interface {
 	DeleteConfig(ctx context.Context, service, environment string) error
 	// 获取配置历史版本
 	GetConfigHistory(ctx context.Context, service, environment string) ([]*ConfigVersion, error)
+	// 列出配置中心中的全部服务配置
+	ListConfigs(ctx context.Context) ([]ConfigKey, error)
+	// 回滚到指定的历史版本
+	RollbackConfig(ctx context.Context, service, environment, version string) error
 }
 
 // ConfigChangeCallback 配置变更回调函数
 type ConfigChangeCallback func(oldConfig, newConfig *Config) error
 
+// ConfigChangeEvent 配置变更事件，包含预先计算的字段级差异
+type ConfigChangeEvent struct {
+	OldConfig    *Config
+	NewConfig    *Config
+	ChangedPaths []string // 发生变化的字段路径，如"log.level"
+}
+
+// ConfigChangeEventCallback 配置变更事件回调函数
+type ConfigChangeEventCallback func(event *ConfigChangeEvent) error
+
+// NewConfigChangeEvent 创建配置变更事件
+func NewConfigChangeEvent(oldConfig, newConfig *Config) *ConfigChangeEvent {
+	return &ConfigChangeEvent{
+		OldConfig:    oldConfig,
+		NewConfig:    newConfig,
+		ChangedPaths: DiffConfig(oldConfig, newConfig),
+	}
+}
+
+// HasChanged 判断指定字段或其子字段是否发生变化，如HasChanged("redis")
+func (e *ConfigChangeEvent) HasChanged(path string) bool {
+	for _, changed := range e.ChangedPaths {
+		if changed == "*" || changed == path || strings.HasPrefix(changed, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
 // ConfigVersion 配置版本信息
 type ConfigVersion struct {
 	Version   string    `json:"version"`
@@ -39,9 +77,59 @@ type ConfigVersion struct {
 	Comment   string    `json:"comment"`
 }
 
+// ConfigKey 配置中心中一份配置的服务、环境和最后修改的索引
+type ConfigKey struct {
+	Service     string `json:"service"`
+	Environment string `json:"environment"`
+	ModifyIndex uint64 `json:"modify_index"` // Consul中最后一次修改的Raft索引
+}
+
+// configKeyPrefix 配置键名前缀，完整键名为 前缀/环境/服务
+const configKeyPrefix = "high-go-press/config/"
+
+// ErrConfigNotFound 配置中心中不存在该服务和环境的配置
+var ErrConfigNotFound = errors.New("config not found")
+
+// ErrConfigVersionNotFound 配置历史中不存在指定的版本
+var ErrConfigVersionNotFound = errors.New("config version not found")
+
+// defaultHistoryComment PutConfig写入历史版本时的说明
+const defaultHistoryComment = "Auto-saved by config center"
+
+// Backoff 重试退避策略，resilience.ExponentialBackoff满足该接口
+type Backoff interface {
+	NextBackOff() time.Duration // 返回负数表示停止重试
+	Reset()
+}
+
+// ConsulRetryConfig 配置中心读写的重试配置
+type ConsulRetryConfig struct {
+	MaxAttempts int            // 最大尝试次数（含首次），不大于1表示不重试
+	NewBackoff  func() Backoff // 每次操作创建新的退避计算器
+}
+
+// defaultConsulTimeout 未配置超时时Consul API调用的超时时间
+const defaultConsulTimeout = 10 * time.Second
+
+// watchWaitTime 监听配置时阻塞查询的最长等待时间
+const watchWaitTime = 30 * time.Second
+
+// watchRetryInitialDelay 监听查询连续失败时的首次重试间隔，之后每次翻倍
+const watchRetryInitialDelay = time.Second
+
+// watchRetryMaxDelay 监听查询连续失败时的最大重试间隔
+const watchRetryMaxDelay = time.Minute
+
+// defaultConfigHistoryLimit 未配置时每个服务和环境保留的历史版本数
+const defaultConfigHistoryLimit = 20
+
 // ConsulConfigCenter 基于Consul的配置中心实现
 type ConsulConfigCenter struct {
 	client   *api.Client
+	timeout  time.Duration      // 单次API调用超时
+	retry    *ConsulRetryConfig // GetConfig/PutConfig/ListConfigs的重试配置，nil表示不重试
+	history  int                // PutConfig后保留的历史版本数
+	crypto   *FieldEncryptor    // 敏感字段加密器，nil表示明文存储
 	logger   *zap.Logger
 	watchers map[string]*ConfigWatcher
 	mutex    sync.RWMutex
@@ -52,52 +140,260 @@ type ConfigWatcher struct {
 	service     string
 	environment string
 	callback    ConfigChangeCallback
+	callbackMu  sync.RWMutex
 	stopCh      chan struct{}
 	lastConfig  *Config
 	lastIndex   uint64
 	running     bool
 }
 
-// NewConsulConfigCenter 创建Consul配置中心
-func NewConsulConfigCenter(consulAddress string, logger *zap.Logger) (*ConsulConfigCenter, error) {
+// setCallback 替换配置变化回调
+func (w *ConfigWatcher) setCallback(callback ConfigChangeCallback) {
+	w.callbackMu.Lock()
+	defer w.callbackMu.Unlock()
+	w.callback = callback
+}
+
+// notify 以当前回调通知配置变化，监听器已停止时不再通知
+func (w *ConfigWatcher) notify(oldConfig, newConfig *Config) error {
+	select {
+	case <-w.stopCh:
+		return nil
+	default:
+	}
+	w.callbackMu.RLock()
+	callback := w.callback
+	w.callbackMu.RUnlock()
+	return callback(oldConfig, newConfig)
+}
+
+// ConfigCenterOption Consul配置中心的可选配置
+type ConfigCenterOption func(*configCenterOptions)
+
+// configCenterOptions 通过ConfigCenterOption设置的选项
+type configCenterOptions struct {
+	encryptionKey   []byte
+	encryptedFields []string
+}
+
+// WithEncryptionKey 使用32字节的AES-256密钥信封加密敏感字段，优先于ConfigEncryptionKeyEnv
+func WithEncryptionKey(key []byte) ConfigCenterOption {
+	return func(o *configCenterOptions) {
+		o.encryptionKey = key
+	}
+}
+
+// WithEncryptedFields 除sensitive标签标记的字段外额外加密的字段路径，如"kafka.security.sasl.username"
+func WithEncryptedFields(paths ...string) ConfigCenterOption {
+	return func(o *configCenterOptions) {
+		o.encryptedFields = append(o.encryptedFields, paths...)
+	}
+}
+
+// newConfigEncryptor 按选项创建字段加密器，未指定密钥时从ConfigEncryptionKeyEnv读取，都没有时返回nil
+func newConfigEncryptor(options *configCenterOptions) (*FieldEncryptor, error) {
+	var encryptor *FieldEncryptor
+	var err error
+	if options.encryptionKey != nil {
+		if len(options.encryptionKey) != 32 {
+			return nil, fmt.Errorf("config encryption key must be 32 bytes for AES-256, got %d", len(options.encryptionKey))
+		}
+		encryptor, err = NewFieldEncryptor(options.encryptionKey)
+	} else {
+		encryptor, err = NewFieldEncryptorFromEnv()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(options.encryptedFields) > 0 {
+		if encryptor == nil {
+			return nil, fmt.Errorf("encrypted fields configured: %w", ErrEncryptionKeyMissing)
+		}
+		if err := encryptor.SetEncryptedFields(options.encryptedFields...); err != nil {
+			return nil, err
+		}
+	}
+	return encryptor, nil
+}
+
+// NewConsulConfigCenter 使用默认超时创建Consul配置中心
+func NewConsulConfigCenter(consulAddress string, logger *zap.Logger, opts ...ConfigCenterOption) (*ConsulConfigCenter, error) {
+	return NewConsulConfigCenterWithConfig(&ConsulConfig{Address: consulAddress}, logger, opts...)
+}
+
+// NewConsulConfigCenterWithConfig 使用指定配置创建Consul配置中心，所有API调用受Timeout约束
+func NewConsulConfigCenterWithConfig(consulConfig *ConsulConfig, logger *zap.Logger, opts ...ConfigCenterOption) (*ConsulConfigCenter, error) {
+	options := &configCenterOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	config := api.DefaultConfig()
-	config.Address = consulAddress
+	config.Address = consulConfig.Address
+	if consulConfig.Scheme != "" {
+		config.Scheme = consulConfig.Scheme
+	}
+	if consulConfig.Token != "" {
+		config.Token = consulConfig.Token
+	}
 
 	client, err := api.NewClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create consul client: %w", err)
 	}
 
-	// 测试连接
-	_, err = client.Status().Leader()
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to consul: %w", err)
+	timeout := consulConfig.Timeout
+	if timeout <= 0 {
+		timeout = defaultConsulTimeout
 	}
-
-	return &ConsulConfigCenter{
+	cc := &ConsulConfigCenter{
 		client:   client,
+		timeout:  timeout,
 		logger:   logger,
 		watchers: make(map[string]*ConfigWatcher),
-	}, nil
+	}
+	cc.SetHistoryLimit(consulConfig.HistoryLimit)
+
+	// 配置了加密密钥时，敏感字段在Consul中加密存储
+	encryptor, err := newConfigEncryptor(options)
+	if err != nil {
+		return nil, err
+	}
+	cc.SetEncryptor(encryptor)
+
+	// 测试连接
+	ctx, cancel := cc.withTimeout(context.Background())
+	defer cancel()
+	if _, err := client.Status().LeaderWithQueryOptions((&api.QueryOptions{}).WithContext(ctx)); err != nil {
+		return nil, fmt.Errorf("failed to connect to consul: %w", err)
+	}
+
+	return cc, nil
+}
+
+// withTimeout 为单次Consul API调用设置超时，调用方ctx更早到期时以其为准
+func (cc *ConsulConfigCenter) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := cc.timeout
+	if timeout <= 0 {
+		timeout = defaultConsulTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// SetRetry 设置GetConfig/PutConfig遇到可重试的Consul错误时的重试策略
+func (cc *ConsulConfigCenter) SetRetry(retry *ConsulRetryConfig) {
+	cc.retry = retry
+}
+
+// SetHistoryLimit 设置PutConfig后保留的历史版本数，<=0时使用默认值
+func (cc *ConsulConfigCenter) SetHistoryLimit(limit int) {
+	cc.history = limit
+}
+
+// SetEncryptor 设置敏感字段加密器，PutConfig加密带sensitive标签的字段，读取时解密；nil表示明文存储
+func (cc *ConsulConfigCenter) SetEncryptor(encryptor *FieldEncryptor) {
+	cc.crypto = encryptor
+}
+
+// historyLimit 获取保留的历史版本数
+func (cc *ConsulConfigCenter) historyLimit() int {
+	if cc.history <= 0 {
+		return defaultConfigHistoryLimit
+	}
+	return cc.history
+}
+
+// isRetryableConsulError 判断Consul错误是否可重试：网络错误、单次调用超时、5xx和429
+// 配置不存在、4xx和调用方取消不重试
+func isRetryableConsulError(err error) bool {
+	if errors.Is(err, ErrConfigNotFound) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr api.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= http.StatusInternalServerError || statusErr.Code == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// withRetry 按重试配置执行Consul操作，每次尝试单独计算超时
+func (cc *ConsulConfigCenter) withRetry(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	maxAttempts := 1
+	var backoff Backoff
+	if cc.retry != nil && cc.retry.MaxAttempts > 1 && cc.retry.NewBackoff != nil {
+		maxAttempts = cc.retry.MaxAttempts
+		backoff = cc.retry.NewBackoff()
+		backoff.Reset()
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := cc.withTimeout(ctx)
+		err = fn(attemptCtx)
+		cancel()
+
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil || !isRetryableConsulError(err) {
+			return err
+		}
+
+		delay := backoff.NextBackOff()
+		if delay < 0 {
+			return err
+		}
+		cc.logger.Warn("Consul operation failed, retrying",
+			zap.String("operation", operation),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// queryOptions 带超时的查询选项
+func queryOptions(ctx context.Context) *api.QueryOptions {
+	return (&api.QueryOptions{}).WithContext(ctx)
+}
+
+// writeOptions 带超时的写入选项
+func writeOptions(ctx context.Context) *api.WriteOptions {
+	return (&api.WriteOptions{}).WithContext(ctx)
 }
 
 // GetConfig 从配置中心获取配置
 func (cc *ConsulConfigCenter) GetConfig(ctx context.Context, service, environment string) (*Config, error) {
 	key := cc.buildConfigKey(service, environment)
 
-	pair, _, err := cc.client.KV().Get(key, nil)
+	var pair *api.KVPair
+	err := cc.withRetry(ctx, "get_config", func(ctx context.Context) error {
+		var err error
+		pair, _, err = cc.client.KV().Get(key, queryOptions(ctx))
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config from consul: %w", err)
 	}
 
 	if pair == nil {
-		return nil, fmt.Errorf("config not found for service %s in environment %s", service, environment)
+		return nil, fmt.Errorf("%w for service %s in environment %s", ErrConfigNotFound, service, environment)
 	}
 
 	var config Config
 	if err := json.Unmarshal(pair.Value, &config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	if err := DecryptConfig(&config, cc.crypto); err != nil {
+		return nil, fmt.Errorf("failed to decrypt config: %w", err)
+	}
 
 	cc.logger.Info("Config retrieved from consul",
 		zap.String("service", service),
@@ -109,30 +405,83 @@ func (cc *ConsulConfigCenter) GetConfig(ctx context.Context, service, environmen
 
 // PutConfig 推送配置到配置中心
 func (cc *ConsulConfigCenter) PutConfig(ctx context.Context, service, environment string, config *Config) error {
+	return cc.putConfig(ctx, service, environment, config, defaultHistoryComment)
+}
+
+// RollbackConfig 将历史中的指定版本校验后写回为当前配置，并记录一条注明回滚的历史版本
+func (cc *ConsulConfigCenter) RollbackConfig(ctx context.Context, service, environment, version string) error {
+	versions, err := cc.GetConfigHistory(ctx, service, environment)
+	if err != nil {
+		return err
+	}
+
+	var target *ConfigVersion
+	for _, v := range versions {
+		if v.Version == version {
+			target = v
+			break
+		}
+	}
+	if target == nil || target.Config == nil {
+		return fmt.Errorf("%w: %s for service %s in environment %s", ErrConfigVersionNotFound, version, service, environment)
+	}
+
+	if err := NewManager(cc.logger).ValidateConfig(target.Config); err != nil {
+		return fmt.Errorf("config version %s is invalid: %w", version, err)
+	}
+
+	if err := cc.putConfig(ctx, service, environment, target.Config, "Rollback to "+version); err != nil {
+		return err
+	}
+
+	cc.logger.Info("Config rolled back",
+		zap.String("service", service),
+		zap.String("environment", environment),
+		zap.String("version", version))
+
+	return nil
+}
+
+// putConfig 写入当前配置，comment为本次写入的历史版本说明
+func (cc *ConsulConfigCenter) putConfig(ctx context.Context, service, environment string, config *Config, comment string) error {
 	key := cc.buildConfigKey(service, environment)
 
+	// 加密敏感字段，历史版本同样只保存密文
+	if cc.crypto != nil {
+		encrypted, err := cc.crypto.EncryptConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt config: %w", err)
+		}
+		config = encrypted
+	}
+
 	// 序列化配置
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// 保存当前版本到历史
-	if err := cc.saveConfigHistory(ctx, service, environment, config); err != nil {
-		cc.logger.Warn("Failed to save config history", zap.Error(err))
-	}
-
 	// 写入Consul
 	pair := &api.KVPair{
 		Key:   key,
 		Value: data,
 	}
 
-	_, err = cc.client.KV().Put(pair, nil)
+	err = cc.withRetry(ctx, "put_config", func(ctx context.Context) error {
+		_, err := cc.client.KV().Put(pair, writeOptions(ctx))
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to put config to consul: %w", err)
 	}
 
+	// 写入成功后才保存到历史，并删除超出保留数量的旧版本，失败的推送不会挤掉可回滚的旧版本
+	if err := cc.saveConfigHistory(ctx, service, environment, config, comment); err != nil {
+		cc.logger.Warn("Failed to save config history", zap.Error(err))
+	} else if _, err := cc.PruneConfigHistory(ctx, service, environment, cc.historyLimit()); err != nil {
+		cc.logger.Warn("Failed to prune config history", zap.Error(err))
+	}
+
 	cc.logger.Info("Config pushed to consul",
 		zap.String("service", service),
 		zap.String("environment", environment),
@@ -141,16 +490,20 @@ func (cc *ConsulConfigCenter) PutConfig(ctx context.Context, service, environmen
 	return nil
 }
 
-// WatchConfig 监听配置变化
+// WatchConfig 监听配置变化，可安全重复调用
+// 同一服务和环境已有运行中的监听器时先停止它，再以新的ctx和回调重新启动，监听器的生命周期总是跟随最近一次调用的ctx
 func (cc *ConsulConfigCenter) WatchConfig(ctx context.Context, service, environment string, callback ConfigChangeCallback) error {
 	watcherKey := cc.buildWatcherKey(service, environment)
 
 	cc.mutex.Lock()
 	defer cc.mutex.Unlock()
 
-	// 检查是否已存在监听器
 	if watcher, exists := cc.watchers[watcherKey]; exists && watcher.running {
-		return fmt.Errorf("watcher already exists for service %s in environment %s", service, environment)
+		watcher.running = false
+		close(watcher.stopCh)
+		cc.logger.Info("Config watcher already running, restarting with new context and callback",
+			zap.String("service", service),
+			zap.String("environment", environment))
 	}
 
 	// 创建新的监听器
@@ -196,7 +549,9 @@ func (cc *ConsulConfigCenter) StopWatch(service, environment string) {
 func (cc *ConsulConfigCenter) DeleteConfig(ctx context.Context, service, environment string) error {
 	key := cc.buildConfigKey(service, environment)
 
-	_, err := cc.client.KV().Delete(key, nil)
+	ctx, cancel := cc.withTimeout(ctx)
+	defer cancel()
+	_, err := cc.client.KV().Delete(key, writeOptions(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete config from consul: %w", err)
 	}
@@ -211,9 +566,12 @@ func (cc *ConsulConfigCenter) DeleteConfig(ctx context.Context, service, environ
 
 // GetConfigHistory 获取配置历史版本
 func (cc *ConsulConfigCenter) GetConfigHistory(ctx context.Context, service, environment string) ([]*ConfigVersion, error) {
-	historyKey := cc.buildConfigHistoryKey(service, environment)
+	// 带上末尾的/，避免列出名称以当前服务名为前缀的其他服务（如counter-v2）的历史
+	historyKey := cc.buildConfigHistoryKey(service, environment) + "/"
 
-	pairs, _, err := cc.client.KV().List(historyKey, nil)
+	ctx, cancel := cc.withTimeout(ctx)
+	defer cancel()
+	pairs, _, err := cc.client.KV().List(historyKey, queryOptions(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config history from consul: %w", err)
 	}
@@ -226,12 +584,125 @@ func (cc *ConsulConfigCenter) GetConfigHistory(ctx context.Context, service, env
 				zap.String("key", pair.Key), zap.Error(err))
 			continue
 		}
+		if err := DecryptConfig(version.Config, cc.crypto); err != nil {
+			cc.logger.Warn("Failed to decrypt config version",
+				zap.String("key", pair.Key), zap.Error(err))
+			continue
+		}
 		versions = append(versions, &version)
 	}
 
 	return versions, nil
 }
 
+// ListConfigs 列出配置中心中的全部服务配置，按环境和服务名排序
+func (cc *ConsulConfigCenter) ListConfigs(ctx context.Context) ([]ConfigKey, error) {
+	var pairs api.KVPairs
+	err := cc.withRetry(ctx, "list_configs", func(ctx context.Context) error {
+		var err error
+		pairs, _, err = cc.client.KV().List(configKeyPrefix, queryOptions(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs from consul: %w", err)
+	}
+
+	keys := make([]ConfigKey, 0, len(pairs))
+	for _, pair := range pairs {
+		key, ok := parseConfigKey(pair.Key)
+		if !ok {
+			cc.logger.Warn("Skipping unrecognized config key", zap.String("key", pair.Key))
+			continue
+		}
+		key.ModifyIndex = pair.ModifyIndex
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Environment != keys[j].Environment {
+			return keys[i].Environment < keys[j].Environment
+		}
+		return keys[i].Service < keys[j].Service
+	})
+	return keys, nil
+}
+
+// parseConfigKey 从 前缀/环境/服务 格式的键名解析服务和环境
+func parseConfigKey(key string) (ConfigKey, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, configKeyPrefix), "/")
+	if !strings.HasPrefix(key, configKeyPrefix) || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ConfigKey{}, false
+	}
+	return ConfigKey{Environment: parts[0], Service: parts[1]}, true
+}
+
+// PruneConfigHistory 只保留最新的keep个历史版本，删除更旧的版本，返回删除的数量
+func (cc *ConsulConfigCenter) PruneConfigHistory(ctx context.Context, service, environment string, keep int) (int, error) {
+	if keep <= 0 {
+		return 0, fmt.Errorf("keep must be positive, got %d", keep)
+	}
+
+	// 带上末尾的/，只清理当前服务的历史，不会删除名称以当前服务名为前缀的其他服务的版本
+	historyKey := cc.buildConfigHistoryKey(service, environment) + "/"
+
+	listCtx, cancel := cc.withTimeout(ctx)
+	pairs, _, err := cc.client.KV().List(historyKey, queryOptions(listCtx))
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list config history from consul: %w", err)
+	}
+	if len(pairs) <= keep {
+		return 0, nil
+	}
+
+	// 按保存时间从新到旧排序，无法解析的版本视为最旧
+	timestamps := make(map[string]time.Time, len(pairs))
+	for _, pair := range pairs {
+		var version ConfigVersion
+		if err := json.Unmarshal(pair.Value, &version); err == nil {
+			timestamps[pair.Key] = version.Timestamp
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		ti, tj := timestamps[pairs[i].Key], timestamps[pairs[j].Key]
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return pairs[i].Key > pairs[j].Key
+	})
+
+	deleted := 0
+	for _, pair := range pairs[keep:] {
+		deleteCtx, cancel := cc.withTimeout(ctx)
+		_, err := cc.client.KV().Delete(pair.Key, writeOptions(deleteCtx))
+		cancel()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete config history %s: %w", pair.Key, err)
+		}
+		deleted++
+	}
+
+	cc.logger.Info("Pruned config history",
+		zap.String("service", service),
+		zap.String("environment", environment),
+		zap.Int("kept", keep),
+		zap.Int("deleted", deleted))
+
+	return deleted, nil
+}
+
+// markWatcherStopped 监听器因context取消或panic退出后移除，之后的WatchConfig会重新启动监听
+func (cc *ConsulConfigCenter) markWatcherStopped(watcher *ConfigWatcher) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	watcher.running = false
+	watcherKey := cc.buildWatcherKey(watcher.service, watcher.environment)
+	if cc.watchers[watcherKey] == watcher {
+		delete(cc.watchers, watcherKey)
+	}
+}
+
 // runWatcher 运行配置监听器
 func (cc *ConsulConfigCenter) runWatcher(ctx context.Context, watcher *ConfigWatcher) {
 	defer func() {
@@ -240,13 +711,25 @@ func (cc *ConsulConfigCenter) runWatcher(ctx context.Context, watcher *ConfigWat
 				zap.String("service", watcher.service),
 				zap.String("environment", watcher.environment),
 				zap.Any("panic", r))
+			cc.markWatcherStopped(watcher)
 		}
 	}()
 
 	key := cc.buildConfigKey(watcher.service, watcher.environment)
-	ticker := time.NewTicker(5 * time.Second) // 每5秒检查一次配置变化
-	defer ticker.Stop()
 
+	// StopWatch时取消进行中的阻塞查询，不必等到WaitTime到期
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-watcher.stopCh:
+			cancel()
+		case <-queryCtx.Done():
+		}
+	}()
+
+	// 连续执行阻塞查询，配置变化后在下一次查询返回时立即通知
+	failures := 0
 	for {
 		select {
 		case <-watcher.stopCh:
@@ -256,36 +739,83 @@ func (cc *ConsulConfigCenter) runWatcher(ctx context.Context, watcher *ConfigWat
 			return
 
 		case <-ctx.Done():
+			cc.markWatcherStopped(watcher)
 			cc.logger.Info("Config watcher context cancelled",
 				zap.String("service", watcher.service),
 				zap.String("environment", watcher.environment))
 			return
 
-		case <-ticker.C:
-			if err := cc.checkConfigChange(watcher, key); err != nil {
-				cc.logger.Error("Failed to check config change",
-					zap.String("service", watcher.service),
-					zap.String("environment", watcher.environment),
-					zap.Error(err))
-			}
+		default:
+		}
+
+		err := cc.checkConfigChange(queryCtx, watcher, key)
+		if err == nil || queryCtx.Err() != nil {
+			failures = 0
+			continue
+		}
+
+		// Consul连续出错时指数退避，避免不可用期间频繁重试
+		failures++
+		delay := watchRetryDelay(failures)
+		cc.logger.Error("Failed to check config change",
+			zap.String("service", watcher.service),
+			zap.String("environment", watcher.environment),
+			zap.Int("consecutive_failures", failures),
+			zap.Duration("retry_in", delay),
+			zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-watcher.stopCh:
+		case <-ctx.Done():
 		}
+		timer.Stop()
+	}
+}
+
+// watchRetryDelay 监听查询连续失败failures次后的重试间隔，指数增长到watchRetryMaxDelay为止
+func watchRetryDelay(failures int) time.Duration {
+	delay := watchRetryInitialDelay
+	for i := 1; i < failures && delay < watchRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > watchRetryMaxDelay {
+		delay = watchRetryMaxDelay
 	}
+	return delay
 }
 
-// checkConfigChange 检查配置变化
-func (cc *ConsulConfigCenter) checkConfigChange(watcher *ConfigWatcher, key string) error {
-	queryOptions := &api.QueryOptions{
+// nextWatchIndex 根据阻塞查询返回的索引计算下一次查询的WaitIndex
+// 索引回退（如Consul从快照恢复）时重置为0，下一次查询立即返回当前值；
+// 返回0时按1处理，避免WaitIndex为0导致查询不阻塞
+func nextWatchIndex(previous, returned uint64) uint64 {
+	if returned < previous {
+		return 0
+	}
+	if returned == 0 {
+		return 1
+	}
+	return returned
+}
+
+// checkConfigChange 检查配置变化，阻塞查询的超时为等待时间加单次调用超时
+func (cc *ConsulConfigCenter) checkConfigChange(ctx context.Context, watcher *ConfigWatcher, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, watchWaitTime+cc.timeout)
+	defer cancel()
+
+	options := &api.QueryOptions{
 		WaitIndex: watcher.lastIndex,
-		WaitTime:  30 * time.Second,
+		WaitTime:  watchWaitTime,
 	}
 
-	pair, meta, err := cc.client.KV().Get(key, queryOptions)
+	pair, meta, err := cc.client.KV().Get(key, options.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to get config: %w", err)
 	}
 
-	// 更新最后查询索引
-	watcher.lastIndex = meta.LastIndex
+	// 更新下一次阻塞查询的索引
+	watcher.lastIndex = nextWatchIndex(watcher.lastIndex, meta.LastIndex)
 
 	if pair == nil {
 		// 配置被删除
@@ -294,7 +824,7 @@ func (cc *ConsulConfigCenter) checkConfigChange(watcher *ConfigWatcher, key stri
 				zap.String("service", watcher.service),
 				zap.String("environment", watcher.environment))
 
-			if err := watcher.callback(watcher.lastConfig, nil); err != nil {
+			if err := watcher.notify(watcher.lastConfig, nil); err != nil {
 				cc.logger.Error("Config change callback failed",
 					zap.Error(err))
 			}
@@ -308,6 +838,9 @@ func (cc *ConsulConfigCenter) checkConfigChange(watcher *ConfigWatcher, key stri
 	if err := json.Unmarshal(pair.Value, &newConfig); err != nil {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	if err := DecryptConfig(&newConfig, cc.crypto); err != nil {
+		return fmt.Errorf("failed to decrypt config: %w", err)
+	}
 
 	// 检查配置是否真的发生了变化
 	if !cc.configChanged(watcher.lastConfig, &newConfig) {
@@ -320,7 +853,7 @@ func (cc *ConsulConfigCenter) checkConfigChange(watcher *ConfigWatcher, key stri
 
 	// 调用回调函数
 	oldConfig := watcher.lastConfig
-	if err := watcher.callback(oldConfig, &newConfig); err != nil {
+	if err := watcher.notify(oldConfig, &newConfig); err != nil {
 		cc.logger.Error("Config change callback failed",
 			zap.Error(err))
 		return err
@@ -349,12 +882,14 @@ func (cc *ConsulConfigCenter) configChanged(oldConfig, newConfig *Config) bool {
 }
 
 // saveConfigHistory 保存配置历史版本
-func (cc *ConsulConfigCenter) saveConfigHistory(ctx context.Context, service, environment string, config *Config) error {
+func (cc *ConsulConfigCenter) saveConfigHistory(ctx context.Context, service, environment string, config *Config, comment string) error {
+	// 版本号精确到纳秒，同一秒内的多次推送不会互相覆盖
+	now := time.Now()
 	version := &ConfigVersion{
-		Version:   fmt.Sprintf("v%d", time.Now().Unix()),
-		Timestamp: time.Now(),
+		Version:   fmt.Sprintf("v%d", now.UnixNano()),
+		Timestamp: now,
 		Config:    config,
-		Comment:   "Auto-saved by config center",
+		Comment:   comment,
 	}
 
 	data, err := json.MarshalIndent(version, "", "  ")
@@ -368,13 +903,15 @@ func (cc *ConsulConfigCenter) saveConfigHistory(ctx context.Context, service, en
 		Value: data,
 	}
 
-	_, err = cc.client.KV().Put(pair, nil)
+	ctx, cancel := cc.withTimeout(ctx)
+	defer cancel()
+	_, err = cc.client.KV().Put(pair, writeOptions(ctx))
 	return err
 }
 
 // buildConfigKey 构建配置键名
 func (cc *ConsulConfigCenter) buildConfigKey(service, environment string) string {
-	return fmt.Sprintf("high-go-press/config/%s/%s", environment, service)
+	return configKeyPrefix + environment + "/" + service
 }
 
 // buildConfigHistoryKey 构建配置历史键名
@@ -0,0 +1,963 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"go.uber.org/zap"
+)
+
+// blockingKV 模拟支持阻塞查询的Consul KV接口，所有key返回同一个值
+// 请求带index且索引未变化时阻塞，直到值被修改、wait到期或请求取消
+type blockingKV struct {
+	mu      sync.Mutex
+	value   []byte
+	index   uint64
+	changed chan struct{} // 每次修改后关闭并替换，唤醒阻塞中的查询
+	hold    chan struct{} // 非nil时所有查询等待其关闭后才返回
+}
+
+// set 修改值和索引，并唤醒阻塞中的查询
+func (kv *blockingKV) set(t *testing.T, config *Config, index uint64) {
+	t.Helper()
+	value, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.value, kv.index = value, index
+	close(kv.changed)
+	kv.changed = make(chan struct{})
+}
+
+func (kv *blockingKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if kv.hold != nil {
+		select {
+		case <-kv.hold:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	waitIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	wait, err := time.ParseDuration(r.URL.Query().Get("wait"))
+	if err != nil {
+		wait = 5 * time.Minute
+	}
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	for {
+		kv.mu.Lock()
+		value, index, changed := kv.value, kv.index, kv.changed
+		kv.mu.Unlock()
+
+		if waitIndex == 0 || index != waitIndex {
+			w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+			json.NewEncoder(w).Encode([]*api.KVPair{{
+				Key:         r.URL.Path,
+				Value:       value,
+				ModifyIndex: index,
+			}})
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			waitIndex = 0 // 超时返回当前值
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// newTestConsulConfigCenter 创建指向模拟Consul KV接口的配置中心，key对应的值为config，索引为7
+func newTestConsulConfigCenter(t *testing.T, config *Config) (*ConsulConfigCenter, *blockingKV) {
+	t.Helper()
+	kv := &blockingKV{changed: make(chan struct{})}
+	kv.set(t, config, 7)
+
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	apiConfig := api.DefaultConfig()
+	apiConfig.Address = server.URL
+	client, err := api.NewClient(apiConfig)
+	if err != nil {
+		t.Fatalf("Failed to create consul client: %v", err)
+	}
+
+	return &ConsulConfigCenter{
+		client:   client,
+		logger:   zap.NewNop(),
+		watchers: make(map[string]*ConfigWatcher),
+	}, kv
+}
+
+// waitFor 等待条件成立，超时则失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchConfigTwiceUsesLatestCallback(t *testing.T) {
+	cc, kv := newTestConsulConfigCenter(t, &Config{Environment: "test"})
+	t.Cleanup(func() { cc.StopWatch("counter", "test") })
+
+	// 两次注册完成前不返回查询结果
+	kv.hold = make(chan struct{})
+
+	var firstCalls, secondCalls int32
+	first := func(oldConfig, newConfig *Config) error { atomic.AddInt32(&firstCalls, 1); return nil }
+	second := func(oldConfig, newConfig *Config) error { atomic.AddInt32(&secondCalls, 1); return nil }
+
+	ctx := context.Background()
+	if err := cc.WatchConfig(ctx, "counter", "test", first); err != nil {
+		t.Fatalf("First WatchConfig failed: %v", err)
+	}
+	if err := cc.WatchConfig(ctx, "counter", "test", second); err != nil {
+		t.Fatalf("Expected repeated WatchConfig to succeed, got %v", err)
+	}
+
+	cc.mutex.RLock()
+	watcherCount := len(cc.watchers)
+	watcher := cc.watchers[cc.buildWatcherKey("counter", "test")]
+	cc.mutex.RUnlock()
+	if watcherCount != 1 || watcher == nil {
+		t.Fatalf("Expected a single watcher, got %d", watcherCount)
+	}
+
+	// 配置变化时通知最新注册的回调
+	close(kv.hold)
+	waitFor(t, func() bool { return atomic.LoadInt32(&secondCalls) == 1 })
+	if calls := atomic.LoadInt32(&firstCalls); calls != 0 {
+		t.Errorf("Expected only latest callback called, got first=%d", calls)
+	}
+}
+
+func TestWatchConfigRestartsAfterContextCancelled(t *testing.T) {
+	cc, _ := newTestConsulConfigCenter(t, &Config{Environment: "test"})
+	t.Cleanup(func() { cc.StopWatch("counter", "test") })
+
+	noop := func(oldConfig, newConfig *Config) error { return nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := cc.WatchConfig(ctx, "counter", "test", noop); err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+
+	cc.mutex.RLock()
+	oldWatcher := cc.watchers[cc.buildWatcherKey("counter", "test")]
+	cc.mutex.RUnlock()
+
+	// context取消后监听器退出，再次监听会启动新的监听器
+	cancel()
+	waitFor(t, func() bool {
+		cc.mutex.RLock()
+		defer cc.mutex.RUnlock()
+		return !oldWatcher.running
+	})
+
+	if err := cc.WatchConfig(context.Background(), "counter", "test", noop); err != nil {
+		t.Fatalf("Expected re-watch after cancel to succeed, got %v", err)
+	}
+	cc.mutex.RLock()
+	newWatcher := cc.watchers[cc.buildWatcherKey("counter", "test")]
+	cc.mutex.RUnlock()
+	if newWatcher == oldWatcher || newWatcher == nil || !newWatcher.running {
+		t.Error("Expected a new running watcher after the old one was cancelled")
+	}
+}
+
+func TestWatchConfigRebindsToLatestContext(t *testing.T) {
+	cc, _ := newTestConsulConfigCenter(t, &Config{Environment: "test"})
+	t.Cleanup(func() { cc.StopWatch("counter", "test") })
+
+	noop := func(oldConfig, newConfig *Config) error { return nil }
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+	if err := cc.WatchConfig(firstCtx, "counter", "test", noop); err != nil {
+		t.Fatalf("First WatchConfig failed: %v", err)
+	}
+	secondCtx, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	if err := cc.WatchConfig(secondCtx, "counter", "test", noop); err != nil {
+		t.Fatalf("Second WatchConfig failed: %v", err)
+	}
+
+	cc.mutex.RLock()
+	watcher := cc.watchers[cc.buildWatcherKey("counter", "test")]
+	cc.mutex.RUnlock()
+
+	// 取消第一次调用的ctx不影响重新绑定后的监听器
+	cancelFirst()
+	time.Sleep(50 * time.Millisecond)
+	cc.mutex.RLock()
+	running := watcher.running
+	cc.mutex.RUnlock()
+	if !running {
+		t.Fatal("Expected watcher to keep running after the replaced context was cancelled")
+	}
+
+	// 取消最近一次调用的ctx后监听器退出
+	cancelSecond()
+	waitFor(t, func() bool {
+		cc.mutex.RLock()
+		defer cc.mutex.RUnlock()
+		return !watcher.running
+	})
+}
+
+func TestWatchConfigRestartsAfterCallbackPanic(t *testing.T) {
+	cc, _ := newTestConsulConfigCenter(t, &Config{Environment: "test"})
+	t.Cleanup(func() { cc.StopWatch("counter", "test") })
+
+	panicking := func(oldConfig, newConfig *Config) error { panic("callback failed") }
+	if err := cc.WatchConfig(context.Background(), "counter", "test", panicking); err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+
+	// 回调panic后监听器被移除，不再标记为运行中
+	waitFor(t, func() bool {
+		cc.mutex.RLock()
+		defer cc.mutex.RUnlock()
+		_, exists := cc.watchers[cc.buildWatcherKey("counter", "test")]
+		return !exists
+	})
+
+	var calls int32
+	callback := func(oldConfig, newConfig *Config) error { atomic.AddInt32(&calls, 1); return nil }
+	if err := cc.WatchConfig(context.Background(), "counter", "test", callback); err != nil {
+		t.Fatalf("Expected re-watch after panic to succeed, got %v", err)
+	}
+	waitFor(t, func() bool { return atomic.LoadInt32(&calls) == 1 })
+}
+
+func TestWatchConfigBlockingQueryPropagatesChanges(t *testing.T) {
+	cc, kv := newTestConsulConfigCenter(t, &Config{Environment: "test"})
+	t.Cleanup(func() { cc.StopWatch("counter", "test") })
+
+	var mu sync.Mutex
+	var levels []string
+	callback := func(oldConfig, newConfig *Config) error {
+		mu.Lock()
+		defer mu.Unlock()
+		levels = append(levels, newConfig.Log.Level)
+		return nil
+	}
+	seen := func(n int) func() bool {
+		return func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(levels) == n
+		}
+	}
+
+	if err := cc.WatchConfig(context.Background(), "counter", "test", callback); err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+	waitFor(t, seen(1))
+
+	// 阻塞查询在修改后立即返回，不需要等待轮询间隔
+	kv.set(t, &Config{Environment: "test", Log: LogConfig{Level: "debug"}}, 8)
+	waitFor(t, seen(2))
+
+	// 索引回退后重新从当前值开始监听，之后的修改仍能收到
+	kv.set(t, &Config{Environment: "test", Log: LogConfig{Level: "warn"}}, 3)
+	waitFor(t, seen(3))
+	kv.set(t, &Config{Environment: "test", Log: LogConfig{Level: "error"}}, 4)
+	waitFor(t, seen(4))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(levels, ","); got != ",debug,warn,error" {
+		t.Errorf("Expected log levels ,debug,warn,error, got %s", got)
+	}
+}
+
+func TestNextWatchIndex(t *testing.T) {
+	cases := []struct {
+		previous, returned, want uint64
+	}{
+		{0, 7, 7},
+		{7, 8, 8},
+		{7, 7, 7},
+		{7, 3, 0}, // 索引回退时重置
+		{0, 0, 1}, // 索引为0时不能作为WaitIndex
+	}
+	for _, c := range cases {
+		if got := nextWatchIndex(c.previous, c.returned); got != c.want {
+			t.Errorf("nextWatchIndex(%d, %d) = %d, want %d", c.previous, c.returned, got, c.want)
+		}
+	}
+}
+
+func TestWatchRetryDelayBacksOffExponentially(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  watchRetryInitialDelay,
+		2:  2 * watchRetryInitialDelay,
+		3:  4 * watchRetryInitialDelay,
+		50: watchRetryMaxDelay,
+	}
+	for failures, want := range cases {
+		if got := watchRetryDelay(failures); got != want {
+			t.Errorf("watchRetryDelay(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+// newSlowConsulServer 模拟无响应的Consul，请求一直阻塞到客户端放弃
+func newSlowConsulServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) }) // 先于server.Close执行，释放阻塞的请求
+	return server
+}
+
+func TestConsulConfigCenterTimesOutOnHungConsul(t *testing.T) {
+	server := newSlowConsulServer(t)
+	consulConfig := &ConsulConfig{Address: strings.TrimPrefix(server.URL, "http://"), Timeout: 100 * time.Millisecond}
+
+	// 创建时的连接检查不会无限阻塞
+	start := time.Now()
+	if _, err := NewConsulConfigCenterWithConfig(consulConfig, zap.NewNop()); err == nil {
+		t.Fatal("Expected connection check to fail against hung consul")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected connection check to time out quickly, took %v", elapsed)
+	}
+
+	// 已创建的配置中心，KV调用同样受超时约束
+	apiConfig := api.DefaultConfig()
+	apiConfig.Address = server.URL
+	client, err := api.NewClient(apiConfig)
+	if err != nil {
+		t.Fatalf("Failed to create consul client: %v", err)
+	}
+	cc := &ConsulConfigCenter{
+		client:   client,
+		timeout:  100 * time.Millisecond,
+		logger:   zap.NewNop(),
+		watchers: make(map[string]*ConfigWatcher),
+	}
+
+	start = time.Now()
+	_, err = cc.GetConfig(context.Background(), "counter", "test")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected GetConfig to fail with deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected GetConfig to time out quickly, took %v", elapsed)
+	}
+}
+
+// fixedBackoff 测试用固定退避
+type fixedBackoff time.Duration
+
+func (b fixedBackoff) NextBackOff() time.Duration { return time.Duration(b) }
+func (b fixedBackoff) Reset()                     {}
+
+// flakyKV 模拟Consul KV：前failures次请求返回status，之后正常读写
+type flakyKV struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	requests int
+	value    []byte
+}
+
+func (kv *flakyKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.requests++
+	if kv.requests <= kv.failures {
+		http.Error(w, "rpc error: No cluster leader", kv.status)
+		return
+	}
+
+	w.Header().Set("X-Consul-Index", "7")
+	switch r.Method {
+	case http.MethodPut:
+		kv.value, _ = io.ReadAll(r.Body)
+		w.Write([]byte("true"))
+	default:
+		if kv.value == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]*api.KVPair{{Key: r.URL.Path, Value: kv.value, ModifyIndex: 7}})
+	}
+}
+
+func (kv *flakyKV) requestCount() int {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.requests
+}
+
+// newFlakyConfigCenter 创建指向flakyKV、最多尝试3次的配置中心
+func newFlakyConfigCenter(t *testing.T, kv *flakyKV) *ConsulConfigCenter {
+	t.Helper()
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	apiConfig := api.DefaultConfig()
+	apiConfig.Address = server.URL
+	client, err := api.NewClient(apiConfig)
+	if err != nil {
+		t.Fatalf("Failed to create consul client: %v", err)
+	}
+	cc := &ConsulConfigCenter{
+		client:   client,
+		timeout:  time.Second,
+		logger:   zap.NewNop(),
+		watchers: make(map[string]*ConfigWatcher),
+	}
+	cc.SetRetry(&ConsulRetryConfig{
+		MaxAttempts: 3,
+		NewBackoff:  func() Backoff { return fixedBackoff(time.Millisecond) },
+	})
+	return cc
+}
+
+func TestConsulConfigCenterRetriesTransientErrors(t *testing.T) {
+	value, _ := json.Marshal(&Config{Environment: "test"})
+	kv := &flakyKV{failures: 2, status: http.StatusInternalServerError, value: value}
+	cc := newFlakyConfigCenter(t, kv)
+
+	cfg, err := cc.GetConfig(context.Background(), "counter", "test")
+	if err != nil {
+		t.Fatalf("Expected GetConfig to succeed after retries, got %v", err)
+	}
+	if cfg.Environment != "test" || kv.requestCount() != 3 {
+		t.Errorf("Expected config after 3 attempts, got %+v after %d", cfg, kv.requestCount())
+	}
+
+	// Put的KV写入同样重试（历史版本写入失败只记录日志）
+	kv = &flakyKV{failures: 2, status: http.StatusServiceUnavailable}
+	cc = newFlakyConfigCenter(t, kv)
+	if err := cc.PutConfig(context.Background(), "counter", "test", &Config{Environment: "prod"}); err != nil {
+		t.Fatalf("Expected PutConfig to succeed after retries, got %v", err)
+	}
+	if !strings.Contains(string(kv.value), `"prod"`) {
+		t.Errorf("Expected config written, got %s", kv.value)
+	}
+}
+
+func TestConsulConfigCenterRetryLimits(t *testing.T) {
+	// 超过最大尝试次数后返回错误
+	kv := &flakyKV{failures: 10, status: http.StatusInternalServerError}
+	cc := newFlakyConfigCenter(t, kv)
+	if _, err := cc.GetConfig(context.Background(), "counter", "test"); err == nil {
+		t.Error("Expected GetConfig to fail after max attempts")
+	}
+	if got := kv.requestCount(); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+
+	// 配置不存在不重试
+	kv = &flakyKV{}
+	cc = newFlakyConfigCenter(t, kv)
+	if _, err := cc.GetConfig(context.Background(), "counter", "test"); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected ErrConfigNotFound, got %v", err)
+	}
+	if got := kv.requestCount(); got != 1 {
+		t.Errorf("Expected not-found not to be retried, got %d attempts", got)
+	}
+
+	// 权限错误等4xx不重试
+	kv = &flakyKV{failures: 10, status: http.StatusForbidden}
+	cc = newFlakyConfigCenter(t, kv)
+	if _, err := cc.GetConfig(context.Background(), "counter", "test"); err == nil {
+		t.Error("Expected GetConfig to fail on 403")
+	}
+	if got := kv.requestCount(); got != 1 {
+		t.Errorf("Expected 403 not to be retried, got %d attempts", got)
+	}
+}
+
+// memoryKV 模拟Consul的leader查询和KV的Put、Get、递归List、Delete，对failPutKey的Put返回500
+type memoryKV struct {
+	mu         sync.Mutex
+	values     map[string][]byte
+	failPutKey string
+}
+
+func (kv *memoryKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if r.URL.Path == "/v1/status/leader" {
+		w.Write([]byte(`"127.0.0.1:8300"`))
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	w.Header().Set("X-Consul-Index", "7")
+	switch r.Method {
+	case http.MethodPut:
+		if key == kv.failPutKey {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		kv.values[key], _ = io.ReadAll(r.Body)
+		w.Write([]byte("true"))
+	case http.MethodDelete:
+		delete(kv.values, key)
+		w.Write([]byte("true"))
+	default:
+		var pairs []*api.KVPair
+		for k, v := range kv.values {
+			if k == key || (r.URL.Query().Has("recurse") && strings.HasPrefix(k, key)) {
+				pairs = append(pairs, &api.KVPair{Key: k, Value: v, ModifyIndex: 7})
+			}
+		}
+		if len(pairs) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(pairs)
+	}
+}
+
+func TestPutConfigPrunesHistory(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	cc, err := NewConsulConfigCenterWithConfig(&ConsulConfig{
+		Address:      server.URL,
+		Timeout:      time.Second,
+		HistoryLimit: 3,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config center: %v", err)
+	}
+
+	ctx := context.Background()
+	const puts = 7
+	for i := 0; i < puts; i++ {
+		if err := cc.PutConfig(ctx, "counter", "test", &Config{Environment: fmt.Sprintf("put-%d", i)}); err != nil {
+			t.Fatalf("PutConfig %d failed: %v", i, err)
+		}
+	}
+
+	versions, err := cc.GetConfigHistory(ctx, "counter", "test")
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("Expected 3 history versions after %d puts, got %d", puts, len(versions))
+	}
+
+	// 保留的是最新的3个版本
+	kept := make(map[string]bool)
+	for _, version := range versions {
+		kept[version.Config.Environment] = true
+	}
+	for i := puts - 3; i < puts; i++ {
+		if !kept[fmt.Sprintf("put-%d", i)] {
+			t.Errorf("Expected put-%d to be kept, got %v", i, kept)
+		}
+	}
+
+	// 手动裁剪到1个版本
+	deleted, err := cc.PruneConfigHistory(ctx, "counter", "test", 1)
+	if err != nil || deleted != 2 {
+		t.Fatalf("Expected 2 versions pruned, got %d, %v", deleted, err)
+	}
+	versions, _ = cc.GetConfigHistory(ctx, "counter", "test")
+	if len(versions) != 1 || versions[0].Config.Environment != fmt.Sprintf("put-%d", puts-1) {
+		t.Errorf("Expected only the latest version to remain, got %+v", versions)
+	}
+
+	if _, err := cc.PruneConfigHistory(ctx, "counter", "test", 0); err == nil {
+		t.Error("Expected error for non-positive keep")
+	}
+}
+
+func TestConfigHistoryIsolatedFromPrefixedServices(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	cc, err := NewConsulConfigCenterWithConfig(&ConsulConfig{Address: server.URL, Timeout: time.Second}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config center: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		for _, service := range []string{"counter", "counter-v2"} {
+			if err := cc.PutConfig(ctx, service, "test", &Config{Environment: service}); err != nil {
+				t.Fatalf("PutConfig %s failed: %v", service, err)
+			}
+		}
+	}
+
+	// counter的历史不包含counter-v2的版本
+	versions, err := cc.GetConfigHistory(ctx, "counter", "test")
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 counter versions, got %d", len(versions))
+	}
+	for _, version := range versions {
+		if version.Config.Environment != "counter" {
+			t.Errorf("Expected only counter versions, got %s", version.Config.Environment)
+		}
+	}
+
+	// 裁剪counter不删除counter-v2的历史
+	if deleted, err := cc.PruneConfigHistory(ctx, "counter", "test", 1); err != nil || deleted != 1 {
+		t.Fatalf("Expected 1 counter version pruned, got %d, %v", deleted, err)
+	}
+	if versions, _ := cc.GetConfigHistory(ctx, "counter-v2", "test"); len(versions) != 2 {
+		t.Errorf("Expected counter-v2 history untouched, got %d versions", len(versions))
+	}
+}
+
+func TestPutConfigFailureKeepsHistory(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	cc, err := NewConsulConfigCenterWithConfig(&ConsulConfig{
+		Address:      server.URL,
+		Timeout:      time.Second,
+		HistoryLimit: 2,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config center: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := cc.PutConfig(ctx, "counter", "test", &Config{Environment: fmt.Sprintf("put-%d", i)}); err != nil {
+			t.Fatalf("PutConfig %d failed: %v", i, err)
+		}
+	}
+
+	// 写入当前配置失败时不保存历史，也不裁剪掉已有的可回滚版本
+	kv.mu.Lock()
+	kv.failPutKey = cc.buildConfigKey("counter", "test")
+	kv.mu.Unlock()
+	if err := cc.PutConfig(ctx, "counter", "test", &Config{Environment: "failed"}); err == nil {
+		t.Fatal("Expected PutConfig to fail")
+	}
+
+	versions, err := cc.GetConfigHistory(ctx, "counter", "test")
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+	kept := make(map[string]bool)
+	for _, version := range versions {
+		kept[version.Config.Environment] = true
+	}
+	if len(versions) != 2 || !kept["put-0"] || !kept["put-1"] {
+		t.Errorf("Expected history put-0 and put-1 kept after failed put, got %v", kept)
+	}
+}
+
+func TestPutConfigEncryptsSensitiveFields(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	t.Setenv(ConfigEncryptionKeyEnv, base64.StdEncoding.EncodeToString(key))
+
+	cc, err := NewConsulConfigCenterWithConfig(&ConsulConfig{Address: server.URL, Timeout: time.Second}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config center: %v", err)
+	}
+
+	original := &Config{Environment: "test"}
+	original.Redis.Address = "redis:6379"
+	original.Redis.Password = "s3cret"
+	original.Redis.Shards = []RedisShardConfig{{Address: "shard:6379", Password: "shard-secret"}}
+	original.Discovery.Consul.Token = "consul-token"
+
+	ctx := context.Background()
+	if err := cc.PutConfig(ctx, "counter", "test", original); err != nil {
+		t.Fatalf("PutConfig failed: %v", err)
+	}
+
+	// 传入的配置不被修改
+	if original.Redis.Password != "s3cret" {
+		t.Errorf("Expected PutConfig not to modify the caller's config, got %q", original.Redis.Password)
+	}
+
+	// Consul中敏感字段为密文，其余字段可读；历史版本同样不含明文
+	kv.mu.Lock()
+	for k, raw := range kv.values {
+		for _, secret := range []string{"s3cret", "shard-secret", "consul-token"} {
+			if strings.Contains(string(raw), secret) {
+				t.Errorf("Expected %s to be encrypted at rest in %s", secret, k)
+			}
+		}
+	}
+	stored := string(kv.values[cc.buildConfigKey("counter", "test")])
+	kv.mu.Unlock()
+	if !strings.Contains(stored, "redis:6379") || !strings.Contains(stored, encryptedValuePrefix) {
+		t.Errorf("Expected readable address and encrypted password, got %s", stored)
+	}
+
+	// 读取后解密为可用的明文
+	loaded, err := cc.GetConfig(ctx, "counter", "test")
+	if err != nil {
+		t.Fatalf("GetConfig failed: %v", err)
+	}
+	if loaded.Redis.Password != "s3cret" || loaded.Redis.Shards[0].Password != "shard-secret" || loaded.Discovery.Consul.Token != "consul-token" {
+		t.Errorf("Expected decrypted secrets after load, got %+v", loaded.Redis)
+	}
+	versions, err := cc.GetConfigHistory(ctx, "counter", "test")
+	if err != nil || len(versions) != 1 || versions[0].Config.Redis.Password != "s3cret" {
+		t.Errorf("Expected decrypted history version, got %v, %v", versions, err)
+	}
+
+	// 没有密钥时拒绝返回密文
+	cc.SetEncryptor(nil)
+	if _, err := cc.GetConfig(ctx, "counter", "test"); !errors.Is(err, ErrEncryptionKeyMissing) {
+		t.Errorf("Expected ErrEncryptionKeyMissing without key, got %v", err)
+	}
+}
+
+func TestListConfigsParsesServiceAndEnvironment(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	cc, err := NewConsulConfigCenterWithConfig(&ConsulConfig{Address: server.URL, Timeout: time.Second}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config center: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, key := range []struct{ service, environment string }{
+		{"gateway", "prod"},
+		{"counter", "prod"},
+		{"counter", "dev"},
+	} {
+		if err := cc.PutConfig(ctx, key.service, key.environment, &Config{Environment: key.environment}); err != nil {
+			t.Fatalf("PutConfig failed: %v", err)
+		}
+	}
+	// 不符合 环境/服务 格式的键被跳过，历史版本不在配置前缀下
+	kv.mu.Lock()
+	kv.values[configKeyPrefix+"orphan"] = []byte("{}")
+	kv.mu.Unlock()
+
+	keys, err := cc.ListConfigs(ctx)
+	if err != nil {
+		t.Fatalf("ListConfigs failed: %v", err)
+	}
+	expected := []ConfigKey{
+		{Service: "counter", Environment: "dev", ModifyIndex: 7},
+		{Service: "counter", Environment: "prod", ModifyIndex: 7},
+		{Service: "gateway", Environment: "prod", ModifyIndex: 7},
+	}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected %d configs, got %+v", len(expected), keys)
+	}
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Errorf("Expected config %d to be %+v, got %+v", i, expected[i], keys[i])
+		}
+	}
+}
+
+func TestRollbackConfigRestoresHistoryVersion(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	cc, err := NewConsulConfigCenterWithConfig(&ConsulConfig{Address: server.URL, Timeout: time.Second}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config center: %v", err)
+	}
+
+	ctx := context.Background()
+	good, err := NewManager(zap.NewNop()).Load(writeTestConfig(t, testConfigYAML))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	good.Redis.Address = "redis-a:6379"
+	if err := cc.PutConfig(ctx, "counter", "test", good); err != nil {
+		t.Fatalf("PutConfig failed: %v", err)
+	}
+	// 缺少environment，校验不通过
+	invalid := *good
+	invalid.Environment = ""
+	invalid.Redis.Address = "redis-b:6379"
+	if err := cc.PutConfig(ctx, "counter", "test", &invalid); err != nil {
+		t.Fatalf("PutConfig failed: %v", err)
+	}
+
+	versions, err := cc.GetConfigHistory(ctx, "counter", "test")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("Expected 2 history versions, got %d, %v", len(versions), err)
+	}
+	versionOf := func(address string) string {
+		for _, v := range versions {
+			if v.Config.Redis.Address == address {
+				return v.Version
+			}
+		}
+		t.Fatalf("No history version with redis address %s", address)
+		return ""
+	}
+
+	// 不存在的版本
+	if err := cc.RollbackConfig(ctx, "counter", "test", "v0"); !errors.Is(err, ErrConfigVersionNotFound) {
+		t.Errorf("Expected ErrConfigVersionNotFound, got %v", err)
+	}
+
+	// 校验失败的版本不写入
+	if err := cc.RollbackConfig(ctx, "counter", "test", versionOf("redis-b:6379")); err == nil {
+		t.Error("Expected rollback to an invalid version to fail")
+	}
+
+	target := versionOf("redis-a:6379")
+	if err := cc.RollbackConfig(ctx, "counter", "test", target); err != nil {
+		t.Fatalf("RollbackConfig failed: %v", err)
+	}
+	current, err := cc.GetConfig(ctx, "counter", "test")
+	if err != nil || current.Redis.Address != "redis-a:6379" {
+		t.Fatalf("Expected rolled back config, got %+v, %v", current, err)
+	}
+
+	// 回滚本身记录为新的历史版本
+	versions, _ = cc.GetConfigHistory(ctx, "counter", "test")
+	if len(versions) != 3 {
+		t.Fatalf("Expected 3 history versions after rollback, got %d", len(versions))
+	}
+	found := false
+	for _, v := range versions {
+		if v.Comment == "Rollback to "+target && v.Version != target {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a history entry noting the rollback to %s", target)
+	}
+}
+
+func TestConfigCenterEnvelopeEncryptionOption(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(255 - i)
+	}
+	cc, err := NewConsulConfigCenterWithConfig(&ConsulConfig{Address: server.URL, Timeout: time.Second}, zap.NewNop(),
+		WithEncryptionKey(key), WithEncryptedFields("kafka.security.sasl.username"))
+	if err != nil {
+		t.Fatalf("Failed to create config center: %v", err)
+	}
+
+	original := &Config{Environment: "test"}
+	original.Redis.Password = "redis-pass"
+	original.Kafka.Security.SASL.Username = "kafka-user"
+	original.Kafka.Security.SASL.Password = "kafka-pass"
+
+	ctx := context.Background()
+	if err := cc.PutConfig(ctx, "counter", "test", original); err != nil {
+		t.Fatalf("PutConfig failed: %v", err)
+	}
+
+	// Consul中只有信封格式的密文，额外指定的字段同样加密
+	kv.mu.Lock()
+	stored := string(kv.values[cc.buildConfigKey("counter", "test")])
+	kv.mu.Unlock()
+	for _, secret := range []string{"redis-pass", "kafka-user", "kafka-pass"} {
+		if strings.Contains(stored, secret) {
+			t.Errorf("Expected %s to be encrypted at rest, got %s", secret, stored)
+		}
+	}
+	if !strings.Contains(stored, envelopeValuePrefix) {
+		t.Errorf("Expected envelope encrypted values, got %s", stored)
+	}
+
+	loaded, err := cc.GetConfig(ctx, "counter", "test")
+	if err != nil {
+		t.Fatalf("GetConfig failed: %v", err)
+	}
+	if loaded.Redis.Password != "redis-pass" || loaded.Kafka.Security.SASL.Username != "kafka-user" || loaded.Kafka.Security.SASL.Password != "kafka-pass" {
+		t.Errorf("Expected decrypted round trip, got redis=%q sasl=%+v", loaded.Redis.Password, loaded.Kafka.Security.SASL)
+	}
+
+	// 加密上线前写入的明文配置和v1格式的密文仍可读取
+	legacy, err := NewFieldEncryptor(key)
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	sealed, err := seal(legacy.aead, []byte("legacy-pass"))
+	if err != nil {
+		t.Fatalf("Failed to seal legacy value: %v", err)
+	}
+	kv.mu.Lock()
+	kv.values[cc.buildConfigKey("analytics", "test")] = []byte(`{"environment":"test","redis":{"password":"plain-pass"}}`)
+	kv.values[cc.buildConfigKey("gateway", "test")] = []byte(`{"environment":"test","redis":{"password":"` +
+		directValuePrefix + base64.StdEncoding.EncodeToString(sealed) + `"}}`)
+	kv.mu.Unlock()
+	if plain, err := cc.GetConfig(ctx, "analytics", "test"); err != nil || plain.Redis.Password != "plain-pass" {
+		t.Errorf("Expected plaintext config to decode, got %v", err)
+	}
+	if v1, err := cc.GetConfig(ctx, "gateway", "test"); err != nil || v1.Redis.Password != "legacy-pass" {
+		t.Errorf("Expected v1 encrypted config to decode, got %v", err)
+	}
+}
+
+func TestConfigCenterEncryptionOptionValidation(t *testing.T) {
+	kv := &memoryKV{values: make(map[string][]byte)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+	consulConfig := &ConsulConfig{Address: server.URL, Timeout: time.Second}
+	t.Setenv(ConfigEncryptionKeyEnv, "")
+
+	if _, err := NewConsulConfigCenterWithConfig(consulConfig, zap.NewNop(), WithEncryptionKey(make([]byte, 16))); err == nil {
+		t.Error("Expected error for a non AES-256 key")
+	}
+	if _, err := NewConsulConfigCenterWithConfig(consulConfig, zap.NewNop(),
+		WithEncryptionKey(make([]byte, 32)), WithEncryptedFields("redis.no_such_field")); err == nil {
+		t.Error("Expected error for an unknown field path")
+	}
+	if _, err := NewConsulConfigCenterWithConfig(consulConfig, zap.NewNop(),
+		WithEncryptedFields("redis.address")); !errors.Is(err, ErrEncryptionKeyMissing) {
+		t.Errorf("Expected ErrEncryptionKeyMissing for fields without a key, got %v", err)
+	}
+}
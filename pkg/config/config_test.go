@@ -0,0 +1,426 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"high-go-press/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+const testConfigYAML = `
+environment: "test"
+redis:
+  address: "localhost:6379"
+  pool_size: 20
+kafka:
+  mode: "mock"
+  topic: "counter-events"
+`
+
+// memoryConfigCenter 内存实现的配置中心，用于测试
+type memoryConfigCenter struct {
+	mu        sync.Mutex
+	configs   map[string]*Config
+	callbacks map[string]ConfigChangeCallback
+}
+
+func newMemoryConfigCenter() *memoryConfigCenter {
+	return &memoryConfigCenter{
+		configs:   make(map[string]*Config),
+		callbacks: make(map[string]ConfigChangeCallback),
+	}
+}
+
+// publish 更新配置并通知监听者
+func (c *memoryConfigCenter) publish(service, environment string, config *Config) error {
+	c.mu.Lock()
+	key := c.key(service, environment)
+	oldConfig := c.configs[key]
+	copied := *config
+	c.configs[key] = &copied
+	callback := c.callbacks[key]
+	c.mu.Unlock()
+
+	if callback == nil {
+		return nil
+	}
+	return callback(oldConfig, config)
+}
+
+func (c *memoryConfigCenter) key(service, environment string) string {
+	return environment + "/" + service
+}
+
+func (c *memoryConfigCenter) GetConfig(ctx context.Context, service, environment string) (*Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	config, ok := c.configs[c.key(service, environment)]
+	if !ok {
+		return nil, fmt.Errorf("config not found for service %s in environment %s", service, environment)
+	}
+	copied := *config
+	return &copied, nil
+}
+
+func (c *memoryConfigCenter) PutConfig(ctx context.Context, service, environment string, config *Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	copied := *config
+	c.configs[c.key(service, environment)] = &copied
+	return nil
+}
+
+func (c *memoryConfigCenter) WatchConfig(ctx context.Context, service, environment string, callback ConfigChangeCallback) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callbacks[c.key(service, environment)] = callback
+	return nil
+}
+
+func (c *memoryConfigCenter) StopWatch(service, environment string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.callbacks, c.key(service, environment))
+}
+
+func (c *memoryConfigCenter) DeleteConfig(ctx context.Context, service, environment string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.configs, c.key(service, environment))
+	return nil
+}
+
+func (c *memoryConfigCenter) GetConfigHistory(ctx context.Context, service, environment string) ([]*ConfigVersion, error) {
+	return nil, nil
+}
+
+func (c *memoryConfigCenter) RollbackConfig(ctx context.Context, service, environment, version string) error {
+	return ErrConfigVersionNotFound
+}
+
+func (c *memoryConfigCenter) ListConfigs(ctx context.Context) ([]ConfigKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]ConfigKey, 0, len(c.configs))
+	for key := range c.configs {
+		environment, service, _ := strings.Cut(key, "/")
+		keys = append(keys, ConfigKey{Service: service, Environment: environment})
+	}
+	return keys, nil
+}
+
+// writeTestConfig 写入临时配置文件
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+// gaugeValue 从指标注册表读取gauge值
+func gaugeValue(t *testing.T, mm *metrics.MetricsManager, name string) float64 {
+	t.Helper()
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == name && len(family.GetMetric()) > 0 {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("Metric %s not found", name)
+	return 0
+}
+
+func TestConfigDriftGauge(t *testing.T) {
+	path := writeTestConfig(t, testConfigYAML)
+
+	fileConfig, err := NewManager(zap.NewNop()).Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config file: %v", err)
+	}
+
+	// 配置中心中的Redis地址与文件不同
+	center := newMemoryConfigCenter()
+	centerConfig := *fileConfig
+	centerConfig.Redis.Address = "redis-center:6379"
+	center.PutConfig(context.Background(), "counter", "test", &centerConfig)
+
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	manager := NewManagerWithCenter(zap.NewNop(), center)
+	manager.SetDriftObserver(func(drifted bool, fields []string) {
+		mm.SetConfigDrift("counter", drifted)
+	})
+
+	config, err := manager.LoadWithServiceInfo(path, "counter", "test")
+	if err != nil {
+		t.Fatalf("LoadWithServiceInfo failed: %v", err)
+	}
+	if config.Redis.Address != "redis-center:6379" {
+		t.Errorf("Expected center config to be active, got redis address %s", config.Redis.Address)
+	}
+
+	if got := gaugeValue(t, mm, "test_config_drift"); got != 1 {
+		t.Errorf("Expected config_drift gauge 1, got %v", got)
+	}
+	if drift := manager.GetDrift(); len(drift) != 1 || drift[0] != "redis.address" {
+		t.Errorf("Expected drift [redis.address], got %v", drift)
+	}
+}
+
+func TestDriftObserverSetAfterLoadReportsCurrentDrift(t *testing.T) {
+	path := writeTestConfig(t, testConfigYAML)
+	fileConfig, err := NewManager(zap.NewNop()).Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config file: %v", err)
+	}
+
+	center := newMemoryConfigCenter()
+	centerConfig := *fileConfig
+	centerConfig.Redis.Address = "redis-center:6379"
+	center.PutConfig(context.Background(), "counter", "test", &centerConfig)
+
+	manager := NewManagerWithCenter(zap.NewNop(), center)
+	if _, err := manager.LoadWithServiceInfo(path, "counter", "test"); err != nil {
+		t.Fatalf("LoadWithServiceInfo failed: %v", err)
+	}
+
+	// 加载完成后才设置观察者（如指标在配置加载后初始化），仍能拿到当前漂移状态
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	manager.SetDriftObserver(func(drifted bool, fields []string) {
+		mm.SetConfigDrift("counter", drifted)
+	})
+	if got := gaugeValue(t, mm, "test_config_drift"); got != 1 {
+		t.Errorf("Expected config_drift gauge 1, got %v", got)
+	}
+}
+
+func TestConfigNoDriftWhenLoadedFromFile(t *testing.T) {
+	path := writeTestConfig(t, testConfigYAML)
+
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	manager := NewManager(zap.NewNop())
+	manager.SetDriftObserver(func(drifted bool, fields []string) {
+		mm.SetConfigDrift("counter", drifted)
+	})
+
+	if _, err := manager.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := gaugeValue(t, mm, "test_config_drift"); got != 0 {
+		t.Errorf("Expected config_drift gauge 0, got %v", got)
+	}
+}
+
+func TestLoadMergesPartialCenterConfig(t *testing.T) {
+	path := writeTestConfig(t, testConfigYAML)
+
+	// 配置中心只设置了部分字段
+	center := newMemoryConfigCenter()
+	center.PutConfig(context.Background(), "counter", "test", &Config{
+		Redis: RedisConfig{Address: "redis-center:6379"},
+		Counter: CounterConfig{
+			Performance: PerformanceConfig{BatchSize: 500},
+		},
+	})
+
+	manager := NewManagerWithCenter(zap.NewNop(), center)
+	config, err := manager.LoadWithServiceInfo(path, "counter", "test")
+	if err != nil {
+		t.Fatalf("LoadWithServiceInfo failed: %v", err)
+	}
+
+	// 配置中心设置的字段生效
+	if config.Redis.Address != "redis-center:6379" {
+		t.Errorf("Expected redis address from center, got %s", config.Redis.Address)
+	}
+	if config.Counter.Performance.BatchSize != 500 {
+		t.Errorf("Expected batch size 500 from center, got %d", config.Counter.Performance.BatchSize)
+	}
+
+	// 未设置的字段沿用文件和默认值
+	if config.Environment != "test" {
+		t.Errorf("Expected environment from file, got %q", config.Environment)
+	}
+	if config.Redis.PoolSize != 20 {
+		t.Errorf("Expected redis pool size from file, got %d", config.Redis.PoolSize)
+	}
+	if config.Kafka.Topic != "counter-events" {
+		t.Errorf("Expected kafka topic from file, got %q", config.Kafka.Topic)
+	}
+	if config.Gateway.Server.Port != 8080 {
+		t.Errorf("Expected default gateway port 8080, got %d", config.Gateway.Server.Port)
+	}
+	if config.Counter.Performance.WorkerPoolSize != 1000 {
+		t.Errorf("Expected default worker pool size 1000, got %d", config.Counter.Performance.WorkerPoolSize)
+	}
+}
+
+func TestConfigChangeEventChangedPaths(t *testing.T) {
+	path := writeTestConfig(t, testConfigYAML)
+
+	center := newMemoryConfigCenter()
+	manager := NewManagerWithCenter(zap.NewNop(), center)
+	initial, err := manager.LoadWithServiceInfo(path, "counter", "test")
+	if err != nil {
+		t.Fatalf("LoadWithServiceInfo failed: %v", err)
+	}
+
+	var events []*ConfigChangeEvent
+	manager.AddConfigEventWatcher(func(event *ConfigChangeEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	legacyCalls := 0
+	manager.AddConfigWatcher(func(oldConfig, newConfig *Config) error {
+		legacyCalls++
+		return nil
+	})
+
+	if err := manager.StartWatchConfig(context.Background(), "counter", "test"); err != nil {
+		t.Fatalf("StartWatchConfig failed: %v", err)
+	}
+
+	updated := *initial
+	updated.Log.Level = "debug"
+	updated.Redis.PoolSize = 50
+	if err := center.publish("counter", "test", &updated); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	if legacyCalls != 1 {
+		t.Errorf("Expected legacy watcher to be called once, got %d", legacyCalls)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 change event, got %d", len(events))
+	}
+
+	event := events[0]
+	expected := []string{"redis.pool_size", "log.level"}
+	if fmt.Sprint(event.ChangedPaths) != fmt.Sprint(expected) {
+		t.Errorf("Expected changed paths %v, got %v", expected, event.ChangedPaths)
+	}
+	if !event.HasChanged("log.level") || !event.HasChanged("redis") {
+		t.Error("Expected log.level and redis to be reported as changed")
+	}
+	if event.HasChanged("kafka") || event.HasChanged("log.format") {
+		t.Error("Expected kafka and log.format to be unchanged")
+	}
+	if event.OldConfig.Log.Level == event.NewConfig.Log.Level {
+		t.Error("Expected event to carry old and new config")
+	}
+}
+
+func TestValidateRedisConfigModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RedisConfig
+		wantErr bool
+	}{
+		{"standalone", RedisConfig{Address: "localhost:6379"}, false},
+		{"standalone without address", RedisConfig{Mode: RedisModeStandalone}, true},
+		{"cluster", RedisConfig{Mode: RedisModeCluster, Addresses: []string{"n1:6379", "n2:6379"}}, false},
+		{"cluster without nodes", RedisConfig{Mode: RedisModeCluster, Address: "n1:6379"}, true},
+		{"cluster with db", RedisConfig{Mode: RedisModeCluster, Addresses: []string{"n1:6379"}, DB: 1}, true},
+		{"sentinel", RedisConfig{Mode: RedisModeSentinel, MasterName: "mymaster", Addresses: []string{"s1:26379"}}, false},
+		{"sentinel without master", RedisConfig{Mode: RedisModeSentinel, Addresses: []string{"s1:26379"}}, true},
+		{"sentinel without sentinels", RedisConfig{Mode: RedisModeSentinel, MasterName: "mymaster"}, true},
+		{"empty address entry", RedisConfig{Mode: RedisModeCluster, Addresses: []string{"n1:6379", ""}}, true},
+		{"unknown mode", RedisConfig{Mode: "proxy", Address: "localhost:6379"}, true},
+	}
+
+	for _, tt := range tests {
+		err := validateRedisConfig("redis", tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateRedisConfig error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateKafkaSecurity(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     KafkaConfig
+		wantErr bool
+	}{
+		{"no auth", KafkaConfig{Mode: "real"}, false},
+		{"scram", KafkaConfig{Mode: "real", Security: KafkaSecurityConfig{SASL: KafkaSASLConfig{Mechanism: "SCRAM-SHA-512", Username: "u", Password: "p"}}}, false},
+		{"missing username", KafkaConfig{Mode: "real", Security: KafkaSecurityConfig{SASL: KafkaSASLConfig{Mechanism: "PLAIN", Password: "p"}}}, true},
+		{"missing password", KafkaConfig{Mode: "real", Security: KafkaSecurityConfig{SASL: KafkaSASLConfig{Mechanism: "SCRAM-SHA-256", Username: "u"}}}, true},
+		{"mock mode skips credentials", KafkaConfig{Mode: "mock", Security: KafkaSecurityConfig{SASL: KafkaSASLConfig{Mechanism: "PLAIN"}}}, false},
+		{"unknown mechanism", KafkaConfig{Mode: "real", Security: KafkaSecurityConfig{SASL: KafkaSASLConfig{Mechanism: "GSSAPI", Username: "u", Password: "p"}}}, true},
+		{"cert without key", KafkaConfig{Mode: "real", Security: KafkaSecurityConfig{TLS: KafkaTLSConfig{Enabled: true, CertFile: "client.pem"}}}, true},
+	}
+
+	for _, tt := range tests {
+		err := validateKafkaSecurity(tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateKafkaSecurity error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadKafkaQueueSize(t *testing.T) {
+	// 未设置时使用默认容量
+	cfg, err := NewManager(zap.NewNop()).Load(writeTestConfig(t, testConfigYAML))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if size := cfg.Kafka.Producer.QueueSize; size == nil || *size != 10000 {
+		t.Errorf("Expected default queue size 10000, got %v", size)
+	}
+
+	// 显式设为0时保留0，表示不使用队列
+	cfg, err = NewManager(zap.NewNop()).Load(writeTestConfig(t, testConfigYAML+"  producer:\n    queue_size: 0\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if size := cfg.Kafka.Producer.QueueSize; size == nil || *size != 0 {
+		t.Errorf("Expected queue size 0 to be kept, got %v", size)
+	}
+}
+
+func TestLoadRunsStructTagValidation(t *testing.T) {
+	// queue_size只有结构体标签校验，Load也必须拒绝
+	path := writeTestConfig(t, testConfigYAML+"  producer:\n    queue_size: -1\n")
+	_, err := NewManager(zap.NewNop()).Load(path)
+	if err == nil {
+		t.Fatal("Expected Load to reject a config failing struct tag validation")
+	}
+	if !strings.Contains(err.Error(), "kafka.producer.queue_size") {
+		t.Errorf("Expected error to name kafka.producer.queue_size, got %v", err)
+	}
+}
+
+func TestLoadCounterAuthIndependentOfGateway(t *testing.T) {
+	// 默认不开启Counter gRPC认证和配额
+	cfg, err := NewManager(zap.NewNop()).Load(writeTestConfig(t, testConfigYAML))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Counter.Auth.Enabled || cfg.Counter.Auth.Quota {
+		t.Errorf("Expected counter auth and quota disabled by default, got %+v", cfg.Counter.Auth)
+	}
+
+	// 只开启Counter认证时同样校验共用的认证提供者
+	counterAuth := "auth:\n  provider: \"api_key\"\ncounter:\n  auth:\n    enabled: true\n"
+	if _, err := NewManager(zap.NewNop()).Load(writeTestConfig(t, testConfigYAML+counterAuth)); err == nil {
+		t.Error("Expected counter auth without api keys to be rejected")
+	}
+
+	// Counter配额需开启Counter认证
+	counterQuota := "counter:\n  auth:\n    quota: true\n"
+	if _, err := NewManager(zap.NewNop()).Load(writeTestConfig(t, testConfigYAML+counterQuota)); err == nil {
+		t.Error("Expected counter quota without counter auth to be rejected")
+	}
+}
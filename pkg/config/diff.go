@@ -0,0 +1,87 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// DiffConfig 比较两份配置，返回存在差异的字段路径（按mapstructure标签，如"redis.address"）
+func DiffConfig(a, b *Config) []string {
+	if a == nil || b == nil {
+		if a == b {
+			return nil
+		}
+		return []string{"*"} // 其中一份配置缺失，视为整体不同
+	}
+
+	var paths []string
+	diffValue(reflect.ValueOf(*a), reflect.ValueOf(*b), "", &paths)
+	return paths
+}
+
+// diffValue 递归比较结构体字段，非结构体字段整体比较
+func diffValue(a, b reflect.Value, prefix string, paths *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*paths = append(*paths, prefix)
+		}
+		return
+	}
+
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldKey(field)
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		diffValue(a.Field(i), b.Field(i), name, paths)
+	}
+}
+
+// fieldKey 获取字段的配置键名
+func fieldKey(field reflect.StructField) string {
+	if tag := field.Tag.Get("mapstructure"); tag != "" {
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(field.Name)
+}
+
+// MergeConfig 以base为底，逐字段用override中的非零值覆盖，返回新的配置
+// 注意：布尔字段的false与未设置无法区分，override中为false时沿用base的值
+func MergeConfig(base, override *Config) *Config {
+	if base == nil {
+		return override
+	}
+
+	merged := *base
+	if override == nil {
+		return &merged
+	}
+
+	mergeValue(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(*override))
+	return &merged
+}
+
+// mergeValue 递归合并结构体字段，非结构体字段仅在override非零时覆盖
+func mergeValue(dst, src reflect.Value) {
+	if dst.Kind() != reflect.Struct {
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+		return
+	}
+
+	for i := 0; i < dst.NumField(); i++ {
+		if !dst.Type().Field(i).IsExported() {
+			continue
+		}
+		mergeValue(dst.Field(i), src.Field(i))
+	}
+}
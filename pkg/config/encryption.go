@@ -0,0 +1,304 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ConfigEncryptionKeyEnv 配置字段加密密钥的环境变量，值为base64编码的16/24/32字节AES密钥
+const ConfigEncryptionKeyEnv = "HGP_CONFIG_ENCRYPTION_KEY"
+
+// encryptedValuePrefix 加密后字段值的公共前缀，后接格式版本，用于区分明文和密文
+const encryptedValuePrefix = "enc:"
+
+// directValuePrefix v1格式：主密钥直接加密，enc:v1:base64(nonce|密文)，只用于读取旧数据
+const directValuePrefix = encryptedValuePrefix + "v1:"
+
+// envelopeValuePrefix v2信封格式：每个值使用随机数据密钥加密，数据密钥由主密钥加密后与密文一起存储
+// enc:v2:base64(主密钥nonce|加密的数据密钥|数据密钥nonce|密文)
+const envelopeValuePrefix = encryptedValuePrefix + "v2:"
+
+// dataKeySize 信封加密数据密钥的长度，AES-256
+const dataKeySize = 32
+
+// sensitiveTag 标记敏感字段的结构体标签，值为true的string字段在配置中心中加密存储
+const sensitiveTag = "sensitive"
+
+// ErrEncryptionKeyMissing 配置中包含加密字段但未配置解密密钥
+var ErrEncryptionKeyMissing = errors.New("config contains encrypted fields but no encryption key is configured")
+
+// FieldEncryptor 使用AES-GCM信封加密配置中的敏感字段
+type FieldEncryptor struct {
+	aead   cipher.AEAD
+	fields map[string]bool // 除sensitive标签外额外加密的字段路径
+}
+
+// NewFieldEncryptor 使用AES密钥创建字段加密器，密钥长度必须为16、24或32字节
+// 密钥可以来自环境变量，也可以由KMS解密数据密钥后传入
+func NewFieldEncryptor(key []byte) (*FieldEncryptor, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config encryption key: %w", err)
+	}
+	return &FieldEncryptor{aead: aead}, nil
+}
+
+// newAESGCM 使用AES密钥创建AES-GCM
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SetEncryptedFields 设置除sensitive标签外额外加密的字段路径，路径按mapstructure标签，如"kafka.security.sasl.username"
+// 切片和map中的结构体字段不含下标，如"redis.shards.password"；路径不是Config中的string字段时返回错误
+func (e *FieldEncryptor) SetEncryptedFields(paths ...string) error {
+	known := make(map[string]bool)
+	collectStringFieldPaths(reflect.TypeOf(Config{}), "", known)
+
+	fields := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if !known[path] {
+			return fmt.Errorf("encrypted field %q is not a string field of config", path)
+		}
+		fields[path] = true
+	}
+	e.fields = fields
+	return nil
+}
+
+// collectStringFieldPaths 收集类型中所有string字段的路径
+func collectStringFieldPaths(t reflect.Type, prefix string, paths map[string]bool) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		collectStringFieldPaths(t.Elem(), prefix, paths)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			path := fieldPath(prefix, field)
+			if field.Type.Kind() == reflect.String {
+				paths[path] = true
+				continue
+			}
+			collectStringFieldPaths(field.Type, path, paths)
+		}
+	}
+}
+
+// fieldPath 拼接字段路径，squash的嵌入字段沿用上级路径
+func fieldPath(prefix string, field reflect.StructField) string {
+	if field.Anonymous && strings.Contains(field.Tag.Get("mapstructure"), ",squash") {
+		return prefix
+	}
+	if prefix == "" {
+		return fieldKey(field)
+	}
+	return prefix + "." + fieldKey(field)
+}
+
+// isEncryptedValue 判断字段值是否为支持的加密格式
+func isEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, directValuePrefix) || strings.HasPrefix(value, envelopeValuePrefix)
+}
+
+// NewFieldEncryptorFromEnv 从ConfigEncryptionKeyEnv读取密钥创建字段加密器，环境变量未设置时返回nil
+func NewFieldEncryptorFromEnv() (*FieldEncryptor, error) {
+	encoded := os.Getenv(ConfigEncryptionKeyEnv)
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be base64 encoded: %w", ConfigEncryptionKeyEnv, err)
+	}
+	return NewFieldEncryptor(key)
+}
+
+// encrypt 使用随机数据密钥信封加密单个值，结果为enc:v2:前缀的格式
+func (e *FieldEncryptor) encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dataAEAD, err := newAESGCM(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AES-GCM: %w", err)
+	}
+
+	wrappedKey, err := seal(e.aead, dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataAEAD, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return envelopeValuePrefix + base64.StdEncoding.EncodeToString(append(wrappedKey, sealed...)), nil
+}
+
+// decrypt 解密单个加密值，兼容enc:v1:和enc:v2:两种格式
+func (e *FieldEncryptor) decrypt(value string) (string, error) {
+	if strings.HasPrefix(value, directValuePrefix) {
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, directValuePrefix))
+		if err != nil {
+			return "", fmt.Errorf("malformed encrypted value: %w", err)
+		}
+		plaintext, err := open(e.aead, sealed)
+		return string(plaintext), err
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, envelopeValuePrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	wrappedSize := e.aead.NonceSize() + dataKeySize + e.aead.Overhead()
+	if len(payload) < wrappedSize {
+		return "", errors.New("malformed encrypted value: too short")
+	}
+	dataKey, err := open(e.aead, payload[:wrappedSize])
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAESGCM(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	plaintext, err := open(dataAEAD, payload[wrappedSize:])
+	return string(plaintext), err
+}
+
+// seal 使用随机nonce加密，结果为nonce|密文
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open 解密seal的结果
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("malformed encrypted value: too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptConfig 返回敏感字段和额外指定字段已加密的配置副本，不修改传入的配置
+// 已经加密的字段和空字段保持不变
+func (e *FieldEncryptor) EncryptConfig(config *Config) (*Config, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	var copied Config
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+
+	selected := func(path string, field reflect.StructField) bool {
+		return field.Tag.Get(sensitiveTag) == "true" || e.fields[path]
+	}
+	err = transformStringFields(reflect.ValueOf(&copied).Elem(), "", selected, func(value string) (string, error) {
+		if value == "" || isEncryptedValue(value) {
+			return value, nil
+		}
+		return e.encrypt(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// DecryptConfig 原地解密配置中的加密字段，encryptor为nil且存在加密字段时返回ErrEncryptionKeyMissing
+// 检查所有string字段而不只是敏感字段，写入时额外指定的字段同样能解密；
+// 未加密的字段原样保留，兼容加密上线前写入的配置
+func DecryptConfig(config *Config, encryptor *FieldEncryptor) error {
+	if config == nil {
+		return nil
+	}
+	all := func(string, reflect.StructField) bool { return true }
+	return transformStringFields(reflect.ValueOf(config).Elem(), "", all, func(value string) (string, error) {
+		if !isEncryptedValue(value) {
+			return value, nil
+		}
+		if encryptor == nil {
+			return "", ErrEncryptionKeyMissing
+		}
+		return encryptor.decrypt(value)
+	})
+}
+
+// transformStringFields 遍历结构体，对selected选中的string字段应用transform，path为字段路径
+// 递归处理嵌套结构体、指针、切片和map中的结构体
+func transformStringFields(v reflect.Value, path string, selected func(path string, field reflect.StructField) bool, transform func(string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return transformStringFields(v.Elem(), path, selected, transform)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fv := v.Field(i)
+			current := fieldPath(path, field)
+			if fv.Kind() == reflect.String {
+				if !selected(current, field) {
+					continue
+				}
+				transformed, err := transform(fv.String())
+				if err != nil {
+					return fmt.Errorf("%s: %w", current, err)
+				}
+				fv.SetString(transformed)
+				continue
+			}
+			if err := transformStringFields(fv, current, selected, transform); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := transformStringFields(v.Index(i), path, selected, transform); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// map的值不可寻址，复制后处理再写回
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := transformStringFields(elem, path, selected, transform); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+	return nil
+}
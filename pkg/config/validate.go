@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 单项配置校验错误
+type FieldError struct {
+	Field   string // 配置路径，如log.level；语义校验错误可能为空
+	Message string
+}
+
+// String 格式化为 "field: message"
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors 配置校验发现的全部错误
+type ValidationErrors []FieldError
+
+// Error 实现error接口
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.String()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateFile 读取配置文件并执行全部校验，不写入配置中心也不替换当前配置；
+// 校验失败时返回ValidationErrors
+func (m *Manager) ValidateFile(configPath string) (*Config, error) {
+	config, err := m.readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return config, m.ValidateConfig(config)
+}
+
+// ValidateConfig 执行结构体标签校验和Manager语义校验，汇总所有错误
+func (m *Manager) ValidateConfig(config *Config) error {
+	errs := validateStructTags(config)
+	if err := m.validate(config); err != nil {
+		errs = append(errs, FieldError{Message: err.Error()})
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateStructTags 按validate标签校验配置，字段路径使用mapstructure名称
+func validateStructTags(config *Config) ValidationErrors {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("mapstructure"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	err := v.Struct(config)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		if err != nil {
+			return ValidationErrors{{Message: err.Error()}}
+		}
+		return nil
+	}
+
+	result := make(ValidationErrors, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		result = append(result, FieldError{
+			Field:   strings.TrimPrefix(fe.Namespace(), "Config."),
+			Message: tagMessage(fe),
+		})
+	}
+	return result
+}
+
+// tagMessage 把校验标签转换为可读的错误说明
+func tagMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fe.Param(), fmt.Sprint(fe.Value()))
+	case "min":
+		return fmt.Sprintf("must be at least %s, got %v", fe.Param(), fe.Value())
+	case "max":
+		return fmt.Sprintf("must be at most %s, got %v", fe.Param(), fe.Value())
+	default:
+		return fmt.Sprintf("failed %s validation, got %v", fe.Tag(), fe.Value())
+	}
+}
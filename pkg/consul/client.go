@@ -1,6 +1,7 @@
 package consul
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,17 +9,22 @@ import (
 	"go.uber.org/zap"
 )
 
+// DefaultTimeout Consul API调用的默认超时时间
+const DefaultTimeout = 10 * time.Second
+
 // Client Consul客户端封装
 type Client struct {
-	client *consulapi.Client
-	logger *zap.Logger
+	client  *consulapi.Client
+	timeout time.Duration
+	logger  *zap.Logger
 }
 
 // Config Consul客户端配置
 type Config struct {
-	Address string `yaml:"address"`
-	Scheme  string `yaml:"scheme"`
-	Token   string `yaml:"token"`
+	Address string        `yaml:"address"`
+	Scheme  string        `yaml:"scheme"`
+	Token   string        `yaml:"token"`
+	Timeout time.Duration `yaml:"timeout"` // 单次API调用超时，0表示使用DefaultTimeout
 }
 
 // ServiceConfig 服务注册配置
@@ -63,12 +69,23 @@ func NewClient(config *Config, logger *zap.Logger) (*Client, error) {
 		return nil, fmt.Errorf("failed to create consul client: %w", err)
 	}
 
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
 	return &Client{
-		client: client,
-		logger: logger,
+		client:  client,
+		timeout: timeout,
+		logger:  logger,
 	}, nil
 }
 
+// withTimeout 为单次Consul API调用设置超时，避免Consul无响应时调用方无限阻塞
+func (c *Client) withTimeout() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
 // RegisterService 注册服务到Consul
 func (c *Client) RegisterService(config *ServiceConfig) error {
 	service := &consulapi.AgentServiceRegistration{
@@ -99,7 +116,11 @@ func (c *Client) RegisterService(config *ServiceConfig) error {
 		service.Check = check
 	}
 
-	if err := c.client.Agent().ServiceRegister(service); err != nil {
+	ctx, cancel := c.withTimeout()
+	defer cancel()
+
+	opts := consulapi.ServiceRegisterOpts{}.WithContext(ctx)
+	if err := c.client.Agent().ServiceRegisterOpts(service, opts); err != nil {
 		return fmt.Errorf("failed to register service %s: %w", config.Name, err)
 	}
 
@@ -114,7 +135,11 @@ func (c *Client) RegisterService(config *ServiceConfig) error {
 
 // DeregisterService 从Consul注销服务
 func (c *Client) DeregisterService(serviceID string) error {
-	if err := c.client.Agent().ServiceDeregister(serviceID); err != nil {
+	ctx, cancel := c.withTimeout()
+	defer cancel()
+
+	opts := (&consulapi.QueryOptions{}).WithContext(ctx)
+	if err := c.client.Agent().ServiceDeregisterOpts(serviceID, opts); err != nil {
 		return fmt.Errorf("failed to deregister service %s: %w", serviceID, err)
 	}
 
@@ -126,16 +151,12 @@ func (c *Client) DeregisterService(serviceID string) error {
 
 // DiscoverService 发现服务
 func (c *Client) DiscoverService(serviceName string, healthy bool) ([]*ServiceInstance, error) {
-	var services []*consulapi.ServiceEntry
-	var err error
-
-	if healthy {
-		// 只返回健康的服务实例
-		services, _, err = c.client.Health().Service(serviceName, "", true, nil)
-	} else {
-		// 返回所有服务实例
-		services, _, err = c.client.Health().Service(serviceName, "", false, nil)
-	}
+	ctx, cancel := c.withTimeout()
+	defer cancel()
+
+	// healthy为true时只返回健康的服务实例
+	opts := (&consulapi.QueryOptions{}).WithContext(ctx)
+	services, _, err := c.client.Health().Service(serviceName, "", healthy, opts)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover service %s: %w", serviceName, err)
@@ -0,0 +1,66 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newHungConsulClient 创建指向无响应Consul的客户端
+func newHungConsulClient(t *testing.T, timeout time.Duration) *Client {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) }) // 先于server.Close执行，释放阻塞的请求
+
+	client, err := NewClient(&Config{Address: strings.TrimPrefix(server.URL, "http://"), Timeout: timeout}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return client
+}
+
+func TestClientCallsTimeOutOnHungConsul(t *testing.T) {
+	client := newHungConsulClient(t, 100*time.Millisecond)
+
+	calls := map[string]func() error{
+		"discover": func() error {
+			_, err := client.DiscoverService("high-go-press-counter", true)
+			return err
+		},
+		"register":   func() error { return client.RegisterService(&ServiceConfig{ID: "counter-1", Name: "counter"}) },
+		"deregister": func() error { return client.DeregisterService("counter-1") },
+	}
+	for name, call := range calls {
+		start := time.Now()
+		err := call()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected deadline exceeded, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: expected call to time out quickly, took %v", name, elapsed)
+		}
+	}
+}
+
+func TestNewClientDefaultTimeout(t *testing.T) {
+	client, err := NewClient(&Config{Address: "localhost:8500"}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.timeout != DefaultTimeout {
+		t.Errorf("Expected default timeout %v, got %v", DefaultTimeout, client.timeout)
+	}
+}
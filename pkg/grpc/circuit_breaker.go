@@ -226,6 +226,24 @@ func (cb *CircuitBreaker) reset() {
 	cb.requestCount = 0
 }
 
+// Reset 强制将熔断器切换为关闭状态并清空计数器
+//
+// 仅用于运维人员确认下游恢复后手动干预，熔断器自身的状态流转不会调用它。
+// 累计统计信息保留，状态变化计入StateChanges。
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.logger.Warn("Circuit breaker forcibly reset",
+		zap.String("from", cb.state.String()),
+		zap.Int("failure_count", cb.failureCount),
+		zap.Int("success_count", cb.successCount),
+		zap.Int("request_count", cb.requestCount))
+
+	cb.reset()
+	cb.setState(StateClosed)
+}
+
 // GetState 获取当前状态
 func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 	cb.mutex.RLock()
@@ -240,6 +258,20 @@ func (cb *CircuitBreaker) GetStats() CircuitBreakerStats {
 	return cb.stats
 }
 
+// Stats 以通用格式返回熔断器统计，实现stats.StatsProvider
+func (cb *CircuitBreaker) Stats() map[string]interface{} {
+	stats := cb.GetStats()
+	return map[string]interface{}{
+		"total_requests":    stats.TotalRequests,
+		"success_requests":  stats.SuccessRequests,
+		"failure_requests":  stats.FailureRequests,
+		"rejected_requests": stats.RejectedRequests,
+		"state_changes":     stats.StateChanges,
+		"current_state":     stats.CurrentState,
+		"last_state_change": stats.LastStateChange.Unix(),
+	}
+}
+
 // IsOpen 检查熔断器是否开启
 func (cb *CircuitBreaker) IsOpen() bool {
 	return cb.GetState() == StateOpen
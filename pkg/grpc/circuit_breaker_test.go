@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCircuitBreakerResetClosesOpenBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(&CircuitBreakerConfig{
+		FailureThreshold: 2,
+		SuccessThreshold: 1,
+		Timeout:          time.Hour,
+		MaxRequests:      1,
+		StatWindow:       time.Minute,
+	}, zap.NewNop())
+
+	failing := func(context.Context) error { return errors.New("boom") }
+	for i := 0; i < 2; i++ {
+		cb.Execute(context.Background(), failing)
+	}
+	if !cb.IsOpen() {
+		t.Fatalf("Expected breaker to be open after failures, got %s", cb.GetState())
+	}
+
+	cb.Reset()
+
+	if !cb.IsClosed() {
+		t.Fatalf("Expected breaker to be closed after Reset, got %s", cb.GetState())
+	}
+	if cb.failureCount != 0 || cb.successCount != 0 || cb.requestCount != 0 {
+		t.Errorf("Expected counters cleared, got failure=%d success=%d request=%d",
+			cb.failureCount, cb.successCount, cb.requestCount)
+	}
+
+	// 重置后计数从零开始：一次失败不应再次开启熔断
+	if err := cb.Execute(context.Background(), failing); err == nil || err.Error() != "boom" {
+		t.Fatalf("Expected request to pass through after Reset, got %v", err)
+	}
+	if !cb.IsClosed() {
+		t.Errorf("Expected breaker to stay closed after a single failure, got %s", cb.GetState())
+	}
+	if stats := cb.GetStats(); stats.CurrentState != StateClosed.String() {
+		t.Errorf("Expected stats to report CLOSED, got %s", stats.CurrentState)
+	}
+}
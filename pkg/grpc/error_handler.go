@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"high-go-press/pkg/middleware"
+
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -43,6 +45,8 @@ type ErrorInfo struct {
 	ServiceName string
 	Method      string
 	Retryable   bool
+	PeerAddr    string // 调用方地址
+	UserAgent   string // 调用方user-agent
 }
 
 // ErrorStats 错误统计信息
@@ -116,6 +120,8 @@ func (h *DefaultErrorHandler) HandleError(ctx context.Context, err error, info *
 		zap.String("service", info.ServiceName),
 		zap.String("method", info.Method),
 		zap.String("request_id", info.RequestID),
+		zap.String("peer", info.PeerAddr),
+		zap.String("user_agent", info.UserAgent),
 		zap.Bool("retryable", info.Retryable),
 		zap.Any("details", info.Details),
 		zap.Error(err))
@@ -227,6 +233,7 @@ func (m *ErrorMiddleware) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 		if err != nil {
 			// 构建错误信息
+			client := middleware.ClientInfoFromContext(ctx)
 			errorInfo := &ErrorInfo{
 				Type:        m.handler.GetErrorType(err),
 				Code:        status.Code(err),
@@ -237,6 +244,8 @@ func (m *ErrorMiddleware) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 				ServiceName: m.serviceName,
 				Method:      info.FullMethod,
 				Retryable:   m.handler.ShouldRetry(err),
+				PeerAddr:    client.PeerAddr,
+				UserAgent:   client.UserAgent,
 			}
 
 			// 处理错误
@@ -262,6 +271,7 @@ func (m *ErrorMiddleware) StreamServerInterceptor() grpc.StreamServerInterceptor
 
 		if err != nil {
 			// 构建错误信息
+			client := middleware.ClientInfoFromContext(ss.Context())
 			errorInfo := &ErrorInfo{
 				Type:        m.handler.GetErrorType(err),
 				Code:        status.Code(err),
@@ -272,6 +282,8 @@ func (m *ErrorMiddleware) StreamServerInterceptor() grpc.StreamServerInterceptor
 				ServiceName: m.serviceName,
 				Method:      info.FullMethod,
 				Retryable:   m.handler.ShouldRetry(err),
+				PeerAddr:    client.PeerAddr,
+				UserAgent:   client.UserAgent,
 			}
 
 			// 处理错误
@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"container/list"
 	"context"
 	"errors"
 	"sync"
@@ -33,6 +34,8 @@ type FallbackConfig struct {
 	TriggerConditions []FallbackCondition
 	// 缓存TTL
 	CacheTTL time.Duration
+	// 缓存最多保留的条目数，0表示使用DefaultFallbackCacheMaxEntries
+	CacheMaxEntries int
 	// 默认响应
 	DefaultResponse interface{}
 	// 备用服务地址
@@ -73,12 +76,18 @@ type FallbackHandler interface {
 	CanHandle(req interface{}) bool
 }
 
+// DefaultFallbackCacheMaxEntries 降级缓存默认最多保留的条目数
+const DefaultFallbackCacheMaxEntries = 10000
+
 // CacheFallbackHandler 缓存降级处理器
+// 最多保留maxEntries个条目，超出时淘汰最久未使用的条目，避免按key缓存时内存无限增长
 type CacheFallbackHandler struct {
-	cache  map[string]CacheEntry
-	mutex  sync.RWMutex
-	ttl    time.Duration
-	logger *zap.Logger
+	cache      map[string]*list.Element // key -> order中的元素，元素值为*cacheItem
+	order      *list.List               // 按最近使用排序，队头最新
+	maxEntries int
+	mutex      sync.Mutex
+	ttl        time.Duration
+	logger     *zap.Logger
 }
 
 // CacheEntry 缓存条目
@@ -88,12 +97,23 @@ type CacheEntry struct {
 	TTL       time.Duration
 }
 
-// NewCacheFallbackHandler 创建缓存降级处理器
-func NewCacheFallbackHandler(ttl time.Duration, logger *zap.Logger) *CacheFallbackHandler {
+// cacheItem LRU链表中的元素
+type cacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewCacheFallbackHandler 创建缓存降级处理器，maxEntries不大于0时使用DefaultFallbackCacheMaxEntries
+func NewCacheFallbackHandler(ttl time.Duration, maxEntries int, logger *zap.Logger) *CacheFallbackHandler {
+	if maxEntries <= 0 {
+		maxEntries = DefaultFallbackCacheMaxEntries
+	}
 	return &CacheFallbackHandler{
-		cache:  make(map[string]CacheEntry),
-		ttl:    ttl,
-		logger: logger,
+		cache:      make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		logger:     logger,
 	}
 }
 
@@ -101,11 +121,7 @@ func NewCacheFallbackHandler(ttl time.Duration, logger *zap.Logger) *CacheFallba
 func (h *CacheFallbackHandler) Handle(ctx context.Context, req interface{}) (interface{}, error) {
 	key := h.generateCacheKey(req)
 
-	h.mutex.RLock()
-	entry, exists := h.cache[key]
-	h.mutex.RUnlock()
-
-	if exists && !h.isExpired(entry) {
+	if entry, ok := h.Get(key); ok {
 		h.logger.Info("Fallback to cache hit", zap.String("key", key))
 		return entry.Data, nil
 	}
@@ -116,24 +132,61 @@ func (h *CacheFallbackHandler) Handle(ctx context.Context, req interface{}) (int
 
 // CanHandle 检查是否可以处理
 func (h *CacheFallbackHandler) CanHandle(req interface{}) bool {
-	key := h.generateCacheKey(req)
-	h.mutex.RLock()
-	entry, exists := h.cache[key]
-	h.mutex.RUnlock()
-
-	return exists && !h.isExpired(entry)
+	_, ok := h.Get(h.generateCacheKey(req))
+	return ok
 }
 
-// Set 设置缓存
+// Set 设置缓存，超出容量时淘汰最久未使用的条目
 func (h *CacheFallbackHandler) Set(key string, data interface{}) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
-	h.cache[key] = CacheEntry{
+	entry := CacheEntry{
 		Data:      data,
 		Timestamp: time.Now(),
 		TTL:       h.ttl,
 	}
+	if elem, exists := h.cache[key]; exists {
+		elem.Value.(*cacheItem).entry = entry
+		h.order.MoveToFront(elem)
+		return
+	}
+
+	h.cache[key] = h.order.PushFront(&cacheItem{key: key, entry: entry})
+	for h.order.Len() > h.maxEntries {
+		h.removeElement(h.order.Back())
+	}
+}
+
+// Get 获取未过期的缓存条目，命中时标记为最近使用，已过期的条目被移除
+func (h *CacheFallbackHandler) Get(key string) (CacheEntry, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	elem, exists := h.cache[key]
+	if !exists {
+		return CacheEntry{}, false
+	}
+	entry := elem.Value.(*cacheItem).entry
+	if h.isExpired(entry) {
+		h.removeElement(elem)
+		return CacheEntry{}, false
+	}
+	h.order.MoveToFront(elem)
+	return entry, true
+}
+
+// Len 当前缓存的条目数
+func (h *CacheFallbackHandler) Len() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.order.Len()
+}
+
+// removeElement 移除条目，调用方需持有锁
+func (h *CacheFallbackHandler) removeElement(elem *list.Element) {
+	h.order.Remove(elem)
+	delete(h.cache, elem.Value.(*cacheItem).key)
 }
 
 // generateCacheKey 生成缓存键
@@ -209,7 +262,7 @@ func NewFallbackManager(config *FallbackConfig, logger *zap.Logger) *FallbackMan
 // initHandlers 初始化处理器
 func (fm *FallbackManager) initHandlers() {
 	// 缓存降级处理器
-	fm.handlers[FallbackToCache] = NewCacheFallbackHandler(fm.config.CacheTTL, fm.logger)
+	fm.handlers[FallbackToCache] = NewCacheFallbackHandler(fm.config.CacheTTL, fm.config.CacheMaxEntries, fm.logger)
 
 	// 默认值降级处理器
 	if fm.config.DefaultResponse != nil {
@@ -356,6 +409,20 @@ func (fm *FallbackManager) GetStats() FallbackStats {
 	return fm.stats
 }
 
+// Stats 以通用格式返回降级统计，实现stats.StatsProvider
+func (fm *FallbackManager) Stats() map[string]interface{} {
+	stats := fm.GetStats()
+	return map[string]interface{}{
+		"total_fallbacks":       stats.TotalFallbacks,
+		"cache_fallbacks":       stats.CacheFallbacks,
+		"default_fallbacks":     stats.DefaultFallbacks,
+		"static_fallbacks":      stats.StaticFallbacks,
+		"alternative_fallbacks": stats.AlternativeFallbacks,
+		"failed_fallbacks":      stats.FailedFallbacks,
+		"last_fallback_time":    stats.LastFallbackTime.Unix(),
+	}
+}
+
 // Reset 重置统计信息
 func (fm *FallbackManager) Reset() {
 	fm.mutex.Lock()
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCacheFallbackHandlerEvictsLeastRecentlyUsed(t *testing.T) {
+	h := NewCacheFallbackHandler(time.Minute, 2, zap.NewNop())
+
+	h.Set("a", 1)
+	h.Set("b", 2)
+	// 访问a后b成为最久未使用的条目
+	if _, ok := h.Get("a"); !ok {
+		t.Fatal("Expected a to be cached")
+	}
+	h.Set("c", 3)
+
+	if h.Len() != 2 {
+		t.Errorf("Expected cache bounded to 2 entries, got %d", h.Len())
+	}
+	if _, ok := h.Get("b"); ok {
+		t.Error("Expected least recently used entry b to be evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if entry, ok := h.Get(key); !ok || entry.Data != want {
+			t.Errorf("Expected %s=%d to be cached, got %v (ok=%v)", key, want, entry.Data, ok)
+		}
+	}
+
+	// 更新已有的key不增加条目
+	h.Set("a", 10)
+	if entry, ok := h.Get("a"); !ok || entry.Data != 10 || h.Len() != 2 {
+		t.Errorf("Expected a updated in place, got %v (len=%d)", entry.Data, h.Len())
+	}
+}
+
+func TestCacheFallbackHandlerDefaultLimit(t *testing.T) {
+	h := NewCacheFallbackHandler(time.Minute, 0, zap.NewNop())
+	for i := 0; i < DefaultFallbackCacheMaxEntries+10; i++ {
+		h.Set(fmt.Sprintf("key_%d", i), i)
+	}
+	if h.Len() != DefaultFallbackCacheMaxEntries {
+		t.Errorf("Expected %d entries, got %d", DefaultFallbackCacheMaxEntries, h.Len())
+	}
+}
+
+func TestCacheFallbackHandlerDropsExpiredEntries(t *testing.T) {
+	h := NewCacheFallbackHandler(time.Millisecond, 10, zap.NewNop())
+	h.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := h.Get("a"); ok {
+		t.Fatal("Expected expired entry to miss")
+	}
+	if h.Len() != 0 {
+		t.Errorf("Expected expired entry to be removed, got %d entries", h.Len())
+	}
+}
@@ -2,12 +2,18 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// ErrUnexpectedResultType 函数或降级返回的结果类型与调用方期望的类型不一致
+var ErrUnexpectedResultType = errors.New("resilience: unexpected result type")
+
 // ResilienceConfig 弹性配置
 type ResilienceConfig struct {
 	// 熔断器配置
@@ -18,8 +24,59 @@ type ResilienceConfig struct {
 	Fallback *FallbackConfig
 	// 错误处理配置
 	ErrorHandling *ErrorHandlingConfig
+	// 健康评估配置，nil表示使用默认值
+	Health *HealthConfig
+	// 按方法覆盖的弹性配置，key为ExecuteMethod传入的方法名
+	Methods map[string]*MethodResilienceConfig
+}
+
+// MethodResilienceConfig 单个方法的弹性配置，未设置的组件沿用默认配置
+//
+// 每个方法使用独立的熔断器、重试器和降级管理器实例，
+// 一个方法熔断不会影响其他方法。
+type MethodResilienceConfig struct {
+	CircuitBreaker *CircuitBreakerConfig
+	Retry          *RetryConfig
+	Fallback       *FallbackConfig
+}
+
+// HealthConfig 健康评估配置
+type HealthConfig struct {
+	// 请求数达到该值后才按成功率判定健康，避免少量请求时误判
+	MinRequests int64
+	// 成功率低于该值视为不健康
+	SuccessRateThreshold float64
+	// 平均响应时间达到该值时延迟得分为0
+	LatencyThreshold time.Duration
+	// 健康得分低于该值时视为降级
+	DegradedScore float64
+
+	// 健康得分中成功率、熔断器状态、平均延迟的权重
+	SuccessRateWeight float64
+	BreakerWeight     float64
+	LatencyWeight     float64
+}
+
+// DefaultHealthConfig 默认健康评估配置
+func DefaultHealthConfig() *HealthConfig {
+	return &HealthConfig{
+		MinRequests:          10,
+		SuccessRateThreshold: 0.8,
+		LatencyThreshold:     time.Second,
+		DegradedScore:        0.8,
+		SuccessRateWeight:    0.5,
+		BreakerWeight:        0.3,
+		LatencyWeight:        0.2,
+	}
 }
 
+// 健康等级
+const (
+	HealthLevelHealthy   = "healthy"
+	HealthLevelDegraded  = "degraded"
+	HealthLevelUnhealthy = "unhealthy"
+)
+
 // ErrorHandlingConfig 错误处理配置
 type ErrorHandlingConfig struct {
 	// 启用错误处理
@@ -40,11 +97,35 @@ type ResilienceManager struct {
 	fallbackManager *FallbackManager
 	errorHandler    ErrorHandler
 	errorConverter  *ErrorConverter
+	defaults        *methodComponents
+	methods         map[string]*methodComponents
 	logger          *zap.Logger
 	stats           ResilienceStats
 	mutex           sync.RWMutex
 }
 
+// methodComponents 一组弹性组件，未配置的组件为nil
+type methodComponents struct {
+	circuitBreaker  *CircuitBreaker
+	retryer         *Retryer
+	fallbackManager *FallbackManager
+}
+
+// newMethodComponents 按配置创建弹性组件
+func newMethodComponents(cb *CircuitBreakerConfig, retry *RetryConfig, fallback *FallbackConfig, logger *zap.Logger) *methodComponents {
+	c := &methodComponents{}
+	if cb != nil {
+		c.circuitBreaker = NewCircuitBreaker(cb, logger)
+	}
+	if retry != nil {
+		c.retryer = NewRetryer(retry, logger)
+	}
+	if fallback != nil {
+		c.fallbackManager = NewFallbackManager(fallback, logger)
+	}
+	return c
+}
+
 // ResilienceStats 弹性统计信息
 type ResilienceStats struct {
 	TotalRequests       int64
@@ -64,6 +145,12 @@ func NewResilienceManager(config *ResilienceConfig, logger *zap.Logger) *Resilie
 		config = DefaultResilienceConfig()
 	}
 
+	if config.Health == nil {
+		cfg := *config
+		cfg.Health = DefaultHealthConfig()
+		config = &cfg
+	}
+
 	rm := &ResilienceManager{
 		config: config,
 		logger: logger,
@@ -77,19 +164,29 @@ func NewResilienceManager(config *ResilienceConfig, logger *zap.Logger) *Resilie
 
 // initComponents 初始化组件
 func (rm *ResilienceManager) initComponents() {
-	// 初始化熔断器
-	if rm.config.CircuitBreaker != nil {
-		rm.circuitBreaker = NewCircuitBreaker(rm.config.CircuitBreaker, rm.logger)
-	}
-
-	// 初始化重试器
-	if rm.config.Retry != nil {
-		rm.retryer = NewRetryer(rm.config.Retry, rm.logger)
-	}
-
-	// 初始化降级管理器
-	if rm.config.Fallback != nil {
-		rm.fallbackManager = NewFallbackManager(rm.config.Fallback, rm.logger)
+	// 初始化默认的熔断器、重试器和降级管理器
+	rm.defaults = newMethodComponents(rm.config.CircuitBreaker, rm.config.Retry, rm.config.Fallback, rm.logger)
+	rm.circuitBreaker = rm.defaults.circuitBreaker
+	rm.retryer = rm.defaults.retryer
+	rm.fallbackManager = rm.defaults.fallbackManager
+
+	// 初始化按方法配置的组件，未设置的部分沿用默认配置
+	rm.methods = make(map[string]*methodComponents, len(rm.config.Methods))
+	for method, mc := range rm.config.Methods {
+		if mc == nil {
+			continue
+		}
+		cb, retry, fallback := rm.config.CircuitBreaker, rm.config.Retry, rm.config.Fallback
+		if mc.CircuitBreaker != nil {
+			cb = mc.CircuitBreaker
+		}
+		if mc.Retry != nil {
+			retry = mc.Retry
+		}
+		if mc.Fallback != nil {
+			fallback = mc.Fallback
+		}
+		rm.methods[method] = newMethodComponents(cb, retry, fallback, rm.logger.With(zap.String("method", method)))
 	}
 
 	// 初始化错误处理器
@@ -99,9 +196,15 @@ func (rm *ResilienceManager) initComponents() {
 	}
 }
 
-// Execute 执行带弹性保护的函数
+// Execute 使用默认配置执行带弹性保护的函数
 func (rm *ResilienceManager) Execute(ctx context.Context, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	return rm.ExecuteMethod(ctx, "", fn)
+}
+
+// ExecuteMethod 使用method对应的弹性配置执行函数，未单独配置的方法使用默认配置
+func (rm *ResilienceManager) ExecuteMethod(ctx context.Context, method string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
 	startTime := time.Now()
+	c := rm.componentsFor(method)
 
 	rm.mutex.Lock()
 	rm.stats.TotalRequests++
@@ -112,20 +215,20 @@ func (rm *ResilienceManager) Execute(ctx context.Context, fn func(context.Contex
 	var err error
 
 	// 执行函数，应用所有弹性策略
-	if rm.circuitBreaker != nil {
+	if c.circuitBreaker != nil {
 		// 使用熔断器保护
-		err = rm.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
-			result, err = rm.executeWithRetry(ctx, fn)
+		err = c.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+			result, err = rm.executeWithRetry(ctx, c.retryer, fn)
 			return err
 		})
 	} else {
 		// 直接执行重试逻辑
-		result, err = rm.executeWithRetry(ctx, fn)
+		result, err = rm.executeWithRetry(ctx, c.retryer, fn)
 	}
 
 	// 如果有错误且配置了降级，尝试降级
-	if err != nil && rm.fallbackManager != nil {
-		result, err = rm.fallbackManager.Execute(ctx, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+	if err != nil && c.fallbackManager != nil {
+		result, err = c.fallbackManager.Execute(ctx, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
 			return result, err
 		})
 	}
@@ -144,7 +247,7 @@ func (rm *ResilienceManager) Execute(ctx context.Context, fn func(context.Contex
 			Timestamp:   time.Now(),
 			RequestID:   rm.getRequestID(ctx),
 			ServiceName: "resilience-manager",
-			Method:      "execute",
+			Method:      errorMethod(method),
 			Retryable:   rm.errorHandler.ShouldRetry(err),
 		}
 
@@ -154,11 +257,72 @@ func (rm *ResilienceManager) Execute(ctx context.Context, fn func(context.Contex
 	return result, err
 }
 
+// Execute 类型安全地执行带弹性保护的函数，使用默认配置
+//
+// 降级可能返回与fn不同类型的结果（如缓存或默认响应），
+// 类型不匹配时返回ErrUnexpectedResultType而不是让调用方断言时panic。
+func Execute[T any](ctx context.Context, rm *ResilienceManager, fn func(context.Context) (T, error)) (T, error) {
+	return ExecuteMethod(ctx, rm, "", fn)
+}
+
+// ExecuteMethod 类型安全地执行带弹性保护的函数，使用method对应的弹性配置
+func ExecuteMethod[T any](ctx context.Context, rm *ResilienceManager, method string, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+
+	result, err := rm.ExecuteMethod(ctx, method, func(ctx context.Context) (interface{}, error) {
+		return fn(ctx)
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	// 降级没有结果时按零值处理
+	if result == nil {
+		return zero, nil
+	}
+
+	typed, ok := result.(T)
+	if !ok {
+		rm.logger.Error("Resilience result type mismatch",
+			zap.String("method", errorMethod(method)),
+			zap.String("expected", reflect.TypeOf(&zero).Elem().String()),
+			zap.String("actual", fmt.Sprintf("%T", result)))
+		return zero, fmt.Errorf("%w: expected %s, got %T", ErrUnexpectedResultType, reflect.TypeOf(&zero).Elem(), result)
+	}
+	return typed, nil
+}
+
+// componentsFor 获取方法对应的弹性组件，未单独配置时返回默认组件
+func (rm *ResilienceManager) componentsFor(method string) *methodComponents {
+	if c, ok := rm.methods[method]; ok {
+		return c
+	}
+	return rm.defaults
+}
+
+// allComponents 默认组件和所有按方法配置的组件
+func (rm *ResilienceManager) allComponents() []*methodComponents {
+	all := make([]*methodComponents, 0, len(rm.methods)+1)
+	all = append(all, rm.defaults)
+	for _, c := range rm.methods {
+		all = append(all, c)
+	}
+	return all
+}
+
+// errorMethod 错误信息中记录的方法名
+func errorMethod(method string) string {
+	if method == "" {
+		return "execute"
+	}
+	return method
+}
+
 // executeWithRetry 执行带重试的函数
-func (rm *ResilienceManager) executeWithRetry(ctx context.Context, fn func(context.Context) (interface{}, error)) (interface{}, error) {
-	if rm.retryer != nil {
+func (rm *ResilienceManager) executeWithRetry(ctx context.Context, retryer *Retryer, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	if retryer != nil {
 		var result interface{}
-		err := rm.retryer.Execute(ctx, func(ctx context.Context) error {
+		err := retryer.Execute(ctx, func(ctx context.Context) error {
 			var execErr error
 			result, execErr = fn(ctx)
 			return execErr
@@ -195,21 +359,22 @@ func (rm *ResilienceManager) updateStats(success bool, duration time.Duration) {
 		rm.stats.SuccessRate = float64(rm.stats.SuccessRequests) / float64(rm.stats.TotalRequests)
 	}
 
-	// 更新其他统计信息
-	if rm.circuitBreaker != nil {
-		cbStats := rm.circuitBreaker.GetStats()
-		rm.stats.CircuitBreakerTrips = cbStats.StateChanges
-	}
-
-	if rm.retryer != nil {
-		retryStats := rm.retryer.GetStats()
-		rm.stats.RetryAttempts = retryStats.RetriedRequests
-	}
-
-	if rm.fallbackManager != nil {
-		fallbackStats := rm.fallbackManager.GetStats()
-		rm.stats.FallbackExecutions = fallbackStats.TotalFallbacks
+	// 更新其他统计信息，汇总所有方法的组件
+	var trips, retries, fallbacks int64
+	for _, c := range rm.allComponents() {
+		if c.circuitBreaker != nil {
+			trips += c.circuitBreaker.GetStats().StateChanges
+		}
+		if c.retryer != nil {
+			retries += c.retryer.GetStats().RetriedRequests
+		}
+		if c.fallbackManager != nil {
+			fallbacks += c.fallbackManager.GetStats().TotalFallbacks
+		}
 	}
+	rm.stats.CircuitBreakerTrips = trips
+	rm.stats.RetryAttempts = retries
+	rm.stats.FallbackExecutions = fallbacks
 }
 
 // getRequestID 获取请求ID
@@ -256,25 +421,44 @@ func (rm *ResilienceManager) GetDetailedStats() map[string]interface{} {
 		}
 	}
 
+	// 按方法配置的组件统计
+	if len(rm.methods) > 0 {
+		methods := make(map[string]interface{}, len(rm.methods))
+		for method, c := range rm.methods {
+			methodStats := make(map[string]interface{})
+			if c.circuitBreaker != nil {
+				methodStats["circuit_breaker"] = c.circuitBreaker.GetStats()
+			}
+			if c.retryer != nil {
+				methodStats["retry"] = c.retryer.GetStats()
+			}
+			if c.fallbackManager != nil {
+				methodStats["fallback"] = c.fallbackManager.GetStats()
+			}
+			methods[method] = methodStats
+		}
+		stats["methods"] = methods
+	}
+
 	return stats
 }
 
-// Reset 重置所有统计信息
+// Reset 重置所有统计信息，并强制关闭熔断器（运维操作）
 func (rm *ResilienceManager) Reset() {
 	rm.mutex.Lock()
 	rm.stats = ResilienceStats{}
 	rm.mutex.Unlock()
 
-	if rm.circuitBreaker != nil {
-		// 熔断器不提供重置方法，这里可以重新创建
-	}
-
-	if rm.retryer != nil {
-		rm.retryer.Reset()
-	}
-
-	if rm.fallbackManager != nil {
-		rm.fallbackManager.Reset()
+	for _, c := range rm.allComponents() {
+		if c.circuitBreaker != nil {
+			c.circuitBreaker.Reset()
+		}
+		if c.retryer != nil {
+			c.retryer.Reset()
+		}
+		if c.fallbackManager != nil {
+			c.fallbackManager.Reset()
+		}
 	}
 
 	if rm.errorHandler != nil {
@@ -284,13 +468,20 @@ func (rm *ResilienceManager) Reset() {
 	}
 }
 
-// IsHealthy 检查系统健康状态
+// IsHealthy 检查系统健康状态：请求数达到MinRequests后成功率低于阈值，或熔断器开启时不健康
 func (rm *ResilienceManager) IsHealthy() bool {
 	rm.mutex.RLock()
-	defer rm.mutex.RUnlock()
+	stats := rm.stats
+	rm.mutex.RUnlock()
+	return rm.isHealthy(stats)
+}
+
+// isHealthy 按统计快照判断健康状态
+func (rm *ResilienceManager) isHealthy(stats ResilienceStats) bool {
+	health := rm.config.Health
 
 	// 检查成功率
-	if rm.stats.TotalRequests > 10 && rm.stats.SuccessRate < 0.8 {
+	if stats.TotalRequests >= health.MinRequests && stats.SuccessRate < health.SuccessRateThreshold {
 		return false
 	}
 
@@ -302,13 +493,85 @@ func (rm *ResilienceManager) IsHealthy() bool {
 	return true
 }
 
-// GetHealthStatus 获取健康状态详情
+// HealthScore 综合健康得分，0到1之间，越高越健康
+type HealthScore struct {
+	Score        float64 // 加权综合得分
+	SuccessScore float64 // 成功率得分，请求数不足MinRequests时为1
+	BreakerScore float64 // 熔断器得分：关闭1，半开0.5，开启0
+	LatencyScore float64 // 延迟得分，平均响应时间线性映射到[0,1]
+}
+
+// GetHealthScore 根据成功率、熔断器状态和平均延迟计算综合健康得分
+func (rm *ResilienceManager) GetHealthScore() HealthScore {
+	rm.mutex.RLock()
+	stats := rm.stats
+	rm.mutex.RUnlock()
+	return rm.healthScore(stats)
+}
+
+// healthScore 按统计快照计算健康得分
+func (rm *ResilienceManager) healthScore(stats ResilienceStats) HealthScore {
+	health := rm.config.Health
+	score := HealthScore{SuccessScore: 1, BreakerScore: 1, LatencyScore: 1}
+
+	if stats.TotalRequests >= health.MinRequests && stats.TotalRequests > 0 {
+		score.SuccessScore = stats.SuccessRate
+	}
+
+	if rm.circuitBreaker != nil {
+		switch rm.circuitBreaker.GetState() {
+		case StateOpen:
+			score.BreakerScore = 0
+		case StateHalfOpen:
+			score.BreakerScore = 0.5
+		}
+	}
+
+	if health.LatencyThreshold > 0 && stats.AvgResponseTime > 0 {
+		score.LatencyScore = 1 - float64(stats.AvgResponseTime)/float64(health.LatencyThreshold)
+		if score.LatencyScore < 0 {
+			score.LatencyScore = 0
+		}
+	}
+
+	totalWeight := health.SuccessRateWeight + health.BreakerWeight + health.LatencyWeight
+	if totalWeight <= 0 {
+		score.Score = score.SuccessScore * score.BreakerScore * score.LatencyScore
+		return score
+	}
+	score.Score = (score.SuccessScore*health.SuccessRateWeight +
+		score.BreakerScore*health.BreakerWeight +
+		score.LatencyScore*health.LatencyWeight) / totalWeight
+	return score
+}
+
+// GetHealthStatus 获取健康状态详情，level为healthy/degraded/unhealthy
 func (rm *ResilienceManager) GetHealthStatus() map[string]interface{} {
-	status := make(map[string]interface{})
+	rm.mutex.RLock()
+	stats := rm.stats
+	rm.mutex.RUnlock()
 
-	status["healthy"] = rm.IsHealthy()
-	status["success_rate"] = rm.stats.SuccessRate
-	status["avg_response_time"] = rm.stats.AvgResponseTime.String()
+	healthy := rm.isHealthy(stats)
+	score := rm.healthScore(stats)
+
+	level := HealthLevelHealthy
+	if !healthy {
+		level = HealthLevelUnhealthy
+	} else if score.Score < rm.config.Health.DegradedScore {
+		level = HealthLevelDegraded
+	}
+
+	status := make(map[string]interface{})
+	status["healthy"] = healthy
+	status["level"] = level
+	status["score"] = score.Score
+	status["score_components"] = map[string]float64{
+		"success_rate":    score.SuccessScore,
+		"circuit_breaker": score.BreakerScore,
+		"latency":         score.LatencyScore,
+	}
+	status["success_rate"] = stats.SuccessRate
+	status["avg_response_time"] = stats.AvgResponseTime.String()
 
 	if rm.circuitBreaker != nil {
 		status["circuit_breaker_state"] = rm.circuitBreaker.GetState().String()
@@ -330,6 +593,7 @@ func DefaultResilienceConfig() *ResilienceConfig {
 			ErrorRateThreshold: 0.1, // 10%错误率
 			LogLevel:           "error",
 		},
+		Health: DefaultHealthConfig(),
 	}
 }
 
@@ -354,6 +618,13 @@ func (m *ResilienceMiddleware) WrapFunction(fn func(context.Context) (interface{
 	}
 }
 
+// WrapMethod 包装函数，使用method对应的弹性配置
+func (m *ResilienceMiddleware) WrapMethod(method string, fn func(context.Context) (interface{}, error)) func(context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		return m.manager.ExecuteMethod(ctx, method, fn)
+	}
+}
+
 // WrapGRPCCall 包装gRPC调用
 func (m *ResilienceMiddleware) WrapGRPCCall(call func(context.Context) error) func(context.Context) error {
 	return func(ctx context.Context) error {
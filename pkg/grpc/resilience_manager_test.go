@@ -0,0 +1,258 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newTestResilienceManager 创建只带熔断器的弹性管理器，统计由测试直接设置
+func newTestResilienceManager(health *HealthConfig) *ResilienceManager {
+	return NewResilienceManager(&ResilienceConfig{
+		CircuitBreaker: DefaultCircuitBreakerConfig(),
+		Health:         health,
+	}, zap.NewNop())
+}
+
+func (rm *ResilienceManager) setTestStats(total, success int64, avg time.Duration) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.stats.TotalRequests = total
+	rm.stats.SuccessRequests = success
+	rm.stats.FailedRequests = total - success
+	rm.stats.SuccessRate = float64(success) / float64(total)
+	rm.stats.AvgResponseTime = avg
+}
+
+func (rm *ResilienceManager) setBreakerState(state CircuitBreakerState) {
+	rm.circuitBreaker.mutex.Lock()
+	defer rm.circuitBreaker.mutex.Unlock()
+	rm.circuitBreaker.setState(state)
+}
+
+func TestIsHealthyThresholdBoundaries(t *testing.T) {
+	rm := newTestResilienceManager(nil)
+
+	cases := []struct {
+		name    string
+		total   int64
+		success int64
+		want    bool
+	}{
+		{"below min requests", 9, 0, true},
+		{"at min requests below threshold", 10, 7, false},
+		{"exactly at threshold", 10, 8, true},
+		{"above threshold", 100, 99, true},
+		{"just below threshold", 100, 79, false},
+	}
+	for _, c := range cases {
+		rm.setTestStats(c.total, c.success, 0)
+		if got := rm.IsHealthy(); got != c.want {
+			t.Errorf("%s: IsHealthy() = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// 熔断器开启时不健康
+	rm.setTestStats(100, 100, 0)
+	rm.setBreakerState(StateOpen)
+	if rm.IsHealthy() {
+		t.Error("Expected unhealthy when circuit breaker is open")
+	}
+}
+
+func TestIsHealthyConfigurableThresholds(t *testing.T) {
+	health := DefaultHealthConfig()
+	health.MinRequests = 3
+	health.SuccessRateThreshold = 0.5
+	rm := newTestResilienceManager(health)
+
+	rm.setTestStats(3, 1, 0)
+	if rm.IsHealthy() {
+		t.Error("Expected unhealthy with 1/3 success and threshold 0.5")
+	}
+	rm.setTestStats(4, 2, 0)
+	if !rm.IsHealthy() {
+		t.Error("Expected healthy with success rate exactly at threshold 0.5")
+	}
+	rm.setTestStats(2, 0, 0)
+	if !rm.IsHealthy() {
+		t.Error("Expected healthy below configured min requests")
+	}
+}
+
+func TestHealthScoreComposite(t *testing.T) {
+	rm := newTestResilienceManager(nil)
+
+	// 无请求时满分
+	if score := rm.GetHealthScore(); score.Score != 1 {
+		t.Errorf("Expected perfect score without traffic, got %+v", score)
+	}
+
+	// 成功率0.9，延迟500ms（阈值1s）：0.5*0.9 + 0.3*1 + 0.2*0.5 = 0.85
+	rm.setTestStats(100, 90, 500*time.Millisecond)
+	score := rm.GetHealthScore()
+	if math.Abs(score.Score-0.85) > 1e-9 || score.LatencyScore != 0.5 || score.SuccessScore != 0.9 {
+		t.Errorf("Unexpected composite score: %+v", score)
+	}
+	status := rm.GetHealthStatus()
+	if status["level"] != HealthLevelHealthy || status["healthy"] != true {
+		t.Errorf("Expected healthy level at score 0.85, got %v", status)
+	}
+
+	// 半开：0.5*0.9 + 0.3*0.5 + 0.2*0.5 = 0.7，低于降级阈值0.8
+	rm.setBreakerState(StateHalfOpen)
+	if score := rm.GetHealthScore(); math.Abs(score.Score-0.7) > 1e-9 || score.BreakerScore != 0.5 {
+		t.Errorf("Expected score 0.7 with half-open breaker, got %+v", score)
+	}
+	if level := rm.GetHealthStatus()["level"]; level != HealthLevelDegraded {
+		t.Errorf("Expected degraded level, got %v", level)
+	}
+
+	// 开启：熔断器得分为0且整体不健康；延迟超过阈值时延迟得分为0
+	rm.setBreakerState(StateOpen)
+	rm.setTestStats(100, 90, 3*time.Second)
+	score = rm.GetHealthScore()
+	if score.BreakerScore != 0 || score.LatencyScore != 0 || math.Abs(score.Score-0.45) > 1e-9 {
+		t.Errorf("Expected score 0.45 with open breaker and slow responses, got %+v", score)
+	}
+	if level := rm.GetHealthStatus()["level"]; level != HealthLevelUnhealthy {
+		t.Errorf("Expected unhealthy level, got %v", level)
+	}
+}
+
+func TestHealthScoreIgnoresSuccessRateBelowMinRequests(t *testing.T) {
+	rm := newTestResilienceManager(nil)
+
+	rm.setTestStats(5, 0, 0)
+	if score := rm.GetHealthScore(); score.SuccessScore != 1 {
+		t.Errorf("Expected success score 1 below min requests, got %+v", score)
+	}
+}
+
+func TestResilienceManagerResetClosesBreaker(t *testing.T) {
+	rm := newTestResilienceManager(nil)
+	rm.setTestStats(100, 10, 0)
+	rm.setBreakerState(StateOpen)
+
+	rm.Reset()
+
+	if !rm.circuitBreaker.IsClosed() {
+		t.Errorf("Expected breaker closed after Reset, got %s", rm.circuitBreaker.GetState())
+	}
+	if !rm.IsHealthy() {
+		t.Error("Expected manager healthy after Reset")
+	}
+}
+
+func TestExecuteMethodUsesIndependentConfigs(t *testing.T) {
+	rm := NewResilienceManager(&ResilienceConfig{
+		CircuitBreaker: DefaultCircuitBreakerConfig(), // 5次失败后熔断
+		Methods: map[string]*MethodResilienceConfig{
+			"batch": {CircuitBreaker: &CircuitBreakerConfig{
+				FailureThreshold: 2,
+				SuccessThreshold: 1,
+				Timeout:          time.Hour,
+				MaxRequests:      1,
+				StatWindow:       time.Minute,
+			}},
+			"single": {}, // 沿用默认配置，但使用独立实例
+		},
+	}, zap.NewNop())
+
+	ctx := context.Background()
+	failing := func(context.Context) (interface{}, error) { return nil, errors.New("boom") }
+	ok := func(context.Context) (interface{}, error) { return "ok", nil }
+
+	for i := 0; i < 2; i++ {
+		rm.ExecuteMethod(ctx, "batch", failing)
+		rm.ExecuteMethod(ctx, "single", failing)
+	}
+
+	if !rm.methods["batch"].circuitBreaker.IsOpen() {
+		t.Fatal("Expected batch breaker to open after 2 failures")
+	}
+	if !rm.methods["single"].circuitBreaker.IsClosed() {
+		t.Fatal("Expected single breaker to stay closed after 2 failures")
+	}
+	if !rm.circuitBreaker.IsClosed() {
+		t.Fatal("Expected default breaker to be untouched")
+	}
+
+	// batch熔断时其他方法和默认配置仍可正常执行
+	if _, err := rm.ExecuteMethod(ctx, "batch", ok); err == nil {
+		t.Error("Expected batch call to be rejected by open breaker")
+	}
+	if result, err := rm.ExecuteMethod(ctx, "single", ok); err != nil || result != "ok" {
+		t.Errorf("Expected single call to succeed, got %v, %v", result, err)
+	}
+	if result, err := rm.ExecuteMethod(ctx, "unknown", ok); err != nil || result != "ok" {
+		t.Errorf("Expected unconfigured method to use default breaker, got %v, %v", result, err)
+	}
+
+	// single按默认阈值5次失败后熔断
+	for i := 0; i < 5; i++ {
+		rm.ExecuteMethod(ctx, "single", failing)
+	}
+	if !rm.methods["single"].circuitBreaker.IsOpen() {
+		t.Error("Expected single breaker to open at the default threshold")
+	}
+
+	if trips := rm.GetStats().CircuitBreakerTrips; trips != 2 {
+		t.Errorf("Expected 2 breaker trips across methods, got %d", trips)
+	}
+
+	// Reset同时关闭所有方法的熔断器
+	rm.Reset()
+	for method, c := range rm.methods {
+		if !c.circuitBreaker.IsClosed() {
+			t.Errorf("Expected %s breaker closed after Reset", method)
+		}
+	}
+}
+
+// newFallbackTestManager 创建只启用默认值降级的弹性管理器
+func newFallbackTestManager(defaultResponse interface{}) *ResilienceManager {
+	return NewResilienceManager(&ResilienceConfig{
+		Fallback: &FallbackConfig{
+			Enabled:         true,
+			Strategy:        FallbackToDefault,
+			DefaultResponse: defaultResponse,
+		},
+	}, zap.NewNop())
+}
+
+func TestExecuteTypedFallbackTypeMismatch(t *testing.T) {
+	rm := newFallbackTestManager("cached response")
+
+	value, err := Execute(context.Background(), rm, func(context.Context) (int64, error) {
+		return 0, errors.New("backend down")
+	})
+	if !errors.Is(err, ErrUnexpectedResultType) {
+		t.Fatalf("Expected ErrUnexpectedResultType, got %v", err)
+	}
+	if value != 0 {
+		t.Errorf("Expected zero value on mismatch, got %d", value)
+	}
+}
+
+func TestExecuteTypedFallbackMatchingType(t *testing.T) {
+	rm := newFallbackTestManager(int64(42))
+
+	value, err := Execute(context.Background(), rm, func(context.Context) (int64, error) {
+		return 0, errors.New("backend down")
+	})
+	if err != nil || value != 42 {
+		t.Fatalf("Expected fallback value 42, got %d, %v", value, err)
+	}
+
+	value, err = Execute(context.Background(), rm, func(context.Context) (int64, error) {
+		return 7, nil
+	})
+	if err != nil || value != 7 {
+		t.Errorf("Expected primary value 7, got %d, %v", value, err)
+	}
+}
@@ -220,6 +220,20 @@ func (r *Retryer) GetStats() RetryStats {
 	return stats
 }
 
+// Stats 以通用格式返回重试统计，实现stats.StatsProvider
+func (r *Retryer) Stats() map[string]interface{} {
+	stats := r.GetStats()
+	return map[string]interface{}{
+		"total_attempts":       stats.TotalAttempts,
+		"success_attempts":     stats.SuccessAttempts,
+		"failed_attempts":      stats.FailedAttempts,
+		"retried_requests":     stats.RetriedRequests,
+		"total_retry_delay_ms": stats.TotalRetryDelay.Milliseconds(),
+		"max_retry_delay_ms":   stats.MaxRetryDelay.Milliseconds(),
+		"avg_retry_delay_ms":   stats.AvgRetryDelay.Milliseconds(),
+	}
+}
+
 // Reset 重置统计信息
 func (r *Retryer) Reset() {
 	r.stats = RetryStats{}
@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,6 +11,12 @@ import (
 )
 
 // Consumer Kafka消费者接口
+//
+// 投递语义：默认开启自动提交，消息处理后（无论成功与否，失败的消息重试耗尽后进入死信主题，
+// 未配置死信主题时跳过）即标记offset，由后台定期提交，崩溃时可能重复处理最近一个提交周期内的消息。
+// 死信主题发送失败时按退避重试，发送成功前不标记offset。
+// 关闭EnableAutoCommit后为严格的at-least-once：只有处理成功或已进入死信主题的消息才会提交，
+// 未能处理的消息使所在分区暂停消费，再均衡或重启后从这条消息重新投递，不会被跳过。
 type Consumer interface {
 	Subscribe(topics []string) error
 	ConsumeMessages(ctx context.Context, handler MessageHandler) error
@@ -25,19 +32,43 @@ type MockConsumer struct {
 	producer *MockProducer // 引用Producer来模拟消息传递
 	logger   *zap.Logger
 	stats    ConsumerStats
+	gate     *drainGate // 优雅排空控制
 	mu       sync.RWMutex
 	running  bool
+
+	pollInterval time.Duration // 检查新消息的间隔
+	lagRecorder  LagRecorder   // 消费延迟指标，为空时不上报
 }
 
+// mockConsumerGroup 模拟消费者上报消费延迟时使用的消费组名
+const mockConsumerGroup = "mock"
+
 // NewMockConsumer 创建模拟消费者
 func NewMockConsumer(producer *MockProducer, logger *zap.Logger) *MockConsumer {
 	return &MockConsumer{
 		producer: producer,
+		gate:     newDrainGate(),
 		logger:   logger,
 		stats:    ConsumerStats{},
+
+		pollInterval: 2 * time.Second,
+	}
+}
+
+// SetPollInterval 设置检查新消息的间隔，需在ConsumeMessages之前调用
+func (c *MockConsumer) SetPollInterval(interval time.Duration) {
+	if interval > 0 {
+		c.pollInterval = interval
 	}
 }
 
+// SetLagRecorder 设置消费延迟指标记录器，需在ConsumeMessages之前调用
+func (c *MockConsumer) SetLagRecorder(recorder LagRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lagRecorder = recorder
+}
+
 // Subscribe 订阅主题
 func (c *MockConsumer) Subscribe(topics []string) error {
 	c.logger.Info("Mock consumer subscribed to topics", zap.Strings("topics", topics))
@@ -52,7 +83,7 @@ func (c *MockConsumer) ConsumeMessages(ctx context.Context, handler MessageHandl
 
 	c.logger.Info("Mock consumer started consuming messages")
 
-	ticker := time.NewTicker(2 * time.Second) // 每2秒检查一次新消息
+	ticker := time.NewTicker(c.pollInterval) // 定期检查新消息
 	defer ticker.Stop()
 
 	var lastProcessed int
@@ -64,14 +95,25 @@ func (c *MockConsumer) ConsumeMessages(ctx context.Context, handler MessageHandl
 			c.running = false
 			c.mu.Unlock()
 			return ctx.Err()
+		case <-c.gate.stopped():
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			return nil
 		case <-ticker.C:
 			// 获取新消息
 			messages := c.producer.GetMessages()
+			c.reportLag(messages, lastProcessed)
 
 			// 处理未处理的消息
 			for i := lastProcessed; i < len(messages); i++ {
 				msg := messages[i]
 
+				// 排空中不再处理新消息
+				if !c.gate.enter() {
+					break
+				}
+
 				c.logger.Debug("Processing message",
 					zap.String("topic", msg.Topic),
 					zap.String("key", msg.Key))
@@ -86,11 +128,38 @@ func (c *MockConsumer) ConsumeMessages(ctx context.Context, handler MessageHandl
 					c.stats.MessagesProcessed++
 					c.mu.Unlock()
 				}
+				c.gate.leave()
+				lastProcessed = i + 1
 			}
+		}
+	}
+}
 
-			lastProcessed = len(messages)
+// reportLag 按主题上报已生产但尚未处理的消息数，模拟消费延迟，分区固定为0
+func (c *MockConsumer) reportLag(messages []Message, processed int) {
+	c.mu.RLock()
+	recorder := c.lagRecorder
+	c.mu.RUnlock()
+	if recorder == nil {
+		return
+	}
+
+	lags := make(map[string]int64)
+	for i, msg := range messages {
+		if i >= processed {
+			lags[msg.Topic]++
+		} else if _, ok := lags[msg.Topic]; !ok {
+			lags[msg.Topic] = 0
 		}
 	}
+	for topic, lag := range lags {
+		recorder.SetConsumerLag(topic, "0", mockConsumerGroup, lag)
+	}
+}
+
+// Drain 停止处理新消息并等待在途消息处理完成
+func (c *MockConsumer) Drain(ctx context.Context) error {
+	return c.gate.drain(ctx)
 }
 
 // Close 关闭消费者
@@ -122,20 +191,48 @@ type ConsumerStats struct {
 	MessagesProcessed int64 `json:"messages_processed"`
 	ErrorsCount       int64 `json:"errors_count"`
 	LastMessageTime   int64 `json:"last_message_time"`
+	DeadLetteredCount int64 `json:"dead_lettered_count"` // 重试耗尽后发送到死信主题的消息数
 }
 
 // CounterEventHandler 计数器事件处理器
 type CounterEventHandler struct {
 	updateFunc func(ctx context.Context, event *CounterEvent) error
+	mode       ProcessingMode
+	deduper    Deduper
 	logger     *zap.Logger
 }
 
-// NewCounterEventHandler 创建计数器事件处理器
+// NewCounterEventHandler 创建计数器事件处理器（at_least_once语义）
 func NewCounterEventHandler(updateFunc func(ctx context.Context, event *CounterEvent) error, logger *zap.Logger) *CounterEventHandler {
-	return &CounterEventHandler{
+	return NewCounterEventHandlerWithConfig(updateFunc, DefaultEventProcessingConfig(), logger)
+}
+
+// NewCounterEventHandlerWithConfig 按处理语义配置创建计数器事件处理器
+func NewCounterEventHandlerWithConfig(updateFunc func(ctx context.Context, event *CounterEvent) error, config *EventProcessingConfig, logger *zap.Logger) *CounterEventHandler {
+	if config == nil {
+		config = DefaultEventProcessingConfig()
+	}
+
+	h := &CounterEventHandler{
 		updateFunc: updateFunc,
+		mode:       config.Mode,
 		logger:     logger,
 	}
+	if h.mode == ProcessingModeEffectivelyOnce {
+		h.deduper = config.Deduper
+		if h.deduper == nil {
+			h.deduper = NewMemoryDeduper(config.DedupeTTL, config.DedupeCapacity)
+		}
+	}
+	return h
+}
+
+// Mode 获取处理语义
+func (h *CounterEventHandler) Mode() ProcessingMode {
+	if h.mode == "" {
+		return ProcessingModeAtLeastOnce
+	}
+	return h.mode
 }
 
 // HandleMessage 处理消息
@@ -152,11 +249,46 @@ func (h *CounterEventHandler) HandleMessage(ctx context.Context, msg *Message) e
 		return err
 	}
 
+	if h.deduper == nil {
+		return h.process(ctx, &event)
+	}
+
+	// effectively_once：按事件ID去重，更新成功后才确认去重记录
+	eventID := event.EventID
+	if eventID == "" {
+		eventID = msg.Headers["event_id"]
+	}
+	if eventID == "" {
+		h.logger.Warn("Counter event without event_id, processing without dedupe",
+			zap.String("resource_id", event.ResourceID))
+		return h.process(ctx, &event)
+	}
+
+	switch h.deduper.Reserve(eventID) {
+	case ReserveDuplicate:
+		h.logger.Debug("Skipping duplicate counter event", zap.String("event_id", eventID))
+		return nil
+	case ReserveInFlight:
+		// 持有者可能已崩溃，事件未必已处理，不能当作重复事件跳过并提交offset
+		return fmt.Errorf("%w: %s", ErrEventInFlight, eventID)
+	}
+
+	if err := h.process(ctx, &event); err != nil {
+		h.deduper.Release(eventID)
+		return err
+	}
+
+	h.deduper.Commit(eventID)
+	return nil
+}
+
+// process 执行计数器事件更新
+func (h *CounterEventHandler) process(ctx context.Context, event *CounterEvent) error {
 	h.logger.Info("Processing counter event",
 		zap.String("resource_id", event.ResourceID),
 		zap.String("counter_type", event.CounterType),
 		zap.Int64("delta", event.Delta))
 
 	// 调用更新函数
-	return h.updateFunc(ctx, &event)
+	return h.updateFunc(ctx, event)
 }
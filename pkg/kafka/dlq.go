@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DLQTopicSuffix 死信主题后缀，原主题为counter-events时死信主题为counter-events.dlq
+const DLQTopicSuffix = ".dlq"
+
+// 死信消息头，记录失败原因和原始位置
+const (
+	HeaderDLQID            = "dlq_id"
+	HeaderDLQOriginalTopic = "dlq_original_topic"
+	HeaderDLQError         = "dlq_error"
+	HeaderDLQFailedAt      = "dlq_failed_at"
+	HeaderDLQAttempts      = "dlq_attempts"
+	HeaderDLQPartition     = "dlq_original_partition"
+	HeaderDLQOffset        = "dlq_original_offset"
+)
+
+// DLQTopic 原主题对应的死信主题
+func DLQTopic(topic string) string {
+	return topic + DLQTopicSuffix
+}
+
+// DeadLetter 死信消息及其失败信息
+type DeadLetter struct {
+	ID            string    `json:"id"`
+	OriginalTopic string    `json:"original_topic"`
+	Error         string    `json:"error"`
+	FailedAt      time.Time `json:"failed_at"`
+	Attempts      int       `json:"attempts"`
+	Message       *Message  `json:"message"`
+}
+
+// NewDeadLetterMessage 将处理失败的消息包装为死信消息，保留原消息头
+func NewDeadLetterMessage(msg *Message, cause error, attempts int) *Message {
+	headers := make(map[string]string, len(msg.Headers)+5)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDLQID] = NewEventID()
+	headers[HeaderDLQOriginalTopic] = msg.Topic
+	headers[HeaderDLQFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+	headers[HeaderDLQAttempts] = strconv.Itoa(attempts)
+	if cause != nil {
+		headers[HeaderDLQError] = cause.Error()
+	}
+
+	return &Message{
+		Topic:     DLQTopic(msg.Topic),
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+		Timestamp: time.Now(),
+	}
+}
+
+// ParseDeadLetter 从死信消息头解析失败信息，缺失的字段按消息本身推断
+func ParseDeadLetter(msg *Message) *DeadLetter {
+	dl := &DeadLetter{
+		ID:            msg.Headers[HeaderDLQID],
+		OriginalTopic: msg.Headers[HeaderDLQOriginalTopic],
+		Error:         msg.Headers[HeaderDLQError],
+		Message:       msg,
+	}
+
+	if dl.OriginalTopic == "" {
+		dl.OriginalTopic = strings.TrimSuffix(msg.Topic, DLQTopicSuffix)
+	}
+	if failedAt, err := time.Parse(time.RFC3339Nano, msg.Headers[HeaderDLQFailedAt]); err == nil {
+		dl.FailedAt = failedAt
+	} else {
+		dl.FailedAt = msg.Timestamp
+	}
+	if attempts, err := strconv.Atoi(msg.Headers[HeaderDLQAttempts]); err == nil {
+		dl.Attempts = attempts
+	}
+	if dl.ID == "" {
+		// 没有ID的死信按key和时间标识
+		dl.ID = msg.Key + "@" + strconv.FormatInt(dl.FailedAt.UnixNano(), 10)
+	}
+	return dl
+}
+
+// RequeueMessage 构建发回原主题的消息，去掉死信相关的消息头
+func (d *DeadLetter) RequeueMessage() *Message {
+	headers := make(map[string]string, len(d.Message.Headers))
+	for k, v := range d.Message.Headers {
+		if strings.HasPrefix(k, "dlq_") {
+			continue
+		}
+		headers[k] = v
+	}
+
+	return &Message{
+		Topic:     d.OriginalTopic,
+		Key:       d.Message.Key,
+		Value:     d.Message.Value,
+		Headers:   headers,
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDrainTimeout 排空超时，仍有消息在处理中
+var ErrDrainTimeout = errors.New("consumer drain timed out")
+
+// Drainer 支持优雅排空的消费者
+//
+// Drain 停止接收新消息并等待在途消息处理完成（受ctx超时约束），
+// 处理完成后由消费者提交offset。Drain返回后调用方再取消消费ctx并Close。
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// drainGate 跟踪在途消息处理，排空时拒绝新消息并等待在途处理完成
+type drainGate struct {
+	mu       sync.Mutex
+	draining bool
+	stop     chan struct{}
+	inFlight sync.WaitGroup
+	active   int64
+}
+
+// newDrainGate 创建排空控制器
+func newDrainGate() *drainGate {
+	return &drainGate{stop: make(chan struct{})}
+}
+
+// enter 开始处理一条消息，排空中返回false
+func (g *drainGate) enter() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.draining {
+		return false
+	}
+	g.inFlight.Add(1)
+	atomic.AddInt64(&g.active, 1)
+	return true
+}
+
+// leave 结束处理一条消息
+func (g *drainGate) leave() {
+	atomic.AddInt64(&g.active, -1)
+	g.inFlight.Done()
+}
+
+// stopped 排空开始后关闭的通道
+func (g *drainGate) stopped() <-chan struct{} {
+	return g.stop
+}
+
+// wait 等待所有在途消息处理完成
+func (g *drainGate) wait() {
+	g.inFlight.Wait()
+}
+
+// drain 停止接收新消息并等待在途处理完成
+func (g *drainGate) drain(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.draining {
+		g.draining = true
+		close(g.stop)
+	}
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %d messages in flight", ErrDrainTimeout, atomic.LoadInt64(&g.active))
+	}
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// fakeSession 测试用ConsumerGroupSession，记录标记和提交的offset
+type fakeSession struct {
+	ctx context.Context
+
+	mu        sync.Mutex
+	marked    []int64
+	committed []int64
+	commits   int
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return nil }
+func (s *fakeSession) MemberID() string           { return "member-1" }
+func (s *fakeSession) GenerationID() int32        { return 1 }
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func (s *fakeSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committed = append(s.committed[:0], s.marked...)
+	s.commits++
+}
+
+func (s *fakeSession) commitCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commits
+}
+
+// fakeClaim 测试用ConsumerGroupClaim
+type fakeClaim struct {
+	messages  chan *sarama.ConsumerMessage
+	partition int32
+}
+
+func (c *fakeClaim) Topic() string                            { return "counter-events" }
+func (c *fakeClaim) Partition() int32                         { return c.partition }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func TestRealConsumerDrainWaitsForInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var processed int64
+
+	consumer := &RealConsumer{gate: newDrainGate(), logger: zap.NewNop()}
+	consumer.handler = func(ctx context.Context, msg *Message) error {
+		if msg.Key == "slow" {
+			close(started)
+			<-release
+		}
+		atomic.AddInt64(&processed, 1)
+		return nil
+	}
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 0, Key: []byte("slow")}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 1, Key: []byte("next")}
+
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	claimDone := make(chan error, 1)
+	go func() { claimDone <- handler.ConsumeClaim(session, claim) }()
+
+	// 第一条消息处理中时开始排空
+	<-started
+	drainDone := make(chan error, 1)
+	go func() { drainDone <- consumer.Drain(context.Background()) }()
+
+	select {
+	case err := <-drainDone:
+		t.Fatalf("Drain returned before in-flight message completed: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	if err := <-drainDone; err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if err := <-claimDone; err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	// 在途消息处理完成并提交，排空后的消息不再处理
+	if got := atomic.LoadInt64(&processed); got != 1 {
+		t.Errorf("Expected only the in-flight message to be processed, got %d", got)
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if len(session.committed) != 1 || session.committed[0] != 0 {
+		t.Errorf("Expected offset 0 to be committed, got %v", session.committed)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	gate := newDrainGate()
+	if !gate.enter() {
+		t.Fatal("Expected enter to succeed before draining")
+	}
+	defer gate.leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := gate.drain(ctx); !errors.Is(err, ErrDrainTimeout) {
+		t.Errorf("Expected ErrDrainTimeout, got %v", err)
+	}
+	if gate.enter() {
+		t.Error("Expected enter to be rejected while draining")
+	}
+}
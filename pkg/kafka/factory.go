@@ -19,6 +19,10 @@ type KafkaConfig struct {
 	Mode     KafkaMode       `yaml:"mode"` // "mock" 或 "real"
 	Producer *ProducerConfig `yaml:"producer"`
 	Consumer *ConsumerConfig `yaml:"consumer"`
+
+	// FallbackToMock real模式下初始化失败时降级为缓冲生产者/空闲消费者，并定期重试升级到真实Kafka
+	FallbackToMock bool            `yaml:"fallback_to_mock"`
+	Fallback       *FallbackConfig `yaml:"fallback"`
 }
 
 // DefaultKafkaConfig 默认Kafka配置
@@ -27,6 +31,7 @@ func DefaultKafkaConfig() *KafkaConfig {
 		Mode:     ModeMock, // 默认使用Mock模式
 		Producer: DefaultProducerConfig(),
 		Consumer: DefaultConsumerConfig(),
+		Fallback: DefaultFallbackConfig(),
 	}
 }
 
@@ -76,7 +81,13 @@ func (f *ConsumerFactory) CreateConsumer(config *KafkaConfig, producer Producer,
 
 	case ModeReal:
 		logger.Info("Creating Real Kafka Consumer")
-		return NewRealConsumer(config.Consumer, logger)
+		consumer, err := NewRealConsumer(config.Consumer, logger)
+		if err != nil {
+			return nil, err
+		}
+		// 处理失败的消息通过同一个Producer发送到死信主题
+		consumer.SetDeadLetterProducer(producer)
+		return consumer, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported kafka mode: %s", config.Mode)
@@ -96,17 +107,31 @@ func NewKafkaManager(config *KafkaConfig, logger *zap.Logger) (*KafkaManager, er
 	producerFactory := NewProducerFactory()
 	consumerFactory := NewConsumerFactory()
 
+	fallback := config.Mode == ModeReal && config.FallbackToMock
+
 	// 创建Producer
 	producer, err := producerFactory.CreateProducer(config, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create producer: %w", err)
+		if !fallback {
+			return nil, fmt.Errorf("failed to create producer: %w", err)
+		}
+		logger.Warn("Real Kafka producer unavailable, falling back to buffering producer", zap.Error(err))
+		producer = NewFallbackProducer(func() (Producer, error) {
+			return producerFactory.CreateProducer(config, logger)
+		}, config.Fallback, logger)
 	}
 
 	// 创建Consumer
 	consumer, err := consumerFactory.CreateConsumer(config, producer, logger)
 	if err != nil {
-		producer.Close() // 清理已创建的Producer
-		return nil, fmt.Errorf("failed to create consumer: %w", err)
+		if !fallback {
+			producer.Close() // 清理已创建的Producer
+			return nil, fmt.Errorf("failed to create consumer: %w", err)
+		}
+		logger.Warn("Real Kafka consumer unavailable, falling back until Kafka recovers", zap.Error(err))
+		consumer = NewFallbackConsumer(func() (Consumer, error) {
+			return consumerFactory.CreateConsumer(config, producer, logger)
+		}, config.Fallback, logger)
 	}
 
 	return &KafkaManager{
@@ -132,6 +157,16 @@ func (m *KafkaManager) GetMode() KafkaMode {
 	return m.config.Mode
 }
 
+// IsDegraded 生产者或消费者是否处于降级模式
+func (m *KafkaManager) IsDegraded() bool {
+	for _, component := range []interface{}{m.producer, m.consumer} {
+		if degradable, ok := component.(interface{ Degraded() bool }); ok && degradable.Degraded() {
+			return true
+		}
+	}
+	return false
+}
+
 // Close 关闭所有连接
 func (m *KafkaManager) Close() error {
 	m.logger.Info("Closing Kafka manager")
@@ -162,13 +197,14 @@ func (m *KafkaManager) HealthCheck() map[string]interface{} {
 	health := make(map[string]interface{})
 
 	health["mode"] = string(m.config.Mode)
+	health["degraded"] = m.IsDegraded()
 	health["producer_stats"] = m.producer.GetStats()
 	health["consumer_stats"] = m.consumer.GetStats()
 
 	if realConsumer, ok := m.consumer.(*RealConsumer); ok {
 		health["consumer_running"] = realConsumer.IsRunning()
-	} else if mockConsumer, ok := m.consumer.(*MockConsumer); ok {
-		health["consumer_running"] = mockConsumer.IsRunning()
+	} else if runner, ok := m.consumer.(interface{ IsRunning() bool }); ok {
+		health["consumer_running"] = runner.IsRunning()
 	}
 
 	return health
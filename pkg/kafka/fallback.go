@@ -0,0 +1,443 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrFallbackBufferFull 降级期间缓冲的消息已达上限
+var ErrFallbackBufferFull = errors.New("kafka: fallback buffer is full")
+
+// FallbackConfig 真实Kafka不可用时的降级配置
+type FallbackConfig struct {
+	RetryInterval time.Duration // 重新连接真实Kafka的间隔
+	MaxBuffered   int           // 降级期间最多缓冲的消息数，超出后发送返回ErrFallbackBufferFull
+}
+
+// DefaultFallbackConfig 默认降级配置
+func DefaultFallbackConfig() *FallbackConfig {
+	return &FallbackConfig{
+		RetryInterval: 30 * time.Second,
+		MaxBuffered:   10000,
+	}
+}
+
+// normalizeFallbackConfig 补齐未设置的降级配置
+func normalizeFallbackConfig(config *FallbackConfig) *FallbackConfig {
+	defaults := DefaultFallbackConfig()
+	if config == nil {
+		return defaults
+	}
+	cfg := *config
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaults.RetryInterval
+	}
+	if cfg.MaxBuffered <= 0 {
+		cfg.MaxBuffered = defaults.MaxBuffered
+	}
+	return &cfg
+}
+
+// bufferedMessage 降级期间缓冲的消息或计数事件，升级后按原顺序重放
+type bufferedMessage struct {
+	msg   *Message
+	event *CounterEvent
+}
+
+// FallbackProducer 真实Kafka初始化失败时使用的降级生产者
+//
+// 降级期间消息缓冲在内存中，后台定期重试连接真实Kafka，
+// 连接成功后按顺序重放缓冲的消息并切换到真实生产者。
+type FallbackProducer struct {
+	connect func() (Producer, error)
+	config  *FallbackConfig
+	logger  *zap.Logger
+
+	mu      sync.RWMutex
+	real    Producer
+	buffer  []bufferedMessage
+	stats   ProducerStats // 降级期间的统计
+	dropped int64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewFallbackProducer 创建降级生产者并启动后台重连，connect用于创建真实生产者
+func NewFallbackProducer(connect func() (Producer, error), config *FallbackConfig, logger *zap.Logger) *FallbackProducer {
+	p := &FallbackProducer{
+		connect: connect,
+		config:  normalizeFallbackConfig(config),
+		logger:  logger,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go p.upgradeLoop()
+	return p
+}
+
+// upgradeLoop 定期重试连接真实Kafka，升级成功后退出
+func (p *FallbackProducer) upgradeLoop() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.config.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if p.tryUpgrade() {
+				return
+			}
+		}
+	}
+}
+
+// tryUpgrade 连接真实Kafka并重放缓冲的消息，重放失败时保留剩余消息等待下次重试
+//
+// 重放在锁外进行，期间的新消息继续追加到缓冲区末尾；缓冲区在持锁时为空才切换到真实生产者，
+// 保证新消息排在缓冲消息之后
+func (p *FallbackProducer) tryUpgrade() bool {
+	real, err := p.connect()
+	if err != nil {
+		p.logger.Debug("Kafka still unavailable, staying in fallback mode", zap.Error(err))
+		return false
+	}
+
+	ctx := context.Background()
+	replayed := 0
+	for {
+		p.mu.Lock()
+		if len(p.buffer) == 0 {
+			p.real = real
+			p.buffer = nil
+			p.mu.Unlock()
+			break
+		}
+		snapshot := p.buffer[:len(p.buffer):len(p.buffer)]
+		p.mu.Unlock()
+
+		sent, err := replayBuffered(ctx, real, snapshot)
+		replayed += sent
+
+		// 只有重放协程会从缓冲区头部移除消息，snapshot之后追加的消息保持原位
+		p.mu.Lock()
+		p.buffer = p.buffer[sent:]
+		remaining := len(p.buffer)
+		p.mu.Unlock()
+
+		if err != nil {
+			p.logger.Warn("Failed to replay buffered messages, will retry",
+				zap.Int("replayed", replayed),
+				zap.Int("remaining", remaining),
+				zap.Error(err))
+			real.Close()
+			return false
+		}
+	}
+
+	p.logger.Info("Kafka is reachable again, upgraded from fallback to real producer",
+		zap.Int("replayed", replayed))
+	return true
+}
+
+// replayBuffered 按顺序重放缓冲的消息，返回成功发送的条数
+func replayBuffered(ctx context.Context, real Producer, buffered []bufferedMessage) (int, error) {
+	for i, message := range buffered {
+		var err error
+		if message.event != nil {
+			err = real.SendCounterEvent(ctx, message.event)
+		} else {
+			err = real.SendMessage(ctx, message.msg)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(buffered), nil
+}
+
+// enqueue 降级期间缓冲消息
+func (p *FallbackProducer) enqueue(buffered bufferedMessage) error {
+	if len(p.buffer) >= p.config.MaxBuffered {
+		p.dropped++
+		p.stats.ErrorsCount++
+		return ErrFallbackBufferFull
+	}
+	p.buffer = append(p.buffer, buffered)
+	p.stats.LastMessageTime = time.Now().Unix()
+	return nil
+}
+
+// SendMessage 发送消息，降级期间缓冲到内存
+func (p *FallbackProducer) SendMessage(ctx context.Context, msg *Message) error {
+	p.mu.Lock()
+	if p.real != nil {
+		real := p.real
+		p.mu.Unlock()
+		return real.SendMessage(ctx, msg)
+	}
+	defer p.mu.Unlock()
+
+	copied := *msg
+	return p.enqueue(bufferedMessage{msg: &copied})
+}
+
+// SendCounterEvent 发送计数事件，降级期间缓冲到内存
+func (p *FallbackProducer) SendCounterEvent(ctx context.Context, event *CounterEvent) error {
+	ensureEventID(event)
+
+	p.mu.Lock()
+	if p.real != nil {
+		real := p.real
+		p.mu.Unlock()
+		return real.SendCounterEvent(ctx, event)
+	}
+	defer p.mu.Unlock()
+
+	copied := *event
+	return p.enqueue(bufferedMessage{event: &copied})
+}
+
+// SendCounterEvents 批量发送计数事件，降级期间整批缓冲到内存，缓冲区放不下时整批拒绝
+func (p *FallbackProducer) SendCounterEvents(ctx context.Context, events []*CounterEvent) error {
+	for _, event := range events {
+		ensureEventID(event)
+	}
+
+	p.mu.Lock()
+	if p.real != nil {
+		real := p.real
+		p.mu.Unlock()
+		return real.SendCounterEvents(ctx, events)
+	}
+	defer p.mu.Unlock()
+
+	if len(p.buffer)+len(events) > p.config.MaxBuffered {
+		p.dropped += int64(len(events))
+		p.stats.ErrorsCount += int64(len(events))
+		return ErrFallbackBufferFull
+	}
+	for _, event := range events {
+		copied := *event
+		p.buffer = append(p.buffer, bufferedMessage{event: &copied})
+	}
+	p.stats.LastMessageTime = time.Now().Unix()
+	return nil
+}
+
+// Degraded 是否仍处于降级模式
+func (p *FallbackProducer) Degraded() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.real == nil
+}
+
+// Close 停止重连并关闭生产者，仍在降级时缓冲的消息会丢失
+func (p *FallbackProducer) Close() error {
+	p.closeOnce.Do(func() { close(p.stop) })
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.real != nil {
+		return p.real.Close()
+	}
+	if len(p.buffer) > 0 {
+		p.logger.Warn("Fallback producer closed before Kafka recovered, buffered messages lost",
+			zap.Int("buffered", len(p.buffer)),
+			zap.Int64("dropped", p.dropped))
+	}
+	return nil
+}
+
+// GetStats 获取统计信息，降级期间Queued为缓冲的消息数
+func (p *FallbackProducer) GetStats() ProducerStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.real != nil {
+		return p.real.GetStats()
+	}
+	stats := p.stats
+	for _, buffered := range p.buffer {
+		if buffered.event != nil {
+			stats.EventsQueued++
+		} else {
+			stats.MessagesQueued++
+		}
+	}
+	return stats
+}
+
+// FallbackConsumer 真实Kafka初始化失败时使用的降级消费者
+//
+// 降级期间不消费任何消息，ConsumeMessages定期重试连接真实Kafka，
+// 连接成功后订阅已记录的主题并开始消费。
+type FallbackConsumer struct {
+	connect func() (Consumer, error)
+	config  *FallbackConfig
+	logger  *zap.Logger
+
+	mu          sync.RWMutex
+	real        Consumer
+	topics      []string
+	lagRecorder LagRecorder
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewFallbackConsumer 创建降级消费者，connect用于创建真实消费者
+func NewFallbackConsumer(connect func() (Consumer, error), config *FallbackConfig, logger *zap.Logger) *FallbackConsumer {
+	return &FallbackConsumer{
+		connect: connect,
+		config:  normalizeFallbackConfig(config),
+		logger:  logger,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Subscribe 订阅主题，降级期间记录主题，升级后再订阅
+func (c *FallbackConsumer) Subscribe(topics []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.topics = append([]string(nil), topics...)
+	if c.real != nil {
+		return c.real.Subscribe(topics)
+	}
+	return nil
+}
+
+// SetLagRecorder 设置消费延迟指标记录器，降级期间记录，升级后交给真实消费者
+func (c *FallbackConsumer) SetLagRecorder(recorder LagRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lagRecorder = recorder
+	if monitor, ok := c.real.(LagMonitor); ok {
+		monitor.SetLagRecorder(recorder)
+	}
+}
+
+// tryUpgrade 连接真实Kafka并订阅已记录的主题
+func (c *FallbackConsumer) tryUpgrade() (Consumer, error) {
+	real, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.topics) > 0 {
+		if err := real.Subscribe(c.topics); err != nil {
+			real.Close()
+			return nil, err
+		}
+	}
+	if monitor, ok := real.(LagMonitor); ok && c.lagRecorder != nil {
+		monitor.SetLagRecorder(c.lagRecorder)
+	}
+	c.real = real
+	return real, nil
+}
+
+// ConsumeMessages 消费消息，降级期间阻塞并定期重试连接，升级后交给真实消费者
+func (c *FallbackConsumer) ConsumeMessages(ctx context.Context, handler MessageHandler) error {
+	c.mu.RLock()
+	real := c.real
+	c.mu.RUnlock()
+	if real != nil {
+		return real.ConsumeMessages(ctx, handler)
+	}
+
+	ticker := time.NewTicker(c.config.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.stop:
+			return nil
+		case <-ticker.C:
+			real, err := c.tryUpgrade()
+			if err != nil {
+				c.logger.Debug("Kafka still unavailable, consumer staying in fallback mode", zap.Error(err))
+				continue
+			}
+			c.logger.Info("Kafka is reachable again, upgraded from fallback to real consumer")
+			return real.ConsumeMessages(ctx, handler)
+		}
+	}
+}
+
+// Degraded 是否仍处于降级模式
+func (c *FallbackConsumer) Degraded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.real == nil
+}
+
+// Drain 排空真实消费者的在途消息，降级期间没有在途消息
+func (c *FallbackConsumer) Drain(ctx context.Context) error {
+	c.mu.RLock()
+	real := c.real
+	c.mu.RUnlock()
+	if drainer, ok := real.(Drainer); ok {
+		return drainer.Drain(ctx)
+	}
+	return nil
+}
+
+// Close 停止重连并关闭真实消费者
+func (c *FallbackConsumer) Close() error {
+	c.closeOnce.Do(func() { close(c.stop) })
+
+	c.mu.RLock()
+	real := c.real
+	c.mu.RUnlock()
+	if real != nil {
+		return real.Close()
+	}
+	return nil
+}
+
+// GetStats 获取统计信息
+func (c *FallbackConsumer) GetStats() ConsumerStats {
+	c.mu.RLock()
+	real := c.real
+	c.mu.RUnlock()
+	if real != nil {
+		return real.GetStats()
+	}
+	return ConsumerStats{}
+}
+
+// IsRunning 降级期间视为未运行
+func (c *FallbackConsumer) IsRunning() bool {
+	c.mu.RLock()
+	real := c.real
+	c.mu.RUnlock()
+	if runner, ok := real.(interface{ IsRunning() bool }); ok {
+		return runner.IsRunning()
+	}
+	return false
+}
+
+// Assignment 真实消费者当前的分区分配
+func (c *FallbackConsumer) Assignment() map[string][]int32 {
+	c.mu.RLock()
+	real := c.real
+	c.mu.RUnlock()
+	if reporter, ok := real.(AssignmentReporter); ok {
+		return reporter.Assignment()
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var errKafkaDown = errors.New("kafka: client has run out of available brokers")
+
+// flakyConnect 前failures次连接失败，之后返回mock生产者
+func flakyConnect(failures int32, producer *MockProducer) (func() (Producer, error), *int32) {
+	var attempts int32
+	return func() (Producer, error) {
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			return nil, errKafkaDown
+		}
+		return producer, nil
+	}, &attempts
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewKafkaManagerFallsBackWhenKafkaUnreachable(t *testing.T) {
+	config := DefaultKafkaConfig()
+	config.Mode = ModeReal
+	config.Producer.Brokers = []string{"127.0.0.1:1"}
+	config.Consumer.Brokers = []string{"127.0.0.1:1"}
+
+	// 未开启降级时初始化失败
+	if _, err := NewKafkaManager(config, zap.NewNop()); err == nil {
+		t.Fatal("Expected init to fail without fallback")
+	}
+
+	config.FallbackToMock = true
+	config.Fallback = &FallbackConfig{RetryInterval: time.Hour}
+	manager, err := NewKafkaManager(config, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Expected fallback instead of error, got %v", err)
+	}
+	defer manager.Close()
+
+	if !manager.IsDegraded() || manager.HealthCheck()["degraded"] != true {
+		t.Error("Expected manager to report degraded mode")
+	}
+	if _, ok := manager.GetProducer().(*FallbackProducer); !ok {
+		t.Errorf("Expected fallback producer, got %T", manager.GetProducer())
+	}
+	if _, ok := manager.GetConsumer().(*FallbackConsumer); !ok {
+		t.Errorf("Expected fallback consumer, got %T", manager.GetConsumer())
+	}
+
+	// 降级期间发送事件被缓冲
+	if err := manager.GetProducer().SendCounterEvent(context.Background(), &CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: 1}); err != nil {
+		t.Errorf("Expected event to be buffered, got %v", err)
+	}
+	if queued := manager.GetProducer().GetStats().EventsQueued; queued != 1 {
+		t.Errorf("Expected 1 buffered event, got %d", queued)
+	}
+}
+
+func TestFallbackProducerUpgradesAndReplays(t *testing.T) {
+	real := NewMockProducer(zap.NewNop())
+	connect, attempts := flakyConnect(2, real)
+	producer := NewFallbackProducer(connect, &FallbackConfig{RetryInterval: 10 * time.Millisecond}, zap.NewNop())
+	defer producer.Close()
+
+	ctx := context.Background()
+	for i := int64(1); i <= 3; i++ {
+		if err := producer.SendCounterEvent(ctx, &CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: i}); err != nil {
+			t.Fatalf("Expected event to be buffered, got %v", err)
+		}
+	}
+	if err := producer.SendMessage(ctx, &Message{Topic: "other", Key: "k"}); err != nil {
+		t.Fatalf("Expected message to be buffered, got %v", err)
+	}
+
+	waitFor(t, 2*time.Second, func() bool { return !producer.Degraded() })
+	if got := atomic.LoadInt32(attempts); got != 3 {
+		t.Errorf("Expected upgrade on 3rd attempt, got %d attempts", got)
+	}
+
+	// 缓冲的事件按顺序重放
+	events := real.GetEvents()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 replayed events, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.Delta != int64(i+1) || event.EventID == "" {
+			t.Errorf("Unexpected replayed event %d: %+v", i, event)
+		}
+	}
+	if messages := real.GetMessages(); len(messages) != 4 || messages[3].Topic != "other" {
+		t.Errorf("Expected buffered message replayed last, got %+v", messages)
+	}
+
+	// 升级后直接发送到真实生产者
+	if err := producer.SendCounterEvent(ctx, &CounterEvent{ResourceID: "article_2", CounterType: "like", Delta: 1}); err != nil {
+		t.Fatalf("SendCounterEvent after upgrade failed: %v", err)
+	}
+	if got := len(real.GetEvents()); got != 4 {
+		t.Errorf("Expected 4 events after upgrade, got %d", got)
+	}
+	if producer.GetStats().EventsSent != 4 {
+		t.Errorf("Expected stats from real producer, got %+v", producer.GetStats())
+	}
+}
+
+// gatedProducer 第一次发送阻塞直到release关闭，用于观察重放期间的并发发送
+type gatedProducer struct {
+	*MockProducer
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (p *gatedProducer) SendCounterEvent(ctx context.Context, event *CounterEvent) error {
+	p.once.Do(func() {
+		close(p.started)
+		<-p.release
+	})
+	return p.MockProducer.SendCounterEvent(ctx, event)
+}
+
+func TestFallbackProducerReplayDoesNotBlockSenders(t *testing.T) {
+	real := &gatedProducer{MockProducer: NewMockProducer(zap.NewNop()), started: make(chan struct{}), release: make(chan struct{})}
+	connect := func() (Producer, error) { return real, nil }
+	producer := NewFallbackProducer(connect, &FallbackConfig{RetryInterval: 50 * time.Millisecond}, zap.NewNop())
+	defer producer.Close()
+
+	ctx := context.Background()
+	if err := producer.SendCounterEvent(ctx, &CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: 1}); err != nil {
+		t.Fatalf("Expected event to be buffered, got %v", err)
+	}
+	<-real.started
+
+	// 重放阻塞期间发送不等待重放完成，新事件继续缓冲
+	sent := make(chan error, 1)
+	go func() {
+		sent <- producer.SendCounterEvent(ctx, &CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: 2})
+	}()
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Fatalf("Expected event to be buffered during replay, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SendCounterEvent blocked behind replay")
+	}
+	close(real.release)
+
+	// 重放期间缓冲的事件排在原缓冲事件之后
+	waitFor(t, 2*time.Second, func() bool { return !producer.Degraded() })
+	events := real.GetEvents()
+	if len(events) != 2 || events[0].Delta != 1 || events[1].Delta != 2 {
+		t.Errorf("Expected events replayed in order, got %+v", events)
+	}
+}
+
+func TestFallbackProducerBufferLimit(t *testing.T) {
+	connect, _ := flakyConnect(1<<30, nil)
+	producer := NewFallbackProducer(connect, &FallbackConfig{RetryInterval: time.Hour, MaxBuffered: 2}, zap.NewNop())
+	defer producer.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := producer.SendMessage(ctx, &Message{Key: "k"}); err != nil {
+			t.Fatalf("Expected message to be buffered, got %v", err)
+		}
+	}
+	if err := producer.SendMessage(ctx, &Message{Key: "k"}); !errors.Is(err, ErrFallbackBufferFull) {
+		t.Errorf("Expected ErrFallbackBufferFull, got %v", err)
+	}
+	if stats := producer.GetStats(); stats.MessagesQueued != 2 || stats.ErrorsCount != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestFallbackProducerBatchBuffering(t *testing.T) {
+	real := NewMockProducer(zap.NewNop())
+	upgrade := make(chan struct{})
+	connect := func() (Producer, error) {
+		select {
+		case <-upgrade:
+			return real, nil
+		default:
+			return nil, errKafkaDown
+		}
+	}
+	producer := NewFallbackProducer(connect, &FallbackConfig{RetryInterval: 10 * time.Millisecond, MaxBuffered: 3}, zap.NewNop())
+	defer producer.Close()
+
+	ctx := context.Background()
+	batch := func(n int) []*CounterEvent {
+		events := make([]*CounterEvent, n)
+		for i := range events {
+			events[i] = &CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: int64(i + 1)}
+		}
+		return events
+	}
+
+	if err := producer.SendCounterEvents(ctx, batch(2)); err != nil {
+		t.Fatalf("Expected batch to be buffered, got %v", err)
+	}
+	// 缓冲区放不下整批时整批拒绝，不缓冲部分事件
+	if err := producer.SendCounterEvents(ctx, batch(2)); !errors.Is(err, ErrFallbackBufferFull) {
+		t.Errorf("Expected ErrFallbackBufferFull, got %v", err)
+	}
+	if stats := producer.GetStats(); stats.EventsQueued != 2 || stats.ErrorsCount != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	close(upgrade)
+	waitFor(t, 2*time.Second, func() bool { return !producer.Degraded() })
+
+	// 升级后整批交给真实生产者
+	if err := producer.SendCounterEvents(ctx, batch(3)); err != nil {
+		t.Fatalf("SendCounterEvents after upgrade failed: %v", err)
+	}
+	events := real.GetEvents()
+	if len(events) != 5 {
+		t.Fatalf("Expected 5 events after upgrade, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.EventID == "" {
+			t.Errorf("Expected event %d to have an event id", i)
+		}
+	}
+}
+
+func TestFallbackConsumerUpgradesAndConsumes(t *testing.T) {
+	source := NewMockProducer(zap.NewNop())
+	var attempts int32
+	consumer := NewFallbackConsumer(func() (Consumer, error) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			return nil, errKafkaDown
+		}
+		real := NewMockConsumer(source, zap.NewNop())
+		real.SetPollInterval(5 * time.Millisecond)
+		return real, nil
+	}, &FallbackConfig{RetryInterval: 10 * time.Millisecond}, zap.NewNop())
+	defer consumer.Close()
+
+	if err := consumer.Subscribe([]string{"counter-events"}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if consumer.IsRunning() {
+		t.Error("Expected degraded consumer not to report running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var received []string
+	go consumer.ConsumeMessages(ctx, func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, msg.Key)
+		return nil
+	})
+
+	source.SendMessage(context.Background(), &Message{Topic: "counter-events", Key: "article_1:like"})
+	waitFor(t, 2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	})
+	if consumer.Degraded() {
+		t.Error("Expected consumer to be upgraded")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("Expected upgrade on 3rd attempt, got %d attempts", got)
+	}
+}
@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// IDGenerator 事件ID生成器
+type IDGenerator interface {
+	NewID() string
+}
+
+// crockfordAlphabet ULID使用的Crockford Base32字符表
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator 生成ULID格式的事件ID
+//
+// ID由48位毫秒时间戳和80位随机数组成，编码为26个字符，按字典序即按时间排序。
+// 同一毫秒内生成的ID在上一个随机数基础上递增，保证单实例内严格单调。
+type ULIDGenerator struct {
+	mu       sync.Mutex
+	entropy  io.Reader
+	now      func() time.Time
+	lastMs   uint64
+	lastRand [10]byte
+}
+
+// NewULIDGenerator 创建ULID生成器，使用crypto/rand作为随机源
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{
+		entropy: rand.Reader,
+		now:     time.Now,
+	}
+}
+
+// NewID 生成新的ULID
+func (g *ULIDGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms <= g.lastMs {
+		// 同一毫秒（或时钟回拨）时沿用上次时间戳并递增随机数
+		ms = g.lastMs
+		if !incrementRandom(&g.lastRand) {
+			// 随机数溢出时借用下一毫秒
+			ms++
+			g.readRandom()
+		}
+	} else {
+		g.readRandom()
+	}
+	g.lastMs = ms
+
+	var raw [16]byte
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(raw[:6], ts[2:])
+	copy(raw[6:], g.lastRand[:])
+	return encodeULID(raw)
+}
+
+// readRandom 读取新的随机数，随机源不可用时退化为时间派生的值
+func (g *ULIDGenerator) readRandom() {
+	if _, err := io.ReadFull(g.entropy, g.lastRand[:]); err != nil {
+		binary.BigEndian.PutUint64(g.lastRand[2:], uint64(time.Now().UnixNano()))
+	}
+}
+
+// incrementRandom 将80位随机数加1，溢出时返回false
+func incrementRandom(r *[10]byte) bool {
+	for i := len(r) - 1; i >= 0; i-- {
+		r[i]++
+		if r[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID 将128位数据编码为26个字符的Crockford Base32字符串
+func encodeULID(raw [16]byte) string {
+	hi := binary.BigEndian.Uint64(raw[:8])
+	lo := binary.BigEndian.Uint64(raw[8:])
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+var (
+	idGeneratorMu      sync.RWMutex
+	defaultIDGenerator IDGenerator = NewULIDGenerator()
+)
+
+// SetIDGenerator 替换全局事件ID生成器，传入nil时恢复默认的ULID生成器
+func SetIDGenerator(g IDGenerator) {
+	if g == nil {
+		g = NewULIDGenerator()
+	}
+	idGeneratorMu.Lock()
+	defaultIDGenerator = g
+	idGeneratorMu.Unlock()
+}
+
+// NewEventID 使用全局生成器生成事件ID
+func NewEventID() string {
+	idGeneratorMu.RLock()
+	g := defaultIDGenerator
+	idGeneratorMu.RUnlock()
+	return g.NewID()
+}
+
+// ensureEventID 事件未设置ID时补充生成
+func ensureEventID(event *CounterEvent) {
+	if event.EventID == "" {
+		event.EventID = NewEventID()
+	}
+}
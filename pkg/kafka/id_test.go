@@ -0,0 +1,112 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestULIDGeneratorUniqueAcrossGoroutines(t *testing.T) {
+	g := NewULIDGenerator()
+
+	const goroutines = 16
+	const perGoroutine = 2000
+
+	var wg sync.WaitGroup
+	results := make([][]string, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids := make([]string, perGoroutine)
+			for j := range ids {
+				ids[j] = g.NewID()
+			}
+			results[i] = ids
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]struct{}, goroutines*perGoroutine)
+	for _, ids := range results {
+		for i, id := range ids {
+			if len(id) != 26 {
+				t.Fatalf("Expected 26-char ID, got %q", id)
+			}
+			if _, dup := seen[id]; dup {
+				t.Fatalf("Duplicate ID generated: %s", id)
+			}
+			seen[id] = struct{}{}
+
+			// 同一goroutine内按生成顺序递增
+			if i > 0 && id <= ids[i-1] {
+				t.Fatalf("Expected increasing IDs, got %s after %s", id, ids[i-1])
+			}
+		}
+	}
+}
+
+func TestULIDGeneratorSortableByTime(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := base
+	g := NewULIDGenerator()
+	g.now = func() time.Time { return current }
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		ids = append(ids, g.NewID(), g.NewID())
+		current = current.Add(time.Millisecond)
+	}
+
+	// 时钟回拨时仍然单调
+	current = base
+	ids = append(ids, g.NewID())
+
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("Expected IDs sorted by generation time, got %v", ids)
+	}
+}
+
+func TestULIDGeneratorRandomOverflow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := NewULIDGenerator()
+	g.now = func() time.Time { return now }
+	g.entropy = bytes.NewReader(bytes.Repeat([]byte{0xff}, 20))
+
+	first := g.NewID()
+	second := g.NewID()
+
+	// 随机数已是最大值，下一个ID借用下一毫秒
+	if second <= first {
+		t.Errorf("Expected ID after overflow to sort later, got %s after %s", second, first)
+	}
+	if first[:10] == second[:10] {
+		t.Errorf("Expected timestamp part to advance after overflow, got %s and %s", first, second)
+	}
+}
+
+func TestProducerAssignsEventID(t *testing.T) {
+	producer := NewMockProducer(zap.NewNop())
+
+	event := &CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: 1}
+	if err := producer.SendCounterEvent(context.Background(), event); err != nil {
+		t.Fatalf("SendCounterEvent failed: %v", err)
+	}
+	if len(event.EventID) != 26 {
+		t.Errorf("Expected producer to assign a ULID event ID, got %q", event.EventID)
+	}
+
+	// 已设置的ID保持不变
+	event = &CounterEvent{EventID: "custom", ResourceID: "article_1", CounterType: "like"}
+	if err := producer.SendCounterEvent(context.Background(), event); err != nil {
+		t.Fatalf("SendCounterEvent failed: %v", err)
+	}
+	if event.EventID != "custom" {
+		t.Errorf("Expected existing event ID preserved, got %q", event.EventID)
+	}
+}
@@ -0,0 +1,147 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// defaultLagReportInterval 未配置时上报消费延迟的间隔
+const defaultLagReportInterval = 15 * time.Second
+
+// LagRecorder 消费延迟指标记录器，metrics.MetricsManager实现了该接口
+type LagRecorder interface {
+	SetConsumerLag(topic, partition, group string, lag int64)
+}
+
+// LagMonitor 能定期上报消费延迟的消费者
+type LagMonitor interface {
+	SetLagRecorder(recorder LagRecorder)
+}
+
+// lagSource 查询分区最新offset和消费组已提交的offset
+type lagSource interface {
+	Partitions(topic string) ([]int32, error)
+	HighWaterMark(topic string, partition int32) (int64, error)
+	CommittedOffsets(group string, partitions map[string][]int32) (map[string]map[int32]int64, error)
+}
+
+// saramaLagSource 基于Sarama Client和ClusterAdmin的lagSource，Client由消费者关闭
+type saramaLagSource struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin // 首次查询时创建，创建时需要连接controller
+}
+
+// Partitions 主题的全部分区
+func (s *saramaLagSource) Partitions(topic string) ([]int32, error) {
+	return s.client.Partitions(topic)
+}
+
+// HighWaterMark 分区下一条消息的offset
+func (s *saramaLagSource) HighWaterMark(topic string, partition int32) (int64, error) {
+	return s.client.GetOffset(topic, partition, sarama.OffsetNewest)
+}
+
+// CommittedOffsets 消费组在各分区已提交的offset，未提交过的分区为-1
+func (s *saramaLagSource) CommittedOffsets(group string, partitions map[string][]int32) (map[string]map[int32]int64, error) {
+	if s.admin == nil {
+		admin, err := sarama.NewClusterAdminFromClient(s.client)
+		if err != nil {
+			return nil, err
+		}
+		s.admin = admin
+	}
+
+	resp, err := s.admin.ListConsumerGroupOffsets(group, partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[string]map[int32]int64, len(partitions))
+	for topic, ids := range partitions {
+		offsets[topic] = make(map[int32]int64, len(ids))
+		for _, partition := range ids {
+			offset := int64(-1)
+			if block := resp.GetBlock(topic, partition); block != nil && block.Err == sarama.ErrNoError {
+				offset = block.Offset
+			}
+			offsets[topic][partition] = offset
+		}
+	}
+	return offsets, nil
+}
+
+// lagReporter 定期计算消费组在各分区的延迟并上报
+type lagReporter struct {
+	source   lagSource
+	recorder LagRecorder
+	group    string
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+// run 按间隔上报消费延迟直到ctx取消或stop关闭
+func (r *lagReporter) run(ctx context.Context, topics []string, stop <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.report(topics)
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// report 计算一次延迟：最新offset减去已提交offset，未提交过的分区跳过
+func (r *lagReporter) report(topics []string) {
+	partitions := make(map[string][]int32, len(topics))
+	for _, topic := range topics {
+		ids, err := r.source.Partitions(topic)
+		if err != nil {
+			r.logger.Warn("Failed to list partitions for lag report",
+				zap.String("topic", topic), zap.Error(err))
+			continue
+		}
+		partitions[topic] = ids
+	}
+	if len(partitions) == 0 {
+		return
+	}
+
+	committed, err := r.source.CommittedOffsets(r.group, partitions)
+	if err != nil {
+		r.logger.Warn("Failed to fetch committed offsets for lag report",
+			zap.String("group_id", r.group), zap.Error(err))
+		return
+	}
+
+	for topic, ids := range partitions {
+		for _, partition := range ids {
+			offset, ok := committed[topic][partition]
+			if !ok || offset < 0 {
+				continue
+			}
+			hwm, err := r.source.HighWaterMark(topic, partition)
+			if err != nil {
+				r.logger.Warn("Failed to fetch high water mark for lag report",
+					zap.String("topic", topic),
+					zap.Int32("partition", partition),
+					zap.Error(err))
+				continue
+			}
+			lag := hwm - offset
+			if lag < 0 {
+				lag = 0
+			}
+			r.recorder.SetConsumerLag(topic, strconv.Itoa(int(partition)), r.group, lag)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recordingLagRecorder 记录每个分区最近一次上报的延迟
+type recordingLagRecorder struct {
+	mu   sync.Mutex
+	lags map[string]int64 // topic/partition/group -> lag
+}
+
+func newRecordingLagRecorder() *recordingLagRecorder {
+	return &recordingLagRecorder{lags: make(map[string]int64)}
+}
+
+func (r *recordingLagRecorder) SetConsumerLag(topic, partition, group string, lag int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lags[topic+"/"+partition+"/"+group] = lag
+}
+
+func (r *recordingLagRecorder) get(key string) (int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lag, ok := r.lags[key]
+	return lag, ok
+}
+
+// fakeLagSource 固定的最新offset和已提交offset
+type fakeLagSource struct {
+	hwm       map[int32]int64
+	committed map[int32]int64
+	err       error
+}
+
+func (s *fakeLagSource) Partitions(topic string) ([]int32, error) {
+	return []int32{0, 1, 2, 3}, nil
+}
+
+func (s *fakeLagSource) HighWaterMark(topic string, partition int32) (int64, error) {
+	return s.hwm[partition], nil
+}
+
+func (s *fakeLagSource) CommittedOffsets(group string, partitions map[string][]int32) (map[string]map[int32]int64, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	offsets := make(map[string]map[int32]int64)
+	for topic := range partitions {
+		offsets[topic] = s.committed
+	}
+	return offsets, nil
+}
+
+func TestLagReporterReportsHighWaterMarkMinusCommitted(t *testing.T) {
+	source := &fakeLagSource{
+		hwm:       map[int32]int64{0: 100, 1: 50, 2: 10, 3: 7},
+		committed: map[int32]int64{0: 40, 1: 50, 2: -1, 3: 9},
+	}
+	recorder := newRecordingLagRecorder()
+	reporter := &lagReporter{source: source, recorder: recorder, group: "analytics", logger: zap.NewNop()}
+
+	reporter.report([]string{"counter-events"})
+
+	if lag, _ := recorder.get("counter-events/0/analytics"); lag != 60 {
+		t.Errorf("Expected lag 60 on partition 0, got %d", lag)
+	}
+	if lag, ok := recorder.get("counter-events/1/analytics"); !ok || lag != 0 {
+		t.Errorf("Expected lag 0 on caught-up partition 1, got %d (reported=%v)", lag, ok)
+	}
+	// 未提交过offset的分区不上报
+	if _, ok := recorder.get("counter-events/2/analytics"); ok {
+		t.Error("Expected partition without committed offset to be skipped")
+	}
+	// 已提交offset超过最新offset（例如分区截断）时按0上报
+	if lag, _ := recorder.get("counter-events/3/analytics"); lag != 0 {
+		t.Errorf("Expected negative lag clamped to 0, got %d", lag)
+	}
+
+	// 查询失败时不上报
+	failing := newRecordingLagRecorder()
+	source.err = errors.New("coordinator not available")
+	(&lagReporter{source: source, recorder: failing, group: "analytics", logger: zap.NewNop()}).report([]string{"counter-events"})
+	if len(failing.lags) != 0 {
+		t.Errorf("Expected no lag reported on fetch error, got %v", failing.lags)
+	}
+}
+
+func TestMockConsumerReportsUnprocessedMessagesAsLag(t *testing.T) {
+	producer := NewMockProducer(zap.NewNop())
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := producer.SendMessage(ctx, &Message{Topic: "counter-events", Key: "k"}); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+
+	consumer := NewMockConsumer(producer, zap.NewNop())
+	consumer.SetPollInterval(5 * time.Millisecond)
+	recorder := newRecordingLagRecorder()
+	consumer.SetLagRecorder(recorder)
+
+	// 处理器阻塞，第一次轮询时上报的延迟为已生产未处理的消息数
+	release := make(chan struct{})
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go consumer.ConsumeMessages(consumeCtx, func(ctx context.Context, msg *Message) error {
+		<-release
+		return nil
+	})
+
+	waitFor(t, 2*time.Second, func() bool {
+		lag, ok := recorder.get("counter-events/0/mock")
+		return ok && lag == 3
+	})
+	close(release)
+
+	// 全部处理后延迟回到0
+	waitFor(t, 2*time.Second, func() bool {
+		lag, _ := recorder.get("counter-events/0/mock")
+		return lag == 0
+	})
+}
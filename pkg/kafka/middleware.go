@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrHandlerPanic 消息处理函数发生panic
+var ErrHandlerPanic = errors.New("kafka message handler panicked")
+
+// consumeOperation 消息处理的业务指标操作名
+const consumeOperation = "consume_message"
+
+// HandlerMiddleware 消息处理中间件
+type HandlerMiddleware func(next MessageHandler) MessageHandler
+
+// HandlerMetricsRecorder 消息处理指标记录器，metrics.MetricsManager实现了该接口
+type HandlerMetricsRecorder interface {
+	RecordBusinessOperation(operation, service, status string, duration time.Duration)
+}
+
+// ChainHandler 按顺序组合中间件，第一个中间件位于最外层
+func ChainHandler(handler MessageHandler, middlewares ...HandlerMiddleware) MessageHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// RecoveryMiddleware 捕获处理函数的panic并转换为ErrHandlerPanic错误，避免消费循环退出
+func RecoveryMiddleware(logger *zap.Logger) HandlerMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Kafka message handler panic recovered",
+						zap.String("topic", msg.Topic),
+						zap.String("key", msg.Key),
+						zap.Any("panic", r),
+						zap.ByteString("stack", debug.Stack()))
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// LoggingMiddleware 记录消息处理日志，成功为Debug级别，失败为Warn级别
+func LoggingMiddleware(logger *zap.Logger) HandlerMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			fields := []zap.Field{
+				zap.String("topic", msg.Topic),
+				zap.String("key", msg.Key),
+				zap.Any("headers", msg.Headers),
+				zap.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				logger.Warn("Kafka message handling failed", append(fields, zap.Error(err))...)
+			} else {
+				logger.Debug("Kafka message handled", fields...)
+			}
+			return err
+		}
+	}
+}
+
+// MetricsMiddleware 记录消息处理耗时和结果，recorder为nil时不记录
+func MetricsMiddleware(recorder HandlerMetricsRecorder, service string) HandlerMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		if recorder == nil {
+			return next
+		}
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			status := "success"
+			if err != nil {
+				status = "error"
+			}
+			recorder.RecordBusinessOperation(consumeOperation, service, status, time.Since(start))
+			return err
+		}
+	}
+}
+
+// DefaultHandlerMiddlewares 默认中间件链：指标 -> 日志 -> panic恢复
+//
+// panic恢复位于最内层，使panic转换后的错误同样计入日志和错误指标。
+func DefaultHandlerMiddlewares(recorder HandlerMetricsRecorder, service string, logger *zap.Logger) []HandlerMiddleware {
+	return []HandlerMiddleware{
+		MetricsMiddleware(recorder, service),
+		LoggingMiddleware(logger),
+		RecoveryMiddleware(logger),
+	}
+}
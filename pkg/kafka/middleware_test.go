@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"high-go-press/pkg/metrics"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecoveryMiddlewareRecordsErrorMetric(t *testing.T) {
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test", EnableBusiness: true}, zap.NewNop())
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	handler := ChainHandler(func(ctx context.Context, msg *Message) error {
+		panic("boom")
+	}, DefaultHandlerMiddlewares(mm, "analytics", logger)...)
+
+	msg := &Message{Topic: "counter-events", Key: "article_1:like", Headers: map[string]string{"event_type": "counter_update"}}
+
+	// panic被恢复为错误，消费循环不会退出
+	err := handler(context.Background(), msg)
+	if !errors.Is(err, ErrHandlerPanic) {
+		t.Fatalf("Expected ErrHandlerPanic, got %v", err)
+	}
+
+	if got := consumeCount(t, mm, "error"); got != 1 {
+		t.Errorf("Expected error metric 1, got %v", got)
+	}
+
+	// 成功的消息计入success
+	ok := ChainHandler(func(ctx context.Context, msg *Message) error { return nil },
+		DefaultHandlerMiddlewares(mm, "analytics", logger)...)
+	if err := ok(context.Background(), msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := consumeCount(t, mm, "success"); got != 1 {
+		t.Errorf("Expected success metric 1, got %v", got)
+	}
+	if got := consumeCount(t, mm, "error"); got != 1 {
+		t.Errorf("Expected error metric to stay 1, got %v", got)
+	}
+
+	if logs.FilterMessage("Kafka message handler panic recovered").Len() != 1 {
+		t.Errorf("Expected panic to be logged")
+	}
+	failed := logs.FilterMessage("Kafka message handling failed").All()
+	if len(failed) != 1 {
+		t.Fatalf("Expected 1 failure log, got %d", len(failed))
+	}
+	if headers, _ := failed[0].ContextMap()["headers"].(map[string]string); headers["event_type"] != "counter_update" {
+		t.Errorf("Expected headers in failure log, got %v", failed[0].ContextMap()["headers"])
+	}
+}
+
+func TestChainHandlerOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) HandlerMiddleware {
+		return func(next MessageHandler) MessageHandler {
+			return func(ctx context.Context, msg *Message) error {
+				calls = append(calls, name+":before")
+				err := next(ctx, msg)
+				calls = append(calls, name+":after")
+				return err
+			}
+		}
+	}
+
+	handler := ChainHandler(func(ctx context.Context, msg *Message) error {
+		calls = append(calls, "handler")
+		return nil
+	}, trace("outer"), trace("inner"))
+
+	if err := handler(context.Background(), &Message{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{"outer:before", "inner:before", "handler", "inner:after", "outer:after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected call order %v, got %v", want, calls)
+	}
+}
+
+// consumeCount 读取消息处理指标计数
+func consumeCount(t *testing.T, mm *metrics.MetricsManager, status string) float64 {
+	t.Helper()
+
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_business_operations_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] == consumeOperation && labels["service"] == "analytics" && labels["status"] == status {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,182 @@
+package kafka
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// partitionInFlightPerWorker 每个worker允许的在途消息数，超过后暂停拉取
+const partitionInFlightPerWorker = 8
+
+// partitionDispatcher 分区内按消息key并发处理
+//
+// 相同key的消息分发到同一个worker以保证顺序，不同key并行处理。
+// worker完成后通过done通道通知消费循环，消费循环只标记从最早在途消息开始
+// 连续完成的部分，保证提交的offset之前的消息都已处理完成。
+type partitionDispatcher struct {
+	handler *consumerGroupHandler
+	session sarama.ConsumerGroupSession
+	workers []chan *sarama.ConsumerMessage
+	done    chan dispatchResult
+	wg      sync.WaitGroup
+
+	// 以下字段只在消费循环中访问
+	pending     []*sarama.ConsumerMessage // 已分发未标记的消息，按offset排序
+	finished    map[int64]bool            // 已处理完成但尚未标记的offset，值为是否已妥善处理
+	unhandled   *sarama.ConsumerMessage   // 第一条不能标记的消息，之后不再标记
+	maxInFlight int
+}
+
+// dispatchResult worker处理完成的消息及是否已妥善处理
+type dispatchResult struct {
+	msg     *sarama.ConsumerMessage
+	handled bool
+}
+
+// newPartitionDispatcher 创建分区并发处理器并启动worker
+func newPartitionDispatcher(h *consumerGroupHandler, session sarama.ConsumerGroupSession, concurrency int) *partitionDispatcher {
+	maxInFlight := concurrency * partitionInFlightPerWorker
+	d := &partitionDispatcher{
+		handler:     h,
+		session:     session,
+		workers:     make([]chan *sarama.ConsumerMessage, concurrency),
+		done:        make(chan dispatchResult, maxInFlight),
+		finished:    make(map[int64]bool),
+		maxInFlight: maxInFlight,
+	}
+
+	for i := range d.workers {
+		// 在途消息总数受maxInFlight限制，分发和完成通知都不会阻塞
+		d.workers[i] = make(chan *sarama.ConsumerMessage, maxInFlight)
+		d.wg.Add(1)
+		go d.work(d.workers[i])
+	}
+	return d
+}
+
+// work 顺序处理分配给该worker的消息
+func (d *partitionDispatcher) work(messages <-chan *sarama.ConsumerMessage) {
+	defer d.wg.Done()
+	for msg := range messages {
+		handled := d.handler.handleMessage(d.session.Context(), msg)
+		d.handler.consumer.gate.leave()
+		d.done <- dispatchResult{msg: msg, handled: handled}
+	}
+}
+
+// full 在途消息是否达到上限
+func (d *partitionDispatcher) full() bool {
+	return len(d.pending) >= d.maxInFlight
+}
+
+// dispatch 按key分发消息
+func (d *partitionDispatcher) dispatch(msg *sarama.ConsumerMessage) {
+	d.pending = append(d.pending, msg)
+	d.workers[workerIndex(msg, len(d.workers))] <- msg
+}
+
+// complete 记录消息处理完成，并标记从最早在途消息开始连续完成的消息
+// 遇到不能标记的消息时停止标记，返回false表示分区需要暂停
+func (d *partitionDispatcher) complete(result dispatchResult, batcher *commitBatcher) bool {
+	d.finished[result.msg.Offset] = result.handled
+	for d.unhandled == nil && len(d.pending) > 0 {
+		head := d.pending[0]
+		handled, ok := d.finished[head.Offset]
+		if !ok {
+			break
+		}
+		if !handled {
+			d.unhandled = head
+			break
+		}
+		delete(d.finished, head.Offset)
+		d.pending = d.pending[1:]
+		d.handler.markMessage(d.session, head, batcher)
+	}
+	return d.unhandled == nil
+}
+
+// close 停止分发并等待所有在途消息处理完成后标记offset
+func (d *partitionDispatcher) close(batcher *commitBatcher) {
+	for _, worker := range d.workers {
+		close(worker)
+	}
+	d.wg.Wait()
+
+	for {
+		select {
+		case result := <-d.done:
+			d.complete(result, batcher)
+		default:
+			return
+		}
+	}
+}
+
+// workerIndex 计算消息分配的worker，无key的消息之间没有顺序要求，按offset轮询
+func workerIndex(msg *sarama.ConsumerMessage, workers int) int {
+	if len(msg.Key) == 0 {
+		return int(msg.Offset % int64(workers))
+	}
+	h := fnv.New32a()
+	h.Write(msg.Key)
+	return int(h.Sum32() % uint32(workers))
+}
+
+// consumeClaimConcurrent 按key并发处理分区消息
+func (h *consumerGroupHandler) consumeClaimConcurrent(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, batcher *commitBatcher) error {
+	gate := h.consumer.gate
+	d := newPartitionDispatcher(h, session, h.consumer.concurrency)
+
+	h.logger.Debug("Consuming claim concurrently",
+		zap.String("topic", claim.Topic()),
+		zap.Int32("partition", claim.Partition()),
+		zap.Int("concurrency", h.consumer.concurrency))
+
+	for {
+		// 在途消息达到上限时暂停拉取，等待worker完成
+		messages := claim.Messages()
+		if d.full() {
+			messages = nil
+		}
+
+		select {
+		case <-session.Context().Done():
+			d.close(batcher)
+			batcher.flush(session)
+			return nil
+		case <-gate.stopped():
+			d.close(batcher)
+			return h.finishDrain(session, claim)
+		case <-batcher.tick():
+			if batcher.due() {
+				batcher.flush(session)
+			}
+		case result := <-d.done:
+			if !d.complete(result, batcher) {
+				d.close(batcher)
+				return h.pauseClaim(session, claim, d.unhandled, batcher)
+			}
+		case saramaMsg := <-messages:
+			if saramaMsg == nil {
+				d.close(batcher)
+				if d.unhandled != nil {
+					return h.pauseClaim(session, claim, d.unhandled, batcher)
+				}
+				batcher.flush(session)
+				return nil
+			}
+
+			// 排空中不再处理新消息，未标记的消息由下一个消费者重新投递
+			if !gate.enter() {
+				d.close(batcher)
+				return h.finishDrain(session, claim)
+			}
+
+			d.dispatch(saramaMsg)
+		}
+	}
+}
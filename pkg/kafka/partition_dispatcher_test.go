@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+func TestConsumeClaimConcurrentPreservesKeyOrder(t *testing.T) {
+	const concurrency = 4
+
+	// 选择与slowKey分配到不同worker的key
+	slowKey := "article_1:like"
+	fastKey := ""
+	for i := 2; i < 100; i++ {
+		key := fmt.Sprintf("article_%d:like", i)
+		if workerIndex(&sarama.ConsumerMessage{Key: []byte(key)}, concurrency) !=
+			workerIndex(&sarama.ConsumerMessage{Key: []byte(slowKey)}, concurrency) {
+			fastKey = key
+			break
+		}
+	}
+	if fastKey == "" {
+		t.Fatal("Failed to find a key on a different worker")
+	}
+
+	release := make(chan struct{})
+	fastDone := make(chan struct{})
+
+	var mu sync.Mutex
+	order := make(map[string][]string)
+
+	consumer := &RealConsumer{
+		concurrency: concurrency,
+		gate:        newDrainGate(),
+		logger:      zap.NewNop(),
+	}
+	consumer.handler = func(ctx context.Context, msg *Message) error {
+		// 慢key的第一条消息阻塞，直到其他key的消息处理完成
+		if msg.Key == slowKey && string(msg.Value) == "0" {
+			select {
+			case <-release:
+			case <-time.After(time.Second):
+				return fmt.Errorf("other keys were not processed concurrently")
+			}
+		}
+
+		mu.Lock()
+		order[msg.Key] = append(order[msg.Key], string(msg.Value))
+		mu.Unlock()
+
+		if msg.Key == fastKey {
+			fastDone <- struct{}{}
+		}
+		return nil
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 10)}
+
+	// offset 0-2 为慢key，offset 3 为快key
+	for i := 0; i < 3; i++ {
+		claim.messages <- &sarama.ConsumerMessage{Offset: int64(i), Key: []byte(slowKey), Value: []byte(fmt.Sprint(i))}
+	}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 3, Key: []byte(fastKey), Value: []byte("0")}
+	close(claim.messages)
+
+	done := make(chan error, 1)
+	go func() { done <- handler.ConsumeClaim(session, claim) }()
+
+	// 不同key的消息在慢消息阻塞期间完成
+	select {
+	case <-fastDone:
+	case <-time.After(time.Second):
+		t.Fatal("Expected message with a different key to be processed concurrently")
+	}
+
+	// 之前的消息未完成时不标记后面的offset
+	time.Sleep(20 * time.Millisecond)
+	session.mu.Lock()
+	marked := len(session.marked)
+	session.mu.Unlock()
+	if marked != 0 {
+		t.Errorf("Expected no offsets marked while offset 0 is in flight, got %d", marked)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	// 同一key内按offset顺序处理
+	if got := order[slowKey]; !reflect.DeepEqual(got, []string{"0", "1", "2"}) {
+		t.Errorf("Expected ordered processing for same key, got %v", got)
+	}
+
+	// 全部完成后按offset顺序标记
+	if !reflect.DeepEqual(session.marked, []int64{0, 1, 2, 3}) {
+		t.Errorf("Expected offsets marked in order, got %v", session.marked)
+	}
+	if stats := consumer.GetStats(); stats.MessagesProcessed != 4 {
+		t.Errorf("Expected 4 processed messages, got %d", stats.MessagesProcessed)
+	}
+}
+
+func TestConsumeClaimConcurrentBatchCommit(t *testing.T) {
+	handler := newBatchCommitConsumer(3, 0)
+	handler.consumer.concurrency = 4
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 10)}
+	for i := 0; i < 10; i++ {
+		claim.messages <- &sarama.ConsumerMessage{Offset: int64(i), Key: []byte(fmt.Sprintf("key_%d", i%5))}
+	}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	// 退出时所有offset都已提交
+	if len(session.committed) != 10 {
+		t.Errorf("Expected all 10 offsets committed on exit, got %d", len(session.committed))
+	}
+	for i, offset := range session.marked {
+		if offset != int64(i) {
+			t.Fatalf("Expected offsets marked in order, got %v", session.marked)
+		}
+	}
+}
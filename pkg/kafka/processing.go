@@ -0,0 +1,190 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProcessingMode 事件处理语义
+//
+// at_least_once（默认）：每条投递的消息都会执行一次更新。Kafka在消费者重启、
+// 分区再均衡或offset提交失败时会重复投递，此时同一事件会被重复计数。
+//
+// effectively_once：投递仍为至少一次，但通过事件ID去重让更新具备幂等性：
+//  1. 处理前按事件ID预占（Reserve），已处理的事件直接跳过，处理中的事件返回ErrEventInFlight稍后重试；
+//  2. 更新成功后确认（Commit）去重记录，失败则释放（Release）以便重投时重试；
+//  3. 去重记录确认后才同步提交offset，保证"更新 -> 去重记录 -> offset"的顺序。
+//
+// 去重窗口由DedupeTTL/DedupeCapacity决定，超出窗口的重复投递仍会被计数；
+// 内存去重记录不跨进程共享，多实例（含热备）部署时应配置共享去重器（RedisDeduper），
+// 分区在实例间交接时，新的所有者会跳过原所有者已处理但未提交offset的事件。
+type ProcessingMode string
+
+const (
+	ProcessingModeAtLeastOnce     ProcessingMode = "at_least_once"
+	ProcessingModeEffectivelyOnce ProcessingMode = "effectively_once"
+)
+
+// ParseProcessingMode 解析处理语义，空字符串视为at_least_once
+func ParseProcessingMode(s string) (ProcessingMode, error) {
+	switch ProcessingMode(s) {
+	case "", ProcessingModeAtLeastOnce:
+		return ProcessingModeAtLeastOnce, nil
+	case ProcessingModeEffectivelyOnce:
+		return ProcessingModeEffectivelyOnce, nil
+	default:
+		return "", fmt.Errorf("unknown processing mode: %s", s)
+	}
+}
+
+// ReserveResult 预占事件ID的结果
+type ReserveResult int
+
+const (
+	// ReserveAcquired 预占成功，由调用方处理事件
+	ReserveAcquired ReserveResult = iota
+	// ReserveDuplicate 事件已处理，可以直接跳过
+	ReserveDuplicate
+	// ReserveInFlight 事件正由其它消费者处理（或其持有者已崩溃、预占尚未过期），
+	// 结果未知，不能跳过也不能提交offset，应稍后重试
+	ReserveInFlight
+)
+
+// ErrEventInFlight 事件正由其它消费者处理，消息未处理，稍后重试
+var ErrEventInFlight = errors.New("kafka: event is in flight on another consumer")
+
+// Deduper 事件去重器
+type Deduper interface {
+	// Reserve 预占事件ID，返回预占成功、已处理或正在处理
+	Reserve(eventID string) ReserveResult
+	// Commit 确认事件已处理
+	Commit(eventID string)
+	// Release 释放预占，允许事件被再次处理
+	Release(eventID string)
+}
+
+// EventProcessingConfig 事件处理配置
+type EventProcessingConfig struct {
+	Mode           ProcessingMode
+	DedupeTTL      time.Duration // 去重记录保留时间
+	DedupeCapacity int           // 去重记录最大数量
+
+	// Deduper 可选的共享去重器，为空时使用内存去重器
+	Deduper Deduper
+}
+
+// DefaultEventProcessingConfig 默认事件处理配置
+func DefaultEventProcessingConfig() *EventProcessingConfig {
+	return &EventProcessingConfig{
+		Mode:           ProcessingModeAtLeastOnce,
+		DedupeTTL:      10 * time.Minute,
+		DedupeCapacity: 100000,
+	}
+}
+
+// dedupeEntry 去重记录
+type dedupeEntry struct {
+	seq       uint64
+	committed bool
+	expireAt  time.Time
+}
+
+// dedupeOrder 淘汰队列中的记录
+type dedupeOrder struct {
+	eventID string
+	seq     uint64
+}
+
+// MemoryDeduper 基于内存的事件去重器
+type MemoryDeduper struct {
+	ttl      time.Duration
+	capacity int
+	entries  map[string]*dedupeEntry
+	order    []dedupeOrder // 按写入顺序记录事件ID，用于淘汰
+	seq      uint64
+	mu       sync.Mutex
+}
+
+// NewMemoryDeduper 创建内存去重器
+func NewMemoryDeduper(ttl time.Duration, capacity int) *MemoryDeduper {
+	defaults := DefaultEventProcessingConfig()
+	if ttl <= 0 {
+		ttl = defaults.DedupeTTL
+	}
+	if capacity <= 0 {
+		capacity = defaults.DedupeCapacity
+	}
+
+	return &MemoryDeduper{
+		ttl:      ttl,
+		capacity: capacity,
+		entries:  make(map[string]*dedupeEntry),
+	}
+}
+
+// Reserve 预占事件ID
+func (d *MemoryDeduper) Reserve(eventID string) ReserveResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if entry, exists := d.entries[eventID]; exists && now.Before(entry.expireAt) {
+		if entry.committed {
+			return ReserveDuplicate
+		}
+		return ReserveInFlight
+	}
+
+	d.evict(now)
+	d.seq++
+	d.entries[eventID] = &dedupeEntry{seq: d.seq, expireAt: now.Add(d.ttl)}
+	d.order = append(d.order, dedupeOrder{eventID: eventID, seq: d.seq})
+	return ReserveAcquired
+}
+
+// Commit 确认事件已处理
+func (d *MemoryDeduper) Commit(eventID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if entry, exists := d.entries[eventID]; exists {
+		entry.committed = true
+		entry.expireAt = time.Now().Add(d.ttl)
+	}
+}
+
+// Release 释放预占
+func (d *MemoryDeduper) Release(eventID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if entry, exists := d.entries[eventID]; exists && !entry.committed {
+		delete(d.entries, eventID)
+	}
+}
+
+// Len 当前去重记录数量
+func (d *MemoryDeduper) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.entries)
+}
+
+// evict 淘汰过期记录，并在超出容量时淘汰最早的记录
+func (d *MemoryDeduper) evict(now time.Time) {
+	i := 0
+	for ; i < len(d.order); i++ {
+		item := d.order[i]
+		entry, exists := d.entries[item.eventID]
+		if !exists || entry.seq != item.seq {
+			continue // 已被释放或重新预占的旧记录
+		}
+		if now.Before(entry.expireAt) && len(d.entries) < d.capacity {
+			break
+		}
+		delete(d.entries, item.eventID)
+	}
+	d.order = d.order[i:]
+}
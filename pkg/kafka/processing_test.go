@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func newCounterEventMessage(t *testing.T, eventID string, delta int64) *Message {
+	event := &CounterEvent{
+		EventID:     eventID,
+		ResourceID:  "article_1",
+		CounterType: "like",
+		Delta:       delta,
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	return &Message{
+		Topic: "counter-events",
+		Key:   event.ResourceID,
+		Value: value,
+		Headers: map[string]string{
+			"event_type": "counter_update",
+			"event_id":   eventID,
+		},
+	}
+}
+
+// deliverWithRedelivery 投递10个事件，并模拟其中一半被重复投递
+func deliverWithRedelivery(t *testing.T, mode ProcessingMode) int64 {
+	var total int64
+	config := DefaultEventProcessingConfig()
+	config.Mode = mode
+	handler := NewCounterEventHandlerWithConfig(func(ctx context.Context, event *CounterEvent) error {
+		total += event.Delta
+		return nil
+	}, config, zap.NewNop())
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := handler.HandleMessage(ctx, newCounterEventMessage(t, fmt.Sprintf("evt-%d", i), 1)); err != nil {
+			t.Fatalf("HandleMessage failed: %v", err)
+		}
+	}
+	for i := 0; i < 10; i += 2 {
+		if err := handler.HandleMessage(ctx, newCounterEventMessage(t, fmt.Sprintf("evt-%d", i), 1)); err != nil {
+			t.Fatalf("HandleMessage failed: %v", err)
+		}
+	}
+	return total
+}
+
+func TestProcessingModeUnderRedelivery(t *testing.T) {
+	if got := deliverWithRedelivery(t, ProcessingModeAtLeastOnce); got != 15 {
+		t.Errorf("at_least_once: expected redelivered events to be double counted (15), got %d", got)
+	}
+	if got := deliverWithRedelivery(t, ProcessingModeEffectivelyOnce); got != 10 {
+		t.Errorf("effectively_once: expected each event counted once (10), got %d", got)
+	}
+}
+
+func TestEffectivelyOnceRetriesFailedEvent(t *testing.T) {
+	var total int64
+	fail := true
+	config := DefaultEventProcessingConfig()
+	config.Mode = ProcessingModeEffectivelyOnce
+	handler := NewCounterEventHandlerWithConfig(func(ctx context.Context, event *CounterEvent) error {
+		if fail {
+			return errors.New("storage unavailable")
+		}
+		total += event.Delta
+		return nil
+	}, config, zap.NewNop())
+
+	msg := newCounterEventMessage(t, "evt-retry", 3)
+	if err := handler.HandleMessage(context.Background(), msg); err == nil {
+		t.Fatal("Expected first delivery to fail")
+	}
+
+	// 失败的事件不应被记为已处理，重投时需要再次执行
+	fail = false
+	if err := handler.HandleMessage(context.Background(), msg); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if err := handler.HandleMessage(context.Background(), msg); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Expected total 3, got %d", total)
+	}
+}
+
+func TestEffectivelyOnceDoesNotSkipInFlightEvent(t *testing.T) {
+	var total int64
+	deduper := NewMemoryDeduper(0, 0)
+	config := DefaultEventProcessingConfig()
+	config.Mode = ProcessingModeEffectivelyOnce
+	config.Deduper = deduper
+	handler := NewCounterEventHandlerWithConfig(func(ctx context.Context, event *CounterEvent) error {
+		total += event.Delta
+		return nil
+	}, config, zap.NewNop())
+
+	// 另一个消费者已预占但尚未确认，结果未知，不能当作重复事件跳过
+	if deduper.Reserve("evt-inflight") != ReserveAcquired {
+		t.Fatal("Expected first reserve to succeed")
+	}
+	msg := newCounterEventMessage(t, "evt-inflight", 2)
+	if err := handler.HandleMessage(context.Background(), msg); !errors.Is(err, ErrEventInFlight) {
+		t.Fatalf("Expected ErrEventInFlight, got %v", err)
+	}
+
+	// 持有者崩溃后释放预占，重投时正常处理
+	deduper.Release("evt-inflight")
+	if err := handler.HandleMessage(context.Background(), msg); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if err := handler.HandleMessage(context.Background(), msg); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("Expected total 2, got %d", total)
+	}
+}
+
+func TestMemoryDeduperCapacity(t *testing.T) {
+	d := NewMemoryDeduper(0, 3)
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("evt-%d", i)
+		if d.Reserve(id) != ReserveAcquired {
+			t.Fatalf("Expected %s to be reserved", id)
+		}
+		d.Commit(id)
+	}
+	if d.Len() > 3 {
+		t.Errorf("Expected at most 3 entries, got %d", d.Len())
+	}
+	if d.Reserve("evt-9") != ReserveDuplicate {
+		t.Error("Expected most recent event to still be deduplicated")
+	}
+}
+
+func TestParseProcessingMode(t *testing.T) {
+	if mode, err := ParseProcessingMode(""); err != nil || mode != ProcessingModeAtLeastOnce {
+		t.Errorf("Expected empty mode to default to at_least_once, got %s, %v", mode, err)
+	}
+	if _, err := ParseProcessingMode("exactly_once"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
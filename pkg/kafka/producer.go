@@ -36,6 +36,8 @@ type CounterEvent struct {
 type Producer interface {
 	SendMessage(ctx context.Context, msg *Message) error
 	SendCounterEvent(ctx context.Context, event *CounterEvent) error
+	// SendCounterEvents 批量发送计数事件，尽量在一次请求中完成
+	SendCounterEvents(ctx context.Context, events []*CounterEvent) error
 	Close() error
 	GetStats() ProducerStats
 }
@@ -80,6 +82,8 @@ func (p *MockProducer) SendMessage(ctx context.Context, msg *Message) error {
 
 // SendCounterEvent 发送计数事件
 func (p *MockProducer) SendCounterEvent(ctx context.Context, event *CounterEvent) error {
+	ensureEventID(event)
+
 	// 序列化事件
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
@@ -113,6 +117,16 @@ func (p *MockProducer) SendCounterEvent(ctx context.Context, event *CounterEvent
 	return nil
 }
 
+// SendCounterEvents 批量发送计数事件
+func (p *MockProducer) SendCounterEvents(ctx context.Context, events []*CounterEvent) error {
+	for _, event := range events {
+		if err := p.SendCounterEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close 关闭生产者
 func (p *MockProducer) Close() error {
 	p.logger.Info("Mock producer closed",
@@ -173,6 +187,9 @@ type ProducerConfig struct {
 	CompressionType  string   `yaml:"compression_type"`
 	Retries          int      `yaml:"retries"`
 	EnableIdempotent bool     `yaml:"enable_idempotent"`
+
+	// Security TLS/SASL认证配置，连接托管Kafka时使用
+	Security SecurityConfig `yaml:"security"`
 }
 
 // DefaultProducerConfig 默认配置
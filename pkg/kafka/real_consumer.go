@@ -2,6 +2,9 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,12 +14,27 @@ import (
 
 // RealConsumer 真实的Kafka消费者（Consumer Group）
 type RealConsumer struct {
+	client        sarama.Client // 消费组和延迟查询共用的客户端
 	consumerGroup sarama.ConsumerGroup
 	topics        []string
 	groupID       string
+	syncCommit    bool          // 每条消息处理后同步提交offset
+	manualCommit  bool          // 只在消息处理成功（或进入死信主题）后提交，失败时暂停分区
+	commitBatch   int           // 批量提交：累计N条消息提交一次
+	commitEvery   time.Duration // 批量提交：距上次提交超过T提交一次
+	concurrency   int           // 每个分区的并发处理数，不大于1时顺序处理
+	maxAttempts   int           // 每条消息最多处理次数，耗尽后发送到死信主题
+	retryBackoff  time.Duration // 重试前等待时间，按已尝试次数线性增长
+	dlqTopic      string        // 死信主题，为空时处理失败的消息直接跳过
+	dlqProducer   Producer      // 发送死信消息的生产者
+	lagSource     lagSource     // 查询分区最新offset和已提交offset
+	lagRecorder   LagRecorder   // 消费延迟指标，为空时不上报
+	lagInterval   time.Duration // 消费延迟上报间隔
 	handler       MessageHandler
+	gate          *drainGate // 优雅排空控制
 	logger        *zap.Logger
 	stats         ConsumerStats
+	assignment    map[string][]int32 // 当前会话分配到的分区，为空时处于standby
 	mu            sync.RWMutex
 	running       bool
 }
@@ -29,8 +47,46 @@ type ConsumerConfig struct {
 	AutoOffsetReset   string   `yaml:"auto_offset_reset"` // earliest, latest
 	SessionTimeout    int      `yaml:"session_timeout_ms"`
 	HeartbeatInterval int      `yaml:"heartbeat_interval_ms"`
+
+	// ProcessingMode 处理语义，effectively_once时关闭自动提交，处理完成后同步提交offset
+	ProcessingMode ProcessingMode `yaml:"processing_mode"`
+
+	// CommitBatchSize/CommitInterval 批量提交offset：每N条消息或每T时间提交一次（先到为准），
+	// 任一大于0时关闭自动提交；崩溃时最多重复处理一个批次。effectively_once时不生效
+	CommitBatchSize int           `yaml:"commit_batch_size"`
+	CommitInterval  time.Duration `yaml:"commit_interval"`
+
+	// PartitionConcurrency 每个分区的并发处理数，按消息key分发以保证同一key内有序，
+	// offset只在之前的消息全部处理完成后标记。不大于1时顺序处理
+	PartitionConcurrency int `yaml:"partition_concurrency"`
+
+	// DeadLetterTopic 死信主题，处理失败的消息重试耗尽后携带错误信息发送到该主题再标记offset。
+	// 为空时不发送死信，处理失败的消息记录错误后跳过
+	DeadLetterTopic string `yaml:"dead_letter_topic"`
+
+	// MaxProcessAttempts 每条消息最多处理次数（含首次），不大于0时只处理一次
+	MaxProcessAttempts int `yaml:"max_process_attempts"`
+
+	// Security TLS/SASL认证配置，连接托管Kafka时使用
+	Security SecurityConfig `yaml:"security"`
+
+	// EnableAutoCommit 为false时关闭Sarama自动提交，只在处理器成功（或消息成功进入死信主题）后提交offset：
+	// 未配置CommitBatchSize/CommitInterval时逐条同步提交，否则批量提交。
+	// 死信主题发送失败时按退避重试，两种模式都不会标记未能进入死信主题的消息。
+	// 未配置死信主题时消息处理失败该分区停止消费（同一会话的其它分区继续消费），
+	// 不提交这条消息及之后的offset，再均衡或重启后从这条消息重新投递
+	EnableAutoCommit bool `yaml:"enable_auto_commit"`
+
+	// LagReportInterval 查询最新offset和已提交offset上报消费延迟的间隔，不大于0时使用默认值
+	LagReportInterval time.Duration `yaml:"lag_report_interval"`
 }
 
+// defaultRetryBackoff 消息处理失败后重试前的基础等待时间
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// maxRetryDelay 等待处理中事件、重试发送死信消息的最大间隔
+const maxRetryDelay = 5 * time.Second
+
 // DefaultConsumerConfig 默认消费者配置
 func DefaultConsumerConfig() *ConsumerConfig {
 	return &ConsumerConfig{
@@ -40,6 +96,12 @@ func DefaultConsumerConfig() *ConsumerConfig {
 		AutoOffsetReset:   "latest",
 		SessionTimeout:    10000, // 10s
 		HeartbeatInterval: 3000,  // 3s
+		ProcessingMode:    ProcessingModeAtLeastOnce,
+
+		DeadLetterTopic:    DLQTopic("counter-events"),
+		MaxProcessAttempts: 3,
+		LagReportInterval:  defaultLagReportInterval,
+		EnableAutoCommit:   true,
 	}
 }
 
@@ -63,19 +125,73 @@ func NewRealConsumer(config *ConsumerConfig, logger *zap.Logger) (*RealConsumer,
 		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
 	}
 
+	// effectively_once：关闭自动提交，由处理器在去重记录确认后同步提交
+	// 批量提交：关闭自动提交，按消息数或时间间隔提交
+	// 手动提交：关闭自动提交，处理成功后同步或批量提交
+	manualCommit := !config.EnableAutoCommit
+	syncCommit := config.ProcessingMode == ProcessingModeEffectivelyOnce
+	batchCommit := !syncCommit && (config.CommitBatchSize > 0 || config.CommitInterval > 0)
+	if manualCommit && !batchCommit {
+		syncCommit = true
+	}
+	if syncCommit || batchCommit {
+		saramaConfig.Consumer.Offsets.AutoCommit.Enable = false
+	}
+
 	// 版本配置
 	saramaConfig.Version = sarama.V2_6_0_0
 
+	// TLS/SASL认证
+	if err := applySecurity(saramaConfig, config.Security); err != nil {
+		return nil, fmt.Errorf("invalid consumer security config: %w", err)
+	}
+
+	// 创建Client，消费组和消费延迟查询共用
+	client, err := sarama.NewClient(config.Brokers, saramaConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建Consumer Group
-	consumerGroup, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, saramaConfig)
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(config.GroupID, client)
 	if err != nil {
+		client.Close()
 		return nil, err
 	}
 
+	var commitBatch int
+	var commitEvery time.Duration
+	if batchCommit {
+		commitBatch = config.CommitBatchSize
+		commitEvery = config.CommitInterval
+	}
+
+	maxAttempts := config.MaxProcessAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	lagInterval := config.LagReportInterval
+	if lagInterval <= 0 {
+		lagInterval = defaultLagReportInterval
+	}
+
 	realConsumer := &RealConsumer{
+		client:        client,
 		consumerGroup: consumerGroup,
 		topics:        config.Topics,
 		groupID:       config.GroupID,
+		syncCommit:    syncCommit,
+		manualCommit:  manualCommit,
+		commitBatch:   commitBatch,
+		commitEvery:   commitEvery,
+		concurrency:   config.PartitionConcurrency,
+		maxAttempts:   maxAttempts,
+		retryBackoff:  defaultRetryBackoff,
+		dlqTopic:      config.DeadLetterTopic,
+		lagSource:     &saramaLagSource{client: client},
+		lagInterval:   lagInterval,
+		gate:          newDrainGate(),
 		logger:        logger,
 		stats:         ConsumerStats{},
 	}
@@ -83,11 +199,34 @@ func NewRealConsumer(config *ConsumerConfig, logger *zap.Logger) (*RealConsumer,
 	logger.Info("Real Kafka consumer created",
 		zap.Strings("brokers", config.Brokers),
 		zap.String("group_id", config.GroupID),
-		zap.Strings("topics", config.Topics))
+		zap.Strings("topics", config.Topics),
+		zap.Bool("sync_commit", syncCommit),
+		zap.Bool("manual_commit", manualCommit),
+		zap.Int("commit_batch_size", config.CommitBatchSize),
+		zap.Duration("commit_interval", config.CommitInterval),
+		zap.Int("partition_concurrency", config.PartitionConcurrency),
+		zap.String("dead_letter_topic", config.DeadLetterTopic),
+		zap.Int("max_process_attempts", maxAttempts),
+		zap.Bool("tls", config.Security.TLS.Enabled),
+		zap.String("sasl_mechanism", config.Security.SASL.Mechanism))
 
 	return realConsumer, nil
 }
 
+// SetDeadLetterProducer 设置发送死信消息的生产者，未设置时处理失败的消息只记录错误
+func (c *RealConsumer) SetDeadLetterProducer(producer Producer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dlqProducer = producer
+}
+
+// SetLagRecorder 设置消费延迟指标记录器，需在ConsumeMessages之前调用
+func (c *RealConsumer) SetLagRecorder(recorder LagRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lagRecorder = recorder
+}
+
 // Subscribe 订阅主题
 func (c *RealConsumer) Subscribe(topics []string) error {
 	c.topics = topics
@@ -115,6 +254,21 @@ func (c *RealConsumer) ConsumeMessages(ctx context.Context, handler MessageHandl
 	// 启动错误处理goroutine
 	go c.handleErrors(ctx)
 
+	// 定期上报消费延迟
+	c.mu.RLock()
+	recorder := c.lagRecorder
+	c.mu.RUnlock()
+	if recorder != nil && c.lagSource != nil {
+		reporter := &lagReporter{
+			source:   c.lagSource,
+			recorder: recorder,
+			group:    c.groupID,
+			interval: c.lagInterval,
+			logger:   c.logger,
+		}
+		go reporter.run(ctx, c.topics, c.gate.stopped())
+	}
+
 	// 开始消费
 	for {
 		select {
@@ -123,6 +277,12 @@ func (c *RealConsumer) ConsumeMessages(ctx context.Context, handler MessageHandl
 			c.running = false
 			c.mu.Unlock()
 			return ctx.Err()
+		case <-c.gate.stopped():
+			// 排空完成后不再加入新的会话
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			return nil
 		default:
 			// 消费消息，这是阻塞调用
 			if err := c.consumerGroup.Consume(ctx, c.topics, consumerHandler); err != nil {
@@ -158,6 +318,19 @@ func (c *RealConsumer) handleErrors(ctx context.Context) {
 	}
 }
 
+// Drain 停止拉取新消息，等待在途消息处理完成并提交offset
+func (c *RealConsumer) Drain(ctx context.Context) error {
+	c.logger.Info("Draining real Kafka consumer")
+
+	if err := c.gate.drain(ctx); err != nil {
+		c.logger.Warn("Kafka consumer drain incomplete", zap.Error(err))
+		return err
+	}
+
+	c.logger.Info("Kafka consumer drained")
+	return nil
+}
+
 // Close 关闭消费者
 func (c *RealConsumer) Close() error {
 	c.mu.Lock()
@@ -165,7 +338,14 @@ func (c *RealConsumer) Close() error {
 	c.mu.Unlock()
 
 	c.logger.Info("Closing real Kafka consumer")
-	return c.consumerGroup.Close()
+	err := c.consumerGroup.Close()
+	// 消费组由Client创建，不负责关闭Client
+	if c.client != nil {
+		if closeErr := c.client.Close(); err == nil && closeErr != sarama.ErrClosedClient {
+			err = closeErr
+		}
+	}
+	return err
 }
 
 // GetStats 获取统计信息
@@ -182,77 +362,368 @@ func (c *RealConsumer) IsRunning() bool {
 	return c.running
 }
 
+// Assignment 当前会话分配到的分区
+func (c *RealConsumer) Assignment() map[string][]int32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	assignment := make(map[string][]int32, len(c.assignment))
+	for topic, partitions := range c.assignment {
+		assignment[topic] = append([]int32(nil), partitions...)
+	}
+	return assignment
+}
+
+// setAssignment 记录再均衡后的分区分配
+func (c *RealConsumer) setAssignment(assignment map[string][]int32) {
+	c.mu.Lock()
+	c.assignment = assignment
+	c.mu.Unlock()
+}
+
 // consumerGroupHandler Sarama Consumer Group处理器
 type consumerGroupHandler struct {
 	consumer *RealConsumer
 	logger   *zap.Logger
 }
 
-// Setup 消费者组启动时调用
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
-	h.logger.Info("Consumer group session setup")
+// Setup 消费者组启动时调用，每次再均衡后记录新的分区分配
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	claims := session.Claims()
+	partitions := 0
+	for _, p := range claims {
+		partitions += len(p)
+	}
+	h.consumer.setAssignment(claims)
+
+	role := ConsumerRoleActive
+	if partitions == 0 {
+		role = ConsumerRoleStandby
+	}
+	h.logger.Info("Consumer group session setup",
+		zap.String("member_id", session.MemberID()),
+		zap.Int32("generation", session.GenerationID()),
+		zap.String("role", string(role)),
+		zap.Any("assignment", claims))
 	return nil
 }
 
-// Cleanup 消费者组关闭时调用
-func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
-	h.logger.Info("Consumer group session cleanup")
+// Cleanup 消费者组关闭时调用，会话结束后不再持有分区
+func (h *consumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	h.consumer.setAssignment(nil)
+	h.logger.Info("Consumer group session cleanup",
+		zap.String("member_id", session.MemberID()),
+		zap.Int32("generation", session.GenerationID()))
 	return nil
 }
 
 // ConsumeClaim 消费消息
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	gate := h.consumer.gate
+	batcher := newCommitBatcher(h.consumer.commitBatch, h.consumer.commitEvery)
+	defer batcher.stop()
+
+	if h.consumer.concurrency > 1 {
+		return h.consumeClaimConcurrent(session, claim, batcher)
+	}
+
 	for {
 		select {
 		case <-session.Context().Done():
+			batcher.flush(session)
 			return nil
+		case <-gate.stopped():
+			return h.finishDrain(session, claim)
+		case <-batcher.tick():
+			if batcher.due() {
+				batcher.flush(session)
+			}
 		case saramaMsg := <-claim.Messages():
 			if saramaMsg == nil {
+				batcher.flush(session)
 				return nil
 			}
 
-			// 转换为内部Message格式
-			msg := &Message{
-				Topic:     saramaMsg.Topic,
-				Key:       string(saramaMsg.Key),
-				Value:     saramaMsg.Value,
-				Headers:   make(map[string]string),
-				Timestamp: saramaMsg.Timestamp,
+			// 排空中不再处理新消息，未标记的消息由下一个消费者重新投递
+			if !gate.enter() {
+				return h.finishDrain(session, claim)
 			}
 
-			// 转换Headers
-			for _, header := range saramaMsg.Headers {
-				msg.Headers[string(header.Key)] = string(header.Value)
+			handled := h.handleMessage(session.Context(), saramaMsg)
+			if !handled {
+				gate.leave()
+				return h.pauseClaim(session, claim, saramaMsg, batcher)
 			}
+			h.markMessage(session, saramaMsg, batcher)
+			gate.leave()
+		}
+	}
+}
+
+// handleMessage 转换并处理单条消息，失败时按配置重试，重试耗尽后发送到死信主题
+// 返回是否可以标记offset：处理成功、已发送到死信主题，或自动提交模式下未配置死信主题时跳过；
+// 手动提交模式下未配置死信主题、或会话在消息妥善处理前结束时返回false
+func (h *consumerGroupHandler) handleMessage(ctx context.Context, saramaMsg *sarama.ConsumerMessage) bool {
+	// 转换为内部Message格式
+	msg := &Message{
+		Topic:     saramaMsg.Topic,
+		Key:       string(saramaMsg.Key),
+		Value:     saramaMsg.Value,
+		Headers:   make(map[string]string),
+		Timestamp: saramaMsg.Timestamp,
+	}
+
+	// 转换Headers
+	for _, header := range saramaMsg.Headers {
+		msg.Headers[string(header.Key)] = string(header.Value)
+	}
 
-			h.logger.Debug("Processing message",
+	h.logger.Debug("Processing message",
+		zap.String("topic", msg.Topic),
+		zap.String("key", msg.Key),
+		zap.Int32("partition", saramaMsg.Partition),
+		zap.Int64("offset", saramaMsg.Offset))
+
+	// 调用消息处理器
+	attempts, err := h.processWithRetry(ctx, msg)
+	if err != nil {
+		h.logger.Error("Failed to process message",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.String("key", msg.Key),
+			zap.Int("attempts", attempts))
+
+		h.consumer.mu.Lock()
+		h.consumer.stats.ErrorsCount++
+		h.consumer.mu.Unlock()
+
+		// 发送到死信主题后由调用方标记offset，会话已结束时不标记，由下一个消费者重新投递
+		if ctx.Err() != nil {
+			return false
+		}
+		h.consumer.mu.RLock()
+		producer := h.consumer.dlqProducer
+		h.consumer.mu.RUnlock()
+		if h.consumer.dlqTopic == "" || producer == nil {
+			// 未配置死信主题：自动提交模式跳过这条消息，手动提交模式暂停分区
+			return !h.consumer.manualCommit
+		}
+		return h.deadLetter(ctx, producer, saramaMsg, msg, err, attempts)
+	}
+
+	h.consumer.mu.Lock()
+	h.consumer.stats.MessagesProcessed++
+	h.consumer.stats.LastMessageTime = time.Now().Unix()
+	h.consumer.mu.Unlock()
+	return true
+}
+
+// processWithRetry 处理消息，失败时最多重试到maxAttempts次，返回实际尝试次数和最后一次的错误
+// 事件正由其它消费者处理（ErrEventInFlight）时不计入尝试次数，一直等到预占被确认或过期，
+// 不会把结果未知的事件发送到死信主题
+func (h *consumerGroupHandler) processWithRetry(ctx context.Context, msg *Message) (int, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = h.consumer.handler(ctx, msg); err == nil {
+			return attempt, nil
+		}
+		if errors.Is(err, ErrEventInFlight) {
+			attempt--
+			h.logger.Debug("Counter event in flight on another consumer, waiting",
+				zap.Error(err),
 				zap.String("topic", msg.Topic),
-				zap.String("key", msg.Key),
-				zap.Int32("partition", saramaMsg.Partition),
-				zap.Int64("offset", saramaMsg.Offset))
-
-			// 调用消息处理器
-			if err := h.consumer.handler(session.Context(), msg); err != nil {
-				h.logger.Error("Failed to process message",
-					zap.Error(err),
-					zap.String("topic", msg.Topic),
-					zap.String("key", msg.Key))
-
-				h.consumer.mu.Lock()
-				h.consumer.stats.ErrorsCount++
-				h.consumer.mu.Unlock()
-
-				// 根据策略决定是否跳过这条消息
-				// 这里我们选择跳过并继续处理下一条
-			} else {
-				h.consumer.mu.Lock()
-				h.consumer.stats.MessagesProcessed++
-				h.consumer.stats.LastMessageTime = time.Now().Unix()
-				h.consumer.mu.Unlock()
+				zap.String("key", msg.Key))
+			select {
+			case <-ctx.Done():
+				return attempt, err
+			case <-time.After(h.inFlightBackoff()):
 			}
+			continue
+		}
+		if attempt >= h.consumer.maxAttempts {
+			return attempt, err
+		}
+
+		h.logger.Warn("Failed to process message, retrying",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.String("key", msg.Key),
+			zap.Int("attempt", attempt))
 
-			// 标记消息已处理（提交offset）
-			session.MarkMessage(saramaMsg, "")
+		select {
+		case <-ctx.Done():
+			// 会话结束时不再重试
+			return attempt, err
+		case <-time.After(h.retryDelay(attempt)):
 		}
 	}
 }
+
+// retryDelay 第attempt次重试前的等待时间，按次数线性增长，不超过maxRetryDelay
+func (h *consumerGroupHandler) retryDelay(attempt int) time.Duration {
+	base := h.consumer.retryBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	if delay := base * time.Duration(attempt); delay < maxRetryDelay {
+		return delay
+	}
+	return maxRetryDelay
+}
+
+// inFlightBackoff 等待其它消费者处理同一事件的轮询间隔
+func (h *consumerGroupHandler) inFlightBackoff() time.Duration {
+	return h.retryDelay(1)
+}
+
+// deadLetter 将处理失败的消息连同错误信息发送到死信主题，返回是否发送成功
+// 发送失败时按退避重试直到成功或会话结束，失败的消息不会被标记offset而丢失
+func (h *consumerGroupHandler) deadLetter(ctx context.Context, producer Producer, saramaMsg *sarama.ConsumerMessage, msg *Message, cause error, attempts int) bool {
+	dlqMsg := NewDeadLetterMessage(msg, cause, attempts)
+	dlqMsg.Topic = h.consumer.dlqTopic
+	dlqMsg.Headers[HeaderDLQPartition] = strconv.FormatInt(int64(saramaMsg.Partition), 10)
+	dlqMsg.Headers[HeaderDLQOffset] = strconv.FormatInt(saramaMsg.Offset, 10)
+
+	for publishAttempt := 1; ; publishAttempt++ {
+		err := producer.SendMessage(ctx, dlqMsg)
+		if err == nil {
+			break
+		}
+		h.logger.Error("Failed to publish message to dead-letter topic, retrying",
+			zap.Error(err),
+			zap.String("dlq_topic", h.consumer.dlqTopic),
+			zap.String("topic", msg.Topic),
+			zap.String("key", msg.Key),
+			zap.Int32("partition", saramaMsg.Partition),
+			zap.Int64("offset", saramaMsg.Offset),
+			zap.Int("publish_attempt", publishAttempt))
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(h.retryDelay(publishAttempt)):
+		}
+	}
+
+	h.logger.Warn("Message sent to dead-letter topic",
+		zap.String("dlq_topic", h.consumer.dlqTopic),
+		zap.String("topic", msg.Topic),
+		zap.String("key", msg.Key),
+		zap.Int32("partition", saramaMsg.Partition),
+		zap.Int64("offset", saramaMsg.Offset),
+		zap.Int("attempts", attempts))
+
+	h.consumer.mu.Lock()
+	h.consumer.stats.DeadLetteredCount++
+	h.consumer.mu.Unlock()
+	return true
+}
+
+// markMessage 标记消息已处理（提交offset）
+func (h *consumerGroupHandler) markMessage(session sarama.ConsumerGroupSession, saramaMsg *sarama.ConsumerMessage, batcher *commitBatcher) {
+	session.MarkMessage(saramaMsg, "")
+	if h.consumer.syncCommit {
+		session.Commit()
+	} else if batcher.mark() {
+		batcher.flush(session)
+	}
+}
+
+// pauseClaim 手动提交模式下消息未能处理时停止消费该分区，阻塞到会话结束（再均衡）或开始排空
+// 之前已标记的offset照常提交，这条消息及之后的消息在再均衡或重启后重新投递。
+// 不能直接返回：Sarama在任一ConsumeClaim返回时结束整个会话，其它分区也会停止消费；
+// 阻塞期间该分区未读取的消息超过Consumer.MaxProcessingTime后，Sarama暂停拉取该分区，不影响其它分区
+func (h *consumerGroupHandler) pauseClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, saramaMsg *sarama.ConsumerMessage, batcher *commitBatcher) error {
+	batcher.flush(session)
+	h.logger.Error("Message could not be handled, pausing partition until rebalance",
+		zap.String("topic", saramaMsg.Topic),
+		zap.Int32("partition", saramaMsg.Partition),
+		zap.Int64("offset", saramaMsg.Offset))
+
+	select {
+	case <-session.Context().Done():
+		return nil
+	case <-h.consumer.gate.stopped():
+		return h.finishDrain(session, claim)
+	}
+}
+
+// finishDrain 等待所有分区的在途消息处理完成后提交offset并退出
+// 在其它分区处理完成前退出会结束会话并取消它们的处理ctx
+func (h *consumerGroupHandler) finishDrain(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	h.consumer.gate.wait()
+	session.Commit()
+
+	h.logger.Info("Consumer claim drained",
+		zap.String("topic", claim.Topic()),
+		zap.Int32("partition", claim.Partition()))
+	return nil
+}
+
+// commitBatcher 按消息数或时间间隔批量提交offset
+type commitBatcher struct {
+	size       int
+	interval   time.Duration
+	pending    int
+	lastCommit time.Time
+	ticker     *time.Ticker
+}
+
+// newCommitBatcher 创建批量提交器，size和interval均为0时不做批量提交
+func newCommitBatcher(size int, interval time.Duration) *commitBatcher {
+	b := &commitBatcher{size: size, interval: interval, lastCommit: time.Now()}
+	if interval > 0 {
+		b.ticker = time.NewTicker(interval)
+	}
+	return b
+}
+
+// enabled 是否启用批量提交
+func (b *commitBatcher) enabled() bool {
+	return b.size > 0 || b.interval > 0
+}
+
+// tick 定时检查通道，未配置时间间隔时返回nil（永不触发）
+func (b *commitBatcher) tick() <-chan time.Time {
+	if b.ticker == nil {
+		return nil
+	}
+	return b.ticker.C
+}
+
+// mark 记录一条已标记的消息，返回是否需要立即提交
+func (b *commitBatcher) mark() bool {
+	if !b.enabled() {
+		return false
+	}
+	b.pending++
+	return b.due()
+}
+
+// due 是否达到提交条件
+func (b *commitBatcher) due() bool {
+	if b.pending == 0 {
+		return false
+	}
+	if b.size > 0 && b.pending >= b.size {
+		return true
+	}
+	return b.interval > 0 && time.Since(b.lastCommit) >= b.interval
+}
+
+// flush 提交已标记但未提交的offset
+func (b *commitBatcher) flush(session sarama.ConsumerGroupSession) {
+	if b.pending == 0 {
+		return
+	}
+	session.Commit()
+	b.pending = 0
+	b.lastCommit = time.Now()
+}
+
+// stop 停止定时器
+func (b *commitBatcher) stop() {
+	if b.ticker != nil {
+		b.ticker.Stop()
+	}
+}
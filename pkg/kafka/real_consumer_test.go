@@ -0,0 +1,589 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+func newBatchCommitConsumer(size int, interval time.Duration) *consumerGroupHandler {
+	consumer := &RealConsumer{
+		commitBatch: size,
+		commitEvery: interval,
+		gate:        newDrainGate(),
+		logger:      zap.NewNop(),
+		handler:     func(ctx context.Context, msg *Message) error { return nil },
+	}
+	return &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+}
+
+func TestConsumeClaimCommitsEveryNMessages(t *testing.T) {
+	handler := newBatchCommitConsumer(3, 0)
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 10)}
+
+	for i := 0; i < 10; i++ {
+		claim.messages <- &sarama.ConsumerMessage{Offset: int64(i)}
+	}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	// 第3、6、9条时各提交一次，退出时提交剩余的1条
+	if got := session.commitCount(); got != 4 {
+		t.Errorf("Expected 4 commits for 10 messages with batch size 3, got %d", got)
+	}
+	if len(session.committed) != 10 {
+		t.Errorf("Expected all 10 offsets committed on exit, got %d", len(session.committed))
+	}
+}
+
+func TestConsumeClaimCommitsOnInterval(t *testing.T) {
+	handler := newBatchCommitConsumer(100, 30*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 10)}
+
+	done := make(chan error, 1)
+	go func() { done <- handler.ConsumeClaim(session, claim) }()
+
+	claim.messages <- &sarama.ConsumerMessage{Offset: 0}
+	claim.messages <- &sarama.ConsumerMessage{Offset: 1}
+
+	// 未达到批量条数，但超过时间间隔后由定时器提交
+	time.Sleep(10 * time.Millisecond)
+	if got := session.commitCount(); got != 0 {
+		t.Errorf("Expected no commit before interval elapsed, got %d", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for session.commitCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := session.commitCount(); got != 1 {
+		t.Fatalf("Expected 1 commit after interval, got %d", got)
+	}
+
+	// 没有新消息时不重复提交
+	time.Sleep(80 * time.Millisecond)
+	if got := session.commitCount(); got != 1 {
+		t.Errorf("Expected no commit without new messages, got %d", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+}
+
+func TestConsumeClaimWithoutBatchingDoesNotCommit(t *testing.T) {
+	// 未配置批量提交时依赖自动提交，处理器只标记不提交
+	handler := newBatchCommitConsumer(0, 0)
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 5)}
+	for i := 0; i < 5; i++ {
+		claim.messages <- &sarama.ConsumerMessage{Offset: int64(i)}
+	}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+	if got := session.commitCount(); got != 0 {
+		t.Errorf("Expected no explicit commits, got %d", got)
+	}
+	if len(session.marked) != 5 {
+		t.Errorf("Expected 5 marked messages, got %d", len(session.marked))
+	}
+}
+
+func TestConsumeClaimRetriesThenDeadLetters(t *testing.T) {
+	dlq := NewMockProducer(zap.NewNop())
+	attempts := make(map[string]int)
+	consumer := &RealConsumer{
+		maxAttempts:  3,
+		retryBackoff: time.Millisecond,
+		dlqTopic:     "counter-events.dlq",
+		dlqProducer:  dlq,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			attempts[msg.Key]++
+			// poison消息始终失败，flaky消息第二次成功
+			if msg.Key == "poison" || (msg.Key == "flaky" && attempts[msg.Key] < 2) {
+				return errors.New("handler failed")
+			}
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Partition: 2, Offset: 10, Key: []byte("ok")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Partition: 2, Offset: 11, Key: []byte("poison"), Value: []byte("payload"),
+		Headers: []*sarama.RecordHeader{{Key: []byte("event_id"), Value: []byte("evt-1")}}}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Partition: 2, Offset: 12, Key: []byte("flaky")}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	if attempts["poison"] != 3 || attempts["flaky"] != 2 || attempts["ok"] != 1 {
+		t.Errorf("Unexpected attempts: %v", attempts)
+	}
+
+	// 只有重试耗尽的消息进入死信主题，并携带错误信息和原始位置
+	messages := dlq.GetMessages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 dead-lettered message, got %d", len(messages))
+	}
+	dead := messages[0]
+	if dead.Topic != "counter-events.dlq" || dead.Key != "poison" || string(dead.Value) != "payload" {
+		t.Errorf("Unexpected dead-letter message: %+v", dead)
+	}
+	wantHeaders := map[string]string{
+		HeaderDLQOriginalTopic: "counter-events",
+		HeaderDLQError:         "handler failed",
+		HeaderDLQAttempts:      "3",
+		HeaderDLQPartition:     "2",
+		HeaderDLQOffset:        "11",
+		"event_id":             "evt-1",
+	}
+	for k, v := range wantHeaders {
+		if dead.Headers[k] != v {
+			t.Errorf("Expected header %s=%q, got %q", k, v, dead.Headers[k])
+		}
+	}
+
+	// 死信消息同样标记offset，不阻塞后续消息
+	if len(session.marked) != 3 {
+		t.Errorf("Expected all 3 offsets marked, got %v", session.marked)
+	}
+	stats := consumer.GetStats()
+	if stats.DeadLetteredCount != 1 || stats.ErrorsCount != 1 || stats.MessagesProcessed != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestConsumeClaimWithoutDeadLetterTopicSkipsFailure(t *testing.T) {
+	dlq := NewMockProducer(zap.NewNop())
+	consumer := &RealConsumer{
+		dlqProducer: dlq,
+		gate:        newDrainGate(),
+		logger:      zap.NewNop(),
+		handler:     func(ctx context.Context, msg *Message) error { return errors.New("handler failed") },
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 0}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+	if len(dlq.GetMessages()) != 0 {
+		t.Error("Expected no dead-letter message without a dead-letter topic")
+	}
+	if stats := consumer.GetStats(); stats.DeadLetteredCount != 0 || stats.ErrorsCount != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestManualCommitPausesPartitionOnUnhandledMessage(t *testing.T) {
+	processed := make(map[string]int)
+	consumer := &RealConsumer{
+		syncCommit:   true,
+		manualCommit: true,
+		maxAttempts:  2,
+		retryBackoff: time.Millisecond,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			processed[msg.Key]++
+			if msg.Key == "poison" {
+				return errors.New("downstream write failed")
+			}
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 10, Key: []byte("ok")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 11, Key: []byte("poison")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 12, Key: []byte("after")}
+	close(claim.messages)
+
+	// 分区暂停后ConsumeClaim阻塞到会话结束，提前返回会结束整个会话
+	done := make(chan error, 1)
+	go func() { done <- handler.ConsumeClaim(session, claim) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Expected paused claim to block until session ends, returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	// 失败的消息及之后的消息都不提交，分区停止消费
+	if processed["after"] != 0 || processed["poison"] != 2 {
+		t.Errorf("Unexpected processing: %v", processed)
+	}
+	if len(session.marked) != 1 || session.marked[0] != 10 {
+		t.Errorf("Expected only offset 10 marked, got %v", session.marked)
+	}
+	if len(session.committed) != 1 || session.committed[0] != 10 {
+		t.Errorf("Expected only offset 10 committed, got %v", session.committed)
+	}
+}
+
+func TestManualCommitContinuesAfterDeadLetter(t *testing.T) {
+	dlq := NewMockProducer(zap.NewNop())
+	consumer := &RealConsumer{
+		syncCommit:   true,
+		manualCommit: true,
+		maxAttempts:  1,
+		dlqTopic:     "counter-events.dlq",
+		dlqProducer:  dlq,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			if msg.Key == "poison" {
+				return errors.New("downstream write failed")
+			}
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 0, Key: []byte("poison")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 1, Key: []byte("ok")}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+	// 进入死信主题视为已妥善处理，照常提交
+	if len(dlq.GetMessages()) != 1 || len(session.committed) != 2 {
+		t.Errorf("Expected dead-lettered message to be committed, dlq=%d committed=%v", len(dlq.GetMessages()), session.committed)
+	}
+}
+
+func TestManualCommitConcurrentStopsMarkingAtUnhandledMessage(t *testing.T) {
+	consumer := &RealConsumer{
+		syncCommit:   true,
+		manualCommit: true,
+		maxAttempts:  1,
+		concurrency:  4,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			if msg.Key == "poison" {
+				return errors.New("downstream write failed")
+			}
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 6)}
+	for offset, key := range []string{"a", "b", "poison", "c", "d", "e"} {
+		claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: int64(offset), Key: []byte(key)}
+	}
+	close(claim.messages)
+
+	done := make(chan error, 1)
+	go func() { done <- handler.ConsumeClaim(session, claim) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Expected paused claim to block until session ends, returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	// 后续消息即使已处理完成也不标记，避免越过未处理的消息提交
+	for _, offset := range session.marked {
+		if offset >= 2 {
+			t.Errorf("Expected no offsets at or after the unhandled message to be marked, got %v", session.marked)
+			break
+		}
+	}
+}
+
+func TestConsumeClaimWaitsForInFlightEvent(t *testing.T) {
+	dlq := NewMockProducer(zap.NewNop())
+	calls := 0
+	consumer := &RealConsumer{
+		maxAttempts:  1,
+		retryBackoff: time.Millisecond,
+		dlqTopic:     "counter-events.dlq",
+		dlqProducer:  dlq,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			// 前两次事件仍被其它消费者预占
+			calls++
+			if calls <= 2 {
+				return ErrEventInFlight
+			}
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 0, Key: []byte("article_1")}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+	// 处理中的事件不计入尝试次数，也不进入死信主题，等预占释放后处理再标记
+	if calls != 3 {
+		t.Errorf("Expected 3 handler calls, got %d", calls)
+	}
+	if len(dlq.GetMessages()) != 0 {
+		t.Errorf("Expected in-flight event not dead-lettered, got %d", len(dlq.GetMessages()))
+	}
+	if len(session.marked) != 1 {
+		t.Errorf("Expected offset marked once processed, got %v", session.marked)
+	}
+	if stats := consumer.GetStats(); stats.ErrorsCount != 0 || stats.MessagesProcessed != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+// runGroupSession 按Sarama消费组会话的方式并发消费各分区：任一ConsumeClaim返回即结束整个会话
+func runGroupSession(handler *consumerGroupHandler, session *fakeSession, cancel context.CancelFunc, claims ...*fakeClaim) chan error {
+	done := make(chan error, len(claims))
+	for _, claim := range claims {
+		go func(claim *fakeClaim) {
+			defer cancel()
+			done <- handler.ConsumeClaim(session, claim)
+		}(claim)
+	}
+	return done
+}
+
+func TestManualCommitPausedPartitionDoesNotStopOtherPartitions(t *testing.T) {
+	var mu sync.Mutex
+	processed := make(map[int32]int)
+	consumer := &RealConsumer{
+		syncCommit:   true,
+		manualCommit: true,
+		maxAttempts:  1,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			if msg.Key == "poison" {
+				return errors.New("downstream write failed")
+			}
+			mu.Lock()
+			processed[int32(msg.Value[0])]++
+			mu.Unlock()
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session := &fakeSession{ctx: ctx}
+
+	paused := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2), partition: 0}
+	healthy := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 10), partition: 1}
+	paused.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Partition: 0, Offset: 0, Key: []byte("poison"), Value: []byte{0}}
+	done := runGroupSession(handler, session, cancel, paused, healthy)
+
+	// 分区0暂停后，分区1在同一会话中继续消费新消息
+	for i := 0; i < 5; i++ {
+		healthy.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Partition: 1, Offset: int64(100 + i), Key: []byte("ok"), Value: []byte{1}}
+		time.Sleep(5 * time.Millisecond)
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := processed[1]
+		mu.Unlock()
+		if n == 5 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	if processed[1] != 5 {
+		t.Errorf("Expected partition 1 to keep consuming while partition 0 is paused, got %d", processed[1])
+	}
+	mu.Unlock()
+	if ctx.Err() != nil {
+		t.Error("Expected paused partition not to end the group session")
+	}
+
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("ConsumeClaim failed: %v", err)
+		}
+	}
+}
+
+// flakyProducer 前failures次发送失败的生产者
+type flakyProducer struct {
+	*MockProducer
+	mu       sync.Mutex
+	failures int
+	sends    int
+}
+
+func (p *flakyProducer) SendMessage(ctx context.Context, msg *Message) error {
+	p.mu.Lock()
+	p.sends++
+	fail := p.sends <= p.failures
+	p.mu.Unlock()
+	if fail {
+		return errors.New("dlq broker unavailable")
+	}
+	return p.MockProducer.SendMessage(ctx, msg)
+}
+
+func TestAutoCommitRetriesDeadLetterPublish(t *testing.T) {
+	dlq := &flakyProducer{MockProducer: NewMockProducer(zap.NewNop()), failures: 2}
+	consumer := &RealConsumer{
+		maxAttempts:  1,
+		retryBackoff: time.Millisecond,
+		dlqTopic:     "counter-events.dlq",
+		dlqProducer:  dlq,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			if msg.Key == "poison" {
+				return errors.New("handler failed")
+			}
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 0, Key: []byte("poison")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 1, Key: []byte("ok")}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+	// 死信发送失败后重试，成功进入死信主题后才标记
+	if dlq.sends != 3 || len(dlq.GetMessages()) != 1 {
+		t.Errorf("Expected dead-letter publish retried until success, sends=%d published=%d", dlq.sends, len(dlq.GetMessages()))
+	}
+	if len(session.marked) != 2 {
+		t.Errorf("Expected both offsets marked, got %v", session.marked)
+	}
+}
+
+func TestAutoCommitDoesNotMarkWhenDeadLetterPublishFails(t *testing.T) {
+	dlq := &flakyProducer{MockProducer: NewMockProducer(zap.NewNop()), failures: 1 << 30}
+	consumer := &RealConsumer{
+		maxAttempts:  1,
+		retryBackoff: time.Millisecond,
+		dlqTopic:     "counter-events.dlq",
+		dlqProducer:  dlq,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler:      func(ctx context.Context, msg *Message) error { return errors.New("handler failed") },
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 0, Key: []byte("poison")}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "counter-events", Offset: 1, Key: []byte("after")}
+
+	done := make(chan error, 1)
+	go func() { done <- handler.ConsumeClaim(session, claim) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ConsumeClaim failed: %v", err)
+	}
+
+	// 死信主题一直不可用时，失败的消息及之后的消息都不标记，由下一个消费者重新投递
+	if len(session.marked) != 0 {
+		t.Errorf("Expected no offsets marked while dead-letter publish fails, got %v", session.marked)
+	}
+	if dlq.sends < 2 {
+		t.Errorf("Expected dead-letter publish to be retried, got %d sends", dlq.sends)
+	}
+}
+
+func TestRetryDelayIsCapped(t *testing.T) {
+	handler := &consumerGroupHandler{consumer: &RealConsumer{retryBackoff: 2 * time.Second}, logger: zap.NewNop()}
+	if got := handler.retryDelay(2); got != 4*time.Second {
+		t.Errorf("Expected linear backoff 4s on attempt 2, got %v", got)
+	}
+	// 线性增长不超过maxRetryDelay
+	for _, attempt := range []int{3, 10, 1000} {
+		if got := handler.retryDelay(attempt); got != maxRetryDelay {
+			t.Errorf("Expected attempt %d to be capped at %v, got %v", attempt, maxRetryDelay, got)
+		}
+	}
+
+	// 未配置退避时使用默认值
+	handler = &consumerGroupHandler{consumer: &RealConsumer{}, logger: zap.NewNop()}
+	if got := handler.retryDelay(1); got != defaultRetryBackoff {
+		t.Errorf("Expected default backoff %v, got %v", defaultRetryBackoff, got)
+	}
+}
+
+func TestProcessWithRetryUsesCappedDelay(t *testing.T) {
+	// 退避远大于上限时，重试间隔也不超过maxRetryDelay
+	calls := 0
+	consumer := &RealConsumer{
+		maxAttempts:  2,
+		retryBackoff: time.Hour,
+		gate:         newDrainGate(),
+		logger:       zap.NewNop(),
+		handler: func(ctx context.Context, msg *Message) error {
+			calls++
+			if calls == 1 {
+				return errors.New("handler failed")
+			}
+			return nil
+		},
+	}
+	handler := &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := handler.processWithRetry(context.Background(), &Message{Topic: "counter-events", Key: "flaky"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected retry to succeed, got %v", err)
+		}
+	case <-time.After(maxRetryDelay + 2*time.Second):
+		t.Fatal("Expected retry delay to be capped at maxRetryDelay")
+	}
+}
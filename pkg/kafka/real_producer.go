@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -60,6 +61,11 @@ func NewRealProducer(config *ProducerConfig, logger *zap.Logger) (*RealProducer,
 	// 版本配置
 	saramaConfig.Version = sarama.V2_6_0_0
 
+	// TLS/SASL认证
+	if err := applySecurity(saramaConfig, config.Security); err != nil {
+		return nil, fmt.Errorf("invalid producer security config: %w", err)
+	}
+
 	realProd := &RealProducer{
 		config:  config,
 		logger:  logger,
@@ -89,14 +95,25 @@ func NewRealProducer(config *ProducerConfig, logger *zap.Logger) (*RealProducer,
 	logger.Info("Real Kafka producer created",
 		zap.Strings("brokers", config.Brokers),
 		zap.Bool("async", config.EnableAsync),
-		zap.String("compression", config.CompressionType))
+		zap.String("compression", config.CompressionType),
+		zap.Bool("tls", config.Security.TLS.Enabled),
+		zap.String("sasl_mechanism", config.Security.SASL.Mechanism))
 
 	return realProd, nil
 }
 
 // SendMessage 发送消息
 func (p *RealProducer) SendMessage(ctx context.Context, msg *Message) error {
-	// 创建Sarama消息
+	saramaMsg := toSaramaMessage(msg)
+	if p.isAsync {
+		return p.sendAsync(ctx, saramaMsg)
+	} else {
+		return p.sendSync(ctx, saramaMsg)
+	}
+}
+
+// toSaramaMessage 转换为Sarama消息
+func toSaramaMessage(msg *Message) *sarama.ProducerMessage {
 	saramaMsg := &sarama.ProducerMessage{
 		Topic:     msg.Topic,
 		Key:       sarama.StringEncoder(msg.Key),
@@ -112,12 +129,7 @@ func (p *RealProducer) SendMessage(ctx context.Context, msg *Message) error {
 			Value: []byte(v),
 		})
 	}
-
-	if p.isAsync {
-		return p.sendAsync(ctx, saramaMsg)
-	} else {
-		return p.sendSync(ctx, saramaMsg)
-	}
+	return saramaMsg
 }
 
 // sendSync 同步发送
@@ -179,17 +191,17 @@ func (p *RealProducer) handleAsyncResponses() {
 	}
 }
 
-// SendCounterEvent 发送计数事件
-func (p *RealProducer) SendCounterEvent(ctx context.Context, event *CounterEvent) error {
+// counterEventMessage 将计数事件序列化为Kafka消息，按资源和类型分区
+func (p *RealProducer) counterEventMessage(event *CounterEvent) (*Message, error) {
+	ensureEventID(event)
+
 	// 序列化事件
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
-		p.stats.ErrorsCount++
-		return fmt.Errorf("failed to marshal counter event: %w", err)
+		return nil, fmt.Errorf("failed to marshal counter event: %w", err)
 	}
 
-	// 构造Kafka消息
-	msg := &Message{
+	return &Message{
 		Topic: p.config.Topic,
 		Key:   fmt.Sprintf("%s:%s", event.ResourceID, event.CounterType),
 		Value: eventJSON,
@@ -200,6 +212,15 @@ func (p *RealProducer) SendCounterEvent(ctx context.Context, event *CounterEvent
 			"content_type": "application/json",
 		},
 		Timestamp: event.Timestamp,
+	}, nil
+}
+
+// SendCounterEvent 发送计数事件
+func (p *RealProducer) SendCounterEvent(ctx context.Context, event *CounterEvent) error {
+	msg, err := p.counterEventMessage(event)
+	if err != nil {
+		p.stats.ErrorsCount++
+		return err
 	}
 
 	// 发送消息
@@ -218,6 +239,64 @@ func (p *RealProducer) SendCounterEvent(ctx context.Context, event *CounterEvent
 	return nil
 }
 
+// SendCounterEvents 批量发送计数事件
+// 同步模式下使用SendMessages在一次请求中发送整批消息；异步模式下依次放入发送队列，由Sarama按Flush配置合并发送
+func (p *RealProducer) SendCounterEvents(ctx context.Context, events []*CounterEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	msgs := make([]*sarama.ProducerMessage, 0, len(events))
+	for _, event := range events {
+		msg, err := p.counterEventMessage(event)
+		if err != nil {
+			p.stats.ErrorsCount++
+			return err
+		}
+		msgs = append(msgs, toSaramaMessage(msg))
+	}
+
+	if p.isAsync {
+		for i, msg := range msgs {
+			if err := p.sendAsync(ctx, msg); err != nil {
+				return fmt.Errorf("queued %d of %d counter events: %w", i, len(msgs), err)
+			}
+		}
+		p.stats.EventsSent += int64(len(msgs))
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if err := p.producer.SendMessages(msgs); err != nil {
+		failed := len(msgs)
+		var producerErrs sarama.ProducerErrors
+		if errors.As(err, &producerErrs) {
+			failed = len(producerErrs)
+		}
+		sent := len(msgs) - failed
+		p.stats.MessagesSent += int64(sent)
+		p.stats.EventsSent += int64(sent)
+		p.stats.ErrorsCount += int64(failed)
+		p.logger.Error("Failed to send counter event batch",
+			zap.Int("events", len(msgs)),
+			zap.Int("failed", failed),
+			zap.Error(err))
+		return fmt.Errorf("failed to send %d of %d counter events: %w", failed, len(msgs), err)
+	}
+
+	p.stats.MessagesSent += int64(len(msgs))
+	p.stats.EventsSent += int64(len(msgs))
+	p.stats.LastMessageTime = time.Now().Unix()
+
+	p.logger.Debug("Counter event batch sent to Kafka", zap.Int("events", len(msgs)))
+	return nil
+}
+
 // Close 关闭生产者
 func (p *RealProducer) Close() error {
 	p.logger.Info("Closing real Kafka producer")
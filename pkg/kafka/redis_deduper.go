@@ -0,0 +1,118 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// dedupeKeyPrefix 去重记录key前缀
+	dedupeKeyPrefix = "dedupe:counter-event:"
+	// dedupePending/dedupeCommitted 去重记录状态
+	dedupePending   = "pending"
+	dedupeCommitted = "committed"
+
+	// defaultDedupeReserveTTL 预占记录保留时间，持有者崩溃时到期后允许其它实例重试
+	defaultDedupeReserveTTL = 30 * time.Second
+	// defaultDedupeTimeout 单次Redis操作超时
+	defaultDedupeTimeout = time.Second
+)
+
+// releaseScript 仅删除仍处于预占状态的记录，避免误删已确认的记录
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisDeduper 基于Redis的共享事件去重器
+//
+// 消费组内所有实例共享去重记录，分区再均衡交接时，新的分区所有者会跳过
+// 原所有者已处理但offset尚未提交的事件。Redis不可用时放行事件（退化为至少一次），
+// 宁可重复计数也不丢弃事件。
+type RedisDeduper struct {
+	client     redis.Cmdable
+	ttl        time.Duration
+	reserveTTL time.Duration
+	timeout    time.Duration
+	logger     *zap.Logger
+}
+
+// NewRedisDeduper 创建Redis去重器，ttl为已处理记录的保留时间
+func NewRedisDeduper(client redis.Cmdable, ttl time.Duration, logger *zap.Logger) *RedisDeduper {
+	if ttl <= 0 {
+		ttl = DefaultEventProcessingConfig().DedupeTTL
+	}
+	reserveTTL := defaultDedupeReserveTTL
+	if reserveTTL > ttl {
+		reserveTTL = ttl
+	}
+
+	return &RedisDeduper{
+		client:     client,
+		ttl:        ttl,
+		reserveTTL: reserveTTL,
+		timeout:    defaultDedupeTimeout,
+		logger:     logger,
+	}
+}
+
+// Reserve 预占事件ID
+// 记录为pending时返回ReserveInFlight：持有者可能仍在处理，也可能已崩溃，要等预占过期后才能重新处理
+func (d *RedisDeduper) Reserve(eventID string) ReserveResult {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	key := dedupeKeyPrefix + eventID
+	reserved, err := d.client.SetNX(ctx, key, dedupePending, d.reserveTTL).Result()
+	if err != nil {
+		d.logger.Warn("Failed to reserve event in Redis, processing without dedupe",
+			zap.String("event_id", eventID),
+			zap.Error(err))
+		return ReserveAcquired
+	}
+	if reserved {
+		return ReserveAcquired
+	}
+
+	state, err := d.client.Get(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		d.logger.Warn("Failed to read event dedupe record, processing without dedupe",
+			zap.String("event_id", eventID),
+			zap.Error(err))
+		return ReserveAcquired
+	}
+	if state == dedupeCommitted {
+		return ReserveDuplicate
+	}
+	// pending，或记录恰好在两次操作之间过期，稍后重试时重新预占
+	return ReserveInFlight
+}
+
+// Commit 确认事件已处理
+func (d *RedisDeduper) Commit(eventID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	if err := d.client.Set(ctx, dedupeKeyPrefix+eventID, dedupeCommitted, d.ttl).Err(); err != nil {
+		d.logger.Warn("Failed to commit event dedupe record",
+			zap.String("event_id", eventID),
+			zap.Error(err))
+	}
+}
+
+// Release 释放预占
+func (d *RedisDeduper) Release(eventID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	if err := releaseScript.Run(ctx, d.client, []string{dedupeKeyPrefix + eventID}, dedupePending).Err(); err != nil {
+		d.logger.Warn("Failed to release event dedupe record",
+			zap.String("event_id", eventID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func TestRedisDeduperReserveStates(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	d := NewRedisDeduper(client, time.Hour, zap.NewNop())
+
+	if got := d.Reserve("evt-1"); got != ReserveAcquired {
+		t.Fatalf("Expected first reserve acquired, got %v", got)
+	}
+	// 预占未确认时事件结果未知，返回处理中而不是重复
+	if got := d.Reserve("evt-1"); got != ReserveInFlight {
+		t.Errorf("Expected pending event in flight, got %v", got)
+	}
+
+	d.Commit("evt-1")
+	if got := d.Reserve("evt-1"); got != ReserveDuplicate {
+		t.Errorf("Expected committed event duplicate, got %v", got)
+	}
+
+	// 已确认的记录不会被释放
+	d.Release("evt-1")
+	if got := d.Reserve("evt-1"); got != ReserveDuplicate {
+		t.Errorf("Expected committed event to survive release, got %v", got)
+	}
+}
+
+func TestRedisDeduperCrashedOwnerExpires(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	d := NewRedisDeduper(client, time.Hour, zap.NewNop())
+
+	// 持有者预占后崩溃，既未确认也未释放
+	if got := d.Reserve("evt-crash"); got != ReserveAcquired {
+		t.Fatalf("Expected first reserve acquired, got %v", got)
+	}
+	if got := d.Reserve("evt-crash"); got != ReserveInFlight {
+		t.Errorf("Expected in flight before reservation expires, got %v", got)
+	}
+
+	// 预占过期后其它实例可以重新处理
+	mr.FastForward(defaultDedupeReserveTTL + time.Second)
+	if got := d.Reserve("evt-crash"); got != ReserveAcquired {
+		t.Errorf("Expected reservation of crashed owner to expire, got %v", got)
+	}
+}
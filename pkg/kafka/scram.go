@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/xdg-go/scram"
+)
+
+// scramClient 基于xdg-go/scram实现sarama.SCRAMClient（RFC 5802）
+type scramClient struct {
+	hashGen      scram.HashGeneratorFcn
+	nonceGen     scram.NonceGeneratorFcn // 为空时使用库默认的随机nonce，测试中可固定
+	conversation *scram.ClientConversation
+}
+
+// newSCRAMClient 使用指定的哈希算法创建SCRAM客户端
+func newSCRAMClient(hashGen scram.HashGeneratorFcn) *scramClient {
+	return &scramClient{hashGen: hashGen}
+}
+
+// Begin 开始一次认证
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGen.NewClient(userName, password, authzID)
+	if err != nil {
+		return fmt.Errorf("failed to create SCRAM client: %w", err)
+	}
+	if c.nonceGen != nil {
+		client = client.WithNonceGenerator(c.nonceGen)
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+// Step 处理服务端的challenge并返回下一条客户端消息
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+// Done 认证流程是否已结束
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
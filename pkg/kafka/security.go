@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"high-go-press/pkg/config"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// SASL认证机制
+const (
+	SASLMechanismPlain       = sarama.SASLTypePlaintext   // PLAIN
+	SASLMechanismSCRAMSHA256 = sarama.SASLTypeSCRAMSHA256 // SCRAM-SHA-256
+	SASLMechanismSCRAMSHA512 = sarama.SASLTypeSCRAMSHA512 // SCRAM-SHA-512
+)
+
+// ErrUnsupportedSASLMechanism 不支持的SASL认证机制
+var ErrUnsupportedSASLMechanism = errors.New("kafka: unsupported SASL mechanism")
+
+// ErrSASLCredentialsMissing 启用SASL但缺少用户名或密码
+var ErrSASLCredentialsMissing = errors.New("kafka: SASL username and password are required")
+
+// SecurityConfig 连接Kafka的安全配置，托管Kafka通常需要TLS加SASL认证
+type SecurityConfig struct {
+	TLS  TLSConfig  `yaml:"tls"`
+	SASL SASLConfig `yaml:"sasl"`
+}
+
+// TLSConfig TLS配置，CAFile为空时使用系统根证书，CertFile/KeyFile用于双向认证
+type TLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // 跳过服务端证书校验，仅用于测试环境
+}
+
+// SASLConfig SASL认证配置，Mechanism为空时不认证
+type SASLConfig struct {
+	Mechanism string `yaml:"mechanism"` // PLAIN、SCRAM-SHA-256、SCRAM-SHA-512
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
+// ValidateSASLMechanism 校验SASL认证机制，空字符串表示不认证
+func ValidateSASLMechanism(mechanism string) error {
+	switch mechanism {
+	case "", SASLMechanismPlain, SASLMechanismSCRAMSHA256, SASLMechanismSCRAMSHA512:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedSASLMechanism, mechanism)
+	}
+}
+
+// SecurityConfigFromAppConfig 将配置文件中的kafka.security转换为安全配置
+func SecurityConfigFromAppConfig(cfg config.KafkaSecurityConfig) SecurityConfig {
+	return SecurityConfig{
+		TLS: TLSConfig{
+			Enabled:            cfg.TLS.Enabled,
+			CAFile:             cfg.TLS.CAFile,
+			CertFile:           cfg.TLS.CertFile,
+			KeyFile:            cfg.TLS.KeyFile,
+			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		},
+		SASL: SASLConfig{
+			Mechanism: cfg.SASL.Mechanism,
+			Username:  cfg.SASL.Username,
+			Password:  cfg.SASL.Password,
+		},
+	}
+}
+
+// applySecurity 将安全配置应用到sarama配置
+func applySecurity(saramaConfig *sarama.Config, security SecurityConfig) error {
+	if security.TLS.Enabled {
+		tlsConfig, err := buildTLSConfig(security.TLS)
+		if err != nil {
+			return err
+		}
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	sasl := security.SASL
+	if sasl.Mechanism == "" {
+		return nil
+	}
+	if err := ValidateSASLMechanism(sasl.Mechanism); err != nil {
+		return err
+	}
+	if sasl.Username == "" || sasl.Password == "" {
+		return ErrSASLCredentialsMissing
+	}
+
+	saramaConfig.Net.SASL.Enable = true
+	saramaConfig.Net.SASL.Handshake = true
+	saramaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(sasl.Mechanism)
+	saramaConfig.Net.SASL.User = sasl.Username
+	saramaConfig.Net.SASL.Password = sasl.Password
+
+	switch sasl.Mechanism {
+	case SASLMechanismSCRAMSHA256:
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return newSCRAMClient(scram.SHA256)
+		}
+	case SASLMechanismSCRAMSHA512:
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return newSCRAMClient(scram.SHA512)
+		}
+	}
+	return nil
+}
+
+// buildTLSConfig 加载CA和客户端证书
+func buildTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in kafka CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("kafka TLS cert_file and key_file must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"high-go-press/pkg/config"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+func TestApplySecuritySASL(t *testing.T) {
+	tests := []struct {
+		name    string
+		sasl    SASLConfig
+		wantErr error
+		scram   bool
+	}{
+		{"disabled", SASLConfig{}, nil, false},
+		{"plain", SASLConfig{Mechanism: SASLMechanismPlain, Username: "u", Password: "p"}, nil, false},
+		{"scram-sha-256", SASLConfig{Mechanism: SASLMechanismSCRAMSHA256, Username: "u", Password: "p"}, nil, true},
+		{"scram-sha-512", SASLConfig{Mechanism: SASLMechanismSCRAMSHA512, Username: "u", Password: "p"}, nil, true},
+		{"missing password", SASLConfig{Mechanism: SASLMechanismPlain, Username: "u"}, ErrSASLCredentialsMissing, false},
+		{"unsupported", SASLConfig{Mechanism: "GSSAPI", Username: "u", Password: "p"}, ErrUnsupportedSASLMechanism, false},
+	}
+
+	for _, tt := range tests {
+		saramaConfig := sarama.NewConfig()
+		err := applySecurity(saramaConfig, SecurityConfig{SASL: tt.sasl})
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if saramaConfig.Net.SASL.Enable != (tt.sasl.Mechanism != "") {
+			t.Errorf("%s: unexpected SASL enable %v", tt.name, saramaConfig.Net.SASL.Enable)
+		}
+		if (saramaConfig.Net.SASL.SCRAMClientGeneratorFunc != nil) != tt.scram {
+			t.Errorf("%s: expected SCRAM generator %v", tt.name, tt.scram)
+		}
+		if tt.sasl.Mechanism != "" && string(saramaConfig.Net.SASL.Mechanism) != tt.sasl.Mechanism {
+			t.Errorf("%s: expected mechanism %s, got %s", tt.name, tt.sasl.Mechanism, saramaConfig.Net.SASL.Mechanism)
+		}
+	}
+}
+
+func TestApplySecurityTLS(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+	if err := applySecurity(saramaConfig, SecurityConfig{TLS: TLSConfig{Enabled: true, InsecureSkipVerify: true}}); err != nil {
+		t.Fatalf("applySecurity failed: %v", err)
+	}
+	if !saramaConfig.Net.TLS.Enable || !saramaConfig.Net.TLS.Config.InsecureSkipVerify {
+		t.Errorf("Expected TLS enabled with InsecureSkipVerify, got %+v", saramaConfig.Net.TLS)
+	}
+
+	// CA文件不存在时报错，而不是静默退回系统根证书
+	err := applySecurity(sarama.NewConfig(), SecurityConfig{TLS: TLSConfig{Enabled: true, CAFile: "/nonexistent/ca.pem"}})
+	if err == nil {
+		t.Error("Expected error for missing CA file")
+	}
+
+	// 客户端证书和私钥必须同时配置
+	err = applySecurity(sarama.NewConfig(), SecurityConfig{TLS: TLSConfig{Enabled: true, CertFile: "client.pem"}})
+	if err == nil {
+		t.Error("Expected error for cert without key")
+	}
+}
+
+// fixedNonce 返回固定nonce的生成器
+func fixedNonce(nonce string) scram.NonceGeneratorFcn {
+	return func() string { return nonce }
+}
+
+// TestSCRAMClientRFC7677 使用RFC 7677中SCRAM-SHA-256的示例验证客户端
+func TestSCRAMClientRFC7677(t *testing.T) {
+	client := newSCRAMClient(scram.SHA256)
+	client.nonceGen = fixedNonce("rOprNGfwEbeRWgbNEkqO")
+	if err := client.Begin("user", "pencil", ""); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+
+	first, err := client.Step("")
+	if err != nil || first != "n,,n=user,r=rOprNGfwEbeRWgbNEkqO" {
+		t.Fatalf("Unexpected client-first %q, %v", first, err)
+	}
+
+	final, err := client.Step("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	want := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	if err != nil || final != want {
+		t.Fatalf("Unexpected client-final %q, %v", final, err)
+	}
+	if client.Done() {
+		t.Fatal("Expected client not done before server-final")
+	}
+
+	if _, err := client.Step("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err != nil {
+		t.Fatalf("Expected server signature to verify, got %v", err)
+	}
+	if !client.Done() {
+		t.Error("Expected client done after server-final")
+	}
+}
+
+func TestSCRAMClientRejectsBadServer(t *testing.T) {
+	client := newSCRAMClient(scram.SHA256)
+	client.nonceGen = fixedNonce("abc")
+	client.Begin("user", "pencil", "")
+	client.Step("")
+
+	// 服务端nonce必须以客户端nonce开头
+	if _, err := client.Step("r=xyz123,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err == nil {
+		t.Error("Expected error for mismatched nonce")
+	}
+
+	client.Begin("user", "pencil", "")
+	client.Step("")
+	if _, err := client.Step("r=abc123,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Step("v=AAAA"); err == nil {
+		t.Error("Expected server signature mismatch")
+	}
+}
+
+func TestSecurityConfigFromAppConfig(t *testing.T) {
+	security := SecurityConfigFromAppConfig(config.KafkaSecurityConfig{
+		TLS:  config.KafkaTLSConfig{Enabled: true, CAFile: "ca.pem"},
+		SASL: config.KafkaSASLConfig{Mechanism: SASLMechanismSCRAMSHA512, Username: "u", Password: "p"},
+	})
+	if !security.TLS.Enabled || security.TLS.CAFile != "ca.pem" {
+		t.Errorf("Unexpected TLS config: %+v", security.TLS)
+	}
+	if security.SASL.Mechanism != SASLMechanismSCRAMSHA512 || security.SASL.Username != "u" || security.SASL.Password != "p" {
+		t.Errorf("Unexpected SASL config: %+v", security.SASL)
+	}
+}
@@ -0,0 +1,58 @@
+package kafka
+
+// ConsumerRole 消费者实例在消费组中的角色
+//
+// 多个实例加入同一消费组即可热备：分区数少于实例数时，多出的实例不分配分区，
+// 处于standby状态；持有分区的实例故障后Kafka触发再均衡，由standby实例接管分区。
+// 角色由每个实例根据自己的分区分配独立得出，不依赖选主。
+type ConsumerRole string
+
+const (
+	ConsumerRoleActive  ConsumerRole = "active"  // 持有分区，正在消费
+	ConsumerRoleStandby ConsumerRole = "standby" // 已加入消费组但未分配分区，等待接管
+	ConsumerRoleStopped ConsumerRole = "stopped" // 未在消费
+)
+
+// AssignmentReporter 能报告当前分区分配的消费者
+type AssignmentReporter interface {
+	// Assignment 当前会话分配到的分区，topic -> 分区列表
+	Assignment() map[string][]int32
+}
+
+// ConsumerStatus 消费者状态快照，用于健康检查
+type ConsumerStatus struct {
+	Role               ConsumerRole       `json:"role"`
+	AssignedPartitions int                `json:"assigned_partitions"`
+	Assignment         map[string][]int32 `json:"assignment,omitempty"`
+	Stats              ConsumerStats      `json:"stats"`
+}
+
+// GetConsumerStatus 获取消费者角色和分区分配，不支持报告分配的消费者运行中即视为active
+func GetConsumerStatus(consumer Consumer) ConsumerStatus {
+	status := ConsumerStatus{Role: ConsumerRoleStopped}
+	if consumer == nil {
+		return status
+	}
+	status.Stats = consumer.GetStats()
+
+	if runner, ok := consumer.(interface{ IsRunning() bool }); ok && !runner.IsRunning() {
+		return status
+	}
+
+	reporter, ok := consumer.(AssignmentReporter)
+	if !ok {
+		status.Role = ConsumerRoleActive
+		return status
+	}
+
+	status.Assignment = reporter.Assignment()
+	for _, partitions := range status.Assignment {
+		status.AssignedPartitions += len(partitions)
+	}
+	if status.AssignedPartitions > 0 {
+		status.Role = ConsumerRoleActive
+	} else {
+		status.Role = ConsumerRoleStandby
+	}
+	return status
+}
@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// claimsSession 带分区分配的测试会话
+type claimsSession struct {
+	*fakeSession
+	claims map[string][]int32
+}
+
+func (s *claimsSession) Claims() map[string][]int32 { return s.claims }
+
+// partitionMessages 构造指定分区的计数器事件消息
+func partitionMessages(t *testing.T, partition int32, n int) *fakeClaim {
+	t.Helper()
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, n)}
+	for i := 0; i < n; i++ {
+		event := &CounterEvent{EventID: fmt.Sprintf("p%d-%d", partition, i), ResourceID: "article_1", CounterType: "like", Delta: 1}
+		value, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("Failed to marshal event: %v", err)
+		}
+		claim.messages <- &sarama.ConsumerMessage{
+			Topic:     "counter-events",
+			Partition: partition,
+			Offset:    int64(i),
+			Value:     value,
+			Headers:   []*sarama.RecordHeader{{Key: []byte("event_type"), Value: []byte("counter_update")}},
+		}
+	}
+	close(claim.messages)
+	return claim
+}
+
+// runHandoff 模拟两个实例的分区交接：A持有分区0、1并处理完成后崩溃（offset未提交），
+// 再均衡后standby实例B接管全部分区，从头重新收到分区0、1的消息。返回每个事件被应用的次数
+func runHandoff(t *testing.T, newDeduper func() Deduper) map[string]int {
+	t.Helper()
+
+	var mu sync.Mutex
+	applied := make(map[string]int)
+	update := func(ctx context.Context, event *CounterEvent) error {
+		mu.Lock()
+		applied[event.EventID]++
+		mu.Unlock()
+		return nil
+	}
+
+	newReplica := func() (*RealConsumer, *consumerGroupHandler) {
+		config := &EventProcessingConfig{Mode: ProcessingModeEffectivelyOnce, Deduper: newDeduper()}
+		consumer := &RealConsumer{
+			syncCommit: true,
+			running:    true,
+			gate:       newDrainGate(),
+			logger:     zap.NewNop(),
+			handler:    NewCounterEventHandlerWithConfig(update, config, zap.NewNop()).HandleMessage,
+		}
+		return consumer, &consumerGroupHandler{consumer: consumer, logger: zap.NewNop()}
+	}
+	consumerA, handlerA := newReplica()
+	consumerB, handlerB := newReplica()
+
+	// 第一代：A持有分区0、1，B未分配分区处于standby
+	sessionA := &claimsSession{fakeSession: &fakeSession{ctx: context.Background()}, claims: map[string][]int32{"counter-events": {0, 1}}}
+	sessionB := &claimsSession{fakeSession: &fakeSession{ctx: context.Background()}, claims: map[string][]int32{}}
+	handlerA.Setup(sessionA)
+	handlerB.Setup(sessionB)
+
+	if status := GetConsumerStatus(consumerA); status.Role != ConsumerRoleActive || status.AssignedPartitions != 2 {
+		t.Fatalf("Expected replica A active with 2 partitions, got %+v", status)
+	}
+	if status := GetConsumerStatus(consumerB); status.Role != ConsumerRoleStandby {
+		t.Fatalf("Expected replica B standby, got %+v", status)
+	}
+
+	for _, p := range []int32{0, 1} {
+		if err := handlerA.ConsumeClaim(sessionA, partitionMessages(t, p, 5)); err != nil {
+			t.Fatalf("ConsumeClaim failed: %v", err)
+		}
+	}
+
+	// A崩溃，B在第二代接管所有分区
+	consumerA.running = false
+	sessionB.claims = map[string][]int32{"counter-events": {0, 1, 2, 3}}
+	handlerB.Setup(sessionB)
+	if status := GetConsumerStatus(consumerB); status.Role != ConsumerRoleActive || status.AssignedPartitions != 4 {
+		t.Fatalf("Expected replica B to take over 4 partitions, got %+v", status)
+	}
+	if status := GetConsumerStatus(consumerA); status.Role != ConsumerRoleStopped {
+		t.Fatalf("Expected crashed replica A stopped, got %+v", status)
+	}
+
+	for _, p := range []int32{0, 1, 2, 3} {
+		if err := handlerB.ConsumeClaim(sessionB, partitionMessages(t, p, 5)); err != nil {
+			t.Fatalf("ConsumeClaim failed: %v", err)
+		}
+	}
+
+	handlerB.Cleanup(sessionB)
+	if got := len(consumerB.Assignment()); got != 0 {
+		t.Errorf("Expected no assignment after cleanup, got %d topics", got)
+	}
+	return applied
+}
+
+func TestStandbyTakeoverProcessesEachEventOnce(t *testing.T) {
+	shared := NewMemoryDeduper(0, 0)
+	applied := runHandoff(t, func() Deduper { return shared })
+
+	if len(applied) != 20 {
+		t.Fatalf("Expected 20 distinct events across 4 partitions, got %d", len(applied))
+	}
+	for eventID, count := range applied {
+		if count != 1 {
+			t.Errorf("Expected event %s applied exactly once, got %d", eventID, count)
+		}
+	}
+}
+
+func TestStandbyTakeoverWithoutSharedDedupeDoubleCounts(t *testing.T) {
+	// 各实例独立的内存去重器无法覆盖交接，分区0、1的事件被重复计数
+	applied := runHandoff(t, func() Deduper { return NewMemoryDeduper(0, 0) })
+
+	if got := applied["p0-0"]; got != 2 {
+		t.Errorf("Expected redelivered event applied twice without shared dedupe, got %d", got)
+	}
+	if got := applied["p2-0"]; got != 1 {
+		t.Errorf("Expected event only seen by replica B applied once, got %d", got)
+	}
+}
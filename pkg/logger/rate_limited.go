@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RateLimitedConfig 限流错误日志配置
+type RateLimitedConfig struct {
+	Burst         int           // 每个窗口内同类错误完整输出的条数
+	SampleEvery   int           // 超出Burst后每N条输出一条
+	Window        time.Duration // 统计窗口，窗口结束时输出被抑制数量的汇总
+	MaxSignatures int           // 最多跟踪的错误签名数量，超出后不再限流
+}
+
+// DefaultRateLimitedConfig 默认限流错误日志配置
+func DefaultRateLimitedConfig() *RateLimitedConfig {
+	return &RateLimitedConfig{
+		Burst:         5,
+		SampleEvery:   100,
+		Window:        time.Minute,
+		MaxSignatures: 1000,
+	}
+}
+
+// errorSignature 同类错误的窗口统计
+type errorSignature struct {
+	msg         string
+	lastErr     error
+	windowStart time.Time
+	count       int64 // 窗口内出现次数
+	suppressed  int64 // 窗口内被抑制的次数
+}
+
+// RateLimitedLogger 限流错误日志 - 按"消息+错误内容"识别同类错误，
+// 每个窗口先完整输出Burst条，之后按SampleEvery采样，窗口结束时输出汇总
+type RateLimitedLogger struct {
+	config *RateLimitedConfig
+	logger *zap.Logger
+
+	signatures map[string]*errorSignature
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+// NewRateLimitedLogger 创建限流错误日志
+func NewRateLimitedLogger(config *RateLimitedConfig, logger *zap.Logger) *RateLimitedLogger {
+	defaults := DefaultRateLimitedConfig()
+	if config == nil {
+		config = defaults
+	}
+	if config.Burst < 0 {
+		config.Burst = 0
+	}
+	if config.SampleEvery <= 0 {
+		config.SampleEvery = defaults.SampleEvery
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.MaxSignatures <= 0 {
+		config.MaxSignatures = defaults.MaxSignatures
+	}
+
+	return &RateLimitedLogger{
+		config:     config,
+		logger:     logger,
+		signatures: make(map[string]*errorSignature),
+		now:        time.Now,
+	}
+}
+
+// Error 输出限流后的错误日志
+func (l *RateLimitedLogger) Error(msg string, err error, fields ...zap.Field) {
+	key := msg
+	if err != nil {
+		key = msg + "|" + err.Error()
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	l.flushExpired(now)
+
+	sig, exists := l.signatures[key]
+	if !exists {
+		if len(l.signatures) >= l.config.MaxSignatures {
+			l.mu.Unlock()
+			l.logger.Error(msg, append(fields, zap.Error(err))...)
+			return
+		}
+		sig = &errorSignature{msg: msg, windowStart: now}
+		l.signatures[key] = sig
+	}
+
+	sig.count++
+	sig.lastErr = err
+	count := sig.count
+	emit := count <= int64(l.config.Burst) ||
+		(count-int64(l.config.Burst))%int64(l.config.SampleEvery) == 0
+	if !emit {
+		sig.suppressed++
+	}
+	l.mu.Unlock()
+
+	if emit {
+		if count > int64(l.config.Burst) {
+			fields = append(fields, zap.Int64("occurrences", count))
+		}
+		l.logger.Error(msg, append(fields, zap.Error(err))...)
+	}
+}
+
+// Flush 输出所有窗口内被抑制错误的汇总并重置统计
+func (l *RateLimitedLogger) Flush() {
+	l.mu.Lock()
+	summaries := make([]*errorSignature, 0, len(l.signatures))
+	for key, sig := range l.signatures {
+		summaries = append(summaries, sig)
+		delete(l.signatures, key)
+	}
+	now := l.now()
+	l.mu.Unlock()
+
+	for _, sig := range summaries {
+		l.summarize(sig, now)
+	}
+}
+
+// flushExpired 输出窗口已结束的错误汇总，调用方需持有锁
+func (l *RateLimitedLogger) flushExpired(now time.Time) {
+	for key, sig := range l.signatures {
+		if now.Sub(sig.windowStart) < l.config.Window {
+			continue
+		}
+		delete(l.signatures, key)
+		l.summarize(sig, now)
+	}
+}
+
+// summarize 输出被抑制错误的汇总
+func (l *RateLimitedLogger) summarize(sig *errorSignature, now time.Time) {
+	if sig.suppressed == 0 {
+		return
+	}
+
+	l.logger.Error(fmt.Sprintf("%d similar errors in the last %s", sig.suppressed, l.config.Window),
+		zap.String("message", sig.msg),
+		zap.Int64("suppressed", sig.suppressed),
+		zap.Int64("total", sig.count),
+		zap.Duration("elapsed", now.Sub(sig.windowStart)),
+		zap.Error(sig.lastErr))
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedRateLimitedLogger(cfg *RateLimitedConfig) (*RateLimitedLogger, *observer.ObservedLogs, *time.Time) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	l := NewRateLimitedLogger(cfg, zap.New(core))
+
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+	return l, logs, &now
+}
+
+func TestRateLimitedLoggerSamplesIdenticalErrors(t *testing.T) {
+	l, logs, now := newObservedRateLimitedLogger(&RateLimitedConfig{
+		Burst:       3,
+		SampleEvery: 10,
+		Window:      time.Minute,
+	})
+
+	err := errors.New("redis: connection refused")
+	for i := 0; i < 100; i++ {
+		l.Error("Failed to increment counter", err)
+	}
+
+	// 前3条完整输出，之后每10条输出一条：3 + 97/10 = 12
+	if got := logs.Len(); got != 12 {
+		t.Fatalf("Expected 12 sampled log entries, got %d", got)
+	}
+	suppressed := 100 - logs.Len()
+
+	// 窗口结束后，下一次记录时输出汇总
+	*now = now.Add(time.Minute)
+	l.Error("Failed to increment counter", err)
+
+	entries := logs.TakeAll()
+	summary := entries[len(entries)-2]
+	if !strings.Contains(summary.Message, "88 similar errors in the last 1m0s") {
+		t.Errorf("Expected summary message, got %q", summary.Message)
+	}
+	if got := summary.ContextMap()["suppressed"]; got != int64(suppressed) {
+		t.Errorf("Expected %d suppressed errors, got %v", suppressed, got)
+	}
+
+	// 新窗口重新计数，首条错误完整输出
+	if last := entries[len(entries)-1]; last.Message != "Failed to increment counter" {
+		t.Errorf("Expected new window to log first error, got %q", last.Message)
+	}
+}
+
+func TestRateLimitedLoggerSeparatesSignatures(t *testing.T) {
+	l, logs, _ := newObservedRateLimitedLogger(&RateLimitedConfig{
+		Burst:       1,
+		SampleEvery: 1000,
+		Window:      time.Minute,
+	})
+
+	for i := 0; i < 10; i++ {
+		l.Error("Failed to get counter", errors.New("timeout"))
+		l.Error("Failed to get counter", errors.New("connection refused"))
+		l.Error("Failed to batch get counters", errors.New("timeout"))
+	}
+
+	// 每种签名各输出一条
+	if got := logs.Len(); got != 3 {
+		t.Fatalf("Expected 3 log entries for 3 signatures, got %d", got)
+	}
+
+	l.Flush()
+	summaries := logs.FilterMessageSnippet("9 similar errors").Len()
+	if summaries != 3 {
+		t.Errorf("Expected 3 summaries on flush, got %d", summaries)
+	}
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrInvalidMetricName 指标名不符合Prometheus命名规则
+	ErrInvalidMetricName = errors.New("invalid metric name")
+	// ErrInvalidLabelName 标签名不符合Prometheus命名规则
+	ErrInvalidLabelName = errors.New("invalid label name")
+)
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// MetricOpts 自定义指标配置，Namespace/Subsystem为空时使用管理器的默认值
+type MetricOpts struct {
+	Namespace string
+	Subsystem string
+	Name      string
+	Help      string
+	Labels    []string
+	Buckets   []float64 // 仅直方图使用，为空时使用prometheus.DefBuckets
+}
+
+// resolve 填充默认命名空间和子系统并校验名称
+func (mm *MetricsManager) resolve(opts MetricOpts) (MetricOpts, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = mm.namespace
+	}
+	if opts.Subsystem == "" {
+		opts.Subsystem = mm.subsystem
+	}
+
+	for _, part := range []string{opts.Namespace, opts.Subsystem} {
+		if part != "" && (!metricNameRE.MatchString(part) || strings.Contains(part, ":")) {
+			return opts, fmt.Errorf("%w: namespace/subsystem %q", ErrInvalidMetricName, part)
+		}
+	}
+	if opts.Name == "" {
+		return opts, fmt.Errorf("%w: name is required", ErrInvalidMetricName)
+	}
+	if fullName := prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name); !metricNameRE.MatchString(fullName) {
+		return opts, fmt.Errorf("%w: %q", ErrInvalidMetricName, fullName)
+	}
+
+	for _, label := range opts.Labels {
+		if !labelNameRE.MatchString(label) || strings.HasPrefix(label, "__") {
+			return opts, fmt.Errorf("%w: %q", ErrInvalidLabelName, label)
+		}
+	}
+	if opts.Help == "" {
+		opts.Help = opts.Name
+	}
+	return opts, nil
+}
+
+// RegisterCounter 注册自定义计数器，同名指标已注册时复用
+func (mm *MetricsManager) RegisterCounter(opts MetricOpts) (*prometheus.CounterVec, error) {
+	opts, err := mm.resolve(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Name:      opts.Name,
+			Help:      opts.Help,
+		},
+		opts.Labels,
+	)
+	return registerCustom(mm, counter)
+}
+
+// RegisterGauge 注册自定义仪表，同名指标已注册时复用
+func (mm *MetricsManager) RegisterGauge(opts MetricOpts) (*prometheus.GaugeVec, error) {
+	opts, err := mm.resolve(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	gauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Name:      opts.Name,
+			Help:      opts.Help,
+		},
+		opts.Labels,
+	)
+	return registerCustom(mm, gauge)
+}
+
+// RegisterHistogram 注册自定义直方图，同名指标已注册时复用
+func (mm *MetricsManager) RegisterHistogram(opts MetricOpts) (*prometheus.HistogramVec, error) {
+	opts, err := mm.resolve(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	histogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Name:      opts.Name,
+			Help:      opts.Help,
+			Buckets:   buckets,
+		},
+		opts.Labels,
+	)
+	return registerCustom(mm, histogram)
+}
+
+// registerCustom 注册自定义收集器，与内置指标冲突等失败情况返回错误
+func registerCustom[T prometheus.Collector](mm *MetricsManager, collector T) (T, error) {
+	err := mm.registry.Register(collector)
+	if err == nil {
+		mm.trackCollector(collector)
+		return collector, nil
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+
+	var zero T
+	return zero, fmt.Errorf("failed to register metric: %w", err)
+}
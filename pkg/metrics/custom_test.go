@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRegisterCounterSubsystemOverride(t *testing.T) {
+	mm := NewMetricsManager(&Config{Namespace: "test", Subsystem: "app"}, zap.NewNop())
+
+	counter, err := mm.RegisterCounter(MetricOpts{
+		Subsystem: "kafka",
+		Name:      "messages_consumed_total",
+		Help:      "Total consumed Kafka messages",
+		Labels:    []string{"topic"},
+	})
+	if err != nil {
+		t.Fatalf("RegisterCounter failed: %v", err)
+	}
+	counter.WithLabelValues("counter-events").Inc()
+
+	// 未覆盖时使用管理器默认的子系统
+	gauge, err := mm.RegisterGauge(MetricOpts{Name: "queue_depth"})
+	if err != nil {
+		t.Fatalf("RegisterGauge failed: %v", err)
+	}
+	gauge.WithLabelValues().Set(3)
+
+	rec := httptest.NewRecorder()
+	mm.GetHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	if !strings.Contains(body, `test_kafka_messages_consumed_total{topic="counter-events"} 1`) {
+		t.Errorf("Expected overridden subsystem in exposed metric name, got:\n%s", body)
+	}
+	if !strings.Contains(body, "test_app_queue_depth 3") {
+		t.Errorf("Expected default subsystem in exposed metric name, got:\n%s", body)
+	}
+
+	// 重复注册返回同一收集器
+	again, err := mm.RegisterCounter(MetricOpts{Subsystem: "kafka", Name: "messages_consumed_total", Help: "Total consumed Kafka messages", Labels: []string{"topic"}})
+	if err != nil || again != counter {
+		t.Errorf("Expected duplicate registration to reuse collector, got %v, %v", again, err)
+	}
+}
+
+func TestRegisterMetricValidatesNames(t *testing.T) {
+	mm := NewMetricsManager(&Config{Namespace: "test"}, zap.NewNop())
+
+	tests := []struct {
+		name    string
+		opts    MetricOpts
+		wantErr error
+	}{
+		{"empty name", MetricOpts{}, ErrInvalidMetricName},
+		{"dash in name", MetricOpts{Name: "bad-name"}, ErrInvalidMetricName},
+		{"space in name", MetricOpts{Name: "bad name"}, ErrInvalidMetricName},
+		{"leading digit namespace", MetricOpts{Namespace: "1st", Name: "ok"}, ErrInvalidMetricName},
+		{"colon in subsystem", MetricOpts{Subsystem: "kafka:x", Name: "ok"}, ErrInvalidMetricName},
+		{"dash in label", MetricOpts{Name: "ok", Labels: []string{"bad-label"}}, ErrInvalidLabelName},
+		{"reserved label", MetricOpts{Name: "ok", Labels: []string{"__name"}}, ErrInvalidLabelName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mm.RegisterCounter(tt.opts); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// LoadScoreConfig 负载评分的归一化参数和权重
+type LoadScoreConfig struct {
+	MaxInFlight       float64 `yaml:"max_in_flight"`      // 视为满载的在途请求数
+	InFlightWeight    float64 `yaml:"in_flight_weight"`   // 在途请求的权重
+	UtilizationWeight float64 `yaml:"utilization_weight"` // 工作池利用率的权重
+	ErrorRateWeight   float64 `yaml:"error_rate_weight"`  // 错误率的权重
+}
+
+// DefaultLoadScoreConfig 默认负载评分配置
+func DefaultLoadScoreConfig() *LoadScoreConfig {
+	return &LoadScoreConfig{
+		MaxInFlight:       1000,
+		InFlightWeight:    0.4,
+		UtilizationWeight: 0.4,
+		ErrorRateWeight:   0.2,
+	}
+}
+
+// LoadInputs 计算负载评分的原始输入
+type LoadInputs struct {
+	InFlight       float64
+	Utilization    float64
+	HasUtilization bool // 没有工作池的服务不参与利用率加权
+	ErrorRate      float64
+}
+
+// LoadScore 负载评分及各项输入
+type LoadScore struct {
+	Score       float64 `json:"score"` // 0-1，越大负载越高
+	InFlight    float64 `json:"in_flight"`
+	Utilization float64 `json:"utilization"`
+	ErrorRate   float64 `json:"error_rate"`
+	QPS         float64 `json:"qps"`
+}
+
+// Compute 将各项输入归一化到0-1后按权重加权平均；缺失的输入不参与加权
+func (c *LoadScoreConfig) Compute(in LoadInputs) float64 {
+	var weighted, totalWeight float64
+
+	if c.InFlightWeight > 0 && c.MaxInFlight > 0 {
+		weighted += c.InFlightWeight * clamp01(in.InFlight/c.MaxInFlight)
+		totalWeight += c.InFlightWeight
+	}
+	if c.UtilizationWeight > 0 && in.HasUtilization {
+		weighted += c.UtilizationWeight * clamp01(in.Utilization)
+		totalWeight += c.UtilizationWeight
+	}
+	if c.ErrorRateWeight > 0 {
+		weighted += c.ErrorRateWeight * clamp01(in.ErrorRate)
+		totalWeight += c.ErrorRateWeight
+	}
+
+	if totalWeight == 0 {
+		return 0
+	}
+	return weighted / totalWeight
+}
+
+// clamp01 限制在0-1之间
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// LoadScorer 根据在途请求、工作池利用率和错误率计算负载评分，供外部自动扩缩容使用
+type LoadScorer struct {
+	mm          *MetricsManager
+	service     string
+	config      *LoadScoreConfig
+	sampler     *RateSampler
+	utilization func() float64
+	mutex       sync.Mutex
+}
+
+// NewLoadScorer 创建负载评分器，错误率按相邻两次评分之间的请求计算
+func NewLoadScorer(mm *MetricsManager, service string, config *LoadScoreConfig) *LoadScorer {
+	if config == nil {
+		config = DefaultLoadScoreConfig()
+	}
+	return &LoadScorer{
+		mm:      mm,
+		service: service,
+		config:  config,
+		sampler: NewRateSampler(mm, service),
+	}
+}
+
+// SetUtilizationSource 设置工作池利用率来源，返回0-1
+func (s *LoadScorer) SetUtilizationSource(fn func() float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.utilization = fn
+}
+
+// Score 计算当前负载评分
+func (s *LoadScorer) Score() LoadScore {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	rate := s.sampler.Sample()
+	in := LoadInputs{ErrorRate: rate.ErrorRate}
+	if s.mm != nil {
+		in.InFlight = s.mm.GetInFlight(s.service)
+	}
+	if s.utilization != nil {
+		in.Utilization = s.utilization()
+		in.HasUtilization = true
+	}
+
+	return LoadScore{
+		Score:       s.config.Compute(in),
+		InFlight:    in.InFlight,
+		Utilization: in.Utilization,
+		ErrorRate:   in.ErrorRate,
+		QPS:         rate.QPS,
+	}
+}
+
+// ServeHTTP 以JSON返回当前负载评分
+func (s *LoadScorer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Score())
+}
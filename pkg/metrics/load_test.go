@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestLoadScoreRisesWithLoad(t *testing.T) {
+	mm := NewMetricsManager(&Config{Namespace: "test"}, zap.NewNop())
+	scorer := NewLoadScorer(mm, "counter", &LoadScoreConfig{
+		MaxInFlight:       10,
+		InFlightWeight:    1,
+		UtilizationWeight: 1,
+		ErrorRateWeight:   1,
+	})
+	utilization := 0.0
+	scorer.SetUtilizationSource(func() float64 { return utilization })
+
+	idle := scorer.Score()
+	if idle.Score != 0 {
+		t.Fatalf("Expected idle score 0, got %+v", idle)
+	}
+
+	// 在途请求增加
+	for i := 0; i < 5; i++ {
+		mm.IncGRPCInFlight("counter")
+	}
+	busy := scorer.Score()
+	if busy.InFlight != 5 || busy.Score <= idle.Score {
+		t.Errorf("Expected score to rise with in-flight requests, got %+v", busy)
+	}
+
+	// 工作池利用率上升
+	utilization = 0.8
+	saturated := scorer.Score()
+	if saturated.Score <= busy.Score {
+		t.Errorf("Expected score to rise with utilization, got %v after %v", saturated.Score, busy.Score)
+	}
+
+	// 错误率上升
+	mm.RecordGRPCRequest("/counter.CounterService/IncrementCounter", "counter", "OK", time.Millisecond)
+	mm.RecordGRPCRequest("/counter.CounterService/IncrementCounter", "counter", "Unavailable", time.Millisecond)
+	failing := scorer.Score()
+	if failing.ErrorRate != 0.5 || failing.Score <= saturated.Score {
+		t.Errorf("Expected score to rise with error rate, got %+v after %v", failing, saturated.Score)
+	}
+
+	// 其他服务的在途请求不计入
+	mm.IncHTTPInFlight("gateway")
+	if got := scorer.Score().InFlight; got != 5 {
+		t.Errorf("Expected in-flight scoped to counter service, got %v", got)
+	}
+}
+
+func TestLoadScoreComputeWeights(t *testing.T) {
+	config := &LoadScoreConfig{MaxInFlight: 100, InFlightWeight: 3, UtilizationWeight: 1, ErrorRateWeight: 0}
+
+	// 超过满载的在途请求按1计算
+	if got := config.Compute(LoadInputs{InFlight: 500}); got != 1 {
+		t.Errorf("Expected in-flight clamped to full load, got %v", got)
+	}
+	// 权重为0的错误率不影响评分
+	if got := config.Compute(LoadInputs{InFlight: 50, ErrorRate: 1}); got != 0.5 {
+		t.Errorf("Expected zero-weight error rate ignored, got %v", got)
+	}
+	// 有利用率来源时按权重加权平均
+	if got := config.Compute(LoadInputs{InFlight: 100, Utilization: 0, HasUtilization: true}); got != 0.75 {
+		t.Errorf("Expected weighted average 0.75, got %v", got)
+	}
+	if got := (&LoadScoreConfig{}).Compute(LoadInputs{InFlight: 10}); got != 0 {
+		t.Errorf("Expected 0 without weights, got %v", got)
+	}
+}
+
+func TestLoadScorerServeHTTP(t *testing.T) {
+	scorer := NewLoadScorer(nil, "gateway", nil)
+	scorer.SetUtilizationSource(func() float64 { return 1 })
+
+	rec := httptest.NewRecorder()
+	scorer.ServeHTTP(rec, httptest.NewRequest("GET", "/load", nil))
+
+	var score LoadScore
+	if err := json.Unmarshal(rec.Body.Bytes(), &score); err != nil {
+		t.Fatalf("Failed to decode load score: %v", err)
+	}
+	if score.Utilization != 1 || score.Score <= 0 {
+		t.Errorf("Expected utilization reflected in score, got %+v", score)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+}
@@ -2,13 +2,16 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"runtime"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +20,10 @@ type MetricsManager struct {
 	registry *prometheus.Registry
 	logger   *zap.Logger
 
+	// 自定义指标未指定时使用的默认命名空间和子系统
+	namespace string
+	subsystem string
+
 	// HTTP 指标
 	httpRequestsTotal    *prometheus.CounterVec
 	httpRequestDuration  *prometheus.HistogramVec
@@ -26,6 +33,7 @@ type MetricsManager struct {
 	grpcRequestsTotal    *prometheus.CounterVec
 	grpcRequestDuration  *prometheus.HistogramVec
 	grpcRequestsInFlight *prometheus.GaugeVec
+	grpcResponseBytes    *prometheus.HistogramVec
 
 	// 系统指标
 	systemCPUUsage    prometheus.Gauge
@@ -37,6 +45,8 @@ type MetricsManager struct {
 	businessCounters   *prometheus.CounterVec
 	businessGauges     *prometheus.GaugeVec
 	businessHistograms *prometheus.HistogramVec
+	eventsDropped      *prometheus.CounterVec
+	consumerLag        *prometheus.GaugeVec
 
 	// 数据库指标
 	dbConnectionsActive *prometheus.GaugeVec
@@ -49,9 +59,25 @@ type MetricsManager struct {
 	cacheMisses            *prometheus.CounterVec
 	cacheOperationDuration *prometheus.HistogramVec
 
+	// 工作池指标
+	workerPoolRunning     *prometheus.GaugeVec
+	workerPoolWaiting     *prometheus.GaugeVec
+	workerPoolCapacity    *prometheus.GaugeVec
+	workerPoolUtilization *prometheus.GaugeVec
+
 	// 服务健康指标
 	serviceHealth *prometheus.GaugeVec
 	serviceUptime prometheus.Gauge
+	configDrift   *prometheus.GaugeVec
+
+	// registered 本管理器注册到registry的收集器，不含复用的已有收集器，Shutdown时按需注销
+	registered           []prometheus.Collector
+	unregisterOnShutdown bool
+
+	// 系统指标收集goroutine的停止信号和退出通知，未启用系统指标时collectorDone为nil
+	stopCh        chan struct{}
+	collectorDone chan struct{}
+	stopOnce      sync.Once
 
 	mu sync.RWMutex
 }
@@ -65,6 +91,13 @@ type Config struct {
 	EnableBusiness bool              `yaml:"enable_business"`
 	EnableDB       bool              `yaml:"enable_db"`
 	EnableCache    bool              `yaml:"enable_cache"`
+
+	// Registry 可选，多个管理器共享同一注册器时传入，为空则创建独立注册器
+	Registry *prometheus.Registry `yaml:"-"`
+
+	// UnregisterOnShutdown Shutdown时从Registry注销本管理器注册的收集器，
+	// 同一进程内共享Registry重新创建管理器时注册新的收集器而不是复用旧的
+	UnregisterOnShutdown bool `yaml:"unregister_on_shutdown"`
 }
 
 // DefaultConfig 默认配置
@@ -86,11 +119,18 @@ func NewMetricsManager(config *Config, logger *zap.Logger) *MetricsManager {
 		config = DefaultConfig()
 	}
 
-	registry := prometheus.NewRegistry()
+	registry := config.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+	}
 
 	mm := &MetricsManager{
-		registry: registry,
-		logger:   logger,
+		registry:             registry,
+		logger:               logger,
+		namespace:            config.Namespace,
+		subsystem:            config.Subsystem,
+		unregisterOnShutdown: config.UnregisterOnShutdown,
+		stopCh:               make(chan struct{}),
 	}
 
 	mm.initHTTPMetrics(config)
@@ -112,6 +152,7 @@ func NewMetricsManager(config *Config, logger *zap.Logger) *MetricsManager {
 		mm.initCacheMetrics(config)
 	}
 
+	mm.initWorkerPoolMetrics(config)
 	mm.initServiceMetrics(config)
 
 	// 注册所有指标到 registry
@@ -119,6 +160,7 @@ func NewMetricsManager(config *Config, logger *zap.Logger) *MetricsManager {
 
 	// 启动系统指标收集
 	if config.EnableSystem {
+		mm.collectorDone = make(chan struct{})
 		go mm.collectSystemMetrics()
 	}
 
@@ -195,6 +237,17 @@ func (mm *MetricsManager) initGRPCMetrics(config *Config) {
 		},
 		[]string{"service"},
 	)
+
+	mm.grpcResponseBytes = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "grpc_response_bytes",
+			Help:      "Serialized gRPC response size in bytes",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 10), // 64B ~ 16MB
+		},
+		[]string{"method", "service"},
+	)
 }
 
 // initSystemMetrics 初始化系统指标
@@ -245,7 +298,7 @@ func (mm *MetricsManager) initBusinessMetrics(config *Config) {
 			Name:      "business_operations_total",
 			Help:      "Total number of business operations",
 		},
-		[]string{"operation", "service", "status"},
+		[]string{"operation", "service", "counter_type", "status"},
 	)
 
 	mm.businessGauges = prometheus.NewGaugeVec(
@@ -266,7 +319,27 @@ func (mm *MetricsManager) initBusinessMetrics(config *Config) {
 			Help:      "Business operation duration in seconds",
 			Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
 		},
-		[]string{"operation", "service"},
+		[]string{"operation", "service", "counter_type"},
+	)
+
+	mm.eventsDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "kafka_events_dropped_total",
+			Help:      "Total number of Kafka events dropped before being sent",
+		},
+		[]string{"service", "reason"},
+	)
+
+	mm.consumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "kafka_consumer_lag",
+			Help:      "Messages between the partition high water mark and the consumer group's committed offset",
+		},
+		[]string{"topic", "partition", "group"},
 	)
 }
 
@@ -348,6 +421,26 @@ func (mm *MetricsManager) initCacheMetrics(config *Config) {
 	)
 }
 
+// initWorkerPoolMetrics 初始化工作池指标
+func (mm *MetricsManager) initWorkerPoolMetrics(config *Config) {
+	newGauge := func(name, help string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      name,
+				Help:      help,
+			},
+			[]string{"service", "pool"},
+		)
+	}
+
+	mm.workerPoolRunning = newGauge("worker_pool_running", "Number of workers currently running tasks")
+	mm.workerPoolWaiting = newGauge("worker_pool_waiting", "Number of tasks blocked waiting for a free worker")
+	mm.workerPoolCapacity = newGauge("worker_pool_capacity", "Maximum number of workers in the pool")
+	mm.workerPoolUtilization = newGauge("worker_pool_utilization", "Running workers divided by pool capacity (0-1)")
+}
+
 // initServiceMetrics 初始化服务指标
 func (mm *MetricsManager) initServiceMetrics(config *Config) {
 	mm.serviceHealth = prometheus.NewGaugeVec(
@@ -368,63 +461,118 @@ func (mm *MetricsManager) initServiceMetrics(config *Config) {
 			Help:      "Service uptime in seconds",
 		},
 	)
+
+	mm.configDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "config_drift",
+			Help:      "Whether the active config differs from the on-disk config file (1 = drifted, 0 = in sync)",
+		},
+		[]string{"service"},
+	)
 }
 
-// registerMetrics 注册所有指标
+// registerMetrics 注册所有指标，已注册的同名指标复用现有收集器
 func (mm *MetricsManager) registerMetrics() {
 	// HTTP 指标
-	mm.registry.MustRegister(mm.httpRequestsTotal)
-	mm.registry.MustRegister(mm.httpRequestDuration)
-	mm.registry.MustRegister(mm.httpRequestsInFlight)
+	mm.httpRequestsTotal = registerCollector(mm, mm.httpRequestsTotal)
+	mm.httpRequestDuration = registerCollector(mm, mm.httpRequestDuration)
+	mm.httpRequestsInFlight = registerCollector(mm, mm.httpRequestsInFlight)
 
 	// gRPC 指标
-	mm.registry.MustRegister(mm.grpcRequestsTotal)
-	mm.registry.MustRegister(mm.grpcRequestDuration)
-	mm.registry.MustRegister(mm.grpcRequestsInFlight)
+	mm.grpcRequestsTotal = registerCollector(mm, mm.grpcRequestsTotal)
+	mm.grpcRequestDuration = registerCollector(mm, mm.grpcRequestDuration)
+	mm.grpcRequestsInFlight = registerCollector(mm, mm.grpcRequestsInFlight)
+	mm.grpcResponseBytes = registerCollector(mm, mm.grpcResponseBytes)
 
 	// 系统指标
 	if mm.systemCPUUsage != nil {
-		mm.registry.MustRegister(mm.systemCPUUsage)
-		mm.registry.MustRegister(mm.systemMemoryUsage)
-		mm.registry.MustRegister(mm.systemGoroutines)
-		mm.registry.MustRegister(mm.systemGCDuration)
+		mm.systemCPUUsage = registerCollector(mm, mm.systemCPUUsage)
+		mm.systemMemoryUsage = registerCollector(mm, mm.systemMemoryUsage)
+		mm.systemGoroutines = registerCollector(mm, mm.systemGoroutines)
+		mm.systemGCDuration = registerCollector(mm, mm.systemGCDuration)
 	}
 
 	// 业务指标
 	if mm.businessCounters != nil {
-		mm.registry.MustRegister(mm.businessCounters)
-		mm.registry.MustRegister(mm.businessGauges)
-		mm.registry.MustRegister(mm.businessHistograms)
+		mm.businessCounters = registerCollector(mm, mm.businessCounters)
+		mm.businessGauges = registerCollector(mm, mm.businessGauges)
+		mm.businessHistograms = registerCollector(mm, mm.businessHistograms)
+		mm.eventsDropped = registerCollector(mm, mm.eventsDropped)
+		mm.consumerLag = registerCollector(mm, mm.consumerLag)
 	}
 
 	// 数据库指标
 	if mm.dbConnectionsActive != nil {
-		mm.registry.MustRegister(mm.dbConnectionsActive)
-		mm.registry.MustRegister(mm.dbConnectionsIdle)
-		mm.registry.MustRegister(mm.dbQueryDuration)
-		mm.registry.MustRegister(mm.dbQueryTotal)
+		mm.dbConnectionsActive = registerCollector(mm, mm.dbConnectionsActive)
+		mm.dbConnectionsIdle = registerCollector(mm, mm.dbConnectionsIdle)
+		mm.dbQueryDuration = registerCollector(mm, mm.dbQueryDuration)
+		mm.dbQueryTotal = registerCollector(mm, mm.dbQueryTotal)
 	}
 
 	// 缓存指标
 	if mm.cacheHits != nil {
-		mm.registry.MustRegister(mm.cacheHits)
-		mm.registry.MustRegister(mm.cacheMisses)
-		mm.registry.MustRegister(mm.cacheOperationDuration)
+		mm.cacheHits = registerCollector(mm, mm.cacheHits)
+		mm.cacheMisses = registerCollector(mm, mm.cacheMisses)
+		mm.cacheOperationDuration = registerCollector(mm, mm.cacheOperationDuration)
 	}
 
+	// 工作池指标
+	mm.workerPoolRunning = registerCollector(mm, mm.workerPoolRunning)
+	mm.workerPoolWaiting = registerCollector(mm, mm.workerPoolWaiting)
+	mm.workerPoolCapacity = registerCollector(mm, mm.workerPoolCapacity)
+	mm.workerPoolUtilization = registerCollector(mm, mm.workerPoolUtilization)
+
 	// 服务指标
-	mm.registry.MustRegister(mm.serviceHealth)
-	mm.registry.MustRegister(mm.serviceUptime)
+	mm.serviceHealth = registerCollector(mm, mm.serviceHealth)
+	mm.serviceUptime = registerCollector(mm, mm.serviceUptime)
+	mm.configDrift = registerCollector(mm, mm.configDrift)
 }
 
-// collectSystemMetrics 收集系统指标
+// registerCollector 注册收集器，重复注册时返回已存在的收集器，注册失败只记录日志不panic
+func registerCollector[T prometheus.Collector](mm *MetricsManager, collector T) T {
+	err := mm.registry.Register(collector)
+	if err == nil {
+		mm.trackCollector(collector)
+		return collector
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			mm.logger.Warn("Metric already registered, reusing existing collector", zap.Error(err))
+			return existing
+		}
+	}
+
+	mm.logger.Error("Failed to register metric", zap.Error(err))
+	return collector
+}
+
+// trackCollector 记录本管理器注册的收集器
+func (mm *MetricsManager) trackCollector(collector prometheus.Collector) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.registered = append(mm.registered, collector)
+}
+
+// collectSystemMetrics 收集系统指标，直到Shutdown
 func (mm *MetricsManager) collectSystemMetrics() {
+	defer close(mm.collectorDone)
+
 	ticker := time.NewTicker(15 * time.Second)
 	defer ticker.Stop()
 
 	startTime := time.Now()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-mm.stopCh:
+			return
+		case <-ticker.C:
+		}
+
 		// 收集 Goroutine 数量
 		mm.systemGoroutines.Set(float64(runtime.NumGoroutine()))
 
@@ -475,6 +623,11 @@ func (mm *MetricsManager) RecordGRPCRequest(method, service, status string, dura
 	mm.grpcRequestDuration.WithLabelValues(method, service).Observe(duration.Seconds())
 }
 
+// RecordGRPCResponseSize 记录 gRPC 响应序列化后的字节数
+func (mm *MetricsManager) RecordGRPCResponseSize(method, service string, size int) {
+	mm.grpcResponseBytes.WithLabelValues(method, service).Observe(float64(size))
+}
+
 // IncGRPCInFlight 增加正在处理的 gRPC 请求数
 func (mm *MetricsManager) IncGRPCInFlight(service string) {
 	mm.grpcRequestsInFlight.WithLabelValues(service).Inc()
@@ -487,9 +640,15 @@ func (mm *MetricsManager) DecGRPCInFlight(service string) {
 
 // RecordBusinessOperation 记录业务操作指标
 func (mm *MetricsManager) RecordBusinessOperation(operation, service, status string, duration time.Duration) {
+	mm.RecordCounterOperation(operation, service, "", status, duration)
+}
+
+// RecordCounterOperation 记录按计数器类型区分的业务操作指标
+// counterType作为标签值，调用方需要保证取值有限，避免指标基数膨胀
+func (mm *MetricsManager) RecordCounterOperation(operation, service, counterType, status string, duration time.Duration) {
 	if mm.businessCounters != nil {
-		mm.businessCounters.WithLabelValues(operation, service, status).Inc()
-		mm.businessHistograms.WithLabelValues(operation, service).Observe(duration.Seconds())
+		mm.businessCounters.WithLabelValues(operation, service, counterType, status).Inc()
+		mm.businessHistograms.WithLabelValues(operation, service, counterType).Observe(duration.Seconds())
 	}
 }
 
@@ -500,6 +659,20 @@ func (mm *MetricsManager) SetBusinessGauge(metric, service string, value float64
 	}
 }
 
+// RecordDroppedEvents 记录未发送就被丢弃的Kafka事件数，reason如queue_full、send_failed
+func (mm *MetricsManager) RecordDroppedEvents(service, reason string, count int) {
+	if mm.eventsDropped != nil {
+		mm.eventsDropped.WithLabelValues(service, reason).Add(float64(count))
+	}
+}
+
+// SetConsumerLag 设置消费组在分区上的消费延迟（未消费的消息数）
+func (mm *MetricsManager) SetConsumerLag(topic, partition, group string, lag int64) {
+	if mm.consumerLag != nil {
+		mm.consumerLag.WithLabelValues(topic, partition, group).Set(float64(lag))
+	}
+}
+
 // RecordDBOperation 记录数据库操作指标
 func (mm *MetricsManager) RecordDBOperation(operation, database, service, status string, duration time.Duration) {
 	if mm.dbQueryTotal != nil {
@@ -528,6 +701,19 @@ func (mm *MetricsManager) RecordCacheOperation(operation, cache, service string,
 	}
 }
 
+// SetWorkerPoolStats 设置工作池的运行数、等待数、容量和利用率
+func (mm *MetricsManager) SetWorkerPoolStats(service, pool string, capacity, running, waiting int) {
+	mm.workerPoolRunning.WithLabelValues(service, pool).Set(float64(running))
+	mm.workerPoolWaiting.WithLabelValues(service, pool).Set(float64(waiting))
+	mm.workerPoolCapacity.WithLabelValues(service, pool).Set(float64(capacity))
+
+	utilization := 0.0
+	if capacity > 0 {
+		utilization = float64(running) / float64(capacity)
+	}
+	mm.workerPoolUtilization.WithLabelValues(service, pool).Set(utilization)
+}
+
 // SetServiceHealth 设置服务健康状态
 func (mm *MetricsManager) SetServiceHealth(service, component string, healthy bool) {
 	value := 0.0
@@ -537,8 +723,135 @@ func (mm *MetricsManager) SetServiceHealth(service, component string, healthy bo
 	mm.serviceHealth.WithLabelValues(service, component).Set(value)
 }
 
-// Shutdown 关闭指标管理器
+// SetConfigDrift 设置配置漂移状态
+func (mm *MetricsManager) SetConfigDrift(service string, drifted bool) {
+	value := 0.0
+	if drifted {
+		value = 1.0
+	}
+	mm.configDrift.WithLabelValues(service).Set(value)
+}
+
+// RequestTotals 请求计数汇总
+type RequestTotals struct {
+	GRPCRequests int64 `json:"grpc_requests"`
+	GRPCErrors   int64 `json:"grpc_errors"` // 状态非OK的gRPC请求
+	HTTPRequests int64 `json:"http_requests"`
+	HTTPErrors   int64 `json:"http_errors"` // 状态码>=400的HTTP请求
+}
+
+// GetRequestTotals 汇总指定服务自启动以来的请求计数，service为空时汇总所有服务
+func (mm *MetricsManager) GetRequestTotals(service string) RequestTotals {
+	var totals RequestTotals
+
+	collectCounter(mm.grpcRequestsTotal, func(labels map[string]string, value int64) {
+		if service != "" && labels["service"] != service {
+			return
+		}
+		totals.GRPCRequests += value
+		if labels["status"] != "OK" {
+			totals.GRPCErrors += value
+		}
+	})
+
+	collectCounter(mm.httpRequestsTotal, func(labels map[string]string, value int64) {
+		if service != "" && labels["service"] != service {
+			return
+		}
+		totals.HTTPRequests += value
+		if code, err := strconv.Atoi(labels["status_code"]); err == nil && code >= 400 {
+			totals.HTTPErrors += value
+		}
+	})
+
+	return totals
+}
+
+// collectCounter 遍历CounterVec的所有标签组合
+func collectCounter(vec *prometheus.CounterVec, fn func(labels map[string]string, value int64)) {
+	if vec == nil {
+		return
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil || pb.Counter == nil {
+			continue
+		}
+		labels := make(map[string]string, len(pb.Label))
+		for _, lp := range pb.Label {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		fn(labels, int64(pb.Counter.GetValue()))
+	}
+}
+
+// GetInFlight 指定服务正在处理的gRPC和HTTP请求数，service为空时汇总所有服务
+func (mm *MetricsManager) GetInFlight(service string) float64 {
+	var inFlight float64
+	for _, vec := range []*prometheus.GaugeVec{mm.grpcRequestsInFlight, mm.httpRequestsInFlight} {
+		collectGauge(vec, func(labels map[string]string, value float64) {
+			if service == "" || labels["service"] == service {
+				inFlight += value
+			}
+		})
+	}
+	return inFlight
+}
+
+// collectGauge 遍历GaugeVec的所有标签组合
+func collectGauge(vec *prometheus.GaugeVec, fn func(labels map[string]string, value float64)) {
+	if vec == nil {
+		return
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil || pb.Gauge == nil {
+			continue
+		}
+		labels := make(map[string]string, len(pb.Label))
+		for _, lp := range pb.Label {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		fn(labels, pb.Gauge.GetValue())
+	}
+}
+
+// Shutdown 停止系统指标收集goroutine，配置了UnregisterOnShutdown时注销本管理器注册的收集器
+// 可重复调用；ctx到期时不再等待收集goroutine退出
 func (mm *MetricsManager) Shutdown(ctx context.Context) error {
 	mm.logger.Info("Shutting down metrics manager")
+	mm.stopOnce.Do(func() { close(mm.stopCh) })
+
+	if mm.collectorDone != nil {
+		select {
+		case <-mm.collectorDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if mm.unregisterOnShutdown {
+		mm.mu.Lock()
+		registered := mm.registered
+		mm.registered = nil
+		mm.mu.Unlock()
+		for _, collector := range registered {
+			mm.registry.Unregister(collector)
+		}
+	}
 	return nil
 }
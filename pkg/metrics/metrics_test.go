@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDuplicateRegistrationDoesNotPanic(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	registry := prometheus.NewRegistry()
+	config := &Config{Namespace: "test", EnableBusiness: true, Registry: registry}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Expected duplicate registration not to panic, got %v", r)
+		}
+	}()
+
+	first := NewMetricsManager(config, zap.New(core))
+	second := NewMetricsManager(config, zap.New(core))
+
+	// 同一管理器重复注册同样不panic
+	second.registerMetrics()
+
+	if logs.FilterMessage("Metric already registered, reusing existing collector").Len() == 0 {
+		t.Errorf("Expected duplicate registration to be logged")
+	}
+
+	// 第二个管理器复用已注册的收集器，两者记录的数据汇总到同一指标
+	first.RecordBusinessOperation("increment", "counter", "success", time.Millisecond)
+	second.RecordBusinessOperation("increment", "counter", "success", time.Millisecond)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_business_operations_total" {
+			continue
+		}
+		if got := family.GetMetric()[0].GetCounter().GetValue(); got != 2 {
+			t.Errorf("Expected shared counter value 2, got %v", got)
+		}
+		return
+	}
+	t.Errorf("Expected test_business_operations_total to be registered")
+}
+
+func TestShutdownStopsCollectorAndUnregisters(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := &Config{Namespace: "test", EnableSystem: true, EnableBusiness: true, Registry: registry, UnregisterOnShutdown: true}
+
+	first := NewMetricsManager(config, zap.NewNop())
+	if _, err := first.RegisterCounter(MetricOpts{Name: "jobs_total", Help: "Jobs", Labels: []string{"kind"}}); err != nil {
+		t.Fatalf("RegisterCounter failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := first.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	// 收集goroutine已退出，重复Shutdown不报错
+	select {
+	case <-first.collectorDone:
+	default:
+		t.Error("Expected system metrics collector to stop")
+	}
+	if err := first.Shutdown(ctx); err != nil {
+		t.Errorf("Expected repeated Shutdown to succeed, got %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Expected registration after Shutdown not to panic, got %v", r)
+		}
+	}()
+
+	// 注销后同名指标可以用MustRegister重新注册
+	registry.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "test", Name: "jobs_total", Help: "Jobs",
+	}, []string{"kind"}))
+
+	// 重新创建的管理器注册新的收集器，而不是复用已关闭管理器的收集器
+	second := NewMetricsManager(config, zap.NewNop())
+	defer second.Shutdown(ctx)
+	if second.grpcRequestsTotal == first.grpcRequestsTotal {
+		t.Error("Expected re-created manager to register fresh collectors")
+	}
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import "time"
+
+// RequestRate 相邻两次采样之间的请求速率
+type RequestRate struct {
+	QPS       float64 `json:"qps"`
+	ErrorRate float64 `json:"error_rate"` // 区间内错误请求占比，0-1
+	Requests  int64   `json:"requests"`   // 启动以来的请求总数
+	Errors    int64   `json:"errors"`     // 启动以来的错误请求总数
+}
+
+// RateSampler 根据累计请求数计算相邻两次采样间的QPS和错误率
+type RateSampler struct {
+	mm      *MetricsManager
+	service string
+	last    RequestTotals
+	lastAt  time.Time
+	now     func() time.Time
+}
+
+// NewRateSampler 创建请求速率采样器，以创建时刻作为第一次采样
+func NewRateSampler(mm *MetricsManager, service string) *RateSampler {
+	s := &RateSampler{mm: mm, service: service, now: time.Now}
+	s.last = s.totals()
+	s.lastAt = s.now()
+	return s
+}
+
+// totals 汇总gRPC和HTTP请求计数，未设置指标管理器时为0
+func (s *RateSampler) totals() RequestTotals {
+	if s.mm == nil {
+		return RequestTotals{}
+	}
+	return s.mm.GetRequestTotals(s.service)
+}
+
+// Sample 采样并返回距上一次采样的请求速率
+func (s *RateSampler) Sample() RequestRate {
+	current := s.totals()
+	now := s.now()
+
+	requests := current.GRPCRequests + current.HTTPRequests
+	errors := current.GRPCErrors + current.HTTPErrors
+	rate := RequestRate{Requests: requests, Errors: errors}
+
+	deltaRequests := requests - s.last.GRPCRequests - s.last.HTTPRequests
+	deltaErrors := errors - s.last.GRPCErrors - s.last.HTTPErrors
+	if elapsed := now.Sub(s.lastAt).Seconds(); elapsed > 0 {
+		rate.QPS = float64(deltaRequests) / elapsed
+	}
+	if deltaRequests > 0 {
+		rate.ErrorRate = float64(deltaErrors) / float64(deltaRequests)
+	}
+
+	s.last = current
+	s.lastAt = now
+	return rate
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"high-go-press/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthConfig HTTP认证中间件配置
+type AuthConfig struct {
+	SkipPaths []string // 不需要认证的路径，如存活检查和指标抓取
+}
+
+// DefaultAuthConfig 默认跳过存活、就绪检查和指标端点
+func DefaultAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		SkipPaths: []string{"/livez", "/metrics", "/api/v1/health", "/api/v1/ready"},
+	}
+}
+
+// authStatus 将认证错误转换为gRPC状态
+func authStatus(err error) *status.Status {
+	if errors.Is(err, auth.ErrMissingCredentials) || errors.Is(err, auth.ErrInvalidCredentials) {
+		return status.New(codes.Unauthenticated, err.Error())
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	return status.New(codes.Internal, err.Error())
+}
+
+// authenticate 认证请求，成功时返回携带调用方身份的context
+// 租户拦截器挂在认证之前时，已解析的租户同样需与调用方身份一致
+func authenticate(ctx context.Context, authenticator auth.Authenticator) (context.Context, error) {
+	identity, err := authenticator.Authenticate(ctx)
+	if err != nil {
+		return ctx, authStatus(err).Err()
+	}
+	ctx = auth.WithIdentity(ctx, identity)
+	if tenantID, ok := TenantIDFromContext(ctx); ok {
+		if _, err := identityTenantID(ctx, tenantID); err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+// incomingContextFromHeaders 将HTTP请求头转换为gRPC incoming metadata，供Authenticator统一读取凭证
+func incomingContextFromHeaders(ctx context.Context, header http.Header) context.Context {
+	md := metadata.MD{}
+	if existing, ok := metadata.FromIncomingContext(ctx); ok {
+		md = existing.Copy()
+	}
+	for key, values := range header {
+		md.Append(strings.ToLower(key), values...)
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// AuthMiddleware Gateway HTTP认证中间件，认证通过后调用方身份写入请求context
+func AuthMiddleware(authenticator auth.Authenticator, config *AuthConfig, logger *zap.Logger) gin.HandlerFunc {
+	if config == nil {
+		config = DefaultAuthConfig()
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		ctx := incomingContextFromHeaders(c.Request.Context(), c.Request.Header)
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			st := status.Convert(err)
+			logger.Debug("Request authentication failed",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("client_ip", c.ClientIP()),
+				zap.Error(err))
+			c.AbortWithStatusJSON(HTTPStatusFromCode(st.Code()), gin.H{
+				"status": "error",
+				"error":  st.Message(),
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// AuthUnaryInterceptor gRPC 一元调用认证拦截器
+func AuthUnaryInterceptor(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthUnary 绑定Auth阶段的认证拦截器
+func AuthUnary(authenticator auth.Authenticator) OrderedUnaryInterceptor {
+	return OrderedUnaryInterceptor{Name: "auth", Stage: StageAuth, Interceptor: AuthUnaryInterceptor(authenticator)}
+}
+
+// AuthStreamInterceptor gRPC 流式调用认证拦截器
+func AuthStreamInterceptor(authenticator auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := authenticate(stream.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// AuthStream 绑定Auth阶段的认证流拦截器
+func AuthStream(authenticator auth.Authenticator) OrderedStreamInterceptor {
+	return OrderedStreamInterceptor{Name: "auth", Stage: StageAuth, Interceptor: AuthStreamInterceptor(authenticator)}
+}
+
+// authServerStream 携带调用方身份context的ServerStream
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回携带调用方身份的context
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"high-go-press/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// stubAuthenticator 测试用认证提供者：token元数据为"valid"时返回固定身份
+var stubAuthenticator = auth.AuthenticatorFunc(func(ctx context.Context) (auth.Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	switch values := md.Get("token"); {
+	case len(values) == 0:
+		return auth.Identity{}, auth.ErrMissingCredentials
+	case values[0] != "valid":
+		return auth.Identity{}, auth.ErrInvalidCredentials
+	}
+	return auth.Identity{Subject: "stub-user", Provider: "stub"}, nil
+})
+
+func newAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AuthMiddleware(stubAuthenticator, DefaultAuthConfig(), zap.NewNop()))
+	router.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/counter/:id", func(c *gin.Context) {
+		identity, _ := auth.IdentityFromContext(c.Request.Context())
+		c.String(http.StatusOK, identity.Subject)
+	})
+	return router
+}
+
+func serveWithToken(router *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Token", token)
+	}
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthMiddlewareWithStubProvider(t *testing.T) {
+	router := newAuthRouter()
+
+	rec := serveWithToken(router, "/api/v1/counter/article_1", "valid")
+	if rec.Code != http.StatusOK || rec.Body.String() != "stub-user" {
+		t.Errorf("Expected identity stub-user in request context, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := serveWithToken(router, "/api/v1/counter/article_1", token); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for token %q, got %d", token, rec.Code)
+		}
+	}
+
+	// 跳过的路径不需要认证
+	if rec := serveWithToken(router, "/livez", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected /livez to skip authentication, got %d", rec.Code)
+	}
+}
+
+func invokeAuthInterceptor(authenticator auth.Authenticator, md metadata.MD) (auth.Identity, error) {
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	var identity auth.Identity
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		identity, _ = auth.IdentityFromContext(ctx)
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/counter.CounterService/IncrementCounter"}
+	_, err := AuthUnaryInterceptor(authenticator)(ctx, nil, info, handler)
+	return identity, err
+}
+
+func TestAuthUnaryInterceptorWithStubProvider(t *testing.T) {
+	identity, err := invokeAuthInterceptor(stubAuthenticator, metadata.Pairs("token", "valid"))
+	if err != nil || identity.Subject != "stub-user" {
+		t.Fatalf("Expected stub-user identity, got %+v, %v", identity, err)
+	}
+
+	for _, md := range []metadata.MD{metadata.Pairs("token", "wrong"), metadata.Pairs()} {
+		if _, err := invokeAuthInterceptor(stubAuthenticator, md); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated, got %v", err)
+		}
+	}
+}
+
+func TestAuthUnaryInterceptorWithAPIKeyProvider(t *testing.T) {
+	authenticator, err := auth.NewAPIKeyAuthenticator(&auth.APIKeyConfig{
+		Keys: []auth.APIKey{{Key: "key-frontend", Subject: "web-frontend"}},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator failed: %v", err)
+	}
+
+	identity, err := invokeAuthInterceptor(authenticator, metadata.Pairs(auth.DefaultAPIKeyHeader, "key-frontend"))
+	if err != nil || identity.Subject != "web-frontend" {
+		t.Errorf("Expected web-frontend identity, got %+v, %v", identity, err)
+	}
+}
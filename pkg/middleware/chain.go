@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+// ErrInterceptorOrder 拦截器链的顺序不符合阶段约定
+var ErrInterceptorOrder = errors.New("invalid interceptor order")
+
+// InterceptorStage 拦截器所属阶段，链中靠前（外层）的拦截器阶段不能大于靠后的拦截器
+//
+// 约定的顺序及原因：
+//   - Recovery 最外层，内层任何拦截器或业务代码panic都能被捕获
+//   - RequestContext 调用方信息、请求ID等，后续拦截器的日志和指标都依赖它
+//   - Admission 元数据大小等廉价的准入检查，被拒绝的请求不进入指标和认证
+//   - Observability 指标和响应大小，包住认证，认证失败也计入请求指标
+//   - Auth 认证和租户，业务代码运行前必须确定调用方身份
+//   - Timeout 最内层，只限制业务处理的耗时
+type InterceptorStage int
+
+const (
+	StageRecovery InterceptorStage = iota
+	StageRequestContext
+	StageAdmission
+	StageObservability
+	StageAuth
+	StageTimeout
+)
+
+// String 阶段名称，用于错误信息
+func (s InterceptorStage) String() string {
+	switch s {
+	case StageRecovery:
+		return "recovery"
+	case StageRequestContext:
+		return "request_context"
+	case StageAdmission:
+		return "admission"
+	case StageObservability:
+		return "observability"
+	case StageAuth:
+		return "auth"
+	case StageTimeout:
+		return "timeout"
+	default:
+		return fmt.Sprintf("stage(%d)", int(s))
+	}
+}
+
+// OrderedUnaryInterceptor 带名称和阶段的一元拦截器
+// 各拦截器的XxxUnary构造函数已绑定所属阶段，调用方无需手动标注
+type OrderedUnaryInterceptor struct {
+	Name        string
+	Stage       InterceptorStage
+	Interceptor grpc.UnaryServerInterceptor
+}
+
+// OrderedStreamInterceptor 带名称和阶段的流拦截器
+type OrderedStreamInterceptor struct {
+	Name        string
+	Stage       InterceptorStage
+	Interceptor grpc.StreamServerInterceptor
+}
+
+// ChainUnaryInterceptors 校验顺序后按给定顺序串联一元拦截器，第一个为最外层
+// 顺序不符合阶段约定时返回ErrInterceptorOrder，服务启动时应直接失败
+func ChainUnaryInterceptors(interceptors ...OrderedUnaryInterceptor) (grpc.ServerOption, error) {
+	names := make([]string, len(interceptors))
+	stages := make([]InterceptorStage, len(interceptors))
+	chain := make([]grpc.UnaryServerInterceptor, len(interceptors))
+	for i, ic := range interceptors {
+		names[i], stages[i], chain[i] = ic.Name, ic.Stage, ic.Interceptor
+	}
+	if err := validateInterceptorOrder("unary", names, stages); err != nil {
+		return nil, err
+	}
+	return grpc.ChainUnaryInterceptor(chain...), nil
+}
+
+// ChainStreamInterceptors 校验顺序后按给定顺序串联流拦截器，第一个为最外层
+// 顺序不符合阶段约定时返回ErrInterceptorOrder，服务启动时应直接失败
+func ChainStreamInterceptors(interceptors ...OrderedStreamInterceptor) (grpc.ServerOption, error) {
+	names := make([]string, len(interceptors))
+	stages := make([]InterceptorStage, len(interceptors))
+	chain := make([]grpc.StreamServerInterceptor, len(interceptors))
+	for i, ic := range interceptors {
+		names[i], stages[i], chain[i] = ic.Name, ic.Stage, ic.Interceptor
+	}
+	if err := validateInterceptorOrder("stream", names, stages); err != nil {
+		return nil, err
+	}
+	return grpc.ChainStreamInterceptor(chain...), nil
+}
+
+// validateInterceptorOrder 检查阶段单调不减，报告第一个放在更后阶段拦截器内层的拦截器
+func validateInterceptorOrder(kind string, names []string, stages []InterceptorStage) error {
+	outer := -1 // 目前阶段最大的拦截器下标
+	for i, stage := range stages {
+		if outer >= 0 && stage < stages[outer] {
+			return fmt.Errorf("%w: %s interceptor %q (%s) is chained inside %q (%s), but %s interceptors must wrap %s interceptors",
+				ErrInterceptorOrder, kind, names[i], stage, names[outer], stages[outer], stage, stages[outer])
+		}
+		if outer < 0 || stage > stages[outer] {
+			outer = i
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// passUnary 直接调用handler的一元拦截器
+func passUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(ctx, req)
+}
+
+// passStream 直接调用handler的流拦截器
+func passStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, ss)
+}
+
+func TestChainInterceptorsRejectsInvalidOrder(t *testing.T) {
+	// 指标放在recovery外层：指标拦截器的panic不会被捕获
+	_, err := ChainUnaryInterceptors(
+		OrderedUnaryInterceptor{Name: "metrics", Stage: StageObservability, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "recovery", Stage: StageRecovery, Interceptor: passUnary},
+	)
+	if !errors.Is(err, ErrInterceptorOrder) {
+		t.Fatalf("Expected ErrInterceptorOrder, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"recovery"`) || !strings.Contains(err.Error(), `"metrics"`) {
+		t.Errorf("Expected error to name both interceptors, got %v", err)
+	}
+
+	// 认证放在超时内层
+	_, err = ChainStreamInterceptors(
+		OrderedStreamInterceptor{Name: "client_info", Stage: StageRequestContext, Interceptor: passStream},
+		OrderedStreamInterceptor{Name: "timeout", Stage: StageTimeout, Interceptor: passStream},
+		OrderedStreamInterceptor{Name: "tenant", Stage: StageAuth, Interceptor: passStream},
+	)
+	if !errors.Is(err, ErrInterceptorOrder) {
+		t.Errorf("Expected ErrInterceptorOrder for auth inside timeout, got %v", err)
+	}
+}
+
+func TestChainInterceptorsAcceptsValidOrder(t *testing.T) {
+	// 同一阶段的多个拦截器可以任意相邻排列
+	opt, err := ChainUnaryInterceptors(
+		OrderedUnaryInterceptor{Name: "recovery", Stage: StageRecovery, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "client_info", Stage: StageRequestContext, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "metadata_limit", Stage: StageAdmission, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "metrics", Stage: StageObservability, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "response_size", Stage: StageObservability, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "auth", Stage: StageAuth, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "tenant", Stage: StageAuth, Interceptor: passUnary},
+		OrderedUnaryInterceptor{Name: "timeout", Stage: StageTimeout, Interceptor: passUnary},
+	)
+	if err != nil || opt == nil {
+		t.Fatalf("Expected valid chain, got %v", err)
+	}
+
+	if _, err := ChainStreamInterceptors(); err != nil {
+		t.Errorf("Expected empty chain to be valid, got %v", err)
+	}
+}
+
+func TestBoundInterceptorStages(t *testing.T) {
+	logger := zap.NewNop()
+	metadataLimit := DefaultMetadataLimitConfig()
+	tenant := &TenantConfig{}
+
+	// 构造函数绑定的阶段保证服务使用的顺序有效
+	if _, err := ChainUnaryInterceptors(
+		ClientInfoUnary(logger),
+		MetadataLimitUnary(metadataLimit),
+		GRPCMetricsUnary(nil, "counter"),
+		ResponseSizeUnary(nil, "counter", DefaultResponseSizeConfig()),
+		TenantUnary(tenant),
+	); err != nil {
+		t.Fatalf("Expected bound interceptors to chain, got %v", err)
+	}
+
+	// 阶段随拦截器绑定，放错位置时启动失败
+	_, err := ChainStreamInterceptors(
+		TenantStream(tenant),
+		ClientInfoStream(logger),
+	)
+	if !errors.Is(err, ErrInterceptorOrder) {
+		t.Fatalf("Expected ErrInterceptorOrder, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"client_info"`) || !strings.Contains(err.Error(), `"tenant"`) {
+		t.Errorf("Expected error to name both interceptors, got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UserAgentMetadataKey 客户端user-agent的gRPC元数据键
+const UserAgentMetadataKey = "user-agent"
+
+// ForwardedForMetadataKey 代理转发的原始客户端地址的gRPC元数据键
+const ForwardedForMetadataKey = "x-forwarded-for"
+
+// clientInfoContextKey 调用方信息的context键
+type clientInfoContextKey struct{}
+
+// ClientInfo 调用方身份信息
+type ClientInfo struct {
+	PeerAddr  string // 对端地址
+	UserAgent string // 客户端user-agent
+}
+
+// WithClientInfo 将调用方信息写入context
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoContextKey{}, info)
+}
+
+// ClientInfoFromContext 获取调用方信息
+// 优先使用拦截器写入的信息，否则直接从gRPC peer和元数据中提取
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	if info, ok := ctx.Value(clientInfoContextKey{}).(ClientInfo); ok {
+		return info
+	}
+	return extractClientInfo(ctx)
+}
+
+// ClientInfoFields 调用方信息的日志字段
+func ClientInfoFields(ctx context.Context) []zap.Field {
+	info := ClientInfoFromContext(ctx)
+	return []zap.Field{
+		zap.String("peer", info.PeerAddr),
+		zap.String("user_agent", info.UserAgent),
+	}
+}
+
+// TrustedProxies 可信代理地址，只有来自可信代理的请求才采用其转发的客户端地址
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// NewTrustedProxies 解析可信代理列表，元素为IP或CIDR
+func NewTrustedProxies(entries []string) (*TrustedProxies, error) {
+	t := &TrustedProxies{prefixes: make([]netip.Prefix, 0, len(entries))}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			t.prefixes = append(t.prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		t.prefixes = append(t.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return t, nil
+}
+
+// Contains 地址是否属于可信代理，nil表示不信任任何代理
+func (t *TrustedProxies) Contains(ip string) bool {
+	if t == nil {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// PeerIP gRPC对端地址的主机部分
+func PeerIP(ctx context.Context) string {
+	addr := ClientInfoFromContext(ctx).PeerAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// ClientIP 获取客户端IP
+// 对端是可信代理时从x-forwarded-for中自右向左跳过可信代理，取第一个不可信的地址；
+// 否则直接使用对端地址，不采用调用方自行填写的x-forwarded-for
+func ClientIP(ctx context.Context, trusted *TrustedProxies) string {
+	peerIP := PeerIP(ctx)
+	if !trusted.Contains(peerIP) {
+		return peerIP
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var chain []string
+	for _, value := range md.Get(ForwardedForMetadataKey) {
+		for _, ip := range strings.Split(value, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				chain = append(chain, ip)
+			}
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !trusted.Contains(chain[i]) {
+			return chain[i]
+		}
+	}
+	if len(chain) > 0 {
+		return chain[0]
+	}
+	return peerIP
+}
+
+// extractClientInfo 从gRPC peer和元数据中提取调用方信息
+func extractClientInfo(ctx context.Context) ClientInfo {
+	var info ClientInfo
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.PeerAddr = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(UserAgentMetadataKey); len(values) > 0 {
+			info.UserAgent = values[0]
+		}
+	}
+	return info
+}
+
+// ClientInfoUnaryInterceptor gRPC 一元调用方信息拦截器
+// 将调用方信息写入context供后续日志使用，logger不为nil时输出访问日志
+func ClientInfoUnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx = WithClientInfo(ctx, extractClientInfo(ctx))
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logAccess(ctx, logger, info.FullMethod, time.Since(start), err)
+		return resp, err
+	}
+}
+
+// ClientInfoUnary 绑定RequestContext阶段的调用方信息拦截器
+func ClientInfoUnary(logger *zap.Logger) OrderedUnaryInterceptor {
+	return OrderedUnaryInterceptor{Name: "client_info", Stage: StageRequestContext, Interceptor: ClientInfoUnaryInterceptor(logger)}
+}
+
+// ClientInfoStreamInterceptor gRPC 流式调用方信息拦截器
+func ClientInfoStreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := WithClientInfo(stream.Context(), extractClientInfo(stream.Context()))
+
+		start := time.Now()
+		err := handler(srv, &clientInfoServerStream{ServerStream: stream, ctx: ctx})
+		logAccess(ctx, logger, info.FullMethod, time.Since(start), err)
+		return err
+	}
+}
+
+// ClientInfoStream 绑定RequestContext阶段的调用方信息流拦截器
+func ClientInfoStream(logger *zap.Logger) OrderedStreamInterceptor {
+	return OrderedStreamInterceptor{Name: "client_info", Stage: StageRequestContext, Interceptor: ClientInfoStreamInterceptor(logger)}
+}
+
+// logAccess 输出携带调用方信息的访问日志，成功请求为Debug级别，失败请求为Warn级别
+func logAccess(ctx context.Context, logger *zap.Logger, method string, duration time.Duration, err error) {
+	if logger == nil {
+		return
+	}
+
+	fields := append([]zap.Field{
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", duration),
+	}, ClientInfoFields(ctx)...)
+
+	if err != nil {
+		logger.Warn("gRPC request failed", append(fields, zap.Error(err))...)
+		return
+	}
+	logger.Debug("gRPC request", fields...)
+}
+
+// clientInfoServerStream 携带调用方信息context的ServerStream
+type clientInfoServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回携带调用方信息的context
+func (s *clientInfoServerStream) Context() context.Context {
+	return s.ctx
+}
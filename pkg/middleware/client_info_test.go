@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestClientInfoInterceptorCapturesPeer(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	// 在调用方信息拦截器之后记录handler看到的context
+	var captured ClientInfo
+	capture := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		captured = ClientInfoFromContext(ctx)
+		return handler(ctx, req)
+	}
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(ClientInfoUnaryInterceptor(zap.New(core)), capture))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUserAgent("counter-admin/1.0"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+
+	if captured.PeerAddr != "bufconn" {
+		t.Errorf("Expected peer address bufconn, got %q", captured.PeerAddr)
+	}
+	if !strings.HasPrefix(captured.UserAgent, "counter-admin/1.0") {
+		t.Errorf("Expected user-agent to start with counter-admin/1.0, got %q", captured.UserAgent)
+	}
+
+	// 访问日志包含调用方信息
+	entries := logs.FilterMessage("gRPC request").All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 access log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["peer"] != "bufconn" {
+		t.Errorf("Expected peer field bufconn, got %v", fields["peer"])
+	}
+	if ua, _ := fields["user_agent"].(string); !strings.HasPrefix(ua, "counter-admin/1.0") {
+		t.Errorf("Expected user_agent field, got %v", fields["user_agent"])
+	}
+	if fields["method"] != "/grpc.health.v1.Health/Check" {
+		t.Errorf("Expected method field, got %v", fields["method"])
+	}
+}
+
+func TestClientInfoFromContextWithoutInterceptor(t *testing.T) {
+	// 没有经过拦截器也没有peer信息时返回空值
+	info := ClientInfoFromContext(context.Background())
+	if info.PeerAddr != "" || info.UserAgent != "" {
+		t.Errorf("Expected empty client info, got %+v", info)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.8"), Port: 52100},
+	})
+	trusted, err := NewTrustedProxies([]string{"10.0.0.0/24", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("NewTrustedProxies failed: %v", err)
+	}
+	forwarded := func(value string) context.Context {
+		return metadata.NewIncomingContext(peerCtx, metadata.Pairs(ForwardedForMetadataKey, value))
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		trusted *TrustedProxies
+		want    string
+	}{
+		{name: "peer host without port", ctx: peerCtx, trusted: trusted, want: "10.0.0.8"},
+		{name: "forwarded from untrusted peer ignored", ctx: forwarded("203.0.113.7"), trusted: nil, want: "10.0.0.8"},
+		{name: "forwarded from trusted peer", ctx: forwarded(" 203.0.113.7 , 10.0.0.1"), trusted: trusted, want: "203.0.113.7"},
+		// 客户端伪造的最左地址不被采用，取最右的不可信地址
+		{name: "spoofed leftmost address", ctx: forwarded("1.2.3.4, 203.0.113.7, 192.0.2.1"), trusted: trusted, want: "203.0.113.7"},
+		{name: "all hops trusted", ctx: forwarded("10.0.0.2, 192.0.2.1"), trusted: trusted, want: "10.0.0.2"},
+		{name: "no peer", ctx: context.Background(), trusted: trusted, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientIP(tt.ctx, tt.trusted); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTrustedProxies(t *testing.T) {
+	if _, err := NewTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected error for invalid proxy address")
+	}
+	trusted, err := NewTrustedProxies([]string{"2001:db8::/32", "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("NewTrustedProxies failed: %v", err)
+	}
+	if !trusted.Contains("2001:db8::1") || !trusted.Contains("::ffff:127.0.0.1") || trusted.Contains("127.0.0.2") {
+		t.Error("Unexpected trusted proxy matching")
+	}
+	var none *TrustedProxies
+	if none.Contains("127.0.0.1") {
+		t.Error("Expected nil TrustedProxies to trust nothing")
+	}
+}
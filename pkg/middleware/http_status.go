@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusClientClosedRequest 客户端取消请求时使用的非标准状态码（同nginx）
+const StatusClientClosedRequest = 499
+
+// grpcHTTPStatus gRPC状态码到HTTP状态码的映射
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           StatusClientClosedRequest,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatusFromCode 将gRPC状态码转换为HTTP状态码，未知状态码返回500
+func HTTPStatusFromCode(code codes.Code) int {
+	if httpStatus, ok := grpcHTTPStatus[code]; ok {
+		return httpStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// HTTPStatusFromError 根据gRPC调用返回的错误选择HTTP状态码，非gRPC错误返回500
+func HTTPStatusFromError(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return HTTPStatusFromCode(status.Code(err))
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.OK, http.StatusOK},
+		{codes.Canceled, StatusClientClosedRequest},
+		{codes.Unknown, http.StatusInternalServerError},
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{codes.NotFound, http.StatusNotFound},
+		{codes.AlreadyExists, http.StatusConflict},
+		{codes.PermissionDenied, http.StatusForbidden},
+		{codes.ResourceExhausted, http.StatusTooManyRequests},
+		{codes.FailedPrecondition, http.StatusBadRequest},
+		{codes.Aborted, http.StatusConflict},
+		{codes.OutOfRange, http.StatusBadRequest},
+		{codes.Unimplemented, http.StatusNotImplemented},
+		{codes.Internal, http.StatusInternalServerError},
+		{codes.Unavailable, http.StatusServiceUnavailable},
+		{codes.DataLoss, http.StatusInternalServerError},
+		{codes.Unauthenticated, http.StatusUnauthorized},
+		{codes.Code(99), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := HTTPStatusFromCode(tt.code); got != tt.want {
+			t.Errorf("HTTPStatusFromCode(%v) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPStatusFromError(t *testing.T) {
+	if got := HTTPStatusFromError(status.Error(codes.InvalidArgument, "bad delta")); got != http.StatusBadRequest {
+		t.Errorf("Expected 400 for InvalidArgument, got %d", got)
+	}
+	// 非gRPC错误按Unknown处理
+	if got := HTTPStatusFromError(errors.New("boom")); got != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for plain error, got %d", got)
+	}
+	if got := HTTPStatusFromError(nil); got != http.StatusOK {
+		t.Errorf("Expected 200 for nil error, got %d", got)
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// KeepaliveConfig 服务端keepalive配置
+type KeepaliveConfig struct {
+	Time    time.Duration // 连接空闲多久后服务端主动ping客户端
+	Timeout time.Duration // 等待ping响应的超时，超时后关闭连接
+	// MinTime 客户端ping的最小间隔，更频繁的ping累计违规后服务端以GOAWAY(too_many_pings)断开连接；
+	// 必须不大于所有客户端配置的keepalive间隔
+	MinTime time.Duration
+	// PermitWithoutStream 允许客户端在没有活跃流时ping，需与客户端的PermitWithoutStream一致
+	PermitWithoutStream bool
+}
+
+// DefaultKeepaliveConfig 默认keepalive配置：与Gateway连接池(30s)和服务发现连接(10s)的客户端ping间隔兼容
+func DefaultKeepaliveConfig() *KeepaliveConfig {
+	return &KeepaliveConfig{
+		Time:                60 * time.Second,
+		Timeout:             10 * time.Second,
+		MinTime:             5 * time.Second,
+		PermitWithoutStream: true,
+	}
+}
+
+// KeepaliveServerOptions 根据配置生成服务端keepalive参数和客户端ping约束
+func KeepaliveServerOptions(config *KeepaliveConfig) []grpc.ServerOption {
+	if config == nil {
+		config = DefaultKeepaliveConfig()
+	}
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    config.Time,
+			Timeout: config.Timeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             config.MinTime,
+			PermitWithoutStream: config.PermitWithoutStream,
+		}),
+	}
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startKeepaliveServer 启动使用指定keepalive配置的gRPC服务
+func startKeepaliveServer(t *testing.T, config *KeepaliveConfig) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer(KeepaliveServerOptions(config)...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// rawClient 直接收发HTTP/2帧，用于以任意频率发送ping
+type rawClient struct {
+	conn    net.Conn
+	framer  *http2.Framer
+	writeMu sync.Mutex
+	pingAck chan struct{}
+	goAway  chan http2.ErrCode
+}
+
+func dialRawClient(t *testing.T, addr string) *rawClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &rawClient{
+		conn:    conn,
+		framer:  http2.NewFramer(conn, conn),
+		pingAck: make(chan struct{}, 16),
+		goAway:  make(chan http2.ErrCode, 1),
+	}
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatalf("Failed to write preface: %v", err)
+	}
+	c.write(t, func(f *http2.Framer) error { return f.WriteSettings() })
+
+	go c.readLoop()
+	return c
+}
+
+func (c *rawClient) write(t *testing.T, fn func(*http2.Framer) error) {
+	t.Helper()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := fn(c.framer); err != nil {
+		t.Fatalf("Failed to write frame: %v", err)
+	}
+}
+
+func (c *rawClient) readLoop() {
+	for {
+		frame, err := c.framer.ReadFrame()
+		if err != nil {
+			return
+		}
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				c.writeMu.Lock()
+				c.framer.WriteSettingsAck()
+				c.writeMu.Unlock()
+			}
+		case *http2.PingFrame:
+			if f.IsAck() {
+				c.pingAck <- struct{}{}
+			}
+		case *http2.GoAwayFrame:
+			c.goAway <- f.ErrCode
+			return
+		}
+	}
+}
+
+func (c *rawClient) ping(t *testing.T) {
+	t.Helper()
+	c.write(t, func(f *http2.Framer) error { return f.WritePing(false, [8]byte{1}) })
+}
+
+func TestKeepalivePolicyAllowsClientWithinMinTime(t *testing.T) {
+	const minTime = 100 * time.Millisecond
+	addr := startKeepaliveServer(t, &KeepaliveConfig{MinTime: minTime, PermitWithoutStream: true})
+	client := dialRawClient(t, addr)
+
+	// 按不小于MinTime的间隔ping，每次都收到响应且连接保持
+	for i := 0; i < 4; i++ {
+		client.ping(t)
+		select {
+		case <-client.pingAck:
+		case code := <-client.goAway:
+			t.Fatalf("Expected connection kept open, got GOAWAY %v after %d pings", code, i+1)
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for ping ack %d", i+1)
+		}
+		time.Sleep(minTime * 3 / 2)
+	}
+
+	select {
+	case code := <-client.goAway:
+		t.Fatalf("Expected connection kept open, got GOAWAY %v", code)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestKeepalivePolicyRejectsAggressiveClient(t *testing.T) {
+	addr := startKeepaliveServer(t, &KeepaliveConfig{MinTime: time.Minute, PermitWithoutStream: true})
+	client := dialRawClient(t, addr)
+
+	// 连续ping远小于MinTime，累计违规后服务端发送GOAWAY(ENHANCE_YOUR_CALM)
+	for i := 0; i < 5; i++ {
+		client.ping(t)
+	}
+
+	select {
+	case code := <-client.goAway:
+		if code != http2.ErrCodeEnhanceYourCalm {
+			t.Errorf("Expected GOAWAY ENHANCE_YOUR_CALM, got %v", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected aggressive client to receive GOAWAY")
+	}
+}
+
+func TestKeepalivePolicyRejectsPingsWithoutStreamWhenNotPermitted(t *testing.T) {
+	addr := startKeepaliveServer(t, &KeepaliveConfig{MinTime: time.Millisecond, PermitWithoutStream: false})
+	client := dialRawClient(t, addr)
+
+	// 没有活跃流时不允许ping，即使间隔满足MinTime
+	for i := 0; i < 5; i++ {
+		client.ping(t)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case code := <-client.goAway:
+		if code != http2.ErrCodeEnhanceYourCalm {
+			t.Errorf("Expected GOAWAY ENHANCE_YOUR_CALM, got %v", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected idle pings to be rejected when not permitted")
+	}
+}
+
+func TestDefaultKeepaliveConfigMatchesClients(t *testing.T) {
+	config := DefaultKeepaliveConfig()
+
+	// Gateway连接池每30s、服务发现连接每10s在无活跃流时ping
+	for _, clientInterval := range []time.Duration{30 * time.Second, 10 * time.Second} {
+		if config.MinTime > clientInterval {
+			t.Errorf("MinTime %v rejects client pinging every %v", config.MinTime, clientInterval)
+		}
+	}
+	if !config.PermitWithoutStream {
+		t.Error("Expected PermitWithoutStream, clients ping without active streams")
+	}
+}
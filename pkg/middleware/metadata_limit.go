@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataLimitConfig 请求元数据限制配置
+type MetadataLimitConfig struct {
+	MaxSize         int      // 元数据键和值的总字节数上限，0表示不限制
+	DisallowedKeys  []string // 禁止携带的元数据键
+	StripDisallowed bool     // 为true时移除禁止的键后继续处理，否则拒绝请求
+}
+
+// DefaultMetadataLimitConfig 默认元数据限制：总大小不超过8KB
+func DefaultMetadataLimitConfig() *MetadataLimitConfig {
+	return &MetadataLimitConfig{
+		MaxSize: 8 * 1024,
+	}
+}
+
+// metadataSize 计算元数据键和值的总字节数
+func metadataSize(md metadata.MD) int {
+	size := 0
+	for key, values := range md {
+		for _, value := range values {
+			size += len(key) + len(value)
+		}
+	}
+	return size
+}
+
+// checkMetadata 校验请求元数据，返回可能移除了禁止键的context
+func checkMetadata(ctx context.Context, config *MetadataLimitConfig) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || config == nil {
+		return ctx, nil
+	}
+
+	var stripped metadata.MD
+	for _, key := range config.DisallowedKeys {
+		key = strings.ToLower(key)
+		if _, exists := md[key]; !exists {
+			continue
+		}
+		if !config.StripDisallowed {
+			return ctx, status.Errorf(codes.InvalidArgument, "metadata key %q is not allowed", key)
+		}
+		if stripped == nil {
+			stripped = md.Copy()
+		}
+		delete(stripped, key)
+	}
+	if stripped != nil {
+		md = stripped
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	if config.MaxSize > 0 {
+		if size := metadataSize(md); size > config.MaxSize {
+			return ctx, status.Errorf(codes.InvalidArgument, "metadata size %d bytes exceeds limit of %d bytes", size, config.MaxSize)
+		}
+	}
+	return ctx, nil
+}
+
+// MetadataLimitUnaryInterceptor gRPC 一元调用元数据限制拦截器
+func MetadataLimitUnaryInterceptor(config *MetadataLimitConfig) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := checkMetadata(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// MetadataLimitUnary 绑定Admission阶段的元数据限制拦截器
+func MetadataLimitUnary(config *MetadataLimitConfig) OrderedUnaryInterceptor {
+	return OrderedUnaryInterceptor{Name: "metadata_limit", Stage: StageAdmission, Interceptor: MetadataLimitUnaryInterceptor(config)}
+}
+
+// MetadataLimitStreamInterceptor gRPC 流式调用元数据限制拦截器
+func MetadataLimitStreamInterceptor(config *MetadataLimitConfig) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := checkMetadata(stream.Context(), config)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &metadataServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// MetadataLimitStream 绑定Admission阶段的元数据限制流拦截器
+func MetadataLimitStream(config *MetadataLimitConfig) OrderedStreamInterceptor {
+	return OrderedStreamInterceptor{Name: "metadata_limit", Stage: StageAdmission, Interceptor: MetadataLimitStreamInterceptor(config)}
+}
+
+// metadataServerStream 携带校验后元数据context的ServerStream
+type metadataServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回校验后的context
+func (s *metadataServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func invokeMetadataLimitInterceptor(t *testing.T, config *MetadataLimitConfig, md metadata.MD) (metadata.MD, error) {
+	t.Helper()
+
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	var seen metadata.MD
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		seen, _ = metadata.FromIncomingContext(ctx)
+		return "ok", nil
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/counter.CounterService/IncrementCounter"}
+	_, err := MetadataLimitUnaryInterceptor(config)(ctx, nil, info, handler)
+	return seen, err
+}
+
+func TestMetadataLimitRejectsOversized(t *testing.T) {
+	config := &MetadataLimitConfig{MaxSize: 1024}
+
+	_, err := invokeMetadataLimitInterceptor(t, config,
+		metadata.Pairs("x-padding", strings.Repeat("a", 2048)))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument for oversized metadata, got %v", err)
+	}
+
+	// 多个值累计超过上限同样拒绝
+	_, err = invokeMetadataLimitInterceptor(t, config,
+		metadata.Pairs("x-a", strings.Repeat("a", 600), "x-a", strings.Repeat("b", 600)))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for accumulated oversized metadata, got %v", err)
+	}
+}
+
+func TestMetadataLimitAllowsNormal(t *testing.T) {
+	seen, err := invokeMetadataLimitInterceptor(t, DefaultMetadataLimitConfig(),
+		metadata.Pairs(TenantMetadataKey, "acme", UserAgentMetadataKey, "counter-admin/1.0"))
+	if err != nil {
+		t.Fatalf("Expected normal metadata to pass, got %v", err)
+	}
+	if got := seen.Get(TenantMetadataKey); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("Expected metadata passed through, got %v", seen)
+	}
+}
+
+func TestMetadataLimitDisallowedKeys(t *testing.T) {
+	md := metadata.Pairs("x-debug-dump", "1", TenantMetadataKey, "acme")
+
+	_, err := invokeMetadataLimitInterceptor(t, &MetadataLimitConfig{DisallowedKeys: []string{"X-Debug-Dump"}}, md)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument for disallowed key, got %v", err)
+	}
+
+	// 开启移除后请求继续处理，handler看不到被移除的键
+	seen, err := invokeMetadataLimitInterceptor(t,
+		&MetadataLimitConfig{DisallowedKeys: []string{"x-debug-dump"}, StripDisallowed: true}, md)
+	if err != nil {
+		t.Fatalf("Expected stripped request to pass, got %v", err)
+	}
+	if len(seen.Get("x-debug-dump")) != 0 || len(seen.Get(TenantMetadataKey)) != 1 {
+		t.Errorf("Expected only disallowed key stripped, got %v", seen)
+	}
+	if len(md.Get("x-debug-dump")) != 1 {
+		t.Errorf("Expected original metadata untouched, got %v", md)
+	}
+}
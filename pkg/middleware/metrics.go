@@ -13,9 +13,40 @@ import (
 	"high-go-press/pkg/metrics"
 )
 
+// HTTPMetricsConfig HTTP 指标中间件配置
+type HTTPMetricsConfig struct {
+	// ExcludePaths 不采集指标的请求路径，避免Prometheus抓取和健康检查轮询干扰请求统计
+	ExcludePaths []string
+}
+
+// DefaultHTTPMetricsConfig 默认配置：排除/metrics和/health
+func DefaultHTTPMetricsConfig() *HTTPMetricsConfig {
+	return &HTTPMetricsConfig{
+		ExcludePaths: []string{"/metrics", "/health"},
+	}
+}
+
 // HTTPMetricsMiddleware HTTP 指标收集中间件
 func HTTPMetricsMiddleware(metricsManager *metrics.MetricsManager, serviceName string) gin.HandlerFunc {
+	return HTTPMetricsMiddlewareWithConfig(metricsManager, serviceName, &HTTPMetricsConfig{})
+}
+
+// HTTPMetricsMiddlewareWithConfig HTTP 指标收集中间件，跳过配置中排除的路径
+func HTTPMetricsMiddlewareWithConfig(metricsManager *metrics.MetricsManager, serviceName string, config *HTTPMetricsConfig) gin.HandlerFunc {
+	if config == nil {
+		config = DefaultHTTPMetricsConfig()
+	}
+	excluded := make(map[string]struct{}, len(config.ExcludePaths))
+	for _, path := range config.ExcludePaths {
+		excluded[path] = struct{}{}
+	}
+
 	return func(c *gin.Context) {
+		if _, skip := excluded[c.Request.URL.Path]; skip {
+			c.Next()
+			return
+		}
+
 		start := time.Now()
 
 		// 增加正在处理的请求数
@@ -78,6 +109,11 @@ func GRPCMetricsUnaryInterceptor(metricsManager *metrics.MetricsManager, service
 	}
 }
 
+// GRPCMetricsUnary 绑定Observability阶段的gRPC指标拦截器
+func GRPCMetricsUnary(metricsManager *metrics.MetricsManager, serviceName string) OrderedUnaryInterceptor {
+	return OrderedUnaryInterceptor{Name: "metrics", Stage: StageObservability, Interceptor: GRPCMetricsUnaryInterceptor(metricsManager, serviceName)}
+}
+
 // GRPCMetricsStreamInterceptor gRPC 流式调用指标收集拦截器
 func GRPCMetricsStreamInterceptor(metricsManager *metrics.MetricsManager, serviceName string) grpc.StreamServerInterceptor {
 	return func(
@@ -117,6 +153,11 @@ func GRPCMetricsStreamInterceptor(metricsManager *metrics.MetricsManager, servic
 	}
 }
 
+// GRPCMetricsStream 绑定Observability阶段的gRPC指标流拦截器
+func GRPCMetricsStream(metricsManager *metrics.MetricsManager, serviceName string) OrderedStreamInterceptor {
+	return OrderedStreamInterceptor{Name: "metrics", Stage: StageObservability, Interceptor: GRPCMetricsStreamInterceptor(metricsManager, serviceName)}
+}
+
 // BusinessMetricsWrapper 业务操作指标包装器
 type BusinessMetricsWrapper struct {
 	metricsManager *metrics.MetricsManager
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"high-go-press/pkg/metrics"
+)
+
+// newMetricsRouter 创建挂载指标中间件和监控端点的路由
+func newMetricsRouter(mm *metrics.MetricsManager, config *HTTPMetricsConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HTTPMetricsMiddlewareWithConfig(mm, "counter", config))
+	router.GET("/metrics", gin.WrapH(mm.GetHandler()))
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/status", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func serve(router *gin.Engine, path string) {
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+}
+
+func TestHTTPMetricsMiddlewareExcludesPaths(t *testing.T) {
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	router := newMetricsRouter(mm, DefaultHTTPMetricsConfig())
+
+	// 抓取和健康检查不计入请求统计
+	for i := 0; i < 3; i++ {
+		serve(router, "/metrics")
+		serve(router, "/health")
+	}
+	if got := mm.GetRequestTotals("counter").HTTPRequests; got != 0 {
+		t.Fatalf("Expected excluded paths not counted, got %d requests", got)
+	}
+
+	// 其他路径正常计数
+	serve(router, "/status")
+	if got := mm.GetRequestTotals("counter").HTTPRequests; got != 1 {
+		t.Errorf("Expected /status counted once, got %d requests", got)
+	}
+}
+
+func TestHTTPMetricsMiddlewareCountsAllWithoutExclusions(t *testing.T) {
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	router := newMetricsRouter(mm, &HTTPMetricsConfig{})
+
+	serve(router, "/metrics")
+	serve(router, "/health")
+	if got := mm.GetRequestTotals("counter").HTTPRequests; got != 2 {
+		t.Errorf("Expected all paths counted without exclusions, got %d requests", got)
+	}
+}
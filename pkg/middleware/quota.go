@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"high-go-press/pkg/auth"
+	"high-go-press/pkg/quota"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// consumeQuota 为已认证的调用方消耗一次配额
+// 未认证的请求不受配额限制；配额存储故障时放行，避免配额服务成为单点
+func consumeQuota(ctx context.Context, service *quota.Service, logger *zap.Logger) (quota.Usage, error) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		return quota.Usage{Unlimited: true}, nil
+	}
+
+	usage, err := service.Consume(ctx, identity, 1)
+	if err != nil && !errors.Is(err, quota.ErrQuotaExceeded) {
+		logger.Warn("Quota check failed, allowing request",
+			zap.String("subject", identity.Subject),
+			zap.Error(err))
+		return quota.Usage{Unlimited: true}, nil
+	}
+	return usage, err
+}
+
+// refundQuota 请求失败时退还consumeQuota消耗的配额，退还失败只记录日志
+func refundQuota(ctx context.Context, service *quota.Service, usage quota.Usage, logger *zap.Logger) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok || usage.Unlimited {
+		return
+	}
+	if err := service.Refund(ctx, identity, usage, 1); err != nil {
+		logger.Warn("Quota refund failed",
+			zap.String("subject", identity.Subject),
+			zap.Error(err))
+	}
+}
+
+// QuotaMiddleware Gateway HTTP每日配额中间件，需挂在认证中间件之后
+// 响应头携带X-Quota-Limit/X-Quota-Remaining/X-Quota-Reset，超出配额返回429
+// 后续处理返回4xx/5xx时退还本次配额，失败的写入不计入用量
+func QuotaMiddleware(service *quota.Service, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		usage, err := consumeQuota(c.Request.Context(), service, logger)
+		for key, value := range usage.Headers() {
+			c.Header(key, value)
+		}
+
+		if err != nil {
+			retryAfter := int(time.Until(usage.ResetAt).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"status": "error",
+				"error":  "Daily quota exceeded",
+				"tier":   usage.Tier,
+				"limit":  usage.Limit,
+			})
+			return
+		}
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			refundQuota(c.Request.Context(), service, usage, logger)
+		}
+	}
+}
+
+// QuotaUnaryInterceptor gRPC 一元调用每日配额拦截器，只对methods中的方法生效，每次调用消耗一次配额
+// 配额信息通过响应header元数据返回，超出配额返回ResourceExhausted，处理失败时退还本次配额
+func QuotaUnaryInterceptor(service *quota.Service, methods []string, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	limited := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		limited[method] = true
+	}
+
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !limited[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		usage, err := consumeQuota(ctx, service, logger)
+		if headers := usage.Headers(); len(headers) > 0 {
+			// 拦截器在非gRPC调用链中（如单元测试）没有stream，忽略设置失败
+			_ = grpc.SetHeader(ctx, metadata.New(headers))
+		}
+		if err != nil {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			refundQuota(ctx, service, usage, logger)
+		}
+		return resp, err
+	}
+}
+
+// QuotaUnary 绑定Auth阶段的配额拦截器，需排在认证之后以获取调用方身份
+func QuotaUnary(service *quota.Service, methods []string, logger *zap.Logger) OrderedUnaryInterceptor {
+	return OrderedUnaryInterceptor{Name: "quota", Stage: StageAuth, Interceptor: QuotaUnaryInterceptor(service, methods, logger)}
+}
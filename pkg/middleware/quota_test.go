@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"high-go-press/pkg/auth"
+	"high-go-press/pkg/quota"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newQuotaService 基于miniredis的配额服务，配额脚本在真实的Lua环境中执行
+func newQuotaService(t *testing.T, limit int64) *quota.Service {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return quota.NewService(client, &quota.Config{
+		Tiers:       map[string]int64{"default": limit},
+		DefaultTier: "default",
+	}, zap.NewNop())
+}
+
+func newQuotaRouter(t *testing.T, limit int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AuthMiddleware(stubAuthenticator, DefaultAuthConfig(), zap.NewNop()))
+	router.GET("/api/v1/counter/:id", QuotaMiddleware(newQuotaService(t, limit), zap.NewNop()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestQuotaMiddlewareUnderAndOverQuota(t *testing.T) {
+	router := newQuotaRouter(t, 2)
+
+	for i, remaining := range []string{"1", "0"} {
+		rec := serveWithToken(router, "/api/v1/counter/article_1", "valid")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200 under quota, got %d", i+1, rec.Code)
+		}
+		if got := rec.Header().Get("X-Quota-Remaining"); got != remaining {
+			t.Errorf("Request %d: expected X-Quota-Remaining %s, got %q", i+1, remaining, got)
+		}
+		if rec.Header().Get("X-Quota-Limit") != "2" || rec.Header().Get("X-Quota-Reset") == "" {
+			t.Errorf("Request %d: expected quota headers, got %v", i+1, rec.Header())
+		}
+	}
+
+	rec := serveWithToken(router, "/api/v1/counter/article_1", "valid")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 over quota, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected Retry-After header when over quota")
+	}
+}
+
+func TestQuotaMiddlewareWithoutIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/counter/:id", QuotaMiddleware(newQuotaService(t, 1), zap.NewNop()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// 未认证的请求不受配额限制
+	for i := 0; i < 3; i++ {
+		if rec := serveWithToken(router, "/api/v1/counter/article_1", ""); rec.Code != http.StatusOK {
+			t.Fatalf("Expected request without identity to pass, got %d", rec.Code)
+		}
+	}
+}
+
+func TestQuotaUnaryInterceptor(t *testing.T) {
+	const method = "/counter.CounterService/IncrementCounter"
+	interceptor := QuotaUnaryInterceptor(newQuotaService(t, 1), []string{method}, zap.NewNop())
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Subject: "svc"})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler); err != nil {
+		t.Fatalf("Expected first call under quota, got %v", err)
+	}
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted over quota, got %v", err)
+	}
+
+	// 未列出的方法不消耗配额
+	if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/counter.CounterService/GetCounter"}, handler); err != nil {
+		t.Errorf("Expected unlisted method to bypass quota, got %v", err)
+	}
+}
+
+func TestQuotaRefundedOnFailure(t *testing.T) {
+	const method = "/counter.CounterService/IncrementCounter"
+	interceptor := QuotaUnaryInterceptor(newQuotaService(t, 1), []string{method}, zap.NewNop())
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Subject: "svc"})
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "redis down")
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	// 写入失败的调用退还配额，不占用当日用量
+	for i := 0; i < 3; i++ {
+		if _, err := interceptor(ctx, nil, info, failing); status.Code(err) != codes.Unavailable {
+			t.Fatalf("Call %d: expected handler error, got %v", i+1, err)
+		}
+	}
+	if _, err := interceptor(ctx, nil, info, handler); err != nil {
+		t.Fatalf("Expected quota to be refunded after failures, got %v", err)
+	}
+	if _, err := interceptor(ctx, nil, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted after successful call, got %v", err)
+	}
+}
+
+func TestQuotaMiddlewareRefundsFailedRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AuthMiddleware(stubAuthenticator, DefaultAuthConfig(), zap.NewNop()))
+	failed := true
+	router.GET("/api/v1/counter/:id", QuotaMiddleware(newQuotaService(t, 1), zap.NewNop()), func(c *gin.Context) {
+		if failed {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	if rec := serveWithToken(router, "/api/v1/counter/article_1", "valid"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected failing handler to return 500, got %d", rec.Code)
+	}
+	failed = false
+	if rec := serveWithToken(router, "/api/v1/counter/article_1", "valid"); rec.Code != http.StatusOK {
+		t.Errorf("Expected refunded quota to allow next request, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReadinessGateConfig 启动就绪门配置
+type ReadinessGateConfig struct {
+	Timeout      time.Duration // 等待依赖就绪的最长时间，超时后仍放行全部路由
+	PollInterval time.Duration // 检查依赖是否就绪的间隔
+	LivenessPath string        // 就绪前也正常响应的存活检查路径
+}
+
+// DefaultReadinessGateConfig 默认就绪门配置
+func DefaultReadinessGateConfig() *ReadinessGateConfig {
+	return &ReadinessGateConfig{
+		Timeout:      30 * time.Second,
+		PollInterval: 500 * time.Millisecond,
+		LivenessPath: "/livez",
+	}
+}
+
+// ReadinessGate 启动就绪门：依赖服务就绪前除存活检查外的请求（包括健康检查）都返回503，
+// 避免端口已监听但下游连接尚未建立时早到的请求失败
+type ReadinessGate struct {
+	config *ReadinessGateConfig
+	ready  atomic.Bool
+	logger *zap.Logger
+}
+
+// NewReadinessGate 创建就绪门，初始为未就绪
+func NewReadinessGate(config *ReadinessGateConfig, logger *zap.Logger) *ReadinessGate {
+	defaults := DefaultReadinessGateConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.LivenessPath == "" {
+		cfg.LivenessPath = defaults.LivenessPath
+	}
+	return &ReadinessGate{config: &cfg, logger: logger}
+}
+
+// Ready 是否已放行全部路由
+func (g *ReadinessGate) Ready() bool {
+	return g.ready.Load()
+}
+
+// MarkReady 放行全部路由
+func (g *ReadinessGate) MarkReady() {
+	g.ready.Store(true)
+}
+
+// Wait 按间隔检查依赖直到就绪或超时，之后放行全部路由；返回依赖是否在超时前就绪
+func (g *ReadinessGate) Wait(ctx context.Context, check func() bool) bool {
+	defer g.MarkReady()
+
+	timer := time.NewTimer(g.config.Timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(g.config.PollInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		if check() {
+			g.logger.Info("Dependencies ready, enabling all routes",
+				zap.Duration("waited", time.Since(start)))
+			return true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			g.logger.Warn("Dependencies not ready before timeout, enabling all routes anyway",
+				zap.Duration("timeout", g.config.Timeout))
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// Middleware 就绪前拦截除存活检查外的所有请求
+func (g *ReadinessGate) Middleware() gin.HandlerFunc {
+	retryAfter := strconv.Itoa(int(g.config.PollInterval.Seconds()) + 1)
+
+	return func(c *gin.Context) {
+		if g.ready.Load() || c.Request.URL.Path == g.config.LivenessPath {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", retryAfter)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"status": "starting",
+			"error":  "Waiting for backend services to become ready",
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// newGatedRouter 创建挂载就绪门的路由
+func newGatedRouter(gate *ReadinessGate) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gate.Middleware())
+	router.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/counter/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func statusOf(router *gin.Engine, path string) int {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	return rec.Code
+}
+
+func TestReadinessGateBlocksUntilReady(t *testing.T) {
+	gate := NewReadinessGate(&ReadinessGateConfig{
+		Timeout:      5 * time.Second,
+		PollInterval: 5 * time.Millisecond,
+	}, zap.NewNop())
+	router := newGatedRouter(gate)
+
+	var counterReady atomic.Bool
+	done := make(chan bool, 1)
+	go func() { done <- gate.Wait(context.Background(), counterReady.Load) }()
+
+	// 就绪前只有存活检查可用
+	for _, path := range []string{"/health", "/api/v1/counter/article_1"} {
+		if code := statusOf(router, path); code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503 for %s before ready, got %d", path, code)
+		}
+	}
+	if code := statusOf(router, "/livez"); code != http.StatusOK {
+		t.Errorf("Expected /livez 200 before ready, got %d", code)
+	}
+
+	counterReady.Store(true)
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("Expected Wait to report dependencies ready")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for readiness gate")
+	}
+
+	for _, path := range []string{"/health", "/api/v1/counter/article_1", "/livez"} {
+		if code := statusOf(router, path); code != http.StatusOK {
+			t.Errorf("Expected 200 for %s after ready, got %d", path, code)
+		}
+	}
+}
+
+func TestReadinessGateOpensAfterTimeout(t *testing.T) {
+	gate := NewReadinessGate(&ReadinessGateConfig{
+		Timeout:      50 * time.Millisecond,
+		PollInterval: 5 * time.Millisecond,
+	}, zap.NewNop())
+	router := newGatedRouter(gate)
+
+	// 依赖始终未就绪，超时后仍放行
+	if gate.Wait(context.Background(), func() bool { return false }) {
+		t.Error("Expected Wait to report timeout")
+	}
+	if code := statusOf(router, "/api/v1/counter/article_1"); code != http.StatusOK {
+		t.Errorf("Expected routes enabled after timeout, got %d", code)
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+
+	"high-go-press/pkg/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ResponseSizeConfig gRPC响应大小配置
+type ResponseSizeConfig struct {
+	MaxSize int // 单个响应序列化后的字节数上限，0表示不限制
+}
+
+// DefaultResponseSizeConfig 默认只记录响应大小，不限制
+func DefaultResponseSizeConfig() *ResponseSizeConfig {
+	return &ResponseSizeConfig{}
+}
+
+// checkResponseSize 记录响应序列化后的大小，超过上限时返回ResourceExhausted
+func checkResponseSize(metricsManager *metrics.MetricsManager, serviceName, method string, config *ResponseSizeConfig, resp interface{}) error {
+	msg, ok := resp.(proto.Message)
+	if !ok || msg == nil {
+		return nil
+	}
+
+	size := proto.Size(msg)
+	if metricsManager != nil {
+		metricsManager.RecordGRPCResponseSize(method, serviceName, size)
+	}
+	if config != nil && config.MaxSize > 0 && size > config.MaxSize {
+		return status.Errorf(codes.ResourceExhausted, "response size %d bytes exceeds limit of %d bytes", size, config.MaxSize)
+	}
+	return nil
+}
+
+// ResponseSizeUnaryInterceptor gRPC 一元调用响应大小拦截器，在发送前记录大小并拒绝超限响应
+func ResponseSizeUnaryInterceptor(metricsManager *metrics.MetricsManager, serviceName string, config *ResponseSizeConfig) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		if err := checkResponseSize(metricsManager, serviceName, info.FullMethod, config, resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// ResponseSizeUnary 绑定Observability阶段的响应大小拦截器
+func ResponseSizeUnary(metricsManager *metrics.MetricsManager, serviceName string, config *ResponseSizeConfig) OrderedUnaryInterceptor {
+	return OrderedUnaryInterceptor{Name: "response_size", Stage: StageObservability, Interceptor: ResponseSizeUnaryInterceptor(metricsManager, serviceName, config)}
+}
+
+// ResponseSizeStreamInterceptor gRPC 流式调用响应大小拦截器，逐条检查发送的消息
+func ResponseSizeStreamInterceptor(metricsManager *metrics.MetricsManager, serviceName string, config *ResponseSizeConfig) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &responseSizeServerStream{
+			ServerStream:   stream,
+			metricsManager: metricsManager,
+			serviceName:    serviceName,
+			method:         info.FullMethod,
+			config:         config,
+		})
+	}
+}
+
+// ResponseSizeStream 绑定Observability阶段的响应大小流拦截器
+func ResponseSizeStream(metricsManager *metrics.MetricsManager, serviceName string, config *ResponseSizeConfig) OrderedStreamInterceptor {
+	return OrderedStreamInterceptor{Name: "response_size", Stage: StageObservability, Interceptor: ResponseSizeStreamInterceptor(metricsManager, serviceName, config)}
+}
+
+// responseSizeServerStream 发送前检查消息大小的ServerStream
+type responseSizeServerStream struct {
+	grpc.ServerStream
+	metricsManager *metrics.MetricsManager
+	serviceName    string
+	method         string
+	config         *ResponseSizeConfig
+}
+
+// SendMsg 记录消息大小，超限时不发送
+func (s *responseSizeServerStream) SendMsg(m interface{}) error {
+	if err := checkResponseSize(s.metricsManager, s.serviceName, s.method, s.config, m); err != nil {
+		return err
+	}
+	return s.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"high-go-press/pkg/metrics"
+)
+
+const batchMethod = "/counter.CounterService/BatchGetCounters"
+
+func invokeResponseSizeInterceptor(mm *metrics.MetricsManager, config *ResponseSizeConfig, resp proto.Message) (interface{}, error) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return resp, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: batchMethod}
+	return ResponseSizeUnaryInterceptor(mm, "counter", config)(context.Background(), nil, info, handler)
+}
+
+// responseBytesHistogram 读取指定方法的grpc_response_bytes样本数和总字节数
+func responseBytesHistogram(t *testing.T, mm *metrics.MetricsManager, method string) (uint64, float64) {
+	t.Helper()
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_grpc_response_bytes" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "method" && label.GetValue() == method {
+					return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+				}
+			}
+		}
+	}
+	return 0, 0
+}
+
+func TestResponseSizeObserved(t *testing.T) {
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	resp := wrapperspb.String(strings.Repeat("a", 512))
+
+	got, err := invokeResponseSizeInterceptor(mm, DefaultResponseSizeConfig(), resp)
+	if err != nil {
+		t.Fatalf("Expected response without limit, got %v", err)
+	}
+	if got != resp {
+		t.Errorf("Expected handler response returned unchanged")
+	}
+
+	count, sum := responseBytesHistogram(t, mm, batchMethod)
+	if count != 1 {
+		t.Fatalf("Expected 1 observation, got %d", count)
+	}
+	if want := float64(proto.Size(resp)); sum != want {
+		t.Errorf("Expected observed size %v, got %v", want, sum)
+	}
+}
+
+func TestResponseSizeRejectsOversized(t *testing.T) {
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	config := &ResponseSizeConfig{MaxSize: 1024}
+
+	got, err := invokeResponseSizeInterceptor(mm, config, wrapperspb.String(strings.Repeat("a", 2048)))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted for oversized response, got %v", err)
+	}
+	if got != nil {
+		t.Errorf("Expected oversized response not to be sent, got %v", got)
+	}
+	// 被拒绝的响应同样记录大小
+	if count, _ := responseBytesHistogram(t, mm, batchMethod); count != 1 {
+		t.Errorf("Expected oversized response observed, got %d observations", count)
+	}
+
+	// 上限以内正常返回
+	if _, err := invokeResponseSizeInterceptor(mm, config, wrapperspb.String("ok")); err != nil {
+		t.Errorf("Expected small response allowed, got %v", err)
+	}
+}
+
+func TestResponseSizeWithoutMetricsManager(t *testing.T) {
+	// 未配置指标时仍然执行大小限制
+	_, err := invokeResponseSizeInterceptor(nil, &ResponseSizeConfig{MaxSize: 16}, wrapperspb.String(strings.Repeat("a", 64)))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted without metrics manager, got %v", err)
+	}
+}
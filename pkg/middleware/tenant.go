@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"high-go-press/pkg/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TenantMetadataKey 租户ID的gRPC元数据键
+const TenantMetadataKey = "tenant-id"
+
+// tenantIDPattern 合法租户ID：字母、数字、下划线和短横线，最长64位
+// 租户ID会参与Redis key拼接，禁止冒号等分隔符以避免命名空间冲突
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// tenantContextKey 租户ID的context键
+type tenantContextKey struct{}
+
+// TenantConfig 多租户配置
+type TenantConfig struct {
+	Enabled bool // 开启后所有请求必须携带tenant-id
+}
+
+// WithTenantID 将租户ID写入context
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantIDFromContext 从context获取租户ID
+func TenantIDFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// identityTenantID 已认证调用方的身份携带租户时以身份为准，请求声明的租户与之不一致时拒绝，防止冒用其他租户
+func identityTenantID(ctx context.Context, tenantID string) (string, error) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok || identity.TenantID == "" {
+		return tenantID, nil
+	}
+	if tenantID != "" && tenantID != identity.TenantID {
+		return "", status.Errorf(codes.PermissionDenied, "%s %q does not match authenticated tenant", TenantMetadataKey, tenantID)
+	}
+	return identity.TenantID, nil
+}
+
+// extractTenantID 从请求元数据中提取并校验租户ID，需挂在认证之后以便按调用方身份校验
+func extractTenantID(ctx context.Context, config *TenantConfig) (context.Context, error) {
+	var tenantID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(TenantMetadataKey); len(values) > 0 {
+			tenantID = strings.TrimSpace(values[0])
+		}
+	}
+
+	tenantID, err := identityTenantID(ctx, tenantID)
+	if err != nil {
+		return ctx, err
+	}
+
+	if tenantID == "" {
+		if config != nil && config.Enabled {
+			return ctx, status.Errorf(codes.InvalidArgument, "missing required metadata: %s", TenantMetadataKey)
+		}
+		return ctx, nil
+	}
+
+	if !tenantIDPattern.MatchString(tenantID) {
+		return ctx, status.Errorf(codes.InvalidArgument, "invalid %s: %q", TenantMetadataKey, tenantID)
+	}
+
+	return WithTenantID(ctx, tenantID), nil
+}
+
+// HTTPTenantID 网关HTTP请求的租户，认证身份携带租户时以身份为准，否则取tenant-id请求头
+// 请求头与认证身份不一致时返回PermissionDenied，格式不合法时返回InvalidArgument，未指定租户时返回空字符串
+func HTTPTenantID(r *http.Request) (string, error) {
+	tenantID, err := identityTenantID(r.Context(), strings.TrimSpace(r.Header.Get(TenantMetadataKey)))
+	if err != nil {
+		return "", err
+	}
+	if tenantID != "" && !tenantIDPattern.MatchString(tenantID) {
+		return "", status.Errorf(codes.InvalidArgument, "invalid %s: %q", TenantMetadataKey, tenantID)
+	}
+	return tenantID, nil
+}
+
+// TenantUnaryInterceptor gRPC 一元调用租户拦截器
+func TenantUnaryInterceptor(config *TenantConfig) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := extractTenantID(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// TenantUnary 绑定Auth阶段的租户拦截器
+func TenantUnary(config *TenantConfig) OrderedUnaryInterceptor {
+	return OrderedUnaryInterceptor{Name: "tenant", Stage: StageAuth, Interceptor: TenantUnaryInterceptor(config)}
+}
+
+// TenantStreamInterceptor gRPC 流式调用租户拦截器
+func TenantStreamInterceptor(config *TenantConfig) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := extractTenantID(stream.Context(), config)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// TenantStream 绑定Auth阶段的租户流拦截器
+func TenantStream(config *TenantConfig) OrderedStreamInterceptor {
+	return OrderedStreamInterceptor{Name: "tenant", Stage: StageAuth, Interceptor: TenantStreamInterceptor(config)}
+}
+
+// tenantServerStream 携带租户ID context的ServerStream
+type tenantServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回携带租户ID的context
+func (s *tenantServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"high-go-press/pkg/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func invokeTenantInterceptor(t *testing.T, config *TenantConfig, md metadata.MD) (string, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	if md != nil {
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+
+	var tenantID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		tenantID, _ = TenantIDFromContext(ctx)
+		return "ok", nil
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/counter.CounterService/IncrementCounter"}
+	_, err := TenantUnaryInterceptor(config)(ctx, nil, info, handler)
+	return tenantID, err
+}
+
+func TestTenantInterceptorPresent(t *testing.T) {
+	tenantID, err := invokeTenantInterceptor(t, &TenantConfig{Enabled: true},
+		metadata.Pairs(TenantMetadataKey, "acme"))
+	if err != nil {
+		t.Fatalf("Expected request with tenant id to pass, got %v", err)
+	}
+	if tenantID != "acme" {
+		t.Errorf("Expected tenant id acme in context, got %q", tenantID)
+	}
+}
+
+func TestTenantInterceptorAbsent(t *testing.T) {
+	_, err := invokeTenantInterceptor(t, &TenantConfig{Enabled: true}, metadata.Pairs("other", "x"))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for missing tenant id, got %v", err)
+	}
+
+	_, err = invokeTenantInterceptor(t, &TenantConfig{Enabled: true}, nil)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without metadata, got %v", err)
+	}
+}
+
+func TestTenantInterceptorInvalid(t *testing.T) {
+	_, err := invokeTenantInterceptor(t, &TenantConfig{Enabled: true},
+		metadata.Pairs(TenantMetadataKey, "acme:counter"))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for invalid tenant id, got %v", err)
+	}
+}
+
+func TestTenantInterceptorDisabled(t *testing.T) {
+	tenantID, err := invokeTenantInterceptor(t, &TenantConfig{Enabled: false}, nil)
+	if err != nil {
+		t.Fatalf("Expected request to pass when multi-tenancy is disabled, got %v", err)
+	}
+	if tenantID != "" {
+		t.Errorf("Expected no tenant id, got %q", tenantID)
+	}
+}
+
+// acmeAuthenticator 测试用认证提供者：返回属于租户acme的调用方身份
+var acmeAuthenticator = auth.AuthenticatorFunc(func(ctx context.Context) (auth.Identity, error) {
+	return auth.Identity{Subject: "acme-client", Provider: "stub", TenantID: "acme"}, nil
+})
+
+// invokeAuthenticatedTenant 按给定顺序串联认证和租户拦截器后调用
+func invokeAuthenticatedTenant(t *testing.T, tenantFirst bool, md metadata.MD) (string, error) {
+	t.Helper()
+
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	var tenantID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		tenantID, _ = TenantIDFromContext(ctx)
+		return "ok", nil
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/counter.CounterService/IncrementCounter"}
+	outer, inner := AuthUnaryInterceptor(acmeAuthenticator), TenantUnaryInterceptor(&TenantConfig{Enabled: true})
+	if tenantFirst {
+		outer, inner = inner, outer
+	}
+	_, err := outer(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return inner(ctx, req, info, handler)
+	})
+	return tenantID, err
+}
+
+func TestTenantInterceptorRejectsTenantMismatchingIdentity(t *testing.T) {
+	// 租户拦截器挂在认证前后都不能冒用其他租户
+	for _, tenantFirst := range []bool{false, true} {
+		_, err := invokeAuthenticatedTenant(t, tenantFirst, metadata.Pairs(TenantMetadataKey, "other"))
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("tenantFirst=%v: expected PermissionDenied for mismatched tenant id, got %v", tenantFirst, err)
+		}
+
+		tenantID, err := invokeAuthenticatedTenant(t, tenantFirst, metadata.Pairs(TenantMetadataKey, "acme"))
+		if err != nil || tenantID != "acme" {
+			t.Errorf("tenantFirst=%v: expected matching tenant id to pass, got %q, %v", tenantFirst, tenantID, err)
+		}
+	}
+}
+
+func TestTenantInterceptorUsesIdentityTenant(t *testing.T) {
+	// 未携带tenant-id时采用调用方身份中的租户
+	tenantID, err := invokeAuthenticatedTenant(t, false, metadata.Pairs())
+	if err != nil {
+		t.Fatalf("Expected identity tenant to satisfy multi-tenancy, got %v", err)
+	}
+	if tenantID != "acme" {
+		t.Errorf("Expected identity tenant acme in context, got %q", tenantID)
+	}
+}
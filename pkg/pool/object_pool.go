@@ -5,205 +5,218 @@ import (
 	"sync"
 
 	"high-go-press/internal/biz"
+	"high-go-press/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-var (
-	// 响应对象池 - 复用API响应对象
-	responsePool = sync.Pool{
-		New: func() interface{} {
-			return &biz.CounterResponse{}
-		},
+// 对象池名称，用于统计和指标标签
+const (
+	poolResponse    = "response"
+	poolRequest     = "request"
+	poolBuffer      = "buffer"
+	poolStringSlice = "string_slice"
+)
+
+// ObjectPoolConfig 对象池配置
+type ObjectPoolConfig struct {
+	MaxBufferCap      int // 归还时允许的最大缓冲区容量（字节），超过则丢弃
+	MaxStringSliceCap int // 归还时允许的最大字符串切片容量，超过则丢弃
+}
+
+// DefaultObjectPoolConfig 默认对象池配置
+func DefaultObjectPoolConfig() *ObjectPoolConfig {
+	return &ObjectPoolConfig{
+		MaxBufferCap:      64 * 1024, // 64KB
+		MaxStringSliceCap: 100,
 	}
+}
 
-	// 请求对象池 - 复用API请求对象
-	requestPool = sync.Pool{
-		New: func() interface{} {
-			return &biz.IncrementRequest{}
-		},
+// ObjectPool 对象池管理器，各类型对象池基于泛型Pool实现
+type ObjectPool struct {
+	config *ObjectPoolConfig
+
+	responsePool    *Pool[*biz.CounterResponse]  // 响应对象池 - 复用API响应对象
+	requestPool     *Pool[*biz.IncrementRequest] // 请求对象池 - 复用API请求对象
+	bufferPool      *Pool[*bytes.Buffer]         // 字节缓冲池 - 复用字节缓冲区
+	stringSlicePool *Pool[*[]string]             // 字符串切片池 - 复用字符串切片
+
+	// 新建对象计数指标，SetMetricsManager后生效
+	allocMetric *prometheus.CounterVec
+	service     string
+
+	mu sync.RWMutex
+}
+
+// NewObjectPool 使用默认配置创建对象池管理器
+func NewObjectPool() *ObjectPool {
+	return NewObjectPoolWithConfig(DefaultObjectPoolConfig())
+}
+
+// NewObjectPoolWithConfig 使用指定配置创建对象池管理器
+func NewObjectPoolWithConfig(config *ObjectPoolConfig) *ObjectPool {
+	defaults := DefaultObjectPoolConfig()
+	if config == nil {
+		config = defaults
 	}
 
-	// 字节缓冲池 - 复用字节缓冲区
-	bufferPool = sync.Pool{
-		New: func() interface{} {
-			return &bytes.Buffer{}
-		},
+	cfg := *config
+	if cfg.MaxBufferCap <= 0 {
+		cfg.MaxBufferCap = defaults.MaxBufferCap
 	}
+	if cfg.MaxStringSliceCap <= 0 {
+		cfg.MaxStringSliceCap = defaults.MaxStringSliceCap
+	}
+
+	p := &ObjectPool{config: &cfg}
+
+	p.responsePool = NewPool(
+		func() *biz.CounterResponse { return &biz.CounterResponse{} },
+		(*biz.CounterResponse).Reset,
+	)
+	p.responsePool.SetAllocHook(p.allocHook(poolResponse))
+
+	p.requestPool = NewPool(
+		func() *biz.IncrementRequest { return &biz.IncrementRequest{} },
+		(*biz.IncrementRequest).Reset,
+	)
+	p.requestPool.SetAllocHook(p.allocHook(poolRequest))
+
+	p.bufferPool = NewPool(
+		func() *bytes.Buffer { return &bytes.Buffer{} },
+		(*bytes.Buffer).Reset,
+	)
+	// 防止缓冲区过大占用内存
+	p.bufferPool.SetDiscard(func(buf *bytes.Buffer) bool { return buf.Cap() > cfg.MaxBufferCap })
+	p.bufferPool.SetAllocHook(p.allocHook(poolBuffer))
 
-	// 字符串切片池 - 复用字符串切片
-	stringSlicePool = sync.Pool{
-		New: func() interface{} {
+	p.stringSlicePool = NewPool(
+		func() *[]string {
 			slice := make([]string, 0, 10) // 预分配容量
 			return &slice
 		},
-	}
-)
+		func(slice *[]string) { *slice = (*slice)[:0] }, // 重置长度但保留容量
+	)
+	// 防止切片过大占用内存
+	p.stringSlicePool.SetDiscard(func(slice *[]string) bool { return cap(*slice) > cfg.MaxStringSliceCap })
+	p.stringSlicePool.SetAllocHook(p.allocHook(poolStringSlice))
 
-// ObjectPool 对象池管理器
-type ObjectPool struct {
-	// 统计信息
-	responseGets    int64
-	responsePuts    int64
-	requestGets     int64
-	requestPuts     int64
-	bufferGets      int64
-	bufferPuts      int64
-	stringSliceGets int64
-	stringSlicePuts int64
+	return p
+}
 
-	mu sync.RWMutex
+// allocHook 池未命中新建对象时上报指标
+func (p *ObjectPool) allocHook(name string) func() {
+	return func() {
+		p.mu.RLock()
+		allocMetric, service := p.allocMetric, p.service
+		p.mu.RUnlock()
+
+		if allocMetric != nil {
+			allocMetric.WithLabelValues(service, name).Inc()
+		}
+	}
 }
 
-// NewObjectPool 创建对象池管理器
-func NewObjectPool() *ObjectPool {
-	return &ObjectPool{}
+// SetMetricsManager 注册对象池新建对象计数指标，已有的统计一并上报
+func (p *ObjectPool) SetMetricsManager(mm *metrics.MetricsManager, service string) error {
+	allocMetric, err := mm.RegisterCounter(metrics.MetricOpts{
+		Name:   "object_pool_allocations_total",
+		Help:   "Objects newly allocated because the object pool was empty",
+		Labels: []string{"service", "pool"},
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.allocMetric = allocMetric
+	p.service = service
+	for name, usage := range p.usageByName() {
+		if usage.Allocations > 0 {
+			allocMetric.WithLabelValues(service, name).Add(float64(usage.Allocations))
+		}
+	}
+	return nil
 }
 
 // GetCounterResponse 从池中获取响应对象
 func (p *ObjectPool) GetCounterResponse() *biz.CounterResponse {
-	p.mu.Lock()
-	p.responseGets++
-	p.mu.Unlock()
-
-	resp := responsePool.Get().(*biz.CounterResponse)
-	// 重置对象状态
-	resp.ResourceID = ""
-	resp.CounterType = ""
-	resp.CurrentValue = 0
-	resp.Success = false
-	resp.Message = ""
-	resp.Timestamp = 0
-
-	return resp
+	return p.responsePool.Get()
 }
 
 // PutCounterResponse 将响应对象归还到池中
 func (p *ObjectPool) PutCounterResponse(resp *biz.CounterResponse) {
-	if resp == nil {
-		return
+	if resp != nil {
+		p.responsePool.Put(resp)
 	}
-
-	p.mu.Lock()
-	p.responsePuts++
-	p.mu.Unlock()
-
-	responsePool.Put(resp)
 }
 
 // GetIncrementRequest 从池中获取请求对象
 func (p *ObjectPool) GetIncrementRequest() *biz.IncrementRequest {
-	p.mu.Lock()
-	p.requestGets++
-	p.mu.Unlock()
-
-	req := requestPool.Get().(*biz.IncrementRequest)
-	// 重置对象状态
-	req.ResourceID = ""
-	req.CounterType = ""
-	req.Delta = 0
-
-	return req
+	return p.requestPool.Get()
 }
 
 // PutIncrementRequest 将请求对象归还到池中
 func (p *ObjectPool) PutIncrementRequest(req *biz.IncrementRequest) {
-	if req == nil {
-		return
+	if req != nil {
+		p.requestPool.Put(req)
 	}
-
-	p.mu.Lock()
-	p.requestPuts++
-	p.mu.Unlock()
-
-	requestPool.Put(req)
 }
 
 // GetBuffer 从池中获取字节缓冲区
 func (p *ObjectPool) GetBuffer() *bytes.Buffer {
-	p.mu.Lock()
-	p.bufferGets++
-	p.mu.Unlock()
-
-	buf := bufferPool.Get().(*bytes.Buffer)
-	buf.Reset() // 清空缓冲区
-	return buf
+	return p.bufferPool.Get()
 }
 
-// PutBuffer 将字节缓冲区归还到池中
+// PutBuffer 将字节缓冲区归还到池中，超过MaxBufferCap的缓冲区被丢弃
 func (p *ObjectPool) PutBuffer(buf *bytes.Buffer) {
-	if buf == nil {
-		return
-	}
-
-	p.mu.Lock()
-	p.bufferPuts++
-	p.mu.Unlock()
-
-	// 防止缓冲区过大占用内存
-	if buf.Cap() > 64*1024 { // 64KB
-		return
+	if buf != nil {
+		p.bufferPool.Put(buf)
 	}
-
-	bufferPool.Put(buf)
 }
 
 // GetStringSlice 从池中获取字符串切片
 func (p *ObjectPool) GetStringSlice() *[]string {
-	p.mu.Lock()
-	p.stringSliceGets++
-	p.mu.Unlock()
-
-	slice := stringSlicePool.Get().(*[]string)
-	*slice = (*slice)[:0] // 重置长度但保留容量
-	return slice
+	return p.stringSlicePool.Get()
 }
 
-// PutStringSlice 将字符串切片归还到池中
+// PutStringSlice 将字符串切片归还到池中，超过MaxStringSliceCap的切片被丢弃
 func (p *ObjectPool) PutStringSlice(slice *[]string) {
-	if slice == nil {
-		return
+	if slice != nil {
+		p.stringSlicePool.Put(slice)
 	}
+}
 
-	p.mu.Lock()
-	p.stringSlicePuts++
-	p.mu.Unlock()
-
-	// 防止切片过大占用内存
-	if cap(*slice) > 100 {
-		return
+// usageByName 按池名称汇总使用情况
+func (p *ObjectPool) usageByName() map[string]PoolUsage {
+	return map[string]PoolUsage{
+		poolResponse:    p.responsePool.GetStats(),
+		poolRequest:     p.requestPool.GetStats(),
+		poolBuffer:      p.bufferPool.GetStats(),
+		poolStringSlice: p.stringSlicePool.GetStats(),
 	}
-
-	stringSlicePool.Put(slice)
 }
 
 // GetStats 获取对象池统计信息
 func (p *ObjectPool) GetStats() ObjectPoolStats {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
 	return ObjectPoolStats{
-		Response: PoolUsage{
-			Gets: p.responseGets,
-			Puts: p.responsePuts,
-			Hit:  calculateHitRate(p.responseGets, p.responsePuts),
-		},
-		Request: PoolUsage{
-			Gets: p.requestGets,
-			Puts: p.requestPuts,
-			Hit:  calculateHitRate(p.requestGets, p.requestPuts),
-		},
-		Buffer: PoolUsage{
-			Gets: p.bufferGets,
-			Puts: p.bufferPuts,
-			Hit:  calculateHitRate(p.bufferGets, p.bufferPuts),
-		},
-		StringSlice: PoolUsage{
-			Gets: p.stringSliceGets,
-			Puts: p.stringSlicePuts,
-			Hit:  calculateHitRate(p.stringSliceGets, p.stringSlicePuts),
-		},
+		Response:    p.responsePool.GetStats(),
+		Request:     p.requestPool.GetStats(),
+		Buffer:      p.bufferPool.GetStats(),
+		StringSlice: p.stringSlicePool.GetStats(),
 	}
 }
 
+// Stats 以通用格式返回各对象池使用情况，实现stats.StatsProvider
+func (p *ObjectPool) Stats() map[string]interface{} {
+	result := make(map[string]interface{}, 4)
+	for name, usage := range p.usageByName() {
+		result[name] = usage.toMap()
+	}
+	return result
+}
+
 // ObjectPoolStats 对象池统计信息
 type ObjectPoolStats struct {
 	Response    PoolUsage `json:"response"`
@@ -214,9 +227,22 @@ type ObjectPoolStats struct {
 
 // PoolUsage 池使用情况
 type PoolUsage struct {
-	Gets int64   `json:"gets"`
-	Puts int64   `json:"puts"`
-	Hit  float64 `json:"hit_rate"` // 命中率
+	Gets        int64   `json:"gets"`
+	Puts        int64   `json:"puts"`
+	Drops       int64   `json:"drops"`       // 因超出容量阈值而丢弃的次数
+	Allocations int64   `json:"allocations"` // 池中无可复用对象而新建的次数，Gets与之差值为实际节省的分配
+	Hit         float64 `json:"hit_rate"`    // 命中率
+}
+
+// toMap 池使用情况的通用格式
+func (u PoolUsage) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"gets":        u.Gets,
+		"puts":        u.Puts,
+		"drops":       u.Drops,
+		"allocations": u.Allocations,
+		"hit_rate":    u.Hit,
+	}
 }
 
 // calculateHitRate 计算命中率
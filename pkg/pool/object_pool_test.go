@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"testing"
+
+	"high-go-press/internal/biz"
+	"high-go-press/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+func TestObjectPoolResetsIncrementRequest(t *testing.T) {
+	p := NewObjectPool()
+
+	req := p.GetIncrementRequest()
+	req.ResourceID = "article_1"
+	req.CounterType = "like"
+	req.Delta = 42
+	p.PutIncrementRequest(req)
+
+	got := p.GetIncrementRequest()
+	if *got != (biz.IncrementRequest{}) {
+		t.Errorf("Expected zeroed request, got %+v", *got)
+	}
+}
+
+func TestObjectPoolResetsCounterResponse(t *testing.T) {
+	p := NewObjectPool()
+
+	resp := p.GetCounterResponse()
+	resp.ResourceID = "article_1"
+	resp.CounterType = "like"
+	resp.CurrentValue = 100
+	resp.Success = true
+	resp.Message = "ok"
+	resp.Timestamp = 1700000000
+	p.PutCounterResponse(resp)
+
+	got := p.GetCounterResponse()
+	if *got != (biz.CounterResponse{}) {
+		t.Errorf("Expected zeroed response, got %+v", *got)
+	}
+}
+
+func TestObjectPoolDropsOversizedBuffer(t *testing.T) {
+	p := NewObjectPoolWithConfig(&ObjectPoolConfig{MaxBufferCap: 16, MaxStringSliceCap: 4})
+
+	buf := p.GetBuffer()
+	buf.Grow(1024)
+	p.PutBuffer(buf)
+
+	stats := p.GetStats()
+	if stats.Buffer.Drops != 1 {
+		t.Errorf("Expected 1 buffer drop, got %d", stats.Buffer.Drops)
+	}
+
+	// 被丢弃的缓冲区不应再从池中取出
+	for i := 0; i < 10; i++ {
+		if got := p.GetBuffer(); got == buf {
+			t.Fatal("Oversized buffer was returned to the pool")
+		}
+	}
+}
+
+func TestObjectPoolDropsOversizedStringSlice(t *testing.T) {
+	p := NewObjectPoolWithConfig(&ObjectPoolConfig{MaxBufferCap: 16, MaxStringSliceCap: 4})
+
+	slice := make([]string, 0, 32)
+	p.PutStringSlice(&slice)
+
+	small := make([]string, 0, 2)
+	p.PutStringSlice(&small)
+
+	stats := p.GetStats()
+	if stats.StringSlice.Drops != 1 {
+		t.Errorf("Expected 1 string slice drop, got %d", stats.StringSlice.Drops)
+	}
+	if stats.StringSlice.Puts != 2 {
+		t.Errorf("Expected 2 string slice puts, got %d", stats.StringSlice.Puts)
+	}
+}
+
+func TestObjectPoolCountsAllocations(t *testing.T) {
+	p := NewObjectPool()
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+
+	// 不归还对象，每次获取都只能新建
+	const n = 5
+	for i := 0; i < n; i++ {
+		p.GetBuffer()
+	}
+	if err := p.SetMetricsManager(mm, "gateway"); err != nil {
+		t.Fatalf("SetMetricsManager failed: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		p.GetCounterResponse()
+	}
+
+	stats := p.GetStats()
+	if stats.Buffer.Allocations != n || stats.Response.Allocations != n {
+		t.Errorf("Expected %d buffer and response allocations, got %d/%d",
+			n, stats.Buffer.Allocations, stats.Response.Allocations)
+	}
+	if stats.Request.Allocations != 0 {
+		t.Errorf("Expected no request allocations, got %d", stats.Request.Allocations)
+	}
+
+	// 注册前的统计也会上报到Prometheus
+	for pool, want := range map[string]float64{"buffer": n, "response": n} {
+		if got := allocationMetric(t, mm, pool); got != want {
+			t.Errorf("Expected %s allocations metric %v, got %v", pool, want, got)
+		}
+	}
+}
+
+// allocationMetric 读取指定池的新建对象计数指标
+func allocationMetric(t *testing.T, mm *metrics.MetricsManager, pool string) float64 {
+	t.Helper()
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_object_pool_allocations_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "pool" && label.GetValue() == pool {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
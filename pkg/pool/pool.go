@@ -0,0 +1,80 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Pool 基于sync.Pool的泛型对象池，取出时重置对象状态并统计使用情况
+// 新增池化类型只需提供新建和重置函数
+type Pool[T any] struct {
+	pool    sync.Pool
+	reset   func(T)      // 取出时重置对象状态，可为nil
+	discard func(T) bool // 归还时返回true的对象被丢弃，可为nil
+	onAlloc func()       // 池中无可复用对象而新建时回调，可为nil
+
+	gets        atomic.Int64
+	puts        atomic.Int64
+	drops       atomic.Int64
+	allocations atomic.Int64
+}
+
+// NewPool 创建泛型对象池，newFn新建对象，reset在每次Get时重置对象状态
+func NewPool[T any](newFn func() T, reset func(T)) *Pool[T] {
+	p := &Pool[T]{reset: reset}
+	p.pool.New = func() interface{} {
+		p.allocations.Add(1)
+		if p.onAlloc != nil {
+			p.onAlloc()
+		}
+		return newFn()
+	}
+	return p
+}
+
+// SetDiscard 设置归还时的丢弃条件，如防止超大缓冲区长期占用内存
+func (p *Pool[T]) SetDiscard(discard func(T) bool) {
+	p.discard = discard
+}
+
+// SetAllocHook 设置新建对象时的回调，用于上报指标，需在使用前设置
+func (p *Pool[T]) SetAllocHook(onAlloc func()) {
+	p.onAlloc = onAlloc
+}
+
+// Get 从池中获取对象并重置状态
+func (p *Pool[T]) Get() T {
+	p.gets.Add(1)
+	v := p.pool.Get().(T)
+	if p.reset != nil {
+		p.reset(v)
+	}
+	return v
+}
+
+// Put 将对象归还到池中，满足丢弃条件的对象不会放回
+func (p *Pool[T]) Put(v T) {
+	p.puts.Add(1)
+	if p.discard != nil && p.discard(v) {
+		p.drops.Add(1)
+		return
+	}
+	p.pool.Put(v)
+}
+
+// Stats 以通用格式返回对象池使用情况，实现stats.StatsProvider
+func (p *Pool[T]) Stats() map[string]interface{} {
+	return p.GetStats().toMap()
+}
+
+// GetStats 获取对象池使用情况
+func (p *Pool[T]) GetStats() PoolUsage {
+	gets, puts := p.gets.Load(), p.puts.Load()
+	return PoolUsage{
+		Gets:        gets,
+		Puts:        puts,
+		Drops:       p.drops.Load(),
+		Allocations: p.allocations.Load(),
+		Hit:         calculateHitRate(gets, puts),
+	}
+}
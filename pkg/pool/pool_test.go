@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+)
+
+type pooledItem struct {
+	ID   int
+	Tags []string
+}
+
+func newItemPool() *Pool[*pooledItem] {
+	return NewPool(
+		func() *pooledItem { return &pooledItem{} },
+		func(item *pooledItem) {
+			item.ID = 0
+			item.Tags = item.Tags[:0]
+		},
+	)
+}
+
+func TestPoolGetPutReset(t *testing.T) {
+	p := newItemPool()
+
+	item := p.Get()
+	item.ID = 42
+	item.Tags = append(item.Tags, "a", "b")
+	p.Put(item)
+
+	// Get时重置对象状态
+	got := p.Get()
+	if got.ID != 0 || len(got.Tags) != 0 {
+		t.Errorf("Expected reset item, got %+v", *got)
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	p := newItemPool()
+
+	// 不归还对象，每次获取都只能新建
+	for i := 0; i < 3; i++ {
+		p.Get()
+	}
+	item := p.Get()
+	p.Put(item)
+
+	stats := p.GetStats()
+	if stats.Gets != 4 || stats.Puts != 1 || stats.Allocations != 4 || stats.Drops != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.Hit != 25 {
+		t.Errorf("Expected hit rate 25, got %v", stats.Hit)
+	}
+}
+
+func TestPoolDiscardAndAllocHook(t *testing.T) {
+	p := newItemPool()
+	p.SetDiscard(func(item *pooledItem) bool { return cap(item.Tags) > 4 })
+
+	var allocs int
+	p.SetAllocHook(func() { allocs++ })
+
+	item := p.Get()
+	item.Tags = make([]string, 0, 16)
+	p.Put(item)
+
+	stats := p.GetStats()
+	if stats.Drops != 1 || stats.Puts != 1 {
+		t.Errorf("Expected 1 drop of 1 put, got %+v", stats)
+	}
+	// 被丢弃的对象不应再从池中取出
+	for i := 0; i < 10; i++ {
+		if got := p.Get(); got == item {
+			t.Fatal("Discarded item was returned to the pool")
+		}
+	}
+	if int64(allocs) != p.GetStats().Allocations {
+		t.Errorf("Expected alloc hook called %d times, got %d", p.GetStats().Allocations, allocs)
+	}
+}
+
+func TestPoolConcurrentStats(t *testing.T) {
+	p := newItemPool()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.Put(p.Get())
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := p.GetStats()
+	if stats.Gets != 800 || stats.Puts != 800 {
+		t.Errorf("Expected 800 gets and puts, got %+v", stats)
+	}
+	if stats.Allocations < 1 || stats.Allocations > stats.Gets {
+		t.Errorf("Unexpected allocations %d", stats.Allocations)
+	}
+}
@@ -166,6 +166,60 @@ func (wp *WorkerPool) GetStats() PoolStats {
 	}
 }
 
+// Stats 以通用格式返回池状态，实现stats.StatsProvider
+func (wp *WorkerPool) Stats() map[string]interface{} {
+	stats := wp.GetStats()
+	return map[string]interface{}{
+		"general_pool": stats.GeneralPool.toMap(),
+		"counter_pool": stats.CounterPool.toMap(),
+	}
+}
+
+// StatsRecorder 工作池状态指标记录器，metrics.MetricsManager实现了该接口
+type StatsRecorder interface {
+	SetWorkerPoolStats(service, pool string, capacity, running, waiting int)
+}
+
+// RecordStats 上报一次通用池和计数池的状态
+func (wp *WorkerPool) RecordStats(recorder StatsRecorder, service string) {
+	stats := wp.GetStats()
+	recorder.SetWorkerPoolStats(service, "general", stats.GeneralPool.Cap, stats.GeneralPool.Running, stats.GeneralPool.Waiting)
+	recorder.SetWorkerPoolStats(service, "counter", stats.CounterPool.Cap, stats.CounterPool.Running, stats.CounterPool.Waiting)
+}
+
+// RunStatsCollector 按interval定期上报池状态，直到ctx取消
+func (wp *WorkerPool) RunStatsCollector(ctx context.Context, recorder StatsRecorder, service string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		wp.RecordStats(recorder, service)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Utilization 运行中的worker占容量的比例，0-1
+func (s PoolStat) Utilization() float64 {
+	if s.Cap <= 0 {
+		return 0
+	}
+	return float64(s.Running) / float64(s.Cap)
+}
+
+// toMap 单个池状态的通用格式
+func (s PoolStat) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"capacity": s.Cap,
+		"running":  s.Running,
+		"waiting":  s.Waiting,
+		"free":     s.Free,
+	}
+}
+
 // Shutdown 优雅关闭worker pool
 func (wp *WorkerPool) Shutdown(ctx context.Context) error {
 	wp.mu.Lock()
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"high-go-press/pkg/metrics"
+
 	"go.uber.org/zap"
 )
 
@@ -168,3 +170,81 @@ func TestWorkerPoolShutdown(t *testing.T) {
 		t.Errorf("Expected ErrPoolClosed, got: %v", err)
 	}
 }
+
+// gaugeValue 读取指标族中带指定pool标签的gauge值
+func gaugeValue(t *testing.T, mm *metrics.MetricsManager, family, pool string) float64 {
+	t.Helper()
+
+	families, err := mm.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != family {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "pool" && label.GetValue() == pool {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("metric %s{pool=%q} not found", family, pool)
+	return 0
+}
+
+func TestWorkerPoolStatsCollectorReportsSaturation(t *testing.T) {
+	pool, err := NewWorkerPool(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Shutdown(context.Background())
+
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	pool.RecordStats(mm, "counter")
+	if got := gaugeValue(t, mm, "test_worker_pool_waiting", "general"); got != 0 {
+		t.Errorf("Expected no waiting tasks on an idle pool, got %v", got)
+	}
+
+	// 占满所有worker，之后提交的任务阻塞等待
+	release := make(chan struct{})
+	capacity := pool.GetStats().GeneralPool.Cap
+	for i := 0; i < capacity; i++ {
+		if err := pool.SubmitTask(func() { <-release }); err != nil {
+			t.Fatalf("SubmitTask failed: %v", err)
+		}
+	}
+	const waiting = 3
+	var wg sync.WaitGroup
+	for i := 0; i < waiting; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.SubmitTask(func() {})
+		}()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.GetStats().GeneralPool.Waiting < waiting {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d waiting submitters, got %d", waiting, pool.GetStats().GeneralPool.Waiting)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	pool.RecordStats(mm, "counter")
+	if got := gaugeValue(t, mm, "test_worker_pool_waiting", "general"); got != waiting {
+		t.Errorf("Expected waiting gauge %d on a saturated pool, got %v", waiting, got)
+	}
+	if got := gaugeValue(t, mm, "test_worker_pool_capacity", "general"); got != float64(capacity) {
+		t.Errorf("Expected capacity gauge %d, got %v", capacity, got)
+	}
+	if got := gaugeValue(t, mm, "test_worker_pool_utilization", "general"); got != 1 {
+		t.Errorf("Expected utilization 1 on a saturated pool, got %v", got)
+	}
+
+	close(release)
+	wg.Wait()
+}
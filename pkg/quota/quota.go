@@ -0,0 +1,208 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"high-go-press/pkg/auth"
+	"high-go-press/pkg/config"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// TierAttribute 身份属性中指定配额等级的键，如API Key配置的tier或JWT的tier claim
+const TierAttribute = "tier"
+
+// ErrQuotaExceeded 调用方当日配额已用完
+var ErrQuotaExceeded = errors.New("quota: daily quota exceeded")
+
+// consumeScript 未超出上限时增加用量并设置到次日零点过期，返回{是否成功, 当前用量}
+// 超出上限时不增加用量，被拒绝的请求不消耗配额
+var consumeScript = redis.NewScript(`
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local n = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+if used + n > limit then
+	return {0, used}
+end
+used = redis.call('INCRBY', KEYS[1], n)
+redis.call('EXPIREAT', KEYS[1], ARGV[3])
+return {1, used}
+`)
+
+// refundScript 退还已消耗的用量，不低于0，保留原过期时间
+var refundScript = redis.NewScript(`
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local n = tonumber(ARGV[1])
+if used <= 0 then
+	return 0
+end
+if n > used then
+	n = used
+end
+return redis.call('DECRBY', KEYS[1], n)
+`)
+
+// Config 配额配置
+type Config struct {
+	Tiers       map[string]int64  // 等级 -> 每日上限，0或未配置表示不限制
+	DefaultTier string            // 身份未指定等级或等级未配置时使用的等级
+	Subjects    map[string]string // 调用方 -> 等级，优先于身份属性中的等级
+	KeyPrefix   string            // Redis key前缀
+	Location    *time.Location    // 按该时区计算自然日
+}
+
+// DefaultConfig 默认配额配置：default等级每日10万次
+func DefaultConfig() *Config {
+	return &Config{
+		Tiers:       map[string]int64{"default": 100000},
+		DefaultTier: "default",
+		KeyPrefix:   "quota:",
+		Location:    time.UTC,
+	}
+}
+
+// ConfigFromAppConfig 将应用配置中的quota段转换为配额配置
+func ConfigFromAppConfig(cfg config.QuotaConfig) (*Config, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quota timezone %q: %w", cfg.Timezone, err)
+	}
+	subjects := make(map[string]string, len(cfg.Subjects))
+	for _, s := range cfg.Subjects {
+		subjects[s.Subject] = s.Tier
+	}
+	return &Config{
+		Tiers:       cfg.Tiers,
+		DefaultTier: cfg.DefaultTier,
+		Subjects:    subjects,
+		Location:    location,
+	}, nil
+}
+
+// Usage 调用方当日配额使用情况
+type Usage struct {
+	Tier      string
+	Limit     int64 // 每日上限，Unlimited为true时无意义
+	Used      int64
+	Remaining int64
+	ResetAt   time.Time // 下次重置时间（次日零点）
+	Unlimited bool
+}
+
+// Service 基于Redis的每日配额服务，每个调用方每天一个计数器，到次日零点过期
+type Service struct {
+	client redis.Cmdable
+	config *Config
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewService 创建配额服务
+func NewService(client redis.Cmdable, config *Config, logger *zap.Logger) *Service {
+	defaults := DefaultConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = defaults.KeyPrefix
+	}
+	if cfg.Location == nil {
+		cfg.Location = defaults.Location
+	}
+	return &Service{client: client, config: &cfg, logger: logger, now: time.Now}
+}
+
+// TierFor 确定调用方的配额等级：配置的调用方映射 > 身份属性 > 默认等级
+// 未在Tiers中配置的等级按默认等级处理，避免令牌中的任意tier绕过配额
+func (s *Service) TierFor(identity auth.Identity) string {
+	for _, tier := range []string{s.config.Subjects[identity.Subject], identity.Attributes[TierAttribute]} {
+		if _, ok := s.config.Tiers[tier]; ok && tier != "" {
+			return tier
+		}
+	}
+	return s.config.DefaultTier
+}
+
+// dayWindow 当前自然日的标识和次日零点
+func (s *Service) dayWindow() (string, time.Time) {
+	now := s.now().In(s.config.Location)
+	year, month, day := now.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, s.config.Location)
+	return start.Format("20060102"), start.AddDate(0, 0, 1)
+}
+
+// key 调用方当日的配额计数器key
+func (s *Service) key(subject, day string) string {
+	return s.config.KeyPrefix + subject + ":" + day
+}
+
+// Consume 为调用方消耗n次配额，超出时返回ErrQuotaExceeded且不消耗
+func (s *Service) Consume(ctx context.Context, identity auth.Identity, n int64) (Usage, error) {
+	tier := s.TierFor(identity)
+	limit := s.config.Tiers[tier]
+	day, resetAt := s.dayWindow()
+	usage := Usage{Tier: tier, Limit: limit, ResetAt: resetAt}
+	if limit <= 0 {
+		usage.Unlimited = true
+		return usage, nil
+	}
+
+	key := s.key(identity.Subject, day)
+	result, err := consumeScript.Run(ctx, s.client, []string{key}, n, limit, resetAt.Unix()).Int64Slice()
+	if err != nil {
+		s.logger.Error("Failed to consume quota",
+			zap.String("key", key),
+			zap.Int64("n", n),
+			zap.Error(err))
+		return usage, fmt.Errorf("quota: consume: %w", err)
+	}
+	if len(result) != 2 {
+		return usage, fmt.Errorf("quota: unexpected script reply %v", result)
+	}
+
+	usage.Used = result[1]
+	usage.Remaining = limit - usage.Used
+	if usage.Remaining < 0 {
+		usage.Remaining = 0
+	}
+	if result[0] == 0 {
+		return usage, fmt.Errorf("%w: %s used %d of %d", ErrQuotaExceeded, identity.Subject, usage.Used, limit)
+	}
+	return usage, nil
+}
+
+// Refund 退还Consume消耗的n次配额，用于请求最终失败的情况
+// 按usage所属的自然日退还，跨过零点后退还到前一天的计数器不影响当日配额
+func (s *Service) Refund(ctx context.Context, identity auth.Identity, usage Usage, n int64) error {
+	if usage.Unlimited || n <= 0 {
+		return nil
+	}
+	day := usage.ResetAt.In(s.config.Location).AddDate(0, 0, -1).Format("20060102")
+	key := s.key(identity.Subject, day)
+	if err := refundScript.Run(ctx, s.client, []string{key}, n).Err(); err != nil {
+		s.logger.Error("Failed to refund quota",
+			zap.String("key", key),
+			zap.Int64("n", n),
+			zap.Error(err))
+		return fmt.Errorf("quota: refund: %w", err)
+	}
+	return nil
+}
+
+// Headers 配额信息的响应头/元数据，不限制时为空
+func (u Usage) Headers() map[string]string {
+	if u.Unlimited {
+		return nil
+	}
+	return map[string]string{
+		"x-quota-limit":     strconv.FormatInt(u.Limit, 10),
+		"x-quota-remaining": strconv.FormatInt(u.Remaining, 10),
+		"x-quota-reset":     strconv.FormatInt(u.ResetAt.Unix(), 10),
+	}
+}
@@ -0,0 +1,216 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/auth"
+	"high-go-press/pkg/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// newTestService 基于miniredis的配额服务，miniredis的时间与clock同步以校验过期时间
+func newTestService(t *testing.T, limit int64) (*Service, *miniredis.Miniredis, *time.Time) {
+	clock := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	mr := miniredis.RunT(t)
+	mr.SetTime(clock)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	service := NewService(client, &Config{
+		Tiers:       map[string]int64{"default": limit, "premium": limit * 10, "internal": 0},
+		DefaultTier: "default",
+		Subjects:    map[string]string{"batch-job": "internal"},
+	}, zap.NewNop())
+	service.now = func() time.Time { return clock }
+	return service, mr, &clock
+}
+
+func TestConsumeUnderAndOverQuota(t *testing.T) {
+	service, mr, _ := newTestService(t, 3)
+	identity := auth.Identity{Subject: "web"}
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		usage, err := service.Consume(ctx, identity, 1)
+		if err != nil {
+			t.Fatalf("Request %d: expected under quota, got %v", i, err)
+		}
+		if usage.Used != i || usage.Remaining != 3-i || usage.Limit != 3 {
+			t.Errorf("Request %d: unexpected usage %+v", i, usage)
+		}
+	}
+
+	usage, err := service.Consume(ctx, identity, 1)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if usage.Remaining != 0 {
+		t.Errorf("Expected no remaining quota, got %d", usage.Remaining)
+	}
+	// 被拒绝的请求不消耗配额
+	if got, _ := mr.Get("quota:web:20240301"); got != "3" {
+		t.Errorf("Expected rejected request not to consume quota, counter is %s", got)
+	}
+	// 计数器在次日零点过期
+	if ttl := mr.TTL("quota:web:20240301"); ttl != time.Hour {
+		t.Errorf("Expected counter to expire at next midnight, ttl %v", ttl)
+	}
+
+	// 其他调用方不受影响
+	if _, err := service.Consume(ctx, auth.Identity{Subject: "mobile"}, 1); err != nil {
+		t.Errorf("Expected other subject to have its own quota, got %v", err)
+	}
+}
+
+func TestConsumeDailyReset(t *testing.T) {
+	service, _, clock := newTestService(t, 2)
+	identity := auth.Identity{Subject: "web"}
+	ctx := context.Background()
+
+	usage, err := service.Consume(ctx, identity, 2)
+	if err != nil {
+		t.Fatalf("Expected under quota, got %v", err)
+	}
+	if want := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC); !usage.ResetAt.Equal(want) {
+		t.Errorf("Expected reset at next midnight %v, got %v", want, usage.ResetAt)
+	}
+	if _, err := service.Consume(ctx, identity, 1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// 跨过零点后配额重置
+	*clock = clock.Add(90 * time.Minute)
+	usage, err = service.Consume(ctx, identity, 1)
+	if err != nil {
+		t.Fatalf("Expected quota reset on next day, got %v", err)
+	}
+	if usage.Used != 1 || usage.Remaining != 1 {
+		t.Errorf("Expected fresh usage after reset, got %+v", usage)
+	}
+}
+
+func TestTierFor(t *testing.T) {
+	service, _, _ := newTestService(t, 5)
+
+	cases := []struct {
+		identity auth.Identity
+		want     string
+	}{
+		{auth.Identity{Subject: "web"}, "default"},
+		{auth.Identity{Subject: "web", Attributes: map[string]string{TierAttribute: "premium"}}, "premium"},
+		// 未配置的等级回退到默认等级
+		{auth.Identity{Subject: "web", Attributes: map[string]string{TierAttribute: "unknown"}}, "default"},
+		// 配置的调用方映射优先于身份属性
+		{auth.Identity{Subject: "batch-job", Attributes: map[string]string{TierAttribute: "premium"}}, "internal"},
+	}
+	for _, c := range cases {
+		if got := service.TierFor(c.identity); got != c.want {
+			t.Errorf("TierFor(%+v) = %s, want %s", c.identity, got, c.want)
+		}
+	}
+}
+
+func TestConfigFromAppConfig(t *testing.T) {
+	cfg, err := ConfigFromAppConfig(config.QuotaConfig{
+		DefaultTier: "default",
+		Tiers:       map[string]int64{"default": 10},
+		Subjects:    []config.QuotaSubjectConfig{{Subject: "batch-job", Tier: "internal"}},
+		Timezone:    "Asia/Shanghai",
+	})
+	if err != nil {
+		t.Fatalf("ConfigFromAppConfig failed: %v", err)
+	}
+	if cfg.Subjects["batch-job"] != "internal" || cfg.Location.String() != "Asia/Shanghai" || cfg.Tiers["default"] != 10 {
+		t.Errorf("Unexpected quota config: %+v", cfg)
+	}
+
+	// 无法识别的时区在启动时报错
+	if _, err := ConfigFromAppConfig(config.QuotaConfig{Timezone: "Mars/Base"}); err == nil {
+		t.Errorf("Expected error for unknown timezone")
+	}
+}
+
+func TestConsumeTierLimits(t *testing.T) {
+	service, _, _ := newTestService(t, 1)
+	ctx := context.Background()
+
+	premium := auth.Identity{Subject: "partner", Attributes: map[string]string{TierAttribute: "premium"}}
+	usage, err := service.Consume(ctx, premium, 5)
+	if err != nil || usage.Limit != 10 || usage.Tier != "premium" {
+		t.Errorf("Expected premium limit 10, got %+v, %v", usage, err)
+	}
+
+	// 上限为0的等级不限制，也不访问Redis
+	for i := 0; i < 5; i++ {
+		usage, err := service.Consume(ctx, auth.Identity{Subject: "batch-job"}, 1)
+		if err != nil || !usage.Unlimited {
+			t.Fatalf("Expected unlimited tier, got %+v, %v", usage, err)
+		}
+		if len(usage.Headers()) != 0 {
+			t.Errorf("Expected no quota headers for unlimited tier, got %v", usage.Headers())
+		}
+	}
+}
+
+func TestConsumeStoreError(t *testing.T) {
+	service, mr, _ := newTestService(t, 1)
+	mr.SetError("connection refused")
+
+	_, err := service.Consume(context.Background(), auth.Identity{Subject: "web"}, 1)
+	if err == nil || errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected store error, got %v", err)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	service, mr, clock := newTestService(t, 2)
+	identity := auth.Identity{Subject: "web"}
+	ctx := context.Background()
+
+	usage, err := service.Consume(ctx, identity, 2)
+	if err != nil {
+		t.Fatalf("Expected under quota, got %v", err)
+	}
+	if err := service.Refund(ctx, identity, usage, 1); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+	if got, _ := mr.Get("quota:web:20240301"); got != "1" {
+		t.Errorf("Expected refund to decrease usage to 1, got %s", got)
+	}
+	// 退还保留原过期时间
+	if ttl := mr.TTL("quota:web:20240301"); ttl != time.Hour {
+		t.Errorf("Expected refund to keep expiry, ttl %v", ttl)
+	}
+	if _, err := service.Consume(ctx, identity, 1); err != nil {
+		t.Errorf("Expected refunded quota to be usable, got %v", err)
+	}
+
+	// 退还不会让用量低于0
+	if err := service.Refund(ctx, identity, usage, 5); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+	if got, _ := mr.Get("quota:web:20240301"); got != "0" {
+		t.Errorf("Expected usage not to go below 0, got %s", got)
+	}
+
+	// 跨过零点后退还前一天的用量，不影响当日配额
+	*clock = clock.Add(90 * time.Minute)
+	today, err := service.Consume(ctx, identity, 2)
+	if err != nil {
+		t.Fatalf("Expected fresh quota on next day, got %v", err)
+	}
+	if err := service.Refund(ctx, identity, usage, 1); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+	if today.Used != 2 {
+		t.Errorf("Expected 2 used today, got %d", today.Used)
+	}
+	if got, _ := mr.Get("quota:web:20240302"); got != "2" {
+		t.Errorf("Expected refund of previous day not to touch today's counter, got %s", got)
+	}
+}
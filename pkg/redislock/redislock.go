@@ -0,0 +1,112 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// lockKeyPrefix 锁key前缀
+	lockKeyPrefix = "lock:"
+	// fenceKeyPrefix 隔离令牌计数器key前缀，计数器不过期以保证令牌单调递增
+	fenceKeyPrefix = "lock-fence:"
+)
+
+var (
+	// ErrNotAcquired 锁已被其他持有者占用
+	ErrNotAcquired = errors.New("redislock: lock is held by another owner")
+	// ErrNotHeld 释放时锁已过期或已被其他持有者重新获取
+	ErrNotHeld = errors.New("redislock: lock not held")
+	// ErrInvalidTTL 锁的过期时间必须为正数
+	ErrInvalidTTL = errors.New("redislock: ttl must be positive")
+)
+
+// releaseScript 仅当锁的值仍为本次获取的令牌时删除，避免误删他人的锁
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Locker 基于Redis的分布式锁，用于保证重置、批量删除等管理操作在多实例间互斥
+//
+// 获取锁时先INCR隔离计数器得到令牌，再以SET NX PX写入令牌。令牌单调递增，
+// 持有者在锁过期后继续写入时，下游可比较令牌拒绝过期持有者的写入。
+type Locker struct {
+	client redis.Cmdable
+	logger *zap.Logger
+}
+
+// NewLocker 创建分布式锁
+func NewLocker(client redis.Cmdable, logger *zap.Logger) *Locker {
+	return &Locker{
+		client: client,
+		logger: logger,
+	}
+}
+
+// Lock 已获取的锁
+type Lock struct {
+	locker *Locker
+	key    string
+	token  int64
+}
+
+// Acquire 获取锁，ttl到期后锁自动释放；锁已被占用时返回ErrNotAcquired
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+
+	token, err := l.client.Incr(ctx, fenceKeyPrefix+key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate fencing token for lock %s: %w", key, err)
+	}
+
+	acquired, err := l.client.SetNX(ctx, lockKeyPrefix+key, strconv.FormatInt(token, 10), ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !acquired {
+		return nil, ErrNotAcquired
+	}
+
+	l.logger.Debug("Lock acquired",
+		zap.String("key", key),
+		zap.Int64("token", token),
+		zap.Duration("ttl", ttl))
+	return &Lock{locker: l, key: key, token: token}, nil
+}
+
+// Key 锁的key
+func (lock *Lock) Key() string {
+	return lock.key
+}
+
+// Token 隔离令牌，后获取的锁令牌更大
+func (lock *Lock) Token() int64 {
+	return lock.token
+}
+
+// Release 释放锁；锁已过期或已被他人获取时返回ErrNotHeld
+func (lock *Lock) Release(ctx context.Context) error {
+	deleted, err := releaseScript.Run(ctx, lock.locker.client,
+		[]string{lockKeyPrefix + lock.key}, strconv.FormatInt(lock.token, 10)).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", lock.key, err)
+	}
+	if deleted == 0 {
+		lock.locker.logger.Warn("Lock expired before release",
+			zap.String("key", lock.key),
+			zap.Int64("token", lock.token))
+		return ErrNotHeld
+	}
+	return nil
+}
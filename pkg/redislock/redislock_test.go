@@ -0,0 +1,154 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// fakeRedis 内存实现锁用到的Redis命令，支持手动推进时间模拟过期
+type fakeRedis struct {
+	redis.Cmdable
+	mu      sync.Mutex
+	now     time.Time
+	values  map[string]string
+	expires map[string]time.Time
+	counts  map[string]int64
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		now:     time.Unix(1700000000, 0),
+		values:  make(map[string]string),
+		expires: make(map[string]time.Time),
+		counts:  make(map[string]int64),
+	}
+}
+
+func (f *fakeRedis) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// getLocked 读取未过期的值，调用方需持有锁
+func (f *fakeRedis) getLocked(key string) (string, bool) {
+	value, ok := f.values[key]
+	if ok && !f.now.Before(f.expires[key]) {
+		delete(f.values, key)
+		delete(f.expires, key)
+		return "", false
+	}
+	return value, ok
+}
+
+func (f *fakeRedis) Incr(ctx context.Context, key string) *redis.IntCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[key]++
+	return redis.NewIntResult(f.counts[key], nil)
+}
+
+func (f *fakeRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.getLocked(key); ok {
+		return redis.NewBoolResult(false, nil)
+	}
+	f.values[key] = value.(string)
+	f.expires[key] = f.now.Add(expiration)
+	return redis.NewBoolResult(true, nil)
+}
+
+func (f *fakeRedis) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	return redis.NewCmdResult(nil, errors.New("NOSCRIPT No matching script"))
+}
+
+// Eval 按releaseScript的语义比较后删除
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if value, ok := f.getLocked(keys[0]); ok && value == args[0] {
+		delete(f.values, keys[0])
+		delete(f.expires, keys[0])
+		return redis.NewCmdResult(int64(1), nil)
+	}
+	return redis.NewCmdResult(int64(0), nil)
+}
+
+func TestLockContention(t *testing.T) {
+	client := newFakeRedis()
+	a := NewLocker(client, zap.NewNop())
+	b := NewLocker(client, zap.NewNop())
+	ctx := context.Background()
+
+	lock, err := a.Acquire(ctx, "counter:reset", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// 另一实例在锁释放前无法获取
+	if _, err := b.Acquire(ctx, "counter:reset", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("Expected ErrNotAcquired while lock held, got %v", err)
+	}
+	// 不同key互不影响
+	if _, err := b.Acquire(ctx, "counter:delete", time.Minute); err != nil {
+		t.Fatalf("Expected independent key to be acquired, got %v", err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	next, err := b.Acquire(ctx, "counter:reset", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected lock acquirable after release, got %v", err)
+	}
+	if next.Token() <= lock.Token() {
+		t.Errorf("Expected increasing fencing token, got %d after %d", next.Token(), lock.Token())
+	}
+}
+
+func TestLockExpiry(t *testing.T) {
+	client := newFakeRedis()
+	a := NewLocker(client, zap.NewNop())
+	b := NewLocker(client, zap.NewNop())
+	ctx := context.Background()
+
+	stale, err := a.Acquire(ctx, "counter:reset", time.Second)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// 持有者未释放，过期后其他实例可以获取
+	client.advance(2 * time.Second)
+	fresh, err := b.Acquire(ctx, "counter:reset", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected expired lock to be acquirable, got %v", err)
+	}
+	if fresh.Token() <= stale.Token() {
+		t.Errorf("Expected new holder to get a larger token, got %d after %d", fresh.Token(), stale.Token())
+	}
+
+	// 过期持有者释放时不能删除新持有者的锁
+	if err := stale.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("Expected ErrNotHeld for expired lock, got %v", err)
+	}
+	if _, err := a.Acquire(ctx, "counter:reset", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("Expected new holder's lock to remain, got %v", err)
+	}
+	if err := fresh.Release(ctx); err != nil {
+		t.Errorf("Release failed: %v", err)
+	}
+}
+
+func TestAcquireRejectsNonPositiveTTL(t *testing.T) {
+	locker := NewLocker(newFakeRedis(), zap.NewNop())
+	if _, err := locker.Acquire(context.Background(), "counter:reset", 0); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("Expected ErrInvalidTTL, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package shutdown
+
+import (
+	"sync"
+	"time"
+
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/metrics"
+	"high-go-press/pkg/pool"
+
+	"go.uber.org/zap"
+)
+
+// PoolStatsProvider 提供worker pool状态的组件
+type PoolStatsProvider interface {
+	GetStats() pool.PoolStats
+}
+
+// Summary 服务退出时的运行汇总
+type Summary struct {
+	Service        string        `json:"service"`
+	Uptime         time.Duration `json:"uptime"`
+	RequestsServed int64         `json:"requests_served"`
+	RequestErrors  int64         `json:"request_errors"`
+	EventsProduced int64         `json:"events_produced"`
+	ProduceErrors  int64         `json:"produce_errors"`
+	EventsConsumed int64         `json:"events_consumed"`
+	ConsumeErrors  int64         `json:"consume_errors"`
+	Errors         int64         `json:"errors"` // 请求、生产、消费错误之和
+
+	Requests *metrics.RequestTotals `json:"requests,omitempty"`
+	Pool     *pool.PoolStats        `json:"pool,omitempty"`
+}
+
+// Reporter 关闭汇总报告器 - 退出时从metrics/kafka/pool收集统计，输出一条结构化日志
+type Reporter struct {
+	service   string
+	startTime time.Time
+	logger    *zap.Logger
+
+	metricsManager *metrics.MetricsManager
+	producer       kafka.Producer
+	consumer       kafka.Consumer
+	workerPool     PoolStatsProvider
+
+	now func() time.Time
+	mu  sync.RWMutex
+}
+
+// NewReporter 创建关闭汇总报告器，创建时间视为服务启动时间
+func NewReporter(service string, logger *zap.Logger) *Reporter {
+	return &Reporter{
+		service:   service,
+		startTime: time.Now(),
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// SetMetricsManager 设置请求计数来源
+func (r *Reporter) SetMetricsManager(mm *metrics.MetricsManager) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metricsManager = mm
+}
+
+// SetProducer 设置事件生产统计来源
+func (r *Reporter) SetProducer(producer kafka.Producer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.producer = producer
+}
+
+// SetConsumer 设置事件消费统计来源
+func (r *Reporter) SetConsumer(consumer kafka.Consumer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.consumer = consumer
+}
+
+// SetWorkerPool 设置worker pool状态来源
+func (r *Reporter) SetWorkerPool(workerPool PoolStatsProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workerPool = workerPool
+}
+
+// Collect 收集当前的运行汇总，未设置的来源对应字段为零值
+func (r *Reporter) Collect() Summary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	summary := Summary{
+		Service: r.service,
+		Uptime:  r.now().Sub(r.startTime),
+	}
+
+	if r.metricsManager != nil {
+		totals := r.metricsManager.GetRequestTotals(r.service)
+		summary.Requests = &totals
+		summary.RequestsServed = totals.GRPCRequests + totals.HTTPRequests
+		summary.RequestErrors = totals.GRPCErrors + totals.HTTPErrors
+	}
+
+	if r.producer != nil {
+		stats := r.producer.GetStats()
+		summary.EventsProduced = stats.EventsSent
+		summary.ProduceErrors = stats.ErrorsCount
+	}
+
+	if r.consumer != nil {
+		stats := r.consumer.GetStats()
+		summary.EventsConsumed = stats.MessagesProcessed
+		summary.ConsumeErrors = stats.ErrorsCount
+	}
+
+	if r.workerPool != nil {
+		stats := r.workerPool.GetStats()
+		summary.Pool = &stats
+	}
+
+	summary.Errors = summary.RequestErrors + summary.ProduceErrors + summary.ConsumeErrors
+	return summary
+}
+
+// Report 收集运行汇总并输出一条"shutdown summary"日志，应在各组件关闭后、进程退出前调用
+func (r *Reporter) Report() Summary {
+	summary := r.Collect()
+
+	fields := []zap.Field{
+		zap.String("service", summary.Service),
+		zap.Duration("uptime", summary.Uptime),
+		zap.Int64("requests_served", summary.RequestsServed),
+		zap.Int64("request_errors", summary.RequestErrors),
+		zap.Int64("events_produced", summary.EventsProduced),
+		zap.Int64("produce_errors", summary.ProduceErrors),
+		zap.Int64("events_consumed", summary.EventsConsumed),
+		zap.Int64("consume_errors", summary.ConsumeErrors),
+		zap.Int64("errors", summary.Errors),
+	}
+	if summary.Requests != nil {
+		fields = append(fields, zap.Any("requests", summary.Requests))
+	}
+	if summary.Pool != nil {
+		fields = append(fields, zap.Any("pool", summary.Pool))
+	}
+
+	r.logger.Info("shutdown summary", fields...)
+	return summary
+}
@@ -0,0 +1,127 @@
+package shutdown
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"high-go-press/pkg/kafka"
+	"high-go-press/pkg/metrics"
+	"high-go-press/pkg/pool"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeConsumer 返回固定统计的消费者
+type fakeConsumer struct {
+	stats kafka.ConsumerStats
+}
+
+func (c *fakeConsumer) Subscribe(topics []string) error { return nil }
+func (c *fakeConsumer) ConsumeMessages(ctx context.Context, handler kafka.MessageHandler) error {
+	return nil
+}
+func (c *fakeConsumer) Close() error                  { return nil }
+func (c *fakeConsumer) GetStats() kafka.ConsumerStats { return c.stats }
+
+// fakePool 返回固定统计的worker pool
+type fakePool struct {
+	stats pool.PoolStats
+}
+
+func (p *fakePool) GetStats() pool.PoolStats { return p.stats }
+
+func TestReportIncludesKeyFields(t *testing.T) {
+	mm := metrics.NewMetricsManager(&metrics.Config{Namespace: "test"}, zap.NewNop())
+	mm.RecordGRPCRequest("/counter.CounterService/GetCounter", "counter", "OK", time.Millisecond)
+	mm.RecordGRPCRequest("/counter.CounterService/GetCounter", "counter", "OK", time.Millisecond)
+	mm.RecordGRPCRequest("/counter.CounterService/IncrementCounter", "counter", "InvalidArgument", time.Millisecond)
+	mm.RecordHTTPRequest("GET", "/health", "200", "counter", time.Millisecond)
+	mm.RecordHTTPRequest("GET", "/health", "503", "counter", time.Millisecond)
+	// 其他服务的请求不计入
+	mm.RecordGRPCRequest("/analytics.AnalyticsService/GetTopCounters", "analytics", "OK", time.Millisecond)
+
+	producer := kafka.NewMockProducer(zap.NewNop())
+	for i := 0; i < 3; i++ {
+		event := &kafka.CounterEvent{ResourceID: "article_1", CounterType: "like", Delta: 1}
+		if err := producer.SendCounterEvent(context.Background(), event); err != nil {
+			t.Fatalf("SendCounterEvent failed: %v", err)
+		}
+	}
+
+	consumer := &fakeConsumer{stats: kafka.ConsumerStats{MessagesProcessed: 7, ErrorsCount: 1}}
+	workerPool := &fakePool{stats: pool.PoolStats{GeneralPool: pool.PoolStat{Cap: 10, Running: 2}}}
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	reporter := NewReporter("counter", zap.New(core))
+	start := reporter.startTime
+	reporter.now = func() time.Time { return start.Add(90 * time.Second) }
+	reporter.SetMetricsManager(mm)
+	reporter.SetProducer(producer)
+	reporter.SetConsumer(consumer)
+	reporter.SetWorkerPool(workerPool)
+
+	summary := reporter.Report()
+
+	if summary.RequestsServed != 5 {
+		t.Errorf("RequestsServed = %d, want 5", summary.RequestsServed)
+	}
+	if summary.RequestErrors != 2 {
+		t.Errorf("RequestErrors = %d, want 2", summary.RequestErrors)
+	}
+	if summary.EventsProduced != 3 {
+		t.Errorf("EventsProduced = %d, want 3", summary.EventsProduced)
+	}
+	if summary.EventsConsumed != 7 {
+		t.Errorf("EventsConsumed = %d, want 7", summary.EventsConsumed)
+	}
+	if summary.Errors != 3 {
+		t.Errorf("Errors = %d, want 3", summary.Errors)
+	}
+
+	// 只输出一条汇总日志
+	entries := logs.FilterMessage("shutdown summary").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 shutdown summary entry, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	want := map[string]interface{}{
+		"service":         "counter",
+		"uptime":          90 * time.Second,
+		"requests_served": int64(5),
+		"request_errors":  int64(2),
+		"events_produced": int64(3),
+		"events_consumed": int64(7),
+		"consume_errors":  int64(1),
+		"errors":          int64(3),
+	}
+	for key, value := range want {
+		got, ok := fields[key]
+		if !ok {
+			t.Errorf("summary missing field %q", key)
+			continue
+		}
+		if got != value {
+			t.Errorf("field %q = %v, want %v", key, got, value)
+		}
+	}
+	if _, ok := fields["pool"]; !ok {
+		t.Error("summary missing pool stats")
+	}
+}
+
+func TestReportWithoutSources(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	summary := NewReporter("gateway", zap.New(core)).Report()
+
+	// 未设置的来源不影响汇总输出
+	if summary.RequestsServed != 0 || summary.EventsProduced != 0 || summary.Errors != 0 {
+		t.Errorf("unexpected summary without sources: %+v", summary)
+	}
+	if logs.FilterMessage("shutdown summary").Len() != 1 {
+		t.Fatal("expected shutdown summary entry")
+	}
+}
@@ -0,0 +1,69 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// ServerGroup 管理在后台goroutine中运行的gRPC/HTTP服务
+//
+// 服务启动失败（如端口被占用）或运行中异常退出时，错误通过通道传回主goroutine，
+// 由Wait返回，主流程据此执行正常的关闭流程并以非0状态码退出。
+type ServerGroup struct {
+	logger *zap.Logger
+	errs   chan error
+}
+
+// NewServerGroup 创建服务组
+func NewServerGroup(logger *zap.Logger) *ServerGroup {
+	return &ServerGroup{
+		logger: logger,
+		errs:   make(chan error, 1),
+	}
+}
+
+// Go 在后台运行服务，serve返回非正常关闭的错误时通知Wait
+// http.ErrServerClosed和grpc.ErrServerStopped视为正常关闭
+func (g *ServerGroup) Go(name string, serve func() error) {
+	go func() {
+		err := serve()
+		if err == nil || errors.Is(err, http.ErrServerClosed) || errors.Is(err, grpc.ErrServerStopped) {
+			return
+		}
+
+		g.logger.Error("Server failed", zap.String("server", name), zap.Error(err))
+		// 只保留第一个错误，其余的已经记录日志
+		select {
+		case g.errs <- fmt.Errorf("%s: %w", name, err):
+		default:
+		}
+	}()
+}
+
+// Errors 服务失败通知通道，只会收到第一个失败
+func (g *ServerGroup) Errors() <-chan error {
+	return g.errs
+}
+
+// Wait 阻塞直到收到指定的信号或某个服务失败
+// 收到信号时返回nil，服务失败时返回失败原因
+func (g *ServerGroup) Wait(signals ...os.Signal) error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, signals...)
+	defer signal.Stop(quit)
+
+	select {
+	case sig := <-quit:
+		g.logger.Info("Received shutdown signal", zap.String("signal", sig.String()))
+		return nil
+	case err := <-g.errs:
+		g.logger.Error("Server failed, shutting down", zap.Error(err))
+		return err
+	}
+}
@@ -0,0 +1,92 @@
+package shutdown
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func TestServerGroupWaitReturnsBindFailure(t *testing.T) {
+	// 先占用端口，模拟服务启动时端口已被占用
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer occupied.Close()
+
+	group := NewServerGroup(zap.NewNop())
+	httpServer := &http.Server{Addr: occupied.Addr().String()}
+	group.Go("http", httpServer.ListenAndServe)
+
+	done := make(chan error, 1)
+	go func() { done <- group.Wait(syscall.SIGUSR1) }()
+
+	select {
+	case err := <-done:
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) {
+			t.Fatalf("Expected bind error from Wait, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait did not return after bind failure")
+	}
+}
+
+func TestServerGroupIgnoresGracefulShutdown(t *testing.T) {
+	group := NewServerGroup(zap.NewNop())
+
+	httpServer := &http.Server{Addr: "127.0.0.1:0"}
+	httpServer.Close()
+	group.Go("http", httpServer.ListenAndServe)
+
+	grpcServer := grpc.NewServer()
+	grpcServer.Stop()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	group.Go("grpc", func() error { return grpcServer.Serve(lis) })
+
+	select {
+	case err := <-group.Errors():
+		t.Fatalf("Expected no failure for closed servers, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServerGroupWaitReturnsNilOnSignal(t *testing.T) {
+	// 测试本身也订阅信号，避免Wait注册前收到信号导致进程退出
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, syscall.SIGUSR1)
+	defer signal.Stop(received)
+
+	group := NewServerGroup(zap.NewNop())
+
+	done := make(chan error, 1)
+	go func() { done <- group.Wait(syscall.SIGUSR1) }()
+
+	// 重复发送信号直到Wait完成注册并返回
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Expected nil on signal, got %v", err)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Wait did not return after signal")
+		}
+	}
+}
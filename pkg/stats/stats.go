@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateProvider 同名统计提供者已注册
+var ErrDuplicateProvider = errors.New("stats: provider already registered")
+
+// StatsProvider 统一的组件统计接口，返回可直接JSON编码的统计快照
+type StatsProvider interface {
+	Stats() map[string]interface{}
+}
+
+// ProviderFunc 函数形式的StatsProvider，便于注册没有实现接口的统计来源
+type ProviderFunc func() map[string]interface{}
+
+// Stats 调用函数本身
+func (f ProviderFunc) Stats() map[string]interface{} {
+	return f()
+}
+
+// Snapshot 一次收集的所有组件统计
+type Snapshot struct {
+	CollectedAt time.Time                         `json:"collected_at"`
+	Components  map[string]map[string]interface{} `json:"components"`
+}
+
+// Collector 统计收集器，按名称注册组件并统一收集，用于/debug/stats端点
+type Collector struct {
+	mu        sync.RWMutex
+	providers map[string]StatsProvider
+}
+
+// NewCollector 创建统计收集器
+func NewCollector() *Collector {
+	return &Collector{providers: make(map[string]StatsProvider)}
+}
+
+// Register 注册统计提供者，名称重复时返回ErrDuplicateProvider
+func (c *Collector) Register(name string, provider StatsProvider) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.providers[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateProvider, name)
+	}
+	c.providers[name] = provider
+	return nil
+}
+
+// RegisterAll 按名称注册一组统计提供者，遇到重复名称时返回错误
+func (c *Collector) RegisterAll(providers map[string]StatsProvider) error {
+	for name, provider := range providers {
+		if err := c.Register(name, provider); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unregister 注销统计提供者
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.providers, name)
+}
+
+// Names 已注册的提供者名称，按字母排序
+func (c *Collector) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.providers))
+	for name := range c.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Collect 从所有已注册的提供者收集统计
+func (c *Collector) Collect() Snapshot {
+	c.mu.RLock()
+	providers := make(map[string]StatsProvider, len(c.providers))
+	for name, provider := range c.providers {
+		providers[name] = provider
+	}
+	c.mu.RUnlock()
+
+	// 在锁外调用提供者，避免组件统计较慢时阻塞注册
+	snapshot := Snapshot{
+		CollectedAt: time.Now(),
+		Components:  make(map[string]map[string]interface{}, len(providers)),
+	}
+	for name, provider := range providers {
+		snapshot.Components[name] = provider.Stats()
+	}
+	return snapshot
+}
+
+// ServeHTTP 以JSON返回所有组件的统计
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.Collect())
+}
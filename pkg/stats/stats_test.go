@@ -0,0 +1,115 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"high-go-press/internal/gateway/client"
+	"high-go-press/internal/gateway/service"
+	resilience "high-go-press/pkg/grpc"
+	"high-go-press/pkg/pool"
+
+	"go.uber.org/zap"
+)
+
+// 所有组件都实现统一的统计接口
+var (
+	_ StatsProvider = (*pool.WorkerPool)(nil)
+	_ StatsProvider = (*pool.ObjectPool)(nil)
+	_ StatsProvider = (*pool.Pool[*[]byte])(nil)
+	_ StatsProvider = (*resilience.CircuitBreaker)(nil)
+	_ StatsProvider = (*resilience.Retryer)(nil)
+	_ StatsProvider = (*resilience.FallbackManager)(nil)
+	_ StatsProvider = (*client.CounterClientPool)(nil)
+	_ StatsProvider = (*service.DiscoveryManager)(nil)
+)
+
+func TestCollectorGathersAllProviders(t *testing.T) {
+	logger := zap.NewNop()
+	workerPool, err := pool.NewWorkerPool(logger)
+	if err != nil {
+		t.Fatalf("NewWorkerPool failed: %v", err)
+	}
+	defer workerPool.Shutdown(context.Background())
+
+	objectPool := pool.NewObjectPool()
+	objectPool.PutBuffer(objectPool.GetBuffer())
+
+	providers := map[string]StatsProvider{
+		"worker_pool":      workerPool,
+		"object_pool":      objectPool,
+		"circuit_breaker":  resilience.NewCircuitBreaker(nil, logger),
+		"retryer":          resilience.NewRetryer(resilience.DefaultRetryConfig(), logger),
+		"fallback_manager": resilience.NewFallbackManager(resilience.DefaultFallbackConfig(), logger),
+		"custom":           ProviderFunc(func() map[string]interface{} { return map[string]interface{}{"ok": true} }),
+	}
+
+	collector := NewCollector()
+	if err := collector.RegisterAll(providers); err != nil {
+		t.Fatalf("RegisterAll failed: %v", err)
+	}
+
+	snapshot := collector.Collect()
+	if len(snapshot.Components) != len(providers) {
+		t.Fatalf("Expected %d components, got %d", len(providers), len(snapshot.Components))
+	}
+	for name := range providers {
+		if snapshot.Components[name] == nil {
+			t.Errorf("Expected stats for %s", name)
+		}
+	}
+
+	buffer := snapshot.Components["object_pool"]["buffer"].(map[string]interface{})
+	if buffer["gets"] != int64(1) || buffer["puts"] != int64(1) {
+		t.Errorf("Expected buffer usage in object pool stats, got %v", buffer)
+	}
+	if snapshot.Components["circuit_breaker"]["current_state"] != "CLOSED" {
+		t.Errorf("Expected closed circuit breaker, got %v", snapshot.Components["circuit_breaker"])
+	}
+
+	// 注销后不再收集
+	collector.Unregister("custom")
+	if _, ok := collector.Collect().Components["custom"]; ok {
+		t.Error("Expected unregistered provider to be skipped")
+	}
+}
+
+func TestCollectorRejectsDuplicateName(t *testing.T) {
+	collector := NewCollector()
+	provider := ProviderFunc(func() map[string]interface{} { return nil })
+
+	if err := collector.Register("a", provider); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := collector.Register("a", provider); !errors.Is(err, ErrDuplicateProvider) {
+		t.Errorf("Expected ErrDuplicateProvider, got %v", err)
+	}
+	if names := collector.Names(); len(names) != 1 || names[0] != "a" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+	if err := collector.RegisterAll(map[string]StatsProvider{"a": provider}); !errors.Is(err, ErrDuplicateProvider) {
+		t.Errorf("Expected RegisterAll to report ErrDuplicateProvider, got %v", err)
+	}
+}
+
+func TestCollectorServeHTTP(t *testing.T) {
+	collector := NewCollector()
+	collector.Register("object_pool", pool.NewObjectPool())
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/stats", nil))
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if _, ok := snapshot.Components["object_pool"]["response"]; !ok {
+		t.Errorf("Expected object pool stats in response, got %v", snapshot.Components)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+}
@@ -2,45 +2,248 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"math/bits"
+	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "high-go-press/api/proto/counter"
+	"high-go-press/internal/gateway/client"
+	"high-go-press/pkg/config"
+	resilience "high-go-press/pkg/grpc"
 )
 
+// pushbackCodes 服务端限流/过载时返回的错误码，压测客户端按重试配置退避后重试
+var pushbackCodes = []codes.Code{codes.ResourceExhausted, codes.Unavailable}
+
+// loadRetryConfig 读取配置文件中的重试退避参数，只对限流/过载错误重试
+func loadRetryConfig(configPath string) *resilience.RetryConfig {
+	retryConfig := resilience.DefaultRetryConfig()
+	retryConfig.RetryableStatusCodes = pushbackCodes
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Printf("读取配置失败，使用默认重试配置: %v", err)
+		return retryConfig
+	}
+
+	retry := cfg.Resilience.Retry
+	if retry.MaxAttempts > 0 {
+		retryConfig.MaxAttempts = retry.MaxAttempts
+	}
+	if retry.InitialBackoff > 0 {
+		retryConfig.InitialBackoff = retry.InitialBackoff
+	}
+	if retry.MaxBackoff > 0 {
+		retryConfig.MaxBackoff = retry.MaxBackoff
+	}
+	if retry.BackoffMultiplier > 0 {
+		retryConfig.BackoffMultiplier = retry.BackoffMultiplier
+	}
+	if retry.Jitter > 0 {
+		retryConfig.Jitter = retry.Jitter
+	}
+	if retry.Timeout > 0 {
+		retryConfig.RetryTimeout = retry.Timeout
+	}
+	return retryConfig
+}
+
+// isPushback 是否为服务端限流/过载信号
+func isPushback(err error) bool {
+	code := status.Code(err)
+	for _, c := range pushbackCodes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// histogramSubBuckets 每个2的幂区间划分的桶数，分位值相对误差不超过1/16
+	histogramSubBuckets = 16
+	// histogramBuckets 覆盖到2^42微秒，远超任何请求耗时
+	histogramBuckets = 38*histogramSubBuckets + 2*histogramSubBuckets
+)
+
+// latencyHistogram 以微秒为单位的对数分桶延迟直方图，内存占用固定，每个worker独立记录后合并
+type latencyHistogram struct {
+	counts [histogramBuckets]int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// histogramBucket 计算微秒值所在的桶：小于32微秒逐个计数，之后每个2的幂区间分16个桶
+func histogramBucket(us int64) int {
+	if us < 2*histogramSubBuckets {
+		return int(us)
+	}
+	shift := bits.Len64(uint64(us)) - 5
+	index := shift*histogramSubBuckets + int(us>>shift)
+	if index >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return index
+}
+
+// histogramBucketUpper 返回桶的上界（微秒）
+func histogramBucketUpper(index int) int64 {
+	if index < 2*histogramSubBuckets {
+		return int64(index)
+	}
+	shift := index/histogramSubBuckets - 1
+	sub := int64(index%histogramSubBuckets + histogramSubBuckets)
+	return (sub+1)<<shift - 1
+}
+
+// Record 记录一次请求延迟
+func (h *latencyHistogram) Record(latency time.Duration) {
+	h.counts[histogramBucket(latency.Microseconds())]++
+	h.count++
+	h.sum += latency
+	if latency > h.max {
+		h.max = latency
+	}
+}
+
+// Merge 合并另一个直方图
+func (h *latencyHistogram) Merge(other *latencyHistogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.count += other.count
+	h.sum += other.sum
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Percentile 返回p分位延迟（所在桶的上界，不超过最大值）
+func (h *latencyHistogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	target := int64(float64(h.count)*p/100 + 0.5)
+	if target < 1 {
+		target = 1
+	}
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			latency := time.Duration(histogramBucketUpper(i)) * time.Microsecond
+			if latency > h.max {
+				latency = h.max
+			}
+			return latency
+		}
+	}
+	return h.max
+}
+
+// Mean 平均延迟
+func (h *latencyHistogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// workerResult 单个worker的统计结果
+type workerResult struct {
+	latencies latencyHistogram
+	errors    map[codes.Code]int64
+	retried   int64
+}
+
+// latencySummary JSON输出的延迟分布，单位毫秒
+type latencySummary struct {
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P95  float64 `json:"p95_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+	Mean float64 `json:"mean_ms"`
+}
+
+// benchmarkResult JSON输出的压测结果，便于CI比对
+type benchmarkResult struct {
+	Target        string           `json:"target"`
+	Concurrency   int              `json:"concurrency"`
+	Connections   int              `json:"connections"`
+	DurationSec   float64          `json:"duration_seconds"`
+	TotalRequests int64            `json:"total_requests"`
+	Success       int64            `json:"success"`
+	Errors        int64            `json:"errors"`
+	Pushback      int64            `json:"pushback_responses"`
+	Retried       int64            `json:"retried"`
+	QPS           float64          `json:"qps"`
+	SuccessRate   float64          `json:"success_rate"`
+	Latency       latencySummary   `json:"latency"`
+	ErrorCodes    map[string]int64 `json:"error_codes"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// writeJSONResult 将结果以JSON写入文件
+func writeJSONResult(path string, result *benchmarkResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
 func main() {
+	addr := flag.String("addr", "localhost:9001", "Counter gRPC服务地址")
+	duration := flag.Duration("duration", 30*time.Second, "测试时长")
+	concurrency := flag.Int("concurrency", 50, "并发数")
+	conns := flag.Int("conns", 10, "gRPC连接池大小")
+	configPath := flag.String("config", "configs/config.yaml", "读取重试配置的配置文件")
+	jsonOut := flag.String("json", "", "将结果以JSON写入该文件，便于CI比对")
+	flag.Parse()
+
 	fmt.Println("🚀 HighGoPress gRPC性能测试 (真实Kafka)")
 	fmt.Println("=====================================")
 
-	// 连接到Counter服务
-	conn, err := grpc.Dial("localhost:9001", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	retryConfig := loadRetryConfig(*configPath)
+
+	// 连接到Counter服务，使用连接池分摊并发流
+	poolConfig := client.DefaultPoolConfig(*addr)
+	poolConfig.PoolSize = *conns
+	counterPool, err := client.NewCounterClientPool(poolConfig, zap.NewNop())
 	if err != nil {
 		log.Fatalf("连接Counter服务失败: %v", err)
 	}
-	defer conn.Close()
-
-	client := pb.NewCounterServiceClient(conn)
-
-	// 测试参数
-	duration := 30 * time.Second
-	concurrency := 50
+	defer counterPool.Close()
 
 	fmt.Printf("测试参数:\n")
-	fmt.Printf("  - 测试时长: %v\n", duration)
-	fmt.Printf("  - 并发数: %d\n", concurrency)
-	fmt.Printf("  - 目标服务: localhost:9001 (Counter gRPC)\n")
+	fmt.Printf("  - 测试时长: %v\n", *duration)
+	fmt.Printf("  - 并发数: %d\n", *concurrency)
+	fmt.Printf("  - 连接数: %d\n", *conns)
+	fmt.Printf("  - 目标服务: %s (Counter gRPC)\n", *addr)
+	fmt.Printf("  - 限流退避: 最多%d次尝试，初始%v，最大%v\n",
+		retryConfig.MaxAttempts, retryConfig.InitialBackoff, retryConfig.MaxBackoff)
 	fmt.Printf("  - Kafka模式: 真实Kafka\n\n")
 
 	// 预热
 	fmt.Println("预热系统...")
 	for i := 0; i < 10; i++ {
-		_, err := client.IncrementCounter(context.Background(), &pb.IncrementRequest{
+		_, err := counterPool.IncrementCounter(context.Background(), &pb.IncrementRequest{
 			ResourceId:  "warmup",
 			CounterType: "test",
 			Delta:       1,
@@ -55,39 +258,56 @@ func main() {
 	fmt.Println("开始性能测试...")
 
 	var (
-		successCount int64
-		errorCount   int64
-		wg           sync.WaitGroup
-		startTime    = time.Now()
-		endTime      = startTime.Add(duration)
+		successCount  int64
+		errorCount    int64
+		pushbackCount int64
+		wg            sync.WaitGroup
+		results       = make([]*workerResult, *concurrency)
+		startTime     = time.Now()
+		endTime       = startTime.Add(*duration)
 	)
 
-	// 启动并发goroutines
-	for i := 0; i < concurrency; i++ {
+	// 启动并发goroutines，每个worker使用独立的重试器
+	for i := 0; i < *concurrency; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
 
+			retryer := resilience.NewRetryer(retryConfig, zap.NewNop())
+			result := &workerResult{errors: make(map[codes.Code]int64)}
+			results[workerID] = result
+
 			requestID := 0
 			for time.Now().Before(endTime) {
 				requestID++
-
-				_, err := client.IncrementCounter(context.Background(), &pb.IncrementRequest{
+				req := &pb.IncrementRequest{
 					ResourceId:  fmt.Sprintf("perf-test-worker-%d-req-%d", workerID, requestID),
 					CounterType: "test",
 					Delta:       1,
+				}
+
+				// 延迟包含限流退避的等待时间，反映客户端实际感受到的耗时
+				start := time.Now()
+				err := retryer.Execute(context.Background(), func(ctx context.Context) error {
+					_, err := counterPool.IncrementCounter(ctx, req)
+					if isPushback(err) {
+						atomic.AddInt64(&pushbackCount, 1)
+					}
+					return err
 				})
+				latency := time.Since(start)
 
 				if err != nil {
 					atomic.AddInt64(&errorCount, 1)
+					result.errors[status.Code(err)]++
 					log.Printf("Worker %d 请求失败: %v", workerID, err)
 				} else {
 					atomic.AddInt64(&successCount, 1)
+					result.latencies.Record(latency)
 				}
-
-				// 小延迟避免过度压力
-				time.Sleep(10 * time.Millisecond)
 			}
+
+			result.retried = retryer.GetStats().RetriedRequests
 		}(i)
 	}
 
@@ -99,15 +319,53 @@ func main() {
 	qps := float64(successCount) / actualDuration.Seconds()
 	successRate := float64(successCount) / float64(totalRequests) * 100
 
+	// 合并各worker的延迟直方图和错误码统计
+	var (
+		latencies    latencyHistogram
+		retriedCount int64
+		errorCodes   = make(map[codes.Code]int64)
+	)
+	for _, result := range results {
+		latencies.Merge(&result.latencies)
+		retriedCount += result.retried
+		for code, count := range result.errors {
+			errorCodes[code] += count
+		}
+	}
+
 	// 输出结果
 	fmt.Println("\n📊 真实Kafka gRPC性能测试结果:")
 	fmt.Printf("  - 总请求数: %d\n", totalRequests)
 	fmt.Printf("  - 成功请求: %d\n", successCount)
 	fmt.Printf("  - 失败请求: %d\n", errorCount)
+	fmt.Printf("  - 限流/过载响应: %d (退避重试 %d 次)\n", pushbackCount, retriedCount)
 	fmt.Printf("  - 实际耗时: %.2f秒\n", actualDuration.Seconds())
 	fmt.Printf("  - QPS: %.2f\n", qps)
 	fmt.Printf("  - 成功率: %.2f%%\n", successRate)
 
+	fmt.Println("\n⏱️ 延迟分布 (成功请求):")
+	fmt.Printf("  - p50: %v\n", latencies.Percentile(50))
+	fmt.Printf("  - p90: %v\n", latencies.Percentile(90))
+	fmt.Printf("  - p95: %v\n", latencies.Percentile(95))
+	fmt.Printf("  - p99: %v\n", latencies.Percentile(99))
+	fmt.Printf("  - max: %v\n", latencies.max)
+	fmt.Printf("  - 平均: %v\n", latencies.Mean())
+
+	// 按错误码统计失败请求，按数量从多到少输出
+	errorCodeCounts := make(map[string]int64, len(errorCodes))
+	if len(errorCodes) > 0 {
+		fmt.Println("\n❌ 错误码分布:")
+		sortedCodes := make([]codes.Code, 0, len(errorCodes))
+		for code := range errorCodes {
+			sortedCodes = append(sortedCodes, code)
+		}
+		sort.Slice(sortedCodes, func(i, j int) bool { return errorCodes[sortedCodes[i]] > errorCodes[sortedCodes[j]] })
+		for _, code := range sortedCodes {
+			fmt.Printf("  - %s: %d (%.2f%%)\n", code, errorCodes[code], float64(errorCodes[code])/float64(totalRequests)*100)
+			errorCodeCounts[code.String()] = errorCodes[code]
+		}
+	}
+
 	// 与历史数据对比
 	fmt.Println("\n📈 性能对比:")
 	fmt.Printf("  - Phase 1 (单体): ~21,000 QPS\n")
@@ -122,5 +380,34 @@ func main() {
 		fmt.Printf("  - Real vs Mock 下降: -%.2f%%\n", decline)
 	}
 
+	if *jsonOut != "" {
+		result := &benchmarkResult{
+			Target:        *addr,
+			Concurrency:   *concurrency,
+			Connections:   *conns,
+			DurationSec:   actualDuration.Seconds(),
+			TotalRequests: totalRequests,
+			Success:       successCount,
+			Errors:        errorCount,
+			Pushback:      pushbackCount,
+			Retried:       retriedCount,
+			QPS:           qps,
+			SuccessRate:   successRate,
+			Latency: latencySummary{
+				P50:  milliseconds(latencies.Percentile(50)),
+				P90:  milliseconds(latencies.Percentile(90)),
+				P95:  milliseconds(latencies.Percentile(95)),
+				P99:  milliseconds(latencies.Percentile(99)),
+				Max:  milliseconds(latencies.max),
+				Mean: milliseconds(latencies.Mean()),
+			},
+			ErrorCodes: errorCodeCounts,
+		}
+		if err := writeJSONResult(*jsonOut, result); err != nil {
+			log.Fatalf("写入JSON结果失败: %v", err)
+		}
+		fmt.Printf("\n📝 JSON结果已写入: %s\n", *jsonOut)
+	}
+
 	fmt.Println("\n✅ 测试完成！")
 }